@@ -0,0 +1,162 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultMaxRegression is the slowdown or allocation growth, in percent, above
+// which a result fails the regression gate
+const DefaultMaxRegression = 20.0
+
+// minComparableDuration is the total time below which timings are too noisy to
+// gate on; allocations are still compared
+const minComparableDuration = 10 * time.Millisecond
+
+// Baseline is a stored set of results that later runs are compared with
+type Baseline struct {
+	CreatedAt string   `json:"created_at"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	Results   []Result `json:"results"`
+}
+
+// NewBaseline records results as a baseline
+func NewBaseline(results []Result) Baseline {
+	return Baseline{
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Results:   results,
+	}
+}
+
+// LoadBaseline reads a baseline written by SaveBaseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// SaveBaseline writes a baseline as indented JSON, creating its directory
+func SaveBaseline(path string, baseline Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Comparison is a result with the baseline result of the same scenario and size
+type Comparison struct {
+	Result   Result
+	Baseline *Result
+	// TimeChange and AllocChange are the growth over the baseline in percent
+	TimeChange  float64
+	AllocChange float64
+	Regressed   bool
+}
+
+// Compare compares results with a baseline, which may be nil. A result
+// regresses when its total time or allocated bytes grew by more than
+// maxRegression percent; timings of runs shorter than 10ms are not gated.
+func Compare(results []Result, baseline *Baseline, maxRegression float64) []Comparison {
+	previous := map[string]Result{}
+	if baseline != nil {
+		for _, result := range baseline.Results {
+			previous[result.Key()] = result
+		}
+	}
+
+	comparisons := make([]Comparison, 0, len(results))
+	for _, result := range results {
+		comparison := Comparison{Result: result}
+		if base, ok := previous[result.Key()]; ok {
+			comparison.Baseline = &base
+			comparison.TimeChange = percentChange(float64(result.Total), float64(base.Total))
+			comparison.AllocChange = percentChange(float64(result.AllocBytes), float64(base.AllocBytes))
+			timed := result.Total >= minComparableDuration || base.Total >= minComparableDuration
+			comparison.Regressed = timed && comparison.TimeChange > maxRegression || comparison.AllocChange > maxRegression
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// Regressions returns the comparisons that failed the regression gate
+func Regressions(comparisons []Comparison) []Comparison {
+	var regressions []Comparison
+	for _, comparison := range comparisons {
+		if comparison.Regressed {
+			regressions = append(regressions, comparison)
+		}
+	}
+	return regressions
+}
+
+// percentChange returns how much value grew over base in percent
+func percentChange(value, base float64) float64 {
+	if base == 0 {
+		if value == 0 {
+			return 0
+		}
+		return 100
+	}
+	return (value - base) / base * 100
+}
+
+// WriteTable prints the comparisons as a table with one row per scenario and size
+func WriteTable(w io.Writer, comparisons []Comparison) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "SCENARIO\tLINES\tEVENTS\tEXECUTE\tPARSE\tSUMMARIZE\tTOTAL\tALLOC\tBASELINE\tTIME Δ\tALLOC Δ\t")
+	for _, comparison := range comparisons {
+		result := comparison.Result
+		baselineTotal, timeChange, allocChange := "-", "-", "-"
+		if comparison.Baseline != nil {
+			baselineTotal = formatDuration(comparison.Baseline.Total)
+			timeChange = fmt.Sprintf("%+.1f%%", comparison.TimeChange)
+			allocChange = fmt.Sprintf("%+.1f%%", comparison.AllocChange)
+		}
+		if comparison.Regressed {
+			timeChange += " !"
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			result.Scenario, result.Lines, result.Events,
+			formatDuration(result.Execute), formatDuration(result.Parse), formatDuration(result.Summarize),
+			formatDuration(result.Total), formatBytes(result.AllocBytes),
+			baselineTotal, timeChange, allocChange)
+	}
+	return table.Flush()
+}
+
+// formatDuration rounds a duration for the table
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(100 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+// formatBytes prints a byte count in MB
+func formatBytes(n uint64) string {
+	return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+}
@@ -0,0 +1,302 @@
+// Package benchmark measures the execute, parse and summarize pipeline on
+// synthetic audit logs and compares the measurements with stored baselines.
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+)
+
+// DefaultSizes are the synthetic audit log sizes, in lines, a run measures
+var DefaultSizes = []int{10000, 100000, 1000000}
+
+// DefaultSeed seeds the synthetic audit logs
+const DefaultSeed = 42
+
+// Scenario is a query run through the pipeline
+type Scenario struct {
+	Name        string
+	Description string
+	Params      types.AuditQueryParams
+}
+
+// DefaultScenarios cover an unfiltered read, selective filters, a date filter
+// and exclusions
+var DefaultScenarios = []Scenario{
+	{
+		Name:        "all-events",
+		Description: "Every event, projected and parsed",
+		Params:      types.AuditQueryParams{LogSource: "kube-apiserver"},
+	},
+	{
+		Name:        "user-deletes",
+		Description: "Deletes by one user",
+		Params:      types.AuditQueryParams{LogSource: "kube-apiserver", Username: "alice", Verb: "delete"},
+	},
+	{
+		Name:        "secrets-24h",
+		Description: "Secret access in the last 24 hours",
+		Params:      types.AuditQueryParams{LogSource: "kube-apiserver", Resource: "secrets", Timeframe: "24h"},
+	},
+	{
+		Name:        "failed-requests",
+		Description: "Requests answered with a 4xx status",
+		Params:      types.AuditQueryParams{LogSource: "kube-apiserver", StatusCodeRange: "4xx"},
+	},
+	{
+		Name:        "namespace-without-service-accounts",
+		Description: "One namespace, excluding service accounts",
+		Params:      types.AuditQueryParams{LogSource: "kube-apiserver", Namespace: "payments", ExcludeUsers: []string{"system:serviceaccount:*"}},
+	},
+}
+
+// Options configures a benchmark run
+type Options struct {
+	// Sizes are the synthetic audit log sizes in lines, DefaultSizes if empty
+	Sizes []int
+	// Scenarios are the queries to run, DefaultScenarios if empty
+	Scenarios []Scenario
+	// Count is how many times each scenario runs; the fastest run is kept
+	Count int
+	// Seed seeds the synthetic audit logs, DefaultSeed if zero
+	Seed int64
+	// MemProfileDir, when set, receives the allocation profile after each
+	// scenario and size. Profiles are cumulative; compare two with
+	// go tool pprof -base.
+	MemProfileDir string
+	// Progress, when set, is called before each log is generated and each
+	// scenario runs
+	Progress func(message string)
+}
+
+// Result is the measurement of one scenario on one synthetic audit log
+type Result struct {
+	Scenario  string        `json:"scenario"`
+	Lines     int           `json:"lines"`
+	Events    int           `json:"events"`
+	JQEngine  string        `json:"jq_engine,omitempty"`
+	Execute   time.Duration `json:"execute_ns"`
+	Parse     time.Duration `json:"parse_ns"`
+	Summarize time.Duration `json:"summarize_ns"`
+	Total     time.Duration `json:"total_ns"`
+	// AllocBytes and Allocs count heap allocations of the server process;
+	// memory used by an external jq or grep is not included
+	AllocBytes uint64 `json:"alloc_bytes"`
+	Allocs     uint64 `json:"allocs"`
+	// HeapBytes is the heap in use once the summary is built
+	HeapBytes uint64 `json:"heap_bytes"`
+}
+
+// Key identifies the scenario and size of a result in a baseline
+func (r Result) Key() string {
+	return fmt.Sprintf("%s/%d", r.Scenario, r.Lines)
+}
+
+// Run generates a synthetic audit log for each size and measures every
+// scenario on it. The logs are written to a temporary directory removed
+// afterwards.
+func Run(ctx context.Context, options Options) ([]Result, error) {
+	if len(options.Sizes) == 0 {
+		options.Sizes = DefaultSizes
+	}
+	if len(options.Scenarios) == 0 {
+		options.Scenarios = DefaultScenarios
+	}
+	if options.Count < 1 {
+		options.Count = 1
+	}
+	if options.Seed == 0 {
+		options.Seed = DefaultSeed
+	}
+	progress := options.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+	if options.MemProfileDir != "" {
+		if err := os.MkdirAll(options.MemProfileDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create profile directory: %w", err)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "audit-benchmark-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	builder := commands.NewCommandBuilder()
+	builder.Config.UseJSONParsing = true
+	end := time.Now()
+
+	var results []Result
+	for _, size := range options.Sizes {
+		progress(fmt.Sprintf("Generating %d synthetic audit events", size))
+		path := filepath.Join(dir, fmt.Sprintf("audit-%d.log", size))
+		if err := writeAuditLog(path, size, options.Seed, end); err != nil {
+			return nil, fmt.Errorf("failed to generate audit log: %w", err)
+		}
+
+		for _, scenario := range options.Scenarios {
+			progress(fmt.Sprintf("Running %s on %d lines", scenario.Name, size))
+			var best Result
+			for i := 0; i < options.Count; i++ {
+				result, err := runScenario(ctx, builder, scenario, path, size)
+				if err != nil {
+					return nil, fmt.Errorf("%s on %d lines: %w", scenario.Name, size, err)
+				}
+				if i == 0 || result.Total < best.Total {
+					best = result
+				}
+			}
+			if options.MemProfileDir != "" {
+				if err := writeMemProfile(filepath.Join(options.MemProfileDir, fmt.Sprintf("%s-%d.mem.pprof", scenario.Name, size))); err != nil {
+					return nil, err
+				}
+			}
+			results = append(results, best)
+		}
+		os.Remove(path)
+	}
+	return results, nil
+}
+
+// writeAuditLog writes a synthetic audit log file
+func writeAuditLog(path string, lines int, seed int64, end time.Time) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := GenerateAuditLog(file, lines, seed, end); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runScenario runs the scenario's generated command on the audit log file, then
+// splits its output into events, parses and summarizes them
+func runScenario(ctx context.Context, builder *commands.CommandBuilder, scenario Scenario, path string, size int) (Result, error) {
+	result := Result{Scenario: scenario.Name, Lines: size}
+
+	command := builder.BuildOptimalCommand(scenario.Params)
+	parsed, err := commands.ParseCommand(command)
+	if err != nil {
+		return result, err
+	}
+	if parsed.UsesJQ() {
+		result.JQEngine = commands.ActiveJQEngine()
+	}
+	input, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer input.Close()
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	var output, stderr bytes.Buffer
+	if err := parsed.RunFilters(ctx, input, &output, &stderr); err != nil && !noMatches(err, output.Len()) {
+		return result, fmt.Errorf("command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	result.Execute = time.Since(start)
+
+	// jq prints each projected event over several lines
+	lines := parsing.SplitAuditRecords(output.String())
+	config := parsing.DefaultParserConfig()
+	// Measure the whole log rather than stopping at the parser's timeout
+	config.Timeout = time.Hour
+	parseResult := parsing.ParseAuditLogs(lines, config)
+	entries, _ := parsing.DeduplicateEntries(parseResult.Entries)
+	result.Parse = time.Since(start) - result.Execute
+
+	parsing.GenerateSummary(entries, map[string]interface{}{
+		"log_source": scenario.Params.LogSource,
+		"timeframe":  scenario.Params.Timeframe,
+	})
+	result.Total = time.Since(start)
+	result.Summarize = result.Total - result.Execute - result.Parse
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	result.Events = len(entries)
+	result.AllocBytes = after.TotalAlloc - before.TotalAlloc
+	result.Allocs = after.Mallocs - before.Mallocs
+	result.HeapBytes = after.HeapAlloc
+	return result, nil
+}
+
+// noMatches reports whether a failed command only found nothing, which grep
+// reports with exit status 1
+func noMatches(err error, outputLen int) bool {
+	exitErr, ok := err.(interface{ ExitCode() int })
+	return ok && exitErr.ExitCode() == 1 && outputLen == 0
+}
+
+// writeMemProfile writes the allocations sampled so far to path
+func writeMemProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %w", err)
+	}
+	defer file.Close()
+	if err := pprof.Lookup("allocs").WriteTo(file, 0); err != nil {
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+	return nil
+}
+
+// ParseSizes parses a comma-separated list of line counts such as "10k,100k,1m"
+func ParseSizes(value string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(value, ",") {
+		original := strings.TrimSpace(field)
+		field = strings.ToLower(original)
+		multiplier := 1
+		switch {
+		case strings.HasSuffix(field, "k"):
+			multiplier, field = 1000, strings.TrimSuffix(field, "k")
+		case strings.HasSuffix(field, "m"):
+			multiplier, field = 1000000, strings.TrimSuffix(field, "m")
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid size: %q", original)
+		}
+		sizes = append(sizes, n*multiplier)
+	}
+	return sizes, nil
+}
+
+// SelectScenarios returns the default scenarios with the given names
+func SelectScenarios(names []string) ([]Scenario, error) {
+	var scenarios []Scenario
+	for _, name := range names {
+		found := false
+		for _, scenario := range DefaultScenarios {
+			if scenario.Name == strings.TrimSpace(name) {
+				scenarios = append(scenarios, scenario)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown scenario: %s", name)
+		}
+	}
+	return scenarios, nil
+}
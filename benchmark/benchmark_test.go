@@ -0,0 +1,249 @@
+package benchmark
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGenerateAuditLog tests that synthetic audit logs are valid and repeatable
+func TestGenerateAuditLog(t *testing.T) {
+	end := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	var first, second bytes.Buffer
+	if err := GenerateAuditLog(&first, 200, DefaultSeed, end); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateAuditLog(&second, 200, DefaultSeed, end); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Error("The same seed produced different audit logs")
+	}
+
+	lines := 0
+	scanner := bufio.NewScanner(&first)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Line %d is not JSON: %v", lines+1, err)
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, event["requestReceivedTimestamp"].(string))
+		if err != nil || timestamp.After(end) || timestamp.Before(end.Add(-24*time.Hour)) {
+			t.Errorf("Timestamp %v outside the last 24 hours", event["requestReceivedTimestamp"])
+		}
+		lines++
+	}
+	if lines != 200 {
+		t.Errorf("Expected 200 lines, got %d", lines)
+	}
+
+	var other bytes.Buffer
+	GenerateAuditLog(&other, 200, DefaultSeed+1, end)
+	if other.String() == second.String() {
+		t.Error("Different seeds produced the same audit log")
+	}
+}
+
+// TestParseSizes tests parsing of audit log sizes
+func TestParseSizes(t *testing.T) {
+	sizes, err := ParseSizes("10k, 100K,1m,250")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{10000, 100000, 1000000, 250}
+	if fmt.Sprint(sizes) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, sizes)
+	}
+
+	for _, invalid := range []string{"", "k", "-5", "10x", "1.5k"} {
+		if _, err := ParseSizes(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+// TestSelectScenarios tests selecting scenarios by name
+func TestSelectScenarios(t *testing.T) {
+	scenarios, err := SelectScenarios([]string{"user-deletes", " all-events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) != 2 || scenarios[0].Name != "user-deletes" || scenarios[1].Name != "all-events" {
+		t.Errorf("Unexpected scenarios %+v", scenarios)
+	}
+	if _, err := SelectScenarios([]string{"missing"}); err == nil {
+		t.Error("Expected an error for an unknown scenario")
+	}
+}
+
+// TestCompare tests the regression gate
+func TestCompare(t *testing.T) {
+	baseline := &Baseline{Results: []Result{
+		{Scenario: "all-events", Lines: 1000, Total: 100 * time.Millisecond, AllocBytes: 1000},
+		{Scenario: "user-deletes", Lines: 1000, Total: 100 * time.Millisecond, AllocBytes: 1000},
+		{Scenario: "secrets-24h", Lines: 1000, Total: time.Millisecond, AllocBytes: 1000},
+		{Scenario: "failed-requests", Lines: 1000, Total: time.Millisecond, AllocBytes: 1000},
+	}}
+	results := []Result{
+		// Within the limit
+		{Scenario: "all-events", Lines: 1000, Total: 110 * time.Millisecond, AllocBytes: 1100},
+		// Slower
+		{Scenario: "user-deletes", Lines: 1000, Total: 150 * time.Millisecond, AllocBytes: 1000},
+		// Slower, but too fast to gate on
+		{Scenario: "secrets-24h", Lines: 1000, Total: 3 * time.Millisecond, AllocBytes: 1000},
+		// Allocates more
+		{Scenario: "failed-requests", Lines: 1000, Total: time.Millisecond, AllocBytes: 2000},
+		// Not in the baseline
+		{Scenario: "all-events", Lines: 5000, Total: time.Second, AllocBytes: 5000},
+	}
+
+	comparisons := Compare(results, baseline, DefaultMaxRegression)
+	if len(comparisons) != len(results) {
+		t.Fatalf("Expected %d comparisons, got %d", len(results), len(comparisons))
+	}
+	regressed := []bool{false, true, false, true, false}
+	for i, comparison := range comparisons {
+		if comparison.Regressed != regressed[i] {
+			t.Errorf("%s: expected regressed=%v, got %v (time %+.1f%%, alloc %+.1f%%)",
+				comparison.Result.Key(), regressed[i], comparison.Regressed, comparison.TimeChange, comparison.AllocChange)
+		}
+	}
+	if comparisons[4].Baseline != nil {
+		t.Error("Expected no baseline for a new size")
+	}
+	if comparisons[1].TimeChange != 50 {
+		t.Errorf("Expected a 50%% slowdown, got %.1f%%", comparisons[1].TimeChange)
+	}
+	if len(Regressions(comparisons)) != 2 {
+		t.Errorf("Expected 2 regressions, got %d", len(Regressions(comparisons)))
+	}
+
+	// Without a baseline nothing regresses
+	if len(Regressions(Compare(results, nil, DefaultMaxRegression))) != 0 {
+		t.Error("Expected no regressions without a baseline")
+	}
+}
+
+// TestBaselineRoundTrip tests saving and loading baselines
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "benchmarks", "baseline.json")
+	results := []Result{{Scenario: "all-events", Lines: 1000, Events: 1000, Total: 42 * time.Millisecond, AllocBytes: 1 << 20}}
+
+	if err := SaveBaseline(path, NewBaseline(results)); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(baseline.Results) != 1 || baseline.Results[0] != results[0] || baseline.GoVersion == "" {
+		t.Errorf("Unexpected baseline %+v", baseline)
+	}
+
+	if _, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
+
+// TestWriteTable tests the comparison table
+func TestWriteTable(t *testing.T) {
+	base := Result{Scenario: "user-deletes", Lines: 1000, Total: 100 * time.Millisecond}
+	comparisons := []Comparison{
+		{Result: Result{Scenario: "all-events", Lines: 1000, Events: 1000, Total: 20 * time.Millisecond, AllocBytes: 3 << 20}},
+		{Result: Result{Scenario: "user-deletes", Lines: 1000, Events: 9, Total: 150 * time.Millisecond}, Baseline: &base, TimeChange: 50, Regressed: true},
+	}
+
+	var output bytes.Buffer
+	if err := WriteTable(&output, comparisons); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %q", output.String())
+	}
+	if !strings.Contains(lines[1], "all-events") || !strings.Contains(lines[1], "3.0MB") {
+		t.Errorf("Unexpected row %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "+50.0% !") || !strings.Contains(lines[2], "100ms") {
+		t.Errorf("Unexpected row %q", lines[2])
+	}
+}
+
+// TestRun tests a small run of every scenario
+func TestRun(t *testing.T) {
+	profiles := t.TempDir()
+	var messages []string
+	results, err := Run(context.Background(), Options{
+		Sizes:         []int{500},
+		MemProfileDir: profiles,
+		Progress:      func(message string) { messages = append(messages, message) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(DefaultScenarios) {
+		t.Fatalf("Expected %d results, got %d", len(DefaultScenarios), len(results))
+	}
+
+	events := map[string]int{}
+	for _, result := range results {
+		events[result.Scenario] = result.Events
+		if result.Lines != 500 || result.Total <= 0 || result.AllocBytes == 0 {
+			t.Errorf("Unexpected result %+v", result)
+		}
+		if result.Total < result.Execute+result.Parse {
+			t.Errorf("%s: total %s is less than its stages", result.Scenario, result.Total)
+		}
+		if _, err := os.Stat(filepath.Join(profiles, fmt.Sprintf("%s-500.mem.pprof", result.Scenario))); err != nil {
+			t.Errorf("Missing memory profile: %v", err)
+		}
+	}
+	if events["all-events"] != 500 {
+		t.Errorf("Expected every event, got %d", events["all-events"])
+	}
+	for _, scenario := range []string{"user-deletes", "secrets-24h", "failed-requests", "namespace-without-service-accounts"} {
+		if events[scenario] == 0 || events[scenario] >= 500 {
+			t.Errorf("%s: expected a selective filter, got %d events", scenario, events[scenario])
+		}
+	}
+	if len(messages) != 1+len(DefaultScenarios) {
+		t.Errorf("Unexpected progress messages %q", messages)
+	}
+}
+
+// benchmarkPipeline runs a scenario on a synthetic audit log of the given size
+func benchmarkPipeline(b *testing.B, name string, size int) {
+	scenarios, err := SelectScenarios([]string{name})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		results, err := Run(context.Background(), Options{Sizes: []int{size}, Scenarios: scenarios})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(results[0].Events), "events")
+	}
+}
+
+func BenchmarkPipeline_AllEvents_10k(b *testing.B) {
+	benchmarkPipeline(b, "all-events", 10000)
+}
+
+func BenchmarkPipeline_UserDeletes_10k(b *testing.B) {
+	benchmarkPipeline(b, "user-deletes", 10000)
+}
+
+func BenchmarkPipeline_UserDeletes_100k(b *testing.B) {
+	benchmarkPipeline(b, "user-deletes", 100000)
+}
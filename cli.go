@@ -0,0 +1,413 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/benchmark"
+	"audit-query-mcp-server/deploy"
+	"audit-query-mcp-server/server"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// programName is the binary as usage lines and completion scripts name it
+const programName = "audit-query-mcp-server"
+
+// queryFlagValues are the completed values of the query parameter flags
+var queryFlagValues = map[string][]string{
+	"log-source": utils.ValidLogSources,
+	"verb":       utils.ValidVerbs,
+	"resource":   utils.ValidResources,
+	"sort-by":    utils.ResultSortFields,
+	"sort-order": utils.ResultSortOrders,
+}
+
+// longFlagPattern matches single-dash long flags, such as -verb or
+// -format=json, which earlier releases parsed with the flag package
+var longFlagPattern = regexp.MustCompile(`^-[A-Za-z][A-Za-z0-9-]+(=.*)?$`)
+
+// rootExamples are the examples of the usage
+const rootExamples = `  # Run setup and validation
+  ./audit-query-mcp-server setup
+
+  # Run all tests
+  ./audit-query-mcp-server test --all
+
+  # Run specific tests
+  ./audit-query-mcp-server test command-builder validation
+
+  # Start HTTP server for testing
+  ./audit-query-mcp-server serve
+
+  # Serve an MCP host, such as Claude Desktop, over stdio
+  ./audit-query-mcp-server mcp
+
+  # Analyze a must-gather audit_logs directory
+  ./audit-query-mcp-server analyze --verb delete --resource secrets must-gather/audit_logs
+
+  # Query secret deletions of the last day outside the control plane
+  ./audit-query-mcp-server query --verb delete --resource secrets --timeframe 24h --exclude-users 'system:*'
+
+  # Ask a question and print the result as JSON
+  ./audit-query-mcp-server query --format json who deleted pods in production today
+
+  # Export the week's secret deletions as an HTML report
+  ./audit-query-mcp-server export --format html --output report.html --verb delete --resource secrets --timeframe 7d
+
+  # Benchmark 10k and 100k line logs and record a baseline
+  ./audit-query-mcp-server bench --sizes 10k,100k --update-baseline
+
+  # Deploy the server into the current cluster
+  ./audit-query-mcp-server deploy --image registry.example.com/audit-query-mcp-server:1.0 | oc apply -f -
+
+  # Convert Sigma Kubernetes audit rules into a watch rules file
+  ./audit-query-mcp-server sigma --notify splunk sigma/rules/application/kubernetes/audit/*.yml > watch-rules.yaml
+
+  # Enable tab completion in bash
+  source <(./audit-query-mcp-server completion bash)`
+
+// runCommand runs the subcommand named by the first argument, showing the
+// usage without one. Argument errors exit with status 2.
+func runCommand(args []string) {
+	root := newRootCommand()
+	root.SetArgs(normalizeFlagArgs(args))
+	if err := root.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// normalizeFlagArgs rewrites single-dash long flags as the double-dash flags
+// cobra parses, so scripts written for the earlier flag syntax keep working.
+// Arguments after "--" are left alone.
+func normalizeFlagArgs(args []string) []string {
+	normalized := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			copy(normalized[i:], args[i:])
+			break
+		}
+		if longFlagPattern.MatchString(arg) {
+			arg = "-" + arg
+		}
+		normalized[i] = arg
+	}
+	return normalized
+}
+
+// newRootCommand returns the CLI with its subcommands. Only the commands that
+// need it create the MCP server.
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           programName,
+		Short:         "OpenShift Audit Query MCP Server",
+		Long:          "🚀 OpenShift Audit Query MCP Server\n\nSafe, structured access to OpenShift audit logs for MCP hosts and the command line.\nSee README.md for detailed usage instructions.",
+		Example:       rootExamples,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w\nRun '%s --help' for usage", err, cmd.CommandPath())
+	})
+	root.AddCommand(
+		newSetupCommand(),
+		newTestCommand(),
+		newServeCommand(),
+		newMCPCommand(),
+		newQueryCommand("query"),
+		newQueryCommand("export"),
+		newDashboardCommand(),
+		newAnalyzeCommand(),
+		newVerifyTrailCommand(),
+		newBenchCommand(),
+		newDeployCommand(),
+		newSigmaCommand(),
+	)
+	return root
+}
+
+// newServer creates the MCP server of a command that uses one
+func newServer() *server.AuditQueryMCPServer {
+	srv := server.NewAuditQueryMCPServer()
+	srv.GetLogger().Info("OpenShift Audit Query MCP Server started")
+	return srv
+}
+
+// registerCompletions completes the flag values of a command: the listed
+// values, file names for fileFlags, and nothing for the other flags that take
+// a value
+func registerCompletions(cmd *cobra.Command, values map[string][]string, fileFlags ...string) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		switch {
+		case len(values[f.Name]) > 0:
+			cmd.RegisterFlagCompletionFunc(f.Name, completeValues(values[f.Name]))
+		case utils.Contains(fileFlags, f.Name):
+			// Files are the default completion
+		case f.NoOptDefVal == "":
+			cmd.RegisterFlagCompletionFunc(f.Name, cobra.NoFileCompletions)
+		}
+	})
+}
+
+// completeValues returns a completion function offering the values starting
+// with the word being completed
+func completeValues(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var completions []string
+		for _, value := range values {
+			if strings.HasPrefix(value, toComplete) {
+				completions = append(completions, value)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// helpText returns the details a help writer writes, shown after the summary
+func helpText(summary string, write func(w io.Writer)) string {
+	var details strings.Builder
+	write(&details)
+	return summary + "\n\n" + strings.TrimRight(details.String(), "\n")
+}
+
+// newSetupCommand returns the setup command, which checks the environment
+func newSetupCommand() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Check the environment, creating .env from env.example, and report what to fix; the run_preflight tool runs the same checks without the development ones",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			runSetup(newServer(), asJSON)
+		},
+	}
+	addSetupFlags(cmd.Flags(), &asJSON)
+	return cmd
+}
+
+// newTestCommand returns the test command, completing test names and
+// categories as arguments
+func newTestCommand() *cobra.Command {
+	config := &TestConfig{}
+	summary := "Run the test suite; without test names or --all, the fast tests run"
+	cmd := &cobra.Command{
+		Use:               "test [flags] [test-names...]",
+		Short:             summary,
+		Long:              helpText(summary, writeTestHelp),
+		ValidArgsFunction: completeValues(testNamesAndCategories()),
+		Run: func(_ *cobra.Command, args []string) {
+			config.TestNames = args
+			if !runTests(config) {
+				os.Exit(1)
+			}
+		},
+	}
+	addTestFlags(cmd.Flags(), config)
+	registerCompletions(cmd, nil, "junit")
+	return cmd
+}
+
+// newServeCommand returns the serve command, which starts the HTTP server
+func newServeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "serve",
+		Short:             "Start the HTTP server for testing, with metrics and the admin API, on PORT (default 3000)",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Run: func(_ *cobra.Command, _ []string) {
+			runHTTPServer(newServer())
+		},
+	}
+}
+
+// newMCPCommand returns the mcp command, which serves an MCP host over stdio
+func newMCPCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "mcp",
+		Short:             "Serve MCP clients over stdin and stdout, as started by an MCP host",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Run: func(_ *cobra.Command, _ []string) {
+			runMCP(newServer())
+		},
+	}
+}
+
+// newQueryCommand returns the query or export command, which take the same
+// parameter flags
+func newQueryCommand(name string) *cobra.Command {
+	config := &queryFlags{}
+	cmd := &cobra.Command{
+		Use:               name + " [flags] [question]",
+		Short:             "Run a query from parameter flags or a natural-language question and print its entries",
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			question, err := finishQueryFlags(cmd.Flags(), name, config, args)
+			if err != nil {
+				return err
+			}
+			if name == "export" {
+				runExport(newServer(), config, question)
+			} else {
+				runQuery(newServer(), config, question)
+			}
+			return nil
+		},
+	}
+	formats := queryFormats
+	if name == "export" {
+		cmd.Short = "Run a query from parameter flags or a question and export its result as a report, CSV or JSON"
+		formats = exportFormats
+	}
+	addQueryFlags(cmd.Flags(), name, config)
+	registerCompletions(cmd, withFlagValues(queryFlagValues, map[string][]string{"format": formats}), "output")
+	return cmd
+}
+
+// newDashboardCommand returns the dashboard command
+func newDashboardCommand() *cobra.Command {
+	config := &dashboardFlags{}
+	cmd := &cobra.Command{
+		Use:               "dashboard",
+		Short:             "Show live statistics and drill into recent results",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if config.refresh <= 0 || config.recentLimit <= 0 || config.entryRows <= 0 {
+				return fmt.Errorf("--refresh, --recent and --rows must be positive")
+			}
+			runDashboard(newServer(), config)
+			return nil
+		},
+	}
+	addDashboardFlags(cmd.Flags(), config)
+	registerCompletions(cmd, nil, "log-file")
+	return cmd
+}
+
+// newAnalyzeCommand returns the analyze command, which runs the pipeline on
+// local audit log files
+func newAnalyzeCommand() *cobra.Command {
+	var params types.AuditQueryParams
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "analyze [flags] <audit log file or directory>",
+		Short: "Run the filter, parse and summary pipeline on exported audit logs without cluster access",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			runAnalyze(newServer(), args[0], params, asJSON)
+		},
+	}
+	addAnalyzeFlags(cmd.Flags(), &params, &asJSON)
+	registerCompletions(cmd, queryFlagValues)
+	return cmd
+}
+
+// newVerifyTrailCommand returns the verify-trail command
+func newVerifyTrailCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify-trail [path]",
+		Short: "Verify the audit trail hash chain (default: AUDIT_TRAIL_FILE or ./logs/audit_trail.json)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			runVerifyTrail(args)
+		},
+	}
+}
+
+// newBenchCommand returns the bench command, listing the scenarios in its help
+func newBenchCommand() *cobra.Command {
+	config := &benchFlags{}
+	summary := "Benchmark the query pipeline on synthetic audit logs against a baseline"
+	cmd := &cobra.Command{
+		Use:               "bench",
+		Short:             summary,
+		Long:              helpText(summary, writeBenchScenarios),
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Run: func(_ *cobra.Command, _ []string) {
+			runBench(config)
+		},
+	}
+	addBenchFlags(cmd.Flags(), config)
+	scenarios := make([]string, len(benchmark.DefaultScenarios))
+	for i, scenario := range benchmark.DefaultScenarios {
+		scenarios[i] = scenario.Name
+	}
+	registerCompletions(cmd, map[string][]string{"scenarios": scenarios}, "baseline", "memprofile")
+	return cmd
+}
+
+// newDeployCommand returns the deploy command, whose flag defaults are the
+// default deploy options
+func newDeployCommand() *cobra.Command {
+	options := deploy.DefaultOptions()
+	cmd := &cobra.Command{
+		Use:               "deploy",
+		Short:             "Print the manifests that run the server in the cluster, for oc apply -f -",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Run: func(_ *cobra.Command, _ []string) {
+			runDeploy(options)
+		},
+	}
+	addDeployFlags(cmd.Flags(), &options)
+	registerCompletions(cmd, nil)
+	return cmd
+}
+
+// newSigmaCommand returns the sigma command, which takes Sigma rule files
+func newSigmaCommand() *cobra.Command {
+	var notify string
+	cmd := &cobra.Command{
+		Use:   "sigma [flags] <rule.yml>...",
+		Short: "Convert Sigma rules into watch rules written to stdout",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			runSigma(notify, args)
+		},
+	}
+	addSigmaFlags(cmd.Flags(), &notify)
+	registerCompletions(cmd, nil)
+	return cmd
+}
+
+// withFlagValues returns the flag value completions of base and extra
+func withFlagValues(base, extra map[string][]string) map[string][]string {
+	values := make(map[string][]string, len(base)+len(extra))
+	for name, list := range base {
+		values[name] = list
+	}
+	for name, list := range extra {
+		values[name] = list
+	}
+	return values
+}
+
+// testNamesAndCategories returns the test names and categories the test
+// command accepts
+func testNamesAndCategories() []string {
+	names := scenarioNames()
+	for category := range testCategories {
+		names = append(names, category)
+	}
+	names = removeDuplicates(names)
+	sort.Strings(names)
+	return names
+}
+
+// writeBenchScenarios lists the benchmark scenarios
+func writeBenchScenarios(w io.Writer) {
+	fmt.Fprintln(w, "Scenarios:")
+	for _, scenario := range benchmark.DefaultScenarios {
+		fmt.Fprintf(w, "  %-36s %s\n", scenario.Name, scenario.Description)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// TestCommandHelp tests the help of commands with and without flags
+func TestCommandHelp(t *testing.T) {
+	root := newRootCommand()
+	seen := make(map[string]bool)
+	for _, cmd := range root.Commands() {
+		if seen[cmd.Name()] {
+			t.Errorf("Command %s is declared twice", cmd.Name())
+		}
+		seen[cmd.Name()] = true
+		if cmd.Short == "" || (cmd.Run == nil && cmd.RunE == nil) {
+			t.Errorf("Command %s lacks a summary or run function", cmd.Name())
+		}
+	}
+
+	var output bytes.Buffer
+	root.SetOut(&output)
+	root.SetArgs(normalizeFlagArgs([]string{"export", "-help"}))
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	help := output.String()
+	for _, expected := range []string{"audit-query-mcp-server export [flags] [question]", "--format string", "--output string", "--sort-by string"} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("Expected the export help to contain %q:\n%s", expected, help)
+		}
+	}
+
+	if _, _, err := root.Find([]string{"bogus"}); err == nil {
+		t.Error("Expected no bogus command")
+	}
+}
+
+// TestNormalizeFlagArgs tests that single-dash long flags keep working
+func TestNormalizeFlagArgs(t *testing.T) {
+	args := normalizeFlagArgs([]string{"-verb", "delete", "-format=json", "-v", "--all", "-", "--", "-literal"})
+	expected := "--verb delete --format=json -v --all - -- -literal"
+	if strings.Join(args, " ") != expected {
+		t.Errorf("Expected %q, got %q", expected, strings.Join(args, " "))
+	}
+}
+
+// TestCompletion tests that completion covers the commands, flags and flag values
+func TestCompletion(t *testing.T) {
+	complete := func(args ...string) string {
+		root := newRootCommand()
+		var output bytes.Buffer
+		root.SetOut(&output)
+		root.SetErr(io.Discard)
+		root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("Unexpected error completing %v: %v", args, err)
+		}
+		return output.String()
+	}
+
+	commands := complete("")
+	for _, expected := range []string{"setup\t", "query\t", "export\t", "sigma\t", "completion\t"} {
+		if !strings.Contains(commands, expected) {
+			t.Errorf("Expected the commands to contain %q:\n%s", expected, commands)
+		}
+	}
+
+	sortFields := complete("query", "--sort-by", "")
+	if !strings.HasPrefix(sortFields, strings.Join(utils.ResultSortFields, "\n")+"\n") {
+		t.Errorf("Expected the sort fields %v:\n%s", utils.ResultSortFields, sortFields)
+	}
+	if formats := complete("export", "--format", ""); !strings.HasPrefix(formats, "markdown\nhtml\ncsv\njson\n") {
+		t.Errorf("Unexpected export formats:\n%s", formats)
+	}
+	// Value flags without listed values complete nothing, file flags files
+	if usernames := complete("query", "--username", ""); usernames != ":4\n" {
+		t.Errorf("Expected no completion of --username:\n%s", usernames)
+	}
+	if junit := complete("test", "--junit", ""); junit != ":0\n" {
+		t.Errorf("Expected file completion of --junit:\n%s", junit)
+	}
+	if names := complete("test", "co"); !strings.HasPrefix(names, "command-builder\ncommand-syntax\ncore\n") {
+		t.Errorf("Unexpected test names:\n%s", names)
+	}
+
+	root := newRootCommand()
+	var script bytes.Buffer
+	root.SetOut(&script)
+	root.SetArgs([]string{"completion", "bash"})
+	if err := root.Execute(); err != nil || !strings.Contains(script.String(), "__start_audit-query-mcp-server") {
+		t.Errorf("Expected a bash completion script, got %v", err)
+	}
+}
+
+// TestTestFlags tests reading the test flags and names
+func TestTestFlags(t *testing.T) {
+	config := &TestConfig{}
+	cmd := &cobra.Command{Use: "test"}
+	addTestFlags(cmd.Flags(), config)
+	if err := cmd.ParseFlags(normalizeFlagArgs([]string{"-compact", "-junit", "report.xml", "-v", "core", "parser"})); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config.TestNames = cmd.Flags().Args()
+	if !config.Compact || !config.Verbose || config.JUnitFile != "report.xml" || strings.Join(config.TestNames, ",") != "core,parser" {
+		t.Errorf("Unexpected test configuration: %+v", config)
+	}
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"strings"
+
+	"audit-query-mcp-server/utils"
+)
+
+// APIResourcesArgs returns the read-only oc arguments that list the resources
+// the cluster serves, with their short names, API versions and kinds
+func APIResourcesArgs() []string {
+	return []string{"api-resources"}
+}
+
+// ParseAPIResources returns the resources listed by the APIResourcesArgs
+// command. Columns are read at the offsets of the header, since the short
+// names column is empty for most resources; older clients print an APIGROUP
+// column instead of APIVERSION.
+func ParseAPIResources(output string) []utils.DiscoveredResource {
+	lines := strings.Split(output, "\n")
+	if len(lines) == 0 {
+		return nil
+	}
+	header := lines[0]
+	columns := map[string]int{}
+	for _, name := range []string{"NAME", "SHORTNAMES", "APIVERSION", "APIGROUP", "NAMESPACED", "KIND"} {
+		columns[name] = strings.Index(header, name)
+	}
+	if columns["NAME"] != 0 || columns["KIND"] < 0 {
+		return nil
+	}
+
+	var resources []utils.DiscoveredResource
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		resource := utils.DiscoveredResource{
+			Name: columnAt(line, columns, "NAME", "SHORTNAMES", "APIVERSION", "APIGROUP", "NAMESPACED", "KIND"),
+			Kind: columnAt(line, columns, "KIND"),
+		}
+		if shortNames := columnAt(line, columns, "SHORTNAMES", "APIVERSION", "APIGROUP", "NAMESPACED", "KIND"); shortNames != "" {
+			resource.ShortNames = strings.Split(shortNames, ",")
+		}
+		if apiVersion := columnAt(line, columns, "APIVERSION", "NAMESPACED", "KIND"); strings.Contains(apiVersion, "/") {
+			resource.Group = apiVersion[:strings.IndexByte(apiVersion, '/')]
+		} else if columns["APIGROUP"] >= 0 {
+			resource.Group = columnAt(line, columns, "APIGROUP", "NAMESPACED", "KIND")
+		}
+		if resource.Name != "" {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// columnAt returns the trimmed text of a line from the offset of a column to
+// the offset of the first following column present in the header
+func columnAt(line string, columns map[string]int, column string, following ...string) string {
+	start := columns[column]
+	if start < 0 || start >= len(line) {
+		return ""
+	}
+	end := len(line)
+	for _, next := range following {
+		if offset := columns[next]; offset > start && offset < end {
+			end = offset
+			break
+		}
+	}
+	return strings.TrimSpace(line[start:end])
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"audit-query-mcp-server/utils"
+)
+
+// TestParseAPIResources tests parsing the resources listed by APIResourcesArgs
+func TestParseAPIResources(t *testing.T) {
+	output := `NAME                        SHORTNAMES   APIVERSION                        NAMESPACED   KIND
+bindings                                 v1                                true         Binding
+configmaps                  cm           v1                                true         ConfigMap
+customresourcedefinitions   crd,crds     apiextensions.k8s.io/v1           false        CustomResourceDefinition
+virtualmachines             vm,vms       kubevirt.io/v1                    true         VirtualMachine
+`
+	expected := []utils.DiscoveredResource{
+		{Name: "bindings", Kind: "Binding"},
+		{Name: "configmaps", ShortNames: []string{"cm"}, Kind: "ConfigMap"},
+		{Name: "customresourcedefinitions", ShortNames: []string{"crd", "crds"}, Kind: "CustomResourceDefinition", Group: "apiextensions.k8s.io"},
+		{Name: "virtualmachines", ShortNames: []string{"vm", "vms"}, Kind: "VirtualMachine", Group: "kubevirt.io"},
+	}
+	if resources := ParseAPIResources(output); !reflect.DeepEqual(resources, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, resources)
+	}
+
+	older := "NAME     SHORTNAMES   APIGROUP             NAMESPACED   KIND\n" +
+		"routes                route.openshift.io   true         Route\n"
+	if resources := ParseAPIResources(older); len(resources) != 1 || resources[0].Group != "route.openshift.io" || resources[0].Kind != "Route" {
+		t.Errorf("Unexpected resources from the older layout: %+v", resources)
+	}
+	if resources := ParseAPIResources("error: the server doesn't have a resource type"); resources != nil {
+		t.Errorf("Expected no resources without a header, got %+v", resources)
+	}
+	if args := APIResourcesArgs(); !reflect.DeepEqual(args, []string{"api-resources"}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"audit-query-mcp-server/types"
+)
+
+// OpenShift APIServer audit profiles
+const (
+	AuditProfileDefault            = "Default"
+	AuditProfileWriteRequestBodies = "WriteRequestBodies"
+	AuditProfileAllRequestBodies   = "AllRequestBodies"
+	AuditProfileNone               = "None"
+)
+
+// auditProfileDetails describes what each audit profile records
+var auditProfileDetails = map[string]types.AuditProfileDetail{
+	AuditProfileDefault: {
+		Metadata:    true,
+		Description: "Metadata for all requests; request bodies only for OAuth access token requests",
+	},
+	AuditProfileWriteRequestBodies: {
+		Metadata:           true,
+		WriteRequestBodies: true,
+		Description:        "Metadata for all requests and request bodies for write requests (create, update, patch, delete)",
+	},
+	AuditProfileAllRequestBodies: {
+		Metadata:           true,
+		WriteRequestBodies: true,
+		ReadRequestBodies:  true,
+		Description:        "Metadata for all requests and request bodies for read and write requests",
+	},
+	AuditProfileNone: {
+		Description: "No audit events are recorded",
+	},
+}
+
+// AuditConfigurationArgs returns the oc arguments that read the cluster APIServer
+// configuration
+func AuditConfigurationArgs() []string {
+	return []string{"get", "apiserver", "cluster", "-o", "json"}
+}
+
+// apiServerConfig is the part of the APIServer resource holding the audit policy
+type apiServerConfig struct {
+	Spec struct {
+		Audit struct {
+			Profile     string `json:"profile"`
+			CustomRules []struct {
+				Group   string `json:"group"`
+				Profile string `json:"profile"`
+			} `json:"customRules"`
+		} `json:"audit"`
+	} `json:"spec"`
+}
+
+// ParseAuditConfiguration describes the audit policy in the JSON of the cluster
+// APIServer resource. An unset profile is the Default profile.
+func ParseAuditConfiguration(data []byte) (*types.AuditConfiguration, error) {
+	var config apiServerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse APIServer configuration: %w", err)
+	}
+
+	profile := config.Spec.Audit.Profile
+	if profile == "" {
+		profile = AuditProfileDefault
+	}
+	result := &types.AuditConfiguration{
+		Profile: profile,
+		Detail:  DescribeAuditProfile(profile),
+	}
+	for _, rule := range config.Spec.Audit.CustomRules {
+		result.CustomRules = append(result.CustomRules, types.AuditCustomRule{
+			Group:   rule.Group,
+			Profile: rule.Profile,
+			Detail:  DescribeAuditProfile(rule.Profile),
+		})
+	}
+	result.Notes = auditConfigurationNotes(result)
+	return result, nil
+}
+
+// DescribeAuditProfile returns what an audit profile records; unknown profiles
+// are described as such
+func DescribeAuditProfile(profile string) types.AuditProfileDetail {
+	if detail, ok := auditProfileDetails[profile]; ok {
+		return detail
+	}
+	return types.AuditProfileDetail{Description: fmt.Sprintf("Unknown audit profile %q", profile)}
+}
+
+// auditConfigurationNotes explains what queries can expect under config
+func auditConfigurationNotes(config *types.AuditConfiguration) []string {
+	var notes []string
+	detail := config.Detail
+	_, known := auditProfileDetails[config.Profile]
+	switch {
+	case !known:
+		notes = append(notes, "The audit profile is not recognised, so the recorded detail is unknown")
+	case config.Profile == AuditProfileNone:
+		notes = append(notes, "Audit logging is disabled; queries return no events except for groups with a custom rule")
+	case !detail.WriteRequestBodies:
+		notes = append(notes, "requestObject is not recorded, so queries cannot show what was sent in create, update or patch requests")
+	case !detail.ReadRequestBodies:
+		notes = append(notes, "requestObject is recorded for write requests only")
+	}
+	for _, rule := range config.CustomRules {
+		notes = append(notes, fmt.Sprintf("Requests by users in group %s use the %s profile: %s", rule.Group, rule.Profile, rule.Detail.Description))
+	}
+	return notes
+}
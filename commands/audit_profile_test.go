@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseAuditConfiguration tests describing the APIServer audit policy
+func TestParseAuditConfiguration(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		profile       string
+		writeBodies   bool
+		readBodies    bool
+		noteSubstring string
+	}{
+		{
+			name:          "unset profile is Default",
+			json:          `{"spec":{}}`,
+			profile:       AuditProfileDefault,
+			noteSubstring: "requestObject is not recorded",
+		},
+		{
+			name:          "write request bodies",
+			json:          `{"spec":{"audit":{"profile":"WriteRequestBodies"}}}`,
+			profile:       AuditProfileWriteRequestBodies,
+			writeBodies:   true,
+			noteSubstring: "write requests only",
+		},
+		{
+			name:        "all request bodies",
+			json:        `{"spec":{"audit":{"profile":"AllRequestBodies"}}}`,
+			profile:     AuditProfileAllRequestBodies,
+			writeBodies: true,
+			readBodies:  true,
+		},
+		{
+			name:          "logging disabled",
+			json:          `{"spec":{"audit":{"profile":"None"}}}`,
+			profile:       AuditProfileNone,
+			noteSubstring: "disabled",
+		},
+		{
+			name:          "unknown profile",
+			json:          `{"spec":{"audit":{"profile":"Verbose"}}}`,
+			profile:       "Verbose",
+			noteSubstring: "not recognised",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseAuditConfiguration([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if config.Profile != tt.profile {
+				t.Errorf("Expected profile %s, got %s", tt.profile, config.Profile)
+			}
+			if config.Detail.WriteRequestBodies != tt.writeBodies || config.Detail.ReadRequestBodies != tt.readBodies {
+				t.Errorf("Unexpected detail %+v", config.Detail)
+			}
+			notes := strings.Join(config.Notes, "\n")
+			if tt.noteSubstring == "" && notes != "" {
+				t.Errorf("Expected no notes, got %q", notes)
+			}
+			if !strings.Contains(notes, tt.noteSubstring) {
+				t.Errorf("Expected a note containing %q, got %q", tt.noteSubstring, notes)
+			}
+		})
+	}
+}
+
+// TestParseAuditConfiguration_CustomRules tests that per-group profiles are described
+func TestParseAuditConfiguration_CustomRules(t *testing.T) {
+	config, err := ParseAuditConfiguration([]byte(`{"spec":{"audit":{"profile":"Default","customRules":[{"group":"system:authenticated:oauth","profile":"WriteRequestBodies"}]}}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(config.CustomRules) != 1 || !config.CustomRules[0].Detail.WriteRequestBodies {
+		t.Fatalf("Unexpected custom rules %+v", config.CustomRules)
+	}
+	if !strings.Contains(strings.Join(config.Notes, "\n"), "group system:authenticated:oauth use the WriteRequestBodies profile") {
+		t.Errorf("Expected a note for the custom rule, got %v", config.Notes)
+	}
+
+	if _, err := ParseAuditConfiguration([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
@@ -1,16 +1,47 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
 	"os/exec"
 )
 
+// Default complexity limits applied to free-text patterns and exclusions
+const (
+	DefaultMaxPatterns   = types.DefaultMaxPatterns
+	DefaultMaxExclusions = types.DefaultMaxExclusions
+)
+
+var (
+	maxPatterns        = DefaultMaxPatterns
+	maxExclusions      = DefaultMaxExclusions
+	complexityLimitsMu sync.RWMutex
+)
+
+// SetComplexityLimits sets the pattern and exclusion limits of the command
+// builders NewCommandBuilder creates; 0 or less disables a limit
+func SetComplexityLimits(patterns, exclusions int) {
+	complexityLimitsMu.Lock()
+	defer complexityLimitsMu.Unlock()
+	maxPatterns, maxExclusions = patterns, exclusions
+}
+
+// ComplexityLimits returns the limits set with SetComplexityLimits
+func ComplexityLimits() (patterns, exclusions int) {
+	complexityLimitsMu.RLock()
+	defer complexityLimitsMu.RUnlock()
+	return maxPatterns, maxExclusions
+}
+
 // LogFileInfo represents information about a log file
 type LogFileInfo struct {
 	Path      string
@@ -25,30 +56,32 @@ type CommandBuilder struct {
 	Discovery types.DiscoveryConfig
 	Cache     *types.FileDiscoveryCache
 	Circuit   *types.CircuitBreaker
+	// OcFlags are global oc flags, such as --context, for the oc commands the
+	// builder runs to discover log files
+	OcFlags []string
 }
 
 // NewCommandBuilder creates a new command builder with default configuration
+// and the complexity limits set with SetComplexityLimits
 func NewCommandBuilder() *CommandBuilder {
+	config := types.DefaultAuditQueryConfig()
+	config.MaxPatterns, config.MaxExclusions = ComplexityLimits()
 	return &CommandBuilder{
-		Config:    types.DefaultAuditQueryConfig(),
+		Config:    config,
 		Migration: types.DefaultMigrationConfig(),
 		Discovery: types.DefaultDiscoveryConfig(),
 		Cache: &types.FileDiscoveryCache{
 			Cache: make(map[string][]string),
 			TTL:   5 * time.Minute,
 		},
-		Circuit: &types.CircuitBreaker{
-			FailureThreshold: 3,
-			ResetTimeout:     30 * time.Second,
-			State:            types.CircuitStateClosed,
-		},
+		Circuit: types.NewCircuitBreaker(types.DefaultCircuitFailureThreshold, types.DefaultCircuitResetTimeout),
 	}
 }
 
 // BuildOcCommand constructs the oc command based on parameters with support for rolling logs
 // This is the legacy function that maintains backward compatibility
 func BuildOcCommand(params types.AuditQueryParams) string {
-	// Use the new command builder with default configuration
+	// Use the new command builder with the configured limits
 	builder := NewCommandBuilder()
 	return builder.BuildOptimalCommand(params)
 }
@@ -60,16 +93,21 @@ func BuildOcCommandWithConfig(params types.AuditQueryParams, config types.AuditQ
 	return builder.BuildOptimalCommand(params)
 }
 
+// BuildOcCommandWithCircuit constructs the oc command using a shared circuit
+// breaker, so failures recorded by the caller switch later commands to the
+// fallback. Log files are discovered with oc run with ocFlags.
+func BuildOcCommandWithCircuit(params types.AuditQueryParams, circuit *types.CircuitBreaker, ocFlags ...string) string {
+	builder := NewCommandBuilder()
+	builder.Circuit = circuit
+	builder.OcFlags = ocFlags
+	return builder.BuildOptimalCommand(params)
+}
+
 // BuildOptimalCommand builds the optimal command based on parameters and configuration
 func (cb *CommandBuilder) BuildOptimalCommand(params types.AuditQueryParams) string {
-	// Check circuit breaker state
-	if cb.Circuit.State == types.CircuitStateOpen {
-		if time.Since(cb.Circuit.LastFailureTime) < cb.Circuit.ResetTimeout {
-			// Use fallback command when circuit breaker is open
-			return cb.buildFallbackCommand(params)
-		}
-		// Reset to half-open
-		cb.Circuit.State = types.CircuitStateHalfOpen
+	// Use the fallback command while the circuit breaker is open
+	if !cb.Circuit.Allow() {
+		return cb.buildFallbackCommand(params)
 	}
 
 	// Always start with simple approach for reliability (Phase 1 fix)
@@ -85,6 +123,57 @@ func (cb *CommandBuilder) BuildOptimalCommand(params types.AuditQueryParams) str
 	return cb.buildFallbackCommand(params)
 }
 
+// BuildCommandWarnings reports parameters a command builder with the configured
+// limits will not apply
+func BuildCommandWarnings(params types.AuditQueryParams) []string {
+	return NewCommandBuilder().CommandWarnings(params)
+}
+
+// CommandWarnings reports parameters dropped by the configured complexity limits
+func (cb *CommandBuilder) CommandWarnings(params types.AuditQueryParams) []string {
+	var warnings []string
+
+	if dropped := truncatedItems(params.Patterns, cb.Config.MaxPatterns); len(dropped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("only the first %d patterns are applied; ignored: %s",
+			cb.Config.MaxPatterns, strings.Join(dropped, ", ")))
+	}
+	if dropped := truncatedItems(params.Exclude, cb.Config.MaxExclusions); len(dropped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("only the first %d exclusions are applied; ignored: %s",
+			cb.Config.MaxExclusions, strings.Join(dropped, ", ")))
+	}
+	if params.Filter != nil && !cb.usesJQ() {
+		if _, exact := buildGrepFilterExpression(*params.Filter); !exact {
+			warnings = append(warnings, "filter expression cannot be fully expressed with grep; "+
+				"unsupported groups were skipped, so results may include events that do not match")
+		}
+	}
+
+	return warnings
+}
+
+// applyComplexityLimits trims patterns and exclusions to the configured limits
+func (cb *CommandBuilder) applyComplexityLimits(params types.AuditQueryParams) types.AuditQueryParams {
+	params.Patterns = limitItems(params.Patterns, cb.Config.MaxPatterns)
+	params.Exclude = limitItems(params.Exclude, cb.Config.MaxExclusions)
+	return params
+}
+
+// limitItems returns at most max items; a non-positive max means no limit
+func limitItems(items []string, max int) []string {
+	if max > 0 && len(items) > max {
+		return items[:max]
+	}
+	return items
+}
+
+// truncatedItems returns the items beyond the limit; a non-positive max means no limit
+func truncatedItems(items []string, max int) []string {
+	if max > 0 && len(items) > max {
+		return items[max:]
+	}
+	return nil
+}
+
 // shouldUseSimpleCommand determines if we should use simple command approach
 func (cb *CommandBuilder) shouldUseSimpleCommand(params types.AuditQueryParams) bool {
 	// Use simple for recent timeframes or when specifically requested
@@ -106,7 +195,7 @@ func (cb *CommandBuilder) shouldUseMultiFile(params types.AuditQueryParams) bool
 // buildSimpleCommand builds a simple, reliable command
 func (cb *CommandBuilder) buildSimpleCommand(params types.AuditQueryParams) string {
 	// Check if JSON parsing is enabled and available
-	if cb.Config.UseJSONParsing && cb.checkJQAvailability() {
+	if cb.usesJQ() {
 		return cb.buildJSONAwareCommand(params)
 	}
 
@@ -117,56 +206,24 @@ func (cb *CommandBuilder) buildSimpleCommand(params types.AuditQueryParams) stri
 	parts = append(parts, getDefaultLogPath(params.LogSource))
 
 	// Add filters with complexity control
+	params = cb.applyComplexityLimits(params)
 	if len(params.Patterns) > 0 {
-		// Limit to first 3 patterns to avoid complexity
-		maxPatterns := 3
-		if len(params.Patterns) > maxPatterns {
-			params.Patterns = params.Patterns[:maxPatterns]
-		}
 		for _, pattern := range params.Patterns {
 			parts = append(parts, fmt.Sprintf("| grep -i '%s'", pattern))
 		}
 	}
 
-	// Add username filter
-	if params.Username != "" {
-		usernameFilter := BuildUsernameFilter(params.Username)
-		if usernameFilter != "" {
-			parts = append(parts, usernameFilter)
-		}
-	}
-
-	// Add resource filter
-	if params.Resource != "" {
-		resourceFilter := BuildResourceFilter(params.Resource)
-		if resourceFilter != "" {
-			parts = append(parts, resourceFilter)
-		}
+	// Add boolean filter expression
+	if params.Filter != nil {
+		stages, _ := buildGrepFilterExpression(*params.Filter)
+		parts = append(parts, stages...)
 	}
 
-	// Add verb filter
-	if params.Verb != "" {
-		verbFilter := BuildVerbFilter(params.Verb)
-		if verbFilter != "" {
-			parts = append(parts, verbFilter)
-		}
-	}
+	// Add username, resource, verb, namespace and status code filters
+	parts = append(parts, buildGrepFieldFilters(params)...)
 
-	// Add namespace filter
-	if params.Namespace != "" {
-		namespaceFilter := BuildNamespaceFilter(params.Namespace)
-		if namespaceFilter != "" {
-			parts = append(parts, namespaceFilter)
-		}
-	}
-
-	// Add exclusions with complexity control
+	// Add exclusions (already limited above)
 	if len(params.Exclude) > 0 {
-		// Limit to first 3 exclusions to avoid complexity
-		maxExclusions := 3
-		if len(params.Exclude) > maxExclusions {
-			params.Exclude = params.Exclude[:maxExclusions]
-		}
 		for _, exclude := range params.Exclude {
 			parts = append(parts, fmt.Sprintf("| grep -v '%s'", exclude))
 		}
@@ -183,6 +240,12 @@ func (cb *CommandBuilder) buildSimpleCommand(params types.AuditQueryParams) stri
 	return strings.Join(parts, " ")
 }
 
+// BuildFetchCommand builds a command that only fetches the raw audit log, for
+// in-process filtering where jq is not available
+func BuildFetchCommand(params types.AuditQueryParams) string {
+	return "oc adm node-logs --role=master " + getDefaultLogPath(params.LogSource)
+}
+
 // buildJSONAwareCommand builds a JSON-aware command using jq for better accuracy
 func (cb *CommandBuilder) buildJSONAwareCommand(params types.AuditQueryParams) string {
 	baseCommand := "oc adm node-logs --role=master " + getDefaultLogPath(params.LogSource)
@@ -190,52 +253,74 @@ func (cb *CommandBuilder) buildJSONAwareCommand(params types.AuditQueryParams) s
 	// Build jq filters for JSON-aware filtering
 	var jqFilters []string
 
-	// Add username filter
-	if params.Username != "" {
-		escapedUsername := escapeForJQ(params.Username)
-		jqFilters = append(jqFilters, fmt.Sprintf(`(.user.username // .userInfo.username // .impersonatedUser // .requestUser) | test("%s"; "i")`, escapedUsername))
+	// Add field filters; an explicit match mode or several values replace the
+	// legacy substring test
+	fieldFilters := []struct {
+		field  string
+		values []string
+		mode   types.MatchMode
+	}{
+		{jqUsernameField, fieldValues(params.Username, params.Usernames), params.UsernameMatch},
+		{jqVerbField, fieldValues(params.Verb, params.Verbs), params.VerbMatch},
+		{jqResourceField, fieldValues(params.Resource, params.Resources), params.ResourceMatch},
+		{jqNamespaceField, fieldValues(params.Namespace, params.Namespaces), params.NamespaceMatch},
+	}
+	for _, f := range fieldFilters {
+		switch {
+		case len(f.values) == 0:
+			continue
+		case len(f.values) == 1 && f.mode == "":
+			jqFilters = append(jqFilters, fmt.Sprintf(`%s | test("%s"; "i")`, f.field, escapeForJQ(f.values[0])))
+		default:
+			jqFilters = append(jqFilters, buildJQFieldMatchAny(f.field, f.values, f.mode))
+		}
 	}
 
-	// Add verb filter
-	if params.Verb != "" {
-		escapedVerb := escapeForJQ(params.Verb)
-		jqFilters = append(jqFilters, fmt.Sprintf(`.verb | test("%s"; "i")`, escapedVerb))
+	// Add subresource filter; subresources are matched exactly
+	if len(params.Subresources) > 0 {
+		jqFilters = append(jqFilters, buildJQFieldMatchAny(jqSubresourceField, params.Subresources, types.MatchModeExact))
+	}
+
+	// Add structured exclusions
+	jqFilters = append(jqFilters, buildJQFieldExclusions(params)...)
+
+	// Add response status code filters
+	jqFilters = append(jqFilters, buildJQStatusCodeFilters(params)...)
+
+	// Add group and impersonation filters
+	jqFilters = append(jqFilters, buildJQIdentityFilters(params)...)
+
+	// Add user agent filter
+	if params.UserAgent != "" {
+		jqFilters = append(jqFilters, buildJQFieldMatch(jqUserAgentField, params.UserAgent, userAgentMatchMode(params)))
 	}
 
-	// Add resource filter
-	if params.Resource != "" {
-		escapedResource := escapeForJQ(params.Resource)
-		jqFilters = append(jqFilters, fmt.Sprintf(`(.objectRef.resource // .objectRef.apiVersion // .requestObject.kind // .responseObject.kind) | test("%s"; "i")`, escapedResource))
+	// Add audit stage and level filters
+	if params.Stage != "" {
+		jqFilters = append(jqFilters, fmt.Sprintf(`.stage == %s`, jqStringLiteral(params.Stage)))
+	}
+	if params.Level != "" {
+		jqFilters = append(jqFilters, fmt.Sprintf(`.level == %s`, jqStringLiteral(params.Level)))
 	}
 
-	// Add namespace filter
-	if params.Namespace != "" {
-		escapedNamespace := escapeForJQ(params.Namespace)
-		jqFilters = append(jqFilters, fmt.Sprintf(`(.objectRef.namespace // .requestObject.metadata.namespace // .responseObject.metadata.namespace) | test("%s"; "i")`, escapedNamespace))
+	// Add source IP pre-filter; CIDR filters are applied when parsing results
+	if params.SourceIP != "" {
+		jqFilters = append(jqFilters, fmt.Sprintf(`(.sourceIPs // []) | any(. == %s)`, jqStringLiteral(params.SourceIP)))
 	}
 
-	// Add pattern filters
+	// Add pattern and exclusion filters as single all()/any() clauses so that
+	// any number of patterns costs one clause instead of one test per pattern
+	params = cb.applyComplexityLimits(params)
 	if len(params.Patterns) > 0 {
-		maxPatterns := 3
-		if len(params.Patterns) > maxPatterns {
-			params.Patterns = params.Patterns[:maxPatterns]
-		}
-		for _, pattern := range params.Patterns {
-			escapedPattern := escapeForJQ(pattern)
-			jqFilters = append(jqFilters, fmt.Sprintf(`tostring | test("%s"; "i")`, escapedPattern))
-		}
+		jqFilters = append(jqFilters, buildJQPatternClause(params.Patterns, "all"))
 	}
-
-	// Add exclusion filters
 	if len(params.Exclude) > 0 {
-		maxExclusions := 3
-		if len(params.Exclude) > maxExclusions {
-			params.Exclude = params.Exclude[:maxExclusions]
-		}
-		for _, exclude := range params.Exclude {
-			escapedExclude := escapeForJQ(exclude)
-			jqFilters = append(jqFilters, fmt.Sprintf(`(tostring | test("%s"; "i") | not)`, escapedExclude))
-		}
+		jqFilters = append(jqFilters, buildJQPatternClause(params.Exclude, "any")+" | not")
+	}
+
+	// Add boolean filter expression
+	if params.Filter != nil {
+		jqFilters = append(jqFilters, buildJQFilterExpressionClause(*params.Filter))
 	}
 
 	// Add timeframe filter
@@ -249,17 +334,21 @@ func (cb *CommandBuilder) buildJSONAwareCommand(params types.AuditQueryParams) s
 	// Build the complete jq expression
 	var jqExpression string
 	if len(jqFilters) > 0 {
-		jqExpression = fmt.Sprintf(`select(%s)`, strings.Join(jqFilters, " and "))
+		// Parenthesize each clause so a clause's pipe does not swallow the "and"
+		jqExpression = fmt.Sprintf(`select((%s))`, strings.Join(jqFilters, ") and ("))
 	} else {
 		jqExpression = "."
 	}
 
 	// Add output formatting for better readability
 	jqExpression += ` | {
+		auditID: .auditID,
+		stage: .stage,
 		timestamp: .requestReceivedTimestamp,
 		username: (.user.username // .userInfo.username // "unknown"),
 		verb: .verb,
 		resource: (.objectRef.resource // "unknown"),
+		subresource: (.objectRef.subresource // ""),
 		namespace: (.objectRef.namespace // "unknown"),
 		name: (.objectRef.name // "unknown"),
 		statusCode: (.responseStatus.code // 0),
@@ -272,39 +361,63 @@ func (cb *CommandBuilder) buildJSONAwareCommand(params types.AuditQueryParams) s
 	return fmt.Sprintf("%s | jq -r '%s'", baseCommand, jqExpression)
 }
 
+// buildJQPatternClause builds a jq clause that tests the whole event against every
+// pattern, combining the results with the given jq reducer ("all" or "any")
+func buildJQPatternClause(patterns []string, reducer string) string {
+	quoted := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		quoted[i] = fmt.Sprintf(`"%s"`, escapeForJQ(pattern))
+	}
+	return fmt.Sprintf(`tostring as $event | [%s] | %s(. as $p | $event | test($p; "i"))`, strings.Join(quoted, ", "), reducer)
+}
+
 // buildJSONTimeframeFilter creates a JSON-aware timeframe filter
 func buildJSONTimeframeFilter(timeframe string) string {
-	now := time.Now()
+	datePattern := TimeframeDatePattern(timeframe)
+	if datePattern == "" {
+		return ""
+	}
+	return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, datePattern)
+}
+
+// TimeframeDatePattern returns the date prefix that requestReceivedTimestamp must
+// contain for the timeframe, or "" when the timeframe is not recognised
+func TimeframeDatePattern(timeframe string) string {
+	return timeframeDatePatternAt(timeframe, time.Now())
+}
+
+// timeframeDatePatternAt returns the date prefix for the timeframe relative to now
+func timeframeDatePatternAt(timeframe string, now time.Time) string {
 
 	switch timeframe {
 	case "today":
 		today := now.Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, today)
+		return today
 	case "yesterday":
 		yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, yesterday)
+		return yesterday
 	case "this week":
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, now.Format("2006-01-02"))
+		return now.Format("2006-01-02")
 	case "last hour":
 		lastHour := now.Add(-1 * time.Hour).Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, lastHour)
+		return lastHour
 	case "24h", "last 24 hours":
 		last24h := now.AddDate(0, 0, -1).Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, last24h)
+		return last24h
 	case "7d", "last 7 days":
 		last7d := now.AddDate(0, 0, -7).Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, last7d)
+		return last7d
 	case "last week":
 		lastWeek := now.AddDate(0, 0, -7).Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, lastWeek)
+		return lastWeek
 	case "this month":
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, now.Format("2006-01"))
+		return now.Format("2006-01")
 	case "last month":
 		lastMonth := now.AddDate(0, -1, 0).Format("2006-01")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, lastMonth)
+		return lastMonth
 	case "last 30 days":
 		last30d := now.AddDate(0, 0, -30).Format("2006-01-02")
-		return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, last30d)
+		return last30d
 	}
 
 	// Handle "last X minutes/hours/days" patterns
@@ -314,7 +427,7 @@ func buildJSONTimeframeFilter(timeframe string) string {
 		if len(matches) > 1 {
 			minutes, _ := strconv.Atoi(matches[1])
 			lastMinutes := now.Add(-time.Duration(minutes) * time.Minute).Format("2006-01-02")
-			return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, lastMinutes)
+			return lastMinutes
 		}
 	}
 
@@ -324,7 +437,7 @@ func buildJSONTimeframeFilter(timeframe string) string {
 		if len(matches) > 1 {
 			hours, _ := strconv.Atoi(matches[1])
 			lastHours := now.Add(-time.Duration(hours) * time.Hour).Format("2006-01-02")
-			return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, lastHours)
+			return lastHours
 		}
 	}
 
@@ -334,43 +447,37 @@ func buildJSONTimeframeFilter(timeframe string) string {
 		if len(matches) > 1 {
 			days, _ := strconv.Atoi(matches[1])
 			lastDays := now.AddDate(0, 0, -days).Format("2006-01-02")
-			return fmt.Sprintf(`.requestReceivedTimestamp | test("%s")`, lastDays)
+			return lastDays
 		}
 	}
 
 	return ""
 }
 
-// checkJQAvailability checks if jq is available in the system
+// usesJQ reports whether simple commands filter with jq rather than grep
+func (cb *CommandBuilder) usesJQ() bool {
+	return cb.Config.UseJSONParsing && cb.checkJQAvailability()
+}
+
+// checkJQAvailability checks if jq programs can run, with the jq binary or the
+// built-in engine
 func (cb *CommandBuilder) checkJQAvailability() bool {
-	cmd := exec.Command("jq", "--version")
-	err := cmd.Run()
-	return err == nil
+	return JQAvailable()
 }
 
-// escapeForJQ escapes special characters for safe jq usage
+// escapeForJQ escapes input for use as a literal regex inside a jq string
+// literal: regex metacharacters are backslash-escaped, then the result is
+// encoded with JSON string escapes, which are the only escapes jq accepts.
 func escapeForJQ(input string) string {
-	// Escape special jq characters: " \ / [ ] { } ( ) * + ? | ^ $ . ~
+	// Escape regex metacharacters: \ [ ] { } ( ) * + ? | ^ $ . ~
 	escaped := input
-	escaped = strings.ReplaceAll(escaped, "\\", "\\\\")
-	escaped = strings.ReplaceAll(escaped, "\"", "\\\"")
-	escaped = strings.ReplaceAll(escaped, "/", "\\/")
-	escaped = strings.ReplaceAll(escaped, "[", "\\[")
-	escaped = strings.ReplaceAll(escaped, "]", "\\]")
-	escaped = strings.ReplaceAll(escaped, "{", "\\{")
-	escaped = strings.ReplaceAll(escaped, "}", "\\}")
-	escaped = strings.ReplaceAll(escaped, "(", "\\(")
-	escaped = strings.ReplaceAll(escaped, ")", "\\)")
-	escaped = strings.ReplaceAll(escaped, "*", "\\*")
-	escaped = strings.ReplaceAll(escaped, "+", "\\+")
-	escaped = strings.ReplaceAll(escaped, "?", "\\?")
-	escaped = strings.ReplaceAll(escaped, "|", "\\|")
-	escaped = strings.ReplaceAll(escaped, "^", "\\^")
-	escaped = strings.ReplaceAll(escaped, "$", "\\$")
-	escaped = strings.ReplaceAll(escaped, ".", "\\.")
-	escaped = strings.ReplaceAll(escaped, "~", "\\~")
-
-	return escaped
+	for _, c := range []string{"\\", "[", "]", "{", "}", "(", ")", "*", "+", "?", "|", "^", "$", ".", "~"} {
+		escaped = strings.ReplaceAll(escaped, c, "\\"+c)
+	}
+
+	// Encode as the contents of a jq string literal
+	literal := jqStringLiteral(escaped)
+	return strings.ReplaceAll(literal[1:len(literal)-1], "/", `\/`)
 }
 
 // buildErrorTolerantMultiFileCommand builds an error-tolerant multi-file command
@@ -412,50 +519,24 @@ func (cb *CommandBuilder) buildSingleFileCommand(params types.AuditQueryParams,
 	}
 
 	// Add filters with complexity control
+	params = cb.applyComplexityLimits(params)
 	if len(params.Patterns) > 0 {
-		maxPatterns := 3
-		if len(params.Patterns) > maxPatterns {
-			params.Patterns = params.Patterns[:maxPatterns]
-		}
 		for _, pattern := range params.Patterns {
 			parts = append(parts, fmt.Sprintf("| grep -i '%s'", pattern))
 		}
 	}
 
-	if params.Username != "" {
-		usernameFilter := BuildUsernameFilter(params.Username)
-		if usernameFilter != "" {
-			parts = append(parts, usernameFilter)
-		}
-	}
-
-	if params.Resource != "" {
-		resourceFilter := BuildResourceFilter(params.Resource)
-		if resourceFilter != "" {
-			parts = append(parts, resourceFilter)
-		}
+	// Add boolean filter expression
+	if params.Filter != nil {
+		stages, _ := buildGrepFilterExpression(*params.Filter)
+		parts = append(parts, stages...)
 	}
 
-	if params.Verb != "" {
-		verbFilter := BuildVerbFilter(params.Verb)
-		if verbFilter != "" {
-			parts = append(parts, verbFilter)
-		}
-	}
-
-	if params.Namespace != "" {
-		namespaceFilter := BuildNamespaceFilter(params.Namespace)
-		if namespaceFilter != "" {
-			parts = append(parts, namespaceFilter)
-		}
-	}
+	// Add username, resource, verb, namespace and status code filters
+	parts = append(parts, buildGrepFieldFilters(params)...)
 
-	// Add exclusions with complexity control
+	// Add exclusions (already limited above)
 	if len(params.Exclude) > 0 {
-		maxExclusions := 3
-		if len(params.Exclude) > maxExclusions {
-			params.Exclude = params.Exclude[:maxExclusions]
-		}
 		for _, exclude := range params.Exclude {
 			parts = append(parts, fmt.Sprintf("| grep -v '%s'", exclude))
 		}
@@ -506,7 +587,8 @@ func (cb *CommandBuilder) getAvailableLogFiles(logSource, timeframe string) []ty
 // discoverAvailableLogFiles discovers available log files from the cluster
 func (cb *CommandBuilder) discoverAvailableLogFiles(logSource string) []string {
 	// Use oc adm node-logs --list-files to discover available files
-	cmd := exec.Command("oc", "adm", "node-logs", "--role=master", "--list-files")
+	args := append(append([]string(nil), cb.OcFlags...), "adm", "node-logs", "--role=master", "--list-files")
+	cmd := exec.Command("oc", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		// Fallback to known patterns
@@ -594,41 +676,22 @@ func (cb *CommandBuilder) parseDateFromFilename(filename string) time.Time {
 
 // ExecuteCommand executes a command with circuit breaker protection
 func (cb *CommandBuilder) ExecuteCommand(command string) (string, error) {
-	if cb.Circuit.State == types.CircuitStateOpen {
+	if !cb.Circuit.Allow() {
 		return "", fmt.Errorf("circuit breaker is open")
 	}
 
-	// Execute command
-	cmd := exec.Command("bash", "-c", command)
-	output, err := cmd.Output()
-
+	// Execute command without a shell
+	var output strings.Builder
+	err := RunCommand(context.Background(), command, &output, io.Discard)
 	if err != nil {
-		cb.recordFailure()
+		cb.Circuit.RecordFailure()
 		return "", err
 	}
 
 	// Reset circuit breaker on success
-	cb.recordSuccess()
-
-	return string(output), nil
-}
-
-// recordFailure records a failure in the circuit breaker
-func (cb *CommandBuilder) recordFailure() {
-	cb.Circuit.FailureCount++
-	cb.Circuit.LastFailureTime = time.Now()
-
-	if cb.Circuit.FailureCount >= cb.Circuit.FailureThreshold {
-		cb.Circuit.State = types.CircuitStateOpen
-	}
-}
+	cb.Circuit.RecordSuccess()
 
-// recordSuccess records a success in the circuit breaker
-func (cb *CommandBuilder) recordSuccess() {
-	cb.Circuit.FailureCount = 0
-	if cb.Circuit.State == types.CircuitStateHalfOpen {
-		cb.Circuit.State = types.CircuitStateClosed
-	}
+	return output.String(), nil
 }
 
 // determineLogFiles determines which log files to query based on timeframe
@@ -642,7 +705,7 @@ func determineLogFiles(logSource, timeframe string) []LogFileInfo {
 }
 
 // buildMultiFileCommand builds a command that queries multiple log files
-func buildMultiFileCommand(params types.AuditQueryParams, logFiles []LogFileInfo) string {
+func (cb *CommandBuilder) buildMultiFileCommand(params types.AuditQueryParams, logFiles []LogFileInfo) string {
 	var commands []string
 
 	for _, logFile := range logFiles {
@@ -660,52 +723,22 @@ func buildMultiFileCommand(params types.AuditQueryParams, logFiles []LogFileInfo
 		}
 
 		// Add filters with complexity control
+		fileParams = cb.applyComplexityLimits(fileParams)
 		if len(fileParams.Patterns) > 0 {
-			// Limit to first 3 patterns to avoid complexity
-			maxPatterns := 3
-			if len(fileParams.Patterns) > maxPatterns {
-				fileParams.Patterns = fileParams.Patterns[:maxPatterns]
-			}
 			for _, pattern := range fileParams.Patterns {
 				parts = append(parts, fmt.Sprintf("| grep -i '%s'", pattern))
 			}
 		}
 
-		if fileParams.Username != "" {
-			usernameFilter := BuildUsernameFilter(fileParams.Username)
-			if usernameFilter != "" {
-				parts = append(parts, usernameFilter)
-			}
-		}
-
-		if fileParams.Resource != "" {
-			resourceFilter := BuildResourceFilter(fileParams.Resource)
-			if resourceFilter != "" {
-				parts = append(parts, resourceFilter)
-			}
+		if fileParams.Filter != nil {
+			stages, _ := buildGrepFilterExpression(*fileParams.Filter)
+			parts = append(parts, stages...)
 		}
 
-		if fileParams.Verb != "" {
-			verbFilter := BuildVerbFilter(fileParams.Verb)
-			if verbFilter != "" {
-				parts = append(parts, verbFilter)
-			}
-		}
+		parts = append(parts, buildGrepFieldFilters(fileParams)...)
 
-		if fileParams.Namespace != "" {
-			namespaceFilter := BuildNamespaceFilter(fileParams.Namespace)
-			if namespaceFilter != "" {
-				parts = append(parts, namespaceFilter)
-			}
-		}
-
-		// Add exclusions with complexity control
+		// Add exclusions (already limited above)
 		if len(fileParams.Exclude) > 0 {
-			// Limit to first 3 exclusions to avoid complexity
-			maxExclusions := 3
-			if len(fileParams.Exclude) > maxExclusions {
-				fileParams.Exclude = fileParams.Exclude[:maxExclusions]
-			}
 			for _, exclude := range fileParams.Exclude {
 				parts = append(parts, fmt.Sprintf("| grep -v '%s'", exclude))
 			}
@@ -818,31 +851,47 @@ func getLogBasePath(logSource string) string {
 	case "node":
 		return "audit/audit"
 	default:
+		if path, ok := utils.CustomLogSourcePath(logSource); ok {
+			return strings.TrimSuffix(path, ".log")
+		}
 		return "kube-apiserver/audit"
 	}
 }
 
 // getDefaultLogPath returns the default log path for a log source
 func getDefaultLogPath(logSource string) string {
+	return "--path=" + LogSourcePath(logSource)
+}
+
+// LogSourcePath returns the current audit log of a log source, relative to a
+// node's /var/log; unknown log sources read the kube-apiserver audit log
+func LogSourcePath(logSource string) string {
 	switch logSource {
 	case "kube-apiserver":
-		return "--path=kube-apiserver/audit.log"
+		return "kube-apiserver/audit.log"
 	case "oauth-server":
-		return "--path=oauth-server/audit.log"
+		return "oauth-server/audit.log"
 	case "openshift-apiserver":
-		return "--path=openshift-apiserver/audit.log"
+		return "openshift-apiserver/audit.log"
 	case "oauth-apiserver":
-		return "--path=oauth-apiserver/audit.log"
+		return "oauth-apiserver/audit.log"
 	case "node":
-		return "--path=audit/audit.log"
+		return "audit/audit.log"
 	default:
-		return "--path=kube-apiserver/audit.log"
+		if path, ok := utils.CustomLogSourcePath(logSource); ok {
+			return path
+		}
+		return "kube-apiserver/audit.log"
 	}
 }
 
 // parseTimeframe parses a timeframe string and returns start and end dates
 func parseTimeframe(timeframe string) (time.Time, time.Time) {
-	now := time.Now()
+	return parseTimeframeAt(timeframe, time.Now())
+}
+
+// parseTimeframeAt parses a timeframe string relative to now
+func parseTimeframeAt(timeframe string, now time.Time) (time.Time, time.Time) {
 
 	// Handle special cases first
 	switch timeframe {
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -813,7 +814,7 @@ func TestBuildMultiFileCommand(t *testing.T) {
 		{Path: "kube-apiserver/audit.log.2", Date: time.Now().AddDate(0, 0, -2)},
 	}
 
-	command := buildMultiFileCommand(params, logFiles)
+	command := NewCommandBuilder().buildMultiFileCommand(params, logFiles)
 
 	if !strings.Contains(command, "&&") {
 		t.Errorf("Should use multi-file command: %s", command)
@@ -1630,6 +1631,14 @@ func TestBuildJSONAwareCommand_OutputFormatting(t *testing.T) {
 	if !strings.Contains(command, "sourceIPs:") {
 		t.Errorf("Expected sourceIPs field, got: %s", command)
 	}
+
+	if !strings.Contains(command, "auditID: .auditID") {
+		t.Errorf("Expected auditID field, got: %s", command)
+	}
+
+	if !strings.Contains(command, "stage: .stage") {
+		t.Errorf("Expected stage field, got: %s", command)
+	}
 }
 
 func TestBuildJSONTimeframeFilter_Today(t *testing.T) {
@@ -2011,10 +2020,11 @@ func TestBuildJSONAwareCommand_NoFilters(t *testing.T) {
 func TestBuildJSONAwareCommand_MaxPatterns(t *testing.T) {
 	builder := NewCommandBuilder()
 	builder.Config.UseJSONParsing = true
+	builder.Config.MaxPatterns = 3
 
 	params := types.AuditQueryParams{
 		LogSource: "kube-apiserver",
-		Patterns:  []string{"pattern1", "pattern2", "pattern3", "pattern4", "pattern5"}, // More than max (3)
+		Patterns:  []string{"pattern1", "pattern2", "pattern3", "pattern4", "pattern5"}, // More than configured max (3)
 	}
 
 	command := builder.buildJSONAwareCommand(params)
@@ -2045,10 +2055,11 @@ func TestBuildJSONAwareCommand_MaxPatterns(t *testing.T) {
 func TestBuildJSONAwareCommand_MaxExclusions(t *testing.T) {
 	builder := NewCommandBuilder()
 	builder.Config.UseJSONParsing = true
+	builder.Config.MaxExclusions = 3
 
 	params := types.AuditQueryParams{
 		LogSource: "kube-apiserver",
-		Exclude:   []string{"exclude1", "exclude2", "exclude3", "exclude4", "exclude5"}, // More than max (3)
+		Exclude:   []string{"exclude1", "exclude2", "exclude3", "exclude4", "exclude5"}, // More than configured max (3)
 	}
 
 	command := builder.buildJSONAwareCommand(params)
@@ -2075,3 +2086,168 @@ func TestBuildJSONAwareCommand_MaxExclusions(t *testing.T) {
 		t.Errorf("Expected exclude3 to be included, got: %s", command)
 	}
 }
+
+func TestBuildJSONAwareCommand_UnlimitedPatterns(t *testing.T) {
+	builder := NewCommandBuilder()
+	builder.Config.MaxPatterns = 0
+	builder.Config.MaxExclusions = 0
+
+	var patterns, exclusions []string
+	for i := 1; i <= 15; i++ {
+		patterns = append(patterns, fmt.Sprintf("pattern%d", i))
+		exclusions = append(exclusions, fmt.Sprintf("exclude%d", i))
+	}
+
+	command := builder.buildJSONAwareCommand(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Patterns:  patterns,
+		Exclude:   exclusions,
+	})
+
+	if !strings.Contains(command, `"pattern15"`) || !strings.Contains(command, `"exclude15"`) {
+		t.Errorf("Expected all patterns and exclusions to be included, got: %s", command)
+	}
+
+	// Patterns and exclusions should each collapse into a single clause
+	if strings.Count(command, "tostring") != 2 {
+		t.Errorf("Expected one tostring clause each for patterns and exclusions, got: %s", command)
+	}
+	if !strings.Contains(command, "all(. as $p") || !strings.Contains(command, "any(. as $p") {
+		t.Errorf("Expected all()/any() pattern clauses, got: %s", command)
+	}
+
+	if warnings := builder.CommandWarnings(types.AuditQueryParams{Patterns: patterns, Exclude: exclusions}); len(warnings) != 0 {
+		t.Errorf("Expected no warnings without limits, got: %v", warnings)
+	}
+}
+
+func TestCommandWarnings(t *testing.T) {
+	builder := NewCommandBuilder()
+	builder.Config.MaxPatterns = 2
+	builder.Config.MaxExclusions = 1
+
+	warnings := builder.CommandWarnings(types.AuditQueryParams{
+		Patterns: []string{"a", "b", "c"},
+		Exclude:  []string{"x", "y", "z"},
+	})
+
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %d: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], "ignored: c") {
+		t.Errorf("Expected pattern warning to list the dropped pattern, got: %s", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "ignored: y, z") {
+		t.Errorf("Expected exclusion warning to list dropped exclusions, got: %s", warnings[1])
+	}
+
+	if warnings := builder.CommandWarnings(types.AuditQueryParams{Patterns: []string{"a"}}); len(warnings) != 0 {
+		t.Errorf("Expected no warnings within limits, got: %v", warnings)
+	}
+}
+
+// TestSetComplexityLimits tests that the configured limits apply to every
+// command path and to the warnings
+func TestSetComplexityLimits(t *testing.T) {
+	defer SetComplexityLimits(DefaultMaxPatterns, DefaultMaxExclusions)
+	SetComplexityLimits(1, 2)
+
+	builder := NewCommandBuilder()
+	if builder.Config.MaxPatterns != 1 || builder.Config.MaxExclusions != 2 {
+		t.Fatalf("Expected the configured limits, got %d and %d", builder.Config.MaxPatterns, builder.Config.MaxExclusions)
+	}
+
+	params := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Patterns:  []string{"pattern1", "pattern2"},
+		Exclude:   []string{"exclude1", "exclude2", "exclude3"},
+	}
+	if command := BuildOcCommand(params); !strings.Contains(command, "pattern1") || strings.Contains(command, "pattern2") || strings.Contains(command, "exclude3") {
+		t.Errorf("Expected BuildOcCommand to apply the configured limits: %s", command)
+	}
+	multiFile := builder.buildMultiFileCommand(params, []LogFileInfo{
+		{Path: "kube-apiserver/audit.log", IsCurrent: true},
+		{Path: "kube-apiserver/audit.log.1", Date: time.Now().AddDate(0, 0, -1)},
+	})
+	if strings.Count(multiFile, "pattern1") != 2 || strings.Contains(multiFile, "pattern2") || !strings.Contains(multiFile, "exclude2") || strings.Contains(multiFile, "exclude3") {
+		t.Errorf("Expected the multi-file command to apply the configured limits: %s", multiFile)
+	}
+	warnings := BuildCommandWarnings(params)
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "first 1 patterns") || !strings.Contains(warnings[1], "ignored: exclude3") {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	SetComplexityLimits(0, 0)
+	if warnings := BuildCommandWarnings(params); len(warnings) != 0 {
+		t.Errorf("Expected no warnings without limits, got: %v", warnings)
+	}
+}
+
+// TestBuildOcCommand_SourceIP tests the source IP pre-filter for both back-ends
+func TestBuildOcCommand_SourceIP(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource:  "kube-apiserver",
+		SourceIP:   "10.0.0.1",
+		SourceCIDR: "10.0.0.0/8",
+	}
+
+	builder := NewCommandBuilder()
+	jqCommand := builder.buildJSONAwareCommand(params)
+	if !strings.Contains(jqCommand, `(.sourceIPs // []) | any(. == "10.0.0.1")`) {
+		t.Errorf("Expected source IP clause in jq command, got: %s", jqCommand)
+	}
+	if strings.Contains(jqCommand, "10.0.0.0/8") {
+		t.Errorf("Expected CIDR to be left to the parsing layer, got: %s", jqCommand)
+	}
+
+	builder.Config.UseJSONParsing = false
+	if grepCommand := builder.BuildOptimalCommand(params); !strings.Contains(grepCommand, `| grep -F '"10.0.0.1"'`) {
+		t.Errorf("Expected source IP stage in grep command, got: %s", grepCommand)
+	}
+}
+
+// TestBuildOcCommand_StageAndLevel tests audit stage and level filters for both back-ends
+func TestBuildOcCommand_StageAndLevel(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Stage:     "ResponseComplete",
+		Level:     "RequestResponse",
+	}
+
+	builder := NewCommandBuilder()
+	jqCommand := builder.buildJSONAwareCommand(params)
+	for _, clause := range []string{`(.stage == "ResponseComplete")`, `(.level == "RequestResponse")`} {
+		if !strings.Contains(jqCommand, clause) {
+			t.Errorf("Expected %s in jq command, got: %s", clause, jqCommand)
+		}
+	}
+
+	builder.Config.UseJSONParsing = false
+	grepCommand := builder.BuildOptimalCommand(params)
+	for _, stage := range []string{`| grep '"stage":"ResponseComplete"'`, `| grep '"level":"RequestResponse"'`} {
+		if !strings.Contains(grepCommand, stage) {
+			t.Errorf("Expected %s in grep command, got: %s", stage, grepCommand)
+		}
+	}
+}
+
+// TestBuildOcCommand_Subresources tests exact subresource filters for both back-ends
+func TestBuildOcCommand_Subresources(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource:    "kube-apiserver",
+		Resource:     "pods",
+		Subresources: []string{"exec", "attach"},
+	}
+
+	builder := NewCommandBuilder()
+	jqCommand := builder.buildJSONAwareCommand(params)
+	if clause := `(.objectRef.subresource // "" | test("^exec$|^attach$"))`; !strings.Contains(jqCommand, clause) {
+		t.Errorf("Expected %s in jq command, got: %s", clause, jqCommand)
+	}
+
+	builder.Config.UseJSONParsing = false
+	grepCommand := builder.BuildOptimalCommand(params)
+	if stage := `| grep -E '"subresource":"(exec|attach)"'`; !strings.Contains(grepCommand, stage) {
+		t.Errorf("Expected %s in grep command, got: %s", stage, grepCommand)
+	}
+}
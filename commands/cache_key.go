@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// DefaultCacheKeyGranularity is the resolution of the timeframe window in cache
+// keys; relative timeframes resolved within the same interval share a key
+const DefaultCacheKeyGranularity = 5 * time.Minute
+
+// cacheKeyParams is the canonical form of the query parameters hashed into a cache key
+type cacheKeyParams struct {
+	Params      types.AuditQueryParams `json:"params"`
+	WindowStart string                 `json:"window_start,omitempty"`
+	WindowEnd   string                 `json:"window_end,omitempty"`
+	DatePattern string                 `json:"date_pattern,omitempty"`
+}
+
+// CacheKey returns a stable cache key for params. List parameters are sorted and
+// deduplicated, so reordered patterns share a key, and a recognised timeframe is
+// replaced by its absolute window at now, with both ends rounded to granularity,
+// so "24h" and "last 24 hours" asked within the same interval share a key. The
+// date pattern the generated command filters on is part of the key, so windows
+// that are equal but produce different commands do not share a key.
+func CacheKey(params types.AuditQueryParams, now time.Time, granularity time.Duration) string {
+	canonical := CanonicalizeParams(params)
+
+	key := cacheKeyParams{Params: canonical, DatePattern: timeframeDatePatternAt(canonical.Timeframe, now)}
+	if start, end := parseTimeframeAt(canonical.Timeframe, now); !start.IsZero() && !end.IsZero() {
+		if granularity > 0 {
+			start = start.Truncate(granularity)
+			if rounded := end.Truncate(granularity); !rounded.Equal(end) {
+				end = rounded.Add(granularity)
+			}
+		}
+		key.Params.Timeframe = ""
+		key.WindowStart = start.UTC().Format(time.RFC3339)
+		key.WindowEnd = end.UTC().Format(time.RFC3339)
+	}
+
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return "key_" + hex.EncodeToString(sum[:16])
+}
+
+// CanonicalizeParams returns a copy of params with the default log source filled
+// in and every list parameter sorted and deduplicated. Patterns and exclusions
+// are first cut to the complexity limits in the given order, as the command
+// builder does, so lists over a limit that apply different filters do not
+// share a key. Scalar values are kept as given, since the generated commands
+// compare them case-sensitively.
+func CanonicalizeParams(params types.AuditQueryParams) types.AuditQueryParams {
+	if params.LogSource == "" {
+		params.LogSource = "kube-apiserver"
+	}
+	patterns, exclusions := ComplexityLimits()
+	params.Patterns = limitItems(params.Patterns, patterns)
+	params.Exclude = limitItems(params.Exclude, exclusions)
+
+	for _, list := range []*[]string{
+		&params.Patterns, &params.Exclude,
+		&params.ExcludeUsers, &params.ExcludeNamespaces, &params.ExcludeVerbs, &params.ExcludeResources,
+		&params.Usernames, &params.Resources, &params.Verbs, &params.Namespaces,
+		&params.Subresources, &params.Groups,
+	} {
+		*list = sortedUnique(*list)
+	}
+	return params
+}
+
+// sortedUnique returns a sorted copy of values without empty strings or duplicates
+func sortedUnique(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// CoverageKey returns a key shared by queries that select the same events and
+// differ only in timeframe or in what is done after parsing (sampling, sorting,
+// paging, output and summary mode), so a result for one window can be reused
+// for an overlapping one
+func CoverageKey(params types.AuditQueryParams) string {
+	params.Timeframe = ""
+	params.SortBy = ""
+	params.SortOrder = ""
+	params.Limit = 0
+	params.Offset = 0
+	params.SampleRate = 0
+	params.SampleSize = 0
+	params.OutputMode = ""
+	params.BucketSize = ""
+	params.SummaryMode = ""
+	return CacheKey(params, time.Time{}, 0)
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestCacheKey tests which parameter variations share a cache key
+func TestCacheKey(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 2, 0, 0, time.UTC)
+	base := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Patterns:  []string{"secrets", "delete"},
+		Timeframe: "24h",
+		Username:  "alice",
+	}
+
+	tests := []struct {
+		name   string
+		params types.AuditQueryParams
+		now    time.Time
+		same   bool
+	}{
+		{
+			name: "reordered and duplicated patterns",
+			params: types.AuditQueryParams{
+				LogSource: "kube-apiserver",
+				Patterns:  []string{"delete", "secrets", "delete"},
+				Timeframe: "24h",
+				Username:  "alice",
+			},
+			now:  now,
+			same: true,
+		},
+		{
+			name: "equivalent timeframe and default log source",
+			params: types.AuditQueryParams{
+				Patterns:  []string{"secrets", "delete"},
+				Timeframe: "last 24 hours",
+				Username:  "alice",
+			},
+			now:  now,
+			same: true,
+		},
+		{
+			name: "same window with a different date filter",
+			params: types.AuditQueryParams{
+				LogSource: "kube-apiserver",
+				Patterns:  []string{"secrets", "delete"},
+				Timeframe: "1440m",
+				Username:  "alice",
+			},
+			now:  now,
+			same: false,
+		},
+		{
+			name:   "same window granule",
+			params: base,
+			now:    now.Add(2 * time.Minute),
+			same:   true,
+		},
+		{
+			name:   "next window granule",
+			params: base,
+			now:    now.Add(4 * time.Minute),
+			same:   false,
+		},
+		{
+			name: "different user",
+			params: types.AuditQueryParams{
+				LogSource: "kube-apiserver",
+				Patterns:  []string{"secrets", "delete"},
+				Timeframe: "24h",
+				Username:  "bob",
+			},
+			now:  now,
+			same: false,
+		},
+		{
+			name: "different timeframe",
+			params: types.AuditQueryParams{
+				LogSource: "kube-apiserver",
+				Patterns:  []string{"secrets", "delete"},
+				Timeframe: "7d",
+				Username:  "alice",
+			},
+			now:  now,
+			same: false,
+		},
+	}
+
+	baseKey := CacheKey(base, now, DefaultCacheKeyGranularity)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := CacheKey(tt.params, tt.now, DefaultCacheKeyGranularity)
+			if (key == baseKey) != tt.same {
+				t.Errorf("Expected same key %v, got %s and %s", tt.same, key, baseKey)
+			}
+		})
+	}
+}
+
+// TestCanonicalizeParams tests that list parameters are sorted and deduplicated
+func TestCanonicalizeParams(t *testing.T) {
+	params := types.AuditQueryParams{
+		Timeframe:    "today",
+		Verbs:        []string{"update", "create", "update"},
+		ExcludeUsers: []string{"", "system:*"},
+	}
+	canonical := CanonicalizeParams(params)
+
+	if canonical.LogSource != "kube-apiserver" || canonical.Timeframe != "today" {
+		t.Errorf("Expected the default log source and the timeframe kept, got %q and %q", canonical.LogSource, canonical.Timeframe)
+	}
+	if len(canonical.Verbs) != 2 || canonical.Verbs[0] != "create" || canonical.Verbs[1] != "update" {
+		t.Errorf("Expected sorted unique verbs, got %v", canonical.Verbs)
+	}
+	if len(canonical.ExcludeUsers) != 1 {
+		t.Errorf("Expected empty values to be dropped, got %v", canonical.ExcludeUsers)
+	}
+	if params.Verbs[0] != "update" {
+		t.Error("Expected the original params to be left unchanged")
+	}
+}
+
+// TestCacheKey_ComplexityLimits tests that patterns over the limit are cut in
+// the given order before sorting, as the command builder cuts them
+func TestCacheKey_ComplexityLimits(t *testing.T) {
+	defer SetComplexityLimits(DefaultMaxPatterns, DefaultMaxExclusions)
+	SetComplexityLimits(2, 1)
+	now := time.Now()
+
+	first := types.AuditQueryParams{Patterns: []string{"a", "b", "c"}, Exclude: []string{"x", "y"}}
+	reordered := types.AuditQueryParams{Patterns: []string{"c", "b", "a"}, Exclude: []string{"x", "y"}}
+	if CacheKey(first, now, DefaultCacheKeyGranularity) == CacheKey(reordered, now, DefaultCacheKeyGranularity) {
+		t.Error("Expected patterns cut to different filters to get different keys")
+	}
+
+	sameFilters := types.AuditQueryParams{Patterns: []string{"b", "a", "d"}, Exclude: []string{"x", "z"}}
+	if CacheKey(first, now, DefaultCacheKeyGranularity) != CacheKey(sameFilters, now, DefaultCacheKeyGranularity) {
+		t.Error("Expected lists applying the same filters after the cut to share a key")
+	}
+	if canonical := CanonicalizeParams(reordered); strings.Join(canonical.Patterns, ",") != "b,c" || strings.Join(canonical.Exclude, ",") != "x" {
+		t.Errorf("Unexpected canonical lists %v and %v", canonical.Patterns, canonical.Exclude)
+	}
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"strings"
+)
+
+// ClusterFlags returns the oc global flags that select a cluster: a
+// kubeconfig file and a context in it. Empty values keep oc's defaults.
+func ClusterFlags(context, kubeconfig string) []string {
+	var flags []string
+	if kubeconfig != "" {
+		flags = append(flags, "--kubeconfig="+kubeconfig)
+	}
+	if context != "" {
+		flags = append(flags, "--context="+context)
+	}
+	return flags
+}
+
+// ConfigContextsArgs returns the oc arguments that list the context names of a
+// kubeconfig file, or of the default kubeconfig when it is empty
+func ConfigContextsArgs(kubeconfig string) []string {
+	return append(ClusterFlags("", kubeconfig), "config", "get-contexts", "-o", "name")
+}
+
+// ParseConfigContexts returns the context names in the output of a
+// ConfigContextsArgs command
+func ParseConfigContexts(output string) []string {
+	var contexts []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			contexts = append(contexts, line)
+		}
+	}
+	return contexts
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestClusterFlags tests the oc flags selecting a cluster
+func TestClusterFlags(t *testing.T) {
+	tests := []struct {
+		context, kubeconfig string
+		expected            []string
+	}{
+		{"prod", "", []string{"--context=prod"}},
+		{"prod", "/etc/kube/prod.yaml", []string{"--kubeconfig=/etc/kube/prod.yaml", "--context=prod"}},
+		{"", "", nil},
+	}
+	for _, tt := range tests {
+		if flags := ClusterFlags(tt.context, tt.kubeconfig); !reflect.DeepEqual(flags, tt.expected) {
+			t.Errorf("Unexpected flags %v for %q, %q", flags, tt.context, tt.kubeconfig)
+		}
+	}
+}
+
+// TestParseConfigContexts tests reading kubeconfig context names
+func TestParseConfigContexts(t *testing.T) {
+	if args := strings.Join(ConfigContextsArgs("/etc/kube/all.yaml"), " "); args != "--kubeconfig=/etc/kube/all.yaml config get-contexts -o name" {
+		t.Errorf("Unexpected args %q", args)
+	}
+	contexts := ParseConfigContexts("prod\ndefault/api-staging:6443/kube:admin\n\n")
+	if !reflect.DeepEqual(contexts, []string{"prod", "default/api-staging:6443/kube:admin"}) {
+		t.Errorf("Unexpected contexts %v", contexts)
+	}
+}
@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// serviceAccountPrefix starts the usernames of service accounts
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// ParseServiceAccountUser returns the namespace and name of the service account
+// a username such as "system:serviceaccount:ci:deployer" belongs to
+func ParseServiceAccountUser(username string) (namespace, name string, ok bool) {
+	if !strings.HasPrefix(username, serviceAccountPrefix) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(username, serviceAccountPrefix), ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// ResourceExistsArgs returns the read-only oc arguments that print the object
+// if it still exists and nothing otherwise. group is empty for core resources.
+func ResourceExistsArgs(resource, group, namespace, name string) []string {
+	if group != "" {
+		resource += "." + group
+	}
+	args := []string{"get", resource, name, "--ignore-not-found", "-o", "name"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	return args
+}
+
+// ParseResourceExists returns whether the output of a ResourceExistsArgs
+// command names an object
+func ParseResourceExists(output string) bool {
+	return strings.TrimSpace(output) != ""
+}
+
+// NamespaceArgs returns the read-only oc arguments that print a namespace as
+// JSON if it exists and nothing otherwise
+func NamespaceArgs(namespace string) []string {
+	return []string{"get", "namespace", namespace, "--ignore-not-found", "-o", "json"}
+}
+
+// ParseNamespace returns whether the output of a NamespaceArgs command holds a
+// namespace, and its labels
+func ParseNamespace(output string) (bool, map[string]string, error) {
+	if strings.TrimSpace(output) == "" {
+		return false, nil, nil
+	}
+	var namespace struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(output), &namespace); err != nil {
+		return false, nil, fmt.Errorf("unexpected oc get namespace output: %w", err)
+	}
+	return true, namespace.Metadata.Labels, nil
+}
+
+// ServiceAccountPodsArgs returns the read-only oc arguments that list the
+// pods running as a service account
+func ServiceAccountPodsArgs(namespace, name string) []string {
+	return []string{"get", "pods", "-n", namespace, "--field-selector=spec.serviceAccountName=" + name, "-o", "json"}
+}
+
+// ParsePodWorkloads returns the workloads that own the pods in the output of a
+// ServiceAccountPodsArgs command, sorted and without duplicates. Pods of a
+// Deployment's ReplicaSet are attributed to the Deployment, which is named by
+// the ReplicaSet name without its pod-template-hash suffix; pods without a
+// controller are their own workload.
+func ParsePodWorkloads(output string) ([]types.WorkloadRef, error) {
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name            string            `json:"name"`
+				Namespace       string            `json:"namespace"`
+				Labels          map[string]string `json:"labels"`
+				OwnerReferences []struct {
+					Kind       string `json:"kind"`
+					Name       string `json:"name"`
+					Controller bool   `json:"controller"`
+				} `json:"ownerReferences"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &pods); err != nil {
+		return nil, fmt.Errorf("unexpected oc get pods output: %w", err)
+	}
+
+	seen := make(map[types.WorkloadRef]bool)
+	var workloads []types.WorkloadRef
+	for _, pod := range pods.Items {
+		workload := types.WorkloadRef{Kind: "Pod", Namespace: pod.Metadata.Namespace, Name: pod.Metadata.Name}
+		for _, owner := range pod.Metadata.OwnerReferences {
+			if !owner.Controller {
+				continue
+			}
+			workload.Kind, workload.Name = owner.Kind, owner.Name
+			if hash := pod.Metadata.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				workload.Kind, workload.Name = "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+		}
+		if !seen[workload] {
+			seen[workload] = true
+			workloads = append(workloads, workload)
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Kind != workloads[j].Kind {
+			return workloads[i].Kind < workloads[j].Kind
+		}
+		return workloads[i].Name < workloads[j].Name
+	})
+	return workloads, nil
+}
+
+// DeploymentArgs returns the read-only oc arguments that print a Deployment
+// as JSON if it exists and nothing otherwise
+func DeploymentArgs(namespace, name string) []string {
+	return []string{"get", "deployments.apps", name, "-n", namespace, "--ignore-not-found", "-o", "json"}
+}
+
+// ParseDeploymentOperator returns the Operator Lifecycle Manager
+// ClusterServiceVersion that manages the Deployment in the output of a
+// DeploymentArgs command, or "" when OLM does not manage it
+func ParseDeploymentOperator(output string) (string, error) {
+	if strings.TrimSpace(output) == "" {
+		return "", nil
+	}
+	var deployment struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(output), &deployment); err != nil {
+		return "", fmt.Errorf("unexpected oc get deployment output: %w", err)
+	}
+	if deployment.Metadata.Labels["olm.owner.kind"] != "ClusterServiceVersion" {
+		return "", nil
+	}
+	return deployment.Metadata.Labels["olm.owner"], nil
+}
+
+// NodesArgs returns the read-only oc arguments that list the cluster's nodes
+func NodesArgs() []string {
+	return []string{"get", "nodes", "-o", "json"}
+}
+
+// ParseNodeAddresses returns the internal and external IP addresses of the
+// nodes in the output of a NodesArgs command
+func ParseNodeAddresses(output string) ([]string, error) {
+	var nodes struct {
+		Items []struct {
+			Status struct {
+				Addresses []struct {
+					Type    string `json:"type"`
+					Address string `json:"address"`
+				} `json:"addresses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &nodes); err != nil {
+		return nil, fmt.Errorf("unexpected oc get nodes output: %w", err)
+	}
+	var addresses []string
+	for _, node := range nodes.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Type == "InternalIP" || address.Type == "ExternalIP" {
+				addresses = append(addresses, address.Address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// ClusterNetworkArgs returns the read-only oc arguments that print the
+// cluster's network configuration
+func ClusterNetworkArgs() []string {
+	return []string{"get", "network.config.openshift.io", "cluster", "-o", "json"}
+}
+
+// ParseClusterNetwork returns the pod and service networks in the output of a
+// ClusterNetworkArgs command
+func ParseClusterNetwork(output string) (podNetworks, serviceNetworks []string, err error) {
+	var network struct {
+		Spec struct {
+			ClusterNetwork []struct {
+				CIDR string `json:"cidr"`
+			} `json:"clusterNetwork"`
+			ServiceNetwork []string `json:"serviceNetwork"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(output), &network); err != nil {
+		return nil, nil, fmt.Errorf("unexpected oc get network output: %w", err)
+	}
+	for _, clusterNetwork := range network.Spec.ClusterNetwork {
+		podNetworks = append(podNetworks, clusterNetwork.CIDR)
+	}
+	return podNetworks, network.Spec.ServiceNetwork, nil
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestParseServiceAccountUser tests reading service accounts from usernames
+func TestParseServiceAccountUser(t *testing.T) {
+	tests := []struct {
+		username  string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		{"system:serviceaccount:ci:deployer", "ci", "deployer", true},
+		{"system:serviceaccount:openshift-monitoring:prometheus-k8s", "openshift-monitoring", "prometheus-k8s", true},
+		{"system:serviceaccount:ci", "", "", false},
+		{"system:serviceaccount::deployer", "", "", false},
+		{"system:serviceaccount:ci:deployer:extra", "", "", false},
+		{"system:admin", "", "", false},
+		{"alice", "", "", false},
+	}
+	for _, tt := range tests {
+		namespace, name, ok := ParseServiceAccountUser(tt.username)
+		if namespace != tt.namespace || name != tt.name || ok != tt.ok {
+			t.Errorf("%s: unexpected %q, %q, %v", tt.username, namespace, name, ok)
+		}
+	}
+}
+
+// TestResourceExistsArgs tests the oc get arguments of existence lookups
+func TestResourceExistsArgs(t *testing.T) {
+	tests := []struct {
+		resource, group, namespace, name string
+		expected                         string
+	}{
+		{"pods", "", "web", "web-1", "get pods web-1 --ignore-not-found -o name -n web"},
+		{"deployments", "apps", "web", "frontend", "get deployments.apps frontend --ignore-not-found -o name -n web"},
+		{"clusterroles", "rbac.authorization.k8s.io", "", "admin", "get clusterroles.rbac.authorization.k8s.io admin --ignore-not-found -o name"},
+	}
+	for _, tt := range tests {
+		if args := strings.Join(ResourceExistsArgs(tt.resource, tt.group, tt.namespace, tt.name), " "); args != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, args)
+		}
+	}
+
+	if !ParseResourceExists("pod/web-1\n") || ParseResourceExists("\n") {
+		t.Error("Unexpected existence")
+	}
+}
+
+// TestParseNamespace tests reading namespace labels
+func TestParseNamespace(t *testing.T) {
+	if args := strings.Join(NamespaceArgs("payments"), " "); args != "get namespace payments --ignore-not-found -o json" {
+		t.Errorf("Unexpected args %q", args)
+	}
+
+	exists, labels, err := ParseNamespace(`{"kind":"Namespace","metadata":{"name":"payments","labels":{"env":"prod","kubernetes.io/metadata.name":"payments"}}}`)
+	if err != nil || !exists || labels["env"] != "prod" || len(labels) != 2 {
+		t.Errorf("Unexpected namespace %v, %v, %v", exists, labels, err)
+	}
+
+	exists, labels, err = ParseNamespace("")
+	if err != nil || exists || labels != nil {
+		t.Errorf("Expected a missing namespace, got %v, %v, %v", exists, labels, err)
+	}
+
+	if _, _, err := ParseNamespace("Error from server"); err == nil {
+		t.Error("Expected an error for output that is not JSON")
+	}
+}
+
+// TestParsePodWorkloads tests mapping service account pods to their workloads
+func TestParsePodWorkloads(t *testing.T) {
+	if args := strings.Join(ServiceAccountPodsArgs("gpu", "gpu-operator"), " "); args != "get pods -n gpu --field-selector=spec.serviceAccountName=gpu-operator -o json" {
+		t.Errorf("Unexpected args %q", args)
+	}
+
+	workloads, err := ParsePodWorkloads(`{"items":[
+		{"metadata":{"name":"gpu-operator-6d4b8-abcde","namespace":"gpu","labels":{"pod-template-hash":"6d4b8"},
+			"ownerReferences":[{"kind":"ReplicaSet","name":"gpu-operator-6d4b8","controller":true}]}},
+		{"metadata":{"name":"gpu-operator-6d4b8-fghij","namespace":"gpu","labels":{"pod-template-hash":"6d4b8"},
+			"ownerReferences":[{"kind":"ReplicaSet","name":"gpu-operator-6d4b8","controller":true}]}},
+		{"metadata":{"name":"feature-discovery-x1","namespace":"gpu",
+			"ownerReferences":[{"kind":"DaemonSet","name":"feature-discovery","controller":true}]}},
+		{"metadata":{"name":"debug","namespace":"gpu","ownerReferences":[{"kind":"Node","name":"worker-1"}]}}
+	]}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []types.WorkloadRef{
+		{Kind: "DaemonSet", Namespace: "gpu", Name: "feature-discovery"},
+		{Kind: "Deployment", Namespace: "gpu", Name: "gpu-operator"},
+		{Kind: "Pod", Namespace: "gpu", Name: "debug"},
+	}
+	if !reflect.DeepEqual(workloads, expected) {
+		t.Errorf("Unexpected workloads %v", workloads)
+	}
+
+	if _, err := ParsePodWorkloads("Error from server"); err == nil {
+		t.Error("Expected an error for output that is not JSON")
+	}
+}
+
+// TestParseDeploymentOperator tests finding the OLM operator of a Deployment
+func TestParseDeploymentOperator(t *testing.T) {
+	if args := strings.Join(DeploymentArgs("gpu", "gpu-operator"), " "); args != "get deployments.apps gpu-operator -n gpu --ignore-not-found -o json" {
+		t.Errorf("Unexpected args %q", args)
+	}
+
+	tests := map[string]string{
+		`{"metadata":{"labels":{"olm.owner":"gpu-operator-certified.v23.9.0","olm.owner.kind":"ClusterServiceVersion"}}}`: "gpu-operator-certified.v23.9.0",
+		`{"metadata":{"labels":{"app":"gpu-operator"}}}`:                                                                  "",
+		"": "",
+	}
+	for output, expected := range tests {
+		if operator, err := ParseDeploymentOperator(output); err != nil || operator != expected {
+			t.Errorf("Unexpected operator %q, %v for %s", operator, err, output)
+		}
+	}
+}
+
+// TestParseNodeAddresses tests reading node IP addresses
+func TestParseNodeAddresses(t *testing.T) {
+	addresses, err := ParseNodeAddresses(`{"items":[
+		{"status":{"addresses":[{"type":"InternalIP","address":"10.0.0.10"},{"type":"Hostname","address":"master-0"}]}},
+		{"status":{"addresses":[{"type":"InternalIP","address":"10.0.0.11"},{"type":"ExternalIP","address":"198.51.100.11"}]}}
+	]}`)
+	expected := []string{"10.0.0.10", "10.0.0.11", "198.51.100.11"}
+	if err != nil || !reflect.DeepEqual(addresses, expected) {
+		t.Errorf("Unexpected addresses %v, %v", addresses, err)
+	}
+	if _, err := ParseNodeAddresses("Error from server"); err == nil {
+		t.Error("Expected an error for output that is not JSON")
+	}
+}
+
+// TestParseClusterNetwork tests reading the pod and service networks
+func TestParseClusterNetwork(t *testing.T) {
+	if args := strings.Join(ClusterNetworkArgs(), " "); args != "get network.config.openshift.io cluster -o json" {
+		t.Errorf("Unexpected args %q", args)
+	}
+	pods, services, err := ParseClusterNetwork(`{"spec":{"clusterNetwork":[{"cidr":"10.128.0.0/14","hostPrefix":23}],"serviceNetwork":["172.30.0.0/16"]}}`)
+	if err != nil || !reflect.DeepEqual(pods, []string{"10.128.0.0/14"}) || !reflect.DeepEqual(services, []string{"172.30.0.0/16"}) {
+		t.Errorf("Unexpected networks %v, %v, %v", pods, services, err)
+	}
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// fieldExclusion pairs a structured exclusion list with the field it applies to
+type fieldExclusion struct {
+	jqField  string
+	grepKey  string
+	excluded []string
+}
+
+// fieldExclusions returns the structured exclusions set in params
+func fieldExclusions(params types.AuditQueryParams) []fieldExclusion {
+	exclusions := []fieldExclusion{
+		{jqUsernameField, "username", params.ExcludeUsers},
+		{jqNamespaceField, "namespace", params.ExcludeNamespaces},
+		{jqVerbField, "verb", params.ExcludeVerbs},
+		{jqResourceField, "resource", params.ExcludeResources},
+	}
+
+	var active []fieldExclusion
+	for _, exclusion := range exclusions {
+		if len(exclusion.excluded) > 0 {
+			active = append(active, exclusion)
+		}
+	}
+	return active
+}
+
+// exclusionAlternatives converts excluded values to regex alternatives. Values
+// match exactly, except that a trailing "*" matches any suffix.
+func exclusionAlternatives(values []string, wildcard string) string {
+	alternatives := make([]string, len(values))
+	for i, value := range values {
+		if prefix, ok := strings.CutSuffix(value, "*"); ok {
+			alternatives[i] = regexp.QuoteMeta(prefix) + wildcard
+		} else {
+			alternatives[i] = regexp.QuoteMeta(value)
+		}
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// ExclusionRegex returns the anchored regular expression matching any excluded value
+func ExclusionRegex(values []string) string {
+	return "^(" + exclusionAlternatives(values, ".*") + ")$"
+}
+
+// buildJQFieldExclusions builds jq "not" clauses for the structured exclusion parameters
+func buildJQFieldExclusions(params types.AuditQueryParams) []string {
+	var filters []string
+	for _, exclusion := range fieldExclusions(params) {
+		filters = append(filters, fmt.Sprintf(`%s // "" | test(%s) | not`, exclusion.jqField, jqStringLiteral(ExclusionRegex(exclusion.excluded))))
+	}
+	return filters
+}
+
+// buildGrepFieldExclusions builds inverted grep stages for the structured exclusion
+// parameters. The username key also appears under impersonatedUser, so grep may
+// drop events that jq would keep.
+func buildGrepFieldExclusions(params types.AuditQueryParams) []string {
+	var stages []string
+	for _, exclusion := range fieldExclusions(params) {
+		stages = append(stages, fmt.Sprintf(`| grep -vE '"%s":"(%s)"'`, exclusion.grepKey, exclusionAlternatives(exclusion.excluded, `[^"]*`)))
+	}
+	return stages
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestBuildJQFieldExclusions tests jq "not" clauses for structured exclusions
+func TestBuildJQFieldExclusions(t *testing.T) {
+	params := types.AuditQueryParams{
+		ExcludeUsers: []string{"system:serviceaccount:ci:*", "admin"},
+		ExcludeVerbs: []string{"get", "list"},
+	}
+
+	expected := []string{
+		jqUsernameField + ` // "" | test("^(system:serviceaccount:ci:.*|admin)$") | not`,
+		`.verb // "" | test("^(get|list)$") | not`,
+	}
+	if got := buildJQFieldExclusions(params); !reflect.DeepEqual(got, expected) {
+		t.Errorf("buildJQFieldExclusions() = %v, want %v", got, expected)
+	}
+}
+
+// TestBuildGrepFieldExclusions tests inverted grep stages for structured exclusions
+func TestBuildGrepFieldExclusions(t *testing.T) {
+	params := types.AuditQueryParams{
+		ExcludeNamespaces: []string{"openshift-*", "kube-system"},
+		ExcludeResources:  []string{"events"},
+	}
+
+	expected := []string{
+		`| grep -vE '"namespace":"(openshift-[^"]*|kube-system)"'`,
+		`| grep -vE '"resource":"(events)"'`,
+	}
+	if got := buildGrepFieldExclusions(params); !reflect.DeepEqual(got, expected) {
+		t.Errorf("buildGrepFieldExclusions() = %v, want %v", got, expected)
+	}
+}
+
+// TestExplainQuery_FieldExclusions tests that structured exclusions are explained as drops
+func TestExplainQuery_FieldExclusions(t *testing.T) {
+	explanation := ExplainQuery(types.AuditQueryParams{
+		LogSource:    "kube-apiserver",
+		Verb:         "delete",
+		ExcludeUsers: []string{"system:serviceaccount:ci:*"},
+	})
+
+	var found bool
+	for _, filter := range explanation.Filters {
+		if filter.Kind == "exclude" && strings.HasPrefix(filter.Description, "drops events") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected an exclude filter, got: %+v", explanation.Filters)
+	}
+}
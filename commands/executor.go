@@ -0,0 +1,509 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stageWaitDelay bounds how long a program killed on cancellation may hold its
+// output open
+const stageWaitDelay = time.Second
+
+// allowedPrograms are the programs a generated command may run. true is run in
+// process, for the "|| true" of error tolerant multi-file commands, and so is
+// jq when the built-in engine is selected.
+var allowedPrograms = map[string]bool{"oc": true, "jq": true, "grep": true, "head": true, "true": true}
+
+// filterOptions lists, per filter program, the short options it may be given and
+// which of them take a value. Options reading files, such as grep -f or
+// jq --rawfile, are not listed.
+var filterOptions = map[string]struct {
+	flags  string
+	valued string
+}{
+	"grep": {flags: "EFGiIvwxcnoqsh", valued: "emABC"},
+	"jq":   {flags: "rcsaejMSn", valued: ""},
+	"head": {flags: "", valued: "nc"},
+}
+
+// filterOperands is how many operands each filter program takes: the grep
+// pattern, unless given with -e, and the jq program. More would name files.
+var filterOperands = map[string]int{"grep": 1, "jq": 1, "head": 0}
+
+// Command is a generated command parsed without a shell. It supports only the
+// syntax the builder generates: words and quoted strings, pipes, parenthesized
+// groups, and lists joined by &&, || and ;. There is no expansion of variables,
+// globs or command substitution, and no redirection.
+type Command struct {
+	list commandList
+}
+
+// commandList is a sequence of pipelines joined by &&, || and ;
+type commandList struct {
+	pipelines []pipeline
+	// operators[i] joins pipelines[i] and pipelines[i+1]
+	operators []string
+}
+
+// pipeline is a sequence of stages connected by pipes
+type pipeline struct {
+	stages []stage
+}
+
+// stage is a program with its arguments, or a parenthesized group
+type stage struct {
+	argv  []string
+	group *commandList
+}
+
+// ParseCommand parses a generated command, rejecting shell syntax and programs
+// the builder does not generate
+func ParseCommand(command string) (*Command, error) {
+	tokens, err := tokenizeCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	p := &commandParser{tokens: tokens}
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return &Command{list: list}, nil
+}
+
+// RunCommand parses and runs a generated command, writing the output of its
+// programs to stdout and stderr, which may be the same writer. Like a shell, it
+// returns the error of the last pipeline run, whose status is that of its last
+// stage.
+func RunCommand(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	parsed, err := ParseCommand(command)
+	if err != nil {
+		return fmt.Errorf("unsupported command: %w", err)
+	}
+	return parsed.Run(ctx, stdout, stderr)
+}
+
+// Run runs the command, writing the output of its programs to stdout and stderr
+func (c *Command) Run(ctx context.Context, stdout, stderr io.Writer) error {
+	// Stages run concurrently, so writes are serialized
+	lockedStdout := &lockedWriter{w: stdout}
+	lockedStderr := lockedStdout
+	if stderr != stdout {
+		lockedStderr = &lockedWriter{w: stderr}
+	}
+	return c.list.run(ctx, nil, lockedStdout, lockedStderr)
+}
+
+// RunFilters runs the filter stages of a single-pipeline command on input in
+// place of the output of its oc adm node-logs stage. It lets audit logs that
+// were read some other way, such as synthetic benchmark logs, go through the
+// generated filters.
+func (c *Command) RunFilters(ctx context.Context, input io.Reader, stdout, stderr io.Writer) error {
+	if len(c.list.pipelines) != 1 {
+		return fmt.Errorf("command has %d pipelines, want 1", len(c.list.pipelines))
+	}
+	stages := c.list.pipelines[0].stages
+	if stages[0].group != nil || stages[0].argv[0] != "oc" {
+		return fmt.Errorf("command does not start with an oc stage")
+	}
+	if len(stages) == 1 {
+		_, err := io.Copy(stdout, input)
+		return err
+	}
+
+	lockedStdout := &lockedWriter{w: stdout}
+	lockedStderr := lockedStdout
+	if stderr != stdout {
+		lockedStderr = &lockedWriter{w: stderr}
+	}
+	return pipeline{stages: stages[1:]}.run(ctx, input, lockedStdout, lockedStderr)
+}
+
+// WithOcFlags returns a copy of the command whose oc stages run with the
+// given global flags, such as --context=prod, before their other arguments
+func (c *Command) WithOcFlags(flags []string) *Command {
+	if len(flags) == 0 {
+		return c
+	}
+	return &Command{list: c.list.withOcFlags(flags)}
+}
+
+// withOcFlags returns a copy of the list with flags added to its oc stages
+func (l commandList) withOcFlags(flags []string) commandList {
+	copied := commandList{operators: l.operators, pipelines: make([]pipeline, len(l.pipelines))}
+	for i, p := range l.pipelines {
+		stages := make([]stage, len(p.stages))
+		for j, s := range p.stages {
+			switch {
+			case s.group != nil:
+				group := s.group.withOcFlags(flags)
+				stages[j] = stage{group: &group}
+			case s.argv[0] == "oc":
+				argv := append([]string{"oc"}, flags...)
+				stages[j] = stage{argv: append(argv, s.argv[1:]...)}
+			default:
+				stages[j] = s
+			}
+		}
+		copied.pipelines[i] = pipeline{stages: stages}
+	}
+	return copied
+}
+
+// UsesJQ reports whether the command has a jq stage
+func (c *Command) UsesJQ() bool {
+	return c.list.usesJQ()
+}
+
+// usesJQ reports whether a stage of the list, or of a group in it, runs jq
+func (l commandList) usesJQ() bool {
+	for _, p := range l.pipelines {
+		for _, s := range p.stages {
+			if s.group != nil && s.group.usesJQ() || s.group == nil && s.argv[0] == "jq" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lockedWriter serializes writes from concurrently running stages
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// run runs the pipelines of the list in order, skipping a pipeline after &&
+// when the previous one failed and after || when it succeeded
+func (l commandList) run(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+	var err error
+	for i, p := range l.pipelines {
+		if i > 0 {
+			if l.operators[i-1] == "&&" && err != nil || l.operators[i-1] == "||" && err == nil {
+				continue
+			}
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		err = p.run(ctx, stdin, stdout, stderr)
+	}
+	return err
+}
+
+// run runs the stages concurrently, each reading the previous stage's output
+// through an OS pipe, and returns the last stage's error
+func (p pipeline) run(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(p.stages) == 1 {
+		return p.stages[0].run(ctx, stdin, stdout, stderr)
+	}
+
+	// readers[i] and writers[i] connect stage i to stage i+1
+	readers := make([]*os.File, len(p.stages)-1)
+	writers := make([]*os.File, len(p.stages)-1)
+	for i := range readers {
+		var err error
+		if readers[i], writers[i], err = os.Pipe(); err != nil {
+			for j := 0; j < i; j++ {
+				readers[j].Close()
+				writers[j].Close()
+			}
+			return err
+		}
+	}
+
+	errs := make([]error, len(p.stages))
+	var wg sync.WaitGroup
+	for i, s := range p.stages {
+		in, out := stdin, stdout
+		if i > 0 {
+			in = readers[i-1]
+		}
+		if i < len(p.stages)-1 {
+			out = writers[i]
+		}
+		wg.Add(1)
+		go func(i int, s stage, in io.Reader, out io.Writer) {
+			defer wg.Done()
+			errs[i] = s.run(ctx, in, out, stderr)
+			// Closing the ends lets the next stage see EOF and the previous
+			// one see a closed pipe when this stage stops reading early
+			if i < len(writers) {
+				writers[i].Close()
+			}
+			if i > 0 {
+				readers[i-1].Close()
+			}
+		}(i, s, in, out)
+	}
+	wg.Wait()
+	return errs[len(errs)-1]
+}
+
+// run runs the stage's program, or its group in process
+func (s stage) run(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) error {
+	if s.group != nil {
+		return s.group.run(ctx, stdin, stdout, stderr)
+	}
+	if s.argv[0] == "true" {
+		return nil
+	}
+	if s.argv[0] == "jq" && ActiveJQEngine() == JQEngineBuiltin {
+		return runBuiltinJQ(ctx, s.argv[1:], stdin, stdout, stderr)
+	}
+
+	cmd := exec.CommandContext(ctx, s.argv[0], s.argv[1:]...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Do not wait for output held open by children of a killed program
+	cmd.WaitDelay = stageWaitDelay
+	err := cmd.Run()
+	if err != nil && cmd.ProcessState == nil {
+		// Report programs that could not start, as a shell does
+		fmt.Fprintf(stderr, "%s: %v\n", s.argv[0], err)
+	}
+	return err
+}
+
+// commandToken is a word or an operator of a command
+type commandToken struct {
+	text     string
+	operator bool
+}
+
+// isWordChar reports whether c may appear unquoted in a word
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-_=./:,@%+", c) >= 0
+}
+
+// tokenizeCommand splits a command into words and the operators | || && ; ( ),
+// removing quotes. Single quotes are literal; double quotes may only escape
+// \ and ", and may not contain $ or `.
+func tokenizeCommand(command string) ([]commandToken, error) {
+	var tokens []commandToken
+	for i := 0; i < len(command); {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '|' || c == '&':
+			if i+1 < len(command) && command[i+1] == c {
+				tokens = append(tokens, commandToken{text: command[i : i+2], operator: true})
+				i += 2
+			} else if c == '|' {
+				tokens = append(tokens, commandToken{text: "|", operator: true})
+				i++
+			} else {
+				return nil, fmt.Errorf("unsupported shell syntax %q", "&")
+			}
+		case c == ';' || c == '(' || c == ')':
+			tokens = append(tokens, commandToken{text: string(c), operator: true})
+			i++
+		case c == '\'' || c == '"' || isWordChar(c):
+			var word strings.Builder
+		word:
+			for i < len(command) {
+				c = command[i]
+				switch {
+				case c == '\'':
+					end := strings.IndexByte(command[i+1:], '\'')
+					if end == -1 {
+						return nil, fmt.Errorf("unterminated single quote")
+					}
+					word.WriteString(command[i+1 : i+1+end])
+					i += end + 2
+				case c == '"':
+					i++
+					for {
+						if i >= len(command) {
+							return nil, fmt.Errorf("unterminated double quote")
+						}
+						c = command[i]
+						if c == '"' {
+							i++
+							break
+						}
+						if c == '$' || c == '`' {
+							return nil, fmt.Errorf("unsupported shell syntax %q", string(c))
+						}
+						if c == '\\' && i+1 < len(command) && (command[i+1] == '\\' || command[i+1] == '"') {
+							i++
+							c = command[i]
+						}
+						word.WriteByte(c)
+						i++
+					}
+				case isWordChar(c):
+					word.WriteByte(c)
+					i++
+				default:
+					break word
+				}
+			}
+			tokens = append(tokens, commandToken{text: word.String()})
+		default:
+			return nil, fmt.Errorf("unsupported shell syntax %q", string(c))
+		}
+	}
+	return tokens, nil
+}
+
+// commandParser builds a command from its tokens by recursive descent
+type commandParser struct {
+	tokens []commandToken
+	pos    int
+}
+
+// peekOperator returns the next token if it is an operator
+func (p *commandParser) peekOperator() string {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].operator {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// parseList parses pipelines joined by &&, || and ;
+func (p *commandParser) parseList() (commandList, error) {
+	var list commandList
+	for {
+		pl, err := p.parsePipeline()
+		if err != nil {
+			return list, err
+		}
+		list.pipelines = append(list.pipelines, pl)
+
+		operator := p.peekOperator()
+		if operator != "&&" && operator != "||" && operator != ";" {
+			return list, nil
+		}
+		p.pos++
+		list.operators = append(list.operators, operator)
+	}
+}
+
+// parsePipeline parses stages joined by |
+func (p *commandParser) parsePipeline() (pipeline, error) {
+	var pl pipeline
+	for {
+		s, err := p.parseStage()
+		if err != nil {
+			return pl, err
+		}
+		pl.stages = append(pl.stages, s)
+
+		if p.peekOperator() != "|" {
+			return pl, nil
+		}
+		p.pos++
+	}
+}
+
+// parseStage parses a parenthesized group or a program with its arguments
+func (p *commandParser) parseStage() (stage, error) {
+	if p.peekOperator() == "(" {
+		p.pos++
+		group, err := p.parseList()
+		if err != nil {
+			return stage{}, err
+		}
+		if p.peekOperator() != ")" {
+			return stage{}, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return stage{group: &group}, nil
+	}
+
+	var argv []string
+	for p.pos < len(p.tokens) && !p.tokens[p.pos].operator {
+		argv = append(argv, p.tokens[p.pos].text)
+		p.pos++
+	}
+	if len(argv) == 0 {
+		if p.pos < len(p.tokens) {
+			return stage{}, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+		}
+		return stage{}, fmt.Errorf("missing command")
+	}
+	if !allowedPrograms[argv[0]] {
+		return stage{}, fmt.Errorf("program %q is not allowed", argv[0])
+	}
+	if argv[0] == "oc" && (len(argv) < 3 || argv[1] != "adm" || argv[2] != "node-logs") {
+		return stage{}, fmt.Errorf("only oc adm node-logs is allowed")
+	}
+	if argv[0] == "true" && len(argv) > 1 {
+		return stage{}, fmt.Errorf("true takes no arguments")
+	}
+	if _, ok := filterOptions[argv[0]]; ok {
+		if err := checkFilterArguments(argv); err != nil {
+			return stage{}, err
+		}
+	}
+	return stage{argv: argv}, nil
+}
+
+// checkFilterArguments rejects options and operands that would make a filter
+// program read files instead of its input
+func checkFilterArguments(argv []string) error {
+	program, options := argv[0], filterOptions[argv[0]]
+	operands := 0
+	patternOption := false
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" {
+			operands += len(argv) - i - 1
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			operands++
+			continue
+		}
+		// head -5 is head -n 5
+		if program == "head" && strings.Trim(arg[1:], "0123456789") == "" {
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			return fmt.Errorf("%s option %s is not allowed", program, arg)
+		}
+		for j := 1; j < len(arg); j++ {
+			flag := arg[j]
+			if strings.IndexByte(options.valued, flag) >= 0 {
+				if flag == 'e' {
+					patternOption = true
+				}
+				// The value is the rest of the argument or the next one
+				if j == len(arg)-1 {
+					i++
+				}
+				break
+			}
+			if strings.IndexByte(options.flags, flag) < 0 {
+				return fmt.Errorf("%s option -%c is not allowed", program, flag)
+			}
+		}
+	}
+
+	allowed := filterOperands[program]
+	if patternOption {
+		allowed = 0
+	}
+	if operands > allowed {
+		return fmt.Errorf("%s may only filter its input, not read files", program)
+	}
+	return nil
+}
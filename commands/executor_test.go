@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestParseCommand_GeneratedCommands tests that the commands the builder
+// generates parse into the programs and arguments a shell would run
+func TestParseCommand_GeneratedCommands(t *testing.T) {
+	jsonBuilder := NewCommandBuilder()
+	jsonBuilder.Config.UseJSONParsing = true
+	grepBuilder := NewCommandBuilder()
+	grepBuilder.Config.UseJSONParsing = false
+
+	paramSets := []types.AuditQueryParams{
+		{LogSource: "kube-apiserver"},
+		{LogSource: "kube-apiserver", Username: "john.doe", Verb: "delete", Resource: "secrets", Namespace: "default", Timeframe: "today"},
+		{LogSource: "oauth-server", Patterns: []string{"login failed"}, Exclude: []string{"system:"}, Verbs: []string{"create", "update"}},
+		{LogSource: "kube-apiserver", StatusCodeRange: "4xx", Groups: []string{"system:masters"}},
+	}
+	for _, params := range paramSets {
+		for _, command := range []string{
+			grepBuilder.buildSimpleCommand(params),
+			grepBuilder.buildMultiFileCommand(params, []LogFileInfo{{Path: "kube-apiserver/audit.log", IsCurrent: true}, {Path: "kube-apiserver/audit-1.log", Date: time.Now()}}),
+			"(" + grepBuilder.buildSingleFileCommand(params, types.LogFileInfo{Path: "kube-apiserver/audit.log", IsCurrent: true}) + ") || true ; (" +
+				grepBuilder.buildSingleFileCommand(params, types.LogFileInfo{Path: "kube-apiserver/audit-1.log"}) + ") || true",
+		} {
+			if _, err := ParseCommand(command); err != nil {
+				t.Errorf("Failed to parse %s: %v", command, err)
+			}
+		}
+	}
+
+	// The jq program is passed as one argument with its quotes removed
+	command := jsonBuilder.buildJSONAwareCommand(types.AuditQueryParams{LogSource: "kube-apiserver", Username: "john.doe", Patterns: []string{"pods"}})
+	parsed, err := ParseCommand(command)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", command, err)
+	}
+	stages := parsed.list.pipelines[0].stages
+	if len(stages) != 2 {
+		t.Fatalf("Expected 2 stages, got %d", len(stages))
+	}
+	if !reflect.DeepEqual(stages[0].argv, []string{"oc", "adm", "node-logs", "--role=master", "--path=kube-apiserver/audit.log"}) {
+		t.Errorf("Unexpected oc stage: %q", stages[0].argv)
+	}
+	start := strings.Index(command, "jq -r '") + len("jq -r '")
+	if expected := []string{"jq", "-r", command[start : len(command)-1]}; !reflect.DeepEqual(stages[1].argv, expected) {
+		t.Errorf("Unexpected jq stage: %q", stages[1].argv)
+	}
+}
+
+// TestParseCommand_Rejects tests rejecting shell syntax, other programs and
+// filters reading files
+func TestParseCommand_Rejects(t *testing.T) {
+	base := "oc adm node-logs --role=master --path=kube-apiserver/audit.log"
+	for _, command := range []string{
+		"",
+		base + " ; rm -rf /",
+		base + " | bash",
+		base + " | grep -i $(id)",
+		base + " | grep -i `id`",
+		base + ` | grep -i "$HOME"`,
+		base + " > /tmp/audit.log",
+		base + " < /etc/passwd",
+		base + " &",
+		base + " | grep -i *",
+		base + "\nid",
+		base + " | grep -i 'unterminated",
+		"(" + base,
+		base + " |",
+		"oc get secrets -A",
+		"oc adm policy add-cluster-role-to-user cluster-admin me",
+		base + " | grep -i root /etc/shadow",
+		base + " | grep -f /etc/shadow",
+		base + " | grep -r root /etc",
+		base + " | grep -e root /etc/shadow",
+		base + " | jq . /etc/shadow",
+		base + " | jq --rawfile secret /etc/shadow .",
+		base + " | jq -f /tmp/program.jq",
+		base + " | head /etc/shadow",
+		"true x",
+	} {
+		if _, err := ParseCommand(command); err == nil {
+			t.Errorf("Expected %q to be rejected", command)
+		}
+	}
+
+	for _, command := range []string{
+		base + " | grep -i 'root'",
+		base + ` | grep -vE '"verb":"(get|list)"'`,
+		base + " | grep -e root -e admin",
+		base + " | grep -m 5 root",
+		base + " | head -10",
+		base + " | head -n 10",
+		base + " | jq -r -c '.user.username'",
+		"(" + base + " && " + base + ") | grep -i root",
+	} {
+		if _, err := ParseCommand(command); err != nil {
+			t.Errorf("Expected %q to parse, got %v", command, err)
+		}
+	}
+}
+
+// installFakeOc puts an oc running script on PATH for the test
+func installFakeOc(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "oc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestRunCommand tests pipes, groups and lists, and their exit status
+func TestRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep is not installed")
+	}
+	// The fake oc prints the name of the file it is asked for, or fails for missing
+	installFakeOc(t, `#!/bin/sh
+case "$4" in
+*missing*) echo "error: file not found" >&2; exit 1 ;;
+*) printf '%s alice\n%s bob\n' "$4" "$4" ;;
+esac
+`)
+	oc := func(path string) string { return "oc adm node-logs --role=master --path=" + path }
+
+	tests := []struct {
+		command string
+		output  string
+		failed  bool
+	}{
+		{oc("a.log") + " | grep -i 'BOB'", "--path=a.log bob\n", false},
+		{oc("a.log") + " | grep -v bob | grep alice", "--path=a.log alice\n", false},
+		{"(" + oc("a.log") + " && " + oc("b.log") + ") | grep bob", "--path=a.log bob\n--path=b.log bob\n", false},
+		{"(" + oc("missing.log") + " && " + oc("b.log") + ") | grep bob", "error: file not found\n", true},
+		{"(" + oc("missing.log") + ") || true ; (" + oc("b.log") + " | grep alice) || true", "error: file not found\n--path=b.log alice\n", false},
+		{oc("a.log") + " | grep carol", "", true},
+		{oc("missing.log") + " | grep -c alice", "error: file not found\n0\n", true},
+	}
+	for _, tt := range tests {
+		var output strings.Builder
+		err := RunCommand(context.Background(), tt.command, &output, &output)
+		if (err != nil) != tt.failed {
+			t.Errorf("%s: expected failure %t, got %v", tt.command, tt.failed, err)
+		}
+		if output.String() != tt.output {
+			t.Errorf("%s: unexpected output %q", tt.command, output.String())
+		}
+	}
+
+	// Unsupported commands are not run
+	if err := RunCommand(context.Background(), oc("a.log")+" | sh", &strings.Builder{}, &strings.Builder{}); err == nil || !strings.Contains(err.Error(), "unsupported command") {
+		t.Errorf("Expected an unsupported command error, got %v", err)
+	}
+}
+
+// TestCommand_WithOcFlags tests adding global flags to every oc stage
+func TestCommand_WithOcFlags(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep is not installed")
+	}
+	// The fake oc prints its arguments
+	installFakeOc(t, "#!/bin/sh\necho \"$*\"\n")
+
+	parsed, err := ParseCommand("(oc adm node-logs --role=master --path=a.log && oc adm node-logs --role=master --path=b.log) | grep -v c.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output strings.Builder
+	if err := parsed.WithOcFlags([]string{"--context=prod"}).Run(context.Background(), &output, &output); err != nil {
+		t.Fatalf("Unexpected error: %v: %s", err, output.String())
+	}
+	expected := "--context=prod adm node-logs --role=master --path=a.log\n--context=prod adm node-logs --role=master --path=b.log\n"
+	if output.String() != expected {
+		t.Errorf("Unexpected output %q", output.String())
+	}
+
+	// The original command is unchanged
+	output.Reset()
+	if err := parsed.Run(context.Background(), &output, &output); err != nil || strings.Contains(output.String(), "--context") {
+		t.Errorf("Unexpected output %q, %v", output.String(), err)
+	}
+	if parsed.WithOcFlags(nil) != parsed {
+		t.Error("Expected the command itself without flags")
+	}
+}
+
+// TestRunCommand_Cancel tests that cancelling the context stops the programs
+func TestRunCommand_Cancel(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not installed")
+	}
+	installFakeOc(t, "#!/bin/sh\nexec sleep 30\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := RunCommand(ctx, "oc adm node-logs --role=master --path=a.log | grep alice", &strings.Builder{}, &strings.Builder{})
+	if err == nil {
+		t.Error("Expected an error for a cancelled command")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Cancelled command ran for %s", elapsed)
+	}
+}
+
+// TestRunFilters tests running the filter stages of a command on other input
+func TestRunFilters(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep is not installed")
+	}
+	input := "alice get pods\nbob delete secrets\nalice delete pods\n"
+
+	parsed, err := ParseCommand("oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i 'alice' | grep -i 'delete'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output strings.Builder
+	if err := parsed.RunFilters(context.Background(), strings.NewReader(input), &output, &output); err != nil {
+		t.Fatalf("Unexpected error: %v: %s", err, output.String())
+	}
+	if output.String() != "alice delete pods\n" {
+		t.Errorf("Unexpected output %q", output.String())
+	}
+
+	// Without filters the input is copied
+	parsed, _ = ParseCommand("oc adm node-logs --role=master --path=kube-apiserver/audit.log")
+	output.Reset()
+	if err := parsed.RunFilters(context.Background(), strings.NewReader(input), &output, &output); err != nil || output.String() != input {
+		t.Errorf("Unexpected output %q, %v", output.String(), err)
+	}
+
+	// Multi-file commands read the oc output more than once
+	parsed, _ = ParseCommand("oc adm node-logs --role=master --path=kube-apiserver/audit.log && oc adm node-logs --role=master --path=kube-apiserver/audit-1.log | grep alice")
+	if err := parsed.RunFilters(context.Background(), strings.NewReader(input), &output, &output); err == nil {
+		t.Error("Expected an error for a command with several pipelines")
+	}
+}
+
+// TestRunFilters_LongLines tests that multi-megabyte events pass through the
+// grep and jq stages whole
+func TestRunFilters_LongLines(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep is not installed")
+	}
+	useJQEngine(t, JQEngineBuiltin)
+	// Keys are sorted, as jq prints them
+	large := `{"requestObject":{"data":{"bundle":"` + strings.Repeat("x", 5<<20) + `"}},"user":{"username":"alice"},"verb":"create"}`
+	input := `{"verb":"get","user":{"username":"alice"}}` + "\n" + large + "\n" + `{"verb":"create","user":{"username":"bob"}}` + "\n"
+
+	parsed, err := ParseCommand(`oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i 'alice' | jq -c 'select(.verb == "create")'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output, stderr strings.Builder
+	if err := parsed.RunFilters(context.Background(), strings.NewReader(input), &output, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v: %s", err, stderr.String())
+	}
+	if output.String() != large+"\n" {
+		t.Errorf("Expected the %d byte event, got %d bytes", len(large)+1, output.Len())
+	}
+}
@@ -0,0 +1,387 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+var (
+	pathFlagPattern   = regexp.MustCompile(`--path=(\S+)`)
+	jqTestPattern     = regexp.MustCompile(`test\("((?:[^"\\]|\\.)*)"`)
+	jqListPattern     = regexp.MustCompile(`^tostring as \$\w+ \| \[(.*)\] \| (all|any)\(`)
+	grepFieldPattern  = regexp.MustCompile(`^"([A-Za-z0-9_./-]+)":`)
+	datePrefixPattern = regexp.MustCompile(`^\d{4}-\d{2}(-\d{2})?( \d{2}:\d{2}(:\d{2})?)?$`)
+)
+
+// ExplainQuery builds the command for the given parameters and explains how it filters events
+func ExplainQuery(params types.AuditQueryParams) types.QueryExplanation {
+	explanation := ExplainCommand(BuildOcCommand(params))
+
+	// Report patterns and exclusions dropped by the complexity limits
+	builder := NewCommandBuilder()
+	explanation.TruncatedPatterns = truncatedItems(params.Patterns, builder.Config.MaxPatterns)
+	explanation.TruncatedExclusions = truncatedItems(params.Exclude, builder.Config.MaxExclusions)
+	explanation.Notes = append(explanation.Notes, builder.CommandWarnings(params)...)
+	if params.Filter != nil {
+		explanation.Notes = append(explanation.Notes, "filter expression: "+params.Filter.String())
+	}
+	if params.SourceCIDR != "" {
+		explanation.Notes = append(explanation.Notes,
+			fmt.Sprintf("source CIDR %s is applied to parsed results, not by the command", params.SourceCIDR))
+	}
+	if params.SortBy != "" || params.Limit > 0 || params.Offset > 0 {
+		explanation.Notes = append(explanation.Notes, describePaging(params))
+	}
+	if params.SampleRate > 1 || params.SampleSize > 0 {
+		explanation.Notes = append(explanation.Notes, describeSampling(params))
+	}
+
+	// Describe the requested window next to the date filter that implements it
+	if params.Timeframe != "" {
+		start, end := parseTimeframe(params.Timeframe)
+		if start.IsZero() {
+			explanation.Notes = append(explanation.Notes,
+				fmt.Sprintf("timeframe %q is not recognised, so no time filter is applied", params.Timeframe))
+		} else {
+			explanation.Timeframe = fmt.Sprintf("%s (%s to %s)", params.Timeframe,
+				start.Format("2006-01-02 15:04:05"), end.Format("2006-01-02 15:04:05"))
+			if start.Format("2006-01-02") != end.Format("2006-01-02") {
+				explanation.Notes = append(explanation.Notes,
+					"the timeframe spans several days but the date filter matches a single date prefix, so events on other days are dropped")
+			}
+		}
+	}
+
+	return explanation
+}
+
+// ExplainCommand decomposes a generated oc command into human-readable filter descriptions
+func ExplainCommand(command string) types.QueryExplanation {
+	explanation := types.QueryExplanation{
+		Command: command,
+		Engine:  "none",
+		Filters: []types.FilterExplanation{},
+	}
+
+	trimmed := strings.TrimSpace(command)
+	if trimmed == "" {
+		explanation.Notes = append(explanation.Notes, "command is empty")
+		return explanation
+	}
+
+	// Multi-file commands repeat the same filters per file, so explain the first one
+	unwrapped := strings.TrimSuffix(strings.TrimPrefix(trimmed, "("), ")")
+	segments := splitOutsideQuotes(unwrapped, " && ")
+	if len(segments) == 1 {
+		segments = splitOutsideQuotes(unwrapped, " ; ")
+	}
+	if len(segments) > 1 {
+		explanation.Notes = append(explanation.Notes,
+			fmt.Sprintf("command queries %d log files; filters are shown for the first file", len(segments)))
+		trimmed = strings.TrimSpace(segments[0])
+	}
+
+	if match := pathFlagPattern.FindStringSubmatch(trimmed); len(match) > 1 {
+		explanation.LogPath = match[1]
+	}
+
+	grepStages := 0
+	for _, stage := range splitOutsideQuotes(trimmed, "|")[1:] {
+		stage = strings.TrimSpace(stage)
+		switch {
+		case strings.HasPrefix(stage, "jq "):
+			explanation.Engine = "jq"
+			explanation.Filters = append(explanation.Filters, explainJQStage(stage)...)
+			explanation.Notes = append(explanation.Notes, "matching events are projected to a summary object with timestamp, username, verb, resource, namespace, name and status fields")
+		case strings.HasPrefix(stage, "grep "):
+			if explanation.Engine == "none" {
+				explanation.Engine = "grep"
+			}
+			grepStages++
+			explanation.Filters = append(explanation.Filters, explainGrepStage(stage))
+		default:
+			explanation.Filters = append(explanation.Filters, types.FilterExplanation{
+				Kind:        "other",
+				Expression:  stage,
+				Description: "unrecognised pipeline stage",
+			})
+		}
+	}
+
+	if grepStages > 1 {
+		explanation.Notes = append(explanation.Notes,
+			fmt.Sprintf("%d grep stages are chained, so a line must match every stage to be returned", grepStages))
+	}
+	if len(explanation.Filters) == 0 {
+		explanation.Notes = append(explanation.Notes, "no filters are applied; every event in the log is returned")
+	}
+
+	return explanation
+}
+
+// explainGrepStage describes a single grep pipeline stage
+func explainGrepStage(stage string) types.FilterExplanation {
+	filter := types.FilterExplanation{Kind: "include", Expression: stage}
+
+	args := splitOutsideQuotes(strings.TrimSpace(strings.TrimPrefix(stage, "grep")), " ")
+	invert := false
+	var patterns []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-e" && i+1 < len(args):
+			i++
+			patterns = append(patterns, strings.Trim(args[i], "'"))
+		case strings.HasPrefix(args[i], "-"):
+			if strings.Contains(args[i], "v") {
+				invert = true
+			}
+		default:
+			patterns = append(patterns, strings.Trim(args[i], "'"))
+		}
+	}
+	pattern := strings.Join(patterns, " ")
+
+	if len(patterns) > 1 {
+		if invert {
+			filter.Kind = "exclude"
+			filter.Description = fmt.Sprintf("drops lines containing any of %q (case-insensitive)", patterns)
+		} else {
+			filter.Description = fmt.Sprintf("keeps lines containing any of %q (case-insensitive)", patterns)
+		}
+		return filter
+	}
+
+	switch {
+	case invert:
+		filter.Kind = "exclude"
+		filter.Description = fmt.Sprintf("drops lines containing %q", pattern)
+	case datePrefixPattern.MatchString(pattern):
+		filter.Kind = "timeframe"
+		filter.Field = "requestReceivedTimestamp"
+		filter.Description = fmt.Sprintf("keeps lines containing the date %q anywhere in the line", pattern)
+	case grepFieldPattern.MatchString(pattern):
+		filter.Kind = "field"
+		filter.Field = grepFieldPattern.FindStringSubmatch(pattern)[1]
+		filter.Description = fmt.Sprintf("keeps lines where the %s field matches %q", filter.Field, pattern)
+	default:
+		filter.Description = fmt.Sprintf("keeps lines containing %q (case-insensitive)", pattern)
+	}
+
+	return filter
+}
+
+// explainJQStage describes each clause of the select() expression in a jq stage
+func explainJQStage(stage string) []types.FilterExplanation {
+	var filters []types.FilterExplanation
+
+	start := strings.Index(stage, "select(")
+	if start == -1 {
+		return filters
+	}
+	body := stage[start+len("select("):]
+	depth, end := 1, -1
+	inString := false
+	for i := 0; i < len(body) && end == -1; i++ {
+		switch c := body[i]; {
+		case c == '\\' && inString:
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end == -1 {
+		return filters
+	}
+
+	for _, clause := range splitOutsideQuotes(body[:end], " and ") {
+		filters = append(filters, explainJQClause(strings.TrimSpace(clause)))
+	}
+	return filters
+}
+
+// explainJQClause describes a single jq select() clause
+func explainJQClause(clause string) types.FilterExplanation {
+	clause = trimEnclosingParens(clause)
+	filter := types.FilterExplanation{Kind: "field", Expression: clause}
+
+	value := ""
+	if match := jqTestPattern.FindStringSubmatch(clause); len(match) > 1 {
+		value = match[1]
+	}
+
+	if strings.HasPrefix(clause, "tostring as $event | (") {
+		filter.Kind = "expression"
+		filter.Description = "keeps events whose JSON matches the boolean filter expression"
+		return filter
+	}
+
+	if strings.HasPrefix(clause, jqStatusCodeBinding) {
+		filter.Field = "responseStatus.code"
+		filter.Description = "keeps events whose response status code satisfies " + strings.TrimPrefix(clause, jqStatusCodeBinding)
+		return filter
+	}
+
+	negated := strings.HasSuffix(clause, "| not") || strings.HasSuffix(clause, "| not)")
+	if match := jqListPattern.FindStringSubmatch(clause); len(match) > 2 {
+		var values []string
+		for _, item := range splitOutsideQuotes(match[1], ", ") {
+			values = append(values, strings.Trim(item, `"`))
+		}
+		if negated {
+			filter.Kind = "exclude"
+			filter.Description = fmt.Sprintf("drops events whose JSON contains any of %q (case-insensitive)", values)
+		} else {
+			filter.Kind = "include"
+			filter.Description = fmt.Sprintf("keeps events whose JSON contains %s of %q (case-insensitive)", match[2], values)
+		}
+		return filter
+	}
+
+	field := strings.TrimSpace(strings.SplitN(strings.TrimPrefix(clause, "("), "|", 2)[0])
+	field = strings.TrimSuffix(field, ")")
+
+	switch {
+	case strings.HasPrefix(field, ".requestReceivedTimestamp"):
+		filter.Kind = "timeframe"
+		filter.Field = "requestReceivedTimestamp"
+		filter.Description = fmt.Sprintf("keeps events whose timestamp contains %q", value)
+	case strings.HasPrefix(field, "tostring") && negated:
+		filter.Kind = "exclude"
+		filter.Description = fmt.Sprintf("drops events whose JSON contains %q (case-insensitive)", value)
+	case strings.HasPrefix(field, "tostring"):
+		filter.Kind = "include"
+		filter.Description = fmt.Sprintf("keeps events whose JSON contains %q (case-insensitive)", value)
+	default:
+		var candidates []string
+		for _, candidate := range strings.Split(field, "//") {
+			candidate = strings.Trim(strings.TrimSpace(candidate), "()")
+			if candidate != `""` {
+				candidates = append(candidates, candidate)
+			}
+		}
+		caseMode := "case-sensitive"
+		if strings.Contains(clause, `; "i")`) {
+			caseMode = "case-insensitive"
+		}
+		filter.Field = strings.TrimPrefix(candidates[0], ".")
+		action := "keeps"
+		if negated {
+			filter.Kind = "exclude"
+			action = "drops"
+		}
+		subject := candidates[0]
+		if len(candidates) > 1 {
+			subject = "the first present of " + strings.Join(candidates, ", ")
+		}
+		if value == "" {
+			// Comparison clauses such as .stage == "Panic" carry no test() pattern
+			filter.Description = fmt.Sprintf("%s events satisfying %s", action, clause)
+		} else {
+			filter.Description = fmt.Sprintf("%s events where %s matches %q (%s)", action, subject, value, caseMode)
+		}
+	}
+
+	return filter
+}
+
+// trimEnclosingParens removes parentheses that wrap the whole expression
+func trimEnclosingParens(expr string) string {
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		depth := 0
+		inString := false
+		closesEarly := false
+		for i := 0; i < len(expr)-1; i++ {
+			switch c := expr[i]; {
+			case c == '\\' && inString:
+				i++
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			}
+			if depth == 0 {
+				closesEarly = true
+				break
+			}
+		}
+		if closesEarly {
+			return expr
+		}
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators inside quotes or parentheses
+func splitOutsideQuotes(s, sep string) []string {
+	var parts []string
+	var quote byte
+	depth, last := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[last:i])
+			last = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// describeSampling describes the sampling applied to parsed results
+func describeSampling(params types.AuditQueryParams) string {
+	var parts []string
+	if params.SampleRate > 1 {
+		parts = append(parts, fmt.Sprintf("about 1 in %d", params.SampleRate))
+	}
+	if params.SampleSize > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d", params.SampleSize))
+	}
+	return "parsed results are sampled, keeping " + strings.Join(parts, ", then ") + " of the matching entries; the command returns every matching event"
+}
+
+// describePaging describes the sorting and paging applied to parsed results
+func describePaging(params types.AuditQueryParams) string {
+	var parts []string
+	if params.SortBy != "" {
+		order := params.SortOrder
+		if order == "" {
+			order = "asc"
+		}
+		parts = append(parts, fmt.Sprintf("sorted by %s %s", params.SortBy, order))
+	}
+	if params.Offset > 0 {
+		parts = append(parts, fmt.Sprintf("skipping %d", params.Offset))
+	}
+	if params.Limit > 0 {
+		parts = append(parts, fmt.Sprintf("limited to %d", params.Limit))
+	}
+	return "parsed results are " + strings.Join(parts, ", ") + "; the command returns every matching event"
+}
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestExplainCommand_JQ tests explanation of jq-based commands
+func TestExplainCommand_JQ(t *testing.T) {
+	command := `oc adm node-logs --role=master --path=kube-apiserver/audit.log | jq -r 'select(.verb | test("delete"; "i") and (.objectRef.namespace // .requestObject.metadata.namespace) | test("default"; "i") and (tostring | test("secret"; "i") | not) and .requestReceivedTimestamp | test("2024-01-15")) | {timestamp: .requestReceivedTimestamp}'`
+
+	explanation := ExplainCommand(command)
+
+	if explanation.Engine != "jq" {
+		t.Errorf("Expected engine jq, got %s", explanation.Engine)
+	}
+	if explanation.LogPath != "kube-apiserver/audit.log" {
+		t.Errorf("Expected log path kube-apiserver/audit.log, got %s", explanation.LogPath)
+	}
+	if len(explanation.Filters) != 4 {
+		t.Fatalf("Expected 4 filters, got %d: %+v", len(explanation.Filters), explanation.Filters)
+	}
+
+	expected := []struct {
+		kind  string
+		field string
+		value string
+	}{
+		{"field", "verb", "delete"},
+		{"field", "objectRef.namespace", "default"},
+		{"exclude", "", "secret"},
+		{"timeframe", "requestReceivedTimestamp", "2024-01-15"},
+	}
+	for i, exp := range expected {
+		filter := explanation.Filters[i]
+		if filter.Kind != exp.kind {
+			t.Errorf("Filter %d: expected kind %s, got %s", i, exp.kind, filter.Kind)
+		}
+		if filter.Field != exp.field {
+			t.Errorf("Filter %d: expected field %q, got %q", i, exp.field, filter.Field)
+		}
+		if !strings.Contains(filter.Description, exp.value) {
+			t.Errorf("Filter %d: description %q should mention %q", i, filter.Description, exp.value)
+		}
+	}
+}
+
+// TestExplainCommand_JQPatternLists tests explanation of combined pattern and exclusion clauses
+func TestExplainCommand_JQPatternLists(t *testing.T) {
+	builder := NewCommandBuilder()
+	command := builder.buildJSONAwareCommand(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Verb:      "get",
+		Patterns:  []string{"pods", "admin"},
+		Exclude:   []string{"healthz"},
+	})
+
+	explanation := ExplainCommand(command)
+
+	if len(explanation.Filters) != 3 {
+		t.Fatalf("Expected 3 filters, got %d: %+v", len(explanation.Filters), explanation.Filters)
+	}
+	if explanation.Filters[0].Field != "verb" {
+		t.Errorf("Expected verb filter first, got %+v", explanation.Filters[0])
+	}
+	if explanation.Filters[1].Kind != "include" || !strings.Contains(explanation.Filters[1].Description, `"admin"`) {
+		t.Errorf("Expected include filter listing patterns, got %+v", explanation.Filters[1])
+	}
+	if explanation.Filters[2].Kind != "exclude" || !strings.Contains(explanation.Filters[2].Description, `"healthz"`) {
+		t.Errorf("Expected exclude filter listing exclusions, got %+v", explanation.Filters[2])
+	}
+}
+
+// TestExplainCommand_Grep tests explanation of grep-based commands
+func TestExplainCommand_Grep(t *testing.T) {
+	command := `oc adm node-logs --role=master --path=oauth-server/audit.log | grep -i 'login' | grep '"verb":"create"' | grep -v 'healthz' | grep '2024-01-15'`
+
+	explanation := ExplainCommand(command)
+
+	if explanation.Engine != "grep" {
+		t.Errorf("Expected engine grep, got %s", explanation.Engine)
+	}
+	if len(explanation.Filters) != 4 {
+		t.Fatalf("Expected 4 filters, got %d", len(explanation.Filters))
+	}
+
+	kinds := []string{"include", "field", "exclude", "timeframe"}
+	for i, kind := range kinds {
+		if explanation.Filters[i].Kind != kind {
+			t.Errorf("Filter %d: expected kind %s, got %s", i, kind, explanation.Filters[i].Kind)
+		}
+	}
+	if explanation.Filters[1].Field != "verb" {
+		t.Errorf("Expected field verb, got %s", explanation.Filters[1].Field)
+	}
+
+	found := false
+	for _, note := range explanation.Notes {
+		if strings.Contains(note, "chained") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected note about chained grep stages")
+	}
+}
+
+// TestExplainCommand_NoFilters tests explanation of an unfiltered command
+func TestExplainCommand_NoFilters(t *testing.T) {
+	explanation := ExplainCommand("oc adm node-logs --role=master --path=kube-apiserver/audit.log")
+
+	if len(explanation.Filters) != 0 {
+		t.Errorf("Expected no filters, got %d", len(explanation.Filters))
+	}
+	if len(explanation.Notes) == 0 {
+		t.Error("Expected a note explaining that no filters are applied")
+	}
+}
+
+// TestExplainCommand_MultiFile tests explanation of multi-file commands
+func TestExplainCommand_MultiFile(t *testing.T) {
+	command := `(oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i 'pods' && oc adm node-logs --role=master --path=kube-apiserver/audit-2024-01-14.log | grep -i 'pods')`
+
+	explanation := ExplainCommand(command)
+
+	if explanation.LogPath != "kube-apiserver/audit.log" {
+		t.Errorf("Expected first log path, got %s", explanation.LogPath)
+	}
+	if len(explanation.Filters) != 1 {
+		t.Errorf("Expected 1 filter for the first file, got %d", len(explanation.Filters))
+	}
+	if len(explanation.Notes) == 0 || !strings.Contains(explanation.Notes[0], "2 log files") {
+		t.Errorf("Expected multi-file note, got %v", explanation.Notes)
+	}
+}
+
+// TestExplainQuery_Truncation tests reporting of patterns dropped by the complexity limits
+func TestExplainQuery_Truncation(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Patterns:  []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10", "p11", "p12"},
+		Exclude:   []string{"e1", "e2", "e3", "e4", "e5", "e6", "e7", "e8", "e9", "e10", "e11"},
+	}
+
+	explanation := ExplainQuery(params)
+
+	if len(explanation.TruncatedPatterns) != 2 || explanation.TruncatedPatterns[0] != "p11" {
+		t.Errorf("Expected truncated patterns [p11 p12], got %v", explanation.TruncatedPatterns)
+	}
+	if len(explanation.TruncatedExclusions) != 1 || explanation.TruncatedExclusions[0] != "e11" {
+		t.Errorf("Expected truncated exclusions [e11], got %v", explanation.TruncatedExclusions)
+	}
+}
+
+// TestExplainQuery_Timeframe tests that the requested window is described
+func TestExplainQuery_Timeframe(t *testing.T) {
+	explanation := ExplainQuery(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Timeframe: "7d",
+	})
+
+	if !strings.HasPrefix(explanation.Timeframe, "7d (") {
+		t.Errorf("Expected timeframe description, got %q", explanation.Timeframe)
+	}
+
+	found := false
+	for _, note := range explanation.Notes {
+		if strings.Contains(note, "single date") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected note about single-date filtering, got %v", explanation.Notes)
+	}
+}
+
+// TestExplainQuery_Paging tests that post-parse sorting and paging are described
+func TestExplainQuery_Paging(t *testing.T) {
+	explanation := ExplainQuery(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Verb:      "delete",
+		SortBy:    "timestamp",
+		SortOrder: "desc",
+		Limit:     100,
+	})
+
+	found := false
+	for _, note := range explanation.Notes {
+		if strings.Contains(note, "sorted by timestamp desc, limited to 100") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected sorting and paging note, got %v", explanation.Notes)
+	}
+}
+
+// TestExplainQuery_Sampling tests that post-parse sampling is described
+func TestExplainQuery_Sampling(t *testing.T) {
+	explanation := ExplainQuery(types.AuditQueryParams{
+		LogSource:  "kube-apiserver",
+		SampleRate: 10,
+		SampleSize: 500,
+	})
+
+	found := false
+	for _, note := range explanation.Notes {
+		if strings.Contains(note, "sampled, keeping about 1 in 10, then at most 500") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected sampling note, got %v", explanation.Notes)
+	}
+}
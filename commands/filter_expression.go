@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// buildJQFilterExpressionClause builds a jq select() clause evaluating the filter
+// expression against the whole event
+func buildJQFilterExpressionClause(expr types.FilterExpression) string {
+	return "tostring as $event | " + buildJQFilterExpression(expr)
+}
+
+// buildJQFilterExpression recursively translates a filter expression to jq
+func buildJQFilterExpression(expr types.FilterExpression) string {
+	if expr.IsLeaf() {
+		return fmt.Sprintf(`($event | test("%s"; "i"))`, escapeForJQ(expr.Pattern))
+	}
+
+	parts := make([]string, len(expr.Children))
+	for i, child := range expr.Children {
+		parts[i] = buildJQFilterExpression(child)
+	}
+
+	switch expr.Operator {
+	case types.FilterOperatorOr:
+		return "(" + strings.Join(parts, " or ") + ")"
+	case types.FilterOperatorNot:
+		return "((" + strings.Join(parts, " or ") + ") | not)"
+	default:
+		return "(" + strings.Join(parts, " and ") + ")"
+	}
+}
+
+// buildGrepFilterExpression translates a filter expression to chained grep stages.
+// grep can only express an AND of single patterns, OR-groups of patterns and
+// NOT-groups of patterns; other sub-expressions are left out, which widens the
+// result, and exact is false.
+func buildGrepFilterExpression(expr types.FilterExpression) (stages []string, exact bool) {
+	if expr.IsLeaf() {
+		return []string{fmt.Sprintf("| grep -i '%s'", expr.Pattern)}, true
+	}
+
+	switch expr.Operator {
+	case types.FilterOperatorAnd:
+		exact = true
+		for _, child := range expr.Children {
+			childStages, childExact := buildGrepFilterExpression(child)
+			stages = append(stages, childStages...)
+			exact = exact && childExact
+		}
+		return stages, exact
+	case types.FilterOperatorOr, types.FilterOperatorNot:
+		patterns, ok := collectOrPatterns(expr.Children)
+		if !ok {
+			return nil, false
+		}
+		flags := "-i"
+		if expr.Operator == types.FilterOperatorNot {
+			flags = "-iv"
+		}
+		var args []string
+		for _, pattern := range patterns {
+			args = append(args, fmt.Sprintf("-e '%s'", pattern))
+		}
+		return []string{fmt.Sprintf("| grep %s %s", flags, strings.Join(args, " "))}, true
+	}
+
+	return nil, false
+}
+
+// collectOrPatterns flattens leaves and nested OR groups into a single pattern list
+func collectOrPatterns(children []types.FilterExpression) ([]string, bool) {
+	var patterns []string
+	for _, child := range children {
+		switch {
+		case child.IsLeaf():
+			patterns = append(patterns, child.Pattern)
+		case child.Operator == types.FilterOperatorOr:
+			nested, ok := collectOrPatterns(child.Children)
+			if !ok {
+				return nil, false
+			}
+			patterns = append(patterns, nested...)
+		default:
+			return nil, false
+		}
+	}
+	return patterns, true
+}
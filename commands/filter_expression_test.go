@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// exampleFilterExpression represents (delete OR patch) AND (crd OR clusterrole) AND NOT system:
+func exampleFilterExpression() types.FilterExpression {
+	return types.FilterExpression{
+		Operator: types.FilterOperatorAnd,
+		Children: []types.FilterExpression{
+			{Operator: types.FilterOperatorOr, Children: []types.FilterExpression{{Pattern: "delete"}, {Pattern: "patch"}}},
+			{Operator: types.FilterOperatorOr, Children: []types.FilterExpression{{Pattern: "crd"}, {Pattern: "clusterrole"}}},
+			{Operator: types.FilterOperatorNot, Children: []types.FilterExpression{{Pattern: "system:"}}},
+		},
+	}
+}
+
+// TestBuildJQFilterExpressionClause tests jq translation of filter expressions
+func TestBuildJQFilterExpressionClause(t *testing.T) {
+	clause := buildJQFilterExpressionClause(exampleFilterExpression())
+
+	expected := `tostring as $event | ((($event | test("delete"; "i")) or ($event | test("patch"; "i"))) and (($event | test("crd"; "i")) or ($event | test("clusterrole"; "i"))) and ((($event | test("system:"; "i"))) | not))`
+	if clause != expected {
+		t.Errorf("Unexpected jq clause:\n got: %s\nwant: %s", clause, expected)
+	}
+}
+
+// TestBuildGrepFilterExpression tests grep translation of filter expressions
+func TestBuildGrepFilterExpression(t *testing.T) {
+	tests := []struct {
+		name          string
+		expr          types.FilterExpression
+		expected      []string
+		expectedExact bool
+	}{
+		{
+			name:          "single pattern",
+			expr:          types.FilterExpression{Pattern: "delete"},
+			expected:      []string{"| grep -i 'delete'"},
+			expectedExact: true,
+		},
+		{
+			name: "and of or-groups and not",
+			expr: exampleFilterExpression(),
+			expected: []string{
+				"| grep -i -e 'delete' -e 'patch'",
+				"| grep -i -e 'crd' -e 'clusterrole'",
+				"| grep -iv -e 'system:'",
+			},
+			expectedExact: true,
+		},
+		{
+			name: "nested or groups are flattened",
+			expr: types.FilterExpression{
+				Operator: types.FilterOperatorOr,
+				Children: []types.FilterExpression{
+					{Pattern: "a"},
+					{Operator: types.FilterOperatorOr, Children: []types.FilterExpression{{Pattern: "b"}, {Pattern: "c"}}},
+				},
+			},
+			expected:      []string{"| grep -i -e 'a' -e 'b' -e 'c'"},
+			expectedExact: true,
+		},
+		{
+			name: "or containing and is skipped",
+			expr: types.FilterExpression{
+				Operator: types.FilterOperatorAnd,
+				Children: []types.FilterExpression{
+					{Pattern: "pods"},
+					{
+						Operator: types.FilterOperatorOr,
+						Children: []types.FilterExpression{
+							{Pattern: "a"},
+							{Operator: types.FilterOperatorAnd, Children: []types.FilterExpression{{Pattern: "b"}, {Pattern: "c"}}},
+						},
+					},
+				},
+			},
+			expected:      []string{"| grep -i 'pods'"},
+			expectedExact: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, exact := buildGrepFilterExpression(tt.expr)
+
+			if exact != tt.expectedExact {
+				t.Errorf("Expected exact=%v, got %v", tt.expectedExact, exact)
+			}
+			if strings.Join(stages, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Unexpected grep stages:\n got: %v\nwant: %v", stages, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildOcCommand_FilterExpression tests that filter expressions reach both back-ends
+func TestBuildOcCommand_FilterExpression(t *testing.T) {
+	expr := exampleFilterExpression()
+	params := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Filter:    &expr,
+	}
+
+	builder := NewCommandBuilder()
+	jqCommand := builder.buildJSONAwareCommand(params)
+	if !strings.Contains(jqCommand, `($event | test("clusterrole"; "i"))`) {
+		t.Errorf("Expected filter expression in jq command, got: %s", jqCommand)
+	}
+
+	builder.Config.UseJSONParsing = false
+	grepCommand := builder.BuildOptimalCommand(params)
+	if !strings.Contains(grepCommand, "| grep -iv -e 'system:'") {
+		t.Errorf("Expected filter expression in grep command, got: %s", grepCommand)
+	}
+
+	if warnings := builder.CommandWarnings(params); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a grep-expressible filter, got: %v", warnings)
+	}
+
+	unsupported := types.FilterExpression{
+		Operator: types.FilterOperatorNot,
+		Children: []types.FilterExpression{
+			{Operator: types.FilterOperatorAnd, Children: []types.FilterExpression{{Pattern: "a"}, {Pattern: "b"}}},
+		},
+	}
+	params.Filter = &unsupported
+	if warnings := builder.CommandWarnings(params); len(warnings) != 1 {
+		t.Errorf("Expected a warning for a filter grep cannot express, got: %v", warnings)
+	}
+}
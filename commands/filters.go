@@ -3,6 +3,8 @@ package commands
 import (
 	"fmt"
 	"strings"
+
+	"audit-query-mcp-server/types"
 )
 
 // BuildUsernameFilter creates a comprehensive username filter for audit logs
@@ -169,6 +171,60 @@ func BuildNamespaceFilter(namespace string) string {
 	return strings.Join(patterns, " ")
 }
 
+// buildGrepFieldFilters builds the username, resource, verb, namespace and status code
+// grep stages. Fields with an explicit match mode or several values use a single
+// field-specific stage; the others keep the legacy comprehensive filters.
+func buildGrepFieldFilters(params types.AuditQueryParams) []string {
+	fieldFilters := []struct {
+		key    string
+		values []string
+		mode   types.MatchMode
+		legacy func(string) string
+	}{
+		{"username", fieldValues(params.Username, params.Usernames), params.UsernameMatch, BuildUsernameFilter},
+		{"resource", fieldValues(params.Resource, params.Resources), params.ResourceMatch, BuildResourceFilter},
+		{"verb", fieldValues(params.Verb, params.Verbs), params.VerbMatch, BuildVerbFilter},
+		{"namespace", fieldValues(params.Namespace, params.Namespaces), params.NamespaceMatch, BuildNamespaceFilter},
+	}
+
+	var stages []string
+	for _, f := range fieldFilters {
+		switch {
+		case len(f.values) == 0:
+			continue
+		case len(f.values) == 1 && f.mode == "":
+			if filter := f.legacy(f.values[0]); filter != "" {
+				stages = append(stages, filter)
+			}
+		default:
+			stages = append(stages, buildGrepFieldMatchAny(f.key, f.values, f.mode))
+		}
+	}
+	if len(params.Subresources) > 0 {
+		stages = append(stages, buildGrepFieldMatchAny("subresource", params.Subresources, types.MatchModeExact))
+	}
+	stages = append(stages, buildGrepFieldExclusions(params)...)
+	stages = append(stages, buildGrepStatusCodeFilters(params)...)
+	stages = append(stages, buildGrepIdentityFilters(params)...)
+
+	if params.UserAgent != "" {
+		stages = append(stages, buildGrepFieldMatch("userAgent", params.UserAgent, userAgentMatchMode(params)))
+	}
+	if params.Stage != "" {
+		stages = append(stages, fmt.Sprintf(`| grep '"stage":"%s"'`, params.Stage))
+	}
+	if params.Level != "" {
+		stages = append(stages, fmt.Sprintf(`| grep '"level":"%s"'`, params.Level))
+	}
+
+	// Source IPs only appear quoted in the sourceIPs array; the parsing layer
+	// rechecks the field and applies any CIDR filter
+	if params.SourceIP != "" {
+		stages = append(stages, fmt.Sprintf(`| grep -F '"%s"'`, params.SourceIP))
+	}
+	return stages
+}
+
 // escapeForGrep escapes special characters for safe grep usage
 func escapeForGrep(input string) string {
 	// Escape special grep characters: [ ] ( ) . * + ? ^ $ { } | \
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// buildJQIdentityFilters builds jq clauses for the group and impersonation parameters.
+// An event matches the group filter when the requesting user belongs to any listed group.
+func buildJQIdentityFilters(params types.AuditQueryParams) []string {
+	var filters []string
+
+	if len(params.Groups) > 0 {
+		quoted := make([]string, len(params.Groups))
+		for i, group := range params.Groups {
+			quoted[i] = jqStringLiteral(group)
+		}
+		filters = append(filters, fmt.Sprintf(`(.user.groups // []) | any(. as $g | [%s] | index([$g]))`, strings.Join(quoted, ", ")))
+	}
+
+	if params.ImpersonatedUser != "" {
+		filters = append(filters, buildJQFieldMatch(`.impersonatedUser.username`, params.ImpersonatedUser, types.MatchModeExact))
+	}
+
+	return filters
+}
+
+// buildGrepIdentityFilters builds grep stages for the group and impersonation parameters.
+// The groups stage may also match the impersonated user's groups.
+func buildGrepIdentityFilters(params types.AuditQueryParams) []string {
+	var stages []string
+
+	if len(params.Groups) > 0 {
+		quoted := make([]string, len(params.Groups))
+		for i, group := range params.Groups {
+			quoted[i] = regexp.QuoteMeta(group)
+		}
+		stages = append(stages, fmt.Sprintf(`| grep -E '"groups":\[[^]]*"(%s)"'`, strings.Join(quoted, "|")))
+	}
+
+	if params.ImpersonatedUser != "" {
+		stages = append(stages, fmt.Sprintf(`| grep -E '"impersonatedUser":\{"username":"%s"'`, regexp.QuoteMeta(params.ImpersonatedUser)))
+	}
+
+	return stages
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestBuildJQIdentityFilters tests jq clauses for group and impersonation parameters
+func TestBuildJQIdentityFilters(t *testing.T) {
+	params := types.AuditQueryParams{
+		Groups:           []string{"ops-team", "system:masters"},
+		ImpersonatedUser: "system:admin",
+	}
+
+	expected := []string{
+		`(.user.groups // []) | any(. as $g | ["ops-team", "system:masters"] | index([$g]))`,
+		`(.impersonatedUser.username // "" | test("^system:admin$"))`,
+	}
+	if got := buildJQIdentityFilters(params); !reflect.DeepEqual(got, expected) {
+		t.Errorf("buildJQIdentityFilters() = %v, want %v", got, expected)
+	}
+
+	if got := buildJQIdentityFilters(types.AuditQueryParams{}); len(got) != 0 {
+		t.Errorf("Expected no clauses without identity filters, got %v", got)
+	}
+}
+
+// TestBuildGrepIdentityFilters tests grep stages for group and impersonation parameters
+func TestBuildGrepIdentityFilters(t *testing.T) {
+	params := types.AuditQueryParams{
+		Groups:           []string{"ops-team"},
+		ImpersonatedUser: "system:admin",
+	}
+
+	expected := []string{
+		`| grep -E '"groups":\[[^]]*"(ops-team)"'`,
+		`| grep -E '"impersonatedUser":\{"username":"system:admin"'`,
+	}
+	if got := buildGrepIdentityFilters(params); !reflect.DeepEqual(got, expected) {
+		t.Errorf("buildGrepIdentityFilters() = %v, want %v", got, expected)
+	}
+}
+
+// TestBuildOcCommand_Identity tests that identity filters reach both back-ends
+func TestBuildOcCommand_Identity(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource:        "kube-apiserver",
+		ImpersonatedUser: "system:admin",
+	}
+
+	builder := NewCommandBuilder()
+	if command := builder.buildJSONAwareCommand(params); !strings.Contains(command, ".impersonatedUser.username") {
+		t.Errorf("Expected impersonation clause in jq command, got: %s", command)
+	}
+
+	builder.Config.UseJSONParsing = false
+	if command := builder.BuildOptimalCommand(params); !strings.Contains(command, `"impersonatedUser":\{"username":"system:admin"`) {
+		t.Errorf("Expected impersonation stage in grep command, got: %s", command)
+	}
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/validation"
+)
+
+// hostileValues are parameter values that try to escape the quoting of
+// generated commands or the string literals of generated jq programs
+var hostileValues = []string{
+	`x`,
+	`'; rm -rf / #`,
+	`' | sh '`,
+	`"; cat /etc/shadow; "`,
+	"`id`",
+	`$(id)`,
+	`${HOME}`,
+	"a\nid",
+	"a\r\nb",
+	"a\x00b",
+	`\`,
+	`a\'b`,
+	`") | input | ("`,
+	`\(env.HOME)`,
+	`'`,
+	`$__loc__`,
+	`a|b`,
+	`a && b`,
+	`a || b`,
+	`a > /tmp/x`,
+	`*`,
+	`[a-z]+.*`,
+	`{"a":1}`,
+	`x' '.' '/etc/shadow`,
+}
+
+// injectionParams returns query parameters with value placed in one free-form field
+func injectionParams(field int, value string) types.AuditQueryParams {
+	params := types.AuditQueryParams{LogSource: "kube-apiserver"}
+	switch field {
+	case 0:
+		params.Username = value
+	case 1:
+		params.Patterns = []string{value}
+	case 2:
+		params.Exclude = []string{value}
+	case 3:
+		params.UserAgent = value
+	case 4:
+		params.ImpersonatedUser = value
+	case 5:
+		params.Groups = []string{value}
+	case 6:
+		params.Filter = &types.FilterExpression{Pattern: value}
+	case 7:
+		params.Resource = value
+	case 8:
+		params.Namespace = value
+	}
+	return params
+}
+
+const injectionFields = 9
+
+// stagePrograms returns the program of every stage of a parsed command
+func stagePrograms(list *commandList) [][]string {
+	var programs [][]string
+	for _, p := range list.pipelines {
+		var names []string
+		for _, s := range p.stages {
+			if s.group != nil {
+				names = append(names, "(")
+				for _, nested := range stagePrograms(s.group) {
+					names = append(names, nested...)
+				}
+				names = append(names, ")")
+				continue
+			}
+			names = append(names, s.argv[0])
+		}
+		programs = append(programs, names)
+	}
+	return programs
+}
+
+// jqArguments returns the program argument of every jq stage of a parsed command
+func jqArguments(list *commandList) []string {
+	var programs []string
+	for _, p := range list.pipelines {
+		for _, s := range p.stages {
+			if s.group != nil {
+				programs = append(programs, jqArguments(s.group)...)
+			} else if s.argv[0] == "jq" {
+				programs = append(programs, s.argv[len(s.argv)-1])
+			}
+		}
+	}
+	return programs
+}
+
+// checkInjection asserts that a command built from a value accepted by input
+// validation is either rejected before running or has the shape of the command
+// built from a harmless value, with only lint-clean jq programs
+func checkInjection(t *testing.T, field int, value string, useJQ bool) {
+	params := injectionParams(field, value)
+	if value == "" || validation.ValidateQueryParams(params) != nil {
+		return
+	}
+	config := types.DefaultAuditQueryConfig()
+	config.UseJSONParsing = useJQ
+	command := BuildOcCommandWithConfig(params, config)
+	if validation.ValidateGeneratedCommand(command) != nil {
+		return
+	}
+	parsed, err := ParseCommand(command)
+	if err != nil {
+		return
+	}
+
+	baseline, err := ParseCommand(BuildOcCommandWithConfig(injectionParams(field, "x"), config))
+	if err != nil {
+		t.Fatalf("Failed to parse the baseline command: %v", err)
+	}
+	if got, want := stagePrograms(&parsed.list), stagePrograms(&baseline.list); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Field %d value %q changed the command shape to %v, want %v: %s", field, value, got, want, command)
+	}
+	for _, program := range jqArguments(&parsed.list) {
+		if err := validation.ValidateJQExpression(program); err != nil {
+			t.Fatalf("Field %d value %q produced an invalid jq program: %v: %s", field, value, err, program)
+		}
+		if _, err := exec.LookPath("jq"); err == nil {
+			// empty keeps jq from running the program, so only compile errors fail
+			if output, err := exec.Command("jq", "-n", "empty | ("+program+")").CombinedOutput(); err != nil {
+				t.Fatalf("Field %d value %q produced a jq program that does not compile: %s: %s", field, value, output, program)
+			}
+		}
+	}
+}
+
+// TestBuildOcCommand_HostileValues runs the injection checks over the hostile values
+func TestBuildOcCommand_HostileValues(t *testing.T) {
+	for field := 0; field < injectionFields; field++ {
+		for _, value := range hostileValues {
+			checkInjection(t, field, value, true)
+			checkInjection(t, field, value, false)
+		}
+	}
+}
+
+// FuzzBuildOcCommand feeds arbitrary parameter values through BuildOcCommand;
+// run with go test -fuzz=FuzzBuildOcCommand ./commands
+func FuzzBuildOcCommand(f *testing.F) {
+	for field := 0; field < injectionFields; field++ {
+		for _, value := range hostileValues {
+			f.Add(field, value, true)
+		}
+	}
+	f.Fuzz(func(t *testing.T, field int, value string, useJQ bool) {
+		if field < 0 {
+			field = -field
+		}
+		checkInjection(t, field%injectionFields, value, useJQ)
+	})
+}
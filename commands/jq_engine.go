@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/itchyny/gojq"
+)
+
+// jq engines that run the jq stages of generated commands
+const (
+	// JQEngineAuto runs the external jq binary when it is installed, since it is
+	// faster on large audit logs, and the built-in engine otherwise
+	JQEngineAuto = "auto"
+	// JQEngineExternal always runs the external jq binary
+	JQEngineExternal = "external"
+	// JQEngineBuiltin always runs jq programs in process with gojq
+	JQEngineBuiltin = "builtin"
+)
+
+var (
+	jqEngineMode      = JQEngineAuto
+	jqEngineModeMutex sync.RWMutex
+)
+
+// SetJQEngine selects how jq stages run: JQEngineAuto, JQEngineExternal or
+// JQEngineBuiltin
+func SetJQEngine(mode string) error {
+	switch mode {
+	case JQEngineAuto, JQEngineExternal, JQEngineBuiltin:
+	default:
+		return fmt.Errorf("invalid jq engine: %s", mode)
+	}
+	jqEngineModeMutex.Lock()
+	defer jqEngineModeMutex.Unlock()
+	jqEngineMode = mode
+	return nil
+}
+
+// JQEngineMode returns the jq engine mode set with SetJQEngine
+func JQEngineMode() string {
+	jqEngineModeMutex.RLock()
+	defer jqEngineModeMutex.RUnlock()
+	return jqEngineMode
+}
+
+// ActiveJQEngine returns the engine jq stages run with now, JQEngineExternal or
+// JQEngineBuiltin
+func ActiveJQEngine() string {
+	switch JQEngineMode() {
+	case JQEngineExternal:
+		return JQEngineExternal
+	case JQEngineBuiltin:
+		return JQEngineBuiltin
+	}
+	if _, err := exec.LookPath("jq"); err == nil {
+		return JQEngineExternal
+	}
+	return JQEngineBuiltin
+}
+
+// JQAvailable reports whether jq stages can run: always, unless the external
+// engine is required and jq is not installed
+func JQAvailable() bool {
+	if JQEngineMode() != JQEngineExternal {
+		return true
+	}
+	_, err := exec.LookPath("jq")
+	return err == nil
+}
+
+// builtinJQOptions are the jq options the built-in engine supports
+type builtinJQOptions struct {
+	raw, compact, nullInput, slurp, joinOutput, exitStatus bool
+}
+
+// runBuiltinJQ runs a jq stage with gojq. Like jq, it reads a stream of JSON
+// values, reports evaluation errors on stderr and carries on with the next
+// value, and stops at invalid input. Objects are printed with sorted keys.
+func runBuiltinJQ(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var options builtinJQOptions
+	var program string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			program = arg
+			continue
+		}
+		for _, flag := range arg[1:] {
+			switch flag {
+			case 'r':
+				options.raw = true
+			case 'c':
+				options.compact = true
+			case 'n':
+				options.nullInput = true
+			case 's':
+				options.slurp = true
+			case 'j':
+				options.raw, options.joinOutput = true, true
+			case 'e':
+				options.exitStatus = true
+			case 'M', 'S':
+				// Output is never colored, and keys are always sorted
+			default:
+				return builtinJQError(stderr, 2, "option -%c is not supported by the built-in jq", flag)
+			}
+		}
+	}
+
+	query, err := gojq.Parse(program)
+	if err != nil {
+		return builtinJQError(stderr, 3, "%v (compile error)", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return builtinJQError(stderr, 3, "%v (compile error)", err)
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	var last interface{}
+	var evaluationErr error
+	run := func(input interface{}) error {
+		iter := code.RunWithContext(ctx, input)
+		for {
+			value, ok := iter.Next()
+			if !ok {
+				return nil
+			}
+			if err, isErr := value.(error); isErr {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				out.Flush()
+				fmt.Fprintf(stderr, "jq: error: %v\n", err)
+				evaluationErr = err
+				return nil
+			}
+			last = value
+			if err := writeJQValue(out, value, options); err != nil {
+				return err
+			}
+		}
+	}
+
+	if options.nullInput {
+		if err := run(nil); err != nil {
+			return err
+		}
+	} else {
+		if stdin == nil {
+			stdin = strings.NewReader("")
+		}
+		decoder := json.NewDecoder(bufio.NewReader(stdin))
+		decoder.UseNumber()
+		var slurped []interface{}
+		for {
+			var input interface{}
+			if err := decoder.Decode(&input); err == io.EOF {
+				break
+			} else if err != nil {
+				out.Flush()
+				return builtinJQError(stderr, 2, "error: %v", err)
+			}
+			if options.slurp {
+				slurped = append(slurped, input)
+				continue
+			}
+			if err := run(input); err != nil {
+				return err
+			}
+		}
+		if options.slurp {
+			if slurped == nil {
+				slurped = []interface{}{}
+			}
+			if err := run(slurped); err != nil {
+				return err
+			}
+		}
+	}
+
+	if evaluationErr != nil {
+		return &builtinJQExitError{code: 5}
+	}
+	if options.exitStatus && (last == nil || last == false) {
+		return &builtinJQExitError{code: 1}
+	}
+	return nil
+}
+
+// writeJQValue prints a jq output value as jq does with the options
+func writeJQValue(out io.Writer, value interface{}, options builtinJQOptions) error {
+	var encoded []byte
+	if s, ok := value.(string); ok && options.raw {
+		encoded = []byte(s)
+	} else {
+		encoded, _ = gojq.Marshal(value)
+		if !options.compact {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, encoded, "", "  "); err == nil {
+				encoded = indented.Bytes()
+			}
+		}
+	}
+	if !options.joinOutput {
+		encoded = append(encoded, '\n')
+	}
+	_, err := out.Write(encoded)
+	return err
+}
+
+// builtinJQExitError is the exit status of a failed built-in jq stage
+type builtinJQExitError struct {
+	code int
+}
+
+func (e *builtinJQExitError) Error() string {
+	return fmt.Sprintf("jq: exit status %d", e.code)
+}
+
+// ExitCode returns the status the jq binary exits with in the same case
+func (e *builtinJQExitError) ExitCode() int {
+	return e.code
+}
+
+// builtinJQError writes a jq error message to stderr and returns the exit status
+func builtinJQError(stderr io.Writer, code int, format string, args ...interface{}) error {
+	fmt.Fprintf(stderr, "jq: "+format+"\n", args...)
+	return &builtinJQExitError{code: code}
+}
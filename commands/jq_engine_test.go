@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// jqTestEvents are audit events the jq engine tests filter
+var jqTestEvents = strings.Join([]string{
+	`{"auditID":"1","stage":"ResponseComplete","verb":"delete","user":{"username":"alice","groups":["system:authenticated"]},"objectRef":{"resource":"secrets","namespace":"payments","name":"db"},"responseStatus":{"code":200},"requestReceivedTimestamp":"2024-05-01T10:00:00.000000Z","sourceIPs":["10.0.0.1"]}`,
+	`{"auditID":"2","stage":"ResponseComplete","verb":"get","user":{"username":"bob"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":403,"message":"forbidden"},"requestReceivedTimestamp":"2024-05-01T11:00:00.000000Z"}`,
+	`{"auditID":"3","stage":"ResponseComplete","verb":"create","user":{"username":"system:serviceaccount:ci:deployer"},"impersonatedUser":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"payments"},"responseStatus":{"code":201},"requestReceivedTimestamp":"2024-05-02T09:00:00.000000Z","userAgent":"kubectl/v1.29.0"}`,
+}, "\n") + "\n"
+
+// useJQEngine selects a jq engine for the test
+func useJQEngine(t *testing.T, mode string) {
+	t.Helper()
+	previous := JQEngineMode()
+	if err := SetJQEngine(mode); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetJQEngine(previous) })
+}
+
+// decodeJQOutput decodes a stream of JSON values
+func decodeJQOutput(t *testing.T, output string) []interface{} {
+	t.Helper()
+	var values []interface{}
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); err == io.EOF {
+			return values
+		} else if err != nil {
+			t.Fatalf("Invalid jq output %q: %v", output, err)
+		}
+		values = append(values, value)
+	}
+}
+
+// TestBuiltinJQ_MatchesExternal tests that the built-in engine selects and
+// formats the same events as the jq binary for generated programs
+func TestBuiltinJQ_MatchesExternal(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+	builder := NewCommandBuilder()
+	builder.Config.UseJSONParsing = true
+
+	cases := []types.AuditQueryParams{
+		{LogSource: "kube-apiserver"},
+		{LogSource: "kube-apiserver", Username: "alice", Verb: "delete"},
+		{LogSource: "kube-apiserver", Namespace: "payments", ExcludeUsers: []string{"system:serviceaccount:ci:*"}},
+		{LogSource: "kube-apiserver", StatusCodeRange: "4xx"},
+		{LogSource: "kube-apiserver", ImpersonatedUser: "alice", Patterns: []string{"deployments"}},
+		{LogSource: "kube-apiserver", Groups: []string{"system:authenticated"}, Exclude: []string{"bob"}},
+		{LogSource: "kube-apiserver", UserAgent: "kubectl"},
+	}
+	for _, params := range cases {
+		parsed, err := ParseCommand(builder.buildJSONAwareCommand(params))
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := parsed.list.pipelines[0].stages[1].argv[1:]
+
+		var builtin strings.Builder
+		if err := runBuiltinJQ(context.Background(), args, strings.NewReader(jqTestEvents), &builtin, io.Discard); err != nil {
+			t.Fatalf("Built-in jq failed for %+v: %v", params, err)
+		}
+
+		cmd := exec.Command("jq", args...)
+		cmd.Stdin = strings.NewReader(jqTestEvents)
+		external, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("jq failed for %+v: %v", params, err)
+		}
+		if got, want := decodeJQOutput(t, builtin.String()), decodeJQOutput(t, string(external)); !reflect.DeepEqual(got, want) {
+			t.Errorf("Built-in jq output differs for %+v:\n%s\nwant:\n%s", params, builtin.String(), external)
+		}
+		if strings.Count(builtin.String(), "\n") != strings.Count(string(external), "\n") {
+			t.Errorf("Built-in jq formatting differs for %+v:\n%s\nwant:\n%s", params, builtin.String(), external)
+		}
+	}
+}
+
+// TestBuiltinJQ_Options tests output options, errors and exit statuses
+func TestBuiltinJQ_Options(t *testing.T) {
+	run := func(input string, args ...string) (string, string, error) {
+		var stdout, stderr strings.Builder
+		err := runBuiltinJQ(context.Background(), args, strings.NewReader(input), &stdout, &stderr)
+		return stdout.String(), stderr.String(), err
+	}
+
+	if out, _, err := run(`{"a":"x","b":[1,2]}`, "-r", ".a"); err != nil || out != "x\n" {
+		t.Errorf("Unexpected raw output %q, %v", out, err)
+	}
+	if out, _, err := run(`{"b":[1,2],"a":"<&>"}`, "-c", "."); err != nil || out != `{"a":"<&>","b":[1,2]}`+"\n" {
+		t.Errorf("Unexpected compact output %q, %v", out, err)
+	}
+	if out, _, err := run(`{"a":{"b":1}}`, "."); err != nil || out != "{\n  \"a\": {\n    \"b\": 1\n  }\n}\n" {
+		t.Errorf("Unexpected indented output %q, %v", out, err)
+	}
+	if out, _, err := run("1 2 3", "-s", "-c", "."); err != nil || out != "[1,2,3]\n" {
+		t.Errorf("Unexpected slurped output %q, %v", out, err)
+	}
+	if out, _, err := run("", "-n", "-j", `"a", "b"`); err != nil || out != "ab" {
+		t.Errorf("Unexpected joined output %q, %v", out, err)
+	}
+	if out, _, err := run(`{"big":12345678901234567890}`, "-c", ".big"); err != nil || out != "12345678901234567890\n" {
+		t.Errorf("Unexpected number output %q, %v", out, err)
+	}
+
+	// Evaluation errors are reported and the next input is still filtered
+	out, stderr, err := run(`{"a":"x"} {"a":1} {"a":"y"}`, "-r", `.a | test("x|y")`)
+	if out != "true\ntrue\n" || !strings.Contains(stderr, "jq: error") || err == nil || err.(*builtinJQExitError).ExitCode() != 5 {
+		t.Errorf("Unexpected evaluation error handling: %q, %q, %v", out, stderr, err)
+	}
+
+	// Invalid input stops with status 2 and compile errors with status 3
+	if out, _, err := run("{\"a\":1}\nnot json\n{\"a\":2}", "-c", ".a"); out != "1\n" || err.(*builtinJQExitError).ExitCode() != 2 {
+		t.Errorf("Unexpected invalid input handling: %q, %v", out, err)
+	}
+	if _, stderr, err := run("{}", "select(("); err.(*builtinJQExitError).ExitCode() != 3 || !strings.Contains(stderr, "compile error") {
+		t.Errorf("Unexpected compile error handling: %q, %v", stderr, err)
+	}
+	if _, _, err := run(`{"a":false}`, "-e", ".a"); err == nil || err.(*builtinJQExitError).ExitCode() != 1 {
+		t.Errorf("Expected exit status 1 for a false result with -e, got %v", err)
+	}
+	if _, _, err := run("{}", "-a", "."); err == nil {
+		t.Error("Expected an unsupported option to fail")
+	}
+
+	// The built-in engine has no access to the environment or other inputs
+	if out, _, _ := run("{}", "-c", "env"); out != "{}\n" {
+		t.Errorf("Expected an empty environment, got %q", out)
+	}
+	if _, _, err := run("{} {}", "input"); err == nil {
+		t.Error("Expected input to fail")
+	}
+}
+
+// TestJQEngineSelection tests choosing between the jq binary and the built-in engine
+func TestJQEngineSelection(t *testing.T) {
+	if err := SetJQEngine("gojq"); err == nil {
+		t.Error("Expected an invalid engine to be rejected")
+	}
+
+	// Without jq on PATH, auto selects the built-in engine and commands keep
+	// filtering with jq programs
+	t.Setenv("PATH", t.TempDir())
+	useJQEngine(t, JQEngineAuto)
+	if engine := ActiveJQEngine(); engine != JQEngineBuiltin {
+		t.Errorf("Expected the built-in engine, got %s", engine)
+	}
+	builder := NewCommandBuilder()
+	builder.Config.UseJSONParsing = true
+	command := builder.buildSimpleCommand(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete"})
+	if !strings.Contains(command, "| jq -r") {
+		t.Errorf("Expected a jq command, got %s", command)
+	}
+
+	// A jq stage runs in process
+	var output strings.Builder
+	installFakeOc(t, "#!/bin/sh\nprintf '%s' '"+strings.ReplaceAll(jqTestEvents, "'", "")+"'\n")
+	if err := RunCommand(context.Background(), command, &output, &output); err != nil {
+		t.Fatalf("Unexpected error: %v: %s", err, output.String())
+	}
+	if values := decodeJQOutput(t, output.String()); len(values) != 1 || values[0].(map[string]interface{})["auditID"] != "1" {
+		t.Errorf("Unexpected output %s", output.String())
+	}
+
+	// Requiring the jq binary without one falls back to grep
+	useJQEngine(t, JQEngineExternal)
+	if JQAvailable() {
+		t.Error("Expected jq to be unavailable")
+	}
+	if command := builder.buildSimpleCommand(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete"}); strings.Contains(command, "jq") {
+		t.Errorf("Expected a grep command, got %s", command)
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"path"
+	"strings"
+)
+
+// logSourceAlternatives lists, per log source, the sources that record related
+// events, most similar first
+var logSourceAlternatives = map[string][]string{
+	"kube-apiserver":      {"openshift-apiserver", "oauth-apiserver"},
+	"oauth-server":        {"oauth-apiserver", "kube-apiserver"},
+	"oauth-apiserver":     {"oauth-server", "kube-apiserver"},
+	"openshift-apiserver": {"kube-apiserver"},
+	"node":                {"kube-apiserver"},
+}
+
+// LogSourceDirectory returns the node log directory a log source is read from,
+// with a trailing slash so oc lists its files
+func LogSourceDirectory(logSource string) string {
+	return path.Dir(getLogBasePath(logSource)) + "/"
+}
+
+// LogSourceProbeArgs returns the oc arguments that list a log source's directory
+// on every master node
+func LogSourceProbeArgs(logSource string) []string {
+	return []string{"adm", "node-logs", "--role=master", "--path=" + LogSourceDirectory(logSource)}
+}
+
+// ParseLogSourceProbe returns the audit log files of a log source per node from
+// the output of the LogSourceProbeArgs command, which prints one "node file"
+// line per file. Files that are not the source's audit logs are ignored.
+func ParseLogSourceProbe(logSource, output string) map[string][]string {
+	prefix := path.Base(getLogBasePath(logSource))
+	nodes := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		node, file := fields[0], path.Base(fields[1])
+		if strings.HasPrefix(file, prefix) && strings.Contains(file, ".log") {
+			nodes[node] = append(nodes[node], file)
+		}
+	}
+	return nodes
+}
+
+// LogSourceAlternatives returns the log sources to suggest when logSource is not
+// available
+func LogSourceAlternatives(logSource string) []string {
+	return append([]string(nil), logSourceAlternatives[logSource]...)
+}
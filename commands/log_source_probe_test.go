@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLogSourceProbeArgs tests the directory listed for each log source
+func TestLogSourceProbeArgs(t *testing.T) {
+	tests := map[string]string{
+		"kube-apiserver": "--path=kube-apiserver/",
+		"oauth-server":   "--path=oauth-server/",
+		"node":           "--path=audit/",
+	}
+	for logSource, expected := range tests {
+		args := strings.Join(LogSourceProbeArgs(logSource), " ")
+		if args != "adm node-logs --role=master "+expected {
+			t.Errorf("Unexpected probe args for %s: %s", logSource, args)
+		}
+	}
+}
+
+// TestParseLogSourceProbe tests that only the source's audit log files are kept per node
+func TestParseLogSourceProbe(t *testing.T) {
+	output := strings.Join([]string{
+		"master-0 audit.log",
+		"master-0 audit-2024-01-15T10-00-00.000.log",
+		"master-0 termination.log",
+		"master-1 audit.log",
+		"error: something unrelated happened",
+		"",
+	}, "\n")
+
+	nodes := ParseLogSourceProbe("kube-apiserver", output)
+	expected := map[string][]string{
+		"master-0": {"audit.log", "audit-2024-01-15T10-00-00.000.log"},
+		"master-1": {"audit.log"},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, got %v", expected, nodes)
+	}
+
+	if nodes := ParseLogSourceProbe("oauth-server", "master-0 termination.log\n"); len(nodes) != 0 {
+		t.Errorf("Expected no audit logs, got %v", nodes)
+	}
+}
+
+// TestLogSourceAlternatives tests the suggestions for a missing log source
+func TestLogSourceAlternatives(t *testing.T) {
+	alternatives := LogSourceAlternatives("oauth-server")
+	if !reflect.DeepEqual(alternatives, []string{"oauth-apiserver", "kube-apiserver"}) {
+		t.Errorf("Unexpected alternatives: %v", alternatives)
+	}
+
+	// The returned slice is a copy
+	alternatives[0] = "changed"
+	if LogSourceAlternatives("oauth-server")[0] != "oauth-apiserver" {
+		t.Error("Expected alternatives to be copied")
+	}
+	if len(LogSourceAlternatives("unknown")) != 0 {
+		t.Error("Expected no alternatives for an unknown log source")
+	}
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// jq field expressions used by the username, resource, verb, namespace, user agent
+// and subresource filters
+const (
+	jqUsernameField    = `(.user.username // .userInfo.username // .impersonatedUser // .requestUser)`
+	jqResourceField    = `(.objectRef.resource // .objectRef.apiVersion // .requestObject.kind // .responseObject.kind)`
+	jqVerbField        = `.verb`
+	jqNamespaceField   = `(.objectRef.namespace // .requestObject.metadata.namespace // .responseObject.metadata.namespace)`
+	jqUserAgentField   = `.userAgent`
+	jqSubresourceField = `.objectRef.subresource`
+)
+
+// matchModeRegex converts a filter value to a regular expression for the given mode
+func matchModeRegex(value string, mode types.MatchMode) string {
+	switch mode {
+	case types.MatchModeExact:
+		return "^" + regexp.QuoteMeta(value) + "$"
+	case types.MatchModePrefix:
+		return "^" + regexp.QuoteMeta(value)
+	case types.MatchModeRegex:
+		return value
+	default:
+		return regexp.QuoteMeta(value)
+	}
+}
+
+// userAgentMatchMode returns the user agent match mode, defaulting to substring
+func userAgentMatchMode(params types.AuditQueryParams) types.MatchMode {
+	if params.UserAgentMatch == "" {
+		return types.MatchModeSubstring
+	}
+	return params.UserAgentMatch
+}
+
+// jqStringLiteral encodes a value as a jq string literal; single quotes are
+// escaped so the jq program stays one shell-quoted word
+func jqStringLiteral(value string) string {
+	encoded, _ := json.Marshal(value)
+	return strings.ReplaceAll(string(encoded), "'", `\u0027`)
+}
+
+// fieldValues combines a single-value field filter with its multi-value list
+func fieldValues(value string, values []string) []string {
+	if value == "" {
+		return values
+	}
+	return append([]string{value}, values...)
+}
+
+// buildJQFieldMatch builds a jq clause matching a field expression with the given mode
+func buildJQFieldMatch(field, value string, mode types.MatchMode) string {
+	return buildJQFieldMatchAny(field, []string{value}, mode)
+}
+
+// buildJQFieldMatchAny builds a jq clause matching a field expression against any of
+// the values. Without a mode the values are matched as case-insensitive substrings.
+func buildJQFieldMatchAny(field string, values []string, mode types.MatchMode) string {
+	regex, caseInsensitive := FieldMatchRegex(values, mode)
+	flags := ""
+	if caseInsensitive {
+		flags = `; "i"`
+	}
+	return fmt.Sprintf(`(%s // "" | test(%s%s))`, field, jqStringLiteral(regex), flags)
+}
+
+// FieldMatchRegex returns the regular expression a field filter applies for the values
+// and mode, and whether it matches case-insensitively. Without a mode the values are
+// matched as case-insensitive substrings.
+func FieldMatchRegex(values []string, mode types.MatchMode) (string, bool) {
+	if mode == "" {
+		mode = types.MatchModeSubstring
+	}
+	regexes := make([]string, len(values))
+	for i, value := range values {
+		regexes[i] = matchModeRegex(value, mode)
+	}
+	return strings.Join(regexes, "|"), mode == types.MatchModeSubstring
+}
+
+// buildGrepFieldMatch builds an extended grep stage matching a JSON string field with the given mode
+func buildGrepFieldMatch(key, value string, mode types.MatchMode) string {
+	return buildGrepFieldMatchAny(key, []string{value}, mode)
+}
+
+// buildGrepFieldMatchAny builds an extended grep stage matching a JSON string field
+// against any of the values. Without a mode the values are matched as case-insensitive substrings.
+func buildGrepFieldMatchAny(key string, values []string, mode types.MatchMode) string {
+	if mode == "" {
+		mode = types.MatchModeSubstring
+	}
+	fragments := make([]string, len(values))
+	for i, value := range values {
+		fragments[i] = grepFieldFragment(value, mode)
+	}
+	fragment := fragments[0]
+	if len(fragments) > 1 {
+		fragment = "(" + strings.Join(fragments, "|") + ")"
+	}
+	flags := "-E"
+	if mode == types.MatchModeSubstring {
+		flags = "-iE"
+	}
+	return fmt.Sprintf(`| grep %s '"%s":"%s"'`, flags, key, fragment)
+}
+
+// grepFieldFragment converts a value to the regex matched between the quotes of a JSON string field
+func grepFieldFragment(value string, mode types.MatchMode) string {
+	switch mode {
+	case types.MatchModeExact:
+		return regexp.QuoteMeta(value)
+	case types.MatchModePrefix:
+		return regexp.QuoteMeta(value) + `[^"]*`
+	case types.MatchModeRegex:
+		// The surrounding quotes anchor the value, so unanchored ends may match
+		// any characters. The group keeps an alternation such as admin|root
+		// between the padding, rather than matching "root" anywhere in the line.
+		fragment := "(" + strings.TrimSuffix(strings.TrimPrefix(value, "^"), "$") + ")"
+		if !strings.HasPrefix(value, "^") {
+			fragment = `[^"]*` + fragment
+		}
+		if !strings.HasSuffix(value, "$") {
+			fragment += `[^"]*`
+		}
+		return fragment
+	default:
+		return `[^"]*` + regexp.QuoteMeta(value) + `[^"]*`
+	}
+}
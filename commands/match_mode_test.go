@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestBuildJQFieldMatch tests jq clauses for each match mode
+func TestBuildJQFieldMatch(t *testing.T) {
+	tests := []struct {
+		mode     types.MatchMode
+		value    string
+		expected string
+	}{
+		{types.MatchModeExact, "admin", `(.verb // "" | test("^admin$"))`},
+		{types.MatchModePrefix, "system:serviceaccount:", `(.verb // "" | test("^system:serviceaccount:"))`},
+		{types.MatchModeSubstring, "admin", `(.verb // "" | test("admin"; "i"))`},
+		{types.MatchModeRegex, "^(get|list)$", `(.verb // "" | test("^(get|list)$"))`},
+		{types.MatchModeExact, "a.b", `(.verb // "" | test("^a\\.b$"))`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"_"+tt.value, func(t *testing.T) {
+			if got := buildJQFieldMatch(jqVerbField, tt.value, tt.mode); got != tt.expected {
+				t.Errorf("buildJQFieldMatch() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildGrepFieldMatch tests grep stages for each match mode
+func TestBuildGrepFieldMatch(t *testing.T) {
+	tests := []struct {
+		mode     types.MatchMode
+		value    string
+		expected string
+	}{
+		{types.MatchModeExact, "admin", `| grep -E '"username":"admin"'`},
+		{types.MatchModePrefix, "system:", `| grep -E '"username":"system:[^"]*"'`},
+		{types.MatchModeSubstring, "admin", `| grep -iE '"username":"[^"]*admin[^"]*"'`},
+		{types.MatchModeRegex, "^adm.n$", `| grep -E '"username":"(adm.n)"'`},
+		{types.MatchModeRegex, "adm", `| grep -E '"username":"[^"]*(adm)[^"]*"'`},
+		{types.MatchModeRegex, "admin|root", `| grep -E '"username":"[^"]*(admin|root)[^"]*"'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"_"+tt.value, func(t *testing.T) {
+			if got := buildGrepFieldMatch("username", tt.value, tt.mode); got != tt.expected {
+				t.Errorf("buildGrepFieldMatch() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildGrepFieldMatch_Alternation tests that a regex alternation only
+// matches within the field, like the jq and in-process filters
+func TestBuildGrepFieldMatch_Alternation(t *testing.T) {
+	stage := buildGrepFieldMatch("username", "admin|root", types.MatchModeRegex)
+	pattern := regexp.MustCompile(strings.TrimSuffix(strings.TrimPrefix(stage, "| grep -E '"), "'"))
+	for line, expected := range map[string]bool{
+		`{"user":{"username":"kube:admin"},"verb":"get"}`:                      true,
+		`{"user":{"username":"root-operator"},"verb":"get"}`:                   true,
+		`{"user":{"username":"alice"},"objectRef":{"name":"root-ca"}}`:         false,
+		`{"user":{"username":"alice"},"requestURI":"/api/v1/namespaces/root"}`: false,
+	} {
+		if pattern.MatchString(line) != expected {
+			t.Errorf("Expected %s to match %t with %s", line, expected, stage)
+		}
+	}
+}
+
+// TestBuildOcCommand_MatchModes tests that match modes replace the legacy field filters
+func TestBuildOcCommand_MatchModes(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource:     "kube-apiserver",
+		Username:      "admin",
+		UsernameMatch: types.MatchModeExact,
+		Verb:          "delete",
+	}
+
+	builder := NewCommandBuilder()
+	jqCommand := builder.buildJSONAwareCommand(params)
+	if !strings.Contains(jqCommand, `test("^admin$")`) {
+		t.Errorf("Expected exact username match in jq command, got: %s", jqCommand)
+	}
+	if !strings.Contains(jqCommand, `.verb | test("delete"; "i")`) {
+		t.Errorf("Expected legacy verb match without a mode, got: %s", jqCommand)
+	}
+
+	builder.Config.UseJSONParsing = false
+	grepCommand := builder.BuildOptimalCommand(params)
+	if !strings.Contains(grepCommand, `| grep -E '"username":"admin"'`) {
+		t.Errorf("Expected exact username match in grep command, got: %s", grepCommand)
+	}
+	if strings.Contains(grepCommand, `"impersonatedUser"`) {
+		t.Errorf("Expected legacy username filters to be replaced, got: %s", grepCommand)
+	}
+	if !strings.Contains(grepCommand, BuildVerbFilter("delete")) {
+		t.Errorf("Expected legacy verb filter without a mode, got: %s", grepCommand)
+	}
+}
+
+// TestBuildOcCommand_UserAgent tests user agent filters with the default and regex modes
+func TestBuildOcCommand_UserAgent(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Verb:      "delete",
+		UserAgent: "kubectl",
+	}
+
+	builder := NewCommandBuilder()
+	if command := builder.buildJSONAwareCommand(params); !strings.Contains(command, `(.userAgent // "" | test("kubectl"; "i"))`) {
+		t.Errorf("Expected substring user agent match in jq command, got: %s", command)
+	}
+
+	params.UserAgent = "-operator/"
+	params.UserAgentMatch = types.MatchModeRegex
+	if command := builder.buildJSONAwareCommand(params); !strings.Contains(command, `(.userAgent // "" | test("-operator/"))`) {
+		t.Errorf("Expected regex user agent match in jq command, got: %s", command)
+	}
+
+	builder.Config.UseJSONParsing = false
+	if command := builder.BuildOptimalCommand(params); !strings.Contains(command, `| grep -E '"userAgent":"[^"]*(-operator/)[^"]*"'`) {
+		t.Errorf("Expected user agent stage in grep command, got: %s", command)
+	}
+}
+
+// TestBuildFieldMatchAny tests multi-value field matches for both back-ends
+func TestBuildFieldMatchAny(t *testing.T) {
+	tests := []struct {
+		mode         types.MatchMode
+		values       []string
+		expectedJQ   string
+		expectedGrep string
+	}{
+		{
+			mode:         "",
+			values:       []string{"database", "customer-service"},
+			expectedJQ:   `(.verb // "" | test("database|customer-service"; "i"))`,
+			expectedGrep: `| grep -iE '"namespace":"([^"]*database[^"]*|[^"]*customer-service[^"]*)"'`,
+		},
+		{
+			mode:         types.MatchModeExact,
+			values:       []string{"admin", "system:admin"},
+			expectedJQ:   `(.verb // "" | test("^admin$|^system:admin$"))`,
+			expectedGrep: `| grep -E '"namespace":"(admin|system:admin)"'`,
+		},
+		{
+			mode:         types.MatchModePrefix,
+			values:       []string{"openshift-"},
+			expectedJQ:   `(.verb // "" | test("^openshift-"))`,
+			expectedGrep: `| grep -E '"namespace":"openshift-[^"]*"'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"_"+strings.Join(tt.values, ","), func(t *testing.T) {
+			if got := buildJQFieldMatchAny(jqVerbField, tt.values, tt.mode); got != tt.expectedJQ {
+				t.Errorf("buildJQFieldMatchAny() = %s, want %s", got, tt.expectedJQ)
+			}
+			if got := buildGrepFieldMatchAny("namespace", tt.values, tt.mode); got != tt.expectedGrep {
+				t.Errorf("buildGrepFieldMatchAny() = %s, want %s", got, tt.expectedGrep)
+			}
+		})
+	}
+}
+
+// TestBuildOcCommand_MultiValue tests that list parameters combine with single values
+func TestBuildOcCommand_MultiValue(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource:  "kube-apiserver",
+		Namespace:  "database",
+		Namespaces: []string{"customer-service"},
+		Verbs:      []string{"delete"},
+	}
+
+	builder := NewCommandBuilder()
+	jqCommand := builder.buildJSONAwareCommand(params)
+	if !strings.Contains(jqCommand, `test("database|customer-service"; "i")`) {
+		t.Errorf("Expected combined namespace alternation in jq command, got: %s", jqCommand)
+	}
+	if !strings.Contains(jqCommand, `.verb | test("delete"; "i")`) {
+		t.Errorf("Expected a single list value to keep the legacy match, got: %s", jqCommand)
+	}
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// requiredPermissions lists the RBAC permissions the server's oc commands need
+var requiredPermissions = []types.PermissionCheck{
+	{Verb: "list", Resource: "nodes", RequiredFor: "oc adm node-logs --role=master finds the master nodes"},
+	{Verb: "get", Resource: "nodes", Subresource: "proxy", RequiredFor: "oc adm node-logs reads audit logs through the node proxy"},
+	{Verb: "get", Resource: "apiservers", Group: "config.openshift.io", RequiredFor: "get_audit_configuration reads the APIServer audit profile"},
+}
+
+// RequiredPermissions returns the RBAC permissions audit queries and tools need,
+// not yet checked
+func RequiredPermissions() []types.PermissionCheck {
+	return append([]types.PermissionCheck(nil), requiredPermissions...)
+}
+
+// PermissionName names a permission as verb and resource, e.g. "get nodes/proxy"
+// or "get apiservers.config.openshift.io"
+func PermissionName(check types.PermissionCheck) string {
+	resource := check.Resource
+	if check.Group != "" {
+		resource += "." + check.Group
+	}
+	if check.Subresource != "" {
+		resource += "/" + check.Subresource
+	}
+	return check.Verb + " " + resource
+}
+
+// ClientVersionArgs returns the oc arguments that print the client version
+func ClientVersionArgs() []string {
+	return []string{"version", "--client"}
+}
+
+// WhoAmIArgs returns the oc arguments that print the current identity
+func WhoAmIArgs() []string {
+	return []string{"whoami"}
+}
+
+// CanIArgs returns the oc arguments that check a permission with a
+// SelfSubjectAccessReview
+func CanIArgs(check types.PermissionCheck) []string {
+	resource := check.Resource
+	if check.Group != "" {
+		resource += "." + check.Group
+	}
+	args := []string{"auth", "can-i", check.Verb, resource}
+	if check.Subresource != "" {
+		args = append(args, "--subresource="+check.Subresource)
+	}
+	return args
+}
+
+// ParseCanI returns whether the output of the CanIArgs command allows the
+// permission. oc prints yes or no, possibly after warnings, and exits with an
+// error for no, so the output is parsed whatever the exit status.
+func ParseCanI(output string) (bool, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	answer := strings.ToLower(strings.TrimSpace(lines[len(lines)-1]))
+	switch {
+	case answer == "yes":
+		return true, nil
+	case answer == "no" || strings.HasPrefix(answer, "no "):
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected oc auth can-i output: %s", strings.TrimSpace(output))
+	}
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCanIArgs tests the oc auth can-i arguments and names of the required permissions
+func TestCanIArgs(t *testing.T) {
+	expected := map[string]string{
+		"list nodes":                         "auth can-i list nodes",
+		"get nodes/proxy":                    "auth can-i get nodes --subresource=proxy",
+		"get apiservers.config.openshift.io": "auth can-i get apiservers.config.openshift.io",
+	}
+	permissions := RequiredPermissions()
+	if len(permissions) != len(expected) {
+		t.Fatalf("Expected %d permissions, got %d", len(expected), len(permissions))
+	}
+	for _, permission := range permissions {
+		name := PermissionName(permission)
+		args, ok := expected[name]
+		if !ok {
+			t.Errorf("Unexpected permission %s", name)
+			continue
+		}
+		if got := strings.Join(CanIArgs(permission), " "); got != args {
+			t.Errorf("Unexpected args for %s: %s", name, got)
+		}
+		if permission.RequiredFor == "" {
+			t.Errorf("Permission %s does not say what it is required for", name)
+		}
+	}
+
+	// Callers may modify the returned permissions
+	permissions[0].Allowed = true
+	if RequiredPermissions()[0].Allowed {
+		t.Error("RequiredPermissions returned the shared slice")
+	}
+}
+
+// TestParseCanI tests reading oc auth can-i answers
+func TestParseCanI(t *testing.T) {
+	tests := map[string]bool{
+		"yes\n": true,
+		"no\n":  false,
+		"no - RBAC: clusterrole.rbac.authorization.k8s.io \"audit-reader\" not found\n":                  false,
+		"Warning: resource 'apiservers' is not namespace scoped in group 'config.openshift.io'\n\nyes\n": true,
+	}
+	for output, expected := range tests {
+		allowed, err := ParseCanI(output)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", output, err)
+		}
+		if allowed != expected {
+			t.Errorf("Expected %t for %q, got %t", expected, output, allowed)
+		}
+	}
+
+	for _, output := range []string{"", "error: You must be logged in to the server (Unauthorized)"} {
+		if _, err := ParseCanI(output); err == nil {
+			t.Errorf("Expected an error for %q", output)
+		}
+	}
+}
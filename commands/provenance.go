@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// nodeRolePattern matches the node role oc adm node-logs reads logs of
+var nodeRolePattern = regexp.MustCompile(`--role=(\S+)`)
+
+// ServerURLArgs returns the oc arguments that print the API server URL of
+// the current context
+func ServerURLArgs() []string {
+	return []string{"whoami", "--show-server"}
+}
+
+// RoleNodesArgs returns the read-only oc arguments that list the nodes of a
+// role, the nodes oc adm node-logs --role=<role> reads
+func RoleNodesArgs(role string) []string {
+	return []string{"get", "nodes", "-l", "node-role.kubernetes.io/" + role, "-o", "name"}
+}
+
+// ParseNodeNames returns the node names printed by the RoleNodesArgs command
+func ParseNodeNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimPrefix(strings.TrimSpace(line), "node/"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CommandNodeRole returns the node role a generated command reads logs of,
+// e.g. "master", or "" when it selects none
+func CommandNodeRole(command string) string {
+	if match := nodeRolePattern.FindStringSubmatch(command); len(match) > 1 {
+		return match[1]
+	}
+	return ""
+}
+
+// CommandLogPaths returns the log file paths a generated command reads, in
+// command order and without repeats. Multi-file commands name one per file.
+func CommandLogPaths(command string) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, match := range pathFlagPattern.FindAllStringSubmatch(command, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			paths = append(paths, match[1])
+		}
+	}
+	return paths
+}
+
+// CommandHash returns the SHA-256 of a command, identifying exactly what ran
+func CommandHash(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	return hex.EncodeToString(sum[:])
+}
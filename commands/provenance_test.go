@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCommandLogPaths tests reading the log paths and node role of generated commands
+func TestCommandLogPaths(t *testing.T) {
+	command := "(oc adm node-logs --role=master --path=kube-apiserver/audit-2026-01-01T00-00-00.000.log | grep -i delete && " +
+		"oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i delete && " +
+		"oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i delete)"
+
+	expected := []string{"kube-apiserver/audit-2026-01-01T00-00-00.000.log", "kube-apiserver/audit.log"}
+	if paths := CommandLogPaths(command); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+	if role := CommandNodeRole(command); role != "master" {
+		t.Errorf("Expected the master role, got %q", role)
+	}
+
+	if paths := CommandLogPaths("loki {job=\"audit\"}"); paths != nil {
+		t.Errorf("Expected no paths for a provider query, got %v", paths)
+	}
+	if role := CommandNodeRole("loki {job=\"audit\"}"); role != "" {
+		t.Errorf("Expected no role for a provider query, got %q", role)
+	}
+}
+
+// TestParseNodeNames tests parsing the nodes listed by RoleNodesArgs
+func TestParseNodeNames(t *testing.T) {
+	names := ParseNodeNames("node/master-0\nnode/master-1\n\n")
+	if !reflect.DeepEqual(names, []string{"master-0", "master-1"}) {
+		t.Errorf("Unexpected node names: %v", names)
+	}
+	if args := RoleNodesArgs("master"); !reflect.DeepEqual(args, []string{"get", "nodes", "-l", "node-role.kubernetes.io/master", "-o", "name"}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+// TestCommandHash tests that command hashes are stable SHA-256 hex digests
+func TestCommandHash(t *testing.T) {
+	hash := CommandHash("oc adm node-logs --role=master --path=kube-apiserver/audit.log")
+	if len(hash) != 64 || hash != CommandHash("oc adm node-logs --role=master --path=kube-apiserver/audit.log") {
+		t.Errorf("Expected a stable SHA-256 digest, got %s", hash)
+	}
+	if hash == CommandHash("oc adm node-logs --role=master --path=openshift-apiserver/audit.log") {
+		t.Error("Expected different commands to hash differently")
+	}
+}
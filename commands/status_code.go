@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// jqStatusCodeBinding binds the response status code for status code clauses
+const jqStatusCodeBinding = `(.responseStatus.code // 0) as $code | `
+
+// buildJQStatusCodeFilters builds jq clauses for the status code parameters
+func buildJQStatusCodeFilters(params types.AuditQueryParams) []string {
+	var filters []string
+
+	if params.StatusCode != 0 {
+		filters = append(filters, fmt.Sprintf("%s$code == %d", jqStatusCodeBinding, params.StatusCode))
+	}
+
+	if params.StatusCodeRange != "" {
+		codes, low, high, err := utils.ParseStatusCodeRange(params.StatusCodeRange)
+		switch {
+		case err != nil:
+			// Invalid ranges are rejected by validation; skip rather than match nothing
+		case len(codes) > 0:
+			filters = append(filters, fmt.Sprintf("%s[%s] | any(. == $code)", jqStatusCodeBinding, joinInts(codes, ", ")))
+		default:
+			filters = append(filters, fmt.Sprintf("%s$code >= %d and $code <= %d", jqStatusCodeBinding, low, high))
+		}
+	}
+
+	return filters
+}
+
+// buildGrepStatusCodeFilters builds grep stages for the status code parameters
+func buildGrepStatusCodeFilters(params types.AuditQueryParams) []string {
+	var stages []string
+
+	if params.StatusCode != 0 {
+		stages = append(stages, fmt.Sprintf(`| grep -E '"code":%d[,}]'`, params.StatusCode))
+	}
+
+	if params.StatusCodeRange != "" {
+		codes, low, high, err := utils.ParseStatusCodeRange(params.StatusCodeRange)
+		if err == nil {
+			alternatives := joinInts(codes, "|")
+			if len(codes) == 0 {
+				alternatives = strings.Join(statusCodeRangeAlternatives(low, high), "|")
+			}
+			stages = append(stages, fmt.Sprintf(`| grep -E '"code":(%s)[,}]'`, alternatives))
+		}
+	}
+
+	return stages
+}
+
+// statusCodeRangeAlternatives returns regex alternatives matching the three-digit
+// codes from low to high, collapsing fully covered hundreds to a single class
+func statusCodeRangeAlternatives(low, high int) []string {
+	var alternatives []string
+	for hundred := low / 100; hundred <= high/100; hundred++ {
+		start, end := hundred*100, hundred*100+99
+		if low <= start && high >= end {
+			alternatives = append(alternatives, fmt.Sprintf("%d[0-9]{2}", hundred))
+			continue
+		}
+		for code := start; code <= end; code++ {
+			if code >= low && code <= high {
+				alternatives = append(alternatives, strconv.Itoa(code))
+			}
+		}
+	}
+	return alternatives
+}
+
+// joinInts joins integers with the given separator
+func joinInts(values []int, sep string) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestBuildJQStatusCodeFilters tests jq clauses for status code parameters
+func TestBuildJQStatusCodeFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   types.AuditQueryParams
+		expected []string
+	}{
+		{
+			name:     "single code",
+			params:   types.AuditQueryParams{StatusCode: 403},
+			expected: []string{`(.responseStatus.code // 0) as $code | $code == 403`},
+		},
+		{
+			name:     "named range",
+			params:   types.AuditQueryParams{StatusCodeRange: "auth_error"},
+			expected: []string{`(.responseStatus.code // 0) as $code | [401, 403] | any(. == $code)`},
+		},
+		{
+			name:     "status class",
+			params:   types.AuditQueryParams{StatusCodeRange: "5xx"},
+			expected: []string{`(.responseStatus.code // 0) as $code | $code >= 500 and $code <= 599`},
+		},
+		{
+			name:   "no status filters",
+			params: types.AuditQueryParams{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildJQStatusCodeFilters(tt.params); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("buildJQStatusCodeFilters() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildGrepStatusCodeFilters tests grep stages for status code parameters
+func TestBuildGrepStatusCodeFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   types.AuditQueryParams
+		expected []string
+	}{
+		{
+			name:     "single code",
+			params:   types.AuditQueryParams{StatusCode: 404},
+			expected: []string{`| grep -E '"code":404[,}]'`},
+		},
+		{
+			name:     "named range",
+			params:   types.AuditQueryParams{StatusCodeRange: "server_error"},
+			expected: []string{`| grep -E '"code":(500|502|503|504)[,}]'`},
+		},
+		{
+			name:     "partial span",
+			params:   types.AuditQueryParams{StatusCodeRange: "498-501"},
+			expected: []string{`| grep -E '"code":(498|499|500|501)[,}]'`},
+		},
+		{
+			name:     "span covering whole classes",
+			params:   types.AuditQueryParams{StatusCodeRange: "400-599"},
+			expected: []string{`| grep -E '"code":(4[0-9]{2}|5[0-9]{2})[,}]'`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildGrepStatusCodeFilters(tt.params); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("buildGrepStatusCodeFilters() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildOcCommand_StatusCode tests that status code filters reach both back-ends
+func TestBuildOcCommand_StatusCode(t *testing.T) {
+	params := types.AuditQueryParams{
+		LogSource:  "kube-apiserver",
+		StatusCode: 403,
+	}
+
+	builder := NewCommandBuilder()
+	if command := builder.buildJSONAwareCommand(params); !strings.Contains(command, "$code == 403") {
+		t.Errorf("Expected status code clause in jq command, got: %s", command)
+	}
+
+	builder.Config.UseJSONParsing = false
+	if command := builder.BuildOptimalCommand(params); !strings.Contains(command, `"code":403[,}]`) {
+		t.Errorf("Expected status code stage in grep command, got: %s", command)
+	}
+}
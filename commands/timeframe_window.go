@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// calendarPreviousTimeframes maps calendar timeframes to the previous period
+	calendarPreviousTimeframes = map[string]string{
+		"today":      "yesterday",
+		"this week":  "last week",
+		"this month": "last month",
+	}
+	// rollingTimeframePattern and shortTimeframePattern match rolling
+	// timeframes such as "last 6 hours" and "6h"
+	rollingTimeframePattern = regexp.MustCompile(`^last (\d+) (minute|hour|day|week|month|year)s?$`)
+	shortTimeframePattern   = regexp.MustCompile(`^(\d+)([mhdwy])$`)
+)
+
+// TimeframeWindow returns the event window a timeframe asks for at now, or false
+// when the timeframe is empty or not recognised
+func TimeframeWindow(timeframe string, now time.Time) (time.Time, time.Time, bool) {
+	start, end := parseTimeframeAt(timeframe, now)
+	if start.IsZero() || end.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// CommandWindow returns the window of events a command generated for timeframe
+// at now can return: the requested window narrowed to the day or month its date
+// filter matches. For example, "24h" only matches events dated yesterday.
+func CommandWindow(timeframe string, now time.Time) (time.Time, time.Time, bool) {
+	start, end, ok := TimeframeWindow(timeframe, now)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	pattern := timeframeDatePatternAt(timeframe, now)
+	var spanStart, spanEnd time.Time
+	if day, err := time.ParseInLocation("2006-01-02", pattern, now.Location()); err == nil {
+		spanStart, spanEnd = day, day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	} else if month, err := time.ParseInLocation("2006-01", pattern, now.Location()); err == nil {
+		spanStart, spanEnd = month, month.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	} else {
+		return start, end, true
+	}
+
+	if spanStart.After(start) {
+		start = spanStart
+	}
+	if spanEnd.Before(end) {
+		end = spanEnd
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// PreviousTimeframe returns a timeframe whose events before the start of
+// timeframe's window form the previous equivalent window: yesterday for today,
+// last week for this week and last month for this month, and twice the span
+// of rolling timeframes such as "1h" or "last 6 hours". It returns false for
+// timeframes without a previous window, such as "since 2024-01-10".
+func PreviousTimeframe(timeframe string) (string, bool) {
+	if timeframe == "last hour" {
+		timeframe = "last 1 hour"
+	}
+	if previous, ok := calendarPreviousTimeframes[timeframe]; ok {
+		return previous, true
+	}
+	if matches := rollingTimeframePattern.FindStringSubmatch(timeframe); matches != nil {
+		count, err := strconv.Atoi(matches[1])
+		if err != nil || count <= 0 {
+			return "", false
+		}
+		return fmt.Sprintf("last %d %ss", 2*count, matches[2]), true
+	}
+	if matches := shortTimeframePattern.FindStringSubmatch(timeframe); matches != nil {
+		count, err := strconv.Atoi(matches[1])
+		if err != nil || count <= 0 {
+			return "", false
+		}
+		return fmt.Sprintf("%d%s", 2*count, matches[2]), true
+	}
+	return "", false
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestCommandWindow tests the event window generated commands can return
+func TestCommandWindow(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	midnight := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		timeframe string
+		start     time.Time
+		end       time.Time
+		ok        bool
+	}{
+		{timeframe: "today", start: midnight, end: now, ok: true},
+		{timeframe: "24h", start: now.Add(-24 * time.Hour), end: midnight.Add(-time.Nanosecond), ok: true},
+		{timeframe: "2h", start: now.Add(-2 * time.Hour), end: now, ok: true},
+		{timeframe: "since 2024-01-10", start: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), end: now, ok: true},
+		{timeframe: ""},
+		{timeframe: "invalid timeframe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeframe, func(t *testing.T) {
+			start, end, ok := CommandWindow(tt.timeframe, now)
+			if ok != tt.ok || !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("Expected [%v, %v] %v, got [%v, %v] %v", tt.start, tt.end, tt.ok, start, end, ok)
+			}
+		})
+	}
+}
+
+// TestPreviousTimeframe tests the timeframes queried for the previous equivalent window
+func TestPreviousTimeframe(t *testing.T) {
+	for timeframe, expected := range map[string]string{
+		"today":            "yesterday",
+		"this week":        "last week",
+		"this month":       "last month",
+		"last hour":        "last 2 hours",
+		"last 6 hours":     "last 12 hours",
+		"last 1 day":       "last 2 days",
+		"30m":              "60m",
+		"2h":               "4h",
+		"yesterday":        "",
+		"since 2024-01-10": "",
+		"0h":               "",
+	} {
+		previous, ok := PreviousTimeframe(timeframe)
+		if previous != expected || ok != (expected != "") {
+			t.Errorf("Expected %q for %q, got %q (%v)", expected, timeframe, previous, ok)
+		}
+	}
+}
+
+// TestCoverageKey tests that only the timeframe and post-parse options are ignored
+func TestCoverageKey(t *testing.T) {
+	base := types.AuditQueryParams{Patterns: []string{"secrets"}, Timeframe: "today"}
+	paged := types.AuditQueryParams{Patterns: []string{"secrets"}, Timeframe: "2h", SortBy: "user", Limit: 10}
+	other := types.AuditQueryParams{Patterns: []string{"configmaps"}, Timeframe: "today"}
+
+	if CoverageKey(base) != CoverageKey(paged) {
+		t.Error("Expected queries differing in timeframe and paging to share a coverage key")
+	}
+	if CoverageKey(base) == CoverageKey(other) {
+		t.Error("Expected queries for different events to have different coverage keys")
+	}
+}
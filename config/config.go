@@ -0,0 +1,286 @@
+// Package config loads the server's settings from the environment, a .env
+// file and a YAML config file. Settings are environment variables, such as
+// AUDIT_PROVIDER, and take their value from, in order of precedence:
+//
+//  1. the process environment
+//  2. the .env file (AUDIT_ENV_FILE, default .env)
+//  3. the YAML config file (AUDIT_CONFIG_FILE, unset by default)
+//  4. the server's built-in defaults
+//
+// Values from the files are applied to the process environment, so the rest
+// of the server reads every setting with os.Getenv.
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a setting's value
+const (
+	SourceEnvironment = "environment"
+	SourceEnvFile     = "env_file"
+	SourceConfigFile  = "config_file"
+)
+
+// Variables selecting the files settings are loaded from
+const (
+	EnvFileVariable    = "AUDIT_ENV_FILE"
+	ConfigFileVariable = "AUDIT_CONFIG_FILE"
+	DefaultEnvFile     = ".env"
+)
+
+// Precedence lists the sources of settings from the highest precedence
+var Precedence = []string{SourceEnvironment, SourceEnvFile, SourceConfigFile, "default"}
+
+// Mask replaces the values of secret settings
+const Mask = "********"
+
+// settingPrefix starts the names of the server's own settings; the other
+// settings it reads are listed in externalSettings
+const settingPrefix = "AUDIT_"
+
+// externalSettings are the settings without the AUDIT_ prefix
+var externalSettings = []string{"OPENAI_API_KEY", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION"}
+
+// secretWords mark the names of settings whose values are masked
+var secretWords = []string{"KEY", "TOKEN", "PASSWORD", "SECRET"}
+
+// Setting is a resolved setting with the source of its value
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Config is the outcome of Load: the files read and the resolved settings
+type Config struct {
+	EnvFile          string    `json:"env_file"`
+	EnvFileLoaded    bool      `json:"env_file_loaded"`
+	ConfigFile       string    `json:"config_file,omitempty"`
+	ConfigFileLoaded bool      `json:"config_file_loaded"`
+	Precedence       []string  `json:"precedence"`
+	Settings         []Setting `json:"settings"`
+}
+
+// applied holds the values Load set in the process environment by name, so
+// the next Load can tell them from values set by the environment
+var (
+	applied      = make(map[string]appliedValue)
+	appliedMutex sync.Mutex
+)
+
+// appliedValue is a value Load set in the process environment and its source
+type appliedValue struct {
+	value  string
+	source string
+}
+
+// Load reads the .env file named by AUDIT_ENV_FILE and the YAML config file
+// named by AUDIT_CONFIG_FILE, which the .env file may set, and sets the
+// variables they define that the environment does not. Values a previous
+// Load set are replaced, so Load can be called again to pick up changed
+// files. A missing .env file is not an error; the settings of the files read
+// are applied even when the other fails.
+func Load() (*Config, error) {
+	appliedMutex.Lock()
+	defer appliedMutex.Unlock()
+
+	for name, previous := range applied {
+		if value, ok := os.LookupEnv(name); ok && value == previous.value {
+			os.Unsetenv(name)
+		}
+	}
+	applied = make(map[string]appliedValue)
+
+	config := &Config{EnvFile: os.Getenv(EnvFileVariable), Precedence: Precedence}
+	if config.EnvFile == "" {
+		config.EnvFile = DefaultEnvFile
+	}
+
+	var errs []string
+	envValues, err := godotenv.Read(config.EnvFile)
+	if err == nil {
+		config.EnvFileLoaded = true
+	} else if !os.IsNotExist(err) {
+		errs = append(errs, fmt.Sprintf("failed to read %s: %v", config.EnvFile, err))
+	}
+
+	config.ConfigFile = os.Getenv(ConfigFileVariable)
+	if config.ConfigFile == "" {
+		config.ConfigFile = envValues[ConfigFileVariable]
+	}
+	var fileValues map[string]string
+	if config.ConfigFile != "" {
+		fileValues, err = ReadConfigFile(config.ConfigFile)
+		if err == nil {
+			config.ConfigFileLoaded = true
+		} else {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	sources := make(map[string]string)
+	for name := range envValues {
+		sources[name] = SourceEnvFile
+	}
+	for name := range fileValues {
+		if _, ok := sources[name]; !ok {
+			sources[name] = SourceConfigFile
+		}
+	}
+	for name, source := range sources {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		value := envValues[name]
+		if source == SourceConfigFile {
+			value = fileValues[name]
+		}
+		os.Setenv(name, value)
+		applied[name] = appliedValue{value: value, source: source}
+	}
+	config.Settings = resolvedSettings()
+
+	if len(errs) > 0 {
+		return config, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return config, nil
+}
+
+// ReadConfigFile reads a YAML config file mapping setting names to values.
+// Values are scalars, or lists of scalars joined with commas. A section, a
+// lowercase name mapping keys to values, sets the settings named AUDIT_, the
+// section and the key in uppercase:
+//
+//	AUDIT_PROVIDER: kubernetes
+//	AUDIT_K8S_NODES: [control-plane-1, control-plane-2]
+//	AUDIT_CACHE_MAX_ENTRIES: 1000
+//	parser:
+//	  strict: true # AUDIT_PARSER_STRICT
+func ReadConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(document))
+	for name, value := range document {
+		if name == "" || strings.ContainsAny(name, "= \t") {
+			return nil, fmt.Errorf("config file %s: invalid setting name %q", path, name)
+		}
+		if section, ok := value.(map[string]interface{}); ok && name == strings.ToLower(name) {
+			for key, value := range section {
+				setting := settingPrefix + strings.ToUpper(name+"_"+key)
+				text, err := settingValue(value)
+				if err != nil {
+					return nil, fmt.Errorf("config file %s: setting %s.%s: %w", path, name, key, err)
+				}
+				values[setting] = text
+			}
+			continue
+		}
+		text, err := settingValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: setting %s: %w", path, name, err)
+		}
+		values[name] = text
+	}
+	return values, nil
+}
+
+// settingValue converts a YAML value to the text of an environment variable
+func settingValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			text, err := settingValue(item)
+			if err != nil || strings.Contains(text, ",") {
+				return "", fmt.Errorf("list items must be scalars without commas")
+			}
+			items[i] = text
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// resolvedSettings returns the server's settings in the process environment
+// sorted by name, with their sources and secrets masked
+func resolvedSettings() []Setting {
+	var settings []Setting
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		source := SourceEnvironment
+		if previous, ok := applied[name]; ok && previous.value == value {
+			source = previous.source
+		} else if !isSetting(name) {
+			continue
+		}
+		settings = append(settings, Setting{Name: name, Value: MaskValue(name, value), Source: source})
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Name < settings[j].Name
+	})
+	return settings
+}
+
+// isSetting reports whether an environment variable is one of the server's
+// settings
+func isSetting(name string) bool {
+	if strings.HasPrefix(name, settingPrefix) {
+		return true
+	}
+	for _, setting := range externalSettings {
+		if name == setting {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecret reports whether the value of the named setting is a secret
+func IsSecret(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range secretWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskValue returns the value of a setting for display: secrets are masked,
+// as are the credentials of URLs
+func MaskValue(name, value string) string {
+	if value == "" {
+		return ""
+	}
+	if IsSecret(name) {
+		return Mask
+	}
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		parsed.User = nil
+		return strings.Replace(parsed.String(), "://", "://"+Mask+"@", 1)
+	}
+	return value
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadForTest runs Load and removes the values it applied when the test ends
+func loadForTest(t *testing.T) (*Config, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		appliedMutex.Lock()
+		defer appliedMutex.Unlock()
+		for name := range applied {
+			os.Unsetenv(name)
+		}
+		applied = make(map[string]appliedValue)
+	})
+	return Load()
+}
+
+// findSetting returns the named setting of a config
+func findSetting(t *testing.T, config *Config, name string) Setting {
+	t.Helper()
+	for _, setting := range config.Settings {
+		if setting.Name == name {
+			return setting
+		}
+	}
+	t.Fatalf("setting %s not resolved", name)
+	return Setting{}
+}
+
+// TestLoad_Precedence tests that the environment overrides the .env file,
+// which overrides the YAML config file
+func TestLoad_Precedence(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", `
+AUDIT_TEST_FROM_ENV: config
+AUDIT_TEST_FROM_ENV_FILE: config
+AUDIT_TEST_FROM_CONFIG: config
+AUDIT_TEST_NODES: [node-1, node-2]
+AUDIT_TEST_LIMIT: 5
+test:
+  section_name: section
+`)
+	envFile := writeFile(t, ".env", "AUDIT_TEST_FROM_ENV=env_file\nAUDIT_TEST_FROM_ENV_FILE=env_file\nAUDIT_CONFIG_FILE="+configFile+"\n")
+	t.Setenv(EnvFileVariable, envFile)
+	t.Setenv("AUDIT_TEST_FROM_ENV", "environment")
+
+	config, err := loadForTest(t)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !config.EnvFileLoaded || !config.ConfigFileLoaded || config.ConfigFile != configFile {
+		t.Errorf("expected both files loaded, got %+v", config)
+	}
+
+	tests := []struct {
+		name   string
+		value  string
+		source string
+	}{
+		{"AUDIT_TEST_FROM_ENV", "environment", SourceEnvironment},
+		{"AUDIT_TEST_FROM_ENV_FILE", "env_file", SourceEnvFile},
+		{"AUDIT_TEST_FROM_CONFIG", "config", SourceConfigFile},
+		{"AUDIT_TEST_NODES", "node-1,node-2", SourceConfigFile},
+		{"AUDIT_TEST_LIMIT", "5", SourceConfigFile},
+		{"AUDIT_TEST_SECTION_NAME", "section", SourceConfigFile},
+	}
+	for _, tt := range tests {
+		if value := os.Getenv(tt.name); value != tt.value {
+			t.Errorf("%s = %q, expected %q", tt.name, value, tt.value)
+		}
+		if setting := findSetting(t, config, tt.name); setting.Value != tt.value || setting.Source != tt.source {
+			t.Errorf("%s resolved as %+v, expected %q from %s", tt.name, setting, tt.value, tt.source)
+		}
+	}
+}
+
+// TestLoad_Reload tests that a second Load replaces the values the first applied
+func TestLoad_Reload(t *testing.T) {
+	envFile := writeFile(t, ".env", "AUDIT_TEST_RELOADED=first\nAUDIT_TEST_REMOVED=yes\n")
+	t.Setenv(EnvFileVariable, envFile)
+	if _, err := loadForTest(t); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if err := os.WriteFile(envFile, []byte("AUDIT_TEST_RELOADED=second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadForTest(t)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if value := os.Getenv("AUDIT_TEST_RELOADED"); value != "second" {
+		t.Errorf("expected the reloaded value, got %q", value)
+	}
+	if findSetting(t, config, "AUDIT_TEST_RELOADED").Source != SourceEnvFile {
+		t.Error("expected the reloaded value to come from the .env file")
+	}
+	if _, ok := os.LookupEnv("AUDIT_TEST_REMOVED"); ok {
+		t.Error("expected the removed setting to be unset")
+	}
+}
+
+// TestLoad_Errors tests that a missing .env file is not an error, and a broken
+// config file is
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv(EnvFileVariable, filepath.Join(t.TempDir(), ".env"))
+	config, err := loadForTest(t)
+	if err != nil || config.EnvFileLoaded {
+		t.Errorf("expected a missing .env file to be skipped, got %v", err)
+	}
+
+	t.Setenv(ConfigFileVariable, writeFile(t, "config.yaml", "AUDIT_TEST_NESTED:\n  key: value\n"))
+	config, err = loadForTest(t)
+	if err == nil || !strings.Contains(err.Error(), "AUDIT_TEST_NESTED") {
+		t.Errorf("expected an error naming the nested setting, got %v", err)
+	}
+	if config == nil || config.ConfigFileLoaded {
+		t.Error("expected the config file not to be loaded")
+	}
+}
+
+// TestMaskValue tests masking secrets and URL credentials
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"OPENAI_API_KEY", "sk-123", Mask},
+		{"AUDIT_LOKI_TOKEN", "abc", Mask},
+		{"AUDIT_ES_PASSWORD", "", ""},
+		{"AUDIT_ES_URL", "https://elastic:changeme@es:9200", "https://" + Mask + "@es:9200"},
+		{"AUDIT_PROVIDER", "loki", "loki"},
+	}
+	for _, tt := range tests {
+		if masked := MaskValue(tt.name, tt.value); masked != tt.expected {
+			t.Errorf("MaskValue(%s, %q) = %q, expected %q", tt.name, tt.value, masked, tt.expected)
+		}
+	}
+}
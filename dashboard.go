@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/server"
+	"audit-query-mcp-server/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/pflag"
+)
+
+// dashboard is the bubbletea model of the dashboard subcommand: the overview
+// of the server and its recent results, or one result drilled into, above
+// the command being typed
+type dashboard struct {
+	srv *server.AuditQueryMCPServer
+	// recent are the results listed by the overview, numbered from 1
+	recent []*types.AuditResult
+	// selected is the result drilled into, nil on the overview
+	selected *types.AuditResult
+	// message reports the outcome of the last command
+	message string
+	// input is the command being typed
+	input string
+	// asking counts the questions being answered in the background
+	asking      int
+	now         time.Time
+	refresh     time.Duration
+	recentLimit int
+	entryRows   int
+}
+
+// tickMsg redraws the overview every refresh interval
+type tickMsg time.Time
+
+// answerMsg is the outcome of an ask command, answered off the update loop
+type answerMsg struct {
+	interpretation *types.QuestionInterpretation
+	result         *types.AuditResult
+	err            error
+}
+
+// dashboardFlags are the flags of the dashboard subcommand
+type dashboardFlags struct {
+	refresh     time.Duration
+	recentLimit int
+	entryRows   int
+	logFile     string
+}
+
+// addDashboardFlags defines the flags of the dashboard subcommand
+func addDashboardFlags(flags *pflag.FlagSet, config *dashboardFlags) {
+	flags.DurationVar(&config.refresh, "refresh", 2*time.Second, "How often the overview is redrawn")
+	flags.IntVar(&config.recentLimit, "recent", 10, "Number of recent results listed")
+	flags.IntVar(&config.entryRows, "rows", 20, "Number of entries shown for a result")
+	flags.StringVar(&config.logFile, "log-file", "", "File the server logs are appended to; they are discarded without it")
+}
+
+// runDashboard shows live server statistics and recent results in the
+// terminal's alternate screen, redrawn every refresh interval, and runs the
+// commands typed below them
+func runDashboard(srv *server.AuditQueryMCPServer, config *dashboardFlags) {
+	// Logs would scroll the screen away
+	var logOutput io.Writer = io.Discard
+	if config.logFile != "" {
+		file, err := os.OpenFile(config.logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			fmt.Printf("❌ Failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		logOutput = file
+	}
+	srv.GetLogger().SetOutput(logOutput)
+	log.SetOutput(logOutput)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv.StartWatching(ctx)
+
+	d := newDashboard(srv, config)
+	if _, err := tea.NewProgram(d, tea.WithAltScreen()).Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Dashboard failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newDashboard returns the dashboard of the server showing the overview
+func newDashboard(srv *server.AuditQueryMCPServer, config *dashboardFlags) *dashboard {
+	d := &dashboard{
+		srv:         srv,
+		refresh:     config.refresh,
+		recentLimit: config.recentLimit,
+		entryRows:   config.entryRows,
+	}
+	d.update(time.Now())
+	return d
+}
+
+// Init starts the refresh ticks
+func (d *dashboard) Init() tea.Cmd {
+	return d.tick()
+}
+
+// tick waits for the next refresh interval
+func (d *dashboard) tick() tea.Cmd {
+	return tea.Tick(d.refresh, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// Update edits the command line on key presses, runs it on enter, and
+// refreshes the overview on ticks and answers
+func (d *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tickMsg:
+		cmd = d.tick()
+		d.update(time.Time(msg))
+		return d, cmd
+	case answerMsg:
+		d.asking--
+		d.answer(msg)
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlD:
+			return d, tea.Quit
+		case tea.KeyEnter:
+			line := d.input
+			d.input = ""
+			cmd = d.handle(line)
+		case tea.KeyBackspace:
+			if runes := []rune(d.input); len(runes) > 0 {
+				d.input = string(runes[:len(runes)-1])
+			}
+		case tea.KeyEsc:
+			d.input = ""
+		case tea.KeySpace:
+			d.input += " "
+		case tea.KeyRunes:
+			d.input += string(msg.Runes)
+		}
+	}
+	d.update(time.Now())
+	return d, cmd
+}
+
+// update refreshes the clock and the recent results of the overview
+func (d *dashboard) update(now time.Time) {
+	d.now = now
+	d.recent = d.srv.RecentResults(d.recentLimit)
+}
+
+// View renders the overview, or the result drilled into, and the command line
+func (d *dashboard) View() string {
+	var view strings.Builder
+	d.render(&view, d.now)
+	return view.String()
+}
+
+// handle runs a dashboard command, returning tea.Quit to quit or the
+// command answering a question in the background
+func (d *dashboard) handle(line string) tea.Cmd {
+	command, argument, _ := strings.Cut(strings.TrimSpace(line), " ")
+	argument = strings.TrimSpace(argument)
+	d.message = ""
+	switch command {
+	case "q", "quit", "exit":
+		return tea.Quit
+	case "", "r", "refresh":
+	case "b", "back":
+		d.selected = nil
+	case "ask":
+		if argument == "" {
+			d.message = "Usage: ask <question>"
+			return nil
+		}
+		d.asking++
+		srv := d.srv
+		return func() tea.Msg {
+			interpretation, result, err := srv.AskAuditQuestion(argument)
+			return answerMsg{interpretation: interpretation, result: result, err: err}
+		}
+	case "open":
+		d.open(argument)
+	case "c", "clear":
+		d.srv.ClearCache()
+		d.selected = nil
+		d.message = "Cache cleared"
+	case "x", "reset":
+		previous := d.srv.ResetCircuitBreaker()
+		d.message = fmt.Sprintf("Circuit breaker reset (was %s)", previous.State)
+	default:
+		if _, err := strconv.Atoi(command); err == nil {
+			d.open(command)
+			return nil
+		}
+		d.message = fmt.Sprintf("Unknown command %q", command)
+	}
+	return nil
+}
+
+// answer drills into the result of an answered question
+func (d *dashboard) answer(msg answerMsg) {
+	if msg.err != nil {
+		d.message = "❌ " + msg.err.Error()
+		return
+	}
+	d.selected = msg.result
+	d.message = "Interpreted as " + describeParams(msg.interpretation.Params)
+}
+
+// open drills into a recent result by its number or query ID
+func (d *dashboard) open(reference string) {
+	if number, err := strconv.Atoi(reference); err == nil {
+		if number < 1 || number > len(d.recent) {
+			d.message = fmt.Sprintf("No recent result %d", number)
+			return
+		}
+		d.selected = d.recent[number-1]
+		return
+	}
+	result, ok := d.srv.GetCachedResult(reference)
+	if !ok {
+		d.message = fmt.Sprintf("No cached result %s", reference)
+		return
+	}
+	d.selected = result
+}
+
+// render writes the overview, or the result drilled into, at now
+func (d *dashboard) render(w io.Writer, now time.Time) {
+	fmt.Fprintf(w, "OpenShift Audit Query Dashboard — %s backend", d.srv.ProviderName())
+	if clusters := d.srv.ClusterNames(); len(clusters) > 0 {
+		fmt.Fprintf(w, ", clusters: %s", strings.Join(clusters, ", "))
+	}
+	fmt.Fprintf(w, " — %s\n\n", now.Format("15:04:05"))
+
+	if d.selected != nil {
+		d.renderResult(w, d.selected)
+		fmt.Fprintln(w)
+		if d.message != "" {
+			fmt.Fprintln(w, d.message)
+		}
+		fmt.Fprintln(w, "Commands: b back · ask <question> · q quit")
+		d.renderPrompt(w)
+		return
+	}
+
+	cache := d.srv.GetCacheStats()
+	fmt.Fprintf(w, "Cache      %v entries · %.1f%% hit rate · %v hits · %v misses · %v evictions · %v bytes\n",
+		cache["size"], cache["hit_rate"], cache["hits"], cache["misses"], cache["evictions"], cache["bytes"])
+	circuit := d.srv.GetCircuitBreakerStatus()
+	fmt.Fprintf(w, "Breaker    %s · %d/%d failures · %d trips · %d rejections\n",
+		strings.ToUpper(string(circuit.State)), circuit.FailureCount, circuit.FailureThreshold, circuit.Trips, circuit.Rejections)
+	requests := d.srv.RequestStats()
+	fmt.Fprintf(w, "Requests   %v handled · %v errors · %v slow · %d running\n",
+		requests["total"], requests["errors"], requests["slow"], len(d.srv.InFlightQueries()))
+	if rules := d.srv.WatchRuleNames(); len(rules) > 0 {
+		fmt.Fprintf(w, "Alerts     %d raised by %d watch rules\n", len(d.srv.RecentAlerts()), len(rules))
+	}
+
+	fmt.Fprintf(w, "\nRecent results (%d)\n", len(d.recent))
+	rows := make([]map[string]interface{}, 0, len(d.recent))
+	for i, result := range d.recent {
+		rows = append(rows, map[string]interface{}{
+			"#":        strconv.Itoa(i + 1),
+			"query_id": result.QueryID,
+			"time":     result.Timestamp,
+			"backend":  result.Backend,
+			"entries":  strconv.Itoa(result.TotalEntries),
+			"summary":  truncate(resultSummary(result), 60),
+		})
+	}
+	writeEntryTable(w, rows, []string{"#", "query_id", "time", "backend", "entries", "summary"})
+
+	fmt.Fprintln(w)
+	if d.message != "" {
+		fmt.Fprintln(w, d.message)
+	}
+	fmt.Fprintln(w, "Commands: <n> or open <query id> drill down · ask <question> · r refresh · c clear cache · x reset breaker · q quit")
+	d.renderPrompt(w)
+}
+
+// renderPrompt writes the command being typed, and the questions being
+// answered
+func (d *dashboard) renderPrompt(w io.Writer) {
+	if d.asking > 0 {
+		fmt.Fprintf(w, "Answering %d question(s)…\n", d.asking)
+	}
+	fmt.Fprintf(w, "> %s█", d.input)
+}
+
+// renderResult writes a result's details and its first entries
+func (d *dashboard) renderResult(w io.Writer, result *types.AuditResult) {
+	fmt.Fprintf(w, "Query      %s\n", result.QueryID)
+	fmt.Fprintf(w, "Command    %s\n", result.Command)
+	fmt.Fprintf(w, "Backend    %s", result.Backend)
+	if result.Cluster != "" {
+		fmt.Fprintf(w, " · cluster %s", result.Cluster)
+	}
+	fmt.Fprintf(w, " · ran %s in %d ms\n", result.Timestamp, result.ExecutionTime)
+	fmt.Fprintf(w, "Summary    %s\n", resultSummary(result))
+	if result.Error != "" {
+		fmt.Fprintf(w, "Error      %s\n", result.Error)
+	}
+
+	entries := result.ParsedData
+	fmt.Fprintf(w, "\nEntries (%d", len(entries))
+	if len(entries) > d.entryRows {
+		fmt.Fprintf(w, ", showing the first %d", d.entryRows)
+		entries = entries[:d.entryRows]
+	}
+	fmt.Fprintln(w, ")")
+	writeEntryTable(w, entries, defaultQueryColumns)
+}
+
+// resultSummary returns a result's summary, or its error when it failed
+func resultSummary(result *types.AuditResult) string {
+	if result.Summary == "" && result.Error != "" {
+		return "error: " + result.Error
+	}
+	return result.Summary
+}
+
+// describeParams lists the set parameters of an interpreted question
+func describeParams(params types.AuditQueryParams) string {
+	parts := []string{"log_source=" + params.LogSource}
+	for _, param := range [][2]string{
+		{"timeframe", params.Timeframe},
+		{"username", params.Username},
+		{"verb", params.Verb},
+		{"resource", params.Resource},
+		{"namespace", params.Namespace},
+	} {
+		if param[1] != "" {
+			parts = append(parts, param[0]+"="+param[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// truncate shortens text to at most limit runes, marking the cut with "…"
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
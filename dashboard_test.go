@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/server"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeCommand types a command line into the dashboard, returning the command
+// of the enter key
+func typeCommand(d *dashboard, line string) tea.Cmd {
+	for _, r := range line {
+		if r == ' ' {
+			d.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+			continue
+		}
+		d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	_, cmd := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return cmd
+}
+
+// isQuit reports whether a command quits the dashboard
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+// TestDashboard tests the dashboard commands, overview and drill-down into a result
+func TestDashboard(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "mock")
+	d := newDashboard(server.NewAuditQueryMCPServer(), &dashboardFlags{refresh: time.Second, recentLimit: 10, entryRows: 1})
+
+	if view := d.View(); !strings.Contains(view, "mock backend") || !strings.Contains(view, "Recent results (0)") {
+		t.Errorf("Unexpected empty overview:\n%s", view)
+	}
+
+	// Ticks redraw the overview without losing the command being typed
+	for _, r := range "ask who" {
+		d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if _, cmd := d.Update(tickMsg(time.Now())); cmd == nil {
+		t.Error("Expected a tick to schedule the next one")
+	}
+	d.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if d.input != "ask wh" || !strings.HasSuffix(d.View(), "> ask wh█") {
+		t.Errorf("Expected the typed command to survive a tick, got %q", d.input)
+	}
+	d.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Questions are answered in the background, then drilled into
+	answer := typeCommand(d, "ask who deleted anything today")
+	if answer == nil || isQuit(answer) || d.asking != 1 || !strings.Contains(d.View(), "Answering 1 question(s)") {
+		t.Fatalf("Expected ask to answer in the background, got message %q", d.message)
+	}
+	d.Update(answer())
+	if d.selected == nil || d.asking != 0 {
+		t.Fatalf("Expected the answer to be drilled into, got message %q", d.message)
+	}
+	queryID := d.selected.QueryID
+	if view := d.View(); !strings.Contains(view, "Query      "+queryID) || !strings.Contains(view, "showing the first 1") {
+		t.Errorf("Unexpected result view:\n%s", view)
+	}
+
+	typeCommand(d, "b")
+	if view := d.View(); d.selected != nil || !strings.Contains(view, queryID) {
+		t.Errorf("Expected the overview to list %s:\n%s", queryID, view)
+	}
+	typeCommand(d, "1")
+	if d.selected == nil || d.selected.QueryID != queryID {
+		t.Errorf("Expected result 1 to be drilled into, got message %q", d.message)
+	}
+	typeCommand(d, "b")
+
+	for command, message := range map[string]string{
+		"2":           "No recent result 2",
+		"open bogus":  "No cached result bogus",
+		"ask":         "Usage: ask <question>",
+		"frobnicate":  `Unknown command "frobnicate"`,
+		"x":           "Circuit breaker reset (was closed)",
+		"clear":       "Cache cleared",
+		"   refresh ": "",
+	} {
+		if cmd := typeCommand(d, command); cmd != nil || d.message != message {
+			t.Errorf("Command %q: expected message %q, got %q", command, message, d.message)
+		}
+	}
+	if !isQuit(typeCommand(d, "q")) {
+		t.Error("Expected q to quit")
+	}
+	if _, cmd := d.Update(tea.KeyMsg{Type: tea.KeyCtrlC}); !isQuit(cmd) {
+		t.Error("Expected ctrl+c to quit")
+	}
+}
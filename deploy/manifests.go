@@ -0,0 +1,228 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"text/template"
+	"time"
+)
+
+// Manifest defaults
+const (
+	DefaultName          = "audit-query-mcp-server"
+	DefaultNamespace     = "audit-query"
+	DefaultPort          = 3000
+	DefaultCPURequest    = "100m"
+	DefaultCPULimit      = "1"
+	DefaultMemoryRequest = "256Mi"
+	DefaultMemoryLimit   = "1Gi"
+	// DefaultMaxQueryBytes bounds the audit log bytes one query reads, well
+	// below the default memory limit
+	DefaultMaxQueryBytes = 256 * 1024 * 1024
+	DefaultQueryTimeout  = 5 * time.Minute
+)
+
+var (
+	// dnsLabelPattern matches Kubernetes object and namespace names
+	dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// quantityPattern matches CPU and memory quantities such as 500m or 1Gi
+	quantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(m|k|M|G|T|Ki|Mi|Gi|Ti)?$`)
+	// imagePattern matches container image references without whitespace or quotes
+	imagePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/:@-]*$`)
+)
+
+// Options configures the generated manifests
+type Options struct {
+	Name      string
+	Namespace string
+	// Image is the server's container image; it has no default
+	Image string
+	Port  int
+	// CPU and memory requests and limits of the server container
+	CPURequest    string
+	CPULimit      string
+	MemoryRequest string
+	MemoryLimit   string
+	// MaxQueryBytes and QueryTimeout limit each query of the in-cluster backend
+	MaxQueryBytes int64
+	QueryTimeout  time.Duration
+}
+
+// DefaultOptions returns the options of a default deployment
+func DefaultOptions() Options {
+	return Options{
+		Name:          DefaultName,
+		Namespace:     DefaultNamespace,
+		Port:          DefaultPort,
+		CPURequest:    DefaultCPURequest,
+		CPULimit:      DefaultCPULimit,
+		MemoryRequest: DefaultMemoryRequest,
+		MemoryLimit:   DefaultMemoryLimit,
+		MaxQueryBytes: DefaultMaxQueryBytes,
+		QueryTimeout:  DefaultQueryTimeout,
+	}
+}
+
+// Validate checks that options render valid manifests
+func (o Options) Validate() error {
+	for _, name := range []struct{ field, value string }{{"name", o.Name}, {"namespace", o.Namespace}} {
+		if len(name.value) > 63 || !dnsLabelPattern.MatchString(name.value) {
+			return fmt.Errorf("invalid %s %q: must be a DNS label", name.field, name.value)
+		}
+	}
+	if o.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	if !imagePattern.MatchString(o.Image) {
+		return fmt.Errorf("invalid image %q", o.Image)
+	}
+	if o.Port < 1 || o.Port > 65535 {
+		return fmt.Errorf("invalid port %d", o.Port)
+	}
+	for _, quantity := range []struct{ field, value string }{
+		{"CPU request", o.CPURequest}, {"CPU limit", o.CPULimit},
+		{"memory request", o.MemoryRequest}, {"memory limit", o.MemoryLimit},
+	} {
+		if !quantityPattern.MatchString(quantity.value) {
+			return fmt.Errorf("invalid %s %q", quantity.field, quantity.value)
+		}
+	}
+	if o.MaxQueryBytes <= 0 {
+		return fmt.Errorf("invalid max query bytes %d", o.MaxQueryBytes)
+	}
+	if o.QueryTimeout <= 0 {
+		return fmt.Errorf("invalid query timeout %s", o.QueryTimeout)
+	}
+	return nil
+}
+
+// manifestsTemplate renders the Namespace, ServiceAccount, RBAC, Deployment
+// and Service of an in-cluster server. The ClusterRole grants only what the
+// in-cluster backend needs: listing nodes and reading their logs through the
+// node proxy.
+var manifestsTemplate = template.Must(template.New("manifests").Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{.Namespace}}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: {{.Name}}
+rules:
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["get", "list"]
+- apiGroups: [""]
+  resources: ["nodes/proxy"]
+  verbs: ["get"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: {{.Name}}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: {{.Name}}
+subjects:
+- kind: ServiceAccount
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}
+    spec:
+      serviceAccountName: {{.Name}}
+      containers:
+      - name: server
+        image: {{.Image}}
+        args: ["serve"]
+        workingDir: /var/lib/audit-query
+        env:
+        - name: AUDIT_PROVIDER
+          value: in-cluster
+        - name: AUDIT_IN_CLUSTER_MAX_BYTES
+          value: "{{.MaxQueryBytes}}"
+        - name: AUDIT_IN_CLUSTER_QUERY_TIMEOUT
+          value: "{{.QueryTimeout}}"
+        - name: PORT
+          value: "{{.Port}}"
+        ports:
+        - name: http
+          containerPort: {{.Port}}
+        readinessProbe:
+          httpGet:
+            path: /health
+            port: http
+        livenessProbe:
+          httpGet:
+            path: /health
+            port: http
+        resources:
+          requests:
+            cpu: {{.CPURequest}}
+            memory: {{.MemoryRequest}}
+          limits:
+            cpu: {{.CPULimit}}
+            memory: {{.MemoryLimit}}
+        securityContext:
+          allowPrivilegeEscalation: false
+          readOnlyRootFilesystem: true
+          runAsNonRoot: true
+          capabilities:
+            drop: ["ALL"]
+        volumeMounts:
+        - name: data
+          mountPath: /var/lib/audit-query
+      volumes:
+      - name: data
+        emptyDir: {}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+spec:
+  selector:
+    app.kubernetes.io/name: {{.Name}}
+  ports:
+  - name: http
+    port: {{.Port}}
+    targetPort: http
+`))
+
+// Manifests renders the YAML manifests that deploy the server in a cluster,
+// reading audit logs with its ServiceAccount through the in-cluster backend
+func Manifests(options Options) (string, error) {
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	var manifests bytes.Buffer
+	if err := manifestsTemplate.Execute(&manifests, options); err != nil {
+		return "", fmt.Errorf("failed to render manifests: %w", err)
+	}
+	return manifests.String(), nil
+}
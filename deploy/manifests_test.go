@@ -0,0 +1,62 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestManifests tests rendering the in-cluster deployment manifests
+func TestManifests(t *testing.T) {
+	options := DefaultOptions()
+	options.Image = "registry.example.com/audit-query-mcp-server:1.0"
+	options.Namespace = "security"
+	options.MemoryLimit = "2Gi"
+	options.QueryTimeout = 90 * time.Second
+	manifests, err := Manifests(options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"kind: ServiceAccount",
+		"resources: [\"nodes/proxy\"]",
+		"serviceAccountName: audit-query-mcp-server",
+		"image: registry.example.com/audit-query-mcp-server:1.0",
+		"namespace: security",
+		"value: in-cluster",
+		"- name: AUDIT_IN_CLUSTER_QUERY_TIMEOUT\n          value: \"1m30s\"",
+		"memory: 2Gi",
+	} {
+		if !strings.Contains(manifests, expected) {
+			t.Errorf("Expected the manifests to contain %q", expected)
+		}
+	}
+	if documents := strings.Count(manifests, "\n---\n"); documents != 5 {
+		t.Errorf("Expected 6 documents, got %d separators", documents)
+	}
+}
+
+// TestOptions_Validate tests that options rendering invalid manifests are rejected
+func TestOptions_Validate(t *testing.T) {
+	valid := DefaultOptions()
+	valid.Image = "registry.example.com/audit-query-mcp-server:1.0"
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, modify := range map[string]func(*Options){
+		"missing image":   func(o *Options) { o.Image = "" },
+		"image injection": func(o *Options) { o.Image = "nginx\n  command: [sh]" },
+		"namespace":       func(o *Options) { o.Namespace = "Security" },
+		"memory limit":    func(o *Options) { o.MemoryLimit = "lots" },
+		"port":            func(o *Options) { o.Port = 0 },
+		"max bytes":       func(o *Options) { o.MaxQueryBytes = 0 },
+	} {
+		options := valid
+		modify(&options)
+		if err := options.Validate(); err == nil {
+			t.Errorf("Expected an error for an invalid %s", name)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package detection
+
+import (
+	"sort"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// MaxAlertEvents bounds the matching events an alert carries
+const MaxAlertEvents = 10
+
+// Evaluate returns the alerts a rule raises on parsed entries at now: one per
+// group_by value, or one in total without group_by, that has at least the
+// rule's threshold of entries timestamped within the window ending at now.
+// Entries without a parsable timestamp are ignored. Alerts are ordered by
+// group and carry no ID or query ID yet.
+func Evaluate(rule Rule, entries []map[string]interface{}, now time.Time) []types.Alert {
+	windowStart := now.Add(-rule.Window)
+	groups := make(map[string][]map[string]interface{})
+	for _, entry := range entries {
+		value, _ := entry["timestamp"].(string)
+		timestamp, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil || !timestamp.After(windowStart) || timestamp.After(now) {
+			continue
+		}
+		group := groupValue(entry, rule.GroupBy)
+		groups[group] = append(groups[group], entry)
+	}
+
+	var alerts []types.Alert
+	for group, matched := range groups {
+		if len(matched) < rule.Threshold {
+			continue
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			return entryTime(matched[i]).Before(entryTime(matched[j]))
+		})
+		events := matched
+		if len(events) > MaxAlertEvents {
+			events = events[len(events)-MaxAlertEvents:]
+		}
+		alert := types.Alert{
+			Rule:        rule.Name,
+			Description: rule.Description,
+			Severity:    rule.Severity,
+			Group:       group,
+			Count:       len(matched),
+			Threshold:   rule.Threshold,
+			Window:      rule.Window.String(),
+			FirstSeen:   matched[0]["timestamp"].(string),
+			LastSeen:    matched[len(matched)-1]["timestamp"].(string),
+			Events:      events,
+			RaisedAt:    now.UTC().Format(time.RFC3339),
+		}
+		if rule.GroupBy != "" {
+			alert.GroupBy = rule.GroupBy
+		}
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Group < alerts[j].Group })
+	return alerts
+}
+
+// entryTime returns the parsed timestamp of an entry Evaluate kept
+func entryTime(entry map[string]interface{}) time.Time {
+	value, _ := entry["timestamp"].(string)
+	timestamp, _ := time.Parse(time.RFC3339Nano, value)
+	return timestamp
+}
+
+// groupValue returns the value of an entry's group_by field; source_ip groups
+// by the first of the entry's source IPs
+func groupValue(entry map[string]interface{}, field string) string {
+	if field != "source_ip" {
+		value, _ := entry[field].(string)
+		return value
+	}
+	switch ips := entry["source_ips"].(type) {
+	case []string:
+		if len(ips) > 0 {
+			return ips[0]
+		}
+	case []interface{}:
+		if len(ips) > 0 {
+			value, _ := ips[0].(string)
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package detection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestEvaluate tests counting events within the window per group against the threshold
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(username string, ago time.Duration) map[string]interface{} {
+		return map[string]interface{}{"username": username, "timestamp": now.Add(-ago).Format(time.RFC3339Nano)}
+	}
+	entries := []map[string]interface{}{
+		entry("alice", 1*time.Minute),
+		entry("alice", 3*time.Minute),
+		entry("alice", 20*time.Minute), // outside the window
+		entry("bob", 2*time.Minute),
+		{"username": "bob", "timestamp": "not a time"},
+	}
+
+	rule := Rule{Name: "denials", Severity: "high", Threshold: 2, Window: 5 * time.Minute, GroupBy: "username"}
+	alerts := Evaluate(rule, entries, now)
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.Group != "alice" || alert.GroupBy != "username" || alert.Count != 2 || alert.Threshold != 2 || alert.Window != "5m0s" {
+		t.Errorf("Unexpected alert: %+v", alert)
+	}
+	if alert.FirstSeen != entries[1]["timestamp"] || alert.LastSeen != entries[0]["timestamp"] {
+		t.Errorf("Unexpected first and last seen: %s, %s", alert.FirstSeen, alert.LastSeen)
+	}
+
+	// Without group_by all events in the window count together
+	rule.GroupBy = ""
+	rule.Threshold = 3
+	alerts = Evaluate(rule, entries, now)
+	if len(alerts) != 1 || alerts[0].Count != 3 || alerts[0].Group != "" || alerts[0].GroupBy != "" {
+		t.Errorf("Unexpected ungrouped alerts: %+v", alerts)
+	}
+
+	rule.Threshold = 4
+	if alerts := Evaluate(rule, entries, now); len(alerts) != 0 {
+		t.Errorf("Expected no alerts below the threshold, got %+v", alerts)
+	}
+}
+
+// TestEvaluate_MaxEvents tests that alerts carry only the latest matching events
+func TestEvaluate_MaxEvents(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var entries []map[string]interface{}
+	for i := 0; i < MaxAlertEvents+5; i++ {
+		entries = append(entries, map[string]interface{}{
+			"name":      fmt.Sprintf("event-%d", i),
+			"timestamp": now.Add(-time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+		})
+	}
+	alerts := Evaluate(Rule{Name: "burst", Threshold: 1, Window: time.Minute}, entries, now)
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(alerts))
+	}
+	if alerts[0].Count != MaxAlertEvents+5 || len(alerts[0].Events) != MaxAlertEvents {
+		t.Errorf("Unexpected count %d with %d events", alerts[0].Count, len(alerts[0].Events))
+	}
+	if alerts[0].Events[MaxAlertEvents-1]["name"] != "event-0" {
+		t.Errorf("Expected the latest event last, got %v", alerts[0].Events[MaxAlertEvents-1]["name"])
+	}
+}
+
+// TestEvaluate_SourceIP tests grouping by the first source IP of each entry
+func TestEvaluate_SourceIP(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := now.Add(-time.Minute).Format(time.RFC3339Nano)
+	entries := []map[string]interface{}{
+		{"timestamp": timestamp, "source_ips": []string{"10.0.0.1", "10.0.0.2"}},
+		{"timestamp": timestamp, "source_ips": []interface{}{"10.0.0.1"}},
+		{"timestamp": timestamp, "source_ips": []string{"10.0.0.3"}},
+	}
+	alerts := Evaluate(Rule{Name: "sources", Threshold: 2, Window: 5 * time.Minute, GroupBy: "source_ip"}, entries, now)
+	if len(alerts) != 1 || alerts[0].Group != "10.0.0.1" || alerts[0].Count != 2 {
+		t.Errorf("Unexpected alerts: %+v", alerts)
+	}
+}
@@ -0,0 +1,268 @@
+package detection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/validation"
+)
+
+// Rule defaults
+const (
+	DefaultSeverity  = "medium"
+	DefaultThreshold = 1
+	DefaultWindow    = 5 * time.Minute
+	// DefaultTimeframe is the timeframe rules query unless their filters set one;
+	// the window then selects the recent events
+	DefaultTimeframe = "today"
+)
+
+// Severities lists the supported rule severities, lowest first
+var Severities = []string{"low", "medium", "high", "critical"}
+
+// GroupByFields lists the parsed entry fields a rule can count events by
+var GroupByFields = []string{"username", "source_ip", "namespace", "resource", "verb", "user_agent", "name"}
+
+// ruleNamePattern matches rule names
+var ruleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Rule is a detection evaluated on new audit events: an alert is raised when
+// at least Threshold events matching Params fall within Window, counted per
+// value of GroupBy when it is set
+type Rule struct {
+	Name        string
+	Description string
+	Severity    string
+	Params      types.AuditQueryParams
+	Threshold   int
+	Window      time.Duration
+	GroupBy     string
+	// Notify names the forwarding destinations alerts are sent to
+	Notify []string
+}
+
+// ruleFile is the YAML form of a rule file; filters use the structured_params
+// field names
+type ruleFile struct {
+	Rules []ruleDefinition `yaml:"rules"`
+}
+
+// ruleDefinition is the YAML form of a Rule
+type ruleDefinition struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description,omitempty"`
+	Severity    string                 `yaml:"severity,omitempty"`
+	Filters     map[string]interface{} `yaml:"filters,omitempty"`
+	Threshold   int                    `yaml:"threshold,omitempty"`
+	Window      string                 `yaml:"window,omitempty"`
+	GroupBy     string                 `yaml:"group_by,omitempty"`
+	Notify      []string               `yaml:"notify,omitempty"`
+}
+
+// LoadRules reads the rules of a YAML rule file, or of every .yaml and .yml
+// file in a directory, in file name order. A file holding a Sigma rule is
+// imported with ImportSigma. Rule names must be unique.
+func LoadRules(path string) ([]Rule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch rules: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list watch rules: %w", err)
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	var rules []Rule
+	seen := make(map[string]bool)
+	for _, file := range files {
+		loaded, err := loadRuleFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range loaded {
+			if seen[rule.Name] {
+				return nil, fmt.Errorf("duplicate watch rule: %s", rule.Name)
+			}
+			seen[rule.Name] = true
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// loadRuleFile reads and validates the rules of one YAML file
+func loadRuleFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch rules: %w", err)
+	}
+	var sigma struct {
+		Detection interface{} `yaml:"detection"`
+	}
+	if yaml.Unmarshal(data, &sigma) == nil && sigma.Detection != nil {
+		rule, err := ImportSigma(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return []Rule{rule}, nil
+	}
+
+	var parsed ruleFile
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse watch rules %s: %w", path, err)
+	}
+
+	rules := make([]Rule, 0, len(parsed.Rules))
+	for _, definition := range parsed.Rules {
+		rule := Rule{
+			Name:        definition.Name,
+			Description: definition.Description,
+			Severity:    definition.Severity,
+			Threshold:   definition.Threshold,
+			GroupBy:     definition.GroupBy,
+			Notify:      definition.Notify,
+		}
+		if definition.Window != "" {
+			window, err := time.ParseDuration(definition.Window)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window of watch rule %s: %s", rule.Name, definition.Window)
+			}
+			rule.Window = window
+		}
+		// Filters are decoded like structured_params in a query template
+		filters, err := json.Marshal(definition.Filters)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filters of watch rule %s: %w", rule.Name, err)
+		}
+		if err := json.Unmarshal(filters, &rule.Params); err != nil {
+			return nil, fmt.Errorf("invalid filters of watch rule %s: %w", rule.Name, err)
+		}
+		if err := rule.normalize(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MarshalRules returns rules in the YAML rule file format LoadRules reads
+func MarshalRules(rules []Rule) ([]byte, error) {
+	var file ruleFile
+	for _, rule := range rules {
+		// Filters are encoded like structured_params, without unset fields
+		data, err := json.Marshal(rule.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filters of watch rule %s: %w", rule.Name, err)
+		}
+		var filters map[string]interface{}
+		if err := json.Unmarshal(data, &filters); err != nil {
+			return nil, fmt.Errorf("failed to encode filters of watch rule %s: %w", rule.Name, err)
+		}
+		for key, value := range filters {
+			if value == nil || value == "" {
+				delete(filters, key)
+			}
+		}
+		file.Rules = append(file.Rules, ruleDefinition{
+			Name:        rule.Name,
+			Description: rule.Description,
+			Severity:    rule.Severity,
+			Filters:     filters,
+			Threshold:   rule.Threshold,
+			Window:      formatWindow(rule.Window),
+			GroupBy:     rule.GroupBy,
+			Notify:      rule.Notify,
+		})
+	}
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return nil, fmt.Errorf("failed to encode watch rules: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode watch rules: %w", err)
+	}
+	return output.Bytes(), nil
+}
+
+// formatWindow formats a window without zero trailing units, e.g. 5m rather than 5m0s
+func formatWindow(window time.Duration) string {
+	if window == 0 {
+		return ""
+	}
+	formatted := window.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+	return formatted
+}
+
+// normalize fills in defaults and validates the rule
+func (r *Rule) normalize() error {
+	if !ruleNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid watch rule name: %q", r.Name)
+	}
+	if r.Severity == "" {
+		r.Severity = DefaultSeverity
+	}
+	if !contains(Severities, r.Severity) {
+		return fmt.Errorf("invalid severity of watch rule %s: %s (supported: %s)", r.Name, r.Severity, strings.Join(Severities, ", "))
+	}
+	if r.Threshold == 0 {
+		r.Threshold = DefaultThreshold
+	}
+	if r.Threshold < 0 {
+		return fmt.Errorf("invalid threshold of watch rule %s: %d", r.Name, r.Threshold)
+	}
+	if r.Window == 0 {
+		r.Window = DefaultWindow
+	}
+	if r.Window < 0 {
+		return fmt.Errorf("invalid window of watch rule %s: %s", r.Name, r.Window)
+	}
+	if r.GroupBy != "" && !contains(GroupByFields, r.GroupBy) {
+		return fmt.Errorf("invalid group_by of watch rule %s: %s (supported: %s)", r.Name, r.GroupBy, strings.Join(GroupByFields, ", "))
+	}
+	if r.Params.LogSource == "" {
+		r.Params.LogSource = "kube-apiserver"
+	}
+	if r.Params.Timeframe == "" {
+		r.Params.Timeframe = DefaultTimeframe
+	}
+	if err := validation.ValidateQueryParams(r.Params); err != nil {
+		return fmt.Errorf("invalid filters of watch rule %s: %w", r.Name, err)
+	}
+	return nil
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
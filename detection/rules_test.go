@@ -0,0 +1,93 @@
+package detection
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeRules writes a rule file to dir and returns its path
+func writeRules(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	return path
+}
+
+// TestLoadRules tests reading rules with their defaults from a file and a directory
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	path := writeRules(t, dir, "a.yaml", `rules:
+  - name: secret-deletions
+    description: Secrets deleted in bulk
+    severity: critical
+    filters:
+      verb: delete
+      resource: secrets
+    threshold: 5
+    window: 10m
+    group_by: username
+    notify: [splunk]
+  - name: oauth-failures
+    filters:
+      log_source: oauth-server
+      status_code_range: 4xx
+`)
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(rules))
+	}
+	rule := rules[0]
+	if rule.Severity != "critical" || rule.Threshold != 5 || rule.Window != 10*time.Minute || rule.GroupBy != "username" {
+		t.Errorf("Unexpected rule: %+v", rule)
+	}
+	if rule.Params.Verb != "delete" || rule.Params.Resource != "secrets" || rule.Params.LogSource != "kube-apiserver" || rule.Params.Timeframe != DefaultTimeframe {
+		t.Errorf("Unexpected filters: %+v", rule.Params)
+	}
+	if len(rule.Notify) != 1 || rule.Notify[0] != "splunk" {
+		t.Errorf("Unexpected notify: %v", rule.Notify)
+	}
+	if defaults := rules[1]; defaults.Severity != DefaultSeverity || defaults.Threshold != DefaultThreshold || defaults.Window != DefaultWindow || defaults.Params.LogSource != "oauth-server" {
+		t.Errorf("Unexpected defaults: %+v", defaults)
+	}
+
+	writeRules(t, dir, "b.yml", "rules:\n  - name: exec\n    filters:\n      resource: pods\n")
+	writeRules(t, dir, "notes.txt", "not rules")
+	rules, err = LoadRules(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 3 || rules[2].Name != "exec" {
+		t.Errorf("Unexpected rules from directory: %+v", rules)
+	}
+}
+
+// TestLoadRules_Invalid tests that invalid rules are rejected
+func TestLoadRules_Invalid(t *testing.T) {
+	for content, expected := range map[string]string{
+		"rules:\n  - name: a/b\n":                                  "invalid watch rule name",
+		"rules:\n  - name: a\n  - name: a\n":                       "duplicate watch rule",
+		"rules:\n  - name: a\n    severity: urgent\n":              "invalid severity",
+		"rules:\n  - name: a\n    threshold: -1\n":                 "invalid threshold",
+		"rules:\n  - name: a\n    window: soon\n":                  "invalid window",
+		"rules:\n  - name: a\n    group_by: password\n":            "invalid group_by",
+		"rules:\n  - name: a\n    filters:\n      verb: destroy\n": "invalid filters of watch rule a",
+		"rules:\n  - name: a\n    filters:\n      verb: [get]\n":   "invalid filters of watch rule a",
+		"rules: [": "failed to parse watch rules",
+	} {
+		_, err := LoadRules(writeRules(t, t.TempDir(), "rules.yaml", content))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q for %q, got %v", expected, content, err)
+		}
+	}
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for a missing rule file")
+	}
+}
@@ -0,0 +1,430 @@
+package detection
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"audit-query-mcp-server/types"
+)
+
+// sigmaRule is the part of a Sigma rule the importer reads
+type sigmaRule struct {
+	Title       string `yaml:"title"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Level       string `yaml:"level"`
+	LogSource   struct {
+		Product string `yaml:"product"`
+		Service string `yaml:"service"`
+	} `yaml:"logsource"`
+	Detection map[string]interface{} `yaml:"detection"`
+}
+
+// sigmaLevels maps Sigma levels to rule severities
+var sigmaLevels = map[string]string{
+	"informational": "low",
+	"low":           "low",
+	"medium":        "medium",
+	"high":          "high",
+	"critical":      "critical",
+}
+
+// sigmaGroupByFields maps the Sigma fields aggregations can count by to entry fields
+var sigmaGroupByFields = map[string]string{
+	"user.username":       "username",
+	"sourceIPs":           "source_ip",
+	"objectRef.namespace": "namespace",
+	"objectRef.resource":  "resource",
+	"objectRef.name":      "name",
+	"verb":                "verb",
+	"userAgent":           "user_agent",
+}
+
+// sigmaEventKeys maps Sigma fields without a query parameter to the JSON key
+// matched in the raw event
+var sigmaEventKeys = map[string]string{
+	"objectRef.subresource": "subresource",
+	"objectRef.apiGroup":    "apiGroup",
+}
+
+var (
+	// sigmaAggregationPattern matches the supported aggregations: count() > N,
+	// optionally by a field
+	sigmaAggregationPattern = regexp.MustCompile(`^count\(\)\s*(?:by\s+(\S+)\s*)?(>=|>)\s*(\d+)$`)
+	// sigmaEventValuePattern matches the values matched in the raw event
+	sigmaEventValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+	// nonNameChars matches the characters a rule name derived from a title drops
+	nonNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// ImportSigma converts a Sigma rule with the kubernetes/audit logsource into a
+// watch rule. The supported subset is:
+//
+//   - conditions of selections joined by "and", each optionally negated with
+//     "not", and a "count() [by field] > N" aggregation over detection.timeframe
+//   - selections mapping fields to a value or a list of values, matched as
+//     exact values or with the contains, startswith, endswith, re and cidr
+//     modifiers where a query parameter supports them
+//   - negated selections of a single verb, user.username, objectRef.namespace
+//     or objectRef.resource field, which become exclusions
+//
+// Anything else is rejected with an error naming it, rather than imported
+// with different semantics.
+func ImportSigma(data []byte) (Rule, error) {
+	var sigma sigmaRule
+	if err := yaml.Unmarshal(data, &sigma); err != nil {
+		return Rule{}, fmt.Errorf("failed to parse Sigma rule: %w", err)
+	}
+	if sigma.LogSource.Product != "kubernetes" || sigma.LogSource.Service != "audit" {
+		return Rule{}, fmt.Errorf("unsupported Sigma logsource %s/%s: only kubernetes/audit rules can be imported",
+			sigma.LogSource.Product, sigma.LogSource.Service)
+	}
+
+	rule := Rule{Name: sigma.Name, Description: sigma.Title}
+	if rule.Name == "" {
+		rule.Name = strings.Trim(nonNameChars.ReplaceAllString(strings.ToLower(sigma.Title), "-"), "-")
+	}
+	if sigma.Description != "" && rule.Description != "" {
+		rule.Description += ": " + strings.TrimSpace(sigma.Description)
+	}
+	if sigma.Level != "" {
+		severity, ok := sigmaLevels[sigma.Level]
+		if !ok {
+			return Rule{}, fmt.Errorf("unsupported Sigma level of %s: %s", rule.Name, sigma.Level)
+		}
+		rule.Severity = severity
+	}
+
+	if err := importSigmaDetection(&rule, sigma.Detection); err != nil {
+		return Rule{}, fmt.Errorf("Sigma rule %s: %w", rule.Name, err)
+	}
+	if err := rule.normalize(); err != nil {
+		return Rule{}, err
+	}
+	return rule, nil
+}
+
+// importSigmaDetection applies the condition, selections and timeframe of a
+// Sigma detection to rule
+func importSigmaDetection(rule *Rule, detection map[string]interface{}) error {
+	condition, ok := detection["condition"].(string)
+	if !ok {
+		return fmt.Errorf("detection needs a single condition")
+	}
+	if value, ok := detection["timeframe"]; ok {
+		window, err := parseSigmaTimeframe(fmt.Sprint(value))
+		if err != nil {
+			return err
+		}
+		rule.Window = window
+	}
+
+	expression, aggregation, _ := strings.Cut(condition, "|")
+	if aggregation = strings.TrimSpace(aggregation); aggregation != "" {
+		match := sigmaAggregationPattern.FindStringSubmatch(aggregation)
+		if match == nil {
+			return fmt.Errorf("unsupported aggregation %q", aggregation)
+		}
+		if match[1] != "" {
+			groupBy, ok := sigmaGroupByFields[match[1]]
+			if !ok {
+				return fmt.Errorf("unsupported aggregation field %s", match[1])
+			}
+			rule.GroupBy = groupBy
+		}
+		threshold, _ := strconv.Atoi(match[3])
+		if match[2] == ">" {
+			threshold++
+		}
+		rule.Threshold = threshold
+	}
+
+	tokens := strings.Fields(expression)
+	if len(tokens) == 0 {
+		return fmt.Errorf("empty condition")
+	}
+	for _, token := range tokens {
+		if lower := strings.ToLower(token); lower == "or" || lower == "of" || strings.ContainsAny(token, "()*") {
+			return fmt.Errorf("unsupported condition %q: only selections joined by \"and\" can be imported", condition)
+		}
+	}
+	for i := 0; i < len(tokens); i++ {
+		if i > 0 {
+			if !strings.EqualFold(tokens[i], "and") || i+1 == len(tokens) {
+				return fmt.Errorf("unsupported condition %q: only selections joined by \"and\" can be imported", condition)
+			}
+			i++
+		}
+		negate := strings.EqualFold(tokens[i], "not")
+		if negate {
+			if i+1 == len(tokens) {
+				return fmt.Errorf("unsupported condition %q", condition)
+			}
+			i++
+		}
+		name := tokens[i]
+		if name == "condition" || name == "timeframe" {
+			return fmt.Errorf("unsupported condition %q", condition)
+		}
+		selection, err := sigmaSelection(detection, name)
+		if err != nil {
+			return err
+		}
+		if negate {
+			err = applySigmaExclusion(&rule.Params, name, selection)
+		} else {
+			err = applySigmaSelection(&rule.Params, selection)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sigmaSelection returns the field map of a named selection
+func sigmaSelection(detection map[string]interface{}, name string) (map[string]interface{}, error) {
+	value, ok := detection[name]
+	if !ok {
+		return nil, fmt.Errorf("condition names unknown selection %s", name)
+	}
+	if list, ok := value.([]interface{}); ok && len(list) == 1 {
+		value = list[0]
+	}
+	selection, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("selection %s must map fields to values; keyword lists and lists of maps are not supported", name)
+	}
+	return selection, nil
+}
+
+// sigmaValues returns the values of a selection field as strings
+func sigmaValues(field string, value interface{}) ([]string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		items = []interface{}{value}
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case string, int, bool, float64:
+			values = append(values, fmt.Sprint(item))
+		default:
+			return nil, fmt.Errorf("unsupported value of %s: %v", field, item)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values for %s", field)
+	}
+	return values, nil
+}
+
+// applySigmaSelection adds the fields of a selection to params
+func applySigmaSelection(params *types.AuditQueryParams, selection map[string]interface{}) error {
+	keys := make([]string, 0, len(selection))
+	for key := range selection {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := selection[key]
+		field, modifier, _ := strings.Cut(key, "|")
+		if strings.Contains(modifier, "|") {
+			return fmt.Errorf("unsupported modifiers of %s: %s", field, modifier)
+		}
+		values, err := sigmaValues(field, value)
+		if err != nil {
+			return err
+		}
+		if err := applySigmaField(params, field, modifier, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySigmaField sets the query parameter of a Sigma field
+func applySigmaField(params *types.AuditQueryParams, field, modifier string, values []string) error {
+	unsupported := func() error {
+		if modifier != "" {
+			return fmt.Errorf("unsupported modifier %s of %s", modifier, field)
+		}
+		return fmt.Errorf("unsupported field %s", field)
+	}
+	single := func(target *string) error {
+		if modifier != "" {
+			return unsupported()
+		}
+		return setSigmaValue(target, field, values)
+	}
+
+	switch field {
+	case "verb":
+		return setSigmaMatch(&params.Verbs, &params.VerbMatch, field, modifier, values)
+	case "objectRef.resource":
+		return setSigmaMatch(&params.Resources, &params.ResourceMatch, field, modifier, values)
+	case "objectRef.namespace":
+		return setSigmaMatch(&params.Namespaces, &params.NamespaceMatch, field, modifier, values)
+	case "user.username":
+		return setSigmaMatch(&params.Usernames, &params.UsernameMatch, field, modifier, values)
+	case "userAgent":
+		var userAgents []string
+		if err := setSigmaMatch(&userAgents, &params.UserAgentMatch, field, modifier, values); err != nil {
+			return err
+		}
+		return setSigmaValue(&params.UserAgent, field, userAgents)
+	case "user.groups":
+		if modifier != "" || len(params.Groups) > 0 {
+			return unsupported()
+		}
+		params.Groups = values
+		return nil
+	case "sourceIPs":
+		switch modifier {
+		case "":
+			return setSigmaValue(&params.SourceIP, field, values)
+		case "cidr":
+			return setSigmaValue(&params.SourceCIDR, field, values)
+		}
+		return unsupported()
+	case "responseStatus.code":
+		if modifier != "" || len(values) != 1 || params.StatusCode != 0 {
+			return fmt.Errorf("%s needs a single exact value", field)
+		}
+		code, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", field, values[0])
+		}
+		params.StatusCode = code
+		return nil
+	case "stage":
+		return single(&params.Stage)
+	case "level":
+		return single(&params.Level)
+	case "impersonatedUser.username":
+		return single(&params.ImpersonatedUser)
+	}
+
+	key, ok := sigmaEventKeys[field]
+	if !ok || modifier != "" {
+		return unsupported()
+	}
+	// Fields without a parameter are matched as "key":"value" in the event
+	var alternatives []types.FilterExpression
+	for _, value := range values {
+		if !sigmaEventValuePattern.MatchString(value) {
+			return fmt.Errorf("unsupported value of %s: %s", field, value)
+		}
+		alternatives = append(alternatives, types.FilterExpression{
+			Pattern: fmt.Sprintf(`"%s":"%s"`, key, strings.ReplaceAll(value, ".", `\.`)),
+		})
+	}
+	match := alternatives[0]
+	if len(alternatives) > 1 {
+		match = types.FilterExpression{Operator: types.FilterOperatorOr, Children: alternatives}
+	}
+	switch {
+	case params.Filter == nil:
+		params.Filter = &match
+	case params.Filter.Operator == types.FilterOperatorAnd:
+		params.Filter.Children = append(params.Filter.Children, match)
+	default:
+		params.Filter = &types.FilterExpression{Operator: types.FilterOperatorAnd, Children: []types.FilterExpression{*params.Filter, match}}
+	}
+	return nil
+}
+
+// setSigmaMatch sets a multi-value field filter and its match mode from a
+// Sigma modifier. Sigma values match whole field values unless a modifier
+// says otherwise.
+func setSigmaMatch(target *[]string, mode *types.MatchMode, field, modifier string, values []string) error {
+	if len(*target) > 0 {
+		return fmt.Errorf("%s appears in more than one selection", field)
+	}
+	switch modifier {
+	case "":
+		*mode = types.MatchModeExact
+	case "contains":
+		*mode = types.MatchModeSubstring
+	case "startswith":
+		*mode = types.MatchModePrefix
+	case "endswith":
+		*mode = types.MatchModeRegex
+		suffixes := make([]string, len(values))
+		for i, value := range values {
+			suffixes[i] = regexp.QuoteMeta(value) + "$"
+		}
+		values = suffixes
+	case "re":
+		*mode = types.MatchModeRegex
+	default:
+		return fmt.Errorf("unsupported modifier %s of %s", modifier, field)
+	}
+	*target = values
+	return nil
+}
+
+// setSigmaValue sets a single-value parameter
+func setSigmaValue(target *string, field string, values []string) error {
+	if len(values) != 1 || *target != "" {
+		return fmt.Errorf("%s needs a single value", field)
+	}
+	*target = values[0]
+	return nil
+}
+
+// applySigmaExclusion turns a negated selection of one field into an exclusion
+func applySigmaExclusion(params *types.AuditQueryParams, name string, selection map[string]interface{}) error {
+	if len(selection) != 1 {
+		return fmt.Errorf("negated selection %s must have a single field", name)
+	}
+	for key, value := range selection {
+		field, modifier, _ := strings.Cut(key, "|")
+		values, err := sigmaValues(field, value)
+		if err != nil {
+			return err
+		}
+		switch modifier {
+		case "":
+		case "startswith":
+			for i := range values {
+				values[i] += "*"
+			}
+		default:
+			return fmt.Errorf("unsupported modifier %s of negated %s", modifier, field)
+		}
+		switch field {
+		case "verb":
+			params.ExcludeVerbs = append(params.ExcludeVerbs, values...)
+		case "objectRef.resource":
+			params.ExcludeResources = append(params.ExcludeResources, values...)
+		case "objectRef.namespace":
+			params.ExcludeNamespaces = append(params.ExcludeNamespaces, values...)
+		case "user.username":
+			params.ExcludeUsers = append(params.ExcludeUsers, values...)
+		default:
+			return fmt.Errorf("unsupported negated field %s", field)
+		}
+	}
+	return nil
+}
+
+// parseSigmaTimeframe parses a Sigma timeframe such as 30s, 5m, 1h or 1d
+func parseSigmaTimeframe(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if count, err := strconv.Atoi(days); err == nil && count > 0 {
+			return time.Duration(count) * 24 * time.Hour, nil
+		}
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("invalid timeframe %s", value)
+	}
+	return window, nil
+}
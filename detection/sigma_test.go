@@ -0,0 +1,147 @@
+package detection
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// sigmaPodExec is a Sigma rule in the style of the community Kubernetes audit rules
+const sigmaPodExec = `title: Kubernetes Pod Exec Outside System Namespaces
+id: 4bb8ab2b-6d4c-4b7c-9d2e-8f6c0f1e0a01
+status: experimental
+description: Detects exec into pods by users.
+logsource:
+  product: kubernetes
+  service: audit
+detection:
+  selection:
+    verb: create
+    objectRef.resource: pods
+    objectRef.subresource: exec
+  filter:
+    objectRef.namespace|startswith: openshift-
+  condition: selection and not filter
+level: high
+`
+
+// TestImportSigma tests converting a Sigma rule into a watch rule
+func TestImportSigma(t *testing.T) {
+	rule, err := ImportSigma([]byte(sigmaPodExec))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rule.Name != "kubernetes-pod-exec-outside-system-namespaces" {
+		t.Errorf("Unexpected name: %s", rule.Name)
+	}
+	if rule.Description != "Kubernetes Pod Exec Outside System Namespaces: Detects exec into pods by users." {
+		t.Errorf("Unexpected description: %s", rule.Description)
+	}
+	if rule.Severity != "high" || rule.Threshold != DefaultThreshold || rule.Window != DefaultWindow {
+		t.Errorf("Unexpected rule: %+v", rule)
+	}
+	params := rule.Params
+	if len(params.Verbs) != 1 || params.Verbs[0] != "create" || params.VerbMatch != types.MatchModeExact {
+		t.Errorf("Unexpected verbs: %v (%s)", params.Verbs, params.VerbMatch)
+	}
+	if len(params.Resources) != 1 || params.Resources[0] != "pods" {
+		t.Errorf("Unexpected resources: %v", params.Resources)
+	}
+	if params.Filter == nil || params.Filter.Pattern != `"subresource":"exec"` {
+		t.Errorf("Unexpected filter: %v", params.Filter)
+	}
+	if len(params.ExcludeNamespaces) != 1 || params.ExcludeNamespaces[0] != "openshift-*" {
+		t.Errorf("Unexpected exclusions: %v", params.ExcludeNamespaces)
+	}
+}
+
+// TestImportSigma_Aggregation tests importing a count aggregation over a timeframe
+func TestImportSigma_Aggregation(t *testing.T) {
+	rule, err := ImportSigma([]byte(`title: Secret enumeration
+name: secret-enumeration
+logsource:
+  product: kubernetes
+  service: audit
+detection:
+  selection:
+    verb: [get, list]
+    objectRef.resource: secrets
+    user.username|startswith: 'system:serviceaccount:'
+    responseStatus.code: 403
+  timeframe: 1d
+  condition: selection | count() by user.username > 10
+level: informational
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rule.Name != "secret-enumeration" || rule.Severity != "low" {
+		t.Errorf("Unexpected rule: %+v", rule)
+	}
+	if rule.Threshold != 11 || rule.Window != 24*time.Hour || rule.GroupBy != "username" {
+		t.Errorf("Unexpected aggregation: threshold %d, window %s, group by %s", rule.Threshold, rule.Window, rule.GroupBy)
+	}
+	if len(rule.Params.Verbs) != 2 || rule.Params.UsernameMatch != types.MatchModePrefix || rule.Params.StatusCode != 403 {
+		t.Errorf("Unexpected params: %+v", rule.Params)
+	}
+}
+
+// TestImportSigma_Unsupported tests that rules outside the supported subset are rejected
+func TestImportSigma_Unsupported(t *testing.T) {
+	rule := func(logsource, detection string) string {
+		return "title: Test\nlogsource:\n" + logsource + "detection:\n" + detection
+	}
+	audit := "  product: kubernetes\n  service: audit\n"
+	for content, expected := range map[string]string{
+		rule("  product: windows\n", "  selection:\n    verb: get\n  condition: selection\n"):                                                  "unsupported Sigma logsource",
+		rule(audit, "  a:\n    verb: get\n  b:\n    verb: list\n  condition: a or b\n"):                                                        "only selections joined by \"and\"",
+		rule(audit, "  selection:\n    verb: get\n  condition: 1 of selection*\n"):                                                             "only selections joined by \"and\"",
+		rule(audit, "  selection:\n    verb: get\n  condition: other\n"):                                                                       "unknown selection other",
+		rule(audit, "  selection:\n    requestURI: /api\n  condition: selection\n"):                                                            "unsupported field requestURI",
+		rule(audit, "  selection:\n    stage|contains: Response\n  condition: selection\n"):                                                    "unsupported modifier contains of stage",
+		rule(audit, "  selection:\n    verb: get\n  filter:\n    verb: list\n    user.username: bob\n  condition: selection and not filter\n"): "must have a single field",
+		rule(audit, "  selection:\n    - exec\n    - attach\n  condition: selection\n"):                                                        "must map fields to values",
+		rule(audit, "  selection:\n    verb: get\n  condition: selection | count(user.username) > 5\n"):                                        "unsupported aggregation",
+		rule(audit, "  selection:\n    verb: destroy\n  condition: selection\n"):                                                               "invalid filters",
+	} {
+		if _, err := ImportSigma([]byte(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q for %q, got %v", expected, content, err)
+		}
+	}
+}
+
+// TestLoadRules_Sigma tests that rule directories load Sigma rules next to watch rules, and
+// that converted rules round-trip through MarshalRules
+func TestLoadRules_Sigma(t *testing.T) {
+	dir := t.TempDir()
+	writeRules(t, dir, "exec.yml", sigmaPodExec)
+	writeRules(t, dir, "rules.yaml", "rules:\n  - name: deletions\n    filters:\n      verb: delete\n")
+	rules, err := LoadRules(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Name != "kubernetes-pod-exec-outside-system-namespaces" {
+		t.Fatalf("Unexpected rules: %+v", rules)
+	}
+
+	data, err := MarshalRules(rules)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), "window: 5m\n") {
+		t.Errorf("Expected the window without zero units, got:\n%s", data)
+	}
+	reloaded, err := LoadRules(writeRules(t, t.TempDir(), "converted.yaml", string(data)))
+	if err != nil {
+		t.Fatalf("Unexpected error reloading:\n%s\n%v", data, err)
+	}
+	if len(reloaded) != 2 || reloaded[0].Params.Filter == nil || reloaded[0].Params.ExcludeNamespaces[0] != "openshift-*" || reloaded[0].Severity != "high" {
+		t.Errorf("Unexpected reloaded rules: %+v", reloaded)
+	}
+	if _, err := LoadRules(filepath.Join(dir, "exec.yml")); err != nil {
+		t.Errorf("Unexpected error loading a single Sigma rule: %v", err)
+	}
+}
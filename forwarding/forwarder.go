@@ -0,0 +1,387 @@
+package forwarding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// Supported destination types
+const (
+	TypeSplunkHEC     = "splunk_hec"
+	TypeElasticsearch = "elasticsearch"
+)
+
+// DestinationTypes lists the supported destination types
+var DestinationTypes = []string{TypeSplunkHEC, TypeElasticsearch}
+
+// Destination defaults
+const (
+	DefaultBatchSize    = 100
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = time.Second
+	DefaultTimeout      = 10 * time.Second
+	DefaultSource       = "audit-query-mcp-server"
+)
+
+// Destination is a SIEM endpoint that audit events can be pushed to
+type Destination struct {
+	Name string
+	Type string
+	// URL is the endpoint events are POSTed to: the HEC event endpoint for
+	// Splunk (.../services/collector/event) or the _bulk endpoint for Elasticsearch
+	URL   string
+	Token string
+	// Index is the Splunk index or Elasticsearch index events are written to
+	Index      string
+	SourceType string
+	BatchSize  int
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles on each retry
+	RetryBackoff time.Duration
+	Timeout      time.Duration
+}
+
+// destinationConfig is the JSON form of a Destination
+type destinationConfig struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	URL          string `json:"url"`
+	Token        string `json:"token"`
+	TokenEnv     string `json:"token_env"`
+	Index        string `json:"index"`
+	SourceType   string `json:"sourcetype"`
+	BatchSize    int    `json:"batch_size"`
+	MaxRetries   *int   `json:"max_retries"`
+	RetryBackoff string `json:"retry_backoff"`
+	Timeout      string `json:"timeout"`
+}
+
+// LoadConfig reads destinations from a JSON file of the form
+// {"destinations": [{"name": ..., "type": ..., "url": ...}, ...]}. A destination's
+// token may be read from the environment variable named by token_env so secrets
+// stay out of the file.
+func LoadConfig(path string) ([]Destination, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read forwarding config: %w", err)
+	}
+
+	var config struct {
+		Destinations []destinationConfig `json:"destinations"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse forwarding config: %w", err)
+	}
+
+	destinations := make([]Destination, 0, len(config.Destinations))
+	for _, raw := range config.Destinations {
+		destination := Destination{
+			Name:       raw.Name,
+			Type:       raw.Type,
+			URL:        raw.URL,
+			Token:      raw.Token,
+			Index:      raw.Index,
+			SourceType: raw.SourceType,
+			BatchSize:  raw.BatchSize,
+			MaxRetries: -1,
+		}
+		if raw.TokenEnv != "" {
+			destination.Token = os.Getenv(raw.TokenEnv)
+		}
+		if raw.MaxRetries != nil {
+			destination.MaxRetries = *raw.MaxRetries
+		}
+		if raw.RetryBackoff != "" {
+			if destination.RetryBackoff, err = time.ParseDuration(raw.RetryBackoff); err != nil {
+				return nil, fmt.Errorf("destination %s: invalid retry_backoff: %w", raw.Name, err)
+			}
+		}
+		if raw.Timeout != "" {
+			if destination.Timeout, err = time.ParseDuration(raw.Timeout); err != nil {
+				return nil, fmt.Errorf("destination %s: invalid timeout: %w", raw.Name, err)
+			}
+		}
+		destinations = append(destinations, destination)
+	}
+	return destinations, nil
+}
+
+// Forwarder pushes audit events to the configured SIEM destinations
+type Forwarder struct {
+	destinations map[string]Destination
+	client       *http.Client
+}
+
+// NewForwarder validates the destinations and fills in defaults. A negative
+// MaxRetries selects DefaultMaxRetries; zero disables retries.
+func NewForwarder(destinations []Destination) (*Forwarder, error) {
+	forwarder := &Forwarder{
+		destinations: make(map[string]Destination),
+		client:       &http.Client{},
+	}
+
+	for _, destination := range destinations {
+		if destination.Name == "" {
+			return nil, fmt.Errorf("destination name is required")
+		}
+		if _, exists := forwarder.destinations[destination.Name]; exists {
+			return nil, fmt.Errorf("duplicate destination name: %s", destination.Name)
+		}
+		if destination.Type != TypeSplunkHEC && destination.Type != TypeElasticsearch {
+			return nil, fmt.Errorf("destination %s: unsupported type %q (supported: %s)",
+				destination.Name, destination.Type, strings.Join(DestinationTypes, ", "))
+		}
+		if !strings.HasPrefix(destination.URL, "http://") && !strings.HasPrefix(destination.URL, "https://") {
+			return nil, fmt.Errorf("destination %s: url must be an http or https URL", destination.Name)
+		}
+		if destination.Type == TypeElasticsearch && destination.Index == "" {
+			return nil, fmt.Errorf("destination %s: index is required for elasticsearch", destination.Name)
+		}
+
+		if destination.BatchSize <= 0 {
+			destination.BatchSize = DefaultBatchSize
+		}
+		if destination.MaxRetries < 0 {
+			destination.MaxRetries = DefaultMaxRetries
+		}
+		if destination.RetryBackoff <= 0 {
+			destination.RetryBackoff = DefaultRetryBackoff
+		}
+		if destination.Timeout <= 0 {
+			destination.Timeout = DefaultTimeout
+		}
+		forwarder.destinations[destination.Name] = destination
+	}
+	return forwarder, nil
+}
+
+// Destinations returns the names of the configured destinations in sorted order
+func (f *Forwarder) Destinations() []string {
+	names := make([]string, 0, len(f.destinations))
+	for name := range f.destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Forward sends events to the named destination in batches of the destination's
+// BatchSize. Failed batches are retried with exponential backoff; a batch that
+// still fails is counted in the result and the remaining batches are still sent.
+// An error is returned only when the destination is unknown or ctx is cancelled.
+func (f *Forwarder) Forward(ctx context.Context, destinationName string, events []map[string]interface{}) (*types.ForwardResult, error) {
+	destination, ok := f.destinations[destinationName]
+	if !ok {
+		return nil, fmt.Errorf("unknown forwarding destination %q (configured: %s)",
+			destinationName, strings.Join(f.Destinations(), ", "))
+	}
+
+	result := &types.ForwardResult{
+		Destination: destination.Name,
+		Type:        destination.Type,
+		Events:      len(events),
+	}
+
+	for start := 0; start < len(events); start += destination.BatchSize {
+		end := start + destination.BatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		batch := events[start:end]
+		result.Batches++
+
+		failed, retries, err := f.sendBatch(ctx, destination, batch)
+		result.Retries += retries
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, ctxErr
+		}
+		result.Sent += len(batch) - failed
+		result.Failed += failed
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("batch %d: %v", result.Batches, err))
+		}
+	}
+
+	return result, nil
+}
+
+// retryableError marks a failure that may succeed if the batch is sent again
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+// sendBatch posts one batch, retrying retryable failures. It returns the number
+// of events that were not accepted and the number of retries made.
+func (f *Forwarder) sendBatch(ctx context.Context, destination Destination, batch []map[string]interface{}) (int, int, error) {
+	body, contentType, err := encodeBatch(destination, batch)
+	if err != nil {
+		return len(batch), 0, err
+	}
+
+	backoff := destination.RetryBackoff
+	retries := 0
+	for {
+		failed, err := f.post(ctx, destination, body, contentType, len(batch))
+		if err == nil {
+			return failed, retries, nil
+		}
+		if _, ok := err.(*retryableError); !ok || retries >= destination.MaxRetries {
+			return len(batch), retries, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return len(batch), retries, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		retries++
+	}
+}
+
+// post sends an encoded batch and checks the response. Network errors, 429 and
+// 5xx responses are retryable. For Elasticsearch the number of items the bulk
+// API rejected is returned.
+func (f *Forwarder) post(ctx context.Context, destination Destination, body []byte, contentType string, batchSize int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, destination.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if destination.Token != "" {
+		switch destination.Type {
+		case TypeSplunkHEC:
+			req.Header.Set("Authorization", "Splunk "+destination.Token)
+		case TypeElasticsearch:
+			req.Header.Set("Authorization", "ApiKey "+destination.Token)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, &retryableError{fmt.Errorf("request failed: %w", err)}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return 0, &retryableError{fmt.Errorf("destination returned %s", resp.Status)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("destination returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	if destination.Type == TypeElasticsearch {
+		return bulkFailures(respBody, batchSize), nil
+	}
+	return 0, nil
+}
+
+// bulkFailures counts the items an Elasticsearch bulk response reports as failed
+func bulkFailures(body []byte, batchSize int) int {
+	var response struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || !response.Errors {
+		return 0
+	}
+
+	failed := 0
+	for _, item := range response.Items {
+		for _, action := range item {
+			if action.Status >= 300 {
+				failed++
+			}
+		}
+	}
+	if failed > batchSize {
+		failed = batchSize
+	}
+	return failed
+}
+
+// encodeBatch serializes a batch in the destination's wire format and returns
+// the body and its content type
+func encodeBatch(destination Destination, batch []map[string]interface{}) ([]byte, string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	switch destination.Type {
+	case TypeSplunkHEC:
+		// HEC accepts several event objects concatenated in one request
+		for _, event := range batch {
+			envelope := map[string]interface{}{
+				"event":  event,
+				"source": DefaultSource,
+			}
+			if timestamp, ok := eventTime(event); ok {
+				envelope["time"] = float64(timestamp.UnixNano()) / float64(time.Second)
+			}
+			if destination.Index != "" {
+				envelope["index"] = destination.Index
+			}
+			if destination.SourceType != "" {
+				envelope["sourcetype"] = destination.SourceType
+			}
+			if err := encoder.Encode(envelope); err != nil {
+				return nil, "", fmt.Errorf("failed to encode event: %w", err)
+			}
+		}
+		return buf.Bytes(), "application/json", nil
+
+	case TypeElasticsearch:
+		action := map[string]interface{}{"index": map[string]string{"_index": destination.Index}}
+		for _, event := range batch {
+			document := event
+			if timestamp, ok := eventTime(event); ok {
+				document = make(map[string]interface{}, len(event)+1)
+				for key, value := range event {
+					document[key] = value
+				}
+				document["@timestamp"] = timestamp.Format(time.RFC3339Nano)
+			}
+			if err := encoder.Encode(action); err != nil {
+				return nil, "", fmt.Errorf("failed to encode bulk action: %w", err)
+			}
+			if err := encoder.Encode(document); err != nil {
+				return nil, "", fmt.Errorf("failed to encode event: %w", err)
+			}
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+
+	return nil, "", fmt.Errorf("unsupported destination type: %s", destination.Type)
+}
+
+// eventTime reads the event's timestamp field
+func eventTime(event map[string]interface{}) (time.Time, bool) {
+	value, ok := event["timestamp"].(string)
+	if !ok || value == "" {
+		return time.Time{}, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
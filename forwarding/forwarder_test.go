@@ -0,0 +1,263 @@
+package forwarding
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testEvents(n int) []map[string]interface{} {
+	events := make([]map[string]interface{}, n)
+	for i := range events {
+		events[i] = map[string]interface{}{
+			"timestamp": "2024-01-15T10:00:00Z",
+			"username":  "alice",
+			"verb":      "get",
+		}
+	}
+	return events
+}
+
+func TestNewForwarder_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination Destination
+		expectError bool
+	}{
+		{name: "valid splunk", destination: Destination{Name: "splunk", Type: TypeSplunkHEC, URL: "https://splunk:8088/services/collector/event"}},
+		{name: "valid elasticsearch", destination: Destination{Name: "es", Type: TypeElasticsearch, URL: "http://es:9200/_bulk", Index: "audit"}},
+		{name: "missing name", destination: Destination{Type: TypeSplunkHEC, URL: "https://splunk"}, expectError: true},
+		{name: "unsupported type", destination: Destination{Name: "x", Type: "kafka", URL: "https://x"}, expectError: true},
+		{name: "invalid url", destination: Destination{Name: "x", Type: TypeSplunkHEC, URL: "splunk:8088"}, expectError: true},
+		{name: "elasticsearch without index", destination: Destination{Name: "es", Type: TypeElasticsearch, URL: "http://es:9200/_bulk"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewForwarder([]Destination{tt.destination})
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	duplicate := Destination{Name: "splunk", Type: TypeSplunkHEC, URL: "https://splunk"}
+	if _, err := NewForwarder([]Destination{duplicate, duplicate}); err == nil {
+		t.Error("Expected error for duplicate destination names")
+	}
+}
+
+func TestForward_SplunkHEC(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") != "Splunk secret" {
+			t.Errorf("Unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		for decoder.More() {
+			var envelope map[string]interface{}
+			if err := decoder.Decode(&envelope); err != nil {
+				t.Fatalf("Invalid HEC payload: %v", err)
+			}
+			if envelope["index"] != "openshift" || envelope["sourcetype"] != "kube:audit" {
+				t.Errorf("Unexpected envelope %v", envelope)
+			}
+			if envelope["time"] != float64(1705312800) {
+				t.Errorf("Expected event time from timestamp, got %v", envelope["time"])
+			}
+			if event, ok := envelope["event"].(map[string]interface{}); !ok || event["username"] != "alice" {
+				t.Errorf("Unexpected event %v", envelope["event"])
+			}
+		}
+		w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	defer server.Close()
+
+	forwarder, err := NewForwarder([]Destination{{
+		Name: "splunk", Type: TypeSplunkHEC, URL: server.URL, Token: "secret",
+		Index: "openshift", SourceType: "kube:audit", BatchSize: 2,
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := forwarder.Forward(context.Background(), "splunk", testEvents(5))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Batches != 3 || result.Sent != 5 || result.Failed != 0 || requests != 3 {
+		t.Errorf("Expected 5 events sent in 3 batches, got %+v with %d requests", result, requests)
+	}
+}
+
+func TestForward_ElasticsearchBulk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected Content-Type %q", r.Header.Get("Content-Type"))
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		lines := 0
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Invalid bulk line %q: %v", scanner.Text(), err)
+			}
+			if lines%2 == 0 {
+				if action, ok := line["index"].(map[string]interface{}); !ok || action["_index"] != "audit" {
+					t.Errorf("Unexpected bulk action %v", line)
+				}
+			} else if line["@timestamp"] != "2024-01-15T10:00:00Z" {
+				t.Errorf("Expected @timestamp on document, got %v", line)
+			}
+			lines++
+		}
+		if lines != 6 {
+			t.Errorf("Expected 6 bulk lines, got %d", lines)
+		}
+
+		// Reject one of the three documents
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400}},{"index":{"status":201}}]}`))
+	}))
+	defer server.Close()
+
+	forwarder, err := NewForwarder([]Destination{{Name: "es", Type: TypeElasticsearch, URL: server.URL, Index: "audit"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := forwarder.Forward(context.Background(), "es", testEvents(3))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Sent != 2 || result.Failed != 1 {
+		t.Errorf("Expected 2 sent and 1 failed, got %+v", result)
+	}
+}
+
+func TestForward_Retry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	forwarder, err := NewForwarder([]Destination{{
+		Name: "splunk", Type: TypeSplunkHEC, URL: server.URL, MaxRetries: 3, RetryBackoff: time.Millisecond,
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := forwarder.Forward(context.Background(), "splunk", testEvents(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Sent != 1 || result.Retries != 2 {
+		t.Errorf("Expected success after 2 retries, got %+v", result)
+	}
+}
+
+func TestForward_Failures(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if strings.HasSuffix(r.URL.Path, "/bad") {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	forwarder, err := NewForwarder([]Destination{
+		{Name: "down", Type: TypeSplunkHEC, URL: server.URL + "/down", MaxRetries: 1, RetryBackoff: time.Millisecond},
+		{Name: "bad", Type: TypeSplunkHEC, URL: server.URL + "/bad", MaxRetries: 3, RetryBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := forwarder.Forward(context.Background(), "down", testEvents(2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Failed != 2 || result.Retries != 1 || len(result.Errors) != 1 {
+		t.Errorf("Expected a failed batch after 1 retry, got %+v", result)
+	}
+
+	requests = 0
+	result, err = forwarder.Forward(context.Background(), "bad", testEvents(2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Failed != 2 || result.Retries != 0 || requests != 1 {
+		t.Errorf("Expected client errors not to be retried, got %+v with %d requests", result, requests)
+	}
+	if !strings.Contains(result.Errors[0], "invalid token") {
+		t.Errorf("Expected the response body in the error, got %v", result.Errors)
+	}
+
+	if _, err := forwarder.Forward(context.Background(), "missing", testEvents(1)); err == nil {
+		t.Error("Expected error for unknown destination")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("TEST_HEC_TOKEN", "from-env")
+	path := filepath.Join(t.TempDir(), "forwarding.json")
+	config := `{"destinations": [
+		{"name": "splunk", "type": "splunk_hec", "url": "https://splunk:8088/services/collector/event", "token_env": "TEST_HEC_TOKEN", "retry_backoff": "2s", "max_retries": 0},
+		{"name": "es", "type": "elasticsearch", "url": "https://es:9200/_bulk", "index": "audit", "batch_size": 500}
+	]}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	destinations, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(destinations) != 2 {
+		t.Fatalf("Expected 2 destinations, got %d", len(destinations))
+	}
+	if destinations[0].Token != "from-env" || destinations[0].RetryBackoff != 2*time.Second || destinations[0].MaxRetries != 0 {
+		t.Errorf("Unexpected splunk destination %+v", destinations[0])
+	}
+
+	forwarder, err := NewForwarder(destinations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	es := forwarder.destinations["es"]
+	if es.BatchSize != 500 || es.MaxRetries != DefaultMaxRetries || es.Timeout != DefaultTimeout {
+		t.Errorf("Expected defaults to be applied, got %+v", es)
+	}
+	if names := forwarder.Destinations(); len(names) != 2 || names[0] != "es" {
+		t.Errorf("Unexpected destination names %v", names)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"destinations": [{"name": "x", "timeout": "soon"}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected error for invalid timeout")
+	}
+}
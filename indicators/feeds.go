@@ -0,0 +1,229 @@
+package indicators
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// mispIPTypes lists the MISP attribute types holding an IP address or network,
+// optionally followed by "|port"
+var mispIPTypes = []string{"ip-src", "ip-dst", "ip-src|port", "ip-dst|port"}
+
+var (
+	// stixComparisonPattern matches the comparisons of a STIX pattern this
+	// package supports: IP addresses and networks, and HTTP user agents
+	stixComparisonPattern = regexp.MustCompile(`(ipv4-addr|ipv6-addr):value\s*(?:=|ISSUBSET)\s*'([^']*)'|network-traffic:extensions\.'http-request-ext'\.request_header\.'User-Agent'\s*=\s*'((?:[^'\\]|\\.)*)'`)
+	// stixUnsupportedPattern matches STIX pattern operators that make a
+	// single comparison insufficient to match
+	stixUnsupportedPattern = regexp.MustCompile(`\b(AND|FOLLOWEDBY|WITHIN|REPEATS|NOT|MATCHES|LIKE)\b|!=`)
+)
+
+// Load reads the indicators of MISP event and STIX bundle JSON files. Each
+// path is a file or a directory whose .json files are read, such as a MISP
+// feed directory; its manifest.json is skipped. Indicators of unsupported
+// types or patterns are skipped.
+func Load(paths []string, now time.Time) ([]types.Indicator, error) {
+	var indicators []types.Indicator
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read indicators: %w", err)
+		}
+		files := []string{path}
+		if info.IsDir() {
+			files, err = filepath.Glob(filepath.Join(path, "*.json"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list indicators: %w", err)
+			}
+			sort.Strings(files)
+		}
+		for _, file := range files {
+			if info.IsDir() && filepath.Base(file) == "manifest.json" {
+				continue
+			}
+			loaded, err := LoadFile(file, now)
+			if err != nil {
+				return nil, err
+			}
+			indicators = append(indicators, loaded...)
+		}
+	}
+	return indicators, nil
+}
+
+// LoadFile reads the indicators of a MISP event or STIX bundle JSON file
+func LoadFile(path string, now time.Time) ([]types.Indicator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indicators: %w", err)
+	}
+	source := filepath.Base(path)
+
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse indicators %s: %w", source, err)
+	}
+	if object, ok := document.(map[string]interface{}); ok && object["type"] == "bundle" {
+		indicators, err := ParseSTIX(data, source, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STIX bundle %s: %w", source, err)
+		}
+		return indicators, nil
+	}
+	indicators, err := ParseMISP(data, source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MISP feed %s: %w", source, err)
+	}
+	return indicators, nil
+}
+
+// mispEvent is the part of a MISP event the loader reads
+type mispEvent struct {
+	Info      string          `json:"info"`
+	Attribute []mispAttribute `json:"Attribute"`
+	Object    []struct {
+		Attribute []mispAttribute `json:"Attribute"`
+	} `json:"Object"`
+}
+
+// mispAttribute is the part of a MISP attribute the loader reads
+type mispAttribute struct {
+	UUID    string `json:"uuid"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Comment string `json:"comment"`
+	Deleted bool   `json:"deleted"`
+}
+
+// ParseMISP reads the IP and user-agent attributes of MISP events, given as
+// one {"Event": ...} object, a list of them, or a {"response": [...]} search
+// result. Attributes inside MISP objects are included; deleted attributes are not.
+func ParseMISP(data []byte, source string) ([]types.Indicator, error) {
+	type wrapped struct {
+		Event *mispEvent `json:"Event"`
+	}
+	var events []wrapped
+	var single wrapped
+	var search struct {
+		Response []wrapped `json:"response"`
+	}
+	switch {
+	case json.Unmarshal(data, &events) == nil:
+	case json.Unmarshal(data, &single) == nil && single.Event != nil:
+		events = []wrapped{single}
+	case json.Unmarshal(data, &search) == nil && search.Response != nil:
+		events = search.Response
+	default:
+		return nil, fmt.Errorf("expected a MISP event, a list of events or a search response")
+	}
+
+	var indicators []types.Indicator
+	for _, event := range events {
+		if event.Event == nil {
+			return nil, fmt.Errorf("expected a MISP event, a list of events or a search response")
+		}
+		attributes := event.Event.Attribute
+		for _, object := range event.Event.Object {
+			attributes = append(attributes, object.Attribute...)
+		}
+		for _, attribute := range attributes {
+			if attribute.Deleted {
+				continue
+			}
+			indicator := types.Indicator{ID: attribute.UUID, Description: event.Event.Info, Source: source}
+			if attribute.Comment != "" {
+				indicator.Description = strings.TrimSpace(strings.Trim(indicator.Description+": "+attribute.Comment, ": "))
+			}
+			switch {
+			case containsString(mispIPTypes, attribute.Type):
+				indicator.Type = types.IndicatorTypeIP
+				indicator.Value, _, _ = strings.Cut(attribute.Value, "|")
+			case attribute.Type == "user-agent":
+				indicator.Type = types.IndicatorTypeUserAgent
+				indicator.Value = attribute.Value
+			default:
+				continue
+			}
+			indicators = append(indicators, indicator)
+		}
+	}
+	return indicators, nil
+}
+
+// stixObject is the part of a STIX 2.1 object the loader reads
+type stixObject struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Pattern     string `json:"pattern"`
+	PatternType string `json:"pattern_type"`
+	ValidUntil  string `json:"valid_until"`
+	Revoked     bool   `json:"revoked"`
+}
+
+// ParseSTIX reads the indicators of a STIX 2.1 bundle whose patterns compare
+// IP addresses, networks or HTTP user agents, joined by OR. Revoked indicators
+// and indicators whose valid_until is before now are skipped, as are patterns
+// with AND, qualifiers or other operators, which one audit event cannot match
+// on a single value.
+func ParseSTIX(data []byte, source string, now time.Time) ([]types.Indicator, error) {
+	var bundle struct {
+		Type    string       `json:"type"`
+		Objects []stixObject `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Type != "bundle" {
+		return nil, fmt.Errorf("expected a bundle, got %q", bundle.Type)
+	}
+
+	var indicators []types.Indicator
+	for _, object := range bundle.Objects {
+		if object.Type != "indicator" || object.Revoked || (object.PatternType != "" && object.PatternType != "stix") {
+			continue
+		}
+		if object.ValidUntil != "" {
+			if validUntil, err := time.Parse(time.RFC3339Nano, object.ValidUntil); err == nil && validUntil.Before(now) {
+				continue
+			}
+		}
+		if stixUnsupportedPattern.MatchString(object.Pattern) {
+			continue
+		}
+		description := object.Name
+		if object.Description != "" {
+			description = strings.TrimSpace(strings.Trim(description+": "+object.Description, ": "))
+		}
+		for _, match := range stixComparisonPattern.FindAllStringSubmatch(object.Pattern, -1) {
+			indicator := types.Indicator{ID: object.ID, Description: description, Source: source}
+			if match[1] != "" {
+				indicator.Type, indicator.Value = types.IndicatorTypeIP, match[2]
+			} else {
+				indicator.Type = types.IndicatorTypeUserAgent
+				indicator.Value = strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(match[3])
+			}
+			indicators = append(indicators, indicator)
+		}
+	}
+	return indicators, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package indicators
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// mispEventFeed is a MISP feed event with IP, user-agent and other attributes
+const mispEventFeed = `{"Event": {
+	"info": "Credential stuffing campaign",
+	"Attribute": [
+		{"uuid": "a1", "type": "ip-src", "value": "203.0.113.50", "comment": "login source"},
+		{"uuid": "a2", "type": "ip-dst|port", "value": "198.51.100.0/24|443"},
+		{"uuid": "a3", "type": "domain", "value": "evil.example.com"},
+		{"uuid": "a4", "type": "ip-src", "value": "192.0.2.1", "deleted": true}
+	],
+	"Object": [{"Attribute": [{"uuid": "a5", "type": "user-agent", "value": "curl/8.4.0"}]}]
+}}`
+
+// stixBundle is a STIX 2.1 bundle with supported, compound, revoked and expired indicators
+const stixBundle = `{"type": "bundle", "id": "bundle--1", "objects": [
+	{"type": "indicator", "id": "indicator--1", "name": "Scanner", "pattern_type": "stix",
+	 "pattern": "[ipv4-addr:value = '203.0.113.10'] OR [ipv6-addr:value ISSUBSET '2001:db8::/32']"},
+	{"type": "indicator", "id": "indicator--2", "name": "Tool", "description": "Offensive tooling",
+	 "pattern": "[network-traffic:extensions.'http-request-ext'.request_header.'User-Agent' = 'kube\\'hunter']"},
+	{"type": "indicator", "id": "indicator--3", "pattern": "[ipv4-addr:value = '192.0.2.2' AND ipv4-addr:value = '192.0.2.3']"},
+	{"type": "indicator", "id": "indicator--4", "revoked": true, "pattern": "[ipv4-addr:value = '192.0.2.4']"},
+	{"type": "indicator", "id": "indicator--5", "valid_until": "2020-01-01T00:00:00Z", "pattern": "[ipv4-addr:value = '192.0.2.5']"},
+	{"type": "indicator", "id": "indicator--6", "pattern_type": "sigma", "pattern": "title: x"},
+	{"type": "malware", "id": "malware--1", "name": "Not an indicator"}
+]}`
+
+// TestParseMISP tests reading IP and user-agent attributes of a MISP event
+func TestParseMISP(t *testing.T) {
+	indicators, err := ParseMISP([]byte(mispEventFeed), "event.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(indicators) != 3 {
+		t.Fatalf("Expected 3 indicators, got %+v", indicators)
+	}
+	expected := []types.Indicator{
+		{ID: "a1", Type: types.IndicatorTypeIP, Value: "203.0.113.50", Description: "Credential stuffing campaign: login source", Source: "event.json"},
+		{ID: "a2", Type: types.IndicatorTypeIP, Value: "198.51.100.0/24", Description: "Credential stuffing campaign", Source: "event.json"},
+		{ID: "a5", Type: types.IndicatorTypeUserAgent, Value: "curl/8.4.0", Description: "Credential stuffing campaign", Source: "event.json"},
+	}
+	for i, indicator := range indicators {
+		if indicator != expected[i] {
+			t.Errorf("Unexpected indicator %d: %+v", i, indicator)
+		}
+	}
+
+	// Lists of events and search responses are read too
+	for _, data := range []string{"[" + mispEventFeed + "]", `{"response": [` + mispEventFeed + `]}`} {
+		if indicators, err := ParseMISP([]byte(data), "events.json"); err != nil || len(indicators) != 3 {
+			t.Errorf("Unexpected result for %s: %+v, %v", data[:20], indicators, err)
+		}
+	}
+	if _, err := ParseMISP([]byte(`{"name": "not misp"}`), "other.json"); err == nil {
+		t.Error("Expected an error for a document without events")
+	}
+}
+
+// TestParseSTIX tests reading supported indicator patterns of a STIX bundle
+func TestParseSTIX(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	indicators, err := ParseSTIX([]byte(stixBundle), "bundle.json", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(indicators) != 3 {
+		t.Fatalf("Expected 3 indicators, got %+v", indicators)
+	}
+	if indicators[0].Value != "203.0.113.10" || indicators[1].Value != "2001:db8::/32" || indicators[0].ID != "indicator--1" {
+		t.Errorf("Unexpected IP indicators: %+v", indicators[:2])
+	}
+	if indicators[2].Type != types.IndicatorTypeUserAgent || indicators[2].Value != "kube'hunter" || indicators[2].Description != "Tool: Offensive tooling" {
+		t.Errorf("Unexpected user-agent indicator: %+v", indicators[2])
+	}
+}
+
+// TestLoad tests loading a MISP feed directory and a STIX bundle file
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	feed := filepath.Join(dir, "feed")
+	if err := os.Mkdir(feed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		filepath.Join(feed, "manifest.json"):  `{"uuid-1": {"info": "Credential stuffing campaign"}}`,
+		filepath.Join(feed, "uuid-1.json"):    mispEventFeed,
+		filepath.Join(dir, "bundle.json"):     stixBundle,
+		filepath.Join(dir, "invalid.json"):    `{"Event": `,
+		filepath.Join(dir, "not-a-feed.json"): `{"name": "other"}`,
+	} {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	indicators, err := Load([]string{feed, filepath.Join(dir, "bundle.json")}, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(indicators) != 6 || indicators[0].Source != "uuid-1.json" || indicators[5].Source != "bundle.json" {
+		t.Errorf("Unexpected indicators: %+v", indicators)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "invalid.json"), filepath.Join(dir, "not-a-feed.json"), filepath.Join(dir, "missing.json")} {
+		if _, err := Load([]string{path}, time.Now()); err == nil {
+			t.Errorf("Expected an error loading %s", path)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package indicators
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// network is an IP network indicator
+type network struct {
+	net   *net.IPNet
+	index int
+}
+
+// Set matches audit entries against indicators: a source IP against IP
+// addresses and networks, and the user agent against user agents, compared
+// case-insensitively as whole values
+type Set struct {
+	indicators []types.Indicator
+	ips        map[string][]int
+	networks   []network
+	userAgents map[string][]int
+}
+
+// NewSet validates indicators and indexes them for matching. An indicator
+// listed more than once, e.g. by two feeds, is kept once.
+func NewSet(indicators []types.Indicator) (*Set, error) {
+	set := &Set{ips: make(map[string][]int), userAgents: make(map[string][]int)}
+	seen := make(map[string]bool)
+	for _, indicator := range indicators {
+		indicator.Value = strings.TrimSpace(indicator.Value)
+		key := indicator.Type + "\x00" + strings.ToLower(indicator.Value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		index := len(set.indicators)
+		switch indicator.Type {
+		case types.IndicatorTypeIP:
+			if ip := net.ParseIP(indicator.Value); ip != nil {
+				set.ips[ip.String()] = append(set.ips[ip.String()], index)
+			} else if _, ipNet, err := net.ParseCIDR(indicator.Value); err == nil {
+				set.networks = append(set.networks, network{net: ipNet, index: index})
+			} else {
+				return nil, fmt.Errorf("invalid IP indicator %q from %s", indicator.Value, indicator.Source)
+			}
+		case types.IndicatorTypeUserAgent:
+			if indicator.Value == "" {
+				return nil, fmt.Errorf("empty user-agent indicator from %s", indicator.Source)
+			}
+			value := strings.ToLower(indicator.Value)
+			set.userAgents[value] = append(set.userAgents[value], index)
+		default:
+			return nil, fmt.Errorf("unsupported indicator type %q from %s", indicator.Type, indicator.Source)
+		}
+		set.indicators = append(set.indicators, indicator)
+	}
+	return set, nil
+}
+
+// Len returns the number of indicators
+func (s *Set) Len() int {
+	return len(s.indicators)
+}
+
+// Indicator returns the indicator at index, as returned by Match
+func (s *Set) Indicator(index int) types.Indicator {
+	return s.indicators[index]
+}
+
+// Match returns the indexes of the indicators a parsed audit entry matches,
+// each once, in indicator order
+func (s *Set) Match(entry map[string]interface{}) []int {
+	matched := make(map[int]bool)
+	for _, value := range sourceIPs(entry) {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			continue
+		}
+		for _, index := range s.ips[ip.String()] {
+			matched[index] = true
+		}
+		for _, network := range s.networks {
+			if network.net.Contains(ip) {
+				matched[network.index] = true
+			}
+		}
+	}
+	if userAgent, _ := entry["user_agent"].(string); userAgent != "" {
+		for _, index := range s.userAgents[strings.ToLower(strings.TrimSpace(userAgent))] {
+			matched[index] = true
+		}
+	}
+
+	var indexes []int
+	for index := range s.indicators {
+		if matched[index] {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// sourceIPs returns the source IPs of a parsed entry, which are strings in
+// fresh results and generic values in results restored from disk
+func sourceIPs(entry map[string]interface{}) []string {
+	switch ips := entry["source_ips"].(type) {
+	case []string:
+		return ips
+	case []interface{}:
+		values := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			if value, ok := ip.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	return nil
+}
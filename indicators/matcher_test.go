@@ -0,0 +1,56 @@
+package indicators
+
+import (
+	"reflect"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestSet_Match tests matching source IPs against addresses and networks, and user agents
+func TestSet_Match(t *testing.T) {
+	set, err := NewSet([]types.Indicator{
+		{Type: types.IndicatorTypeIP, Value: "203.0.113.50"},
+		{Type: types.IndicatorTypeIP, Value: "198.51.100.0/24"},
+		{Type: types.IndicatorTypeUserAgent, Value: "curl/8.4.0"},
+		{Type: types.IndicatorTypeIP, Value: " 203.0.113.50 "}, // listed by a second feed
+		{Type: types.IndicatorTypeIP, Value: "2001:db8::/32"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if set.Len() != 4 {
+		t.Errorf("Expected duplicate indicators to be kept once, got %d", set.Len())
+	}
+
+	for _, test := range []struct {
+		entry    map[string]interface{}
+		expected []int
+	}{
+		{map[string]interface{}{"source_ips": []string{"203.0.113.50"}}, []int{0}},
+		{map[string]interface{}{"source_ips": []interface{}{"10.0.0.1", "198.51.100.7"}, "user_agent": "CURL/8.4.0"}, []int{1, 2}},
+		{map[string]interface{}{"source_ips": []string{"2001:db8::1"}}, []int{3}},
+		{map[string]interface{}{"source_ips": []string{"10.0.0.1", "not an ip"}, "user_agent": "curl/8.5.0"}, nil},
+		{map[string]interface{}{}, nil},
+	} {
+		if matched := set.Match(test.entry); !reflect.DeepEqual(matched, test.expected) {
+			t.Errorf("Unexpected matches for %v: %v, expected %v", test.entry, matched, test.expected)
+		}
+	}
+	if set.Indicator(1).Value != "198.51.100.0/24" {
+		t.Errorf("Unexpected indicator: %+v", set.Indicator(1))
+	}
+}
+
+// TestNewSet_Invalid tests that malformed indicators are rejected
+func TestNewSet_Invalid(t *testing.T) {
+	for _, indicator := range []types.Indicator{
+		{Type: types.IndicatorTypeIP, Value: "evil.example.com"},
+		{Type: types.IndicatorTypeUserAgent, Value: " "},
+		{Type: "domain", Value: "evil.example.com"},
+	} {
+		if _, err := NewSet([]types.Indicator{indicator}); err == nil {
+			t.Errorf("Expected an error for %+v", indicator)
+		}
+	}
+}
@@ -1,75 +1,247 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
-	"os/exec"
+	"os/signal"
 	"strings"
+	"syscall"
 
+	"audit-query-mcp-server/benchmark"
+	"audit-query-mcp-server/deploy"
+	"audit-query-mcp-server/detection"
+	"audit-query-mcp-server/preflight"
+	"audit-query-mcp-server/providers"
 	"audit-query-mcp-server/server"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	server := server.NewAuditQueryMCPServer()
-	server.GetLogger().Info("OpenShift Audit Query MCP Server started")
+	runCommand(os.Args[1:])
+}
 
-	// Run setup if requested
-	if len(os.Args) > 1 && os.Args[1] == "setup" {
-		runSetup()
-		return
+// runVerifyTrail validates the audit trail hash chain and exits non-zero if it is broken
+func runVerifyTrail(args []string) {
+	path := server.AuditTrailFile()
+	if len(args) > 0 {
+		path = args[0]
 	}
 
-	// Run tests if this is a test run
-	if len(os.Args) > 1 && os.Args[1] == "test" {
-		// Remove the "test" argument so flag.Parse() works correctly
-		os.Args = append(os.Args[:1], os.Args[2:]...)
-		RunTestsWithArgs()
-		return
+	result, err := utils.VerifyAuditTrail(path)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, issue := range result.Issues {
+		fmt.Printf("  %s:%d [%s] %s\n", issue.File, issue.Line, issue.Type, issue.Detail)
+	}
+	if !result.Valid {
+		fmt.Printf("❌ %s\n", result.Summary)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %s\n", result.Summary)
+}
+
+// addAnalyzeFlags defines the flags of the analyze subcommand, whose filters
+// are set in params
+func addAnalyzeFlags(flags *pflag.FlagSet, params *types.AuditQueryParams, asJSON *bool) {
+	flags.StringVar(&params.LogSource, "log-source", "kube-apiserver", "Log source to analyze")
+	flags.StringVar(&params.Username, "username", "", "Filter by username")
+	flags.StringVar(&params.Verb, "verb", "", "Filter by verb")
+	flags.StringVar(&params.Resource, "resource", "", "Filter by resource")
+	flags.StringVar(&params.Namespace, "namespace", "", "Filter by namespace")
+	flags.StringVar(&params.Timeframe, "timeframe", "", "Filter by timeframe, e.g. 24h or 7d")
+	flags.BoolVar(asJSON, "json", false, "Print the full result as JSON")
+}
+
+// runAnalyze runs the filter, parse and summary pipeline on local audit log files
+func runAnalyze(srv *server.AuditQueryMCPServer, path string, params types.AuditQueryParams, asJSON bool) {
+	progress := func(percent float64, message string) {
+		fmt.Fprintf(os.Stderr, "[%3.0f%%] %s\n", percent, message)
+	}
+	result, err := srv.AnalyzeAuditFiles(path, params, progress)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
-	// Run HTTP server for testing if requested
-	if len(os.Args) > 1 && os.Args[1] == "serve" {
-		runHTTPServer(server)
+	if asJSON {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
 		return
 	}
+	fmt.Printf("✅ %s\n", result.Command)
+	fmt.Println(result.Summary)
+}
+
+// addDeployFlags defines the flags of the deploy subcommand, whose defaults
+// are the options
+func addDeployFlags(flags *pflag.FlagSet, options *deploy.Options) {
+	flags.StringVar(&options.Image, "image", "", "Container image of the server (required)")
+	flags.StringVar(&options.Name, "name", options.Name, "Name of the ServiceAccount, RBAC objects, Deployment and Service")
+	flags.StringVar(&options.Namespace, "namespace", options.Namespace, "Namespace to deploy into")
+	flags.IntVar(&options.Port, "port", options.Port, "HTTP port of the server")
+	flags.StringVar(&options.CPURequest, "cpu-request", options.CPURequest, "CPU request of the server container")
+	flags.StringVar(&options.CPULimit, "cpu-limit", options.CPULimit, "CPU limit of the server container")
+	flags.StringVar(&options.MemoryRequest, "memory-request", options.MemoryRequest, "Memory request of the server container")
+	flags.StringVar(&options.MemoryLimit, "memory-limit", options.MemoryLimit, "Memory limit of the server container")
+	flags.Int64Var(&options.MaxQueryBytes, "max-query-bytes", options.MaxQueryBytes, "Audit log bytes one query may read")
+	flags.DurationVar(&options.QueryTimeout, "query-timeout", options.QueryTimeout, "How long one query may read audit logs")
+}
+
+// runDeploy prints the manifests of a server running in the cluster with its
+// ServiceAccount and the in-cluster backend
+func runDeploy(options deploy.Options) {
+	manifests, err := deploy.Manifests(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Print(manifests)
+}
+
+// addSigmaFlags defines the flags of the sigma subcommand
+func addSigmaFlags(flags *pflag.FlagSet, notify *string) {
+	flags.StringVar(notify, "notify", "", "Comma-separated forwarding destinations of the converted rules")
+}
+
+// runSigma converts the Sigma rules of the paths into watch rules written to
+// stdout, exiting non-zero when a rule could not be converted
+func runSigma(notify string, paths []string) {
+	var destinations []string
+	for _, destination := range strings.Split(notify, ",") {
+		if destination = strings.TrimSpace(destination); destination != "" {
+			destinations = append(destinations, destination)
+		}
+	}
+
+	var rules []detection.Rule
+	failed := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var rule detection.Rule
+			rule, err = detection.ImportSigma(data)
+			if err == nil {
+				rule.Notify = destinations
+				rules = append(rules, rule)
+				continue
+			}
+		}
+		fmt.Fprintf(os.Stderr, "❌ Skipped %s: %v\n", path, err)
+		failed++
+	}
+
+	output, err := detection.MarshalRules(rules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(output))
+	fmt.Fprintf(os.Stderr, "Converted %d of %d Sigma rules\n", len(rules), len(rules)+failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// benchFlags are the flags of the bench subcommand
+type benchFlags struct {
+	sizes          string
+	scenarios      string
+	count          int
+	seed           int64
+	baselinePath   string
+	updateBaseline bool
+	maxRegression  float64
+	memProfileDir  string
+	asJSON         bool
+}
 
-	// Show usage information
-	showUsage()
+// addBenchFlags defines the flags of the bench subcommand
+func addBenchFlags(flags *pflag.FlagSet, config *benchFlags) {
+	flags.StringVar(&config.sizes, "sizes", "10k,100k,1m", "Comma-separated synthetic audit log sizes in lines")
+	flags.StringVar(&config.scenarios, "scenarios", "", "Comma-separated scenarios to run (default: all)")
+	flags.IntVar(&config.count, "count", 1, "Runs per scenario; the fastest is reported")
+	flags.Int64Var(&config.seed, "seed", benchmark.DefaultSeed, "Seed of the synthetic audit logs")
+	flags.StringVar(&config.baselinePath, "baseline", "./benchmarks/baseline.json", "Baseline file to compare with")
+	flags.BoolVar(&config.updateBaseline, "update-baseline", false, "Save the results as the new baseline")
+	flags.Float64Var(&config.maxRegression, "max-regression", benchmark.DefaultMaxRegression, "Slowdown or allocation growth in percent that fails the run")
+	flags.StringVar(&config.memProfileDir, "memprofile", "", "Directory to write allocation profiles to")
+	flags.BoolVar(&config.asJSON, "json", false, "Print the results as JSON")
 }
 
-func showUsage() {
-	fmt.Println("🚀 OpenShift Audit Query MCP Server")
-	fmt.Println("=====================================")
-	fmt.Println()
-	fmt.Println("Usage:")
-	fmt.Println("  ./audit-query-mcp-server setup   - Run environment setup and validation")
-	fmt.Println("  ./audit-query-mcp-server test    - Run tests (use -h for options)")
-	fmt.Println("  ./audit-query-mcp-server serve   - Start HTTP server for testing")
-	fmt.Println("  ./audit-query-mcp-server         - Show this help message")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Run setup and validation")
-	fmt.Println("  ./audit-query-mcp-server setup")
-	fmt.Println()
-	fmt.Println("  # Run all tests")
-	fmt.Println("  ./audit-query-mcp-server test -all")
-	fmt.Println()
-	fmt.Println("  # Run specific tests")
-	fmt.Println("  ./audit-query-mcp-server test command-builder validation")
-	fmt.Println()
-	fmt.Println("  # Run fast tests only")
-	fmt.Println("  ./audit-query-mcp-server test -skip-slow")
-	fmt.Println()
-	fmt.Println("  # Show test help")
-	fmt.Println("  ./audit-query-mcp-server test -h")
-	fmt.Println()
-	fmt.Println("  # Start HTTP server for testing")
-	fmt.Println("  ./audit-query-mcp-server serve")
-	fmt.Println()
-	fmt.Println("For production use, integrate this server with the MCP protocol.")
-	fmt.Println("See README.md for detailed usage instructions.")
+// runBench measures the execute, parse and summarize pipeline on synthetic
+// audit logs, prints a comparison with the stored baseline and exits non-zero
+// when a result regressed
+func runBench(config *benchFlags) {
+	options := benchmark.Options{
+		Count:         config.count,
+		Seed:          config.seed,
+		MemProfileDir: config.memProfileDir,
+		Progress: func(message string) {
+			fmt.Fprintf(os.Stderr, "%s\n", message)
+		},
+	}
+	var err error
+	if options.Sizes, err = benchmark.ParseSizes(config.sizes); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(2)
+	}
+	if config.scenarios != "" {
+		if options.Scenarios, err = benchmark.SelectScenarios(strings.Split(config.scenarios, ",")); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	var baseline *benchmark.Baseline
+	if !config.updateBaseline {
+		if baseline, err = benchmark.LoadBaseline(config.baselinePath); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "No baseline at %s; run with --update-baseline to record one\n", config.baselinePath)
+		}
+	}
+
+	results, err := benchmark.Run(context.Background(), options)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	comparisons := benchmark.Compare(results, baseline, config.maxRegression)
+
+	if config.asJSON {
+		output, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		benchmark.WriteTable(os.Stdout, comparisons)
+	}
+
+	if config.updateBaseline {
+		if err := benchmark.SaveBaseline(config.baselinePath, benchmark.NewBaseline(results)); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Baseline saved to %s\n", config.baselinePath)
+		return
+	}
+	if regressions := benchmark.Regressions(comparisons); len(regressions) > 0 {
+		fmt.Printf("❌ %d of %d results regressed by more than %.0f%%\n", len(regressions), len(comparisons), config.maxRegression)
+		os.Exit(1)
+	}
+	if baseline != nil {
+		fmt.Printf("✅ No result regressed by more than %.0f%%\n", config.maxRegression)
+	}
 }
 
 func runHTTPServer(srv *server.AuditQueryMCPServer) {
@@ -95,6 +267,25 @@ func runHTTPServer(srv *server.AuditQueryMCPServer) {
 		w.Write(jsonResponse)
 	})
 
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(srv.PrometheusMetrics()))
+	})
+
+	// Audit webhook sink for Kubernetes clusters whose API server pushes events
+	if sink, err := providers.WebhookSinkFromEnv(); err != nil {
+		srv.GetLogger().Warnf("Audit webhook sink disabled: %v", err)
+	} else if sink != nil {
+		http.Handle("/audit/webhook", sink)
+		srv.GetLogger().Info("Audit webhook sink listening on /audit/webhook")
+	}
+
+	// Admin API for operators, disabled without a token
+	if token := os.Getenv("AUDIT_ADMIN_TOKEN"); token != "" {
+		http.Handle(server.AdminPathPrefix, srv.AdminHandler(token))
+		srv.GetLogger().Info("Admin API listening on " + server.AdminPathPrefix)
+	}
+
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		html := `
@@ -119,17 +310,24 @@ func runHTTPServer(srv *server.AuditQueryMCPServer) {
     <div class="endpoint">
         <span class="method">GET</span> <code>/tools</code> - List available MCP tools
     </div>
+    <div class="endpoint">
+        <span class="method">GET</span> <code>/metrics</code> - Prometheus metrics
+    </div>
+    <div class="endpoint">
+        <span class="method">GET</span> <code>/admin/...</code> - Admin API (with <code>AUDIT_ADMIN_TOKEN</code>)
+    </div>
     
     <h2>Usage:</h2>
     <ul>
         <li><code>curl http://localhost:3000/health</code> - Check server health</li>
         <li><code>curl http://localhost:3000/tools</code> - List available tools</li>
+        <li><code>curl http://localhost:3000/metrics</code> - Scrape metrics</li>
     </ul>
     
     <h2>For Production:</h2>
-    <p>This HTTP server is for testing only. For production use, integrate with the MCP protocol.</p>
+    <p>This HTTP server is for testing only. For production use, start the server with the <code>mcp</code> command under an MCP host.</p>
     
-    <p><a href="/health">Health Check</a> | <a href="/tools">View Tools</a></p>
+    <p><a href="/health">Health Check</a> | <a href="/tools">View Tools</a> | <a href="/metrics">Metrics</a></p>
 </body>
 </html>`
 		w.Write([]byte(html))
@@ -139,7 +337,12 @@ func runHTTPServer(srv *server.AuditQueryMCPServer) {
 	srv.GetLogger().Info("Visit http://localhost" + port + " for testing interface")
 	srv.GetLogger().Info("Press Ctrl+C to stop the server")
 
-	if err := http.ListenAndServe(port, nil); err != nil {
+	shutdownOnSignal(srv)
+
+	// Watch rules are evaluated in the background while the server runs
+	srv.StartWatching(context.Background())
+
+	if err := http.ListenAndServe(port, server.CompressHandler(http.DefaultServeMux)); err != nil {
 		srv.GetLogger().Errorf("HTTP server failed: %v", err)
 		fmt.Printf("❌ Failed to start HTTP server: %v\n", err)
 		fmt.Printf("💡 Try using a different port: PORT=8081 ./audit-query-mcp-server serve\n")
@@ -147,111 +350,81 @@ func runHTTPServer(srv *server.AuditQueryMCPServer) {
 	}
 }
 
-func runSetup() {
-	fmt.Println("🔍 Testing Audit Query MCP Server Setup")
-	fmt.Println("======================================")
+// runMCP serves an MCP host over stdin and stdout until it closes stdin,
+// then saves the cache and closes the audit trail
+func runMCP(srv *server.AuditQueryMCPServer) {
+	shutdownOnSignal(srv)
 
-	// Check Go version
-	fmt.Println("Checking Go version...")
-	if goVersion, err := exec.Command("go", "version").Output(); err == nil {
-		version := strings.TrimSpace(string(goVersion))
-		fmt.Printf("✅ Go version: %s\n", version)
-	} else {
-		fmt.Println("❌ Go is not installed")
-		os.Exit(1)
-	}
+	// Watch rules are evaluated in the background, notifying the host
+	srv.StartWatching(context.Background())
 
-	// Check if we're in the right directory
-	if _, err := os.Stat("go.mod"); os.IsNotExist(err) {
-		fmt.Println("❌ go.mod not found. Please run this script from the audit-query-mcp-server directory")
-		os.Exit(1)
+	err := srv.ServeStdio(os.Stdin, os.Stdout)
+	if shutdownErr := srv.Shutdown(); shutdownErr != nil {
+		srv.GetLogger().Errorf("Shutdown failed: %v", shutdownErr)
 	}
-
-	// Check dependencies
-	fmt.Println("Checking Go dependencies...")
-	if cmd := exec.Command("go", "mod", "tidy"); cmd.Run() == nil {
-		fmt.Println("✅ Dependencies are up to date")
-	} else {
-		fmt.Println("❌ Failed to update dependencies")
+	if err != nil {
+		srv.GetLogger().Errorf("MCP stdio transport failed: %v", err)
 		os.Exit(1)
 	}
+}
 
-	// Check if .env file exists
-	if _, err := os.Stat(".env"); err == nil {
-		fmt.Println("✅ .env file found")
-	} else {
-		fmt.Println("⚠️  .env file not found. Creating from template...")
-		if _, err := os.Stat("env.example"); err == nil {
-			if input, err := os.ReadFile("env.example"); err == nil {
-				if err := os.WriteFile(".env", input, 0644); err == nil {
-					fmt.Println("✅ Created .env file from template")
-					fmt.Println("⚠️  Please edit .env and add your OpenAI API key")
-				} else {
-					fmt.Println("❌ Failed to create .env file")
-					os.Exit(1)
-				}
-			} else {
-				fmt.Println("❌ Failed to read env.example")
-				os.Exit(1)
-			}
-		} else {
-			fmt.Println("❌ env.example not found")
-			os.Exit(1)
+// shutdownOnSignal saves the cache and closes the audit trail on Ctrl+C or
+// SIGTERM, then exits
+func shutdownOnSignal(srv *server.AuditQueryMCPServer) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		srv.GetLogger().Info("Shutting down")
+		if err := srv.Shutdown(); err != nil {
+			srv.GetLogger().Errorf("Shutdown failed: %v", err)
 		}
-	}
+		os.Exit(0)
+	}()
+}
 
-	// Check OpenShift CLI
-	fmt.Println("Checking OpenShift CLI...")
-	if ocVersion, err := exec.Command("oc", "version", "--client").Output(); err == nil {
-		lines := strings.Split(string(ocVersion), "\n")
-		if len(lines) > 0 {
-			fmt.Printf("✅ OpenShift CLI: %s\n", strings.TrimSpace(lines[0]))
-		}
-	} else {
-		fmt.Println("❌ OpenShift CLI (oc) is not installed")
-		fmt.Println("   Install from: https://docs.openshift.com/container-platform/latest/cli_reference/openshift_cli/getting-started-cli.html")
-		os.Exit(1)
-	}
+// addSetupFlags defines the flags of the setup subcommand
+func addSetupFlags(flags *pflag.FlagSet, asJSON *bool) {
+	flags.BoolVar(asJSON, "json", false, "Print the preflight report as JSON")
+}
 
-	// Test OpenShift connection
-	fmt.Println("Testing OpenShift connection...")
-	if whoami, err := exec.Command("oc", "whoami").Output(); err == nil {
-		user := strings.TrimSpace(string(whoami))
-		fmt.Printf("✅ Connected to OpenShift as: %s\n", user)
-	} else {
-		fmt.Println("❌ Not connected to OpenShift cluster")
-		fmt.Println("   Run: oc login <cluster-url>")
-		os.Exit(1)
-	}
+// preflightIcons mark the status of each check printed by setup
+var preflightIcons = map[types.PreflightStatus]string{
+	types.PreflightPass: "✅",
+	types.PreflightWarn: "⚠️ ",
+	types.PreflightFail: "❌",
+	types.PreflightSkip: "⏭️ ",
+}
 
-	// Test basic OpenShift access
-	fmt.Println("Testing OpenShift access...")
-	if cmd := exec.Command("oc", "get", "nodes"); cmd.Run() == nil {
-		fmt.Println("✅ Can access OpenShift cluster nodes")
+// runSetup runs the preflight checks of a source checkout, creating .env
+// from env.example when it is missing, prints the report and exits non-zero
+// when a check failed
+func runSetup(srv *server.AuditQueryMCPServer, asJSON bool) {
+	report := srv.RunPreflight(preflight.DevelopmentChecks(".", true)...)
+	if asJSON {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(output))
 	} else {
-		fmt.Println("❌ Cannot access OpenShift cluster nodes")
-		fmt.Println("   Check your permissions and cluster status")
-		os.Exit(1)
+		fmt.Println("🔍 Audit Query MCP Server Preflight")
+		fmt.Println("===================================")
+		for _, check := range report.Checks {
+			fmt.Printf("%s %s: %s\n", preflightIcons[check.Status], check.Description, check.Detail)
+			if check.Remediation != "" {
+				fmt.Printf("   💡 %s\n", check.Remediation)
+			}
+		}
+		fmt.Println()
+		fmt.Printf("%s Preflight %s: %s\n", preflightIcons[report.Status], report.Status, report.Summary)
+		if report.Status != types.PreflightFail {
+			fmt.Println()
+			fmt.Println("To run the MCP server tests:")
+			fmt.Println("  ./audit-query-mcp-server test")
+			fmt.Println()
+			fmt.Println("To start the server:")
+			fmt.Println("  ./audit-query-mcp-server mcp")
+		}
 	}
-
-	// Test audit log access
-	fmt.Println("Testing audit log access...")
-	auditCmd := exec.Command("oc", "adm", "node-logs", "--role=master", "--path=kube-apiserver/audit.log", "--since=1h")
-	auditCmd.Stdout = nil
-	auditCmd.Stderr = nil
-	if auditCmd.Run() == nil {
-		fmt.Println("✅ Can access audit logs")
-	} else {
-		fmt.Println("⚠️  Cannot access audit logs (this might be normal depending on permissions)")
-		fmt.Println("   You may need cluster-admin privileges for audit log access")
+	if report.Status == types.PreflightFail {
+		os.Exit(1)
 	}
-
-	fmt.Println("")
-	fmt.Println("🎉 Setup test completed!")
-	fmt.Println("")
-	fmt.Println("To run the MCP server tests:")
-	fmt.Println("  ./audit-query-mcp-server test")
-	fmt.Println("")
-	fmt.Println("To start the server:")
-	fmt.Println("  ./audit-query-mcp-server serve")
 }
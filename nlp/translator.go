@@ -0,0 +1,203 @@
+// Package nlp translates natural-language audit questions to structured query parameters.
+// Translation is rule-based: it recognises log sources, verbs, resources, users,
+// namespaces, timeframes and outcomes, and reports each phrase it interpreted.
+package nlp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// DefaultLogSource is queried when the question does not name a log source
+const DefaultLogSource = "kube-apiserver"
+
+// phraseRule maps a phrase pattern to the value it selects
+type phraseRule struct {
+	pattern *regexp.Regexp
+	value   string
+}
+
+var logSourceRules = []phraseRule{
+	{regexp.MustCompile(`(?i)\boauth[- ]?api[- ]?server\b`), "oauth-apiserver"},
+	{regexp.MustCompile(`(?i)\bopenshift[- ]?api[- ]?server\b`), "openshift-apiserver"},
+	{regexp.MustCompile(`(?i)\b(oauth[- ]?server|logins?|log(?:ged)? in|authentications?)\b`), "oauth-server"},
+	{regexp.MustCompile(`(?i)\bnode logs?\b`), "node"},
+}
+
+var timeframeRules = []phraseRule{
+	{regexp.MustCompile(`(?i)\btoday\b`), "today"},
+	{regexp.MustCompile(`(?i)\byesterday\b`), "yesterday"},
+	{regexp.MustCompile(`(?i)\bthis week\b`), "this week"},
+	{regexp.MustCompile(`(?i)\blast week\b`), "last week"},
+	{regexp.MustCompile(`(?i)\bthis month\b`), "this month"},
+	{regexp.MustCompile(`(?i)\blast month\b`), "last month"},
+	{regexp.MustCompile(`(?i)\b(?:last|past) hour\b`), "last hour"},
+}
+
+var statusRules = []phraseRule{
+	{regexp.MustCompile(`(?i)\b(denied|forbidden|unauthori[sz]ed|not allowed)\b`), "auth_error"},
+	{regexp.MustCompile(`(?i)\bnot found\b`), "not_found"},
+	{regexp.MustCompile(`(?i)\bserver errors?\b`), "server_error"},
+	{regexp.MustCompile(`(?i)\b(failed|failures?|errors?)\b`), "400-599"},
+}
+
+var (
+	relativeTimePattern = regexp.MustCompile(`(?i)\b(?:last|past) (\d+) (minute|hour|day|week|month)s?\b`)
+	namespacePatterns   = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bin (?:the )?namespace ([a-z0-9][a-z0-9-]*)`),
+		regexp.MustCompile(`(?i)\bin (?:the )?([a-z0-9][a-z0-9-]*) namespace\b`),
+	}
+	impersonationPattern = regexp.MustCompile(`(?i)\bimpersonat\w* (?:user )?([\w:.@-]+)`)
+	usernamePatterns     = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\buser ([\w:.@-]+)`),
+		regexp.MustCompile(`(?i)\b(?:by|from) (system:[\w:.@-]+)`),
+	}
+	wordPattern = regexp.MustCompile(`[a-z]+`)
+)
+
+// verbSynonyms maps question words to API verbs. "list", "get" and "show" are left
+// out because questions use them as instructions ("list all deletions").
+var verbSynonyms = map[string]string{
+	"create": "create", "created": "create", "creates": "create", "creation": "create", "creations": "create",
+	"delete": "delete", "deleted": "delete", "deletes": "delete", "deletion": "delete", "deletions": "delete", "removed": "delete",
+	"update": "update", "updated": "update", "updates": "update", "modified": "update", "changed": "update",
+	"patch": "patch", "patched": "patch", "patches": "patch",
+	"listed": "list",
+	"read":   "get", "viewed": "get", "accessed": "get",
+	"watch": "watch", "watched": "watch",
+}
+
+// unitShortForms maps relative time units to the short timeframe forms
+var unitShortForms = map[string]string{"minute": "m", "hour": "h", "day": "d", "week": "w"}
+
+// TranslateQuestion translates a natural-language question to query parameters.
+// It fails when the question names no filter at all, rather than querying everything.
+func TranslateQuestion(question string) (*types.QuestionInterpretation, error) {
+	text := strings.TrimSpace(question)
+	if text == "" {
+		return nil, fmt.Errorf("question must not be empty")
+	}
+
+	interp := &types.QuestionInterpretation{Question: text}
+	params := &interp.Params
+	matched := func(phrase, meaning string) {
+		interp.Matched = append(interp.Matched, fmt.Sprintf("%q -> %s", phrase, meaning))
+	}
+
+	// Extract phrases with free-form values first and blank them out, so that
+	// e.g. the namespace "pods-prod" is not also read as the resource "pods"
+	if rule, phrase := firstMatch(logSourceRules, text); rule != nil {
+		params.LogSource = rule.value
+		matched(phrase, "log source "+rule.value)
+	} else {
+		params.LogSource = DefaultLogSource
+		interp.Notes = append(interp.Notes, "no log source named; querying "+DefaultLogSource)
+	}
+	sourceMatches := len(interp.Matched)
+
+	for _, pattern := range namespacePatterns {
+		if m := pattern.FindStringSubmatch(text); m != nil {
+			params.Namespace = strings.ToLower(m[1])
+			matched(m[0], "namespace "+params.Namespace)
+			text = strings.Replace(text, m[0], " ", 1)
+			break
+		}
+	}
+
+	if m := impersonationPattern.FindStringSubmatch(text); m != nil {
+		params.ImpersonatedUser = m[1]
+		matched(m[0], "impersonated user "+m[1])
+		text = strings.Replace(text, m[0], " ", 1)
+	}
+
+	for _, pattern := range usernamePatterns {
+		if m := pattern.FindStringSubmatch(text); m != nil {
+			params.Username = m[1]
+			matched(m[0], "username "+m[1])
+			text = strings.Replace(text, m[0], " ", 1)
+			break
+		}
+	}
+
+	if m := relativeTimePattern.FindStringSubmatch(text); m != nil {
+		params.Timeframe = relativeTimeframe(m[1], strings.ToLower(m[2]))
+		matched(m[0], "timeframe "+params.Timeframe)
+	} else if rule, phrase := firstMatch(timeframeRules, text); rule != nil {
+		params.Timeframe = rule.value
+		matched(phrase, "timeframe "+rule.value)
+	} else {
+		interp.Notes = append(interp.Notes, "no timeframe recognised; the whole current log is searched")
+	}
+
+	if rule, phrase := firstMatch(statusRules, text); rule != nil {
+		params.StatusCodeRange = rule.value
+		matched(phrase, "status code range "+rule.value)
+	}
+
+	var verbs, resources []string
+	for _, word := range wordPattern.FindAllString(strings.ToLower(text), -1) {
+		if verb, ok := verbSynonyms[word]; ok && !utils.Contains(verbs, verb) {
+			verbs = append(verbs, verb)
+			matched(word, "verb "+verb)
+		}
+		if resource := resourceForWord(word); resource != "" && !utils.Contains(resources, resource) {
+			resources = append(resources, resource)
+			matched(word, "resource "+resource)
+		}
+	}
+	params.Verb, params.Verbs = splitValues(verbs)
+	params.Resource, params.Resources = splitValues(resources)
+
+	if len(interp.Matched) == sourceMatches {
+		return interp, fmt.Errorf("no filters recognised in question %q; use structured_params instead", text)
+	}
+
+	return interp, nil
+}
+
+// firstMatch returns the first rule whose pattern matches the text, with the matched phrase
+func firstMatch(rules []phraseRule, text string) (*phraseRule, string) {
+	for i := range rules {
+		if phrase := rules[i].pattern.FindString(text); phrase != "" {
+			return &rules[i], phrase
+		}
+	}
+	return nil, ""
+}
+
+// relativeTimeframe converts "last N <unit>" to a supported timeframe
+func relativeTimeframe(count, unit string) string {
+	if unit == "month" {
+		// Months are approximated as 30 days; the short forms have no month unit
+		var months int
+		fmt.Sscanf(count, "%d", &months)
+		return fmt.Sprintf("%dd", months*30)
+	}
+	return count + unitShortForms[unit]
+}
+
+// resourceForWord returns the resource type a question word refers to, if any,
+// resolving kinds, singular and short names such as "crds" with the resource
+// dictionary. "event" and "events" usually mean audit events rather than the
+// resource.
+func resourceForWord(word string) string {
+	if word == "event" || word == "events" {
+		return ""
+	}
+	if resource, ok := utils.ResolveResource(word); ok {
+		return resource
+	}
+	return ""
+}
+
+// splitValues returns a single value as the first result and several values as the second
+func splitValues(values []string) (string, []string) {
+	if len(values) == 1 {
+		return values[0], nil
+	}
+	return "", values
+}
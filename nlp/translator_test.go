@@ -0,0 +1,89 @@
+package nlp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+func TestTranslateQuestion(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		expected types.AuditQueryParams
+	}{
+		{
+			name:     "verb, resource, namespace and timeframe",
+			question: "Who deleted secrets in the payments namespace yesterday?",
+			expected: types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete", Resource: "secrets", Namespace: "payments", Timeframe: "yesterday"},
+		},
+		{
+			name:     "several verbs and a relative timeframe",
+			question: "pods created or patched by user alice@example.com in the last 6 hours",
+			expected: types.AuditQueryParams{LogSource: "kube-apiserver", Verbs: []string{"create", "patch"}, Resource: "pods", Username: "alice@example.com", Timeframe: "6h"},
+		},
+		{
+			name:     "impersonation",
+			question: "everything done while impersonating system:admin this week",
+			expected: types.AuditQueryParams{LogSource: "kube-apiserver", ImpersonatedUser: "system:admin", Timeframe: "this week"},
+		},
+		{
+			name:     "log source and outcome",
+			question: "failed logins today",
+			expected: types.AuditQueryParams{LogSource: "oauth-server", StatusCodeRange: "400-599", Timeframe: "today"},
+		},
+		{
+			name:     "denied access",
+			question: "forbidden requests to configmaps in namespace kube-system",
+			expected: types.AuditQueryParams{LogSource: "kube-apiserver", StatusCodeRange: "auth_error", Resource: "configmaps", Namespace: "kube-system"},
+		},
+		{
+			name:     "resource short name",
+			question: "CRDs deleted yesterday",
+			expected: types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete", Resource: "customresourcedefinitions", Timeframe: "yesterday"},
+		},
+		{
+			name:     "resource kind",
+			question: "who patched a NetworkPolicy today",
+			expected: types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "patch", Resource: "networkpolicies", Timeframe: "today"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interp, err := TranslateQuestion(tt.question)
+			if err != nil {
+				t.Fatalf("TranslateQuestion() error = %v", err)
+			}
+			if !reflect.DeepEqual(interp.Params, tt.expected) {
+				t.Errorf("TranslateQuestion() params = %+v, want %+v", interp.Params, tt.expected)
+			}
+			if len(interp.Matched) == 0 {
+				t.Errorf("Expected matched phrases to be reported")
+			}
+		})
+	}
+}
+
+func TestTranslateQuestion_NoFilters(t *testing.T) {
+	for _, question := range []string{"", "show me what happened", "oauth server"} {
+		if _, err := TranslateQuestion(question); err == nil {
+			t.Errorf("Expected an error for question %q", question)
+		}
+	}
+}
+
+func TestTranslateQuestion_NamespaceIsNotResource(t *testing.T) {
+	interp, err := TranslateQuestion("deletions in the pods-prod namespace")
+	if err != nil {
+		t.Fatalf("TranslateQuestion() error = %v", err)
+	}
+	if interp.Params.Resource != "" {
+		t.Errorf("Expected no resource, got %q", interp.Params.Resource)
+	}
+	if !strings.Contains(strings.Join(interp.Matched, " "), "namespace pods-prod") {
+		t.Errorf("Expected namespace to be reported, got %v", interp.Matched)
+	}
+}
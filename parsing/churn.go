@@ -0,0 +1,161 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// Churn defaults
+const (
+	DefaultChurnWindow     = 10 * time.Minute
+	DefaultChurnMinChanges = 5
+)
+
+// ChurnVerbs are the verbs that change an object
+var ChurnVerbs = []string{"create", "delete", "patch", "update"}
+
+// churnKey identifies an object
+type churnKey struct {
+	resource, apiGroup, namespace, name string
+}
+
+// DetectChurn reports the objects changed at least minChanges times within
+// any window of the given length. Only successful create, delete, patch and
+// update requests on the objects themselves count: subresource changes such
+// as status updates, which controllers make continuously, and failed requests
+// do not. Objects created with a generated name are only known by their kind
+// and namespace, so their creations count together. Entries without a
+// parseable timestamp are skipped. Zero arguments select the defaults.
+func DetectChurn(entries []AuditLogEntry, window time.Duration, minChanges int) types.ChurnReport {
+	if window <= 0 {
+		window = DefaultChurnWindow
+	}
+	if minChanges <= 0 {
+		minChanges = DefaultChurnMinChanges
+	}
+
+	changes := make(map[churnKey][]timedEntry)
+	report := types.ChurnReport{Window: window.String(), MinChanges: minChanges, Objects: []types.ObjectChurn{}}
+	for _, entry := range entries {
+		if !isChurnEvent(entry) {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			continue
+		}
+		key := churnKey{entry.Resource, entry.APIGroup, entry.Namespace, entry.Name}
+		changes[key] = append(changes[key], timedEntry{at: at, entry: entry})
+		report.TotalChanges++
+	}
+	report.ChangedObjects = len(changes)
+
+	for key, timed := range changes {
+		sort.SliceStable(timed, func(i, j int) bool { return timed[i].at.Before(timed[j].at) })
+
+		// Slide a window over the changes for the busiest period
+		peak, peakStart := 0, 0
+		for start, end := 0, 0; end < len(timed); end++ {
+			for timed[end].at.Sub(timed[start].at) >= window {
+				start++
+			}
+			if count := end - start + 1; count > peak {
+				peak, peakStart = count, start
+			}
+		}
+		if peak < minChanges {
+			continue
+		}
+
+		object := types.ObjectChurn{
+			Resource:  key.resource,
+			APIGroup:  key.apiGroup,
+			Namespace: key.namespace,
+			Name:      key.name,
+			Changes:   len(timed),
+			Verbs:     make(map[string]int),
+			Peak:      peak,
+			PeakStart: timed[peakStart].entry.Timestamp,
+			FirstSeen: timed[0].entry.Timestamp,
+			LastSeen:  timed[len(timed)-1].entry.Timestamp,
+			Users:     []string{},
+		}
+		users := make(map[string]bool)
+		for _, change := range timed {
+			object.Verbs[change.entry.Verb]++
+			if change.entry.Username != "" && !users[change.entry.Username] {
+				users[change.entry.Username] = true
+				object.Users = append(object.Users, change.entry.Username)
+			}
+		}
+		sort.Strings(object.Users)
+		report.Objects = append(report.Objects, object)
+	}
+	sort.Slice(report.Objects, func(i, j int) bool {
+		a, b := report.Objects[i], report.Objects[j]
+		if a.Peak != b.Peak {
+			return a.Peak > b.Peak
+		}
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return objectLabel(a) < objectLabel(b)
+	})
+
+	report.Summary = summarizeChurn(report)
+	return report
+}
+
+// isChurnEvent reports whether an entry is a successful change of an object
+func isChurnEvent(entry AuditLogEntry) bool {
+	if entry.Resource == "" || entry.Subresource != "" || entry.StatusCode >= 400 {
+		return false
+	}
+	for _, verb := range ChurnVerbs {
+		if entry.Verb == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// objectLabel names an object as kind/namespace/name, such as
+// "secrets/payments/db-password"
+func objectLabel(object types.ObjectChurn) string {
+	resource := object.Resource
+	if object.APIGroup != "" {
+		resource += "." + object.APIGroup
+	}
+	parts := []string{resource}
+	if object.Namespace != "" {
+		parts = append(parts, object.Namespace)
+	}
+	if object.Name != "" {
+		parts = append(parts, object.Name)
+	} else {
+		parts = append(parts, "(generated names)")
+	}
+	return strings.Join(parts, "/")
+}
+
+// summarizeChurn produces a short human-readable description of a report
+func summarizeChurn(report types.ChurnReport) string {
+	summary := fmt.Sprintf("%d changes to %d objects", report.TotalChanges, report.ChangedObjects)
+	if len(report.Objects) == 0 {
+		return summary + fmt.Sprintf("; no object changed %d or more times within %s", report.MinChanges, report.Window)
+	}
+
+	var objects []string
+	for i, object := range report.Objects {
+		if i == 5 {
+			objects = append(objects, fmt.Sprintf("and %d more", len(report.Objects)-i))
+			break
+		}
+		objects = append(objects, fmt.Sprintf("%s (%d)", objectLabel(object), object.Peak))
+	}
+	return summary + fmt.Sprintf("; %d churning within %s: %s", len(report.Objects), report.Window, strings.Join(objects, ", "))
+}
@@ -0,0 +1,73 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDetectChurn tests counting object changes within sliding windows
+func TestDetectChurn(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	change := func(verb, resource, name string, minutes int) AuditLogEntry {
+		return AuditLogEntry{
+			Timestamp:  start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339Nano),
+			Verb:       verb,
+			Resource:   resource,
+			APIGroup:   map[string]string{"secrets": "", "widgets": "example.com"}[resource],
+			Namespace:  "payments",
+			Name:       name,
+			Username:   "system:serviceaccount:payments:operator",
+			StatusCode: 200,
+		}
+	}
+
+	var entries []AuditLogEntry
+	// The secret is recreated every two minutes for ten minutes
+	for minute := 0; minute < 10; minute += 2 {
+		entries = append(entries, change("delete", "secrets", "token", minute), change("create", "secrets", "token", minute+1))
+	}
+	// The widget changes five times, but spread over an hour
+	for minute := 0; minute < 60; minute += 12 {
+		entries = append(entries, change("patch", "widgets", "w1", minute))
+	}
+	ignored := []AuditLogEntry{
+		change("get", "secrets", "token", 1),
+		change("update", "secrets", "token", 2),
+		change("create", "secrets", "token", 3),
+	}
+	ignored[1].Subresource = "status"
+	ignored[2].StatusCode = 403
+	entries = append(entries, ignored...)
+	entries = append(entries, AuditLogEntry{Verb: "delete", Resource: "secrets", Name: "token", Namespace: "payments"})
+
+	report := DetectChurn(entries, 0, 0)
+	if report.Window != "10m0s" || report.MinChanges != DefaultChurnMinChanges {
+		t.Errorf("Unexpected defaults: %s, %d", report.Window, report.MinChanges)
+	}
+	if report.TotalChanges != 15 || report.ChangedObjects != 2 {
+		t.Errorf("Unexpected totals: %d changes to %d objects", report.TotalChanges, report.ChangedObjects)
+	}
+	if len(report.Objects) != 1 {
+		t.Fatalf("Expected 1 churning object, got %+v", report.Objects)
+	}
+	secret := report.Objects[0]
+	if secret.Resource != "secrets" || secret.Name != "token" || secret.Changes != 10 || secret.Peak != 10 || secret.Verbs["create"] != 5 || secret.Verbs["delete"] != 5 {
+		t.Errorf("Unexpected secret churn: %+v", secret)
+	}
+	if secret.PeakStart != entries[0].Timestamp || secret.FirstSeen != entries[0].Timestamp || len(secret.Users) != 1 {
+		t.Errorf("Unexpected secret churn: %+v", secret)
+	}
+	if !strings.Contains(report.Summary, "1 churning within 10m0s: secrets/payments/token (10)") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+
+	// Over a whole hour the widget churns too, after the secret
+	report = DetectChurn(entries, time.Hour, 5)
+	if len(report.Objects) != 2 || report.Objects[1].Name != "w1" || report.Objects[1].Peak != 5 {
+		t.Errorf("Unexpected churn within an hour: %+v", report.Objects)
+	}
+	if report = DetectChurn(entries, 0, 11); len(report.Objects) != 0 || !strings.Contains(report.Summary, "no object changed 11 or more times") {
+		t.Errorf("Unexpected churn above the threshold: %+v", report)
+	}
+}
@@ -0,0 +1,134 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// activityProfile collects the distinct values seen in a set of entries
+type activityProfile struct {
+	resources  map[string]bool
+	namespaces map[string]bool
+	sourceIPs  map[string]bool
+	verbs      map[string]int
+}
+
+// newActivityProfile profiles the resources, namespaces, source IPs and verb counts of entries
+func newActivityProfile(entries []AuditLogEntry) activityProfile {
+	profile := activityProfile{
+		resources:  make(map[string]bool),
+		namespaces: make(map[string]bool),
+		sourceIPs:  make(map[string]bool),
+		verbs:      make(map[string]int),
+	}
+	for _, entry := range entries {
+		if entry.Resource != "" {
+			resource := entry.Resource
+			if entry.Subresource != "" {
+				resource += "/" + entry.Subresource
+			}
+			profile.resources[resource] = true
+		}
+		if entry.Namespace != "" {
+			profile.namespaces[entry.Namespace] = true
+		}
+		for _, ip := range entry.SourceIPs {
+			if ip != "" {
+				profile.sourceIPs[ip] = true
+			}
+		}
+		if entry.Verb != "" {
+			profile.verbs[entry.Verb]++
+		}
+	}
+	return profile
+}
+
+// newValues returns the sorted keys of current that are absent from previous
+func newValues(previous, current map[string]bool) []string {
+	values := []string{}
+	for value := range current {
+		if !previous[value] {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// CompareActivity reports what the comparison entries did that the baseline
+// entries did not: resources, namespaces and source IPs seen only in the
+// comparison, and verbs whose event count changed
+func CompareActivity(baselineLabel string, baseline []AuditLogEntry, comparisonLabel string, comparison []AuditLogEntry) types.ActivityComparison {
+	before := newActivityProfile(baseline)
+	after := newActivityProfile(comparison)
+
+	result := types.ActivityComparison{
+		Baseline:      types.ActivityTotals{Label: baselineLabel, Events: len(baseline)},
+		Comparison:    types.ActivityTotals{Label: comparisonLabel, Events: len(comparison)},
+		NewResources:  newValues(before.resources, after.resources),
+		NewNamespaces: newValues(before.namespaces, after.namespaces),
+		NewSourceIPs:  newValues(before.sourceIPs, after.sourceIPs),
+		VerbChanges:   []types.VerbChange{},
+	}
+
+	verbs := make(map[string]bool)
+	for verb := range before.verbs {
+		verbs[verb] = true
+	}
+	for verb := range after.verbs {
+		verbs[verb] = true
+	}
+	for verb := range verbs {
+		if before.verbs[verb] != after.verbs[verb] {
+			result.VerbChanges = append(result.VerbChanges, types.VerbChange{
+				Verb:       verb,
+				Baseline:   before.verbs[verb],
+				Comparison: after.verbs[verb],
+				Delta:      after.verbs[verb] - before.verbs[verb],
+			})
+		}
+	}
+	sort.Slice(result.VerbChanges, func(i, j int) bool {
+		a, b := abs(result.VerbChanges[i].Delta), abs(result.VerbChanges[j].Delta)
+		if a != b {
+			return a > b
+		}
+		return result.VerbChanges[i].Verb < result.VerbChanges[j].Verb
+	})
+
+	result.Summary = summarizeComparison(result)
+	return result
+}
+
+// summarizeComparison produces a short human-readable description of a comparison
+func summarizeComparison(result types.ActivityComparison) string {
+	parts := []string{fmt.Sprintf("%s: %d events, %s: %d events",
+		result.Baseline.Label, result.Baseline.Events, result.Comparison.Label, result.Comparison.Events)}
+
+	if len(result.NewResources) > 0 {
+		parts = append(parts, "new resources: "+strings.Join(result.NewResources, ", "))
+	}
+	if len(result.NewNamespaces) > 0 {
+		parts = append(parts, "new namespaces: "+strings.Join(result.NewNamespaces, ", "))
+	}
+	if len(result.NewSourceIPs) > 0 {
+		parts = append(parts, "new source IPs: "+strings.Join(result.NewSourceIPs, ", "))
+	}
+	if len(result.VerbChanges) > 0 {
+		change := result.VerbChanges[0]
+		parts = append(parts, fmt.Sprintf("largest verb change: %s %d -> %d", change.Verb, change.Baseline, change.Comparison))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
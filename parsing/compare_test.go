@@ -0,0 +1,62 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareActivity(t *testing.T) {
+	baseline := []AuditLogEntry{
+		{Verb: "get", Resource: "pods", Namespace: "web", SourceIPs: []string{"10.0.0.1"}},
+		{Verb: "get", Resource: "pods", Namespace: "web", SourceIPs: []string{"10.0.0.1"}},
+		{Verb: "list", Resource: "configmaps", Namespace: "web", SourceIPs: []string{"10.0.0.1"}},
+	}
+	comparison := []AuditLogEntry{
+		{Verb: "get", Resource: "pods", Namespace: "web", SourceIPs: []string{"10.0.0.1"}},
+		{Verb: "get", Resource: "secrets", Namespace: "payments", SourceIPs: []string{"203.0.113.7"}},
+		{Verb: "delete", Resource: "secrets", Namespace: "payments", SourceIPs: []string{"203.0.113.7"}},
+		{Verb: "delete", Resource: "pods", Subresource: "eviction", Namespace: "web", SourceIPs: []string{"203.0.113.7"}},
+		{Verb: "list", Resource: "configmaps", Namespace: "web", SourceIPs: []string{"10.0.0.1"}},
+	}
+
+	result := CompareActivity("timeframe yesterday", baseline, "timeframe today", comparison)
+
+	if result.Baseline.Events != 3 || result.Comparison.Events != 5 {
+		t.Errorf("Unexpected totals: %+v / %+v", result.Baseline, result.Comparison)
+	}
+	if !reflect.DeepEqual(result.NewResources, []string{"pods/eviction", "secrets"}) {
+		t.Errorf("Unexpected new resources: %v", result.NewResources)
+	}
+	if !reflect.DeepEqual(result.NewNamespaces, []string{"payments"}) {
+		t.Errorf("Unexpected new namespaces: %v", result.NewNamespaces)
+	}
+	if !reflect.DeepEqual(result.NewSourceIPs, []string{"203.0.113.7"}) {
+		t.Errorf("Unexpected new source IPs: %v", result.NewSourceIPs)
+	}
+
+	// list is unchanged; delete rose by two, get is level at two
+	if len(result.VerbChanges) != 1 {
+		t.Fatalf("Expected 1 verb change, got %+v", result.VerbChanges)
+	}
+	change := result.VerbChanges[0]
+	if change.Verb != "delete" || change.Baseline != 0 || change.Comparison != 2 || change.Delta != 2 {
+		t.Errorf("Unexpected verb change: %+v", change)
+	}
+
+	if !strings.Contains(result.Summary, "new resources: pods/eviction, secrets") {
+		t.Errorf("Expected summary to list new resources, got %q", result.Summary)
+	}
+}
+
+func TestCompareActivity_NoChanges(t *testing.T) {
+	entries := []AuditLogEntry{{Verb: "get", Resource: "pods"}}
+	result := CompareActivity("username alice", entries, "username bob", entries)
+
+	if len(result.NewResources) != 0 || len(result.NewSourceIPs) != 0 || len(result.VerbChanges) != 0 {
+		t.Errorf("Expected no differences, got %+v", result)
+	}
+	if result.Summary != "username alice: 1 events, username bob: 1 events" {
+		t.Errorf("Unexpected summary %q", result.Summary)
+	}
+}
@@ -0,0 +1,182 @@
+package parsing
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// Privileged operation categories
+const (
+	PrivilegedEscalation        = "escalation"
+	PrivilegedRBACChange        = "rbac_change"
+	PrivilegedPodExec           = "pod_exec"
+	PrivilegedSecretChange      = "secret_change"
+	PrivilegedWebhookChange     = "webhook_change"
+	PrivilegedInfrastructure    = "infrastructure_change"
+	PrivilegedCSRApproval       = "csr_approval"
+	PrivilegedNamespaceDeletion = "namespace_deletion"
+)
+
+// DefaultSensitiveNamespaces are the namespaces whose access is reviewed when
+// none are given: the control plane's and those holding cluster-wide
+// configuration and credentials. A trailing "*" matches any suffix.
+var DefaultSensitiveNamespaces = []string{
+	"kube-system",
+	"openshift-config",
+	"openshift-config-managed",
+	"openshift-etcd",
+	"openshift-kube-apiserver",
+	"openshift-authentication",
+}
+
+// secretChangeVerbs are the verbs that change secrets
+var secretChangeVerbs = map[string]bool{"create": true, "update": true, "patch": true, "delete": true, "deletecollection": true}
+
+// privilegedCategory returns the category of an entry that grants, uses or
+// changes elevated access
+func privilegedCategory(entry AuditLogEntry) (string, bool) {
+	switch {
+	case escalationVerbs[entry.Verb]:
+		return PrivilegedEscalation, true
+	case isRBACChange(entry):
+		return PrivilegedRBACChange, true
+	case isPodExec(entry):
+		return PrivilegedPodExec, true
+	case entry.Resource == "secrets" && entry.Subresource == "" && secretChangeVerbs[entry.Verb]:
+		return PrivilegedSecretChange, true
+	case isWebhookChange(entry):
+		return PrivilegedWebhookChange, true
+	case entry.Resource == "certificatesigningrequests" && entry.Subresource == "approval":
+		return PrivilegedCSRApproval, true
+	case entry.Resource == "namespaces" && entry.Subresource == "" && entry.Verb == "delete":
+		return PrivilegedNamespaceDeletion, true
+	}
+	if _, ok := infrastructureCategory(entry); ok {
+		return PrivilegedInfrastructure, true
+	}
+	return "", false
+}
+
+// isHumanUser reports whether a username belongs to a person rather than a
+// service account, node or control plane component
+func isHumanUser(username string) bool {
+	return username != "" && !IsSystemUser(username)
+}
+
+// BuildComplianceReport builds the access review artifacts of a month from
+// its entries: the human users who made requests with their verbs and
+// namespaces, every privileged operation in time order, and the requests in
+// namespaces matching sensitiveNamespaces counted by user, verb and resource.
+// Nil sensitiveNamespaces selects DefaultSensitiveNamespaces. The events of
+// one request count once. Privileged operations and sensitive namespace
+// access include service accounts and failed requests, which are part of an
+// access review.
+func BuildComplianceReport(month string, entries []AuditLogEntry, sensitiveNamespaces []string) types.ComplianceReport {
+	if sensitiveNamespaces == nil {
+		sensitiveNamespaces = DefaultSensitiveNamespaces
+	}
+	sensitive := regexp.MustCompile(commands.ExclusionRegex(sensitiveNamespaces))
+	report := types.ComplianceReport{
+		Month:                month,
+		SensitiveNamespaces:  sensitiveNamespaces,
+		Users:                []types.ComplianceUser{},
+		PrivilegedOperations: []types.PrivilegedOperation{},
+		SensitiveAccess:      []types.SensitiveNamespaceAccess{},
+	}
+
+	users := make(map[string]*types.ComplianceUser)
+	userNamespaces := make(map[string]map[string]bool)
+	access := make(map[string]*types.SensitiveNamespaceAccess)
+	for _, entry := range uniqueRequests(entries, func(AuditLogEntry) bool { return true }) {
+		report.TotalEvents++
+		failed := entry.StatusCode >= 400
+
+		if isHumanUser(entry.Username) {
+			user := users[entry.Username]
+			if user == nil {
+				user = &types.ComplianceUser{Username: entry.Username, Groups: entry.Groups, Verbs: make(map[string]int), FirstSeen: entry.Timestamp}
+				users[entry.Username] = user
+				userNamespaces[entry.Username] = make(map[string]bool)
+			}
+			user.Events++
+			if failed {
+				user.Failed++
+			}
+			user.Verbs[entry.Verb]++
+			if entry.Namespace != "" {
+				userNamespaces[entry.Username][entry.Namespace] = true
+			}
+			user.LastSeen = entry.Timestamp
+		}
+
+		if category, ok := privilegedCategory(entry); ok {
+			report.PrivilegedOperations = append(report.PrivilegedOperations, types.PrivilegedOperation{
+				Timestamp:   entry.Timestamp,
+				Username:    entry.Username,
+				Category:    category,
+				Verb:        entry.Verb,
+				Resource:    entry.Resource,
+				Subresource: entry.Subresource,
+				Namespace:   entry.Namespace,
+				Name:        entry.Name,
+				StatusCode:  entry.StatusCode,
+				SourceIPs:   entry.SourceIPs,
+				AuditID:     entry.AuditID,
+			})
+		}
+
+		if entry.Namespace != "" && sensitive.MatchString(entry.Namespace) {
+			key := strings.Join([]string{entry.Namespace, entry.Username, entry.Verb, entry.Resource}, "\x00")
+			row := access[key]
+			if row == nil {
+				row = &types.SensitiveNamespaceAccess{Namespace: entry.Namespace, Username: entry.Username, Verb: entry.Verb, Resource: entry.Resource, FirstSeen: entry.Timestamp}
+				access[key] = row
+			}
+			row.Events++
+			if failed {
+				row.Failed++
+			}
+			row.LastSeen = entry.Timestamp
+		}
+	}
+
+	for username, user := range users {
+		user.Namespaces = sortedKeys(userNamespaces[username])
+		report.Users = append(report.Users, *user)
+	}
+	sort.Slice(report.Users, func(i, j int) bool { return report.Users[i].Username < report.Users[j].Username })
+	for _, row := range access {
+		report.SensitiveAccess = append(report.SensitiveAccess, *row)
+	}
+	sort.Slice(report.SensitiveAccess, func(i, j int) bool {
+		a, b := report.SensitiveAccess[i], report.SensitiveAccess[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Verb < b.Verb
+	})
+
+	report.Summary = summarizeCompliance(report)
+	return report
+}
+
+// summarizeCompliance produces a short human-readable description of a report
+func summarizeCompliance(report types.ComplianceReport) string {
+	accessors := make(map[string]bool)
+	for _, row := range report.SensitiveAccess {
+		accessors[row.Username] = true
+	}
+	return fmt.Sprintf("%s: %d events, %d human users with cluster access, %d privileged operations, %d users accessing sensitive namespaces",
+		report.Month, report.TotalEvents, len(report.Users), len(report.PrivilegedOperations), len(accessors))
+}
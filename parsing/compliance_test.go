@@ -0,0 +1,56 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestBuildComplianceReport tests the human users, privileged operations and sensitive namespace access of a month
+func TestBuildComplianceReport(t *testing.T) {
+	entries := []AuditLogEntry{
+		{AuditID: "a1", Timestamp: "2026-03-01T10:00:00.000000Z", Username: "alice", Groups: []string{"developers"}, Verb: "get", Resource: "pods", Namespace: "web", StatusCode: 200},
+		{AuditID: "a2", Timestamp: "2026-03-02T10:00:00.000000Z", Username: "alice", Verb: "create", Resource: "clusterrolebindings", Name: "admins", StatusCode: 201},
+		{AuditID: "a3", Stage: "ResponseStarted", Timestamp: "2026-03-03T10:00:00.000000Z", Username: "alice", Verb: "create", Resource: "pods", Subresource: "exec", Namespace: "kube-system", Name: "etcd", StatusCode: 101},
+		{AuditID: "a3", Stage: "ResponseComplete", Timestamp: "2026-03-03T10:00:00.000000Z", Username: "alice", Verb: "create", Resource: "pods", Subresource: "exec", Namespace: "kube-system", Name: "etcd", StatusCode: 101},
+		{AuditID: "a4", Timestamp: "2026-03-04T10:00:00.000000Z", Username: "bob", Verb: "get", Resource: "secrets", Namespace: "payments", StatusCode: 403},
+		{AuditID: "a5", Timestamp: "2026-03-05T10:00:00.000000Z", Username: "system:serviceaccount:kube-system:deployer", Verb: "patch", Resource: "secrets", Namespace: "kube-system", Name: "token", StatusCode: 200},
+		{AuditID: "a6", Timestamp: "2026-03-06T10:00:00.000000Z", Username: "system:node:worker-0", Verb: "get", Resource: "configmaps", Namespace: "web", StatusCode: 200},
+	}
+
+	report := BuildComplianceReport("2026-03", entries, []string{"kube-system", "pay*"})
+	if report.TotalEvents != 6 {
+		t.Errorf("Expected the exec session to count once, got %d events", report.TotalEvents)
+	}
+
+	if len(report.Users) != 2 {
+		t.Fatalf("Expected alice and bob, got %+v", report.Users)
+	}
+	alice, bob := report.Users[0], report.Users[1]
+	if alice.Events != 3 || !reflect.DeepEqual(alice.Verbs, map[string]int{"get": 1, "create": 2}) || !reflect.DeepEqual(alice.Namespaces, []string{"kube-system", "web"}) {
+		t.Errorf("Unexpected user: %+v", alice)
+	}
+	if alice.FirstSeen != "2026-03-01T10:00:00.000000Z" || alice.LastSeen != "2026-03-03T10:00:00.000000Z" {
+		t.Errorf("Unexpected first and last seen: %+v", alice)
+	}
+	if bob.Failed != 1 {
+		t.Errorf("Expected bob's denied read to count as failed: %+v", bob)
+	}
+
+	var categories []string
+	for _, operation := range report.PrivilegedOperations {
+		categories = append(categories, operation.Category)
+	}
+	if !reflect.DeepEqual(categories, []string{PrivilegedRBACChange, PrivilegedPodExec, PrivilegedSecretChange}) {
+		t.Errorf("Unexpected privileged operations: %+v", report.PrivilegedOperations)
+	}
+
+	if len(report.SensitiveAccess) != 3 {
+		t.Fatalf("Expected 3 sensitive namespace access rows, got %+v", report.SensitiveAccess)
+	}
+	if row := report.SensitiveAccess[2]; row.Namespace != "payments" || row.Username != "bob" || row.Failed != 1 {
+		t.Errorf("Expected the wildcard to match payments: %+v", row)
+	}
+	if report.Summary != "2026-03: 6 events, 2 human users with cluster access, 3 privileged operations, 3 users accessing sensitive namespaces" {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+}
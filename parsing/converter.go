@@ -22,18 +22,31 @@ func ConvertToTypesAuditLogEntry(entry AuditLogEntry) types.AuditLogEntry {
 		RequestURI:       entry.RequestURI,
 		UserAgent:        entry.UserAgent,
 		SourceIPs:        entry.SourceIPs,
+		AuditID:          entry.AuditID,
+		Stage:            entry.Stage,
+		Level:            entry.Level,
+		StageTimestamp:   entry.StageTimestamp,
+		Subresource:      entry.Subresource,
 		StatusCode:       entry.StatusCode,
 		StatusMessage:    entry.StatusMessage,
 		StatusReason:     entry.StatusReason,
 		AuthDecision:     entry.AuthDecision,
 		AuthzDecision:    entry.AuthzDecision,
+		AuthzReason:      entry.AuthzReason,
 		ImpersonatedUser: entry.ImpersonatedUser,
+		IsSystemUser:     entry.IsSystemUser,
+		IsServiceAccount: entry.IsServiceAccount,
+		RequestObject:    entry.RequestObject,
+		ResponseObject:   entry.ResponseObject,
+		OmittedObjects:   entry.OmittedObjects,
 		Annotations:      entry.Annotations,
 		Extra:            entry.Extra,
 		Headers:          entry.Headers,
 		RawLine:          entry.RawLine,
 		ParseErrors:      entry.ParseErrors,
 		ParseTime:        entry.ParseTime.Format(time.RFC3339),
+		Truncated:        entry.Truncated,
+		LineLength:       entry.LineLength,
 	}
 }
 
@@ -45,13 +58,14 @@ func ConvertToTypesParseResult(result ParseResult) types.ParseResult {
 	}
 
 	return types.ParseResult{
-		Entries:     entries,
-		TotalLines:  result.TotalLines,
-		ParsedLines: result.ParsedLines,
-		ErrorLines:  result.ErrorLines,
-		ParseErrors: result.ParseErrors,
-		ParseTime:   result.ParseTime.String(),
-		Performance: types.ParsePerformance(result.Performance),
+		Entries:        entries,
+		TotalLines:     result.TotalLines,
+		ParsedLines:    result.ParsedLines,
+		ErrorLines:     result.ErrorLines,
+		ParseErrors:    result.ParseErrors,
+		ParseTime:      result.ParseTime.String(),
+		Performance:    types.ParsePerformance(result.Performance),
+		TruncatedLines: result.TruncatedLines,
 	}
 }
 
@@ -63,6 +77,11 @@ func ConvertToTypesParserConfig(config ParserConfig) types.ParserConfig {
 		Timeout:          config.Timeout.String(),
 		EnableValidation: config.EnableValidation,
 		EnableMetrics:    config.EnableMetrics,
+		IncludeObjects:   config.IncludeObjects,
+		MaxObjectSize:    config.MaxObjectSize,
+		DisableFallback:  config.DisableFallback,
+		Strict:           config.Strict,
+		QuarantineLines:  config.QuarantineLines,
 	}
 }
 
@@ -75,6 +94,11 @@ func ConvertFromTypesParserConfig(config types.ParserConfig) ParserConfig {
 		Timeout:          timeout,
 		EnableValidation: config.EnableValidation,
 		EnableMetrics:    config.EnableMetrics,
+		IncludeObjects:   config.IncludeObjects,
+		MaxObjectSize:    config.MaxObjectSize,
+		DisableFallback:  config.DisableFallback,
+		Strict:           config.Strict,
+		QuarantineLines:  config.QuarantineLines,
 	}
 }
 
@@ -0,0 +1,163 @@
+package parsing
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// DefaultCSRApprovers are the users expected to approve certificate signing
+// requests: OpenShift's machine approver, which approves node certificates,
+// and the controller manager's approver for kubelet client certificates. A
+// trailing "*" matches any suffix.
+var DefaultCSRApprovers = []string{
+	"system:serviceaccount:openshift-cluster-machine-approver:*",
+	"system:serviceaccount:kube-system:certificate-controller",
+	"system:kube-controller-manager",
+}
+
+// csrDecision returns the decision of an approval request from the
+// conditions of its body, or CSRDecisionUnknown without one
+func csrDecision(entry AuditLogEntry) string {
+	status, _ := entry.RequestObject["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	decision := types.CSRDecisionUnknown
+	for _, condition := range conditions {
+		fields, _ := condition.(map[string]interface{})
+		if fields["status"] == "False" {
+			continue
+		}
+		switch fields["type"] {
+		case "Denied":
+			return types.CSRDecisionDenied
+		case "Approved":
+			decision = types.CSRDecisionApproved
+		}
+	}
+	return decision
+}
+
+// AnalyzeCSRActivity tracks the certificate signing requests created in
+// entries and the approvals and denials of them, in time order. Approvals are
+// updates of the approval subresource; their decision is read from the
+// request body, so entries parsed without objects, or logged at the Metadata
+// level, have unknown decisions. Approvals by users not matching
+// expectedApprovers, or by a request's own requester, are flagged as unusual;
+// nil expectedApprovers selects DefaultCSRApprovers. Requests answered with
+// an error are only counted as failed.
+func AnalyzeCSRActivity(entries []AuditLogEntry, expectedApprovers []string) types.CSRActivityReport {
+	if expectedApprovers == nil {
+		expectedApprovers = DefaultCSRApprovers
+	}
+	expected := regexp.MustCompile(commands.ExclusionRegex(expectedApprovers))
+	report := types.CSRActivityReport{ExpectedApprovers: expectedApprovers, Approvers: []types.CSRApprover{}, Requests: []types.CSRRequest{}}
+
+	var csrEntries []AuditLogEntry
+	for _, entry := range entries {
+		if entry.Resource == "certificatesigningrequests" && entry.Name != "" {
+			csrEntries = append(csrEntries, entry)
+		}
+	}
+	// Audit timestamps are UTC with a fixed precision, so they sort as strings
+	sort.SliceStable(csrEntries, func(i, j int) bool { return csrEntries[i].Timestamp < csrEntries[j].Timestamp })
+
+	requests := make(map[string]*types.CSRRequest)
+	var order []string
+	request := func(name string) *types.CSRRequest {
+		if requests[name] == nil {
+			requests[name] = &types.CSRRequest{Name: name}
+			order = append(order, name)
+		}
+		return requests[name]
+	}
+	approvers := make(map[string]*types.CSRApprover)
+	for _, entry := range csrEntries {
+		isCreate := entry.Verb == "create" && entry.Subresource == ""
+		isApproval := (entry.Verb == "update" || entry.Verb == "patch") && entry.Subresource == "approval"
+		if !isCreate && !isApproval {
+			continue
+		}
+		if entry.StatusCode >= 400 {
+			report.Failed++
+			continue
+		}
+
+		csr := request(entry.Name)
+		if isCreate {
+			report.Created++
+			csr.Requester, csr.CreatedAt = entry.Username, entry.Timestamp
+			if spec, ok := entry.RequestObject["spec"].(map[string]interface{}); ok {
+				csr.SignerName, _ = spec["signerName"].(string)
+			}
+			continue
+		}
+
+		approver := approvers[entry.Username]
+		if approver == nil {
+			approver = &types.CSRApprover{Username: entry.Username}
+			approvers[entry.Username] = approver
+		}
+		csr.Decision, csr.DecidedBy, csr.DecidedAt = csrDecision(entry), entry.Username, entry.Timestamp
+		switch csr.Decision {
+		case types.CSRDecisionApproved:
+			report.Approved++
+			approver.Approved++
+		case types.CSRDecisionDenied:
+			report.Denied++
+			approver.Denied++
+		default:
+			report.UnknownDecisions++
+			approver.Unknown++
+		}
+
+		// A denial is never unusual; an approval of unknown outcome may be
+		if csr.Decision != types.CSRDecisionDenied {
+			csr.SelfApproved = csr.Requester != "" && csr.Requester == entry.Username
+			csr.UnusualApprover = csr.SelfApproved || !expected.MatchString(entry.Username)
+			if csr.UnusualApprover {
+				report.UnusualApprovals++
+				approver.Unusual = true
+			}
+		}
+	}
+
+	for _, name := range order {
+		report.Requests = append(report.Requests, *requests[name])
+	}
+	for _, approver := range approvers {
+		report.Approvers = append(report.Approvers, *approver)
+	}
+	sort.Slice(report.Approvers, func(i, j int) bool {
+		a, b := report.Approvers[i], report.Approvers[j]
+		if a.Unusual != b.Unusual {
+			return a.Unusual
+		}
+		return a.Username < b.Username
+	})
+
+	report.Summary = summarizeCSRActivity(report)
+	return report
+}
+
+// summarizeCSRActivity produces a short human-readable description of a report
+func summarizeCSRActivity(report types.CSRActivityReport) string {
+	summary := fmt.Sprintf("%d certificate signing requests created, %d approved, %d denied", report.Created, report.Approved, report.Denied)
+	if report.UnknownDecisions > 0 {
+		summary += fmt.Sprintf(", %d decisions without a request body", report.UnknownDecisions)
+	}
+	if report.UnusualApprovals == 0 {
+		return summary + "; no unusual approvers"
+	}
+
+	var approvers []string
+	for _, approver := range report.Approvers {
+		if approver.Unusual {
+			approvers = append(approvers, approver.Username)
+		}
+	}
+	return summary + fmt.Sprintf("; %d approvals by unusual approvers: %s", report.UnusualApprovals, strings.Join(approvers, ", "))
+}
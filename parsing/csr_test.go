@@ -0,0 +1,64 @@
+package parsing
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestAnalyzeCSRActivity tests tracking signing requests and flagging unusual approvers
+func TestAnalyzeCSRActivity(t *testing.T) {
+	csr := func(username, verb, subresource, name, timestamp string, status int, body map[string]interface{}) AuditLogEntry {
+		return AuditLogEntry{Timestamp: timestamp, Username: username, Verb: verb, Resource: "certificatesigningrequests", Subresource: subresource, Name: name, StatusCode: status, RequestObject: body}
+	}
+	decision := func(condition string) map[string]interface{} {
+		return map[string]interface{}{"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{"type": condition, "status": "True"}}}}
+	}
+	machineApprover := "system:serviceaccount:openshift-cluster-machine-approver:machine-approver-sa"
+	entries := []AuditLogEntry{
+		csr("system:node:worker-0", "create", "", "csr-node", "2026-03-01T12:00:00.000000Z", 201, map[string]interface{}{"spec": map[string]interface{}{"signerName": "kubernetes.io/kube-apiserver-client-kubelet"}}),
+		csr(machineApprover, "update", "approval", "csr-node", "2026-03-01T12:00:05.000000Z", 200, decision("Approved")),
+		csr("mallory", "create", "", "csr-mallory", "2026-03-01T12:01:00.000000Z", 201, nil),
+		csr("mallory", "update", "approval", "csr-mallory", "2026-03-01T12:02:00.000000Z", 200, decision("Approved")),
+		csr("bob", "update", "approval", "csr-old", "2026-03-01T12:03:00.000000Z", 200, decision("Denied")),
+		csr("bob", "update", "approval", "csr-other", "2026-03-01T12:04:00.000000Z", 200, nil),
+		csr("eve", "update", "approval", "csr-node", "2026-03-01T12:05:00.000000Z", 403, decision("Approved")),
+		csr("system:kube-controller-manager", "update", "status", "csr-node", "2026-03-01T12:00:06.000000Z", 200, nil),
+	}
+
+	report := AnalyzeCSRActivity(entries, nil)
+	if report.Created != 2 || report.Approved != 2 || report.Denied != 1 || report.UnknownDecisions != 1 || report.Failed != 1 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if report.UnusualApprovals != 2 {
+		t.Errorf("Expected 2 unusual approvals, got %d", report.UnusualApprovals)
+	}
+	if len(report.Requests) != 4 {
+		t.Fatalf("Expected 4 requests, got %+v", report.Requests)
+	}
+
+	node := report.Requests[0]
+	if node.Name != "csr-node" || node.SignerName != "kubernetes.io/kube-apiserver-client-kubelet" || node.Decision != types.CSRDecisionApproved || node.UnusualApprover {
+		t.Errorf("Unexpected node request: %+v", node)
+	}
+	self := report.Requests[1]
+	if self.Requester != "mallory" || !self.SelfApproved || !self.UnusualApprover {
+		t.Errorf("Expected a self-approval: %+v", self)
+	}
+	if denied := report.Requests[2]; denied.Decision != types.CSRDecisionDenied || denied.UnusualApprover || denied.Requester != "" {
+		t.Errorf("Unexpected denied request: %+v", denied)
+	}
+	if unknown := report.Requests[3]; unknown.Decision != types.CSRDecisionUnknown || !unknown.UnusualApprover {
+		t.Errorf("Unexpected undecided request: %+v", unknown)
+	}
+
+	// Unusual approvers come first
+	if len(report.Approvers) != 3 || report.Approvers[0].Username != "bob" || !report.Approvers[0].Unusual || report.Approvers[2].Username != machineApprover {
+		t.Errorf("Unexpected approvers: %+v", report.Approvers)
+	}
+
+	// Expected approvers replace the defaults
+	if report := AnalyzeCSRActivity(entries, []string{"bob", "mallory"}); report.UnusualApprovals != 2 {
+		t.Errorf("Expected the machine approver and mallory's self-approval to be unusual, got %+v", report.Approvers)
+	}
+}
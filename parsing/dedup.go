@@ -0,0 +1,29 @@
+package parsing
+
+// DeduplicateEntries removes events that appear more than once, as happens when
+// a query reads both a rotated and the current audit log file. Events are
+// identified by audit ID and stage, since one request is logged once per stage.
+// Entries without an audit ID are always kept. Returns the remaining entries
+// in their original order and the number of duplicates removed.
+func DeduplicateEntries(entries []AuditLogEntry) ([]AuditLogEntry, int) {
+	seen := make(map[string]bool, len(entries))
+	deduplicated := make([]AuditLogEntry, 0, len(entries))
+	removed := 0
+
+	for _, entry := range entries {
+		if entry.AuditID == "" {
+			deduplicated = append(deduplicated, entry)
+			continue
+		}
+
+		key := entry.AuditID + "/" + entry.Stage
+		if seen[key] {
+			removed++
+			continue
+		}
+		seen[key] = true
+		deduplicated = append(deduplicated, entry)
+	}
+
+	return deduplicated, removed
+}
@@ -0,0 +1,37 @@
+package parsing
+
+import "testing"
+
+func TestDeduplicateEntries(t *testing.T) {
+	entries := []AuditLogEntry{
+		{AuditID: "a", Stage: "ResponseComplete", Username: "first"},
+		{AuditID: "b", Stage: "ResponseComplete", Username: "other"},
+		{AuditID: "a", Stage: "ResponseComplete", Username: "rotated copy"},
+		{AuditID: "a", Stage: "RequestReceived", Username: "earlier stage"},
+		{Username: "no id"},
+		{Username: "no id"},
+	}
+
+	deduplicated, removed := DeduplicateEntries(entries)
+
+	if removed != 1 {
+		t.Errorf("Expected 1 duplicate removed, got %d", removed)
+	}
+
+	expected := []string{"first", "other", "earlier stage", "no id", "no id"}
+	if len(deduplicated) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(deduplicated))
+	}
+	for i, entry := range deduplicated {
+		if entry.Username != expected[i] {
+			t.Errorf("Entry %d: expected %s, got %s", i, expected[i], entry.Username)
+		}
+	}
+}
+
+func TestDeduplicateEntries_Empty(t *testing.T) {
+	deduplicated, removed := DeduplicateEntries(nil)
+	if len(deduplicated) != 0 || removed != 0 {
+		t.Errorf("Expected no entries and no duplicates, got %d entries, %d removed", len(deduplicated), removed)
+	}
+}
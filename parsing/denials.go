@@ -0,0 +1,180 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// rbacResources are the resources whose modification grants permissions
+var rbacResources = map[string]bool{
+	"roles":               true,
+	"rolebindings":        true,
+	"clusterroles":        true,
+	"clusterrolebindings": true,
+}
+
+// escalationVerbs are verbs that let a user act with more privileges than they hold
+var escalationVerbs = map[string]bool{
+	"escalate":    true,
+	"bind":        true,
+	"impersonate": true,
+}
+
+// IsPermissionDenial reports whether the authorizer refused the request, either
+// through the authorization decision annotation or a 403 response
+func IsPermissionDenial(entry AuditLogEntry) bool {
+	return entry.AuthzDecision == "forbid" || entry.StatusCode == 403
+}
+
+// isEscalationAttempt reports whether a denied request tried to gain privileges
+func isEscalationAttempt(entry AuditLogEntry) bool {
+	if escalationVerbs[entry.Verb] {
+		return true
+	}
+	if !rbacResources[entry.Resource] {
+		return false
+	}
+	switch entry.Verb {
+	case "create", "update", "patch":
+		return true
+	}
+	return false
+}
+
+// AnalyzePermissionDenials groups the denied entries by user, resource and the
+// verb/resource rule they were refused. Entries that were not denied are ignored.
+func AnalyzePermissionDenials(entries []AuditLogEntry) types.PermissionDenialReport {
+	report := types.PermissionDenialReport{
+		ByUser:     []types.DenialCount{},
+		ByResource: []types.DenialCount{},
+		Rules:      []types.DeniedRule{},
+	}
+
+	userCounts := make(map[string]int)
+	resourceCounts := make(map[string]int)
+	rules := make(map[string]*types.DeniedRule)
+	var ruleOrder []string
+	escalations := make(map[string]bool)
+
+	for _, entry := range entries {
+		if !IsPermissionDenial(entry) {
+			continue
+		}
+		report.TotalDenials++
+
+		username := entry.Username
+		if username == "" {
+			username = "unknown"
+		}
+		userCounts[username]++
+
+		resource := entry.Resource
+		if resource == "" {
+			resource = "unknown"
+		}
+		if entry.Subresource != "" {
+			resource += "/" + entry.Subresource
+		}
+		resourceCounts[resource]++
+
+		key := strings.Join([]string{entry.Verb, entry.APIGroup, entry.Resource, entry.Subresource, entry.Namespace}, "|")
+		rule, ok := rules[key]
+		if !ok {
+			rule = &types.DeniedRule{
+				Verb:        entry.Verb,
+				APIGroup:    entry.APIGroup,
+				Resource:    entry.Resource,
+				Subresource: entry.Subresource,
+				Namespace:   entry.Namespace,
+				Users:       []string{},
+			}
+			rules[key] = rule
+			ruleOrder = append(ruleOrder, key)
+		}
+		rule.Count++
+		if !utils.Contains(rule.Users, username) {
+			rule.Users = append(rule.Users, username)
+		}
+		if entry.AuthzReason != "" && !utils.Contains(rule.Reasons, entry.AuthzReason) {
+			rule.Reasons = append(rule.Reasons, entry.AuthzReason)
+		}
+		if isEscalationAttempt(entry) {
+			escalations[key] = true
+		}
+	}
+
+	report.ByUser = sortedDenialCounts(userCounts)
+	report.ByResource = sortedDenialCounts(resourceCounts)
+
+	for _, key := range ruleOrder {
+		report.Rules = append(report.Rules, *rules[key])
+		if escalations[key] {
+			report.EscalationAttempts = append(report.EscalationAttempts, *rules[key])
+		}
+	}
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		return report.Rules[i].Count > report.Rules[j].Count
+	})
+	sort.SliceStable(report.EscalationAttempts, func(i, j int) bool {
+		return report.EscalationAttempts[i].Count > report.EscalationAttempts[j].Count
+	})
+
+	report.Summary = summarizeDenials(report)
+	return report
+}
+
+// sortedDenialCounts orders counts from most to least denials, then by key
+func sortedDenialCounts(counts map[string]int) []types.DenialCount {
+	result := make([]types.DenialCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, types.DenialCount{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// summarizeDenials produces a short human-readable description of the report
+func summarizeDenials(report types.PermissionDenialReport) string {
+	if report.TotalDenials == 0 {
+		return "No permission denials found"
+	}
+
+	summary := fmt.Sprintf("Found %d permission denials across %d users and %d rules",
+		report.TotalDenials, len(report.ByUser), len(report.Rules))
+	if len(report.ByUser) > 0 {
+		top := report.ByUser[0]
+		summary += fmt.Sprintf("; most denied user: %s (%d)", top.Key, top.Count)
+	}
+	if len(report.Rules) > 0 {
+		top := report.Rules[0]
+		summary += fmt.Sprintf("; most denied rule: %s %s (%d)", top.Verb, describeRuleTarget(top), top.Count)
+	}
+	if len(report.EscalationAttempts) > 0 {
+		summary += fmt.Sprintf("; %d possible privilege escalation attempts", len(report.EscalationAttempts))
+	}
+	return summary
+}
+
+// describeRuleTarget formats the resource a rule applies to, e.g. "deployments/scale in prod"
+func describeRuleTarget(rule types.DeniedRule) string {
+	target := rule.Resource
+	if rule.APIGroup != "" {
+		target += "." + rule.APIGroup
+	}
+	if rule.Subresource != "" {
+		target += "/" + rule.Subresource
+	}
+	if rule.Namespace != "" {
+		target += " in " + rule.Namespace
+	}
+	return target
+}
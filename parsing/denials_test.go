@@ -0,0 +1,95 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsPermissionDenial(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    AuditLogEntry
+		expected bool
+	}{
+		{name: "forbid annotation", entry: AuditLogEntry{AuthzDecision: "forbid"}, expected: true},
+		{name: "403 response", entry: AuditLogEntry{StatusCode: 403}, expected: true},
+		{name: "allowed", entry: AuditLogEntry{AuthzDecision: "allow", StatusCode: 200}},
+		{name: "unauthenticated", entry: AuditLogEntry{StatusCode: 401}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermissionDenial(tt.entry); got != tt.expected {
+				t.Errorf("IsPermissionDenial() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzePermissionDenials(t *testing.T) {
+	lines := []string{
+		`{"verb":"get","user":{"username":"dev"},"objectRef":{"resource":"secrets","namespace":"prod"},"responseStatus":{"code":403},"annotations":{"authorization.k8s.io/decision":"forbid","authorization.k8s.io/reason":"RBAC: secrets is forbidden"}}`,
+		`{"verb":"get","user":{"username":"dev"},"objectRef":{"resource":"secrets","namespace":"prod"},"responseStatus":{"code":403},"annotations":{"authorization.k8s.io/decision":"forbid"}}`,
+		`{"verb":"get","user":{"username":"ci"},"objectRef":{"resource":"secrets","namespace":"prod"},"responseStatus":{"code":403}}`,
+		`{"verb":"create","user":{"username":"dev"},"objectRef":{"resource":"clusterrolebindings","apiGroup":"rbac.authorization.k8s.io"},"responseStatus":{"code":403}}`,
+		`{"verb":"update","user":{"username":"ci"},"objectRef":{"resource":"deployments","namespace":"prod","apiGroup":"apps","subresource":"scale"},"responseStatus":{"code":403}}`,
+		`{"verb":"get","user":{"username":"admin"},"objectRef":{"resource":"secrets","namespace":"prod"},"responseStatus":{"code":200},"annotations":{"authorization.k8s.io/decision":"allow"}}`,
+	}
+
+	result := ParseAuditLogs(lines, DefaultParserConfig())
+	report := AnalyzePermissionDenials(result.Entries)
+
+	if report.TotalDenials != 5 {
+		t.Fatalf("Expected 5 denials, got %d", report.TotalDenials)
+	}
+
+	if len(report.ByUser) != 2 || report.ByUser[0].Key != "dev" || report.ByUser[0].Count != 3 {
+		t.Errorf("Expected dev to lead with 3 denials, got %+v", report.ByUser)
+	}
+
+	if report.ByResource[0].Key != "secrets" || report.ByResource[0].Count != 3 {
+		t.Errorf("Expected secrets to lead with 3 denials, got %+v", report.ByResource)
+	}
+	foundScale := false
+	for _, count := range report.ByResource {
+		if count.Key == "deployments/scale" {
+			foundScale = true
+		}
+	}
+	if !foundScale {
+		t.Errorf("Expected deployments/scale in resource counts, got %+v", report.ByResource)
+	}
+
+	if len(report.Rules) != 3 {
+		t.Fatalf("Expected 3 denied rules, got %d: %+v", len(report.Rules), report.Rules)
+	}
+	top := report.Rules[0]
+	if top.Verb != "get" || top.Resource != "secrets" || top.Count != 3 {
+		t.Errorf("Expected get secrets to be the most denied rule, got %+v", top)
+	}
+	if len(top.Users) != 2 {
+		t.Errorf("Expected 2 users on the secrets rule, got %v", top.Users)
+	}
+	if len(top.Reasons) != 1 || top.Reasons[0] != "RBAC: secrets is forbidden" {
+		t.Errorf("Expected the authorizer reason to be kept, got %v", top.Reasons)
+	}
+
+	if len(report.EscalationAttempts) != 1 || report.EscalationAttempts[0].Resource != "clusterrolebindings" {
+		t.Errorf("Expected the clusterrolebinding create to be flagged, got %+v", report.EscalationAttempts)
+	}
+
+	if !strings.Contains(report.Summary, "most denied user: dev (3)") {
+		t.Errorf("Expected summary to name the top user, got %q", report.Summary)
+	}
+}
+
+func TestAnalyzePermissionDenials_NoDenials(t *testing.T) {
+	report := AnalyzePermissionDenials([]AuditLogEntry{{Username: "admin", StatusCode: 200}})
+
+	if report.TotalDenials != 0 || len(report.Rules) != 0 {
+		t.Errorf("Expected an empty report, got %+v", report)
+	}
+	if report.Summary != "No permission denials found" {
+		t.Errorf("Unexpected summary %q", report.Summary)
+	}
+}
@@ -192,6 +192,7 @@ func (ep *EnhancedParser) parseJSONLineEnhanced(line string, entry *AuditLogEntr
 
 	// Enhanced field extraction with better error handling
 	ep.extractTimestampEnhanced(rawData, entry)
+	extractEventMetadata(rawData, entry)
 	ep.extractUserInfoEnhanced(rawData, entry)
 	ep.extractVerbEnhanced(rawData, entry)
 	ep.extractObjectRefEnhanced(rawData, entry)
@@ -400,6 +401,11 @@ func (ep *EnhancedParser) extractObjectRefEnhanced(rawData map[string]interface{
 				}
 			}
 
+			// Extract subresource
+			if subresource, ok := objRef[utils.AuditLogFields["Subresource"]].(string); ok {
+				entry.Subresource = subresource
+			}
+
 			break
 		}
 	}
@@ -557,6 +563,17 @@ func (ep *EnhancedParser) extractAuthenticationInfoEnhanced(rawData map[string]i
 		}
 	}
 
+	// Decisions recorded as annotations take precedence over flattened keys
+	annotated := AuditLogEntry{}
+	extractDecisions(rawData, &annotated)
+	if annotated.AuthDecision != "" {
+		entry.AuthDecision = annotated.AuthDecision
+	}
+	if annotated.AuthzDecision != "" {
+		entry.AuthzDecision = annotated.AuthzDecision
+	}
+	entry.AuthzReason = annotated.AuthzReason
+
 	// Extract impersonated user
 	impersonatedUserFields := []string{
 		utils.AuditLogFields["ImpersonatedUser"],
@@ -566,7 +583,7 @@ func (ep *EnhancedParser) extractAuthenticationInfoEnhanced(rawData map[string]i
 	}
 
 	for _, field := range impersonatedUserFields {
-		if impersonatedUser, ok := rawData[field].(string); ok && impersonatedUser != "" {
+		if impersonatedUser := impersonatedUsername(rawData[field]); impersonatedUser != "" {
 			entry.ImpersonatedUser = impersonatedUser
 			break
 		}
@@ -674,6 +691,7 @@ func (ep *EnhancedParser) getEnhancedRegexPatterns() map[string]*regexp.Regexp {
 		"username":      regexp.MustCompile(`"username":"([^"]+)"`),
 		"verb":          regexp.MustCompile(`"verb":"([^"]+)"`),
 		"resource":      regexp.MustCompile(`"resource":"([^"]+)"`),
+		"subresource":   regexp.MustCompile(`"subresource":"([^"]+)"`),
 		"namespace":     regexp.MustCompile(`"namespace":"([^"]+)"`),
 		"name":          regexp.MustCompile(`"name":"([^"]+)"`),
 		"statusCode":    regexp.MustCompile(`"code":(\d+)`),
@@ -694,6 +712,8 @@ func (ep *EnhancedParser) setFieldValue(entry *AuditLogEntry, fieldName, value s
 		entry.Verb = value
 	case "resource":
 		entry.Resource = value
+	case "subresource":
+		entry.Subresource = value
 	case "namespace":
 		entry.Namespace = value
 	case "name":
@@ -0,0 +1,158 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// Error rate spike defaults
+const (
+	// DefaultSpikeThreshold is the increase of a namespace's error rate, in
+	// percentage points, flagged as a spike
+	DefaultSpikeThreshold = 20.0
+	// DefaultSpikeMinRequests is the responses a namespace needs in the
+	// current window to be flagged, so a single failed request is not a spike
+	DefaultSpikeMinRequests = 3
+)
+
+// errorRateTally counts the responses of one namespace or user
+type errorRateTally struct {
+	requests, clientErrors, serverErrors int
+}
+
+// add counts an entry's response; entries without a status code are ignored
+func (t *errorRateTally) add(entry AuditLogEntry) {
+	if entry.StatusCode == 0 {
+		return
+	}
+	t.requests++
+	switch {
+	case entry.StatusCode >= 500:
+		t.serverErrors++
+	case entry.StatusCode >= 400:
+		t.clientErrors++
+	}
+}
+
+// counts converts the tally to counts with rates in percent
+func (t errorRateTally) counts() types.ErrorRateCounts {
+	counts := types.ErrorRateCounts{Requests: t.requests, ClientErrors: t.clientErrors, ServerErrors: t.serverErrors}
+	if t.requests > 0 {
+		counts.ClientErrorRate = float64(t.clientErrors) * 100 / float64(t.requests)
+		counts.ServerErrorRate = float64(t.serverErrors) * 100 / float64(t.requests)
+		counts.ErrorRate = float64(t.clientErrors+t.serverErrors) * 100 / float64(t.requests)
+	}
+	return counts
+}
+
+// AnalyzeErrorRates computes the 4xx and 5xx rates per namespace and per user
+// of the current entries and compares them with the previous window's. A row
+// spikes when it has at least minRequests responses and its error rate rose by
+// spikeThreshold percentage points or more; a row absent from the previous
+// window counts as having had no errors. Zero thresholds use the defaults.
+func AnalyzeErrorRates(current, previous []AuditLogEntry, spikeThreshold float64, minRequests int) types.ErrorRateReport {
+	if spikeThreshold <= 0 {
+		spikeThreshold = DefaultSpikeThreshold
+	}
+	if minRequests <= 0 {
+		minRequests = DefaultSpikeMinRequests
+	}
+
+	var currentTotal, previousTotal errorRateTally
+	for _, entry := range current {
+		currentTotal.add(entry)
+	}
+	for _, entry := range previous {
+		previousTotal.add(entry)
+	}
+
+	report := types.ErrorRateReport{
+		Current:        types.ErrorRateWindow{ErrorRateCounts: currentTotal.counts()},
+		Previous:       types.ErrorRateWindow{ErrorRateCounts: previousTotal.counts()},
+		SpikeThreshold: spikeThreshold,
+		MinRequests:    minRequests,
+	}
+	report.Namespaces, report.SpikingNamespaces = errorRateRows(current, previous, func(entry AuditLogEntry) string { return entry.Namespace }, spikeThreshold, minRequests)
+	report.Users, report.SpikingUsers = errorRateRows(current, previous, func(entry AuditLogEntry) string { return entry.Username }, spikeThreshold, minRequests)
+	report.Summary = summarizeErrorRates(report)
+	return report
+}
+
+// errorRateRows tallies the entries per key, skipping entries without one, and
+// returns the rows of the keys seen in the current window, the highest error
+// rate first, and the keys that spiked
+func errorRateRows(current, previous []AuditLogEntry, key func(AuditLogEntry) string, spikeThreshold float64, minRequests int) ([]types.ErrorRateRow, []string) {
+	tally := func(entries []AuditLogEntry) map[string]*errorRateTally {
+		tallies := make(map[string]*errorRateTally)
+		for _, entry := range entries {
+			name := key(entry)
+			if name == "" {
+				continue
+			}
+			if tallies[name] == nil {
+				tallies[name] = &errorRateTally{}
+			}
+			tallies[name].add(entry)
+		}
+		return tallies
+	}
+	currentTallies, previousTallies := tally(current), tally(previous)
+
+	rows := []types.ErrorRateRow{}
+	for name, currentTally := range currentTallies {
+		if currentTally.requests == 0 {
+			continue
+		}
+		row := types.ErrorRateRow{Name: name, ErrorRateCounts: currentTally.counts()}
+		if previousTally, ok := previousTallies[name]; ok {
+			previousCounts := previousTally.counts()
+			row.PreviousRequests = previousCounts.Requests
+			row.PreviousErrorRate = previousCounts.ErrorRate
+		}
+		row.Change = row.ErrorRate - row.PreviousErrorRate
+		row.Spike = row.Requests >= minRequests && row.Change >= spikeThreshold
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ErrorRate != rows[j].ErrorRate {
+			return rows[i].ErrorRate > rows[j].ErrorRate
+		}
+		if rows[i].Requests != rows[j].Requests {
+			return rows[i].Requests > rows[j].Requests
+		}
+		return rows[i].Name < rows[j].Name
+	})
+
+	spiking := []string{}
+	for _, row := range rows {
+		if row.Spike {
+			spiking = append(spiking, row.Name)
+		}
+	}
+	return rows, spiking
+}
+
+// summarizeErrorRates produces a short human-readable description of a report
+func summarizeErrorRates(report types.ErrorRateReport) string {
+	parts := []string{fmt.Sprintf("error rate %.1f%% of %d responses (previous window %.1f%% of %d)",
+		report.Current.ErrorRate, report.Current.Requests, report.Previous.ErrorRate, report.Previous.Requests)}
+
+	var spikes []string
+	for _, row := range report.Namespaces {
+		if row.Spike {
+			spikes = append(spikes, fmt.Sprintf("%s %.1f%% -> %.1f%%", row.Name, row.PreviousErrorRate, row.ErrorRate))
+		}
+	}
+	if len(spikes) > 0 {
+		parts = append(parts, "spiking namespaces: "+strings.Join(spikes, ", "))
+	} else {
+		parts = append(parts, "no namespace error rate spikes")
+	}
+	if len(report.SpikingUsers) > 0 {
+		parts = append(parts, "spiking users: "+strings.Join(report.SpikingUsers, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
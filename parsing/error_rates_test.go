@@ -0,0 +1,70 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAnalyzeErrorRates tests error rates per namespace and user and flagging spikes
+func TestAnalyzeErrorRates(t *testing.T) {
+	previous := []AuditLogEntry{
+		{Namespace: "web", Username: "alice", StatusCode: 200},
+		{Namespace: "web", Username: "alice", StatusCode: 404},
+		{Namespace: "web", Username: "alice", StatusCode: 200},
+		{Namespace: "web", Username: "alice", StatusCode: 200},
+		{Namespace: "payments", Username: "bob", StatusCode: 200},
+	}
+	current := []AuditLogEntry{
+		{Namespace: "web", Username: "alice", StatusCode: 200},
+		{Namespace: "web", Username: "alice", StatusCode: 403},
+		{Namespace: "web", Username: "alice", StatusCode: 200},
+		{Namespace: "web", Username: "alice", StatusCode: 200},
+		{Namespace: "payments", Username: "bob", StatusCode: 403},
+		{Namespace: "payments", Username: "bob", StatusCode: 403},
+		{Namespace: "payments", Username: "bob", StatusCode: 500},
+		{Namespace: "payments", Username: "bob", StatusCode: 200},
+		{Namespace: "staging", Username: "carol", StatusCode: 503},
+		{Username: "carol", StatusCode: 201},
+		{Namespace: "staging", Username: "carol"}, // no response status
+	}
+
+	report := AnalyzeErrorRates(current, previous, 0, 0)
+	if report.SpikeThreshold != DefaultSpikeThreshold || report.MinRequests != DefaultSpikeMinRequests {
+		t.Errorf("Unexpected defaults: %v, %d", report.SpikeThreshold, report.MinRequests)
+	}
+	if report.Current.Requests != 10 || report.Current.ClientErrors != 3 || report.Current.ServerErrors != 2 || report.Current.ErrorRate != 50 {
+		t.Errorf("Unexpected current window: %+v", report.Current)
+	}
+	if report.Previous.Requests != 5 || report.Previous.ErrorRate != 20 {
+		t.Errorf("Unexpected previous window: %+v", report.Previous)
+	}
+
+	// staging fails every request but has too few to be flagged
+	if len(report.Namespaces) != 3 || report.Namespaces[0].Name != "staging" || report.Namespaces[0].Spike {
+		t.Fatalf("Unexpected namespaces: %+v", report.Namespaces)
+	}
+	payments := report.Namespaces[1]
+	if payments.Name != "payments" || payments.ClientErrorRate != 50 || payments.ServerErrorRate != 25 || payments.ErrorRate != 75 || payments.PreviousErrorRate != 0 || payments.Change != 75 || !payments.Spike {
+		t.Errorf("Unexpected payments row: %+v", payments)
+	}
+	web := report.Namespaces[2]
+	if web.Name != "web" || web.ErrorRate != 25 || web.PreviousRequests != 4 || web.PreviousErrorRate != 25 || web.Change != 0 || web.Spike {
+		t.Errorf("Unexpected web row: %+v", web)
+	}
+	if !reflect.DeepEqual(report.SpikingNamespaces, []string{"payments"}) || !reflect.DeepEqual(report.SpikingUsers, []string{"bob"}) {
+		t.Errorf("Unexpected spikes: %v, %v", report.SpikingNamespaces, report.SpikingUsers)
+	}
+	if !strings.Contains(report.Summary, "spiking namespaces: payments 0.0% -> 75.0%") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+
+	// A higher threshold flags nothing
+	report = AnalyzeErrorRates(current, previous, 80, 3)
+	if len(report.SpikingNamespaces) != 0 || !strings.Contains(report.Summary, "no namespace error rate spikes") {
+		t.Errorf("Unexpected spikes above the threshold: %v (%s)", report.SpikingNamespaces, report.Summary)
+	}
+	if report := AnalyzeErrorRates(nil, nil, 0, 0); len(report.Namespaces) != 0 || report.Current.ErrorRate != 0 {
+		t.Errorf("Unexpected report without entries: %+v", report)
+	}
+}
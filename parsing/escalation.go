@@ -0,0 +1,181 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// Escalation chain defaults
+const (
+	DefaultEscalationMinFailures = 3
+	DefaultEscalationWindow      = time.Hour
+)
+
+// chainCandidate is a failed attempt or RBAC modification of a user
+type chainCandidate struct {
+	at    time.Time
+	event types.ChainEvent
+}
+
+// classifyChainEvent returns the user an entry is attributed to and its kind
+// when it is a failed authentication, a denied request or a successful RBAC
+// modification. Failed logins are recorded for the anonymous user, so they
+// are attributed to the username OpenShift's OAuth server tried.
+func classifyChainEvent(entry AuditLogEntry) (string, string, bool) {
+	switch {
+	case isFailedLogin(entry):
+		username := attemptedUsername(entry)
+		return username, types.ChainEventFailedAuthentication, username != ""
+	case IsPermissionDenial(entry):
+		return entry.Username, types.ChainEventForbidden, entry.Username != ""
+	case rbacResources[entry.Resource] && entry.Subresource == "" && entry.StatusCode > 0 && entry.StatusCode < 400:
+		switch entry.Verb {
+		case "create", "update", "patch":
+			return entry.Username, types.ChainEventRBACModification, entry.Username != ""
+		}
+	}
+	return "", "", false
+}
+
+// DetectEscalationChains finds users with at least minFailures failed
+// authentications or denied requests within window before a successful
+// create, update or patch of a role, cluster role or binding. Further
+// modifications by the user within window of the last one join the chain,
+// until the user fails again. entriesBySource holds the entries of each log
+// source, such as the oauth-server's failed logins and the kube-apiserver's
+// denials and RBAC changes. Entries without a parseable timestamp are
+// skipped. Zero arguments select the defaults.
+func DetectEscalationChains(entriesBySource map[string][]AuditLogEntry, minFailures int, window time.Duration) types.EscalationReport {
+	if minFailures <= 0 {
+		minFailures = DefaultEscalationMinFailures
+	}
+	if window <= 0 {
+		window = DefaultEscalationWindow
+	}
+	report := types.EscalationReport{MinFailures: minFailures, Window: window.String(), Chains: []types.EscalationChain{}}
+
+	sources := make([]string, 0, len(entriesBySource))
+	for source := range entriesBySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	byUser := make(map[string][]chainCandidate)
+	for _, source := range sources {
+		for _, entry := range entriesBySource[source] {
+			username, kind, ok := classifyChainEvent(entry)
+			if !ok {
+				continue
+			}
+			at, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			if err != nil {
+				continue
+			}
+			if kind == types.ChainEventRBACModification {
+				report.RBACModifications++
+			} else {
+				report.FailedAttempts++
+			}
+			byUser[username] = append(byUser[username], chainCandidate{
+				at: at,
+				event: types.ChainEvent{
+					Timestamp:  entry.Timestamp,
+					Kind:       kind,
+					LogSource:  source,
+					Verb:       entry.Verb,
+					Resource:   entry.Resource,
+					Namespace:  entry.Namespace,
+					Name:       entry.Name,
+					RequestURI: entry.RequestURI,
+					StatusCode: entry.StatusCode,
+					SourceIPs:  entry.SourceIPs,
+					AuditID:    entry.AuditID,
+				},
+			})
+		}
+	}
+
+	for username, candidates := range byUser {
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].at.Before(candidates[j].at) })
+
+		var failures []chainCandidate
+		var chain *types.EscalationChain
+		var lastModification time.Time
+		closeChain := func() {
+			if chain != nil {
+				chain.End = chain.Events[len(chain.Events)-1].Timestamp
+				report.Chains = append(report.Chains, *chain)
+				chain = nil
+			}
+		}
+		for _, candidate := range candidates {
+			if candidate.event.Kind != types.ChainEventRBACModification {
+				closeChain()
+				failures = append(failures, candidate)
+				continue
+			}
+
+			if chain != nil && candidate.at.Sub(lastModification) <= window {
+				chain.Events = append(chain.Events, candidate.event)
+				chain.Modifications++
+				lastModification = candidate.at
+				continue
+			}
+			closeChain()
+
+			// Only failures within the window before the modification count
+			recent := failures[:0]
+			for _, failure := range failures {
+				if candidate.at.Sub(failure.at) <= window {
+					recent = append(recent, failure)
+				}
+			}
+			failures = recent
+			if len(failures) < minFailures {
+				continue
+			}
+
+			chain = &types.EscalationChain{Username: username, Start: failures[0].event.Timestamp}
+			for _, failure := range failures {
+				chain.Events = append(chain.Events, failure.event)
+				if failure.event.Kind == types.ChainEventFailedAuthentication {
+					chain.FailedAuthentications++
+				} else {
+					chain.Forbidden++
+				}
+			}
+			chain.Events = append(chain.Events, candidate.event)
+			chain.Modifications = 1
+			lastModification = candidate.at
+			failures = nil
+		}
+		closeChain()
+	}
+	sort.Slice(report.Chains, func(i, j int) bool {
+		if report.Chains[i].Start != report.Chains[j].Start {
+			return report.Chains[i].Start < report.Chains[j].Start
+		}
+		return report.Chains[i].Username < report.Chains[j].Username
+	})
+
+	report.Summary = summarizeEscalationChains(report)
+	return report
+}
+
+// summarizeEscalationChains produces a short human-readable description of a report
+func summarizeEscalationChains(report types.EscalationReport) string {
+	summary := fmt.Sprintf("%d failed attempts and %d RBAC modifications", report.FailedAttempts, report.RBACModifications)
+	if len(report.Chains) == 0 {
+		return summary + "; no privilege escalation chains"
+	}
+
+	var chains []string
+	for _, chain := range report.Chains {
+		chains = append(chains, fmt.Sprintf("%s (%d failed, then %d RBAC changes)", chain.Username, chain.FailedAuthentications+chain.Forbidden, chain.Modifications))
+	}
+	return summary + fmt.Sprintf("; %d privilege escalation chains: %s", len(report.Chains), strings.Join(chains, ", "))
+}
@@ -0,0 +1,86 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestDetectEscalationChains tests correlating failed attempts with later RBAC changes
+func TestDetectEscalationChains(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) string {
+		return start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339Nano)
+	}
+	failedLogin := func(username string, minutes int) AuditLogEntry {
+		return AuditLogEntry{
+			Timestamp:    at(minutes),
+			Username:     "system:anonymous",
+			Verb:         "post",
+			RequestURI:   "/login",
+			StatusCode:   401,
+			AuthDecision: "deny",
+			Annotations:  map[string]interface{}{"authentication.openshift.io/username": username},
+		}
+	}
+	forbidden := func(username string, minutes int) AuditLogEntry {
+		return AuditLogEntry{Timestamp: at(minutes), Username: username, Verb: "list", Resource: "secrets", StatusCode: 403, AuthzDecision: "forbid"}
+	}
+	binding := func(username, verb string, minutes, status int) AuditLogEntry {
+		return AuditLogEntry{Timestamp: at(minutes), Username: username, Verb: verb, Resource: "clusterrolebindings", Name: "backdoor", StatusCode: status}
+	}
+
+	oauth := []AuditLogEntry{
+		failedLogin("alice", 0), failedLogin("alice", 1),
+		failedLogin("bob", 0), failedLogin("bob", 1),
+		failedLogin("carol", 0), failedLogin("carol", 1), failedLogin("carol", 2),
+	}
+	apiserver := []AuditLogEntry{
+		forbidden("alice", 5),
+		binding("alice", "create", 10, 201),
+		binding("alice", "patch", 20, 200),  // joins alice's chain
+		binding("alice", "get", 21, 200),    // not a modification
+		binding("bob", "create", 10, 201),   // only two failures
+		binding("carol", "create", 90, 201), // failures too long ago
+		binding("alice", "create", 25, 403), // denied: a failure that ends the chain
+		forbidden("alice", 26),
+		binding("alice", "update", 30, 200),                                     // two failures since the chain
+		{Username: "alice", Verb: "create", Resource: "roles", StatusCode: 201}, // no timestamp
+	}
+
+	report := DetectEscalationChains(map[string][]AuditLogEntry{"oauth-server": oauth, "kube-apiserver": apiserver}, 0, 0)
+	if report.MinFailures != DefaultEscalationMinFailures || report.Window != "1h0m0s" {
+		t.Errorf("Unexpected defaults: %d, %s", report.MinFailures, report.Window)
+	}
+	if report.FailedAttempts != 10 || report.RBACModifications != 5 {
+		t.Errorf("Unexpected totals: %d failed, %d modifications", report.FailedAttempts, report.RBACModifications)
+	}
+	if len(report.Chains) != 1 {
+		t.Fatalf("Expected 1 chain, got %+v", report.Chains)
+	}
+	chain := report.Chains[0]
+	if chain.Username != "alice" || chain.FailedAuthentications != 2 || chain.Forbidden != 1 || chain.Modifications != 2 {
+		t.Errorf("Unexpected chain: %+v", chain)
+	}
+	if chain.Start != at(0) || chain.End != at(20) || len(chain.Events) != 5 {
+		t.Errorf("Unexpected chain events: %+v", chain.Events)
+	}
+	if chain.Events[0].Kind != types.ChainEventFailedAuthentication || chain.Events[0].LogSource != "oauth-server" ||
+		chain.Events[2].Kind != types.ChainEventForbidden || chain.Events[3].Kind != types.ChainEventRBACModification || chain.Events[3].LogSource != "kube-apiserver" {
+		t.Errorf("Unexpected chain events: %+v", chain.Events)
+	}
+	if !strings.Contains(report.Summary, "1 privilege escalation chains: alice (3 failed, then 2 RBAC changes)") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+
+	// With two failures enough, bob's change is a chain too, and so is alice's last one
+	report = DetectEscalationChains(map[string][]AuditLogEntry{"oauth-server": oauth, "kube-apiserver": apiserver}, 2, 0)
+	if len(report.Chains) != 3 || report.Chains[0].Username != "alice" || report.Chains[1].Username != "bob" || report.Chains[2].Start != at(25) {
+		t.Errorf("Unexpected chains: %+v", report.Chains)
+	}
+	if report = DetectEscalationChains(nil, 0, 0); len(report.Chains) != 0 || !strings.Contains(report.Summary, "no privilege escalation chains") {
+		t.Errorf("Unexpected report without entries: %+v", report)
+	}
+}
@@ -0,0 +1,362 @@
+package parsing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// Field paths tried in order, mirroring the jq field expressions of the command builder
+var (
+	usernamePaths    = [][]string{{"user", "username"}, {"userInfo", "username"}, {"impersonatedUser"}, {"requestUser"}}
+	resourcePaths    = [][]string{{"objectRef", "resource"}, {"objectRef", "apiVersion"}, {"requestObject", "kind"}, {"responseObject", "kind"}}
+	verbPaths        = [][]string{{"verb"}}
+	namespacePaths   = [][]string{{"objectRef", "namespace"}, {"requestObject", "metadata", "namespace"}, {"responseObject", "metadata", "namespace"}}
+	userAgentPaths   = [][]string{{"userAgent"}}
+	subresourcePaths = [][]string{{"objectRef", "subresource"}}
+)
+
+// fieldMatcher tests the first present value of a field against a regular expression
+type fieldMatcher struct {
+	paths  [][]string
+	regex  *regexp.Regexp
+	negate bool
+}
+
+// filterMatcher is a filter expression with its leaf patterns compiled
+type filterMatcher struct {
+	operator string
+	regex    *regexp.Regexp
+	children []filterMatcher
+}
+
+// EventFilter applies query parameters to raw audit events in Go, with the same
+// semantics as the jq command, so queries can run on hosts without jq.
+// Patterns are compiled once as case-insensitive regular expressions of their
+// literal text, as jq's test() runs them after the command builder escapes
+// them, and matched against the raw line rather than jq's re-serialized event.
+type EventFilter struct {
+	params         types.AuditQueryParams
+	fields         []fieldMatcher
+	hasStatusRange bool
+	statusCodes    []int
+	statusLow      int
+	statusHigh     int
+	sourceIP       net.IP
+	sourceCIDR     *net.IPNet
+	datePattern    string
+	patterns       []*regexp.Regexp
+	exclusions     []*regexp.Regexp
+	expression     *filterMatcher
+}
+
+// NewEventFilter compiles the filters for the given parameters
+func NewEventFilter(params types.AuditQueryParams) (*EventFilter, error) {
+	f := &EventFilter{
+		params:      params,
+		datePattern: commands.TimeframeDatePattern(params.Timeframe),
+	}
+
+	f.patterns = compilePatterns(params.Patterns)
+	f.exclusions = compilePatterns(params.Exclude)
+	if params.Filter != nil {
+		expression := compileFilterExpression(*params.Filter)
+		f.expression = &expression
+	}
+
+	includes := []struct {
+		paths  [][]string
+		values []string
+		mode   types.MatchMode
+	}{
+		{usernamePaths, fieldValues(params.Username, params.Usernames), params.UsernameMatch},
+		{verbPaths, fieldValues(params.Verb, params.Verbs), params.VerbMatch},
+		{resourcePaths, fieldValues(params.Resource, params.Resources), params.ResourceMatch},
+		{namespacePaths, fieldValues(params.Namespace, params.Namespaces), params.NamespaceMatch},
+		{userAgentPaths, fieldValues(params.UserAgent, nil), params.UserAgentMatch},
+		{subresourcePaths, params.Subresources, types.MatchModeExact},
+	}
+	for _, include := range includes {
+		if len(include.values) == 0 {
+			continue
+		}
+		pattern, caseInsensitive := commands.FieldMatchRegex(include.values, include.mode)
+		if caseInsensitive {
+			pattern = "(?i)" + pattern
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field filter %q: %w", pattern, err)
+		}
+		f.fields = append(f.fields, fieldMatcher{paths: include.paths, regex: regex})
+	}
+
+	exclusions := []struct {
+		paths  [][]string
+		values []string
+	}{
+		{usernamePaths, params.ExcludeUsers},
+		{namespacePaths, params.ExcludeNamespaces},
+		{verbPaths, params.ExcludeVerbs},
+		{resourcePaths, params.ExcludeResources},
+	}
+	for _, exclusion := range exclusions {
+		if len(exclusion.values) == 0 {
+			continue
+		}
+		regex, err := regexp.Compile(commands.ExclusionRegex(exclusion.values))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion: %w", err)
+		}
+		f.fields = append(f.fields, fieldMatcher{paths: exclusion.paths, regex: regex, negate: true})
+	}
+
+	if params.StatusCodeRange != "" {
+		codes, low, high, err := utils.ParseStatusCodeRange(params.StatusCodeRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code range: %w", err)
+		}
+		f.statusCodes, f.statusLow, f.statusHigh, f.hasStatusRange = codes, low, high, true
+	}
+
+	if params.SourceIP != "" {
+		if f.sourceIP = net.ParseIP(params.SourceIP); f.sourceIP == nil {
+			return nil, fmt.Errorf("invalid source IP: %s", params.SourceIP)
+		}
+	}
+	if params.SourceCIDR != "" {
+		var err error
+		if _, f.sourceCIDR, err = net.ParseCIDR(params.SourceCIDR); err != nil {
+			return nil, fmt.Errorf("invalid source CIDR: %s", params.SourceCIDR)
+		}
+	}
+
+	return f, nil
+}
+
+// FilterAuditLines returns the lines whose events match the parameters
+func FilterAuditLines(lines []string, params types.AuditQueryParams) ([]string, error) {
+	filter, err := NewEventFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, line := range lines {
+		if filter.Match(line) {
+			matched = append(matched, line)
+		}
+	}
+	return matched, nil
+}
+
+// Match reports whether a raw audit log line matches every filter. Lines that are
+// not JSON objects never match, as jq would not select them either.
+func (f *EventFilter) Match(line string) bool {
+	line = strings.TrimSpace(line)
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return false
+	}
+
+	for _, field := range f.fields {
+		if field.regex.MatchString(firstString(event, field.paths)) == field.negate {
+			return false
+		}
+	}
+
+	if !f.matchStatus(event) || !f.matchIdentity(event) || !f.matchSource(event) {
+		return false
+	}
+
+	if f.params.Stage != "" && stringAt(event, "stage") != f.params.Stage {
+		return false
+	}
+	if f.params.Level != "" && stringAt(event, "level") != f.params.Level {
+		return false
+	}
+
+	if f.datePattern != "" && !strings.Contains(stringAt(event, "requestReceivedTimestamp"), f.datePattern) {
+		return false
+	}
+
+	for _, pattern := range f.patterns {
+		if !pattern.MatchString(line) {
+			return false
+		}
+	}
+	for _, exclusion := range f.exclusions {
+		if exclusion.MatchString(line) {
+			return false
+		}
+	}
+	if f.expression != nil && !f.expression.match(line) {
+		return false
+	}
+
+	return true
+}
+
+// matchStatus applies the status code and status code range filters
+func (f *EventFilter) matchStatus(event map[string]interface{}) bool {
+	if f.params.StatusCode == 0 && !f.hasStatusRange {
+		return true
+	}
+
+	code := 0
+	if value, ok := valueAt(event, "responseStatus", "code").(float64); ok {
+		code = int(value)
+	}
+
+	if f.params.StatusCode != 0 && code != f.params.StatusCode {
+		return false
+	}
+	if !f.hasStatusRange {
+		return true
+	}
+	if len(f.statusCodes) > 0 {
+		for _, c := range f.statusCodes {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	}
+	return code >= f.statusLow && code <= f.statusHigh
+}
+
+// matchIdentity applies the group and impersonated user filters
+func (f *EventFilter) matchIdentity(event map[string]interface{}) bool {
+	if len(f.params.Groups) > 0 {
+		groups, _ := valueAt(event, "user", "groups").([]interface{})
+		found := false
+		for _, group := range groups {
+			if name, ok := group.(string); ok && utils.Contains(f.params.Groups, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return f.params.ImpersonatedUser == "" || stringAt(event, "impersonatedUser", "username") == f.params.ImpersonatedUser
+}
+
+// matchSource applies the source IP and CIDR filters
+func (f *EventFilter) matchSource(event map[string]interface{}) bool {
+	if f.sourceIP == nil && f.sourceCIDR == nil {
+		return true
+	}
+
+	entry := AuditLogEntry{}
+	ips, _ := valueAt(event, "sourceIPs").([]interface{})
+	for _, ip := range ips {
+		if s, ok := ip.(string); ok {
+			entry.SourceIPs = append(entry.SourceIPs, s)
+		}
+	}
+	return entryMatchesSource(entry, f.sourceIP, f.sourceCIDR)
+}
+
+// compilePattern compiles a pattern as a case-insensitive regular expression
+// of its literal text, the expression jq's test($p; "i") runs
+func compilePattern(pattern string) *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+}
+
+// compilePatterns compiles each pattern with compilePattern
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = compilePattern(pattern)
+	}
+	return compiled
+}
+
+// compileFilterExpression compiles the leaf patterns of a filter expression
+func compileFilterExpression(expr types.FilterExpression) filterMatcher {
+	if expr.IsLeaf() {
+		return filterMatcher{regex: compilePattern(expr.Pattern)}
+	}
+
+	matcher := filterMatcher{operator: expr.Operator, children: make([]filterMatcher, len(expr.Children))}
+	for i, child := range expr.Children {
+		matcher.children[i] = compileFilterExpression(child)
+	}
+	return matcher
+}
+
+// match evaluates the filter expression against the event text
+func (m filterMatcher) match(text string) bool {
+	if m.regex != nil {
+		return m.regex.MatchString(text)
+	}
+
+	switch m.operator {
+	case types.FilterOperatorOr, types.FilterOperatorNot:
+		matched := false
+		for _, child := range m.children {
+			if child.match(text) {
+				matched = true
+				break
+			}
+		}
+		return matched != (m.operator == types.FilterOperatorNot)
+	default:
+		for _, child := range m.children {
+			if !child.match(text) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// valueAt returns the value at a path of object keys, or nil when absent
+func valueAt(event map[string]interface{}, path ...string) interface{} {
+	var current interface{} = event
+	for _, key := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[key]
+	}
+	return current
+}
+
+// stringAt returns the string at a path of object keys, or "" when absent
+func stringAt(event map[string]interface{}, path ...string) string {
+	s, _ := valueAt(event, path...).(string)
+	return s
+}
+
+// firstString returns the first present value among the paths, like jq's "//"
+// operator, or "" when that value is not a string
+func firstString(event map[string]interface{}, paths [][]string) string {
+	for _, path := range paths {
+		value := valueAt(event, path...)
+		if value == nil || value == false {
+			continue
+		}
+		s, _ := value.(string)
+		return s
+	}
+	return ""
+}
+
+// fieldValues combines a single-value field filter with its multi-value list
+func fieldValues(value string, values []string) []string {
+	if value == "" {
+		return values
+	}
+	return append([]string{value}, values...)
+}
@@ -0,0 +1,207 @@
+package parsing
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+
+	"github.com/itchyny/gojq"
+)
+
+// sampleAuditLines returns audit events covering the fields the event filter reads
+func sampleAuditLines() []string {
+	today := time.Now().Format("2006-01-02")
+	return []string{
+		`{"auditID":"a1","stage":"ResponseComplete","level":"RequestResponse","verb":"delete","user":{"username":"admin","groups":["ops-team","system:authenticated"]},"sourceIPs":["10.128.0.12"],"userAgent":"kubectl/v1","objectRef":{"resource":"secrets","namespace":"payments"},"responseStatus":{"code":200},"requestReceivedTimestamp":"` + today + `T10:00:00Z"}`,
+		`{"auditID":"a2","stage":"ResponseComplete","level":"Metadata","verb":"delete","user":{"username":"system:serviceaccount:ci:deployer","groups":["system:serviceaccounts"]},"sourceIPs":["203.0.113.7"],"userAgent":"argocd-controller","objectRef":{"resource":"pods","namespace":"payments"},"responseStatus":{"code":403},"requestReceivedTimestamp":"` + today + `T11:00:00Z"}`,
+		`{"auditID":"a3","stage":"RequestReceived","level":"Metadata","verb":"get","user":{"username":"alice"},"impersonatedUser":{"username":"system:admin"},"sourceIPs":["10.128.0.40"],"userAgent":"oc/v4","objectRef":{"resource":"configmaps","namespace":"kube-system"},"requestReceivedTimestamp":"2020-01-01T00:00:00Z"}`,
+		`{"auditID":"a4","stage":"ResponseComplete","level":"Request","verb":"create","user":{"username":"bob"},"sourceIPs":["10.129.0.9"],"userAgent":"kubectl/v1","objectRef":{"resource":"deployments","namespace":"shop","subresource":"scale"},"responseStatus":{"code":500},"requestReceivedTimestamp":"` + today + `T12:00:00Z"}`,
+		`not a json line`,
+	}
+}
+
+// auditIDs returns the auditID of each matched line
+func auditIDs(lines []string) []string {
+	var ids []string
+	for _, line := range lines {
+		start := strings.Index(line, `"auditID":"`) + len(`"auditID":"`)
+		ids = append(ids, line[start:start+2])
+	}
+	return ids
+}
+
+func TestFilterAuditLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   types.AuditQueryParams
+		expected []string
+	}{
+		{"no filters", types.AuditQueryParams{}, []string{"a1", "a2", "a3", "a4"}},
+		{"verb", types.AuditQueryParams{Verb: "delete"}, []string{"a1", "a2"}},
+		{"usernames exact", types.AuditQueryParams{Usernames: []string{"admin", "bob"}, UsernameMatch: types.MatchModeExact}, []string{"a1", "a4"}},
+		{"username substring", types.AuditQueryParams{Username: "ADMIN"}, []string{"a1"}},
+		{"namespace prefix", types.AuditQueryParams{Namespace: "kube-", NamespaceMatch: types.MatchModePrefix}, []string{"a3"}},
+		{"resource", types.AuditQueryParams{Resource: "secrets"}, []string{"a1"}},
+		{"excluded users", types.AuditQueryParams{Verb: "delete", ExcludeUsers: []string{"system:serviceaccount:ci:*"}}, []string{"a1"}},
+		{"status code", types.AuditQueryParams{StatusCode: 403}, []string{"a2"}},
+		{"status class", types.AuditQueryParams{StatusCodeRange: "5xx"}, []string{"a4"}},
+		{"groups", types.AuditQueryParams{Groups: []string{"ops-team"}}, []string{"a1"}},
+		{"impersonated user", types.AuditQueryParams{ImpersonatedUser: "system:admin"}, []string{"a3"}},
+		{"user agent", types.AuditQueryParams{UserAgent: "kubectl"}, []string{"a1", "a4"}},
+		{"stage and level", types.AuditQueryParams{Stage: "ResponseComplete", Level: "Metadata"}, []string{"a2"}},
+		{"subresources", types.AuditQueryParams{Subresources: []string{"exec", "scale"}}, []string{"a4"}},
+		{"source IP", types.AuditQueryParams{SourceIP: "10.128.0.40"}, []string{"a3"}},
+		{"source CIDR", types.AuditQueryParams{SourceCIDR: "10.128.0.0/16"}, []string{"a1", "a3"}},
+		{"timeframe", types.AuditQueryParams{Timeframe: "today"}, []string{"a1", "a2", "a4"}},
+		{"patterns and exclusions", types.AuditQueryParams{Patterns: []string{"PAYMENTS"}, Exclude: []string{"argocd"}}, []string{"a1"}},
+		{
+			name: "filter expression",
+			params: types.AuditQueryParams{Filter: &types.FilterExpression{
+				Operator: types.FilterOperatorAnd,
+				Children: []types.FilterExpression{
+					{Operator: types.FilterOperatorOr, Children: []types.FilterExpression{{Pattern: "secrets"}, {Pattern: "deployments"}}},
+					{Operator: types.FilterOperatorNot, Children: []types.FilterExpression{{Pattern: "bob"}}},
+				},
+			}},
+			expected: []string{"a1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := FilterAuditLines(sampleAuditLines(), tt.params)
+			if err != nil {
+				t.Fatalf("FilterAuditLines() error = %v", err)
+			}
+			if got := strings.Join(auditIDs(lines), ","); got != strings.Join(tt.expected, ",") {
+				t.Errorf("FilterAuditLines() matched %s, want %s", got, strings.Join(tt.expected, ","))
+			}
+		})
+	}
+}
+
+func TestNewEventFilter_InvalidParams(t *testing.T) {
+	invalid := []types.AuditQueryParams{
+		{Username: "(", UsernameMatch: types.MatchModeRegex},
+		{StatusCodeRange: "teapots"},
+		{SourceIP: "not-an-ip"},
+		{SourceCIDR: "10.0.0.0/40"},
+	}
+	for _, params := range invalid {
+		if _, err := NewEventFilter(params); err == nil {
+			t.Errorf("Expected an error for %+v", params)
+		}
+	}
+}
+
+// TestFilterAuditLines_MatchesJQ checks that in-process filtering selects the same
+// events as the generated jq program
+func TestFilterAuditLines_MatchesJQ(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(logFile, []byte(strings.Join(sampleAuditLines()[:4], "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample log: %v", err)
+	}
+
+	cases := []types.AuditQueryParams{
+		{Verb: "delete", Timeframe: "today"},
+		{Namespace: "payments", ExcludeUsers: []string{"system:serviceaccount:ci:*"}},
+		{Usernames: []string{"admin", "alice"}, UsernameMatch: types.MatchModeExact},
+		{StatusCodeRange: "auth_error", Stage: "ResponseComplete"},
+		{Groups: []string{"ops-team"}, Level: "RequestResponse"},
+		{UserAgent: "kubectl", Patterns: []string{"shop"}},
+		{ImpersonatedUser: "system:admin", SourceIP: "10.128.0.40"},
+		{Subresources: []string{"scale"}, Verb: "create"},
+	}
+
+	for _, params := range cases {
+		params.LogSource = "kube-apiserver"
+		command := commands.BuildOcCommand(params)
+		start := strings.Index(command, "jq -r '")
+		if start < 0 {
+			t.Fatalf("Expected a jq command, got: %s", command)
+		}
+		program := strings.TrimSuffix(command[start+len("jq -r '"):], "'")
+
+		output, err := exec.Command("jq", "-c", program, logFile).CombinedOutput()
+		if err != nil {
+			t.Fatalf("jq failed for %+v: %v: %s", params, err, output)
+		}
+		jqCount := 0
+		if trimmed := strings.TrimSpace(string(output)); trimmed != "" {
+			jqCount = len(strings.Split(trimmed, "\n"))
+		}
+
+		lines, err := FilterAuditLines(sampleAuditLines(), params)
+		if err != nil {
+			t.Fatalf("FilterAuditLines() error = %v", err)
+		}
+		if len(lines) != jqCount {
+			t.Errorf("In-process filtering matched %d events, jq matched %d, for %+v", len(lines), jqCount, params)
+		}
+	}
+}
+
+// TestFilterAuditLines_PatternsMatchJQProgram checks that patterns, exclusions
+// and filter expressions match alike in both paths: the generated jq program,
+// run with gojq, and in-process filtering select the same events, matching
+// case-insensitively and taking regular expression syntax literally
+func TestFilterAuditLines_PatternsMatchJQProgram(t *testing.T) {
+	cases := []types.AuditQueryParams{
+		{Patterns: []string{"DELETE"}},
+		{Patterns: []string{"KubeCtl/V1"}, Exclude: []string{"Shop"}},
+		{Patterns: []string{"del.te"}},
+		{Patterns: []string{"secrets|deployments"}},
+		{Exclude: []string{"10.128.0.", "argocd-.*"}},
+		{Exclude: []string{"system:serviceaccount:ci:"}},
+		{Filter: &types.FilterExpression{Operator: types.FilterOperatorOr, Children: []types.FilterExpression{{Pattern: "CONFIGMAPS"}, {Pattern: `"code":500`}}}},
+		{Filter: &types.FilterExpression{Operator: types.FilterOperatorNot, Children: []types.FilterExpression{{Pattern: "10.128."}, {Pattern: "(pods)"}}}},
+	}
+
+	for _, params := range cases {
+		params.LogSource = "kube-apiserver"
+		command := commands.BuildOcCommand(params)
+		start := strings.Index(command, "jq -r '")
+		if start < 0 {
+			t.Fatalf("Expected a jq command, got: %s", command)
+		}
+		// The program is single-quoted for the shell, which leaves it as is
+		query, err := gojq.Parse(strings.TrimSuffix(command[start+len("jq -r '"):], "'"))
+		if err != nil {
+			t.Fatalf("Failed to parse the jq program of %+v: %v", params, err)
+		}
+
+		var jqIDs []string
+		for _, line := range sampleAuditLines() {
+			var event map[string]interface{}
+			if json.Unmarshal([]byte(line), &event) != nil {
+				continue
+			}
+			value, selected := query.Run(event).Next()
+			if err, failed := value.(error); failed {
+				t.Fatalf("The jq program of %+v failed: %v", params, err)
+			}
+			if selected {
+				jqIDs = append(jqIDs, event["auditID"].(string))
+			}
+		}
+
+		lines, err := FilterAuditLines(sampleAuditLines(), params)
+		if err != nil {
+			t.Fatalf("FilterAuditLines() error = %v", err)
+		}
+		if ids := auditIDs(lines); strings.Join(ids, ",") != strings.Join(jqIDs, ",") {
+			t.Errorf("In-process filtering matched %v, the jq program %v, for %+v", ids, jqIDs, params)
+		}
+	}
+}
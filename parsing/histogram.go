@@ -0,0 +1,79 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// bucketStart truncates a timestamp to the start of its bucket, in UTC
+func bucketStart(t time.Time, bucketSize string) time.Time {
+	t = t.UTC()
+	switch bucketSize {
+	case "minute":
+		return t.Truncate(time.Minute)
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	default:
+		return t.Truncate(time.Hour)
+	}
+}
+
+// BuildHistogram counts entries per time bucket ("minute", "hour" or "day"; hour
+// when empty) with per-verb and per-user breakdowns. Entries whose timestamp is
+// missing or unparseable are counted as untimed rather than dropped silently.
+func BuildHistogram(entries []AuditLogEntry, bucketSize string) (types.Histogram, error) {
+	if bucketSize == "" {
+		bucketSize = utils.DefaultHistogramBucketSize
+	}
+	if !utils.Contains(utils.HistogramBucketSizes, bucketSize) {
+		return types.Histogram{}, fmt.Errorf("invalid bucket size: %s", bucketSize)
+	}
+
+	histogram := types.Histogram{
+		BucketSize: bucketSize,
+		Buckets:    []types.HistogramBucket{},
+	}
+	buckets := make(map[time.Time]*types.HistogramBucket)
+
+	for _, entry := range entries {
+		timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			histogram.Untimed++
+			continue
+		}
+
+		start := bucketStart(timestamp, bucketSize)
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &types.HistogramBucket{
+				Start:  start.Format(time.RFC3339),
+				ByVerb: make(map[string]int),
+				ByUser: make(map[string]int),
+			}
+			buckets[start] = bucket
+		}
+
+		bucket.Count++
+		if entry.Verb != "" {
+			bucket.ByVerb[entry.Verb]++
+		}
+		if entry.Username != "" {
+			bucket.ByUser[entry.Username]++
+		}
+	}
+
+	starts := make([]time.Time, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	for _, start := range starts {
+		histogram.Buckets = append(histogram.Buckets, *buckets[start])
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,71 @@
+package parsing
+
+import "testing"
+
+func TestBuildHistogram(t *testing.T) {
+	entries := []AuditLogEntry{
+		{Timestamp: "2024-01-15T10:05:00.000000Z", Verb: "get", Username: "alice"},
+		{Timestamp: "2024-01-15T10:59:59.999999Z", Verb: "delete", Username: "alice"},
+		{Timestamp: "2024-01-15T09:30:00Z", Verb: "get", Username: "bob"},
+		{Timestamp: "2024-01-15T12:00:00.000000+02:00", Verb: "get", Username: "bob"},
+		{Verb: "list", Username: "carol"},
+	}
+
+	tests := []struct {
+		name       string
+		bucketSize string
+		starts     []string
+		counts     []int
+	}{
+		{
+			name:   "default hour buckets",
+			starts: []string{"2024-01-15T09:00:00Z", "2024-01-15T10:00:00Z"},
+			counts: []int{1, 3},
+		},
+		{
+			name:       "minute buckets",
+			bucketSize: "minute",
+			starts:     []string{"2024-01-15T09:30:00Z", "2024-01-15T10:00:00Z", "2024-01-15T10:05:00Z", "2024-01-15T10:59:00Z"},
+			counts:     []int{1, 1, 1, 1},
+		},
+		{
+			name:       "day buckets",
+			bucketSize: "day",
+			starts:     []string{"2024-01-15T00:00:00Z"},
+			counts:     []int{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histogram, err := BuildHistogram(entries, tt.bucketSize)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if histogram.Untimed != 1 {
+				t.Errorf("Expected 1 untimed entry, got %d", histogram.Untimed)
+			}
+			if len(histogram.Buckets) != len(tt.starts) {
+				t.Fatalf("Expected %d buckets, got %d: %+v", len(tt.starts), len(histogram.Buckets), histogram.Buckets)
+			}
+			for i, bucket := range histogram.Buckets {
+				if bucket.Start != tt.starts[i] || bucket.Count != tt.counts[i] {
+					t.Errorf("Bucket %d: expected %s x%d, got %s x%d", i, tt.starts[i], tt.counts[i], bucket.Start, bucket.Count)
+				}
+			}
+		})
+	}
+
+	histogram, _ := BuildHistogram(entries, "hour")
+	tenOClock := histogram.Buckets[1]
+	if tenOClock.ByVerb["get"] != 2 || tenOClock.ByVerb["delete"] != 1 {
+		t.Errorf("Unexpected verb counts: %v", tenOClock.ByVerb)
+	}
+	if tenOClock.ByUser["alice"] != 2 || tenOClock.ByUser["bob"] != 1 {
+		t.Errorf("Unexpected user counts: %v", tenOClock.ByUser)
+	}
+
+	if _, err := BuildHistogram(entries, "week"); err == nil {
+		t.Error("Expected error for unsupported bucket size")
+	}
+}
@@ -0,0 +1,157 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// Infrastructure change categories
+const (
+	InfrastructureMachineConfig = "machineconfig"
+	InfrastructureNode          = "node"
+	InfrastructureScheduler     = "scheduler"
+	InfrastructureAPIServer     = "apiserver"
+)
+
+// infrastructureCategories maps the infrastructure resources to their
+// category: the machine config operator's resources, nodes, and the
+// scheduler and API server configuration of config.openshift.io and
+// operator.openshift.io
+var infrastructureCategories = map[string]string{
+	"machineconfigs":          InfrastructureMachineConfig,
+	"machineconfigpools":      InfrastructureMachineConfig,
+	"kubeletconfigs":          InfrastructureMachineConfig,
+	"containerruntimeconfigs": InfrastructureMachineConfig,
+	"nodes":                   InfrastructureNode,
+	"schedulers":              InfrastructureScheduler,
+	"kubeschedulers":          InfrastructureScheduler,
+	"apiservers":              InfrastructureAPIServer,
+	"kubeapiservers":          InfrastructureAPIServer,
+}
+
+// InfrastructureResources are the resources audited for infrastructure changes
+var InfrastructureResources = []string{
+	"machineconfigs", "machineconfigpools", "kubeletconfigs", "containerruntimeconfigs",
+	"nodes", "schedulers", "kubeschedulers", "apiservers", "kubeapiservers",
+}
+
+// InfrastructureChangeVerbs are the verbs that change infrastructure objects
+var InfrastructureChangeVerbs = []string{"create", "update", "patch", "delete", "deletecollection"}
+
+// InfrastructureControllers are the users that change infrastructure objects
+// as part of normal operation: kubelets, the control plane and the cluster's
+// operators, such as the machine config daemon annotating nodes. A trailing
+// "*" matches any suffix.
+var InfrastructureControllers = []string{
+	"system:node:*",
+	"system:kube-controller-manager",
+	"system:serviceaccount:kube-system:*",
+	"system:serviceaccount:openshift-*",
+}
+
+// infrastructureCategory returns the category of an entry that changes an
+// infrastructure object. Subresource requests such as status updates are
+// reported by the components themselves, not configuration changes.
+func infrastructureCategory(entry AuditLogEntry) (string, bool) {
+	category, ok := infrastructureCategories[entry.Resource]
+	if !ok || entry.Subresource != "" {
+		return "", false
+	}
+	for _, verb := range InfrastructureChangeVerbs {
+		if entry.Verb == verb {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// AnalyzeInfrastructureChanges builds the change log of the infrastructure
+// objects changed in entries, with the changes counted by category and user.
+// Requests answered with an error changed nothing and are only counted as
+// failed. Other entries are ignored.
+func AnalyzeInfrastructureChanges(entries []AuditLogEntry) types.InfrastructureChangeReport {
+	report := types.InfrastructureChangeReport{
+		ByCategory: make(map[string]int),
+		Users:      []types.InfrastructureChangeUser{},
+		Changes:    []types.InfrastructureChange{},
+	}
+	users := make(map[string]*types.InfrastructureChangeUser)
+	for _, entry := range entries {
+		category, ok := infrastructureCategory(entry)
+		if !ok {
+			continue
+		}
+		if entry.StatusCode >= 400 {
+			report.Failed++
+			continue
+		}
+
+		report.Changes = append(report.Changes, types.InfrastructureChange{
+			Timestamp:  entry.Timestamp,
+			Username:   entry.Username,
+			Category:   category,
+			Verb:       entry.Verb,
+			Resource:   entry.Resource,
+			APIGroup:   entry.APIGroup,
+			Name:       entry.Name,
+			StatusCode: entry.StatusCode,
+			SourceIPs:  entry.SourceIPs,
+			UserAgent:  entry.UserAgent,
+			AuditID:    entry.AuditID,
+		})
+		report.ByCategory[category]++
+		user, ok := users[entry.Username]
+		if !ok {
+			user = &types.InfrastructureChangeUser{Username: entry.Username, Categories: make(map[string]int)}
+			users[entry.Username] = user
+		}
+		user.Changes++
+		user.Categories[category]++
+	}
+	report.TotalChanges = len(report.Changes)
+
+	// Audit timestamps are UTC with a fixed precision, so they sort as strings
+	sort.SliceStable(report.Changes, func(i, j int) bool { return report.Changes[i].Timestamp < report.Changes[j].Timestamp })
+	for _, user := range users {
+		report.Users = append(report.Users, *user)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		if report.Users[i].Changes != report.Users[j].Changes {
+			return report.Users[i].Changes > report.Users[j].Changes
+		}
+		return report.Users[i].Username < report.Users[j].Username
+	})
+
+	report.Summary = summarizeInfrastructureChanges(report)
+	return report
+}
+
+// summarizeInfrastructureChanges produces a short human-readable description of a report
+func summarizeInfrastructureChanges(report types.InfrastructureChangeReport) string {
+	summary := fmt.Sprintf("%d infrastructure changes by %d users", report.TotalChanges, len(report.Users))
+	if report.Failed > 0 {
+		summary += fmt.Sprintf(", %d failed", report.Failed)
+	}
+	if report.TotalChanges == 0 {
+		return summary
+	}
+
+	var categories []string
+	for _, category := range []string{InfrastructureMachineConfig, InfrastructureNode, InfrastructureScheduler, InfrastructureAPIServer} {
+		if count := report.ByCategory[category]; count > 0 {
+			categories = append(categories, fmt.Sprintf("%s %d", category, count))
+		}
+	}
+	var users []string
+	for i, user := range report.Users {
+		if i == 5 {
+			users = append(users, fmt.Sprintf("and %d more", len(report.Users)-i))
+			break
+		}
+		users = append(users, fmt.Sprintf("%s (%d)", user.Username, user.Changes))
+	}
+	return summary + "; " + strings.Join(categories, ", ") + "; by " + strings.Join(users, ", ")
+}
@@ -0,0 +1,45 @@
+package parsing
+
+import (
+	"testing"
+)
+
+// TestAnalyzeInfrastructureChanges tests building the infrastructure change log
+func TestAnalyzeInfrastructureChanges(t *testing.T) {
+	change := func(username, verb, resource, name, timestamp string, status int) AuditLogEntry {
+		return AuditLogEntry{Timestamp: timestamp, Username: username, Verb: verb, Resource: resource, Name: name, StatusCode: status}
+	}
+	status := change("system:node:worker-0", "patch", "nodes", "worker-0", "2026-03-01T12:00:00.000000Z", 200)
+	status.Subresource = "status"
+	entries := []AuditLogEntry{
+		change("alice", "patch", "nodes", "worker-1", "2026-03-01T12:05:00.000000Z", 200),
+		change("alice", "create", "machineconfigs", "99-worker-ssh", "2026-03-01T12:01:00.000000Z", 201),
+		change("bob", "update", "schedulers", "cluster", "2026-03-01T12:03:00.000000Z", 200),
+		change("bob", "patch", "apiservers", "cluster", "2026-03-01T12:04:00.000000Z", 403),
+		change("bob", "get", "machineconfigs", "99-worker-ssh", "2026-03-01T12:02:00.000000Z", 200),
+		change("carol", "delete", "deployments", "web", "2026-03-01T12:02:00.000000Z", 200),
+		status,
+	}
+
+	report := AnalyzeInfrastructureChanges(entries)
+	if report.TotalChanges != 3 || report.Failed != 1 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if report.Changes[0].Name != "99-worker-ssh" || report.Changes[0].Category != InfrastructureMachineConfig || report.Changes[2].Category != InfrastructureNode {
+		t.Errorf("Unexpected change log: %+v", report.Changes)
+	}
+	if report.ByCategory[InfrastructureScheduler] != 1 || report.ByCategory[InfrastructureAPIServer] != 0 {
+		t.Errorf("Unexpected categories: %v", report.ByCategory)
+	}
+	if len(report.Users) != 2 || report.Users[0].Username != "alice" || report.Users[0].Categories[InfrastructureNode] != 1 {
+		t.Errorf("Unexpected users: %+v", report.Users)
+	}
+
+	expected := "3 infrastructure changes by 2 users, 1 failed; machineconfig 1, node 1, scheduler 1; by alice (2), bob (1)"
+	if report.Summary != expected {
+		t.Errorf("Expected summary %q, got %q", expected, report.Summary)
+	}
+	if summary := AnalyzeInfrastructureChanges(nil).Summary; summary != "0 infrastructure changes by 0 users" {
+		t.Errorf("Unexpected summary: %s", summary)
+	}
+}
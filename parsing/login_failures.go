@@ -0,0 +1,254 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// Default login failure burst detection settings
+const (
+	DefaultLoginBurstThreshold = 5
+	DefaultLoginBurstWindow    = 5 * time.Minute
+)
+
+// attemptedUsername returns the username a login tried. Logins are recorded
+// for the anonymous user, with the username OpenShift's OAuth server tried in
+// an annotation.
+func attemptedUsername(entry AuditLogEntry) string {
+	if attempted, ok := entry.Annotations[utils.AuditLogFields["AuthenticationUsername"]].(string); ok && attempted != "" {
+		return attempted
+	}
+	return entry.Username
+}
+
+// isFailedLogin reports whether an OAuth server entry is a refused login
+func isFailedLogin(entry AuditLogEntry) bool {
+	return entry.AuthDecision == "deny" || entry.StatusCode == 401
+}
+
+// isSuccessfulLogin reports whether an OAuth server entry is an accepted login
+func isSuccessfulLogin(entry AuditLogEntry) bool {
+	return entry.AuthDecision == "allow"
+}
+
+// loginFailureKind classifies a failed login and returns the identity
+// provider it went through, when the request path names one. Callbacks from
+// external identity providers arrive at /oauth2callback/<provider>, refused
+// code and refresh token grants at /oauth/token, and password logins at the
+// login form or as basic authentication of /oauth/authorize.
+func loginFailureKind(entry AuditLogEntry) (string, string) {
+	path := entry.RequestURI
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	provider := ""
+	if len(segments) > 1 && (segments[0] == "oauth2callback" || segments[0] == "login") {
+		provider = segments[1]
+	}
+
+	switch {
+	case segments[0] == "oauth2callback":
+		return types.LoginFailureIdP, provider
+	case strings.HasPrefix(path, "/oauth/token"), strings.Contains(strings.ToLower(entry.StatusMessage), "token"):
+		return types.LoginFailureToken, provider
+	default:
+		return types.LoginFailurePassword, provider
+	}
+}
+
+// loginBursts returns the runs of times, in order, with at least threshold
+// within window of each other
+func loginBursts(scope, key string, times []time.Time, threshold int, window time.Duration) []types.LoginFailureBurst {
+	var bursts []types.LoginFailureBurst
+	start, end, in := 0, 0, false
+	closeBurst := func() {
+		bursts = append(bursts, types.LoginFailureBurst{
+			Scope:    scope,
+			Key:      key,
+			Start:    times[start].UTC().Format(time.RFC3339Nano),
+			End:      times[end].UTC().Format(time.RFC3339Nano),
+			Failures: end - start + 1,
+		})
+		in = false
+	}
+	first := 0
+	for last := range times {
+		for times[last].Sub(times[first]) > window {
+			first++
+		}
+		if last-first+1 >= threshold {
+			if !in {
+				start, in = first, true
+			}
+			end = last
+		} else if in {
+			closeBurst()
+		}
+	}
+	if in {
+		closeBurst()
+	}
+	return bursts
+}
+
+// AnalyzeLoginFailures groups the failed logins of OAuth server entries by
+// the username tried and by source IP, classifying each as a password, token
+// or identity provider failure, and reports the bursts of at least threshold
+// failures of one user or source IP within window. Users whose login then
+// succeeded are flagged. Entries without a parseable timestamp count but
+// cannot form bursts. Zero arguments select the defaults.
+func AnalyzeLoginFailures(entries []AuditLogEntry, threshold int, window time.Duration) types.LoginFailureReport {
+	if threshold <= 0 {
+		threshold = DefaultLoginBurstThreshold
+	}
+	if window <= 0 {
+		window = DefaultLoginBurstWindow
+	}
+	report := types.LoginFailureReport{
+		ByKind:         make(map[string]int),
+		BurstThreshold: threshold,
+		BurstWindow:    window.String(),
+		Users:          []types.LoginFailureUser{},
+		SourceIPs:      []types.LoginFailureSource{},
+		Bursts:         []types.LoginFailureBurst{},
+	}
+
+	users := make(map[string]*types.LoginFailureUser)
+	sources := make(map[string]*types.LoginFailureSource)
+	userTimes := make(map[string][]time.Time)
+	sourceTimes := make(map[string][]time.Time)
+	for _, entry := range uniqueRequests(entries, func(entry AuditLogEntry) bool { return isFailedLogin(entry) || isSuccessfulLogin(entry) }) {
+		username := attemptedUsername(entry)
+		if isSuccessfulLogin(entry) {
+			report.SuccessfulLogins++
+			if user := users[username]; user != nil {
+				user.SucceededAfterFailures = true
+			}
+			continue
+		}
+
+		report.TotalFailures++
+		kind, provider := loginFailureKind(entry)
+		report.ByKind[kind]++
+		timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+
+		user := users[username]
+		if user == nil {
+			user = &types.LoginFailureUser{Username: username, Kinds: make(map[string]int), SourceIPs: []string{}, FirstFailure: entry.Timestamp}
+			users[username] = user
+		}
+		user.Failures++
+		user.Kinds[kind]++
+		user.LastFailure = entry.Timestamp
+		if provider != "" && !containsString(user.IdentityProviders, provider) {
+			user.IdentityProviders = append(user.IdentityProviders, provider)
+		}
+		if err == nil {
+			userTimes[username] = append(userTimes[username], timestamp)
+		}
+
+		for _, ip := range entry.SourceIPs {
+			if !containsString(user.SourceIPs, ip) {
+				user.SourceIPs = append(user.SourceIPs, ip)
+			}
+			source := sources[ip]
+			if source == nil {
+				source = &types.LoginFailureSource{SourceIP: ip, Usernames: []string{}, Kinds: make(map[string]int), FirstFailure: entry.Timestamp}
+				sources[ip] = source
+			}
+			source.Failures++
+			source.Kinds[kind]++
+			source.LastFailure = entry.Timestamp
+			if !containsString(source.Usernames, username) {
+				source.Usernames = append(source.Usernames, username)
+			}
+			if err == nil {
+				sourceTimes[ip] = append(sourceTimes[ip], timestamp)
+			}
+		}
+	}
+
+	for username, user := range users {
+		bursts := loginBursts(types.LoginBurstUser, username, userTimes[username], threshold, window)
+		user.Burst = len(bursts) > 0
+		report.Bursts = append(report.Bursts, bursts...)
+		sort.Strings(user.SourceIPs)
+		report.Users = append(report.Users, *user)
+	}
+	for ip, source := range sources {
+		bursts := loginBursts(types.LoginBurstSourceIP, ip, sourceTimes[ip], threshold, window)
+		source.Burst = len(bursts) > 0
+		report.Bursts = append(report.Bursts, bursts...)
+		sort.Strings(source.Usernames)
+		report.SourceIPs = append(report.SourceIPs, *source)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		if report.Users[i].Failures != report.Users[j].Failures {
+			return report.Users[i].Failures > report.Users[j].Failures
+		}
+		return report.Users[i].Username < report.Users[j].Username
+	})
+	sort.Slice(report.SourceIPs, func(i, j int) bool {
+		if report.SourceIPs[i].Failures != report.SourceIPs[j].Failures {
+			return report.SourceIPs[i].Failures > report.SourceIPs[j].Failures
+		}
+		return report.SourceIPs[i].SourceIP < report.SourceIPs[j].SourceIP
+	})
+	sort.Slice(report.Bursts, func(i, j int) bool {
+		a, b := report.Bursts[i], report.Bursts[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.Scope+a.Key < b.Scope+b.Key
+	})
+
+	report.Summary = summarizeLoginFailures(report)
+	return report
+}
+
+// containsString reports whether values holds value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeLoginFailures produces a short human-readable description of a report
+func summarizeLoginFailures(report types.LoginFailureReport) string {
+	summary := fmt.Sprintf("%d failed logins (%d password, %d token, %d identity provider) for %d users from %d source IPs",
+		report.TotalFailures, report.ByKind[types.LoginFailurePassword], report.ByKind[types.LoginFailureToken], report.ByKind[types.LoginFailureIdP],
+		len(report.Users), len(report.SourceIPs))
+	if len(report.Bursts) == 0 {
+		return summary + "; no bursts"
+	}
+
+	var bursts []string
+	for _, burst := range report.Bursts {
+		if len(bursts) == 5 {
+			bursts = append(bursts, fmt.Sprintf("and %d more", len(report.Bursts)-5))
+			break
+		}
+		bursts = append(bursts, fmt.Sprintf("%s %s (%d)", strings.ReplaceAll(burst.Scope, "_", " "), burst.Key, burst.Failures))
+	}
+	summary += fmt.Sprintf("; %d bursts of %d or more within %s: %s", len(report.Bursts), report.BurstThreshold, report.BurstWindow, strings.Join(bursts, ", "))
+
+	var succeeded []string
+	for _, user := range report.Users {
+		if user.Burst && user.SucceededAfterFailures {
+			succeeded = append(succeeded, user.Username)
+		}
+	}
+	if len(succeeded) > 0 {
+		summary += "; logged in after a burst: " + strings.Join(succeeded, ", ")
+	}
+	return summary
+}
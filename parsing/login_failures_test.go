@@ -0,0 +1,86 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestLoginFailureKind tests classifying failed logins by request path and message
+func TestLoginFailureKind(t *testing.T) {
+	tests := []struct {
+		requestURI, message string
+		kind, provider      string
+	}{
+		{"/login/htpasswd", "", types.LoginFailurePassword, "htpasswd"},
+		{"/oauth/authorize?client_id=openshift-challenging-client", "", types.LoginFailurePassword, ""},
+		{"/oauth/token", "", types.LoginFailureToken, ""},
+		{"/apis/user.openshift.io/v1/users/~", "invalid bearer token", types.LoginFailureToken, ""},
+		{"/oauth2callback/github?code=abc", "", types.LoginFailureIdP, "github"},
+	}
+	for _, test := range tests {
+		kind, provider := loginFailureKind(AuditLogEntry{RequestURI: test.requestURI, StatusMessage: test.message})
+		if kind != test.kind || provider != test.provider {
+			t.Errorf("%s: expected %s via %q, got %s via %q", test.requestURI, test.kind, test.provider, kind, provider)
+		}
+	}
+}
+
+// TestAnalyzeLoginFailures tests grouping failed logins and detecting bursts by user and source IP
+func TestAnalyzeLoginFailures(t *testing.T) {
+	login := func(auditID, username, ip, requestURI, decision, timestamp string) AuditLogEntry {
+		status := 401
+		if decision == "allow" {
+			status = 302
+		}
+		return AuditLogEntry{
+			AuditID: auditID, Timestamp: timestamp, Username: "system:anonymous", SourceIPs: []string{ip}, RequestURI: requestURI,
+			StatusCode: status, AuthDecision: decision,
+			Annotations: map[string]interface{}{"authentication.openshift.io/username": username},
+		}
+	}
+	entries := []AuditLogEntry{
+		login("a1", "alice", "203.0.113.50", "/login/htpasswd", "deny", "2026-03-01T12:00:00.000000Z"),
+		login("a2", "alice", "203.0.113.50", "/login/htpasswd", "deny", "2026-03-01T12:01:00.000000Z"),
+		login("a3", "alice", "203.0.113.50", "/login/htpasswd", "deny", "2026-03-01T12:02:00.000000Z"),
+		login("a4", "alice", "203.0.113.50", "/login/htpasswd", "allow", "2026-03-01T12:03:00.000000Z"),
+		login("b1", "bob", "203.0.113.50", "/oauth/token", "deny", "2026-03-01T12:03:30.000000Z"),
+		login("c1", "carol", "10.0.0.7", "/oauth2callback/github", "deny", "2026-03-01T12:00:00.000000Z"),
+		login("c2", "carol", "10.0.0.7", "/oauth2callback/github", "deny", "2026-03-01T13:00:00.000000Z"),
+		{AuditID: "x1", Timestamp: "2026-03-01T12:00:00.000000Z", Username: "alice", Verb: "get", StatusCode: 200},
+	}
+
+	report := AnalyzeLoginFailures(entries, 3, 0)
+	if report.TotalFailures != 6 || report.SuccessfulLogins != 1 || report.BurstWindow != "5m0s" {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if !reflect.DeepEqual(report.ByKind, map[string]int{types.LoginFailurePassword: 3, types.LoginFailureToken: 1, types.LoginFailureIdP: 2}) {
+		t.Errorf("Unexpected failure kinds: %+v", report.ByKind)
+	}
+
+	alice := report.Users[0]
+	if alice.Username != "alice" || !alice.Burst || !alice.SucceededAfterFailures || !reflect.DeepEqual(alice.IdentityProviders, []string{"htpasswd"}) {
+		t.Errorf("Expected alice's burst and later login: %+v", alice)
+	}
+	if carol := report.Users[1]; carol.Username != "carol" || carol.Burst || carol.Kinds[types.LoginFailureIdP] != 2 {
+		t.Errorf("Expected carol's failures an hour apart not to burst: %+v", carol)
+	}
+
+	source := report.SourceIPs[0]
+	if source.SourceIP != "203.0.113.50" || source.Failures != 4 || !reflect.DeepEqual(source.Usernames, []string{"alice", "bob"}) || !source.Burst {
+		t.Errorf("Unexpected source: %+v", source)
+	}
+
+	expected := []types.LoginFailureBurst{
+		{Scope: types.LoginBurstSourceIP, Key: "203.0.113.50", Start: "2026-03-01T12:00:00Z", End: "2026-03-01T12:03:30Z", Failures: 4},
+		{Scope: types.LoginBurstUser, Key: "alice", Start: "2026-03-01T12:00:00Z", End: "2026-03-01T12:02:00Z", Failures: 3},
+	}
+	if !reflect.DeepEqual(report.Bursts, expected) {
+		t.Errorf("Unexpected bursts:\n%+v\nexpected\n%+v", report.Bursts, expected)
+	}
+	if !strings.Contains(report.Summary, "3 password, 1 token, 2 identity provider") || !strings.Contains(report.Summary, "logged in after a burst: alice") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+}
@@ -0,0 +1,97 @@
+package parsing
+
+import (
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/utils"
+)
+
+// serviceAccountPrefix starts the usernames of service accounts
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// timestampLayouts are the layouts timestamps are read with, besides RFC 3339.
+// Timestamps without a zone are taken as UTC.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// IsSystemUser reports whether a username belongs to the platform rather than
+// a person: a service account, node or control plane component
+func IsSystemUser(username string) bool {
+	return strings.HasPrefix(username, "system:")
+}
+
+// IsServiceAccount reports whether a username is a service account's
+func IsServiceAccount(username string) bool {
+	return strings.HasPrefix(username, serviceAccountPrefix)
+}
+
+// CanonicalResource returns the audit resource string for a resource name as
+// users and other sources write it, resolved with the resource alias
+// dictionary: lower-cased, plural, such as "Deployment" or "deploy" for
+// "deployments", and without an API group qualifier, which is returned
+// separately, as in "deployments.apps"
+func CanonicalResource(name string) (resource, apiGroup string) {
+	resource = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexByte(resource, '.'); i > 0 {
+		resource, apiGroup = resource[:i], resource[i+1:]
+	}
+	resource, _ = utils.ResolveResource(resource)
+	return resource, apiGroup
+}
+
+// NormalizeTimestamp returns a timestamp as RFC 3339 in UTC, reading the
+// layouts of timestampLayouts as well, and whether it could be read. UTC
+// RFC 3339 timestamps are returned unchanged, keeping their precision.
+func NormalizeTimestamp(timestamp string) (string, bool) {
+	timestamp = strings.TrimSpace(timestamp)
+	if parsed, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		if strings.HasSuffix(timestamp, "Z") {
+			return timestamp, true
+		}
+		return parsed.UTC().Format(time.RFC3339Nano), true
+	}
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, timestamp); err == nil {
+			return parsed.UTC().Format(time.RFC3339Nano), true
+		}
+	}
+	return timestamp, false
+}
+
+// NormalizeEntry makes the fields of a parsed entry consistent, so analytics
+// and filters treat events from every source alike: timestamps in UTC
+// RFC 3339, lower-case verbs, canonical resource names with a subresource or
+// API group qualifier split off, and the derived user classification.
+// Timestamps that cannot be read are left for validation to report.
+func NormalizeEntry(entry *AuditLogEntry) {
+	if timestamp, ok := NormalizeTimestamp(entry.Timestamp); ok {
+		entry.Timestamp = timestamp
+	}
+	if timestamp, ok := NormalizeTimestamp(entry.StageTimestamp); ok {
+		entry.StageTimestamp = timestamp
+	}
+
+	entry.Verb = strings.ToLower(strings.TrimSpace(entry.Verb))
+
+	if entry.Resource != "" {
+		resource := entry.Resource
+		if i := strings.IndexByte(resource, '/'); i > 0 {
+			if entry.Subresource == "" {
+				entry.Subresource = resource[i+1:]
+			}
+			resource = resource[:i]
+		}
+		var apiGroup string
+		entry.Resource, apiGroup = CanonicalResource(resource)
+		if entry.APIGroup == "" {
+			entry.APIGroup = apiGroup
+		}
+	}
+
+	entry.IsSystemUser = IsSystemUser(entry.Username)
+	entry.IsServiceAccount = IsServiceAccount(entry.Username)
+}
@@ -0,0 +1,90 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		apiGroup string
+	}{
+		{"pods", "pods", ""},
+		{"Pod", "pods", ""},
+		{"deployment", "deployments", ""},
+		{"deployments.apps", "deployments", "apps"},
+		{"Route.route.openshift.io", "routes", "route.openshift.io"},
+		{"ingress", "ingresses", ""},
+		{"NetworkPolicy", "networkpolicies", ""},
+		{"endpoints", "endpoints", ""},
+		{"securitycontextconstraints", "securitycontextconstraints", ""},
+		{"virtualmachine", "virtualmachine", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, apiGroup := CanonicalResource(tt.name)
+			if resource != tt.resource || apiGroup != tt.apiGroup {
+				t.Errorf("Expected %q in group %q, got %q in group %q", tt.resource, tt.apiGroup, resource, apiGroup)
+			}
+		})
+	}
+}
+
+func TestNormalizeTimestamp(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		expected  string
+		ok        bool
+	}{
+		{"2024-01-15T10:30:00.000000Z", "2024-01-15T10:30:00.000000Z", true},
+		{"2024-01-15T12:30:00.5+02:00", "2024-01-15T10:30:00.5Z", true},
+		{"2024-01-15 10:30:00", "2024-01-15T10:30:00Z", true},
+		{"2024-01-15T10:30:00.123", "2024-01-15T10:30:00.123Z", true},
+		{"yesterday", "yesterday", false},
+	}
+
+	for _, tt := range tests {
+		timestamp, ok := NormalizeTimestamp(tt.timestamp)
+		if timestamp != tt.expected || ok != tt.ok {
+			t.Errorf("%s: expected %q, %v, got %q, %v", tt.timestamp, tt.expected, tt.ok, timestamp, ok)
+		}
+	}
+}
+
+func TestNormalizeEntry(t *testing.T) {
+	entry := AuditLogEntry{
+		Timestamp: "2024-01-15T12:30:00+02:00",
+		Username:  "system:serviceaccount:ci:deployer",
+		Verb:      " GET ",
+		Resource:  "Pod/log",
+	}
+	NormalizeEntry(&entry)
+	if entry.Timestamp != "2024-01-15T10:30:00Z" || entry.Verb != "get" || entry.Resource != "pods" || entry.Subresource != "log" {
+		t.Errorf("Unexpected normalized entry %+v", entry)
+	}
+	if !entry.IsSystemUser || !entry.IsServiceAccount {
+		t.Errorf("Expected a system service account, got %v and %v", entry.IsSystemUser, entry.IsServiceAccount)
+	}
+
+	entry = AuditLogEntry{Username: "system:node:master-0", Resource: "deployments.apps", APIGroup: "extensions"}
+	NormalizeEntry(&entry)
+	if entry.Resource != "deployments" || entry.APIGroup != "extensions" || !entry.IsSystemUser || entry.IsServiceAccount {
+		t.Errorf("Unexpected normalized entry %+v", entry)
+	}
+
+	// Parsed lines are normalized before validation
+	line := `{"requestReceivedTimestamp":"2024-01-15 10:30:00","stageTimestamp":"soon","user":{"username":"alice"},"verb":"DELETE","objectRef":{"resource":"Secret"}}`
+	parsed, err := ParseAuditLogLine(line, DefaultParserConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parsed.Timestamp != "2024-01-15T10:30:00Z" || parsed.Verb != "delete" || parsed.Resource != "secrets" || parsed.IsSystemUser {
+		t.Errorf("Unexpected parsed entry %+v", parsed)
+	}
+	if len(parsed.ParseErrors) != 1 || !strings.Contains(parsed.ParseErrors[0], "invalid stage timestamp format: soon") {
+		t.Errorf("Expected the stage timestamp reported, got %v", parsed.ParseErrors)
+	}
+}
@@ -0,0 +1,143 @@
+package parsing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// maxDiffValueLength is the length above which string values, such as CA
+// bundles, are shown as their size and digest
+const maxDiffValueLength = 128
+
+// ignoredDiffFields are the fields the API server maintains, which change
+// with every write and say nothing about the change itself
+var ignoredDiffFields = []string{
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.managedFields",
+	"metadata.uid",
+	"metadata.creationTimestamp",
+	"status",
+}
+
+// DiffObjects compares two versions of an object field by field and returns
+// the changes sorted by path. Fields the API server maintains, such as the
+// resource version, managed fields and status, are ignored.
+func DiffObjects(old, new map[string]interface{}) []types.FieldChange {
+	oldFields, newFields := make(map[string]interface{}), make(map[string]interface{})
+	flattenObject("", old, oldFields)
+	flattenObject("", new, newFields)
+
+	changes := []types.FieldChange{}
+	for path, oldValue := range oldFields {
+		newValue, ok := newFields[path]
+		switch {
+		case !ok:
+			changes = append(changes, types.FieldChange{Path: path, Old: diffValue(oldValue)})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, types.FieldChange{Path: path, Old: diffValue(oldValue), New: diffValue(newValue)})
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			changes = append(changes, types.FieldChange{Path: path, New: diffValue(newValue)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// flattenObject records the leaves of value under their paths. Empty maps
+// and lists are leaves, so adding or removing one is a change.
+func flattenObject(path string, value interface{}, fields map[string]interface{}) {
+	for _, ignored := range ignoredDiffFields {
+		if path == ignored {
+			return
+		}
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if len(typed) == 0 && path != "" {
+			fields[path] = typed
+		}
+		for key, child := range typed {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenObject(childPath, child, fields)
+		}
+	case []interface{}:
+		if len(typed) == 0 {
+			fields[path] = typed
+		}
+		for i, child := range typed {
+			flattenObject(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	default:
+		if path != "" {
+			fields[path] = value
+		}
+	}
+}
+
+// diffValue shortens long strings to their size and digest
+func diffValue(value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || len(text) <= maxDiffValueLength {
+		return value
+	}
+	digest := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("sha256:%s (%d bytes)", hex.EncodeToString(digest[:])[:12], len(text))
+}
+
+// objectKey identifies an object by resource, namespace and name
+func objectKey(entry AuditLogEntry) string {
+	return strings.Join([]string{entry.Resource, entry.Namespace, entry.Name}, "/")
+}
+
+// objectAfterChange returns an object as a change left it: the response body,
+// or the request body of a create or update, which carries the whole object.
+// Deletions and patches whose response was not logged return nil.
+func objectAfterChange(entry AuditLogEntry) map[string]interface{} {
+	if entry.Verb == "delete" {
+		return nil
+	}
+	if len(entry.ResponseObject) > 0 {
+		return entry.ResponseObject
+	}
+	if entry.Verb != "patch" && len(entry.RequestObject) > 0 {
+		return entry.RequestObject
+	}
+	return nil
+}
+
+// uniqueRequests returns the entries selected by include with the events of
+// one request counted once, preferring the ResponseComplete stage, in time
+// order
+func uniqueRequests(entries []AuditLogEntry, include func(AuditLogEntry) bool) []AuditLogEntry {
+	var changes []AuditLogEntry
+	requests := make(map[string]int)
+	for _, entry := range entries {
+		if !include(entry) {
+			continue
+		}
+		if i, ok := requests[entry.AuditID]; ok && entry.AuditID != "" {
+			if entry.Stage == "ResponseComplete" {
+				changes[i] = entry
+			}
+			continue
+		}
+		requests[entry.AuditID] = len(changes)
+		changes = append(changes, entry)
+	}
+	// Audit timestamps are UTC with a fixed precision, so they sort as strings
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Timestamp < changes[j].Timestamp })
+	return changes
+}
@@ -28,6 +28,13 @@ type AuditLogEntry struct {
 	UserAgent  string   `json:"user_agent,omitempty"`
 	SourceIPs  []string `json:"source_ips,omitempty"`
 
+	// Event metadata
+	AuditID        string `json:"audit_id,omitempty"`
+	Stage          string `json:"stage,omitempty"`
+	Level          string `json:"level,omitempty"`
+	StageTimestamp string `json:"stage_timestamp,omitempty"`
+	Subresource    string `json:"subresource,omitempty"`
+
 	// Response fields
 	StatusCode    int    `json:"status_code,omitempty"`
 	StatusMessage string `json:"status_message,omitempty"`
@@ -36,8 +43,20 @@ type AuditLogEntry struct {
 	// Authentication fields
 	AuthDecision     string `json:"auth_decision,omitempty"`
 	AuthzDecision    string `json:"authz_decision,omitempty"`
+	AuthzReason      string `json:"authz_reason,omitempty"`
 	ImpersonatedUser string `json:"impersonated_user,omitempty"`
 
+	// Derived fields, filled in by normalization
+	IsSystemUser     bool `json:"is_system_user"`
+	IsServiceAccount bool `json:"is_service_account"`
+
+	// Request and response bodies, only captured when the parser is
+	// configured to include objects. Bodies larger than the configured cap
+	// are dropped and listed in OmittedObjects instead.
+	RequestObject  map[string]interface{} `json:"request_object,omitempty"`
+	ResponseObject map[string]interface{} `json:"response_object,omitempty"`
+	OmittedObjects []string               `json:"omitted_objects,omitempty"`
+
 	// Additional fields
 	Annotations map[string]interface{} `json:"annotations,omitempty"`
 	Extra       map[string]interface{} `json:"extra,omitempty"`
@@ -47,6 +66,10 @@ type AuditLogEntry struct {
 	RawLine     string    `json:"raw_line,omitempty"`
 	ParseErrors []string  `json:"parse_errors,omitempty"`
 	ParseTime   time.Time `json:"parse_time,omitempty"`
+	// Truncated is set when the line was longer than the configured
+	// maximum: RawLine holds only its start and LineLength its length
+	Truncated  bool `json:"truncated,omitempty"`
+	LineLength int  `json:"line_length,omitempty"`
 }
 
 // ParseResult represents the result of parsing audit logs
@@ -58,6 +81,12 @@ type ParseResult struct {
 	ParseErrors []string         `json:"parse_errors"`
 	ParseTime   time.Duration    `json:"parse_time"`
 	Performance ParsePerformance `json:"performance"`
+	// TruncatedLines counts the parsed lines longer than the configured
+	// maximum, whose entries keep a truncated raw line
+	TruncatedLines int `json:"truncated_lines"`
+	// RejectedLines are the first rejected lines, up to the configured
+	// QuarantineLines
+	RejectedLines []RejectedLine `json:"rejected_lines,omitempty"`
 }
 
 // ParsePerformance tracks parsing performance metrics
@@ -69,13 +98,51 @@ type ParsePerformance struct {
 
 // ParserConfig holds configuration for the parser
 type ParserConfig struct {
+	// MaxLineLength is the longest line kept whole. Longer lines, such as
+	// events with large request bodies, are parsed as JSON only and their
+	// entries keep the first MaxLineLength bytes as a truncated raw line.
 	MaxLineLength    int           `json:"max_line_length"`
 	MaxParseErrors   int           `json:"max_parse_errors"`
 	Timeout          time.Duration `json:"timeout"`
 	EnableValidation bool          `json:"enable_validation"`
 	EnableMetrics    bool          `json:"enable_metrics"`
+	IncludeObjects   bool          `json:"include_objects"`
+	MaxObjectSize    int           `json:"max_object_size"`
+	// DisableFallback rejects lines that are not JSON instead of parsing
+	// them as structured text
+	DisableFallback bool `json:"disable_fallback"`
+	// Strict rejects entries that fail validation instead of keeping them
+	// with their validation errors
+	Strict bool `json:"strict"`
+	// QuarantineLines keeps up to this many rejected lines, redacted, in
+	// the result's RejectedLines; 0 keeps none
+	QuarantineLines int `json:"quarantine_lines"`
+}
+
+// ValidateParserConfig checks that a parser configuration's limits are usable
+func ValidateParserConfig(config ParserConfig) error {
+	if config.MaxLineLength <= 0 {
+		return fmt.Errorf("max_line_length must be positive, got %d", config.MaxLineLength)
+	}
+	if config.MaxParseErrors < 0 {
+		return fmt.Errorf("max_parse_errors must not be negative, got %d", config.MaxParseErrors)
+	}
+	if config.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %s", config.Timeout)
+	}
+	if config.MaxObjectSize < 0 {
+		return fmt.Errorf("max_object_size must not be negative, got %d", config.MaxObjectSize)
+	}
+	if config.QuarantineLines < 0 {
+		return fmt.Errorf("quarantine_lines must not be negative, got %d", config.QuarantineLines)
+	}
+	return nil
 }
 
+// DefaultMaxObjectSize caps the serialized size of captured request and
+// response objects
+const DefaultMaxObjectSize = 64 * 1024 // 64KB
+
 // DefaultParserConfig returns the default parser configuration
 func DefaultParserConfig() ParserConfig {
 	return ParserConfig{
@@ -84,6 +151,8 @@ func DefaultParserConfig() ParserConfig {
 		Timeout:          30 * time.Second,
 		EnableValidation: true,
 		EnableMetrics:    true,
+		IncludeObjects:   false,
+		MaxObjectSize:    DefaultMaxObjectSize,
 	}
 }
 
@@ -107,27 +176,31 @@ func ParseAuditLogs(lines []string, config ParserConfig) ParseResult {
 			break
 		}
 
-		// Check line length
+		// Parse individual line, truncating lines over the maximum length
+		var entry AuditLogEntry
+		var err error
 		if len(line) > config.MaxLineLength {
-			result.ErrorLines++
-			errorCount++
-			result.ParseErrors = append(result.ParseErrors, fmt.Sprintf("Line %d: exceeds max length (%d > %d)", i+1, len(line), config.MaxLineLength))
-			continue
+			entry, err = parseLongLine(line, config)
+		} else {
+			entry, err = ParseAuditLogLine(line, config)
 		}
-
-		// Parse individual line
-		entry, err := ParseAuditLogLine(line, config)
 		if err != nil {
 			result.ErrorLines++
 			errorCount++
 			if errorCount <= config.MaxParseErrors {
 				result.ParseErrors = append(result.ParseErrors, fmt.Sprintf("Line %d: %v", i+1, err))
 			}
+			if len(result.RejectedLines) < config.QuarantineLines {
+				result.RejectedLines = append(result.RejectedLines, newRejectedLine(i+1, line, err))
+			}
 			continue
 		}
 
 		result.Entries = append(result.Entries, entry)
 		result.ParsedLines++
+		if entry.Truncated {
+			result.TruncatedLines++
+		}
 		totalLineSize += len(line)
 	}
 
@@ -151,39 +224,72 @@ func ParseAuditLogLine(line string, config ParserConfig) (AuditLogEntry, error)
 	}
 
 	// Try JSON parsing first
-	jsonErr := parseJSONLine(line, &entry)
+	jsonErr := parseJSONLine(line, &entry, config)
 	if jsonErr == nil {
-		// Validate entry if enabled
-		if config.EnableValidation {
-			if err := validateEntry(&entry); err != nil {
-				entry.ParseErrors = append(entry.ParseErrors, err.Error())
-			}
-		}
-		return entry, nil
+		NormalizeEntry(&entry)
+		return entry, checkEntry(&entry, config)
 	}
 
 	// For truly malformed JSON, return error instead of falling back to regex
 	if strings.Contains(line, "{") && strings.Contains(line, "}") {
 		return entry, fmt.Errorf("malformed JSON: %v", jsonErr)
 	}
+	if config.DisableFallback {
+		return entry, fmt.Errorf("not a JSON audit event: %v", jsonErr)
+	}
 
 	// Fallback to structured parsing for non-JSON lines
 	if err := parseStructuredLine(line, &entry); err != nil {
 		return entry, fmt.Errorf("failed to parse line: %v", err)
 	}
 
-	// Validate entry if enabled
-	if config.EnableValidation {
-		if err := validateEntry(&entry); err != nil {
-			entry.ParseErrors = append(entry.ParseErrors, err.Error())
-		}
-	}
+	NormalizeEntry(&entry)
+	return entry, checkEntry(&entry, config)
+}
 
+// parseLongLine parses a line longer than the configured maximum length. Only
+// JSON events are accepted, as the structured text fallback is not meant for
+// request bodies, and the entry keeps the first MaxLineLength bytes of the
+// line, flagged as truncated.
+func parseLongLine(line string, config ParserConfig) (AuditLogEntry, error) {
+	jsonOnly := config
+	jsonOnly.DisableFallback = true
+	entry, err := ParseAuditLogLine(line, jsonOnly)
+	if err != nil {
+		return entry, fmt.Errorf("exceeds max length (%d > %d): %v", len(line), config.MaxLineLength, err)
+	}
+	entry.RawLine = truncateUTF8(line, config.MaxLineLength)
+	entry.Truncated = true
+	entry.LineLength = len(line)
 	return entry, nil
 }
 
+// truncateUTF8 returns a copy of the first n bytes of s, dropping a rune cut
+// in half, so the rest of a long line can be released
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.Clone(strings.ToValidUTF8(s[:n], ""))
+}
+
+// checkEntry validates a parsed entry when validation is enabled, recording
+// the problems on the entry, or returning them when the parser is strict
+func checkEntry(entry *AuditLogEntry, config ParserConfig) error {
+	if !config.EnableValidation && !config.Strict {
+		return nil
+	}
+	if err := validateEntry(entry); err != nil {
+		if config.Strict {
+			return fmt.Errorf("invalid entry: %v", err)
+		}
+		entry.ParseErrors = append(entry.ParseErrors, err.Error())
+	}
+	return nil
+}
+
 // parseJSONLine attempts to parse the line as JSON
-func parseJSONLine(line string, entry *AuditLogEntry) error {
+func parseJSONLine(line string, entry *AuditLogEntry, config ParserConfig) error {
 	var rawData map[string]interface{}
 	if err := json.Unmarshal([]byte(line), &rawData); err != nil {
 		return fmt.Errorf("JSON unmarshal failed: %v", err)
@@ -194,6 +300,9 @@ func parseJSONLine(line string, entry *AuditLogEntry) error {
 		entry.Timestamp = timestamp
 	}
 
+	// Extract event metadata
+	extractEventMetadata(rawData, entry)
+
 	// Extract user information
 	if userData, ok := rawData[utils.AuditLogFields["User"]].(map[string]interface{}); ok {
 		if username, ok := userData[utils.AuditLogFields["Username"]].(string); ok {
@@ -237,6 +346,9 @@ func parseJSONLine(line string, entry *AuditLogEntry) error {
 		if apiVersion, ok := objRef[utils.AuditLogFields["APIVersion"]].(string); ok {
 			entry.APIVersion = apiVersion
 		}
+		if subresource, ok := objRef[utils.AuditLogFields["Subresource"]].(string); ok {
+			entry.Subresource = subresource
+		}
 	}
 
 	// Extract response status
@@ -278,17 +390,88 @@ func parseJSONLine(line string, entry *AuditLogEntry) error {
 	}
 
 	// Extract authentication decisions
-	if authDecision, ok := rawData[utils.AuditLogFields["AuthenticationDecision"]].(string); ok {
-		entry.AuthDecision = authDecision
+	extractDecisions(rawData, entry)
+	entry.ImpersonatedUser = impersonatedUsername(rawData[utils.AuditLogFields["ImpersonatedUser"]])
+
+	// Extract request and response bodies
+	if config.IncludeObjects {
+		extractAuditObjects(rawData, entry, config.MaxObjectSize)
+	}
+
+	return nil
+}
+
+// extractEventMetadata extracts the audit ID, stage, level and stage timestamp
+func extractEventMetadata(rawData map[string]interface{}, entry *AuditLogEntry) {
+	if auditID, ok := rawData[utils.AuditLogFields["AuditID"]].(string); ok {
+		entry.AuditID = auditID
 	}
-	if authzDecision, ok := rawData[utils.AuditLogFields["AuthorizationDecision"]].(string); ok {
-		entry.AuthzDecision = authzDecision
+	if stage, ok := rawData[utils.AuditLogFields["Stage"]].(string); ok {
+		entry.Stage = stage
 	}
-	if impersonatedUser, ok := rawData[utils.AuditLogFields["ImpersonatedUser"]].(string); ok {
-		entry.ImpersonatedUser = impersonatedUser
+	if level, ok := rawData[utils.AuditLogFields["Level"]].(string); ok {
+		entry.Level = level
 	}
+	if stageTimestamp, ok := rawData[utils.AuditLogFields["StageTimestamp"]].(string); ok {
+		entry.StageTimestamp = stageTimestamp
+	}
+}
 
-	return nil
+// extractDecisions extracts the authentication and authorization decisions.
+// The API server records them as annotations; top-level keys are still
+// accepted for pre-flattened logs.
+func extractDecisions(rawData map[string]interface{}, entry *AuditLogEntry) {
+	lookup := func(key string) string {
+		if annotations, ok := rawData[utils.AuditLogFields["Annotations"]].(map[string]interface{}); ok {
+			if value, ok := annotations[key].(string); ok && value != "" {
+				return value
+			}
+		}
+		if value, ok := rawData[key].(string); ok {
+			return value
+		}
+		return ""
+	}
+
+	entry.AuthDecision = lookup(utils.AuditLogFields["AuthenticationDecision"])
+	entry.AuthzDecision = lookup(utils.AuditLogFields["AuthorizationDecision"])
+	entry.AuthzReason = lookup(utils.AuditLogFields["AuthorizationReason"])
+}
+
+// impersonatedUsername returns the impersonated username, which the API
+// server records as a user info object
+func impersonatedUsername(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if username, ok := v[utils.AuditLogFields["Username"]].(string); ok {
+			return username
+		}
+	}
+	return ""
+}
+
+// extractAuditObjects captures the request and response objects, dropping
+// any whose serialized size exceeds maxSize
+func extractAuditObjects(rawData map[string]interface{}, entry *AuditLogEntry, maxSize int) {
+	capture := func(field string) map[string]interface{} {
+		object, ok := rawData[field].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if maxSize > 0 {
+			encoded, err := json.Marshal(object)
+			if err != nil || len(encoded) > maxSize {
+				entry.OmittedObjects = append(entry.OmittedObjects, field)
+				return nil
+			}
+		}
+		return object
+	}
+
+	entry.RequestObject = capture(utils.AuditLogFields["RequestObject"])
+	entry.ResponseObject = capture(utils.AuditLogFields["ResponseObject"])
 }
 
 // parseStructuredLine parses non-JSON lines using regex patterns (fallback method)
@@ -317,6 +500,12 @@ func parseStructuredLine(line string, entry *AuditLogEntry) error {
 		entry.Resource = match[1]
 	}
 
+	// Extract subresource using the field constant
+	subresourceRegex := regexp.MustCompile(fmt.Sprintf(`"%s":"([^"]+)"`, utils.AuditLogFields["Subresource"]))
+	if match := subresourceRegex.FindStringSubmatch(line); len(match) > 1 {
+		entry.Subresource = match[1]
+	}
+
 	// Extract namespace using the field constant
 	namespaceRegex := regexp.MustCompile(fmt.Sprintf(`"%s":"([^"]+)"`, utils.AuditLogFields["Namespace"]))
 	if match := namespaceRegex.FindStringSubmatch(line); len(match) > 1 {
@@ -386,6 +575,11 @@ func validateEntry(entry *AuditLogEntry) error {
 			errors = append(errors, fmt.Sprintf("invalid timestamp format: %s", entry.Timestamp))
 		}
 	}
+	if entry.StageTimestamp != "" {
+		if _, err := time.Parse(time.RFC3339, entry.StageTimestamp); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid stage timestamp format: %s", entry.StageTimestamp))
+		}
+	}
 
 	// Validate status code range
 	if entry.StatusCode != 0 && (entry.StatusCode < 100 || entry.StatusCode > 599) {
@@ -404,82 +598,13 @@ func validateEntry(entry *AuditLogEntry) error {
 	return nil
 }
 
-// GenerateSummary creates a human-readable summary of the results
+// GenerateSummary creates a human-readable summary of the results with the
+// built-in summary templates
 func GenerateSummary(entries []AuditLogEntry, context map[string]interface{}) string {
-	if len(entries) == 0 {
-		return "No audit entries found matching the criteria."
-	}
-
-	summary := fmt.Sprintf("Found %d audit entries", len(entries))
-
-	// Count by username
-	userCounts := make(map[string]int)
-	for _, entry := range entries {
-		if entry.Username != "" {
-			userCounts[entry.Username]++
-		}
-	}
-
-	if len(userCounts) > 0 {
-		summary += ". Users involved: "
-		userList := make([]string, 0, len(userCounts))
-		for user, count := range userCounts {
-			userList = append(userList, fmt.Sprintf("%s (%d)", user, count))
-		}
-		summary += strings.Join(userList, ", ")
+	summary, err := defaultSummaryTemplates.Generate(entries, context)
+	if err != nil {
+		return fmt.Sprintf("Found %d audit entries", len(entries))
 	}
-
-	// Count by status code
-	statusCounts := make(map[int]int)
-	for _, entry := range entries {
-		if entry.StatusCode != 0 {
-			statusCounts[entry.StatusCode]++
-		}
-	}
-
-	if len(statusCounts) > 0 {
-		summary += ". Status codes: "
-		statusList := make([]string, 0, len(statusCounts))
-		for status, count := range statusCounts {
-			statusList = append(statusList, fmt.Sprintf("%d (%d)", status, count))
-		}
-		summary += strings.Join(statusList, ", ")
-	}
-
-	// Count by verb
-	verbCounts := make(map[string]int)
-	for _, entry := range entries {
-		if entry.Verb != "" {
-			verbCounts[entry.Verb]++
-		}
-	}
-
-	if len(verbCounts) > 0 {
-		summary += ". Actions: "
-		verbList := make([]string, 0, len(verbCounts))
-		for verb, count := range verbCounts {
-			verbList = append(verbList, fmt.Sprintf("%s (%d)", verb, count))
-		}
-		summary += strings.Join(verbList, ", ")
-	}
-
-	// Count by resource
-	resourceCounts := make(map[string]int)
-	for _, entry := range entries {
-		if entry.Resource != "" {
-			resourceCounts[entry.Resource]++
-		}
-	}
-
-	if len(resourceCounts) > 0 {
-		summary += ". Resources: "
-		resourceList := make([]string, 0, len(resourceCounts))
-		for resource, count := range resourceCounts {
-			resourceList = append(resourceList, fmt.Sprintf("%s (%d)", resource, count))
-		}
-		summary += strings.Join(resourceList, ", ")
-	}
-
 	return summary
 }
 
@@ -586,6 +711,9 @@ func ConvertLegacyEntries(legacyEntries []map[string]interface{}) []AuditLogEntr
 		if resource, ok := legacy["resource"].(string); ok {
 			entry.Resource = resource
 		}
+		if subresource, ok := legacy["subresource"].(string); ok {
+			entry.Subresource = subresource
+		}
 		if namespace, ok := legacy["namespace"].(string); ok {
 			entry.Namespace = namespace
 		}
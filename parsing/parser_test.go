@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestParseAuditLogLine(t *testing.T) {
@@ -551,6 +552,203 @@ func TestParserConfig(t *testing.T) {
 	if !config.EnableMetrics {
 		t.Errorf("Expected EnableMetrics true, got %v", config.EnableMetrics)
 	}
+	if config.IncludeObjects {
+		t.Errorf("Expected IncludeObjects false, got %v", config.IncludeObjects)
+	}
+	if config.MaxObjectSize != DefaultMaxObjectSize {
+		t.Errorf("Expected MaxObjectSize %d, got %d", DefaultMaxObjectSize, config.MaxObjectSize)
+	}
+	if config.DisableFallback || config.Strict {
+		t.Errorf("Expected fallback on and strict off, got %+v", config)
+	}
+	if err := ValidateParserConfig(config); err != nil {
+		t.Errorf("Expected the default configuration to be valid, got %v", err)
+	}
+
+	invalid := config
+	invalid.MaxLineLength = 0
+	if err := ValidateParserConfig(invalid); err == nil {
+		t.Error("Expected an error for a zero max line length")
+	}
+	invalid = config
+	invalid.MaxParseErrors = -1
+	if err := ValidateParserConfig(invalid); err == nil {
+		t.Error("Expected an error for negative max parse errors")
+	}
+}
+
+func TestParseAuditLogLine_FallbackAndStrict(t *testing.T) {
+	textLine := `"username":"admin" "verb":"get" "resource":"pods"`
+	userless := `{"requestReceivedTimestamp":"2024-01-15T10:30:00Z","verb":"get"}`
+
+	config := DefaultParserConfig()
+	entry, err := ParseAuditLogLine(textLine, config)
+	if err != nil || entry.Username != "admin" {
+		t.Errorf("Expected the text line to be parsed by the fallback, got %+v, %v", entry, err)
+	}
+	entry, err = ParseAuditLogLine(userless, config)
+	if err != nil || len(entry.ParseErrors) != 1 {
+		t.Errorf("Expected the userless entry kept with a validation error, got %v, %v", entry.ParseErrors, err)
+	}
+
+	config.DisableFallback = true
+	if _, err := ParseAuditLogLine(textLine, config); err == nil || !strings.Contains(err.Error(), "not a JSON audit event") {
+		t.Errorf("Expected the text line to be rejected without fallback, got %v", err)
+	}
+
+	config = DefaultParserConfig()
+	config.Strict = true
+	if _, err := ParseAuditLogLine(userless, config); err == nil || !strings.Contains(err.Error(), "missing user identification") {
+		t.Errorf("Expected the strict parser to reject the userless entry, got %v", err)
+	}
+	result := ParseAuditLogs([]string{userless, textLine}, config)
+	if result.ParsedLines != 1 || result.ErrorLines != 1 {
+		t.Errorf("Expected 1 parsed and 1 error line, got %d and %d", result.ParsedLines, result.ErrorLines)
+	}
+}
+
+// largeAuditEvent returns a configmap create event whose request body makes
+// the line about size bytes long
+func largeAuditEvent(size int) string {
+	return `{"auditID":"big-1","stage":"ResponseComplete","verb":"create","user":{"username":"admin"},"objectRef":{"resource":"configmaps","namespace":"default","name":"bundle"},"responseStatus":{"code":201},"requestReceivedTimestamp":"2024-01-15T10:30:00Z","requestObject":{"kind":"ConfigMap","data":{"bundle":"` +
+		strings.Repeat("x", size) + `"}}}`
+}
+
+func TestParseAuditLogs_LongLines(t *testing.T) {
+	large := largeAuditEvent(5 << 20)
+	lines := []string{
+		large,
+		`{"requestReceivedTimestamp":"2024-01-15T10:31:00Z","user":{"username":"dev"},"verb":"get"}`,
+		strings.Repeat("a", 5<<20),
+	}
+
+	config := DefaultParserConfig()
+	config.IncludeObjects = true
+	result := ParseAuditLogs(lines, config)
+	if result.ParsedLines != 2 || result.ErrorLines != 1 || result.TruncatedLines != 1 {
+		t.Fatalf("Expected 2 parsed, 1 error and 1 truncated line, got %d, %d and %d", result.ParsedLines, result.ErrorLines, result.TruncatedLines)
+	}
+	if !strings.Contains(result.ParseErrors[0], "Line 3: exceeds max length") || !strings.Contains(result.ParseErrors[0], "not a JSON audit event") {
+		t.Errorf("Expected the long text line rejected, got %q", result.ParseErrors[0])
+	}
+
+	entry := result.Entries[0]
+	if entry.Username != "admin" || entry.Verb != "create" || entry.Name != "bundle" || entry.StatusCode != 201 {
+		t.Errorf("Expected the fields of the long event, got %+v", entry)
+	}
+	if !entry.Truncated || entry.LineLength != len(large) || len(entry.RawLine) != config.MaxLineLength || !strings.HasPrefix(large, entry.RawLine) {
+		t.Errorf("Expected the raw line truncated to %d bytes, got truncated %v, length %d, %d bytes", config.MaxLineLength, entry.Truncated, entry.LineLength, len(entry.RawLine))
+	}
+	if entry.RequestObject != nil || len(entry.OmittedObjects) != 1 {
+		t.Errorf("Expected the request body over the object cap omitted, got %v", entry.OmittedObjects)
+	}
+	if result.Entries[1].Truncated {
+		t.Error("Expected the short line kept whole")
+	}
+
+	// The cap is configurable
+	config.MaxLineLength = len(large)
+	result = ParseAuditLogs(lines[:1], config)
+	if result.TruncatedLines != 0 || result.Entries[0].RawLine != large {
+		t.Errorf("Expected the event kept whole under a raised cap, got %d truncated lines", result.TruncatedLines)
+	}
+
+	// Truncation does not split a multi-byte rune
+	config.MaxLineLength = 10
+	entry, err := parseLongLine(`{"user":{"username":"é"},"verb":"get"}`, config)
+	if err != nil || entry.RawLine != `{"user":{"` {
+		t.Errorf("Expected a 10 byte prefix, got %q, %v", entry.RawLine, err)
+	}
+	config.MaxLineLength = 22
+	if entry, _ := parseLongLine(`{"user":{"username":"é"},"verb":"get"}`, config); entry.RawLine != `{"user":{"username":"` || !utf8.ValidString(entry.RawLine) {
+		t.Errorf("Expected the cut rune dropped, got %q", entry.RawLine)
+	}
+}
+
+func TestParseAuditLogLine_FullSchema(t *testing.T) {
+	line := `{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"RequestResponse","auditID":"7e0a9b0c-1f2d-4c3b-9a8e-2f6d5c4b3a21","stage":"ResponseComplete","requestURI":"/apis/apps/v1/namespaces/prod/deployments/web/scale","verb":"update","user":{"username":"dev"},"impersonatedUser":{"username":"system:admin","groups":["system:masters"]},"objectRef":{"resource":"deployments","namespace":"prod","name":"web","apiGroup":"apps","apiVersion":"v1","subresource":"scale"},"responseStatus":{"code":403,"reason":"Forbidden"},"requestObject":{"kind":"Scale","spec":{"replicas":5}},"responseObject":{"kind":"Status","status":"Failure"},"requestReceivedTimestamp":"2024-01-15T10:30:00.000000Z","stageTimestamp":"2024-01-15T10:30:00.012345Z","annotations":{"authorization.k8s.io/decision":"forbid","authorization.k8s.io/reason":"RBAC: access denied"}}`
+
+	t.Run("metadata and annotations", func(t *testing.T) {
+		entry, err := ParseAuditLogLine(line, DefaultParserConfig())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		checks := map[string][2]string{
+			"AuditID":          {entry.AuditID, "7e0a9b0c-1f2d-4c3b-9a8e-2f6d5c4b3a21"},
+			"Stage":            {entry.Stage, "ResponseComplete"},
+			"Level":            {entry.Level, "RequestResponse"},
+			"StageTimestamp":   {entry.StageTimestamp, "2024-01-15T10:30:00.012345Z"},
+			"APIGroup":         {entry.APIGroup, "apps"},
+			"APIVersion":       {entry.APIVersion, "v1"},
+			"Subresource":      {entry.Subresource, "scale"},
+			"AuthzDecision":    {entry.AuthzDecision, "forbid"},
+			"AuthzReason":      {entry.AuthzReason, "RBAC: access denied"},
+			"ImpersonatedUser": {entry.ImpersonatedUser, "system:admin"},
+		}
+		for field, check := range checks {
+			if check[0] != check[1] {
+				t.Errorf("Expected %s '%s', got '%s'", field, check[1], check[0])
+			}
+		}
+
+		if entry.RequestObject != nil || entry.ResponseObject != nil {
+			t.Errorf("Expected objects to be skipped by default, got %v / %v", entry.RequestObject, entry.ResponseObject)
+		}
+	})
+
+	t.Run("objects included", func(t *testing.T) {
+		config := DefaultParserConfig()
+		config.IncludeObjects = true
+
+		entry, err := ParseAuditLogLine(line, config)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if entry.RequestObject["kind"] != "Scale" {
+			t.Errorf("Expected request object kind 'Scale', got %v", entry.RequestObject["kind"])
+		}
+		if entry.ResponseObject["status"] != "Failure" {
+			t.Errorf("Expected response object status 'Failure', got %v", entry.ResponseObject["status"])
+		}
+		if len(entry.OmittedObjects) != 0 {
+			t.Errorf("Expected no omitted objects, got %v", entry.OmittedObjects)
+		}
+	})
+
+	t.Run("objects over size cap", func(t *testing.T) {
+		config := DefaultParserConfig()
+		config.IncludeObjects = true
+		config.MaxObjectSize = 36
+
+		entry, err := ParseAuditLogLine(line, config)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if entry.RequestObject != nil {
+			t.Errorf("Expected oversized request object to be dropped, got %v", entry.RequestObject)
+		}
+		if entry.ResponseObject == nil {
+			t.Error("Expected response object under the cap to be kept")
+		}
+		if len(entry.OmittedObjects) != 1 || entry.OmittedObjects[0] != "requestObject" {
+			t.Errorf("Expected omitted objects [requestObject], got %v", entry.OmittedObjects)
+		}
+	})
+
+	t.Run("flattened decision keys", func(t *testing.T) {
+		flat := `{"verb":"get","user":{"username":"dev"},"authorization.k8s.io/decision":"allow","impersonatedUser":"bot"}`
+		entry, err := ParseAuditLogLine(flat, DefaultParserConfig())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if entry.AuthzDecision != "allow" {
+			t.Errorf("Expected authz decision 'allow', got '%s'", entry.AuthzDecision)
+		}
+		if entry.ImpersonatedUser != "bot" {
+			t.Errorf("Expected impersonated user 'bot', got '%s'", entry.ImpersonatedUser)
+		}
+	})
 }
 
 func TestJSONMarshalUnmarshal(t *testing.T) {
@@ -0,0 +1,147 @@
+package parsing
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// PodExecSubresources are the pod subresources that open a session into a
+// running container
+var PodExecSubresources = []string{"exec", "attach", "portforward"}
+
+// isPodExec reports whether an entry opens a session into a pod
+func isPodExec(entry AuditLogEntry) bool {
+	if entry.Resource != "pods" {
+		return false
+	}
+	for _, subresource := range PodExecSubresources {
+		if entry.Subresource == subresource {
+			return true
+		}
+	}
+	return false
+}
+
+// podExecSession converts an entry to a session, reading the command,
+// container, ports and terminal flags from the query of its request URI
+func podExecSession(entry AuditLogEntry) types.PodExecSession {
+	session := types.PodExecSession{
+		Timestamp:   entry.Timestamp,
+		Username:    entry.Username,
+		Namespace:   entry.Namespace,
+		Pod:         entry.Name,
+		Subresource: entry.Subresource,
+		StatusCode:  entry.StatusCode,
+		Failed:      entry.StatusCode >= 400,
+		SourceIPs:   entry.SourceIPs,
+		UserAgent:   entry.UserAgent,
+		AuditID:     entry.AuditID,
+	}
+	if parsed, err := url.Parse(entry.RequestURI); err == nil {
+		query := parsed.Query()
+		session.Container = query.Get("container")
+		session.Command = strings.Join(query["command"], " ")
+		session.Ports = query["ports"]
+		session.Interactive = query.Get("tty") == "true" || query.Get("tty") == "1"
+	}
+	return session
+}
+
+// AnalyzePodExec summarizes the exec, attach and port-forward requests of
+// entries by user, with each request as a session in time order. Sessions are
+// long-running requests, logged when the response starts and again when it
+// completes; the events of one request count once, with the status of the
+// last stage. Requests answered with an error opened no session and are
+// counted as failed. Entries on other resources and subresources are ignored.
+func AnalyzePodExec(entries []AuditLogEntry) types.PodExecReport {
+	report := types.PodExecReport{Users: []types.PodExecUser{}, Sessions: []types.PodExecSession{}}
+	requests := make(map[string]int)
+	for _, entry := range entries {
+		if !isPodExec(entry) {
+			continue
+		}
+		if i, ok := requests[entry.AuditID]; ok && entry.AuditID != "" {
+			if entry.Stage == "ResponseComplete" {
+				report.Sessions[i] = podExecSession(entry)
+			}
+			continue
+		}
+		requests[entry.AuditID] = len(report.Sessions)
+		report.Sessions = append(report.Sessions, podExecSession(entry))
+	}
+	// Audit timestamps are UTC with a fixed precision, so they sort as strings
+	sort.SliceStable(report.Sessions, func(i, j int) bool { return report.Sessions[i].Timestamp < report.Sessions[j].Timestamp })
+
+	users := make(map[string]*types.PodExecUser)
+	userPods := make(map[string]map[string]bool)
+	pods := make(map[string]bool)
+	var order []string
+	for _, session := range report.Sessions {
+		user, ok := users[session.Username]
+		if !ok {
+			user = &types.PodExecUser{Username: session.Username, Subresources: make(map[string]int), Pods: []string{}}
+			users[session.Username] = user
+			userPods[session.Username] = make(map[string]bool)
+			order = append(order, session.Username)
+		}
+		if session.Failed {
+			user.Failed++
+			report.TotalFailed++
+		} else {
+			user.Sessions++
+			report.TotalSessions++
+			user.Subresources[session.Subresource]++
+			pod := session.Namespace + "/" + session.Pod
+			pods[pod] = true
+			if !userPods[session.Username][pod] {
+				userPods[session.Username][pod] = true
+				user.Pods = append(user.Pods, pod)
+			}
+		}
+		if user.FirstSeen == "" {
+			user.FirstSeen = session.Timestamp
+		}
+		user.LastSeen = session.Timestamp
+	}
+	report.Pods = len(pods)
+
+	for _, username := range order {
+		sort.Strings(users[username].Pods)
+		report.Users = append(report.Users, *users[username])
+	}
+	sort.SliceStable(report.Users, func(i, j int) bool {
+		a, b := report.Users[i], report.Users[j]
+		if a.Sessions != b.Sessions {
+			return a.Sessions > b.Sessions
+		}
+		return a.Username < b.Username
+	})
+
+	report.Summary = summarizePodExec(report)
+	return report
+}
+
+// summarizePodExec produces a short human-readable description of a report
+func summarizePodExec(report types.PodExecReport) string {
+	summary := fmt.Sprintf("%d exec, attach and port-forward sessions into %d pods", report.TotalSessions, report.Pods)
+	if report.TotalFailed > 0 {
+		summary += fmt.Sprintf(", %d failed", report.TotalFailed)
+	}
+	if len(report.Users) == 0 {
+		return summary
+	}
+
+	var users []string
+	for i, user := range report.Users {
+		if i == 5 {
+			users = append(users, fmt.Sprintf("and %d more", len(report.Users)-i))
+			break
+		}
+		users = append(users, fmt.Sprintf("%s (%d)", user.Username, user.Sessions))
+	}
+	return summary + "; by " + strings.Join(users, ", ")
+}
@@ -0,0 +1,72 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAnalyzePodExec tests summarizing exec, attach and port-forward sessions by user
+func TestAnalyzePodExec(t *testing.T) {
+	session := func(username, subresource, pod, requestURI, timestamp string, status int) AuditLogEntry {
+		return AuditLogEntry{
+			Timestamp:   timestamp,
+			Username:    username,
+			Verb:        "create",
+			Resource:    "pods",
+			Subresource: subresource,
+			Namespace:   "payments",
+			Name:        pod,
+			RequestURI:  requestURI,
+			StatusCode:  status,
+		}
+	}
+	entries := []AuditLogEntry{
+		session("alice", "exec", "api-1", "/api/v1/namespaces/payments/pods/api-1/exec?command=sh&command=-c&command=id&container=api&stdin=true&tty=true", "2026-03-01T12:05:00.000000Z", 101),
+		session("alice", "portforward", "db-0", "/api/v1/namespaces/payments/pods/db-0/portforward?ports=5432", "2026-03-01T12:01:00.000000Z", 101),
+		session("alice", "exec", "api-1", "/api/v1/namespaces/payments/pods/api-1/exec?command=env", "2026-03-01T12:10:00.000000Z", 101),
+		session("bob", "attach", "api-1", "/api/v1/namespaces/payments/pods/api-1/attach?container=api", "2026-03-01T12:03:00.000000Z", 403),
+		session("bob", "log", "api-1", "/api/v1/namespaces/payments/pods/api-1/log", "2026-03-01T12:04:00.000000Z", 200),
+		session("bob", "", "api-1", "/api/v1/namespaces/payments/pods/api-1", "2026-03-01T12:04:00.000000Z", 200),
+	}
+	// The same request logged when the response started and completed counts once
+	started := entries[0]
+	completed := started
+	started.AuditID, started.Stage, started.StatusCode = "exec-1", "ResponseStarted", 0
+	completed.AuditID, completed.Stage = "exec-1", "ResponseComplete"
+	entries[0] = started
+	entries = append(entries, completed)
+
+	report := AnalyzePodExec(entries)
+	if report.TotalSessions != 3 || report.TotalFailed != 1 || report.Pods != 2 || len(report.Sessions) != 4 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+
+	// Sessions are in time order with the request URI's details
+	first := report.Sessions[0]
+	if first.Subresource != "portforward" || len(first.Ports) != 1 || first.Ports[0] != "5432" {
+		t.Errorf("Unexpected first session: %+v", first)
+	}
+	shell := report.Sessions[2]
+	if shell.Command != "sh -c id" || shell.Container != "api" || !shell.Interactive || shell.StatusCode != 101 {
+		t.Errorf("Unexpected exec session: %+v", shell)
+	}
+	if !report.Sessions[1].Failed || report.Sessions[1].Username != "bob" {
+		t.Errorf("Expected bob's attach to fail: %+v", report.Sessions[1])
+	}
+
+	alice := report.Users[0]
+	if alice.Username != "alice" || alice.Sessions != 3 || alice.Subresources["exec"] != 2 || strings.Join(alice.Pods, ",") != "payments/api-1,payments/db-0" {
+		t.Errorf("Unexpected user: %+v", alice)
+	}
+	if alice.FirstSeen != "2026-03-01T12:01:00.000000Z" || alice.LastSeen != "2026-03-01T12:10:00.000000Z" {
+		t.Errorf("Unexpected first and last seen: %s, %s", alice.FirstSeen, alice.LastSeen)
+	}
+	bob := report.Users[1]
+	if bob.Sessions != 0 || bob.Failed != 1 || len(bob.Pods) != 0 {
+		t.Errorf("Unexpected user: %+v", bob)
+	}
+
+	if report.Summary != "3 exec, attach and port-forward sessions into 2 pods, 1 failed; by alice (3), bob (0)" {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+}
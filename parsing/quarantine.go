@@ -0,0 +1,57 @@
+package parsing
+
+import "regexp"
+
+// MaxQuarantinedLineBytes caps the length of a rejected line kept for
+// diagnosis, after redaction
+const MaxQuarantinedLineBytes = 4096
+
+// Redacted replaces sensitive values in rejected lines
+const Redacted = "[REDACTED]"
+
+// RejectedLine is a line the parser rejected, redacted and truncated, kept so
+// malformed or truncated log formats can be diagnosed
+type RejectedLine struct {
+	LineNumber int    `json:"line_number"`
+	Error      string `json:"error"`
+	Line       string `json:"line"`
+	// Length is the length of the line as read, before redaction
+	Length    int  `json:"length"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+var (
+	// sensitiveObjectPattern matches the data of secrets and config maps
+	sensitiveObjectPattern = regexp.MustCompile(`(?i)"(data|stringData|binaryData)"\s*:\s*\{[^{}]*\}?`)
+	// sensitiveFieldPattern matches string fields holding credentials
+	sensitiveFieldPattern = regexp.MustCompile(`(?i)"([\w.-]*(?:token|password|passwd|secret|authorization|api[_-]?key|credential)[\w.-]*)"\s*:\s*"(?:[^"\\]|\\.)*"?`)
+	// bearerPattern matches bearer tokens in headers and messages
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	// jwtPattern matches JSON web tokens, such as service account tokens
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`)
+)
+
+// RedactLine replaces credentials in a raw audit log line, which may be
+// malformed or truncated: the data of secrets and config maps, fields named
+// like tokens, passwords and keys, bearer tokens and JSON web tokens
+func RedactLine(line string) string {
+	line = sensitiveObjectPattern.ReplaceAllString(line, `"$1":"`+Redacted+`"`)
+	line = sensitiveFieldPattern.ReplaceAllString(line, `"$1":"`+Redacted+`"`)
+	line = bearerPattern.ReplaceAllString(line, "${1}"+Redacted)
+	return jwtPattern.ReplaceAllString(line, Redacted)
+}
+
+// newRejectedLine returns the redacted and truncated record of a rejected line
+func newRejectedLine(lineNumber int, line string, err error) RejectedLine {
+	rejected := RejectedLine{
+		LineNumber: lineNumber,
+		Error:      err.Error(),
+		Line:       RedactLine(line),
+		Length:     len(line),
+	}
+	if len(rejected.Line) > MaxQuarantinedLineBytes {
+		rejected.Line = truncateUTF8(rejected.Line, MaxQuarantinedLineBytes)
+		rejected.Truncated = true
+	}
+	return rejected
+}
@@ -0,0 +1,94 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRedactLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		contains []string
+		hidden   []string
+	}{
+		{
+			name:     "secret data",
+			line:     `{"verb":"create","requestObject":{"kind":"Secret","data":{"password":"c2VjcmV0"}},"user":{"username":"admin"}`,
+			contains: []string{`"data":"[REDACTED]"`, `"username":"admin"`},
+			hidden:   []string{"c2VjcmV0"},
+		},
+		{
+			name:     "token fields",
+			line:     `{"spec":{"token":"abc123","clientSecret":"s3cr3t","apiKey":"k-1"},"verb":"get"`,
+			contains: []string{`"token":"[REDACTED]"`, `"clientSecret":"[REDACTED]"`, `"verb":"get"`},
+			hidden:   []string{"abc123", "s3cr3t", "k-1"},
+		},
+		{
+			name:     "truncated token field",
+			line:     `{"verb":"get","password":"hunter`,
+			contains: []string{`"password":"[REDACTED]"`},
+			hidden:   []string{"hunter"},
+		},
+		{
+			name:     "bearer and JWT",
+			line:     `Authorization: Bearer sha256~abcdef and eyJhbGciOi.eyJzdWIiOiJ0ZXN0.c2lnbmF0dXJl`,
+			contains: []string{"Bearer [REDACTED]"},
+			hidden:   []string{"sha256~abcdef", "eyJzdWIiOiJ0ZXN0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted := RedactLine(tt.line)
+			for _, expected := range tt.contains {
+				if !strings.Contains(redacted, expected) {
+					t.Errorf("Expected %q in %q", expected, redacted)
+				}
+			}
+			for _, secret := range tt.hidden {
+				if strings.Contains(redacted, secret) {
+					t.Errorf("Expected %q to be redacted from %q", secret, redacted)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAuditLogs_QuarantineLines(t *testing.T) {
+	long := `{"verb":"get","message":"` + strings.Repeat("é", MaxQuarantinedLineBytes)
+	lines := []string{
+		`{"verb":"get","user":{"username":"admin"},"token":"abc123"`,
+		`{"requestReceivedTimestamp":"2024-01-15T10:30:00Z","user":{"username":"admin"},"verb":"get"}`,
+		long,
+		`{"broken": }`,
+	}
+
+	config := DefaultParserConfig()
+	config.MaxLineLength = len(long) - 1
+	if result := ParseAuditLogs(lines, config); len(result.RejectedLines) != 0 {
+		t.Errorf("Expected no rejected lines without quarantine, got %d", len(result.RejectedLines))
+	}
+
+	config.QuarantineLines = 2
+	result := ParseAuditLogs(lines, config)
+	if result.ErrorLines != 3 {
+		t.Fatalf("Expected 3 error lines, got %d", result.ErrorLines)
+	}
+	if len(result.RejectedLines) != 2 {
+		t.Fatalf("Expected 2 rejected lines, got %d", len(result.RejectedLines))
+	}
+
+	first := result.RejectedLines[0]
+	if first.LineNumber != 1 || !strings.Contains(first.Error, "malformed JSON") || strings.Contains(first.Line, "abc123") {
+		t.Errorf("Expected the first line malformed and redacted, got %+v", first)
+	}
+	second := result.RejectedLines[1]
+	if second.LineNumber != 3 || !second.Truncated || second.Length != len(long) {
+		t.Errorf("Expected the long line truncated, got line %d, truncated %v, length %d", second.LineNumber, second.Truncated, second.Length)
+	}
+	if len(second.Line) > MaxQuarantinedLineBytes || !utf8.ValidString(second.Line) {
+		t.Errorf("Expected at most %d bytes of valid UTF-8, got %d bytes", MaxQuarantinedLineBytes, len(second.Line))
+	}
+}
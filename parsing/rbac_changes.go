@@ -0,0 +1,352 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// RBACResources are the resources whose changes grant or revoke permissions
+var RBACResources = []string{"roles", "clusterroles", "rolebindings", "clusterrolebindings"}
+
+// RBACChangeVerbs are the verbs that change roles and bindings
+var RBACChangeVerbs = []string{"create", "update", "patch", "delete"}
+
+// isRBACChange reports whether an entry changes a role or binding
+func isRBACChange(entry AuditLogEntry) bool {
+	if !rbacResources[entry.Resource] || entry.Subresource != "" || entry.Name == "" {
+		return false
+	}
+	for _, verb := range RBACChangeVerbs {
+		if entry.Verb == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValues returns the strings of a decoded JSON list
+func stringValues(value interface{}) []string {
+	items, _ := value.([]interface{})
+	values := []string{}
+	for _, item := range items {
+		if text, ok := item.(string); ok {
+			values = append(values, text)
+		}
+	}
+	return values
+}
+
+// describeRule formats a policy rule, e.g. "get,list secrets, deployments.apps"
+// or "get /healthz"
+func describeRule(rule map[string]interface{}) string {
+	verbs := strings.Join(stringValues(rule["verbs"]), ",")
+	if urls := stringValues(rule["nonResourceURLs"]); len(urls) > 0 {
+		return verbs + " " + strings.Join(urls, ", ")
+	}
+	var targets []string
+	for _, group := range stringValues(rule["apiGroups"]) {
+		for _, resource := range stringValues(rule["resources"]) {
+			if group != "" {
+				resource += "." + group
+			}
+			targets = append(targets, resource)
+		}
+	}
+	description := verbs + " " + strings.Join(targets, ", ")
+	if names := stringValues(rule["resourceNames"]); len(names) > 0 {
+		description += " named " + strings.Join(names, ", ")
+	}
+	return description
+}
+
+// roleRules returns the described rules of a role, nil for an unknown role
+func roleRules(role map[string]interface{}) map[string]bool {
+	if role == nil {
+		return nil
+	}
+	rules := make(map[string]bool)
+	items, _ := role["rules"].([]interface{})
+	for _, item := range items {
+		if rule, ok := item.(map[string]interface{}); ok {
+			rules[describeRule(rule)] = true
+		}
+	}
+	return rules
+}
+
+// rbacSubject identifies a binding subject; only service accounts are namespaced
+type rbacSubject struct {
+	kind, namespace, name string
+}
+
+// String formats a subject, e.g. "ServiceAccount ci/deployer" or "User alice"
+func (subject rbacSubject) String() string {
+	if subject.namespace != "" {
+		return subject.kind + " " + subject.namespace + "/" + subject.name
+	}
+	return subject.kind + " " + subject.name
+}
+
+// bindingGrants returns the grant a binding makes to each of its subjects,
+// nil for an unknown binding
+func bindingGrants(entry AuditLogEntry, binding map[string]interface{}) map[rbacSubject]types.RBACGrant {
+	if binding == nil {
+		return nil
+	}
+	roleRef, _ := binding["roleRef"].(map[string]interface{})
+	grant := types.RBACGrant{Binding: "clusterrolebinding " + entry.Name}
+	grant.RoleKind, _ = roleRef["kind"].(string)
+	grant.RoleName, _ = roleRef["name"].(string)
+	if entry.Resource == "rolebindings" {
+		grant.Namespace = entry.Namespace
+		grant.Binding = "rolebinding " + entry.Namespace + "/" + entry.Name
+	}
+
+	grants := make(map[rbacSubject]types.RBACGrant)
+	subjects, _ := binding["subjects"].([]interface{})
+	for _, item := range subjects {
+		fields, _ := item.(map[string]interface{})
+		subject := rbacSubject{}
+		subject.kind, _ = fields["kind"].(string)
+		subject.name, _ = fields["name"].(string)
+		if subject.kind == "ServiceAccount" {
+			subject.namespace, _ = fields["namespace"].(string)
+		}
+		grants[subject] = grant
+	}
+	return grants
+}
+
+// rbacChange accumulates the net change of a subject or role: each value
+// counts its grants minus its revocations
+type rbacChange struct {
+	grants    map[types.RBACGrant]int
+	rules     map[string]int
+	changedBy map[string]bool
+	deleted   bool
+}
+
+func newRBACChange() *rbacChange {
+	return &rbacChange{grants: make(map[types.RBACGrant]int), rules: make(map[string]int), changedBy: make(map[string]bool)}
+}
+
+// AnalyzeRBACChanges consolidates the role and binding changes of entries, in
+// time order, into the role bindings each subject gained and lost and the
+// rules each role gained and lost. Effects are read from the bodies of the
+// changes, which needs entries parsed with objects from a RequestResponse
+// audit level: a creation's body is enough, while updates, patches and
+// deletions are compared with the object's previous version in entries and
+// are listed as unresolved without one. Changes of a role's rules affect the
+// subjects bound to it, listed from the bindings seen. Requests answered with
+// an error changed nothing and are only counted as failed.
+func AnalyzeRBACChanges(entries []AuditLogEntry) types.RBACChangeReport {
+	report := types.RBACChangeReport{Subjects: []types.RBACSubjectChange{}, Roles: []types.RBACRoleChange{}, Unresolved: []types.RBACUnresolvedChange{}}
+
+	versions := make(map[string]map[string]interface{})
+	bindings := make(map[string]AuditLogEntry)
+	subjects := make(map[rbacSubject]*rbacChange)
+	roles := make(map[string]*rbacChange)
+	for _, entry := range uniqueRequests(entries, isRBACChange) {
+		if entry.StatusCode >= 400 {
+			report.Failed++
+			continue
+		}
+		report.TotalChanges++
+
+		key := objectKey(entry)
+		previous, object := versions[key], objectAfterChange(entry)
+		if object == nil {
+			delete(versions, key)
+		} else {
+			versions[key] = object
+		}
+		if strings.HasSuffix(entry.Resource, "bindings") {
+			bindings[key] = entry
+		}
+		if (entry.Verb == "create" && object == nil) || (entry.Verb != "create" && previous == nil) || (entry.Verb != "delete" && object == nil) {
+			report.Unresolved = append(report.Unresolved, types.RBACUnresolvedChange{
+				Timestamp: entry.Timestamp,
+				Username:  entry.Username,
+				Verb:      entry.Verb,
+				Resource:  entry.Resource,
+				Namespace: entry.Namespace,
+				Name:      entry.Name,
+				AuditID:   entry.AuditID,
+			})
+			continue
+		}
+
+		if entry.Resource == "roles" || entry.Resource == "clusterroles" {
+			role := roles[key]
+			if role == nil {
+				role = newRBACChange()
+				roles[key] = role
+			}
+			role.changedBy[entry.Username] = true
+			role.deleted = entry.Verb == "delete"
+			before, after := roleRules(previous), roleRules(object)
+			for rule := range before {
+				if !after[rule] {
+					role.rules[rule]--
+				}
+			}
+			for rule := range after {
+				if !before[rule] {
+					role.rules[rule]++
+				}
+			}
+			continue
+		}
+
+		before, after := bindingGrants(entry, previous), bindingGrants(entry, object)
+		change := func(subject rbacSubject) *rbacChange {
+			if subjects[subject] == nil {
+				subjects[subject] = newRBACChange()
+			}
+			subjects[subject].changedBy[entry.Username] = true
+			return subjects[subject]
+		}
+		for subject, grant := range before {
+			if after[subject] != grant {
+				change(subject).grants[grant]--
+			}
+		}
+		for subject, grant := range after {
+			if before[subject] != grant {
+				change(subject).grants[grant]++
+			}
+		}
+	}
+
+	for subject, change := range subjects {
+		result := types.RBACSubjectChange{Kind: subject.kind, Name: subject.name, Namespace: subject.namespace, Gained: []types.RBACGrant{}, Lost: []types.RBACGrant{}, ChangedBy: sortedKeys(change.changedBy)}
+		for grant, count := range change.grants {
+			if count > 0 {
+				result.Gained = append(result.Gained, grant)
+			} else if count < 0 {
+				result.Lost = append(result.Lost, grant)
+			}
+		}
+		if len(result.Gained) == 0 && len(result.Lost) == 0 {
+			continue
+		}
+		sortGrants(result.Gained)
+		sortGrants(result.Lost)
+		report.Subjects = append(report.Subjects, result)
+	}
+	sort.Slice(report.Subjects, func(i, j int) bool {
+		a, b := report.Subjects[i], report.Subjects[j]
+		return rbacSubject{a.Kind, a.Namespace, a.Name}.String() < rbacSubject{b.Kind, b.Namespace, b.Name}.String()
+	})
+
+	for key, change := range roles {
+		parts := strings.SplitN(key, "/", 3)
+		result := types.RBACRoleChange{Kind: "ClusterRole", Namespace: parts[1], Name: parts[2], Deleted: change.deleted, AddedRules: []string{}, RemovedRules: []string{}, ChangedBy: sortedKeys(change.changedBy)}
+		if parts[0] == "roles" {
+			result.Kind = "Role"
+		}
+		for rule, count := range change.rules {
+			if count > 0 {
+				result.AddedRules = append(result.AddedRules, rule)
+			} else if count < 0 {
+				result.RemovedRules = append(result.RemovedRules, rule)
+			}
+		}
+		if len(result.AddedRules) == 0 && len(result.RemovedRules) == 0 && !result.Deleted {
+			continue
+		}
+		sort.Strings(result.AddedRules)
+		sort.Strings(result.RemovedRules)
+		result.BoundSubjects = boundSubjects(result, bindings, versions)
+		report.Roles = append(report.Roles, result)
+	}
+	sort.Slice(report.Roles, func(i, j int) bool {
+		a, b := report.Roles[i], report.Roles[j]
+		return a.Kind+"/"+a.Namespace+"/"+a.Name < b.Kind+"/"+b.Namespace+"/"+b.Name
+	})
+
+	report.Summary = summarizeRBACChanges(report)
+	return report
+}
+
+// boundSubjects returns the subjects of the bindings seen that still bind a
+// role, by their last version
+func boundSubjects(role types.RBACRoleChange, bindings map[string]AuditLogEntry, versions map[string]map[string]interface{}) []string {
+	bound := make(map[string]bool)
+	for key, entry := range bindings {
+		for subject, grant := range bindingGrants(entry, versions[key]) {
+			if grant.RoleKind == role.Kind && grant.RoleName == role.Name && (role.Kind == "ClusterRole" || grant.Namespace == role.Namespace) {
+				bound[subject.String()] = true
+			}
+		}
+	}
+	return sortedKeys(bound)
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortGrants orders grants by role, then scope and binding
+func sortGrants(grants []types.RBACGrant) {
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		if a.RoleKind+"/"+a.RoleName != b.RoleKind+"/"+b.RoleName {
+			return a.RoleKind+"/"+a.RoleName < b.RoleKind+"/"+b.RoleName
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Binding < b.Binding
+	})
+}
+
+// describeGrant formats a grant, e.g. "ClusterRole/admin in team-a"
+func describeGrant(grant types.RBACGrant) string {
+	description := grant.RoleKind + "/" + grant.RoleName
+	if grant.Namespace != "" {
+		description += " in " + grant.Namespace
+	}
+	return description
+}
+
+// summarizeRBACChanges produces a short human-readable description of a report
+func summarizeRBACChanges(report types.RBACChangeReport) string {
+	summary := fmt.Sprintf("%d RBAC changes", report.TotalChanges)
+	if report.Failed > 0 {
+		summary += fmt.Sprintf(", %d failed", report.Failed)
+	}
+	summary += fmt.Sprintf("; %d subjects with changed bindings, %d roles with changed rules", len(report.Subjects), len(report.Roles))
+	if len(report.Unresolved) > 0 {
+		summary += fmt.Sprintf(", %d changes without enough logged bodies to resolve", len(report.Unresolved))
+	}
+
+	var gains []string
+	for _, subject := range report.Subjects {
+		if len(subject.Gained) == 0 {
+			continue
+		}
+		if len(gains) == 5 {
+			gains = append(gains, "and more")
+			break
+		}
+		var grants []string
+		for _, grant := range subject.Gained {
+			grants = append(grants, describeGrant(grant))
+		}
+		gains = append(gains, fmt.Sprintf("%s gained %s", rbacSubject{subject.Kind, subject.Namespace, subject.Name}, strings.Join(grants, ", ")))
+	}
+	if len(gains) == 0 {
+		return summary
+	}
+	return summary + "; " + strings.Join(gains, "; ")
+}
@@ -0,0 +1,71 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestAnalyzeRBACChanges tests consolidating binding and role changes per subject
+func TestAnalyzeRBACChanges(t *testing.T) {
+	change := func(auditID, verb, resource, namespace, name, timestamp string, status int, request, response map[string]interface{}) AuditLogEntry {
+		return AuditLogEntry{AuditID: auditID, Stage: "ResponseComplete", Timestamp: timestamp, Username: "mallory", Verb: verb, Resource: resource, Namespace: namespace, Name: name, StatusCode: status, RequestObject: request, ResponseObject: response}
+	}
+	binding := func(roleKind, roleName string, subjects ...map[string]interface{}) map[string]interface{} {
+		items := make([]interface{}, len(subjects))
+		for i, subject := range subjects {
+			items[i] = subject
+		}
+		return map[string]interface{}{"roleRef": map[string]interface{}{"kind": roleKind, "name": roleName}, "subjects": items}
+	}
+	user := func(name string) map[string]interface{} { return map[string]interface{}{"kind": "User", "name": name} }
+	deployer := map[string]interface{}{"kind": "ServiceAccount", "namespace": "ci", "name": "deployer"}
+	role := func(resources ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"rules": []interface{}{map[string]interface{}{"apiGroups": []interface{}{""}, "resources": resources, "verbs": []interface{}{"get", "list"}}}}
+	}
+
+	entries := []AuditLogEntry{
+		change("a1", "create", "clusterrolebindings", "", "admins", "2026-03-01T12:00:00.000000Z", 201, binding("ClusterRole", "cluster-admin", user("alice")), nil),
+		change("a2", "update", "clusterrolebindings", "", "admins", "2026-03-01T12:01:00.000000Z", 200, nil, binding("ClusterRole", "cluster-admin", user("bob"))),
+		change("a3", "create", "rolebindings", "team-a", "deploy", "2026-03-01T12:02:00.000000Z", 201, binding("Role", "reader", deployer), nil),
+		change("a4", "create", "roles", "team-a", "reader", "2026-03-01T12:03:00.000000Z", 201, role("pods"), nil),
+		change("a5", "update", "roles", "team-a", "reader", "2026-03-01T12:04:00.000000Z", 200, role("pods", "secrets"), nil),
+		change("a6", "create", "rolebindings", "team-b", "temp", "2026-03-01T12:05:00.000000Z", 201, binding("ClusterRole", "edit", user("carol")), nil),
+		change("a7", "delete", "rolebindings", "team-b", "temp", "2026-03-01T12:06:00.000000Z", 200, nil, nil),
+		change("a8", "delete", "clusterrolebindings", "", "legacy", "2026-03-01T12:07:00.000000Z", 200, nil, nil),
+		change("a9", "patch", "clusterroles", "", "view", "2026-03-01T12:08:00.000000Z", 403, nil, nil),
+	}
+
+	report := AnalyzeRBACChanges(entries)
+	if report.TotalChanges != 8 || report.Failed != 1 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if len(report.Unresolved) != 1 || report.Unresolved[0].Name != "legacy" {
+		t.Errorf("Expected the deletion of an unknown binding to be unresolved: %+v", report.Unresolved)
+	}
+
+	// alice's and carol's bindings were granted and revoked within the timeframe, so they cancel out
+	expected := []types.RBACSubjectChange{
+		{Kind: "ServiceAccount", Namespace: "ci", Name: "deployer", Gained: []types.RBACGrant{{RoleKind: "Role", RoleName: "reader", Namespace: "team-a", Binding: "rolebinding team-a/deploy"}}, Lost: []types.RBACGrant{}, ChangedBy: []string{"mallory"}},
+		{Kind: "User", Name: "bob", Gained: []types.RBACGrant{{RoleKind: "ClusterRole", RoleName: "cluster-admin", Binding: "clusterrolebinding admins"}}, Lost: []types.RBACGrant{}, ChangedBy: []string{"mallory"}},
+	}
+	if !reflect.DeepEqual(report.Subjects, expected) {
+		t.Errorf("Unexpected subjects:\n%+v\nexpected\n%+v", report.Subjects, expected)
+	}
+
+	if len(report.Roles) != 1 {
+		t.Fatalf("Expected one changed role, got %+v", report.Roles)
+	}
+	reader := report.Roles[0]
+	if reader.Kind != "Role" || reader.Namespace != "team-a" || !reflect.DeepEqual(reader.AddedRules, []string{"get,list pods, secrets"}) {
+		t.Errorf("Unexpected role change: %+v", reader)
+	}
+	if !reflect.DeepEqual(reader.BoundSubjects, []string{"ServiceAccount ci/deployer"}) {
+		t.Errorf("Expected the deployer to be bound to the role: %+v", reader.BoundSubjects)
+	}
+	if !strings.Contains(report.Summary, "User bob gained ClusterRole/cluster-admin") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+}
@@ -0,0 +1,56 @@
+package parsing
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// sampleHash returns the hash that decides whether an entry is sampled. Entries
+// are hashed by audit ID, so every stage of a request is kept or dropped
+// together, and by raw line when they have none.
+func sampleHash(entry AuditLogEntry) uint64 {
+	h := fnv.New64a()
+	if entry.AuditID != "" {
+		h.Write([]byte(entry.AuditID))
+	} else {
+		h.Write([]byte(entry.RawLine))
+	}
+	return h.Sum64()
+}
+
+// SampleEntries returns a sample of entries in their original order: about one
+// in rate of them, then at most size. Entries are picked by a hash of their
+// audit ID rather than at random, so the same query samples the same requests
+// and a sample of a larger window includes those of a smaller one. Zero rate
+// and size keep every entry.
+func SampleEntries(entries []AuditLogEntry, rate, size int) []AuditLogEntry {
+	if rate <= 1 && (size <= 0 || size >= len(entries)) {
+		return entries
+	}
+
+	type candidate struct {
+		index int
+		hash  uint64
+	}
+	var candidates []candidate
+	for i, entry := range entries {
+		hash := sampleHash(entry)
+		if rate > 1 && hash%uint64(rate) != 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{index: i, hash: hash})
+	}
+
+	// Keep the size entries with the smallest hashes, a uniform sample
+	if size > 0 && size < len(candidates) {
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].hash < candidates[j].hash })
+		candidates = candidates[:size]
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].index < candidates[j].index })
+	}
+
+	sampled := make([]AuditLogEntry, len(candidates))
+	for i, c := range candidates {
+		sampled[i] = entries[c.index]
+	}
+	return sampled
+}
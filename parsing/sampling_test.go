@@ -0,0 +1,57 @@
+package parsing
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSampleEntries tests sampling by rate and size, keeping request stages together
+func TestSampleEntries(t *testing.T) {
+	var entries []AuditLogEntry
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("audit-%d", i)
+		entries = append(entries,
+			AuditLogEntry{AuditID: id, Stage: "RequestReceived", Timestamp: fmt.Sprintf("%04d", i)},
+			AuditLogEntry{AuditID: id, Stage: "ResponseComplete", Timestamp: fmt.Sprintf("%04d", i)})
+	}
+
+	if sampled := SampleEntries(entries, 0, 0); len(sampled) != len(entries) {
+		t.Errorf("Expected every entry without sampling, got %d", len(sampled))
+	}
+
+	sampled := SampleEntries(entries, 10, 0)
+	if len(sampled) < 120 || len(sampled) > 280 {
+		t.Errorf("Expected about 200 entries at a rate of 1 in 10, got %d", len(sampled))
+	}
+	stages := make(map[string]int)
+	for i, entry := range sampled {
+		stages[entry.AuditID]++
+		if i > 0 && entry.Timestamp < sampled[i-1].Timestamp {
+			t.Fatalf("Expected the sample in log order")
+		}
+	}
+	for id, count := range stages {
+		if count != 2 {
+			t.Errorf("Expected both stages of %s, got %d", id, count)
+		}
+	}
+
+	// The same entries are sampled again, and a sized sample of the rate
+	// sample is part of it
+	if again := SampleEntries(entries, 10, 0); len(again) != len(sampled) || again[0].AuditID != sampled[0].AuditID {
+		t.Errorf("Expected a deterministic sample")
+	}
+	sized := SampleEntries(entries, 10, 50)
+	if len(sized) != 50 {
+		t.Fatalf("Expected 50 entries, got %d", len(sized))
+	}
+	for _, entry := range sized {
+		if stages[entry.AuditID] == 0 {
+			t.Errorf("Expected %s from the rate sample", entry.AuditID)
+		}
+	}
+
+	if small := SampleEntries(entries[:4], 0, 10); len(small) != 4 {
+		t.Errorf("Expected a size above the entry count to keep every entry, got %d", len(small))
+	}
+}
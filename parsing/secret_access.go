@@ -0,0 +1,149 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// SecretReadVerbs are the verbs that read secrets
+var SecretReadVerbs = []string{"get", "list", "watch"}
+
+// DefaultSecretAccessAllowlist are the users expected to read secrets: the
+// control plane, nodes and the cluster's own operators. A trailing "*"
+// matches any suffix.
+var DefaultSecretAccessAllowlist = []string{
+	"system:apiserver",
+	"system:kube-controller-manager",
+	"system:kube-scheduler",
+	"system:node:*",
+	"system:serviceaccount:kube-system:*",
+	"system:serviceaccount:openshift-*",
+}
+
+// secretAccessKey identifies a user and namespace
+type secretAccessKey struct {
+	username, namespace string
+}
+
+// isSecretRead reports whether an entry reads secrets
+func isSecretRead(entry AuditLogEntry) bool {
+	if entry.Resource != "secrets" || entry.Subresource != "" {
+		return false
+	}
+	for _, verb := range SecretReadVerbs {
+		if entry.Verb == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeSecretAccess groups the secret reads of current by user and
+// namespace. Pairs whose user read the namespace's secrets without having done
+// so in baseline are flagged as first-time accessors. Requests refused by the
+// authorizer are counted as denied rather than read, and never make a user a
+// known accessor. Entries that do not read secrets are ignored.
+func AnalyzeSecretAccess(current, baseline []AuditLogEntry) types.SecretAccessReport {
+	knownPairs := make(map[secretAccessKey]bool)
+	knownUsers := make(map[string]bool)
+	for _, entry := range baseline {
+		if isSecretRead(entry) && !IsPermissionDenial(entry) {
+			knownPairs[secretAccessKey{entry.Username, entry.Namespace}] = true
+			knownUsers[entry.Username] = true
+		}
+	}
+
+	report := types.SecretAccessReport{Accessors: []types.SecretAccessor{}}
+	accessors := make(map[secretAccessKey]*types.SecretAccessor)
+	secrets := make(map[secretAccessKey]map[string]bool)
+	var order []secretAccessKey
+	for _, entry := range current {
+		if !isSecretRead(entry) {
+			continue
+		}
+		key := secretAccessKey{entry.Username, entry.Namespace}
+		accessor, ok := accessors[key]
+		if !ok {
+			accessor = &types.SecretAccessor{Username: entry.Username, Namespace: entry.Namespace, Verbs: make(map[string]int), Secrets: []string{}}
+			accessors[key] = accessor
+			secrets[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+
+		if IsPermissionDenial(entry) {
+			accessor.Denied++
+			report.TotalDenied++
+		} else {
+			accessor.Reads++
+			report.TotalReads++
+			accessor.Verbs[entry.Verb]++
+			if entry.Name != "" && !secrets[key][entry.Name] {
+				secrets[key][entry.Name] = true
+				accessor.Secrets = append(accessor.Secrets, entry.Name)
+			}
+		}
+		if at, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+			if first, err := time.Parse(time.RFC3339Nano, accessor.FirstSeen); err != nil || at.Before(first) {
+				accessor.FirstSeen = entry.Timestamp
+			}
+			if last, err := time.Parse(time.RFC3339Nano, accessor.LastSeen); err != nil || at.After(last) {
+				accessor.LastSeen = entry.Timestamp
+			}
+		}
+	}
+
+	for _, key := range order {
+		accessor := accessors[key]
+		sort.Strings(accessor.Secrets)
+		if accessor.Reads > 0 && !knownPairs[key] {
+			accessor.FirstTime = true
+			accessor.NewUser = !knownUsers[key.username]
+			report.FirstTimeAccessors++
+		}
+		report.Accessors = append(report.Accessors, *accessor)
+	}
+	sort.SliceStable(report.Accessors, func(i, j int) bool {
+		a, b := report.Accessors[i], report.Accessors[j]
+		if a.FirstTime != b.FirstTime {
+			return a.FirstTime
+		}
+		if a.Reads != b.Reads {
+			return a.Reads > b.Reads
+		}
+		return a.Username+"/"+a.Namespace < b.Username+"/"+b.Namespace
+	})
+
+	report.Summary = summarizeSecretAccess(report)
+	return report
+}
+
+// accessorLabel names a user and namespace pair, such as "alice in payments"
+func accessorLabel(accessor types.SecretAccessor) string {
+	if accessor.Namespace == "" {
+		return accessor.Username + " in all namespaces"
+	}
+	return accessor.Username + " in " + accessor.Namespace
+}
+
+// summarizeSecretAccess produces a short human-readable description of a report
+func summarizeSecretAccess(report types.SecretAccessReport) string {
+	summary := fmt.Sprintf("%d secret reads by %d user and namespace pairs", report.TotalReads, len(report.Accessors))
+	if report.TotalDenied > 0 {
+		summary += fmt.Sprintf(", %d denied", report.TotalDenied)
+	}
+	if report.FirstTimeAccessors == 0 {
+		return summary + "; no first-time accessors"
+	}
+
+	var accessors []string
+	for _, accessor := range report.Accessors {
+		if accessor.FirstTime {
+			accessors = append(accessors, accessorLabel(accessor))
+		}
+	}
+	return summary + fmt.Sprintf("; %d first-time accessors: %s", report.FirstTimeAccessors, strings.Join(accessors, ", "))
+}
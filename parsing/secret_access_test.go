@@ -0,0 +1,76 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAnalyzeSecretAccess tests grouping secret reads and flagging first-time accessors
+func TestAnalyzeSecretAccess(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	read := func(username, verb, namespace, name string, minutes, status int) AuditLogEntry {
+		return AuditLogEntry{
+			Timestamp:  start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339Nano),
+			Verb:       verb,
+			Resource:   "secrets",
+			Namespace:  namespace,
+			Name:       name,
+			Username:   username,
+			StatusCode: status,
+		}
+	}
+
+	baseline := []AuditLogEntry{
+		read("alice", "get", "payments", "db-password", -600, 200),
+		read("bob", "get", "web", "tls", -500, 403),
+	}
+	ignored := read("alice", "delete", "payments", "db-password", 3, 200)
+	status := read("carol", "get", "payments", "db-password", 4, 200)
+	status.Subresource = "status"
+	current := []AuditLogEntry{
+		read("alice", "get", "payments", "db-password", 0, 200),
+		read("alice", "get", "payments", "api-key", 5, 200),
+		read("alice", "list", "web", "", 10, 200),
+		read("bob", "get", "web", "tls", 2, 403),
+		read("carol", "watch", "", "", 1, 200),
+		ignored,
+		status,
+	}
+
+	report := AnalyzeSecretAccess(current, baseline)
+	if report.TotalReads != 4 || report.TotalDenied != 1 || len(report.Accessors) != 4 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if report.FirstTimeAccessors != 2 {
+		t.Errorf("Expected 2 first-time accessors, got %d", report.FirstTimeAccessors)
+	}
+
+	// First-time accessors come first
+	carol := report.Accessors[1]
+	if carol.Username != "carol" || !carol.FirstTime || !carol.NewUser || carol.Namespace != "" || carol.Verbs["watch"] != 1 {
+		t.Errorf("Unexpected accessor: %+v", carol)
+	}
+	web := report.Accessors[0]
+	if web.Username != "alice" || web.Namespace != "web" || !web.FirstTime || web.NewUser {
+		t.Errorf("Unexpected accessor: %+v", web)
+	}
+	payments := report.Accessors[2]
+	if payments.Username != "alice" || payments.FirstTime || payments.Reads != 2 || strings.Join(payments.Secrets, ",") != "api-key,db-password" {
+		t.Errorf("Unexpected accessor: %+v", payments)
+	}
+	if payments.FirstSeen != current[0].Timestamp || payments.LastSeen != current[1].Timestamp {
+		t.Errorf("Unexpected first and last seen: %s, %s", payments.FirstSeen, payments.LastSeen)
+	}
+	bob := report.Accessors[3]
+	if bob.Username != "bob" || bob.FirstTime || bob.Reads != 0 || bob.Denied != 1 || len(bob.Secrets) != 0 {
+		t.Errorf("Unexpected accessor: %+v", bob)
+	}
+
+	if !strings.Contains(report.Summary, "2 first-time accessors: alice in web, carol in all namespaces") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+	if summary := AnalyzeSecretAccess(nil, nil).Summary; summary != "0 secret reads by 0 user and namespace pairs; no first-time accessors" {
+		t.Errorf("Unexpected summary: %s", summary)
+	}
+}
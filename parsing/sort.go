@@ -0,0 +1,54 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+)
+
+// entryLess compares two entries by a single sort field
+var entryLess = map[string]func(a, b AuditLogEntry) bool{
+	"timestamp":  func(a, b AuditLogEntry) bool { return a.Timestamp < b.Timestamp },
+	"user":       func(a, b AuditLogEntry) bool { return a.Username < b.Username },
+	"resource":   func(a, b AuditLogEntry) bool { return a.Resource < b.Resource },
+	"statusCode": func(a, b AuditLogEntry) bool { return a.StatusCode < b.StatusCode },
+}
+
+// SortEntries orders entries in place by sortBy ("timestamp", "user", "resource"
+// or "statusCode") in the given order ("asc" or "desc", ascending when empty).
+// The sort is stable, so entries with equal keys keep their log order. An empty
+// sortBy leaves the entries untouched.
+func SortEntries(entries []AuditLogEntry, sortBy, order string) error {
+	if sortBy == "" {
+		return nil
+	}
+
+	less, ok := entryLess[sortBy]
+	if !ok {
+		return fmt.Errorf("invalid sort field: %s", sortBy)
+	}
+
+	switch order {
+	case "", "asc":
+		sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	case "desc":
+		sort.SliceStable(entries, func(i, j int) bool { return less(entries[j], entries[i]) })
+	default:
+		return fmt.Errorf("invalid sort order: %s", order)
+	}
+	return nil
+}
+
+// PageEntries skips the first offset entries and returns at most limit of the
+// rest; a limit of zero returns every remaining entry
+func PageEntries(entries []AuditLogEntry, offset, limit int) []AuditLogEntry {
+	if offset >= len(entries) {
+		return []AuditLogEntry{}
+	}
+	if offset > 0 {
+		entries = entries[offset:]
+	}
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries
+}
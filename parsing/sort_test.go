@@ -0,0 +1,85 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortEntries(t *testing.T) {
+	newEntries := func() []AuditLogEntry {
+		return []AuditLogEntry{
+			{Name: "b", Timestamp: "2024-01-15T10:00:02.000000Z", Username: "carol", Resource: "pods", StatusCode: 200},
+			{Name: "a", Timestamp: "2024-01-15T10:00:01.000000Z", Username: "alice", Resource: "secrets", StatusCode: 403},
+			{Name: "c", Timestamp: "2024-01-15T10:00:03.000000Z", Username: "alice", Resource: "configmaps", StatusCode: 201},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		sortBy   string
+		order    string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "no sort keeps log order", expected: []string{"b", "a", "c"}},
+		{name: "timestamp ascending", sortBy: "timestamp", expected: []string{"a", "b", "c"}},
+		{name: "timestamp descending", sortBy: "timestamp", order: "desc", expected: []string{"c", "b", "a"}},
+		{name: "user is stable", sortBy: "user", order: "asc", expected: []string{"a", "c", "b"}},
+		{name: "resource", sortBy: "resource", expected: []string{"c", "b", "a"}},
+		{name: "status code descending", sortBy: "statusCode", order: "desc", expected: []string{"a", "c", "b"}},
+		{name: "unknown field", sortBy: "verb", wantErr: true},
+		{name: "unknown order", sortBy: "user", order: "up", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := newEntries()
+			err := SortEntries(entries, tt.sortBy, tt.order)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SortEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Expected order %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestPageEntries(t *testing.T) {
+	entries := []AuditLogEntry{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+
+	tests := []struct {
+		name     string
+		offset   int
+		limit    int
+		expected int
+		first    string
+	}{
+		{name: "no paging", expected: 4, first: "a"},
+		{name: "limit", limit: 2, expected: 2, first: "a"},
+		{name: "offset", offset: 1, expected: 3, first: "b"},
+		{name: "offset and limit", offset: 2, limit: 1, expected: 1, first: "c"},
+		{name: "limit beyond end", offset: 3, limit: 10, expected: 1, first: "d"},
+		{name: "offset beyond end", offset: 4, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := PageEntries(entries, tt.offset, tt.limit)
+			if len(page) != tt.expected {
+				t.Fatalf("Expected %d entries, got %d", tt.expected, len(page))
+			}
+			if tt.expected > 0 && page[0].Name != tt.first {
+				t.Errorf("Expected first entry %s, got %s", tt.first, page[0].Name)
+			}
+		})
+	}
+}
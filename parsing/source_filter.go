@@ -0,0 +1,56 @@
+package parsing
+
+import (
+	"fmt"
+	"net"
+)
+
+// FilterEntriesBySource keeps the entries whose source IPs include sourceIP and
+// fall within sourceCIDR. Empty arguments are ignored; entries without source IPs
+// never match an active filter.
+func FilterEntriesBySource(entries []AuditLogEntry, sourceIP, sourceCIDR string) ([]AuditLogEntry, error) {
+	var ip net.IP
+	if sourceIP != "" {
+		if ip = net.ParseIP(sourceIP); ip == nil {
+			return nil, fmt.Errorf("invalid source IP: %s", sourceIP)
+		}
+	}
+
+	var network *net.IPNet
+	if sourceCIDR != "" {
+		var err error
+		if _, network, err = net.ParseCIDR(sourceCIDR); err != nil {
+			return nil, fmt.Errorf("invalid source CIDR: %s", sourceCIDR)
+		}
+	}
+
+	if ip == nil && network == nil {
+		return entries, nil
+	}
+
+	var filtered []AuditLogEntry
+	for _, entry := range entries {
+		if entryMatchesSource(entry, ip, network) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// entryMatchesSource reports whether any of the entry's source IPs satisfies both filters
+func entryMatchesSource(entry AuditLogEntry, ip net.IP, network *net.IPNet) bool {
+	for _, value := range entry.SourceIPs {
+		candidate := net.ParseIP(value)
+		if candidate == nil {
+			continue
+		}
+		if ip != nil && !ip.Equal(candidate) {
+			continue
+		}
+		if network != nil && !network.Contains(candidate) {
+			continue
+		}
+		return true
+	}
+	return false
+}
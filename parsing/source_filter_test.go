@@ -0,0 +1,50 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterEntriesBySource(t *testing.T) {
+	entries := []AuditLogEntry{
+		{Username: "cluster", SourceIPs: []string{"10.128.0.12"}},
+		{Username: "external", SourceIPs: []string{"203.0.113.7"}},
+		{Username: "proxied", SourceIPs: []string{"203.0.113.9", "10.128.0.1"}},
+		{Username: "ipv6", SourceIPs: []string{"fd00::10"}},
+		{Username: "unknown"},
+	}
+
+	tests := []struct {
+		name       string
+		sourceIP   string
+		sourceCIDR string
+		expected   []string
+		wantErr    bool
+	}{
+		{name: "no filters", expected: []string{"cluster", "external", "proxied", "ipv6", "unknown"}},
+		{name: "exact IP", sourceIP: "203.0.113.7", expected: []string{"external"}},
+		{name: "IPv4 CIDR", sourceCIDR: "10.128.0.0/14", expected: []string{"cluster", "proxied"}},
+		{name: "IPv6 CIDR", sourceCIDR: "fd00::/8", expected: []string{"ipv6"}},
+		{name: "IP and CIDR", sourceIP: "10.128.0.1", sourceCIDR: "10.128.0.0/14", expected: []string{"proxied"}},
+		{name: "IP outside CIDR", sourceIP: "203.0.113.7", sourceCIDR: "10.0.0.0/8"},
+		{name: "invalid IP", sourceIP: "not-an-ip", wantErr: true},
+		{name: "invalid CIDR", sourceCIDR: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterEntriesBySource(entries, tt.sourceIP, tt.sourceCIDR)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FilterEntriesBySource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var usernames []string
+			for _, entry := range filtered {
+				usernames = append(usernames, entry.Username)
+			}
+			if strings.Join(usernames, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("FilterEntriesBySource() kept %v, want %v", usernames, tt.expected)
+			}
+		})
+	}
+}
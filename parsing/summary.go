@@ -0,0 +1,270 @@
+package parsing
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"audit-query-mcp-server/utils"
+)
+
+// DefaultBriefSummaryTemplate renders the single sentence summary
+const DefaultBriefSummaryTemplate = `{{if eq .Total 0}}No audit entries found matching the criteria.{{else -}}
+Found {{.Total}} audit entries
+{{- with .Users}}. Users involved: {{counts .}}{{end}}
+{{- with .StatusCodes}}. Status codes: {{counts .}}{{end}}
+{{- with .Verbs}}. Actions: {{counts .}}{{end}}
+{{- with .Resources}}. Resources: {{counts .}}{{end}}
+{{- end}}`
+
+// DefaultVerboseSummaryTemplate renders a multi-line summary with the top
+// users, verbs, namespaces and resources and the error rate
+const DefaultVerboseSummaryTemplate = `{{if eq .Total 0}}No audit entries found matching the criteria.{{else -}}
+Found {{.Total}} audit {{plural .Total "entry" "entries"}}{{if .First}} from {{.First}} to {{.Last}}{{end}}.
+{{- with .Users}}
+Top users: {{counts (top 5 .)}}{{if gt (len .) 5}} and {{sub (len .) 5}} more{{end}}{{end}}
+{{- with .Verbs}}
+Top verbs: {{counts (top 5 .)}}{{end}}
+{{- with .Namespaces}}
+Top namespaces: {{counts (top 5 .)}}{{if gt (len .) 5}} and {{sub (len .) 5}} more{{end}}{{end}}
+{{- with .Resources}}
+Top resources: {{counts (top 5 .)}}{{end}}
+{{- with .StatusCodes}}
+Status codes: {{counts .}}
+Error rate: {{percent $.ErrorRate}} ({{$.Errors}} of {{$.WithStatus}} responses failed){{end}}
+{{- end}}`
+
+// SummaryCount is how many entries share a value, such as a username
+type SummaryCount struct {
+	Value string
+	Count int
+}
+
+// String renders the count as "value (count)"
+func (c SummaryCount) String() string {
+	return fmt.Sprintf("%s (%d)", c.Value, c.Count)
+}
+
+// SummaryData is the aggregation a summary template renders. Counts are
+// ordered by count, highest first, then by value.
+type SummaryData struct {
+	Total       int
+	Users       []SummaryCount
+	Verbs       []SummaryCount
+	Resources   []SummaryCount
+	Namespaces  []SummaryCount
+	StatusCodes []SummaryCount
+
+	// Errors counts responses with status 400 or above among the WithStatus
+	// entries that have a status code; ErrorRate is their share in percent
+	Errors     int
+	WithStatus int
+	ErrorRate  float64
+
+	// First and Last are the earliest and latest entry timestamps, when known
+	First string
+	Last  string
+
+	// Context is the query context the summary is generated for
+	Context map[string]interface{}
+}
+
+// NewSummaryData aggregates entries for a summary template
+func NewSummaryData(entries []AuditLogEntry, context map[string]interface{}) SummaryData {
+	data := SummaryData{Total: len(entries), Context: context}
+
+	users := make(map[string]int)
+	verbs := make(map[string]int)
+	resources := make(map[string]int)
+	namespaces := make(map[string]int)
+	statusCodes := make(map[string]int)
+	var first, last time.Time
+	for _, entry := range entries {
+		countValue(users, entry.Username)
+		countValue(verbs, entry.Verb)
+		countValue(resources, entry.Resource)
+		countValue(namespaces, entry.Namespace)
+		if entry.StatusCode != 0 {
+			statusCodes[fmt.Sprintf("%d", entry.StatusCode)]++
+			data.WithStatus++
+			if entry.StatusCode >= 400 {
+				data.Errors++
+			}
+		}
+		if timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+			if first.IsZero() || timestamp.Before(first) {
+				first = timestamp
+				data.First = entry.Timestamp
+			}
+			if last.IsZero() || timestamp.After(last) {
+				last = timestamp
+				data.Last = entry.Timestamp
+			}
+		}
+	}
+
+	data.Users = sortedCounts(users)
+	data.Verbs = sortedCounts(verbs)
+	data.Resources = sortedCounts(resources)
+	data.Namespaces = sortedCounts(namespaces)
+	data.StatusCodes = sortedCounts(statusCodes)
+	if data.WithStatus > 0 {
+		data.ErrorRate = float64(data.Errors) * 100 / float64(data.WithStatus)
+	}
+	return data
+}
+
+// countValue counts a non-empty value
+func countValue(counts map[string]int, value string) {
+	if value != "" {
+		counts[value]++
+	}
+}
+
+// sortedCounts orders counts by count, highest first, then by value
+func sortedCounts(counts map[string]int) []SummaryCount {
+	if len(counts) == 0 {
+		return nil
+	}
+	sorted := make([]SummaryCount, 0, len(counts))
+	for value, count := range counts {
+		sorted = append(sorted, SummaryCount{Value: value, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Value < sorted[j].Value
+	})
+	return sorted
+}
+
+// summaryFuncs are the helpers available to summary templates
+var summaryFuncs = template.FuncMap{
+	// top returns the first n counts
+	"top": func(n int, counts []SummaryCount) []SummaryCount {
+		if n < len(counts) {
+			return counts[:n]
+		}
+		return counts
+	},
+	// counts renders counts as "value (count), ..."
+	"counts": func(counts []SummaryCount) string {
+		parts := make([]string, len(counts))
+		for i, count := range counts {
+			parts[i] = count.String()
+		}
+		return strings.Join(parts, ", ")
+	},
+	// values renders the counted values as "value, ..."
+	"values": func(counts []SummaryCount) string {
+		parts := make([]string, len(counts))
+		for i, count := range counts {
+			parts[i] = count.Value
+		}
+		return strings.Join(parts, ", ")
+	},
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%.1f%%", value)
+	},
+	"plural": func(n int, singular, plural string) string {
+		if n == 1 {
+			return singular
+		}
+		return plural
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseSummaryTemplate parses a summary template, which renders SummaryData
+// with the helpers top, counts, values, percent, plural, sub, join, upper and lower
+func ParseSummaryTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(summaryFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid summary template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// SummaryTemplates holds the template of each summary mode
+type SummaryTemplates struct {
+	Brief   *template.Template
+	Verbose *template.Template
+}
+
+// defaultSummaryTemplates are the built-in templates
+var defaultSummaryTemplates = DefaultSummaryTemplates()
+
+// DefaultSummaryTemplates returns the built-in summary templates
+func DefaultSummaryTemplates() *SummaryTemplates {
+	return &SummaryTemplates{
+		Brief:   template.Must(ParseSummaryTemplate(utils.SummaryModeBrief, DefaultBriefSummaryTemplate)),
+		Verbose: template.Must(ParseSummaryTemplate(utils.SummaryModeVerbose, DefaultVerboseSummaryTemplate)),
+	}
+}
+
+// LoadSummaryTemplates reads custom summary templates from files; an empty
+// path keeps the built-in template of that mode
+func LoadSummaryTemplates(briefPath, verbosePath string) (*SummaryTemplates, error) {
+	templates := DefaultSummaryTemplates()
+	for _, file := range []struct {
+		path     string
+		mode     string
+		template **template.Template
+	}{
+		{briefPath, utils.SummaryModeBrief, &templates.Brief},
+		{verbosePath, utils.SummaryModeVerbose, &templates.Verbose},
+	} {
+		if file.path == "" {
+			continue
+		}
+		text, err := os.ReadFile(file.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read summary template: %w", err)
+		}
+		tmpl, err := ParseSummaryTemplate(file.mode, string(text))
+		if err != nil {
+			return nil, err
+		}
+		// Render sample data so template errors surface when loading
+		if _, err := execute(tmpl, NewSummaryData(sampleSummaryEntries, nil)); err != nil {
+			return nil, err
+		}
+		*file.template = tmpl
+	}
+	return templates, nil
+}
+
+// sampleSummaryEntries are rendered to check custom templates
+var sampleSummaryEntries = []AuditLogEntry{
+	{Timestamp: "2026-01-01T00:00:00Z", Username: "admin", Verb: "delete", Resource: "pods", Namespace: "default", StatusCode: 200},
+	{Timestamp: "2026-01-01T00:01:00Z", Username: "developer", Verb: "get", Resource: "secrets", Namespace: "default", StatusCode: 403},
+}
+
+// Generate renders the summary of entries in the mode set by the "summary_mode"
+// context key, brief by default
+func (t *SummaryTemplates) Generate(entries []AuditLogEntry, context map[string]interface{}) (string, error) {
+	tmpl := t.Brief
+	if mode, _ := context["summary_mode"].(string); mode == utils.SummaryModeVerbose {
+		tmpl = t.Verbose
+	}
+	return execute(tmpl, NewSummaryData(entries, context))
+}
+
+// execute renders a summary template
+func execute(tmpl *template.Template, data SummaryData) (string, error) {
+	var summary bytes.Buffer
+	if err := tmpl.Execute(&summary, data); err != nil {
+		return "", fmt.Errorf("failed to render summary template %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(summary.String()), nil
+}
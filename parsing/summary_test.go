@@ -0,0 +1,141 @@
+package parsing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// summaryEntries are audit entries with six users, so verbose summaries
+// truncate the user list
+var summaryEntries = []AuditLogEntry{
+	{Timestamp: "2024-01-15T10:00:00Z", Username: "admin", Verb: "get", Resource: "pods", Namespace: "default", StatusCode: 200},
+	{Timestamp: "2024-01-15T10:05:00Z", Username: "admin", Verb: "delete", Resource: "pods", Namespace: "default", StatusCode: 200},
+	{Timestamp: "2024-01-15T09:55:00Z", Username: "admin", Verb: "get", Resource: "secrets", Namespace: "payments", StatusCode: 403},
+	{Timestamp: "2024-01-15T10:10:00Z", Username: "bob", Verb: "get", Resource: "secrets", Namespace: "payments", StatusCode: 403},
+	{Timestamp: "2024-01-15T10:15:00Z", Username: "carol", Verb: "list", Resource: "pods", StatusCode: 200},
+	{Timestamp: "2024-01-15T10:20:00Z", Username: "dave", Verb: "get", Resource: "configmaps", Namespace: "default"},
+	{Timestamp: "2024-01-15T10:25:00Z", Username: "erin", Verb: "watch", Resource: "pods", Namespace: "default", StatusCode: 200},
+	{Timestamp: "invalid", Username: "frank", Verb: "get", Resource: "pods", Namespace: "default", StatusCode: 500},
+}
+
+// TestNewSummaryData tests aggregating entries for summary templates
+func TestNewSummaryData(t *testing.T) {
+	data := NewSummaryData(summaryEntries, map[string]interface{}{"log_source": "kube-apiserver"})
+
+	if data.Total != 8 || len(data.Users) != 6 {
+		t.Fatalf("Unexpected data %+v", data)
+	}
+	// Counts are ordered by count, then by value
+	if data.Users[0] != (SummaryCount{Value: "admin", Count: 3}) || data.Users[1].Value != "bob" {
+		t.Errorf("Unexpected users %v", data.Users)
+	}
+	if data.Verbs[0] != (SummaryCount{Value: "get", Count: 5}) {
+		t.Errorf("Unexpected verbs %v", data.Verbs)
+	}
+	if len(data.Namespaces) != 2 || data.Namespaces[0].Value != "default" {
+		t.Errorf("Unexpected namespaces %v", data.Namespaces)
+	}
+	if data.Errors != 3 || data.WithStatus != 7 || data.ErrorRate < 42.8 || data.ErrorRate > 42.9 {
+		t.Errorf("Unexpected errors %d of %d (%.2f%%)", data.Errors, data.WithStatus, data.ErrorRate)
+	}
+	if data.First != "2024-01-15T09:55:00Z" || data.Last != "2024-01-15T10:25:00Z" {
+		t.Errorf("Unexpected time range %s to %s", data.First, data.Last)
+	}
+	if data.Context["log_source"] != "kube-apiserver" {
+		t.Errorf("Expected the query context, got %v", data.Context)
+	}
+
+	empty := NewSummaryData(nil, nil)
+	if empty.Total != 0 || empty.Users != nil || empty.ErrorRate != 0 {
+		t.Errorf("Unexpected data for no entries %+v", empty)
+	}
+}
+
+// TestSummaryTemplates_Generate tests the built-in brief and verbose summaries
+func TestSummaryTemplates_Generate(t *testing.T) {
+	templates := DefaultSummaryTemplates()
+
+	brief, err := templates.Generate(summaryEntries[:2], nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "Found 2 audit entries. Users involved: admin (2). Status codes: 200 (2). Actions: delete (1), get (1). Resources: pods (2)"
+	if brief != expected {
+		t.Errorf("Expected %q, got %q", expected, brief)
+	}
+
+	verbose, err := templates.Generate(summaryEntries, map[string]interface{}{"summary_mode": "verbose"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, line := range []string{
+		"Found 8 audit entries from 2024-01-15T09:55:00Z to 2024-01-15T10:25:00Z.",
+		"Top users: admin (3), bob (1), carol (1), dave (1), erin (1) and 1 more",
+		"Top verbs: get (5), delete (1), list (1), watch (1)",
+		"Top namespaces: default (5), payments (2)",
+		"Status codes: 200 (4), 403 (2), 500 (1)",
+		"Error rate: 42.9% (3 of 7 responses failed)",
+	} {
+		if !strings.Contains(verbose, line+"\n") && !strings.HasSuffix(verbose, line) {
+			t.Errorf("Expected line %q in %q", line, verbose)
+		}
+	}
+
+	single, _ := templates.Generate(summaryEntries[5:6], map[string]interface{}{"summary_mode": "verbose"})
+	if !strings.HasPrefix(single, "Found 1 audit entry ") || strings.Contains(single, "Error rate") {
+		t.Errorf("Unexpected summary of one entry without a status %q", single)
+	}
+
+	for _, mode := range []string{"brief", "verbose"} {
+		none, _ := templates.Generate(nil, map[string]interface{}{"summary_mode": mode})
+		if none != "No audit entries found matching the criteria." {
+			t.Errorf("Unexpected %s summary of no entries %q", mode, none)
+		}
+	}
+}
+
+// TestLoadSummaryTemplates tests loading custom summary templates
+func TestLoadSummaryTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	brief := write("brief.tmpl", `{{.Total}} events in {{.Context.log_source}} by {{values (top 2 .Users)}}{{"\n"}}`)
+	templates, err := LoadSummaryTemplates(brief, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	summary, err := templates.Generate(summaryEntries, map[string]interface{}{"log_source": "kube-apiserver"})
+	if err != nil || summary != "8 events in kube-apiserver by admin, bob" {
+		t.Errorf("Unexpected summary %q, %v", summary, err)
+	}
+	// The verbose template stays built in
+	verbose, _ := templates.Generate(summaryEntries, map[string]interface{}{"summary_mode": "verbose"})
+	if !strings.Contains(verbose, "Top users:") {
+		t.Errorf("Expected the built-in verbose summary, got %q", verbose)
+	}
+
+	invalid := []struct {
+		name string
+		text string
+	}{
+		{"syntax.tmpl", `{{if .Total}}`},
+		{"function.tmpl", `{{missing .Users}}`},
+		{"field.tmpl", `{{.Unknown}}`},
+	}
+	for _, tt := range invalid {
+		if _, err := LoadSummaryTemplates("", write(tt.name, tt.text)); err == nil {
+			t.Errorf("Expected an error for %s", tt.name)
+		}
+	}
+	if _, err := LoadSummaryTemplates(filepath.Join(dir, "missing.tmpl"), ""); err == nil {
+		t.Error("Expected an error for a missing template file")
+	}
+}
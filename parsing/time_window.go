@@ -0,0 +1,78 @@
+package parsing
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// recordTimestamp extracts the time a request was received from a raw audit
+// event or from the jq projection generated commands print
+var recordTimestamp = regexp.MustCompile(`"(?:requestReceivedTimestamp|timestamp)"\s*:\s*"([^"]+)"`)
+
+// SplitAuditRecords splits command output into one compact JSON record per
+// element, joining the multi-line objects jq prints. Text outside JSON objects,
+// such as oc warnings, is dropped.
+func SplitAuditRecords(output string) []string {
+	var records []string
+	var pending strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			// A top-level object starts; drop any unterminated one before it
+			pending.Reset()
+		} else if pending.Len() == 0 {
+			continue
+		}
+		pending.WriteString(line)
+		pending.WriteString("\n")
+
+		if candidate := []byte(pending.String()); json.Valid(candidate) {
+			var compact bytes.Buffer
+			if json.Compact(&compact, candidate) == nil {
+				records = append(records, compact.String())
+			}
+			pending.Reset()
+		}
+	}
+	return records
+}
+
+// LineTimestamp returns the time an audit record's request was received
+func LineTimestamp(line string) (time.Time, bool) {
+	match := recordTimestamp.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
+
+// FilterLinesByTime keeps the records received after `after` and no later than
+// `until`; a zero bound is open. Records without a readable timestamp are
+// dropped, since they cannot be placed in the window.
+func FilterLinesByTime(lines []string, after, until time.Time) []string {
+	var kept []string
+	for _, line := range lines {
+		timestamp, ok := LineTimestamp(line)
+		if !ok {
+			continue
+		}
+		if !after.IsZero() && !timestamp.After(after) {
+			continue
+		}
+		if !until.IsZero() && timestamp.After(until) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
@@ -0,0 +1,83 @@
+package parsing
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFilterLinesByTime tests window filtering of raw audit log lines
+func TestFilterLinesByTime(t *testing.T) {
+	lines := []string{
+		`{"auditID":"a","requestReceivedTimestamp":"2024-01-15T09:00:00.000000Z"}`,
+		`{"auditID":"b","requestReceivedTimestamp":"2024-01-15T10:00:00.000000Z"}`,
+		`{"auditID":"c","requestReceivedTimestamp":"2024-01-15T11:30:00.123456Z"}`,
+		`{"auditID":"d"}`,
+		`not json`,
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 15, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		after    time.Time
+		until    time.Time
+		expected int
+	}{
+		{name: "open window", expected: 3},
+		{name: "after is exclusive", after: at(10, 0), expected: 1},
+		{name: "until is inclusive", until: at(10, 0), expected: 2},
+		{name: "bounded window", after: at(9, 30), until: at(11, 0), expected: 1},
+		{name: "empty window", after: at(12, 0), expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := FilterLinesByTime(lines, tt.after, tt.until)
+			if len(kept) != tt.expected {
+				t.Errorf("Expected %d lines, got %d: %v", tt.expected, len(kept), kept)
+			}
+		})
+	}
+
+	if timestamp, ok := LineTimestamp(lines[2]); !ok || timestamp.Nanosecond() != 123456000 {
+		t.Errorf("Expected a fractional timestamp, got %v", timestamp)
+	}
+	if _, ok := LineTimestamp(lines[3]); ok {
+		t.Error("Expected no timestamp for a line without one")
+	}
+}
+
+// TestSplitAuditRecords tests splitting line-delimited and jq pretty-printed output
+func TestSplitAuditRecords(t *testing.T) {
+	output := `Warning: audit log rotated
+{"auditID":"a","requestReceivedTimestamp":"2024-01-15T09:00:00Z"}
+{
+  "auditID": "b",
+  "timestamp": "2024-01-15T10:00:00Z",
+  "sourceIPs": [
+    "10.0.0.1"
+  ]
+}
+{
+  "auditID": "truncated",
+{"auditID":"c","requestReceivedTimestamp":"2024-01-15T11:00:00Z"}
+`
+	records := SplitAuditRecords(output)
+	expected := []string{
+		`{"auditID":"a","requestReceivedTimestamp":"2024-01-15T09:00:00Z"}`,
+		`{"auditID":"b","timestamp":"2024-01-15T10:00:00Z","sourceIPs":["10.0.0.1"]}`,
+		`{"auditID":"c","requestReceivedTimestamp":"2024-01-15T11:00:00Z"}`,
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d: %v", len(expected), len(records), records)
+	}
+	for i := range expected {
+		if records[i] != expected[i] {
+			t.Errorf("Record %d: expected %s, got %s", i, expected[i], records[i])
+		}
+	}
+	if kept := FilterLinesByTime(records, time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC), time.Time{}); len(kept) != 2 {
+		t.Errorf("Expected the projected timestamp to be read, got %v", kept)
+	}
+}
@@ -0,0 +1,143 @@
+package parsing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// Timeline defaults
+const (
+	DefaultTimelineBurstWindow  = time.Minute
+	DefaultTimelineGapThreshold = 30 * time.Minute
+)
+
+// timedEntry is an entry with its parsed timestamp
+type timedEntry struct {
+	at    time.Time
+	entry AuditLogEntry
+}
+
+// sameOperation reports whether two entries repeat the same operation on the same object
+func sameOperation(a, b AuditLogEntry) bool {
+	return a.Verb == b.Verb &&
+		a.Resource == b.Resource &&
+		a.Subresource == b.Subresource &&
+		a.Namespace == b.Namespace &&
+		a.Name == b.Name &&
+		a.StatusCode == b.StatusCode
+}
+
+// BuildTimeline orders entries chronologically and collapses runs of identical
+// operations that follow each other within burstWindow into a single event.
+// Idle periods longer than gapThreshold between events are reported as gaps.
+// Zero durations select the defaults. Entries without a parseable timestamp
+// cannot be placed and are only counted.
+func BuildTimeline(username string, entries []AuditLogEntry, burstWindow, gapThreshold time.Duration) types.UserTimeline {
+	if burstWindow <= 0 {
+		burstWindow = DefaultTimelineBurstWindow
+	}
+	if gapThreshold <= 0 {
+		gapThreshold = DefaultTimelineGapThreshold
+	}
+
+	timeline := types.UserTimeline{
+		Username:    username,
+		TotalEvents: len(entries),
+		Events:      []types.TimelineEvent{},
+		Gaps:        []types.TimelineGap{},
+		Narrative:   []string{},
+	}
+
+	var timed []timedEntry
+	for _, entry := range entries {
+		at, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			timeline.Untimed++
+			continue
+		}
+		timed = append(timed, timedEntry{at: at.UTC(), entry: entry})
+	}
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].at.Before(timed[j].at) })
+
+	// gapBefore maps an event index to the gap that precedes it
+	gapBefore := make(map[int]types.TimelineGap)
+	var lastEntry AuditLogEntry
+	var lastAt time.Time
+	for i, current := range timed {
+		if i > 0 {
+			idle := current.at.Sub(lastAt)
+			if sameOperation(lastEntry, current.entry) && idle <= burstWindow {
+				event := &timeline.Events[len(timeline.Events)-1]
+				event.Count++
+				event.End = current.at.Format(time.RFC3339)
+				lastAt = current.at
+				continue
+			}
+			if idle > gapThreshold {
+				gap := types.TimelineGap{
+					From:     lastAt.Format(time.RFC3339),
+					To:       current.at.Format(time.RFC3339),
+					Duration: idle.Truncate(time.Second).String(),
+				}
+				timeline.Gaps = append(timeline.Gaps, gap)
+				gapBefore[len(timeline.Events)] = gap
+			}
+		}
+
+		timeline.Events = append(timeline.Events, types.TimelineEvent{
+			Start:       current.at.Format(time.RFC3339),
+			End:         current.at.Format(time.RFC3339),
+			Count:       1,
+			Verb:        current.entry.Verb,
+			Resource:    current.entry.Resource,
+			Subresource: current.entry.Subresource,
+			Namespace:   current.entry.Namespace,
+			Name:        current.entry.Name,
+			StatusCode:  current.entry.StatusCode,
+			SourceIPs:   current.entry.SourceIPs,
+		})
+		lastEntry, lastAt = current.entry, current.at
+	}
+	for i, event := range timeline.Events {
+		if gap, ok := gapBefore[i]; ok {
+			timeline.Narrative = append(timeline.Narrative, fmt.Sprintf("-- no activity for %s --", gap.Duration))
+		}
+		timeline.Narrative = append(timeline.Narrative, describeTimelineEvent(event))
+	}
+
+	return timeline
+}
+
+// describeTimelineEvent renders an event as a narrative line, such as
+// "2024-01-15T10:05:01Z delete secrets/db-pass in payments (403) x3 until 2024-01-15T10:05:20Z"
+func describeTimelineEvent(event types.TimelineEvent) string {
+	var b strings.Builder
+	b.WriteString(event.Start)
+	b.WriteString(" ")
+	b.WriteString(event.Verb)
+
+	target := event.Resource
+	if event.Subresource != "" {
+		target += "/" + event.Subresource
+	}
+	if event.Name != "" {
+		target += " " + event.Name
+	}
+	if target != "" {
+		b.WriteString(" " + target)
+	}
+	if event.Namespace != "" {
+		b.WriteString(" in " + event.Namespace)
+	}
+	if event.StatusCode != 0 {
+		fmt.Fprintf(&b, " (%d)", event.StatusCode)
+	}
+	if event.Count > 1 {
+		fmt.Fprintf(&b, " x%d until %s", event.Count, event.End)
+	}
+	return b.String()
+}
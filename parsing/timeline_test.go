@@ -0,0 +1,69 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	entries := []AuditLogEntry{
+		{Timestamp: "2024-01-15T12:00:00Z", Verb: "delete", Resource: "secrets", Name: "db-pass", Namespace: "payments", StatusCode: 403},
+		{Timestamp: "2024-01-15T10:00:00Z", Verb: "get", Resource: "pods", Namespace: "web", StatusCode: 200},
+		{Timestamp: "2024-01-15T10:00:20Z", Verb: "get", Resource: "pods", Namespace: "web", StatusCode: 200},
+		{Timestamp: "2024-01-15T10:00:50Z", Verb: "get", Resource: "pods", Namespace: "web", StatusCode: 200},
+		{Timestamp: "2024-01-15T10:05:00Z", Verb: "get", Resource: "pods", Namespace: "web", StatusCode: 200},
+		{Timestamp: "2024-01-15T10:05:10Z", Verb: "create", Resource: "pods", Subresource: "exec", Name: "web-1", Namespace: "web", StatusCode: 101},
+		{Verb: "list", Resource: "pods"},
+	}
+
+	timeline := BuildTimeline("alice", entries, 0, 0)
+
+	if timeline.TotalEvents != 7 || timeline.Untimed != 1 {
+		t.Errorf("Expected 7 events with 1 untimed, got %d and %d", timeline.TotalEvents, timeline.Untimed)
+	}
+
+	// The first three gets collapse; the fourth is outside the burst window
+	if len(timeline.Events) != 4 {
+		t.Fatalf("Expected 4 timeline events, got %d: %+v", len(timeline.Events), timeline.Events)
+	}
+	burst := timeline.Events[0]
+	if burst.Count != 3 || burst.Start != "2024-01-15T10:00:00Z" || burst.End != "2024-01-15T10:00:50Z" {
+		t.Errorf("Unexpected burst event: %+v", burst)
+	}
+	if timeline.Events[1].Count != 1 || timeline.Events[3].Verb != "delete" {
+		t.Errorf("Unexpected events: %+v", timeline.Events)
+	}
+
+	if len(timeline.Gaps) != 1 || timeline.Gaps[0].Duration != "1h54m50s" {
+		t.Fatalf("Expected one gap of 1h54m50s, got %+v", timeline.Gaps)
+	}
+
+	expected := []string{
+		"2024-01-15T10:00:00Z get pods in web (200) x3 until 2024-01-15T10:00:50Z",
+		"2024-01-15T10:05:00Z get pods in web (200)",
+		"2024-01-15T10:05:10Z create pods/exec web-1 in web (101)",
+		"-- no activity for 1h54m50s --",
+		"2024-01-15T12:00:00Z delete secrets db-pass in payments (403)",
+	}
+	if !reflect.DeepEqual(timeline.Narrative, expected) {
+		t.Errorf("Unexpected narrative:\n%v\nwant:\n%v", timeline.Narrative, expected)
+	}
+}
+
+func TestBuildTimeline_CustomWindows(t *testing.T) {
+	entries := []AuditLogEntry{
+		{Timestamp: "2024-01-15T10:00:00Z", Verb: "get", Resource: "pods"},
+		{Timestamp: "2024-01-15T10:03:00Z", Verb: "get", Resource: "pods"},
+		{Timestamp: "2024-01-15T10:20:00Z", Verb: "get", Resource: "pods"},
+	}
+
+	timeline := BuildTimeline("alice", entries, 5*time.Minute, 10*time.Minute)
+
+	if len(timeline.Events) != 2 || timeline.Events[0].Count != 2 {
+		t.Errorf("Expected a burst of 2 then a single event, got %+v", timeline.Events)
+	}
+	if len(timeline.Gaps) != 1 || timeline.Gaps[0].Duration != "17m0s" {
+		t.Errorf("Expected one 17m gap, got %+v", timeline.Gaps)
+	}
+}
@@ -0,0 +1,177 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// DefaultMaxTokenLifetime is the lifetime above which a token is long-lived
+const DefaultMaxTokenLifetime = 24 * time.Hour
+
+// serviceAccountTokenSecretType is the type of secrets holding a
+// non-expiring service account token
+const serviceAccountTokenSecretType = "kubernetes.io/service-account-token"
+
+// TokenResources are the resources audited for token creation: the
+// serviceaccounts/token subresource of the TokenRequest API and secrets
+var TokenResources = []string{"serviceaccounts", "secrets"}
+
+// isTokenCreation reports whether an entry may create a service account
+// token; secrets only do when their type is the token type
+func isTokenCreation(entry AuditLogEntry) bool {
+	if entry.Verb != "create" {
+		return false
+	}
+	return (entry.Resource == "serviceaccounts" && entry.Subresource == "token") ||
+		(entry.Resource == "secrets" && entry.Subresource == "")
+}
+
+// tokenLifetime returns the lifetime of a TokenRequest: the one granted, from
+// the expiration timestamp of the response, or the one requested
+func tokenLifetime(entry AuditLogEntry) (time.Duration, bool) {
+	if status, ok := entry.ResponseObject["status"].(map[string]interface{}); ok {
+		expiration, _ := status["expirationTimestamp"].(string)
+		expiresAt, err := time.Parse(time.RFC3339, expiration)
+		issuedAt, issuedErr := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err == nil && issuedErr == nil {
+			return expiresAt.Sub(issuedAt).Round(time.Second), true
+		}
+	}
+	for _, body := range []map[string]interface{}{entry.RequestObject, entry.ResponseObject} {
+		spec, _ := body["spec"].(map[string]interface{})
+		if seconds, ok := spec["expirationSeconds"].(float64); ok {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// tokenRequest converts a TokenRequest entry to a token creation
+func tokenRequest(entry AuditLogEntry, maxLifetime time.Duration) types.TokenCreation {
+	token := types.TokenCreation{Kind: types.TokenKindRequest, ServiceAccount: entry.Name, Lifetime: "unknown"}
+	if lifetime, ok := tokenLifetime(entry); ok {
+		token.ExpirationSeconds = int64(lifetime / time.Second)
+		token.Lifetime = lifetime.String()
+		token.LongLived = lifetime > maxLifetime
+	}
+	for _, body := range []map[string]interface{}{entry.RequestObject, entry.ResponseObject} {
+		spec, ok := body["spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		token.Audiences = stringValues(spec["audiences"])
+		if bound, ok := spec["boundObjectRef"].(map[string]interface{}); ok {
+			token.BoundObject = fmt.Sprintf("%v/%v", bound["kind"], bound["name"])
+		}
+		break
+	}
+	return token
+}
+
+// secretToken converts a secret creation to a token creation, and reports
+// whether the secret's body was logged and shows the token type
+func secretToken(entry AuditLogEntry) (types.TokenCreation, bool, bool) {
+	body := entry.RequestObject
+	if len(body) == 0 {
+		body = entry.ResponseObject
+	}
+	if len(body) == 0 {
+		return types.TokenCreation{}, false, false
+	}
+	if body["type"] != serviceAccountTokenSecretType {
+		return types.TokenCreation{}, true, false
+	}
+	metadata, _ := body["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	serviceAccount, _ := annotations["kubernetes.io/service-account.name"].(string)
+	return types.TokenCreation{Kind: types.TokenKindSecret, ServiceAccount: serviceAccount, SecretName: entry.Name, Lifetime: "never", LongLived: true}, true, true
+}
+
+// AnalyzeTokenCreation tracks the service account tokens created in entries,
+// in time order: TokenRequests, and secrets of the service account token
+// type, which never expire. Tokens living longer than maxLifetime are
+// long-lived, and flagged when a human user created them; zero selects
+// DefaultMaxTokenLifetime. Lifetimes and secret types are read from the
+// bodies, which needs entries parsed with objects from a Request or
+// RequestResponse audit level. Requests answered with an error are only
+// counted as failed.
+func AnalyzeTokenCreation(entries []AuditLogEntry, maxLifetime time.Duration) types.TokenCreationReport {
+	if maxLifetime <= 0 {
+		maxLifetime = DefaultMaxTokenLifetime
+	}
+	report := types.TokenCreationReport{MaxLifetime: maxLifetime.String(), Tokens: []types.TokenCreation{}}
+
+	for _, entry := range uniqueRequests(entries, isTokenCreation) {
+		var token types.TokenCreation
+		if entry.Resource == "serviceaccounts" {
+			token = tokenRequest(entry, maxLifetime)
+		} else {
+			secret, logged, ok := secretToken(entry)
+			if !logged {
+				report.SecretsWithoutBody++
+			}
+			if !ok {
+				continue
+			}
+			token = secret
+		}
+		if entry.StatusCode >= 400 {
+			report.Failed++
+			continue
+		}
+
+		token.Timestamp, token.Username, token.Human = entry.Timestamp, entry.Username, isHumanUser(entry.Username)
+		token.Namespace, token.SourceIPs, token.UserAgent, token.AuditID = entry.Namespace, entry.SourceIPs, entry.UserAgent, entry.AuditID
+		token.Flagged = token.Human && token.LongLived
+		if token.Kind == types.TokenKindSecret {
+			report.SecretTokens++
+		} else {
+			report.TokenRequests++
+		}
+		if token.LongLived {
+			report.LongLived++
+		}
+		if token.Flagged {
+			report.HumanLongLived++
+		}
+		if token.Lifetime == "unknown" {
+			report.UnknownLifetime++
+		}
+		report.Tokens = append(report.Tokens, token)
+	}
+	report.TotalTokens = len(report.Tokens)
+
+	report.Summary = summarizeTokenCreation(report)
+	return report
+}
+
+// summarizeTokenCreation produces a short human-readable description of a report
+func summarizeTokenCreation(report types.TokenCreationReport) string {
+	summary := fmt.Sprintf("%d service account tokens created (%d token requests, %d token secrets), %d living longer than %s",
+		report.TotalTokens, report.TokenRequests, report.SecretTokens, report.LongLived, report.MaxLifetime)
+	if report.UnknownLifetime > 0 {
+		summary += fmt.Sprintf(", %d of unknown lifetime", report.UnknownLifetime)
+	}
+	if report.SecretsWithoutBody > 0 {
+		summary += fmt.Sprintf("; %d secrets created without a logged body", report.SecretsWithoutBody)
+	}
+	if report.HumanLongLived == 0 {
+		return summary + "; no long-lived tokens created by human users"
+	}
+
+	var flagged []string
+	for _, token := range report.Tokens {
+		if !token.Flagged {
+			continue
+		}
+		if len(flagged) == 5 {
+			flagged = append(flagged, fmt.Sprintf("and %d more", report.HumanLongLived-5))
+			break
+		}
+		flagged = append(flagged, fmt.Sprintf("%s for %s/%s", token.Username, token.Namespace, token.ServiceAccount))
+	}
+	return summary + fmt.Sprintf("; %d long-lived tokens created by human users: %s", report.HumanLongLived, strings.Join(flagged, ", "))
+}
@@ -0,0 +1,63 @@
+package parsing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestAnalyzeTokenCreation tests token lifetimes, secret tokens and flagging human users
+func TestAnalyzeTokenCreation(t *testing.T) {
+	tokenRequest := func(auditID, username string, request, response map[string]interface{}) AuditLogEntry {
+		return AuditLogEntry{AuditID: auditID, Timestamp: "2026-03-01T12:00:00.000000Z", Username: username, Verb: "create", Resource: "serviceaccounts", Subresource: "token", Namespace: "ci", Name: "deployer", StatusCode: 201, RequestObject: request, ResponseObject: response}
+	}
+	expiring := func(seconds float64) map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"expirationSeconds": seconds, "audiences": []interface{}{"https://kubernetes.default.svc"}}}
+	}
+	secret := func(auditID, secretType string) AuditLogEntry {
+		var body map[string]interface{}
+		if secretType != "" {
+			body = map[string]interface{}{"type": secretType, "metadata": map[string]interface{}{"annotations": map[string]interface{}{"kubernetes.io/service-account.name": "deployer"}}}
+		}
+		return AuditLogEntry{AuditID: auditID, Timestamp: "2026-03-01T13:00:00.000000Z", Username: "bob", Verb: "create", Resource: "secrets", Namespace: "ci", Name: "deployer-token", StatusCode: 201, RequestObject: body}
+	}
+	entries := []AuditLogEntry{
+		tokenRequest("t1", "alice", expiring(3600), nil),
+		tokenRequest("t2", "alice", expiring(3600), map[string]interface{}{"status": map[string]interface{}{"expirationTimestamp": "2027-03-01T12:00:00Z"}}),
+		tokenRequest("t3", "system:serviceaccount:ci:pipeline", expiring(7*24*3600), nil),
+		tokenRequest("t4", "carol", nil, nil),
+		secret("s1", "kubernetes.io/service-account-token"),
+		secret("s2", "Opaque"),
+		secret("s3", ""),
+		{AuditID: "g1", Timestamp: "2026-03-01T14:00:00.000000Z", Username: "alice", Verb: "get", Resource: "secrets", Namespace: "ci", Name: "deployer-token", StatusCode: 200},
+	}
+
+	report := AnalyzeTokenCreation(entries, time.Hour)
+	if report.TotalTokens != 5 || report.TokenRequests != 4 || report.SecretTokens != 1 || report.SecretsWithoutBody != 1 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if report.LongLived != 3 || report.HumanLongLived != 2 || report.UnknownLifetime != 1 {
+		t.Errorf("Unexpected long-lived counts: %+v", report)
+	}
+
+	if short := report.Tokens[0]; short.Lifetime != "1h0m0s" || short.LongLived || short.Audiences[0] != "https://kubernetes.default.svc" {
+		t.Errorf("Unexpected short-lived token: %+v", short)
+	}
+	if granted := report.Tokens[1]; granted.ExpirationSeconds != 365*24*3600 || !granted.Flagged {
+		t.Errorf("Expected the granted lifetime from the response: %+v", granted)
+	}
+	if service := report.Tokens[2]; !service.LongLived || service.Human || service.Flagged {
+		t.Errorf("Expected a long-lived token of a service account not to be flagged: %+v", service)
+	}
+	if unknown := report.Tokens[3]; unknown.Lifetime != "unknown" || unknown.LongLived {
+		t.Errorf("Unexpected token without a body: %+v", unknown)
+	}
+	if secretToken := report.Tokens[4]; secretToken.Kind != types.TokenKindSecret || secretToken.Lifetime != "never" || secretToken.ServiceAccount != "deployer" || !secretToken.Flagged {
+		t.Errorf("Unexpected secret token: %+v", secretToken)
+	}
+	if !strings.Contains(report.Summary, "2 long-lived tokens created by human users: alice for ci/deployer, bob for ci/deployer") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+}
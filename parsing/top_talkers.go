@@ -0,0 +1,238 @@
+package parsing
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// Default top talker settings
+const (
+	DefaultTopTalkersLimit = 10
+	DefaultNoisyShare      = 0.05
+)
+
+// talker accumulates the events of a user or user agent product
+type talker struct {
+	types.TopTalker
+	// users counts the events of each user, for user agents
+	users map[string]int
+	// versioned and unversioned are set when the agent strings had and had
+	// not a "/" after the product
+	versioned, unversioned bool
+}
+
+// userAgentProduct returns the product of a user agent, the part before the
+// first "/", e.g. "kube-controller-manager" for
+// "kube-controller-manager/v1.28.3 (linux/amd64) kubernetes/a1b2c3d", and
+// whether the agent had a "/"
+func userAgentProduct(agent string) (string, bool) {
+	if i := strings.Index(agent, "/"); i >= 0 {
+		return agent[:i], true
+	}
+	return agent, false
+}
+
+// userAgentExclusion returns the exclude pattern matching the events of a user
+// agent product. Exclude patterns match the raw event, where the agent is the
+// userAgent field.
+func userAgentExclusion(agent *talker) string {
+	pattern := `"userAgent":"` + agent.Name
+	switch {
+	case agent.versioned && !agent.unversioned:
+		pattern += "/"
+	case agent.unversioned && !agent.versioned:
+		pattern += `"`
+	}
+	return pattern
+}
+
+// usernameGroup returns the prefix shared by the users of a group, such as
+// "system:serviceaccount:<namespace>:" or "system:node:", or "" when the
+// username is not part of one
+func usernameGroup(username string) string {
+	i := strings.LastIndex(username, ":")
+	if !strings.HasPrefix(username, "system:") || strings.Count(username, ":") < 2 {
+		return ""
+	}
+	return username[:i+1]
+}
+
+// roundShare rounds a share of the events to four decimals
+func roundShare(events, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(events)/float64(total)*10000) / 10000
+}
+
+// AnalyzeTopTalkers ranks the users and user agent products of entries by
+// event volume, keeping the limit largest of each, and suggests exclusions for
+// the controllers, users that are not human, with at least minShare of the
+// events. Users of a service account namespace or the nodes are excluded with
+// a prefix wildcard when two or more of them are noisy. A user agent is
+// suggested when it is only used by controllers and its events not already
+// dropped by the suggested users still reach minShare. Every event counts,
+// since volume is what drowns out the events under investigation. Zero
+// arguments select the defaults.
+func AnalyzeTopTalkers(entries []AuditLogEntry, limit int, minShare float64) types.TopTalkersReport {
+	if limit <= 0 {
+		limit = DefaultTopTalkersLimit
+	}
+	if minShare <= 0 {
+		minShare = DefaultNoisyShare
+	}
+	report := types.TopTalkersReport{
+		TotalEvents:         len(entries),
+		MinShare:            minShare,
+		Users:               []types.TopTalker{},
+		UserAgents:          []types.TopTalker{},
+		SuggestedExclusions: types.ExclusionSuggestion{ExcludeUsers: []string{}, Exclude: []string{}},
+	}
+
+	users := make(map[string]*talker)
+	agents := make(map[string]*talker)
+	for _, entry := range entries {
+		user := users[entry.Username]
+		if user == nil {
+			user = &talker{TopTalker: types.TopTalker{Name: entry.Username, Human: isHumanUser(entry.Username), Verbs: make(map[string]int)}}
+			users[entry.Username] = user
+		}
+		user.Events++
+		user.Verbs[entry.Verb]++
+
+		if entry.UserAgent == "" {
+			continue
+		}
+		product, versioned := userAgentProduct(entry.UserAgent)
+		agent := agents[product]
+		if agent == nil {
+			agent = &talker{TopTalker: types.TopTalker{Name: product, Verbs: make(map[string]int)}, users: make(map[string]int)}
+			agents[product] = agent
+		}
+		agent.Events++
+		agent.Verbs[entry.Verb]++
+		agent.users[entry.Username]++
+		agent.Human = agent.Human || user.Human
+		agent.versioned = agent.versioned || versioned
+		agent.unversioned = agent.unversioned || !versioned
+	}
+	rankedUsers, rankedAgents := rankTalkers(users), rankTalkers(agents)
+	noisy := func(events int) bool { return float64(events) >= minShare*float64(report.TotalEvents) }
+
+	// Suggest the noisy controllers, grouping those sharing a prefix
+	groups := make(map[string][]*talker)
+	var groupOrder []string
+	for _, user := range rankedUsers {
+		if user.Human || user.Name == "" || !noisy(user.Events) {
+			continue
+		}
+		group := usernameGroup(user.Name)
+		if group == "" {
+			group = user.Name
+		}
+		if groups[group] == nil {
+			groupOrder = append(groupOrder, group)
+		}
+		groups[group] = append(groups[group], user)
+	}
+	for _, group := range groupOrder {
+		exclusion := groups[group][0].Name
+		if len(groups[group]) > 1 {
+			exclusion = group + "*"
+		}
+		report.SuggestedExclusions.ExcludeUsers = append(report.SuggestedExclusions.ExcludeUsers, exclusion)
+		for _, user := range groups[group] {
+			user.Suggested = true
+		}
+	}
+	var excludedUsers *regexp.Regexp
+	if len(report.SuggestedExclusions.ExcludeUsers) > 0 {
+		excludedUsers = regexp.MustCompile(commands.ExclusionRegex(report.SuggestedExclusions.ExcludeUsers))
+	}
+	excluded := func(username string) bool { return excludedUsers != nil && excludedUsers.MatchString(username) }
+
+	for _, agent := range rankedAgents {
+		if agent.Human || !noisy(agent.Events) {
+			continue
+		}
+		remaining := 0
+		for username, events := range agent.users {
+			if !excluded(username) {
+				remaining += events
+			}
+		}
+		if noisy(remaining) {
+			agent.Suggested = true
+			report.SuggestedExclusions.Exclude = append(report.SuggestedExclusions.Exclude, userAgentExclusion(agent))
+		}
+	}
+
+	for _, entry := range entries {
+		product, _ := userAgentProduct(entry.UserAgent)
+		if excluded(entry.Username) || (entry.UserAgent != "" && agents[product].Suggested) {
+			report.SuggestedExclusions.DroppedEvents++
+		}
+	}
+	report.SuggestedExclusions.DroppedShare = roundShare(report.SuggestedExclusions.DroppedEvents, report.TotalEvents)
+
+	for i, user := range rankedUsers {
+		if i == limit {
+			break
+		}
+		user.Share = roundShare(user.Events, report.TotalEvents)
+		report.Users = append(report.Users, user.TopTalker)
+	}
+	for i, agent := range rankedAgents {
+		if i == limit {
+			break
+		}
+		agent.Share = roundShare(agent.Events, report.TotalEvents)
+		report.UserAgents = append(report.UserAgents, agent.TopTalker)
+	}
+
+	report.Summary = summarizeTopTalkers(report)
+	return report
+}
+
+// rankTalkers orders talkers by event count, then name
+func rankTalkers(talkers map[string]*talker) []*talker {
+	ranked := make([]*talker, 0, len(talkers))
+	for _, t := range talkers {
+		ranked = append(ranked, t)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Events != ranked[j].Events {
+			return ranked[i].Events > ranked[j].Events
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
+
+// summarizeTopTalkers produces a short human-readable description of a report
+func summarizeTopTalkers(report types.TopTalkersReport) string {
+	var top []string
+	for i, user := range report.Users {
+		if i == 3 {
+			break
+		}
+		top = append(top, fmt.Sprintf("%s (%.1f%%)", user.Name, user.Share*100))
+	}
+	summary := fmt.Sprintf("%d events", report.TotalEvents)
+	if len(top) > 0 {
+		summary += ", top users: " + strings.Join(top, ", ")
+	}
+
+	suggestions := report.SuggestedExclusions
+	if len(suggestions.ExcludeUsers) == 0 && len(suggestions.Exclude) == 0 {
+		return summary + fmt.Sprintf("; no controllers with %.1f%% or more of the events", report.MinShare*100)
+	}
+	return summary + fmt.Sprintf("; %d suggested user and %d user agent exclusions would drop %d events (%.1f%%)",
+		len(suggestions.ExcludeUsers), len(suggestions.Exclude), suggestions.DroppedEvents, suggestions.DroppedShare*100)
+}
@@ -0,0 +1,63 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAnalyzeTopTalkers tests ranking, grouping service accounts and user agent suggestions
+func TestAnalyzeTopTalkers(t *testing.T) {
+	var entries []AuditLogEntry
+	add := func(count int, username, userAgent string) {
+		for i := 0; i < count; i++ {
+			entries = append(entries, AuditLogEntry{Username: username, UserAgent: userAgent, Verb: "watch"})
+		}
+	}
+	add(30, "system:serviceaccount:openshift-monitoring:prometheus-k8s", "Prometheus/2.46.0")
+	add(20, "system:serviceaccount:openshift-monitoring:node-exporter", "node_exporter/1.6.1")
+	add(15, "system:node:worker-0", "kubelet/v1.28.3 (linux/amd64) kubernetes/a1b2c3d")
+	add(10, "system:node:worker-1", "kubelet/v1.28.3 (linux/amd64) kubernetes/a1b2c3d")
+	add(10, "system:apiserver", "kube-apiserver")
+	add(9, "alice", "oc/4.14.0 (linux/amd64) kubernetes/a1b2c3d")
+	add(2, "system:kube-scheduler", "kube-apiserver")
+	add(2, "system:kube-proxy", "kube-apiserver")
+	add(2, "bob", "")
+
+	report := AnalyzeTopTalkers(entries, 3, 0.1)
+	if report.TotalEvents != 100 {
+		t.Fatalf("Expected 100 events, got %d", report.TotalEvents)
+	}
+	if len(report.Users) != 3 || report.Users[0].Share != 0.3 || len(report.UserAgents) != 3 {
+		t.Errorf("Unexpected ranking: %+v %+v", report.Users, report.UserAgents)
+	}
+	if report.UserAgents[0].Name != "Prometheus" || report.UserAgents[1].Name != "kubelet" {
+		t.Errorf("Expected user agents ranked by product: %+v", report.UserAgents)
+	}
+
+	expectedUsers := []string{"system:serviceaccount:openshift-monitoring:*", "system:node:*", "system:apiserver"}
+	if !reflect.DeepEqual(report.SuggestedExclusions.ExcludeUsers, expectedUsers) {
+		t.Errorf("Expected user exclusions %v, got %v", expectedUsers, report.SuggestedExclusions.ExcludeUsers)
+	}
+	// kube-apiserver only has 4 events not dropped by the user exclusions,
+	// and oc is used by a human
+	if len(report.SuggestedExclusions.Exclude) != 0 {
+		t.Errorf("Expected no user agent exclusions, got %v", report.SuggestedExclusions.Exclude)
+	}
+	if report.SuggestedExclusions.DroppedEvents != 85 || report.SuggestedExclusions.DroppedShare != 0.85 {
+		t.Errorf("Unexpected dropped events: %+v", report.SuggestedExclusions)
+	}
+
+	// With a lower share, the agent is suggested by its product
+	report = AnalyzeTopTalkers(entries, 0, 0.03)
+	if !reflect.DeepEqual(report.SuggestedExclusions.Exclude, []string{`"userAgent":"kube-apiserver"`}) {
+		t.Errorf("Expected the unversioned agent to be excluded, got %v", report.SuggestedExclusions.Exclude)
+	}
+	if !strings.Contains(report.Summary, "would drop 89 events (89.0%)") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+
+	if report := AnalyzeTopTalkers(entries[85:94], 0, 0); len(report.SuggestedExclusions.ExcludeUsers) != 0 || !strings.Contains(report.Summary, "no controllers") {
+		t.Errorf("Expected no suggestions for human users: %+v", report)
+	}
+}
@@ -0,0 +1,161 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// WebhookResources are the resources whose changes can add, remove or
+// redirect webhooks: the admission webhook configurations and custom resource
+// definitions, which may convert their objects through a webhook
+var WebhookResources = []string{"validatingwebhookconfigurations", "mutatingwebhookconfigurations", "customresourcedefinitions"}
+
+// WebhookChangeVerbs are the verbs that change webhook configurations
+var WebhookChangeVerbs = []string{"create", "update", "patch", "delete"}
+
+// isWebhookChange reports whether an entry changes a webhook configuration or
+// CRD. Subresource requests such as CRD status updates are made by the API
+// server itself.
+func isWebhookChange(entry AuditLogEntry) bool {
+	if entry.Subresource != "" || entry.Name == "" {
+		return false
+	}
+	resource, verb := false, false
+	for _, candidate := range WebhookResources {
+		resource = resource || entry.Resource == candidate
+	}
+	for _, candidate := range WebhookChangeVerbs {
+		verb = verb || entry.Verb == candidate
+	}
+	return resource && verb
+}
+
+// webhookNames returns the names of the webhooks of a webhook configuration
+func webhookNames(object map[string]interface{}) []string {
+	webhooks, _ := object["webhooks"].([]interface{})
+	var names []string
+	for _, webhook := range webhooks {
+		fields, _ := webhook.(map[string]interface{})
+		if name, ok := fields["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// conversionWebhook returns the service or URL a CRD converts its objects
+// through, or "" when it does not use a conversion webhook
+func conversionWebhook(object map[string]interface{}) string {
+	spec, _ := object["spec"].(map[string]interface{})
+	conversion, _ := spec["conversion"].(map[string]interface{})
+	if conversion["strategy"] != "Webhook" {
+		return ""
+	}
+	webhook, _ := conversion["webhook"].(map[string]interface{})
+	clientConfig, _ := webhook["clientConfig"].(map[string]interface{})
+	// Before apiextensions.k8s.io/v1 the client config was in the conversion itself
+	if clientConfig == nil {
+		clientConfig, _ = conversion["webhookClientConfig"].(map[string]interface{})
+	}
+	if url, ok := clientConfig["url"].(string); ok {
+		return url
+	}
+	if service, ok := clientConfig["service"].(map[string]interface{}); ok {
+		return fmt.Sprintf("service %v/%v", service["namespace"], service["name"])
+	}
+	return "webhook"
+}
+
+// AnalyzeWebhookChanges builds the change log of the webhook configurations
+// and CRDs changed in entries, in time order. Each change is diffed with the
+// object's previous version in entries when both were logged with their
+// bodies, which needs entries parsed with objects from a RequestResponse
+// audit level; patches are reported with their body otherwise. The events of
+// one request count once, preferring the ResponseComplete stage. Requests
+// answered with an error changed nothing and are only counted as failed.
+func AnalyzeWebhookChanges(entries []AuditLogEntry) types.WebhookChangeReport {
+	report := types.WebhookChangeReport{ByResource: make(map[string]int), Changes: []types.WebhookChange{}}
+
+	changes := uniqueRequests(entries, isWebhookChange)
+
+	versions := make(map[string]map[string]interface{})
+	for _, entry := range changes {
+		if entry.StatusCode >= 400 {
+			report.Failed++
+			continue
+		}
+
+		change := types.WebhookChange{
+			Timestamp: entry.Timestamp,
+			Username:  entry.Username,
+			Verb:      entry.Verb,
+			Resource:  entry.Resource,
+			Name:      entry.Name,
+			SourceIPs: entry.SourceIPs,
+			UserAgent: entry.UserAgent,
+			AuditID:   entry.AuditID,
+		}
+		key := objectKey(entry)
+		previous := versions[key]
+		object := objectAfterChange(entry)
+		switch {
+		case object != nil:
+			change.BodyLogged = true
+			if entry.Resource == "customresourcedefinitions" {
+				change.ConversionWebhook = conversionWebhook(object)
+			} else {
+				change.Webhooks = webhookNames(object)
+			}
+			if previous != nil {
+				change.Diff = DiffObjects(previous, object)
+			}
+			versions[key] = object
+		case entry.Verb == "patch" && len(entry.RequestObject) > 0:
+			change.BodyLogged = true
+			change.Patch = entry.RequestObject
+			// The patched object is unknown, so the next change cannot be diffed
+			delete(versions, key)
+		default:
+			delete(versions, key)
+		}
+
+		report.Changes = append(report.Changes, change)
+		report.ByResource[entry.Resource]++
+	}
+	report.TotalChanges = len(report.Changes)
+
+	report.Summary = summarizeWebhookChanges(report)
+	return report
+}
+
+// summarizeWebhookChanges produces a short human-readable description of a report
+func summarizeWebhookChanges(report types.WebhookChangeReport) string {
+	summary := fmt.Sprintf("%d webhook configuration changes", report.TotalChanges)
+	if report.Failed > 0 {
+		summary += fmt.Sprintf(", %d failed", report.Failed)
+	}
+	if report.TotalChanges == 0 {
+		return summary
+	}
+
+	var resources []string
+	for _, resource := range WebhookResources {
+		if count := report.ByResource[resource]; count > 0 {
+			resources = append(resources, fmt.Sprintf("%s %d", resource, count))
+		}
+	}
+	users := make(map[string]bool)
+	var order []string
+	for _, change := range report.Changes {
+		if !users[change.Username] {
+			users[change.Username] = true
+			order = append(order, change.Username)
+		}
+	}
+	if len(order) > 5 {
+		order = append(order[:5], fmt.Sprintf("and %d more", len(order)-5))
+	}
+	return summary + "; " + strings.Join(resources, ", ") + "; by " + strings.Join(order, ", ")
+}
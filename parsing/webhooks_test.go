@@ -0,0 +1,102 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestDiffObjects tests diffing nested objects while ignoring server-maintained fields
+func TestDiffObjects(t *testing.T) {
+	old := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "hook", "resourceVersion": "1", "labels": map[string]interface{}{"team": "a"}},
+		"webhooks": []interface{}{
+			map[string]interface{}{"name": "a.example.com", "failurePolicy": "Fail", "clientConfig": map[string]interface{}{"caBundle": strings.Repeat("A", 200)}},
+		},
+	}
+	new := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "hook", "resourceVersion": "2", "labels": map[string]interface{}{}},
+		"webhooks": []interface{}{
+			map[string]interface{}{"name": "a.example.com", "failurePolicy": "Ignore", "clientConfig": map[string]interface{}{"caBundle": strings.Repeat("B", 200)}},
+		},
+		"status": map[string]interface{}{"observed": true},
+	}
+
+	changes := DiffObjects(old, new)
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.Path
+	}
+	expected := []string{"metadata.labels", "metadata.labels.team", "webhooks[0].clientConfig.caBundle", "webhooks[0].failurePolicy"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected paths %v, got %v", expected, paths)
+	}
+	if changes[1].Old != "a" || changes[1].New != nil {
+		t.Errorf("Expected a removed label: %+v", changes[1])
+	}
+	if old, _ := changes[2].Old.(string); !strings.HasPrefix(old, "sha256:") || !strings.HasSuffix(old, "(200 bytes)") {
+		t.Errorf("Expected a digest of the long value: %+v", changes[2])
+	}
+	if changes[3].Old != "Fail" || changes[3].New != "Ignore" {
+		t.Errorf("Unexpected failure policy change: %+v", changes[3])
+	}
+	if len(DiffObjects(old, old)) != 0 {
+		t.Error("Expected no changes between identical objects")
+	}
+}
+
+// TestAnalyzeWebhookChanges tests tracking webhook changes and diffing consecutive versions
+func TestAnalyzeWebhookChanges(t *testing.T) {
+	change := func(auditID, stage, verb, resource, name, timestamp string, status int, request, response map[string]interface{}) AuditLogEntry {
+		return AuditLogEntry{AuditID: auditID, Stage: stage, Timestamp: timestamp, Username: "mallory", Verb: verb, Resource: resource, Name: name, StatusCode: status, RequestObject: request, ResponseObject: response}
+	}
+	webhook := func(url string) map[string]interface{} {
+		return map[string]interface{}{"webhooks": []interface{}{map[string]interface{}{"name": "hook.example.com", "clientConfig": map[string]interface{}{"url": url}}}}
+	}
+	crd := map[string]interface{}{"spec": map[string]interface{}{"conversion": map[string]interface{}{
+		"strategy": "Webhook",
+		"webhook":  map[string]interface{}{"clientConfig": map[string]interface{}{"service": map[string]interface{}{"namespace": "evil", "name": "converter"}}},
+	}}}
+	entries := []AuditLogEntry{
+		change("a1", "ResponseComplete", "create", "mutatingwebhookconfigurations", "hook", "2026-03-01T12:00:00.000000Z", 201, webhook("https://good.example.com"), nil),
+		change("a2", "RequestReceived", "update", "mutatingwebhookconfigurations", "hook", "2026-03-01T12:01:00.000000Z", 0, nil, nil),
+		change("a2", "ResponseComplete", "update", "mutatingwebhookconfigurations", "hook", "2026-03-01T12:01:00.000000Z", 200, nil, webhook("https://evil.example.com")),
+		change("a3", "ResponseComplete", "patch", "customresourcedefinitions", "widgets.example.com", "2026-03-01T12:02:00.000000Z", 200, nil, crd),
+		change("a4", "ResponseComplete", "patch", "validatingwebhookconfigurations", "policy", "2026-03-01T12:03:00.000000Z", 200, map[string]interface{}{"webhooks": nil}, nil),
+		change("a5", "ResponseComplete", "delete", "validatingwebhookconfigurations", "other", "2026-03-01T12:04:00.000000Z", 403, nil, nil),
+		change("a6", "ResponseComplete", "update", "customresourcedefinitions", "widgets.example.com", "2026-03-01T12:05:00.000000Z", 200, nil, nil),
+		{Timestamp: "2026-03-01T12:06:00.000000Z", Verb: "update", Resource: "customresourcedefinitions", Subresource: "status", Name: "widgets.example.com", StatusCode: 200},
+		{Timestamp: "2026-03-01T12:07:00.000000Z", Verb: "get", Resource: "mutatingwebhookconfigurations", Name: "hook", StatusCode: 200},
+	}
+
+	report := AnalyzeWebhookChanges(entries)
+	if report.TotalChanges != 5 || report.Failed != 1 {
+		t.Fatalf("Unexpected totals: %+v", report)
+	}
+	if report.ByResource["mutatingwebhookconfigurations"] != 2 || report.ByResource["customresourcedefinitions"] != 2 {
+		t.Errorf("Unexpected counts by resource: %+v", report.ByResource)
+	}
+
+	created, updated := report.Changes[0], report.Changes[1]
+	if !created.BodyLogged || len(created.Diff) != 0 || !reflect.DeepEqual(created.Webhooks, []string{"hook.example.com"}) {
+		t.Errorf("Unexpected creation: %+v", created)
+	}
+	expected := []types.FieldChange{{Path: "webhooks[0].clientConfig.url", Old: "https://good.example.com", New: "https://evil.example.com"}}
+	if !reflect.DeepEqual(updated.Diff, expected) {
+		t.Errorf("Expected the URL change, got %+v", updated.Diff)
+	}
+	if conversion := report.Changes[2]; conversion.ConversionWebhook != "service evil/converter" {
+		t.Errorf("Expected the conversion webhook, got %+v", conversion)
+	}
+	if patch := report.Changes[3]; patch.Patch == nil || patch.Diff != nil {
+		t.Errorf("Expected the patch body, got %+v", patch)
+	}
+	if unlogged := report.Changes[4]; unlogged.BodyLogged || unlogged.Diff != nil {
+		t.Errorf("Expected a change without a body, got %+v", unlogged)
+	}
+	if !strings.Contains(report.Summary, "5 webhook configuration changes, 1 failed") {
+		t.Errorf("Unexpected summary: %s", report.Summary)
+	}
+}
@@ -0,0 +1,134 @@
+package preflight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// ocInstallURL is where the OpenShift CLI is downloaded from
+const ocInstallURL = "https://docs.openshift.com/container-platform/latest/cli_reference/openshift_cli/getting-started-cli.html"
+
+// DevelopmentChecks returns the checks of a source checkout in dir: the Go
+// toolchain, the module and its dependencies, and the .env file. With
+// createEnvFile, a missing .env is created from env.example.
+func DevelopmentChecks(dir string, createEnvFile bool) []Check {
+	return []Check{
+		{
+			Name:        "go_toolchain",
+			Description: "The Go toolchain is installed",
+			Run: func() types.PreflightCheck {
+				output, err := runCommand(dir, "go", "version")
+				if err != nil {
+					return Fail(err.Error(), "Install Go from https://go.dev/dl/")
+				}
+				return Pass(firstLine(output))
+			},
+		},
+		{
+			Name:        "go_module",
+			Description: "The directory is the server's Go module",
+			Run: func() types.PreflightCheck {
+				if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+					return Fail("go.mod not found", "Run the command from the audit-query-mcp-server directory")
+				}
+				return Pass("go.mod found")
+			},
+		},
+		{
+			Name:        "go_dependencies",
+			Description: "The module's dependencies are downloaded and unmodified",
+			Requires:    []string{"go_toolchain", "go_module"},
+			Run: func() types.PreflightCheck {
+				if _, err := runCommand(dir, "go", "mod", "verify"); err != nil {
+					return Fail(err.Error(), "Run go mod download, or go mod tidy when go.mod changed")
+				}
+				return Pass("all modules verified")
+			},
+		},
+		{
+			Name:        "env_file",
+			Description: "A .env file configures the server",
+			Run: func() types.PreflightCheck {
+				return checkEnvFile(dir, createEnvFile)
+			},
+		},
+	}
+}
+
+// checkEnvFile reports whether dir has a .env file, creating it from
+// env.example when create is set
+func checkEnvFile(dir string, create bool) types.PreflightCheck {
+	envFile := filepath.Join(dir, ".env")
+	if _, err := os.Stat(envFile); err == nil {
+		return Pass(".env found")
+	}
+	template, err := os.ReadFile(filepath.Join(dir, "env.example"))
+	if err != nil {
+		return Warn(".env and env.example not found", "Set the configuration in environment variables, or restore env.example from the repository and copy it to .env")
+	}
+	if !create {
+		return Warn(".env not found", "Copy env.example to .env and adjust it")
+	}
+	if err := os.WriteFile(envFile, template, 0644); err != nil {
+		return Fail(fmt.Sprintf("failed to create .env: %v", err), "Copy env.example to .env and adjust it")
+	}
+	return Warn("created .env from env.example", "Edit .env and add your OpenAI API key and backend settings")
+}
+
+// OcChecks returns the checks of the OpenShift CLI: that it is installed, is
+// logged in and reaches the cluster. oc runs oc with the arguments.
+func OcChecks(oc func(args []string) (string, error)) []Check {
+	return []Check{
+		{
+			Name:        "oc_cli",
+			Description: "The OpenShift CLI (oc) is installed",
+			Run: func() types.PreflightCheck {
+				output, err := oc(commands.ClientVersionArgs())
+				if err != nil {
+					if errors.Is(err, os.ErrNotExist) || strings.Contains(err.Error(), "executable file not found") {
+						return Fail("oc is not installed", "Install the OpenShift CLI from "+ocInstallURL)
+					}
+					return Fail(err.Error(), "Reinstall the OpenShift CLI from "+ocInstallURL)
+				}
+				return Pass(firstLine(output))
+			},
+		},
+		{
+			Name:        "oc_login",
+			Description: "oc is logged in to a cluster",
+			Requires:    []string{"oc_cli"},
+			Run: func() types.PreflightCheck {
+				output, err := oc(commands.WhoAmIArgs())
+				if err != nil {
+					return Fail("not logged in to an OpenShift cluster: "+err.Error(), "Run oc login <cluster-url>")
+				}
+				return Pass("logged in as " + strings.TrimSpace(output))
+			},
+		},
+		{
+			Name:        "cluster_access",
+			Description: "The cluster's nodes can be listed",
+			Requires:    []string{"oc_login"},
+			Run: func() types.PreflightCheck {
+				output, err := oc(commands.NodesArgs())
+				if err != nil {
+					return Fail("cannot list the cluster's nodes: "+err.Error(), "Check your permissions and the cluster's status")
+				}
+				var nodes struct {
+					Items []json.RawMessage `json:"items"`
+				}
+				if err := json.Unmarshal([]byte(output), &nodes); err != nil {
+					return Fail("unexpected oc get nodes output: "+err.Error(), "Check that oc and the cluster versions match")
+				}
+				return Pass(fmt.Sprintf("%d nodes", len(nodes.Items)))
+			},
+		},
+	}
+}
@@ -0,0 +1,120 @@
+// Package preflight checks the environment the server runs in and reports
+// each check's status with how to fix it, for the setup command and the
+// run_preflight tool.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// CommandTimeout bounds each command a check runs
+const CommandTimeout = 30 * time.Second
+
+// Check is a named check of the environment
+type Check struct {
+	Name        string
+	Description string
+	// Requires names earlier checks that must pass or warn for this one to
+	// run; it is skipped otherwise
+	Requires []string
+	// Run returns the check's status, detail and remediation
+	Run func() types.PreflightCheck
+}
+
+// Run runs the checks in order and returns their report
+func Run(checks []Check) types.PreflightReport {
+	report := types.PreflightReport{
+		Status:    types.PreflightPass,
+		Checks:    make([]types.PreflightCheck, 0, len(checks)),
+		CheckedAt: time.Now().Format(time.RFC3339),
+	}
+	statuses := make(map[string]types.PreflightStatus, len(checks))
+	counts := make(map[types.PreflightStatus]int)
+	for _, check := range checks {
+		var result types.PreflightCheck
+		if blocker := failedRequirement(check, statuses); blocker != "" {
+			result = types.PreflightCheck{Status: types.PreflightSkip, Detail: fmt.Sprintf("not run: %s did not pass", blocker)}
+		} else {
+			start := time.Now()
+			result = check.Run()
+			result.DurationMs = time.Since(start).Milliseconds()
+		}
+		result.Name = check.Name
+		result.Description = check.Description
+		statuses[check.Name] = result.Status
+		counts[result.Status]++
+		report.Checks = append(report.Checks, result)
+	}
+
+	switch {
+	case counts[types.PreflightFail] > 0:
+		report.Status = types.PreflightFail
+	case counts[types.PreflightWarn] > 0:
+		report.Status = types.PreflightWarn
+	}
+	report.Summary = fmt.Sprintf("%d passed, %d warned, %d failed, %d skipped",
+		counts[types.PreflightPass], counts[types.PreflightWarn], counts[types.PreflightFail], counts[types.PreflightSkip])
+	return report
+}
+
+// failedRequirement returns the first required check that neither passed
+// nor warned, or "" when the check can run
+func failedRequirement(check Check, statuses map[string]types.PreflightStatus) string {
+	for _, name := range check.Requires {
+		if status := statuses[name]; status != types.PreflightPass && status != types.PreflightWarn {
+			return name
+		}
+	}
+	return ""
+}
+
+// Pass returns the outcome of a passed check
+func Pass(detail string) types.PreflightCheck {
+	return types.PreflightCheck{Status: types.PreflightPass, Detail: detail}
+}
+
+// Warn returns the outcome of a check that found a problem the server can
+// run with
+func Warn(detail, remediation string) types.PreflightCheck {
+	return types.PreflightCheck{Status: types.PreflightWarn, Detail: detail, Remediation: remediation}
+}
+
+// Fail returns the outcome of a check that found a problem the server
+// cannot run with
+func Fail(detail, remediation string) types.PreflightCheck {
+	return types.PreflightCheck{Status: types.PreflightFail, Detail: detail, Remediation: remediation}
+}
+
+// runCommand runs a command in dir, the working directory when empty, and
+// returns its output; a failed command's error includes the output
+func runCommand(dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), fmt.Errorf("%s %s timed out after %s", name, strings.Join(args, " "), CommandTimeout)
+	}
+	if err != nil {
+		return string(output), fmt.Errorf("%w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// firstLine returns the first non-empty line of command output
+func firstLine(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestRun tests the report status, summary and skipping checks whose requirements failed
+func TestRun(t *testing.T) {
+	report := Run([]Check{
+		{Name: "a", Run: func() types.PreflightCheck { return Pass("ok") }},
+		{Name: "b", Requires: []string{"a"}, Run: func() types.PreflightCheck { return Warn("odd", "fix b") }},
+		{Name: "c", Requires: []string{"b"}, Run: func() types.PreflightCheck { return Fail("broken", "fix c") }},
+		{Name: "d", Requires: []string{"c"}, Run: func() types.PreflightCheck {
+			t.Error("Expected d to be skipped")
+			return Pass("")
+		}},
+	})
+	if report.Status != types.PreflightFail || report.Summary != "1 passed, 1 warned, 1 failed, 1 skipped" {
+		t.Errorf("Unexpected status %s or summary %q", report.Status, report.Summary)
+	}
+	if skipped := report.Checks[3]; skipped.Name != "d" || skipped.Status != types.PreflightSkip || skipped.Detail != "not run: c did not pass" {
+		t.Errorf("Unexpected skipped check: %+v", skipped)
+	}
+
+	report = Run([]Check{{Name: "a", Run: func() types.PreflightCheck { return Warn("odd", "fix a") }}})
+	if report.Status != types.PreflightWarn || report.Checks[0].Remediation != "fix a" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+// TestDevelopmentChecks tests the module and .env checks of a directory without a module
+func TestDevelopmentChecks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "env.example"), []byte("AUDIT_PROVIDER=mock\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Run(DevelopmentChecks(dir, false))
+	statuses := make(map[string]types.PreflightStatus)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	if statuses["go_module"] != types.PreflightFail || statuses["go_dependencies"] != types.PreflightSkip || statuses["env_file"] != types.PreflightWarn {
+		t.Errorf("Unexpected statuses: %v", statuses)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".env")); !os.IsNotExist(err) {
+		t.Error("Expected no .env without createEnvFile")
+	}
+
+	if check := checkEnvFile(dir, true); check.Status != types.PreflightWarn || check.Detail != "created .env from env.example" {
+		t.Errorf("Unexpected check: %+v", check)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, ".env")); err != nil || string(data) != "AUDIT_PROVIDER=mock\n" {
+		t.Errorf("Expected .env copied from env.example, got %q, %v", data, err)
+	}
+	if check := checkEnvFile(dir, true); check.Status != types.PreflightPass {
+		t.Errorf("Expected the created .env to pass, got %+v", check)
+	}
+}
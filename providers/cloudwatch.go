@@ -0,0 +1,388 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// CloudWatch provider defaults
+const (
+	// DefaultCloudWatchPageSize is the most rows one Logs Insights query returns
+	DefaultCloudWatchPageSize  = 10000
+	DefaultCloudWatchMaxEvents = 50000
+	DefaultCloudWatchPoll      = time.Second
+	// DefaultCloudWatchQueryTimeout bounds fetching the events of one query,
+	// which can take several Logs Insights queries
+	DefaultCloudWatchQueryTimeout = 5 * time.Minute
+	// maxCloudWatchResponseBytes bounds the size of an API response
+	maxCloudWatchResponseBytes = 256 * 1024 * 1024
+)
+
+// regionPattern matches AWS region names
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// logGroupPattern matches CloudWatch log group names
+var logGroupPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-/.#]{1,512}$`)
+
+// CloudWatchConfig configures the CloudWatch Logs provider
+type CloudWatchConfig struct {
+	Region string
+	// LogGroups are the log groups holding the audit logs, e.g. <cluster>.audit
+	LogGroups []string
+	// Endpoint overrides the regional CloudWatch Logs endpoint
+	Endpoint    string
+	Credentials AWSCredentials
+	// PageSize is the limit of each Logs Insights query and MaxEvents the most
+	// events a query returns
+	PageSize  int
+	MaxEvents int
+	// PollInterval is how often a running query's results are checked
+	PollInterval time.Duration
+	// QueryTimeout bounds a fetch and Timeout each API request
+	QueryTimeout time.Duration
+	Timeout      time.Duration
+}
+
+// CloudWatchConfigFromEnv reads the CloudWatch provider configuration from
+// AUDIT_CLOUDWATCH_LOG_GROUPS, AUDIT_CLOUDWATCH_REGION (or AWS_REGION),
+// AUDIT_CLOUDWATCH_ENDPOINT, AUDIT_CLOUDWATCH_MAX_EVENTS and
+// AUDIT_CLOUDWATCH_QUERY_TIMEOUT, with credentials from the standard AWS variables
+func CloudWatchConfigFromEnv() (CloudWatchConfig, error) {
+	config := CloudWatchConfig{
+		Region:      os.Getenv("AUDIT_CLOUDWATCH_REGION"),
+		Endpoint:    os.Getenv("AUDIT_CLOUDWATCH_ENDPOINT"),
+		Credentials: AWSCredentialsFromEnv(),
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+	for _, group := range strings.Split(os.Getenv("AUDIT_CLOUDWATCH_LOG_GROUPS"), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			config.LogGroups = append(config.LogGroups, group)
+		}
+	}
+	if value := os.Getenv("AUDIT_CLOUDWATCH_MAX_EVENTS"); value != "" {
+		maxEvents, err := strconv.Atoi(value)
+		if err != nil || maxEvents <= 0 {
+			return config, fmt.Errorf("invalid AUDIT_CLOUDWATCH_MAX_EVENTS: %s", value)
+		}
+		config.MaxEvents = maxEvents
+	}
+	if value := os.Getenv("AUDIT_CLOUDWATCH_QUERY_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return config, fmt.Errorf("invalid AUDIT_CLOUDWATCH_QUERY_TIMEOUT: %s", value)
+		}
+		config.QueryTimeout = timeout
+	}
+	return config, nil
+}
+
+// CloudWatchProvider queries audit events shipped to CloudWatch Logs, as on ROSA
+// clusters, with Logs Insights
+type CloudWatchProvider struct {
+	config CloudWatchConfig
+	client *http.Client
+}
+
+// NewCloudWatchProvider validates config, fills in defaults and creates the provider
+func NewCloudWatchProvider(config CloudWatchConfig) (*CloudWatchProvider, error) {
+	if !regionPattern.MatchString(config.Region) {
+		return nil, fmt.Errorf("invalid AWS region %q", config.Region)
+	}
+	if len(config.LogGroups) == 0 {
+		return nil, fmt.Errorf("at least one CloudWatch log group is required")
+	}
+	for _, group := range config.LogGroups {
+		if !logGroupPattern.MatchString(group) {
+			return nil, fmt.Errorf("invalid CloudWatch log group %q", group)
+		}
+	}
+	if config.Credentials.AccessKeyID == "" || config.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://logs.%s.amazonaws.com", config.Region)
+	}
+	parsed, err := url.Parse(config.Endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid CloudWatch endpoint %q", config.Endpoint)
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	if config.PageSize <= 0 || config.PageSize > DefaultCloudWatchPageSize {
+		config.PageSize = DefaultCloudWatchPageSize
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = DefaultCloudWatchMaxEvents
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultCloudWatchPoll
+	}
+	if config.QueryTimeout <= 0 {
+		config.QueryTimeout = DefaultCloudWatchQueryTimeout
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &CloudWatchProvider{config: config, client: &http.Client{Timeout: config.Timeout}}, nil
+}
+
+// Name returns the provider name
+func (p *CloudWatchProvider) Name() string {
+	return ProviderCloudWatch
+}
+
+// Capabilities reports that message filters and the time range are applied by
+// Logs Insights and results are paged; which log sources have events depends on
+// what is forwarded to the log groups
+func (p *CloudWatchProvider) Capabilities() Capabilities {
+	return Capabilities{
+		LogSources:        []string{"kube-apiserver", "oauth-server", "node", "openshift-apiserver", "oauth-apiserver"},
+		ServerSideFilters: true,
+		TimeRange:         true,
+		Pagination:        true,
+		MaxEvents:         p.config.MaxEvents,
+	}
+}
+
+// ExecuteTimeout returns how long fetching the events of one query may take
+func (p *CloudWatchProvider) ExecuteTimeout() time.Duration {
+	return p.config.QueryTimeout
+}
+
+// BuildQuery describes the Logs Insights query run for params
+func (p *CloudWatchProvider) BuildQuery(params types.AuditQueryParams) (string, error) {
+	start, end := queryRange(params.Timeframe, time.Now())
+	return fmt.Sprintf("cloudwatch logs insights %s %q (%s to %s, limit %d)", strings.Join(p.config.LogGroups, ","),
+		p.InsightsQuery(params, 0), start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), p.config.MaxEvents), nil
+}
+
+// Execute runs Logs Insights queries for params over the timeframe's window and
+// returns the matching events, oldest first. A query returns at most PageSize
+// rows, so later pages start at the last timestamp seen, skipping rows already
+// returned by their @ptr.
+func (p *CloudWatchProvider) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	start, end := queryRange(params.Timeframe, time.Now())
+	var output strings.Builder
+	seen := make(map[string]bool)
+	var after int64
+	for len(seen) < p.config.MaxEvents {
+		rows, err := p.runQuery(ctx, p.InsightsQuery(params, after), start, end)
+		if err != nil {
+			return "", err
+		}
+		added := 0
+		for _, row := range rows {
+			if seen[row.pointer] || len(seen) >= p.config.MaxEvents {
+				continue
+			}
+			seen[row.pointer] = true
+			added++
+			output.WriteString(strings.TrimRight(row.message, "\n"))
+			output.WriteString("\n")
+			if row.timestamp > after {
+				after = row.timestamp
+			}
+		}
+		// A short page is the last. A full page of rows already seen means more
+		// events share one timestamp than a page holds; the rest are skipped.
+		if len(rows) < p.config.PageSize {
+			break
+		}
+		if added == 0 {
+			after++
+		}
+	}
+	return output.String(), nil
+}
+
+// InsightsQuery translates params into a Logs Insights query returning events at
+// or after the after timestamp, in milliseconds. Message filters select a
+// superset of the matching events, and the server applies the exact filters to
+// the fetched events in Go.
+func (p *CloudWatchProvider) InsightsQuery(params types.AuditQueryParams, after int64) string {
+	parts := []string{"fields @timestamp, @message, @ptr"}
+	fields := []struct {
+		values []string
+		mode   types.MatchMode
+	}{
+		{fieldValues(params.Username, params.Usernames), params.UsernameMatch},
+		{fieldValues(params.Verb, params.Verbs), params.VerbMatch},
+		{fieldValues(params.Resource, params.Resources), params.ResourceMatch},
+		{fieldValues(params.Namespace, params.Namespaces), params.NamespaceMatch},
+		{fieldValues(params.UserAgent, nil), params.UserAgentMatch},
+	}
+	for _, field := range fields {
+		// A regex match mode cannot be checked against the whole message
+		if field.mode == types.MatchModeRegex {
+			continue
+		}
+		if filter := messageFilter("like", field.values); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	for _, pattern := range params.Patterns {
+		if filter := messageFilter("like", []string{pattern}); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	for _, exclusion := range params.Exclude {
+		if filter := messageFilter("not like", []string{exclusion}); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	if after > 0 {
+		parts = append(parts, fmt.Sprintf("filter @timestamp >= %d", after))
+	}
+	parts = append(parts, "sort @timestamp asc", fmt.Sprintf("limit %d", p.config.PageSize))
+	return strings.Join(parts, " | ")
+}
+
+// messageFilter returns a case-insensitive filter on @message matching any of
+// values, or "" when a value could be escaped differently in the JSON message
+func messageFilter(operator string, values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || strings.ContainsAny(value, "\"\\") {
+			return ""
+		}
+		quoted = append(quoted, strings.ReplaceAll(regexp.QuoteMeta(value), "/", `\/`))
+	}
+	if len(quoted) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("filter @message %s /(?i)(%s)/", operator, strings.Join(quoted, "|"))
+}
+
+// cloudWatchRow is an event returned by a Logs Insights query
+type cloudWatchRow struct {
+	timestamp int64
+	message   string
+	pointer   string
+}
+
+// runQuery starts a Logs Insights query and polls until it completes. A query
+// that is still running when ctx ends is stopped.
+func (p *CloudWatchProvider) runQuery(ctx context.Context, query string, start, end time.Time) ([]cloudWatchRow, error) {
+	var started struct {
+		QueryID string `json:"queryId"`
+	}
+	err := p.call(ctx, "StartQuery", map[string]interface{}{
+		"logGroupNames": p.config.LogGroups,
+		"startTime":     start.Unix(),
+		"endTime":       end.Unix(),
+		"queryString":   query,
+		"limit":         p.config.PageSize,
+	}, &started)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var results struct {
+			Status  string `json:"status"`
+			Results [][]struct {
+				Field string `json:"field"`
+				Value string `json:"value"`
+			} `json:"results"`
+		}
+		if err := p.call(ctx, "GetQueryResults", map[string]string{"queryId": started.QueryID}, &results); err != nil {
+			p.stopQuery(started.QueryID)
+			return nil, err
+		}
+
+		switch results.Status {
+		case "Complete":
+			rows := make([]cloudWatchRow, 0, len(results.Results))
+			for _, fields := range results.Results {
+				var row cloudWatchRow
+				for _, field := range fields {
+					switch field.Field {
+					case "@timestamp":
+						row.timestamp = cloudWatchTimestamp(field.Value)
+					case "@message":
+						row.message = field.Value
+					case "@ptr":
+						row.pointer = field.Value
+					}
+				}
+				rows = append(rows, row)
+			}
+			return rows, nil
+		case "Failed", "Cancelled", "Timeout", "Unknown":
+			return nil, fmt.Errorf("CloudWatch Logs Insights query %s ended with status %s", started.QueryID, results.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			p.stopQuery(started.QueryID)
+			return nil, fmt.Errorf("CloudWatch Logs Insights query %s did not complete: %w", started.QueryID, ctx.Err())
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+// stopQuery stops a running query so it does not keep scanning logs
+func (p *CloudWatchProvider) stopQuery(queryID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = p.call(ctx, "StopQuery", map[string]string{"queryId": queryID}, nil)
+}
+
+// call invokes a CloudWatch Logs API action and decodes its response into result
+func (p *CloudWatchProvider) call(ctx context.Context, action string, input interface{}, result interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode CloudWatch %s request: %w", action, err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create CloudWatch %s request: %w", action, err)
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	signAWSRequest(request, body, "logs", p.config.Region, p.config.Credentials, time.Now())
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("CloudWatch %s failed: %w", action, err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxCloudWatchResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read CloudWatch %s response: %w", action, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("CloudWatch %s failed with status %d: %s", action, response.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to parse CloudWatch %s response: %w", action, err)
+	}
+	return nil
+}
+
+// cloudWatchTimestamp converts a Logs Insights @timestamp, such as
+// "2024-01-02 03:04:05.678", to milliseconds since the epoch
+func cloudWatchTimestamp(value string) int64 {
+	parsed, err := time.Parse("2006-01-02 15:04:05.000", value)
+	if err != nil {
+		return 0
+	}
+	return parsed.UnixMilli()
+}
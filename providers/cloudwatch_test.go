@@ -0,0 +1,170 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// testAWSCredentials are the example credentials from the AWS signing documentation
+var testAWSCredentials = AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+// TestSignAWSRequest tests Signature Version 4 against the AWS documentation example
+func TestSignAWSRequest(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSRequest(request, nil, "iam", "us-east-1", testAWSCredentials, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := request.Header.Get("Authorization"); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+// TestCloudWatchProvider_InsightsQuery tests translating query parameters into Logs Insights
+func TestCloudWatchProvider_InsightsQuery(t *testing.T) {
+	provider, err := NewCloudWatchProvider(CloudWatchConfig{Region: "us-east-1", LogGroups: []string{"rosa.audit"}, Credentials: testAWSCredentials, PageSize: 100})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	query := provider.InsightsQuery(types.AuditQueryParams{
+		Usernames:     []string{"alice", "system:serviceaccount:a/b"},
+		Verb:          "delete",
+		Resource:      "^secrets$",
+		ResourceMatch: types.MatchModeRegex,
+		Exclude:       []string{"kube-system"},
+	}, 1700000000000)
+	expected := "fields @timestamp, @message, @ptr" +
+		" | filter @message like /(?i)(alice|system:serviceaccount:a\\/b)/" +
+		" | filter @message like /(?i)(delete)/" +
+		" | filter @message not like /(?i)(kube-system)/" +
+		" | filter @timestamp >= 1700000000000" +
+		" | sort @timestamp asc | limit 100"
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+
+	if _, err := NewCloudWatchProvider(CloudWatchConfig{Region: "us-east-1", LogGroups: []string{"rosa.audit"}}); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+	if _, err := NewCloudWatchProvider(CloudWatchConfig{Region: "us-east-1", LogGroups: []string{"a b"}, Credentials: testAWSCredentials}); err == nil {
+		t.Error("Expected an error for an invalid log group")
+	}
+	if _, err := NewCloudWatchProvider(CloudWatchConfig{Region: "east", LogGroups: []string{"rosa.audit"}, Credentials: testAWSCredentials}); err == nil {
+		t.Error("Expected an error for an invalid region")
+	}
+}
+
+// fakeCloudWatch serves Logs Insights queries over events, polling once before
+// each query completes
+type fakeCloudWatch struct {
+	events  []string
+	queries []string
+	polls   int
+	status  string
+}
+
+func (f *fakeCloudWatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	var input map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&input)
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "Logs_20140328.StartQuery":
+		f.queries = append(f.queries, input["queryString"].(string))
+		fmt.Fprintf(w, `{"queryId":"q%d"}`, len(f.queries))
+	case "Logs_20140328.GetQueryResults":
+		f.polls++
+		if f.polls%2 == 1 {
+			w.Write([]byte(`{"status":"Running","results":[]}`))
+			return
+		}
+		if f.status != "" {
+			fmt.Fprintf(w, `{"status":%q}`, f.status)
+			return
+		}
+		// Each page returns up to two events at or after the query's timestamp
+		query := f.queries[len(f.queries)-1]
+		var after int64
+		if index := strings.Index(query, "@timestamp >= "); index >= 0 {
+			fmt.Sscanf(query[index+len("@timestamp >= "):], "%d", &after)
+		}
+		var rows []string
+		for i, event := range f.events {
+			timestamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).Add(time.Duration(i/2) * time.Second)
+			if timestamp.UnixMilli() < after || len(rows) == 2 {
+				continue
+			}
+			message, _ := json.Marshal(event)
+			rows = append(rows, fmt.Sprintf(`[{"field":"@timestamp","value":%q},{"field":"@message","value":%s},{"field":"@ptr","value":"p%d"}]`,
+				timestamp.Format("2006-01-02 15:04:05.000"), message, i))
+		}
+		fmt.Fprintf(w, `{"status":"Complete","results":[%s]}`, strings.Join(rows, ","))
+	default:
+		w.Write([]byte(`{}`))
+	}
+}
+
+// TestCloudWatchProvider_Execute tests polling queries and paging by timestamp
+func TestCloudWatchProvider_Execute(t *testing.T) {
+	// Events 0 and 1 share a timestamp, as do 2 and 3, so each pair fills a page
+	fake := &fakeCloudWatch{events: []string{`{"auditID":"a0"}`, `{"auditID":"a1"}`, `{"auditID":"a2"}`, `{"auditID":"a3"}`, `{"auditID":"a4"}`}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, err := NewCloudWatchProvider(CloudWatchConfig{Region: "us-east-1", LogGroups: []string{"rosa.audit"}, Endpoint: server.URL,
+		Credentials: testAWSCredentials, PageSize: 2, PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{Verb: "delete"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := strings.Join(fake.events, "\n") + "\n"
+	if output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+	if len(fake.queries) < 3 || strings.Contains(fake.queries[0], "@timestamp >=") || !strings.Contains(fake.queries[1], "@timestamp >=") {
+		t.Errorf("Unexpected queries %v", fake.queries)
+	}
+
+	// A failed query is reported
+	fake.status = "Failed"
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{}); err == nil || !strings.Contains(err.Error(), "status Failed") {
+		t.Errorf("Expected a failed query error, got %v", err)
+	}
+}
+
+// TestCloudWatchConfigFromEnv tests reading the CloudWatch configuration from the environment
+func TestCloudWatchConfigFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_CLOUDWATCH_LOG_GROUPS", "rosa.audit, rosa.infrastructure")
+	t.Setenv("AUDIT_CLOUDWATCH_REGION", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AUDIT_CLOUDWATCH_QUERY_TIMEOUT", "2m")
+	config, err := CloudWatchConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Region != "eu-west-1" || len(config.LogGroups) != 2 || config.Credentials.AccessKeyID != "AKIDEXAMPLE" || config.QueryTimeout != 2*time.Minute {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Setenv("AUDIT_CLOUDWATCH_MAX_EVENTS", "0")
+	if _, err := CloudWatchConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid event limit")
+	}
+}
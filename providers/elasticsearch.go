@@ -0,0 +1,385 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// Elasticsearch provider defaults
+const (
+	DefaultElasticsearchIndex     = "audit-*"
+	DefaultElasticsearchPageSize  = 1000
+	DefaultElasticsearchMaxEvents = 10000
+	// maxElasticsearchResponseBytes bounds the size of a search response
+	maxElasticsearchResponseBytes = 256 * 1024 * 1024
+)
+
+// indexPatternPattern matches comma-separated index names and wildcard patterns
+var indexPatternPattern = regexp.MustCompile(`^[a-z0-9*][a-z0-9._*+-]*(,[a-z0-9*][a-z0-9._*+-]*)*$`)
+
+// fieldNamePattern matches Elasticsearch field paths
+var fieldNamePattern = regexp.MustCompile(`^@?[a-zA-Z_][a-zA-Z0-9_.@-]*$`)
+
+// ElasticsearchFields maps audit event fields to the index's field names. The
+// filter fields must be keyword fields.
+type ElasticsearchFields struct {
+	Timestamp string
+	// AuditID breaks timestamp ties when paging through results
+	AuditID string
+	// LogSource, when set, is a field whose value is the log source
+	LogSource string
+	Username  string
+	Verb      string
+	Resource  string
+	Namespace string
+	UserAgent string
+}
+
+// DefaultElasticsearchFields are the audit event's own field names
+var DefaultElasticsearchFields = ElasticsearchFields{
+	Timestamp: "requestReceivedTimestamp",
+	AuditID:   "auditID",
+	Username:  "user.username",
+	Verb:      "verb",
+	Resource:  "objectRef.resource",
+	Namespace: "objectRef.namespace",
+	UserAgent: "userAgent",
+}
+
+// ElasticsearchConfig configures the Elasticsearch provider, which also works
+// with OpenSearch
+type ElasticsearchConfig struct {
+	// URL is the cluster base URL, e.g. https://elasticsearch:9200
+	URL string
+	// Index is the index name or pattern holding the audit events
+	Index string
+	// Username and Password are sent with basic authentication; APIKey is
+	// sent instead when set
+	Username string
+	Password string
+	APIKey   string
+	// PageSize is the number of events fetched per search request and
+	// MaxEvents the most events a query returns
+	PageSize  int
+	MaxEvents int
+	Fields    ElasticsearchFields
+	Timeout   time.Duration
+}
+
+// ElasticsearchConfigFromEnv reads the Elasticsearch provider configuration from
+// AUDIT_ES_URL, AUDIT_ES_INDEX, AUDIT_ES_USERNAME, AUDIT_ES_PASSWORD,
+// AUDIT_ES_API_KEY, AUDIT_ES_PAGE_SIZE, AUDIT_ES_MAX_EVENTS and AUDIT_ES_FIELDS.
+// AUDIT_ES_FIELDS overrides field names as comma-separated name=field pairs,
+// e.g. "username=user.username.keyword,timestamp=@timestamp".
+func ElasticsearchConfigFromEnv() (ElasticsearchConfig, error) {
+	config := ElasticsearchConfig{
+		URL:      os.Getenv("AUDIT_ES_URL"),
+		Index:    os.Getenv("AUDIT_ES_INDEX"),
+		Username: os.Getenv("AUDIT_ES_USERNAME"),
+		Password: os.Getenv("AUDIT_ES_PASSWORD"),
+		APIKey:   os.Getenv("AUDIT_ES_API_KEY"),
+		Fields:   DefaultElasticsearchFields,
+	}
+	for name, target := range map[string]*int{"AUDIT_ES_PAGE_SIZE": &config.PageSize, "AUDIT_ES_MAX_EVENTS": &config.MaxEvents} {
+		if value := os.Getenv(name); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number <= 0 {
+				return config, fmt.Errorf("invalid %s: %s", name, value)
+			}
+			*target = number
+		}
+	}
+	if value := os.Getenv("AUDIT_ES_FIELDS"); value != "" {
+		fields, err := parseElasticsearchFields(value, config.Fields)
+		if err != nil {
+			return config, fmt.Errorf("invalid AUDIT_ES_FIELDS: %w", err)
+		}
+		config.Fields = fields
+	}
+	return config, nil
+}
+
+// parseElasticsearchFields applies name=field overrides to fields
+func parseElasticsearchFields(value string, fields ElasticsearchFields) (ElasticsearchFields, error) {
+	targets := map[string]*string{
+		"timestamp":  &fields.Timestamp,
+		"audit_id":   &fields.AuditID,
+		"log_source": &fields.LogSource,
+		"username":   &fields.Username,
+		"verb":       &fields.Verb,
+		"resource":   &fields.Resource,
+		"namespace":  &fields.Namespace,
+		"user_agent": &fields.UserAgent,
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, field, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fields, fmt.Errorf("expected name=field, got %q", pair)
+		}
+		target, known := targets[strings.TrimSpace(name)]
+		if !known {
+			return fields, fmt.Errorf("unknown field %q", name)
+		}
+		*target = strings.TrimSpace(field)
+	}
+	return fields, nil
+}
+
+// ElasticsearchProvider queries audit events stored in Elasticsearch or OpenSearch
+type ElasticsearchProvider struct {
+	config ElasticsearchConfig
+	client *http.Client
+}
+
+// NewElasticsearchProvider validates config, fills in defaults and creates the provider
+func NewElasticsearchProvider(config ElasticsearchConfig) (*ElasticsearchProvider, error) {
+	parsed, err := url.Parse(config.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Elasticsearch URL %q", config.URL)
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	if config.Index == "" {
+		config.Index = DefaultElasticsearchIndex
+	}
+	if !indexPatternPattern.MatchString(config.Index) {
+		return nil, fmt.Errorf("invalid Elasticsearch index pattern %q", config.Index)
+	}
+	if config.Fields == (ElasticsearchFields{}) {
+		config.Fields = DefaultElasticsearchFields
+	}
+	if config.Fields.Timestamp == "" || config.Fields.AuditID == "" {
+		return nil, fmt.Errorf("the timestamp and audit ID fields are required")
+	}
+	for _, field := range []string{config.Fields.Timestamp, config.Fields.AuditID, config.Fields.LogSource, config.Fields.Username,
+		config.Fields.Verb, config.Fields.Resource, config.Fields.Namespace, config.Fields.UserAgent} {
+		if field != "" && !fieldNamePattern.MatchString(field) {
+			return nil, fmt.Errorf("invalid Elasticsearch field name %q", field)
+		}
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = DefaultElasticsearchPageSize
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = DefaultElasticsearchMaxEvents
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &ElasticsearchProvider{config: config, client: &http.Client{Timeout: config.Timeout}}, nil
+}
+
+// Name returns the provider name
+func (p *ElasticsearchProvider) Name() string {
+	return ProviderElasticsearch
+}
+
+// Capabilities reports that field filters and the time range are applied by the
+// search and results are paged; which log sources have events depends on what
+// is indexed
+func (p *ElasticsearchProvider) Capabilities() Capabilities {
+	return Capabilities{
+		LogSources:        []string{"kube-apiserver", "oauth-server", "node", "openshift-apiserver", "oauth-apiserver"},
+		ServerSideFilters: true,
+		TimeRange:         true,
+		Pagination:        true,
+		MaxEvents:         p.config.MaxEvents,
+	}
+}
+
+// BuildQuery describes the search run for params
+func (p *ElasticsearchProvider) BuildQuery(params types.AuditQueryParams) (string, error) {
+	query, err := json.Marshal(p.Query(params, time.Now()))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Elasticsearch query: %w", err)
+	}
+	return fmt.Sprintf("elasticsearch search %s %s (limit %d)", p.config.Index, query, p.config.MaxEvents), nil
+}
+
+// Execute pages through the events matching params, oldest first, and returns
+// them as JSON lines
+func (p *ElasticsearchProvider) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	request := map[string]interface{}{
+		"query":            p.Query(params, time.Now()),
+		"sort":             []interface{}{map[string]string{p.config.Fields.Timestamp: "asc"}, map[string]string{p.config.Fields.AuditID: "asc"}},
+		"track_total_hits": false,
+	}
+
+	var output strings.Builder
+	fetched := 0
+	for fetched < p.config.MaxEvents {
+		size := p.config.PageSize
+		if remaining := p.config.MaxEvents - fetched; remaining < size {
+			size = remaining
+		}
+		request["size"] = size
+
+		hits, err := p.search(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		for _, hit := range hits {
+			output.Write(hit.Source)
+			output.WriteString("\n")
+		}
+		fetched += len(hits)
+		if len(hits) < size {
+			break
+		}
+		request["search_after"] = hits[len(hits)-1].Sort
+	}
+	return output.String(), nil
+}
+
+// elasticsearchHit is a search hit and its sort values
+type elasticsearchHit struct {
+	Source json.RawMessage `json:"_source"`
+	Sort   []interface{}   `json:"sort"`
+}
+
+// search runs one search request against the configured index
+func (p *ElasticsearchProvider) search(ctx context.Context, body map[string]interface{}) ([]elasticsearchHit, error) {
+	data, err := p.post(ctx, "_search", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []elasticsearchHit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse Elasticsearch response: %w", err)
+	}
+	return result.Hits.Hits, nil
+}
+
+// Count returns how many events the search for params matches, with the
+// count API rather than fetching them
+func (p *ElasticsearchProvider) Count(ctx context.Context, params types.AuditQueryParams) (int, error) {
+	data, err := p.post(ctx, "_count", map[string]interface{}{"query": p.Query(params, time.Now())})
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse Elasticsearch response: %w", err)
+	}
+	return result.Count, nil
+}
+
+// post sends a request body to an endpoint of the configured index, such as
+// _search, and returns the response body
+func (p *ElasticsearchProvider) post(ctx context.Context, endpoint string, body map[string]interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Elasticsearch query: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.URL+"/"+p.config.Index+"/"+endpoint, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Elasticsearch request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		request.Header.Set("Authorization", "ApiKey "+p.config.APIKey)
+	} else if p.config.Username != "" {
+		request.SetBasicAuth(p.config.Username, p.config.Password)
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Elasticsearch search failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxElasticsearchResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Elasticsearch response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Elasticsearch search failed with status %d: %s", response.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// Query translates params into a bool query over the timeframe's window at now.
+// Field filters select a superset of the matching events, and the server applies
+// the exact filters to the fetched events in Go.
+func (p *ElasticsearchProvider) Query(params types.AuditQueryParams, now time.Time) map[string]interface{} {
+	start, end := queryRange(params.Timeframe, now)
+	filters := []interface{}{
+		map[string]interface{}{"range": map[string]interface{}{p.config.Fields.Timestamp: map[string]string{
+			"gte": start.UTC().Format(time.RFC3339Nano),
+			"lte": end.UTC().Format(time.RFC3339Nano),
+		}}},
+	}
+	if p.config.Fields.LogSource != "" {
+		logSource := params.LogSource
+		if logSource == "" {
+			logSource = "kube-apiserver"
+		}
+		filters = append(filters, map[string]interface{}{"term": map[string]string{p.config.Fields.LogSource: logSource}})
+	}
+
+	fields := []struct {
+		field  string
+		values []string
+		mode   types.MatchMode
+	}{
+		{p.config.Fields.Username, fieldValues(params.Username, params.Usernames), params.UsernameMatch},
+		{p.config.Fields.Verb, fieldValues(params.Verb, params.Verbs), params.VerbMatch},
+		{p.config.Fields.Resource, fieldValues(params.Resource, params.Resources), params.ResourceMatch},
+		{p.config.Fields.Namespace, fieldValues(params.Namespace, params.Namespaces), params.NamespaceMatch},
+		{p.config.Fields.UserAgent, fieldValues(params.UserAgent, nil), params.UserAgentMatch},
+	}
+	for _, field := range fields {
+		if filter := fieldFilter(field.field, field.values, field.mode); filter != nil {
+			filters = append(filters, filter)
+		}
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": filters}}
+}
+
+// fieldFilter returns a query matching any of values in field, or nil when the
+// field is not mapped or the mode is only checked in Go
+func fieldFilter(field string, values []string, mode types.MatchMode) map[string]interface{} {
+	if field == "" || len(values) == 0 || mode == types.MatchModeRegex {
+		return nil
+	}
+	if mode == types.MatchModeExact {
+		return map[string]interface{}{"terms": map[string]interface{}{field: values}}
+	}
+
+	should := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if mode == types.MatchModePrefix {
+			should = append(should, map[string]interface{}{"prefix": map[string]interface{}{field: map[string]string{"value": value}}})
+			continue
+		}
+		should = append(should, map[string]interface{}{"wildcard": map[string]interface{}{field: map[string]interface{}{
+			"value":            "*" + escapeWildcard(value) + "*",
+			"case_insensitive": true,
+		}}})
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"should": should, "minimum_should_match": 1}}
+}
+
+// escapeWildcard escapes the wildcard query metacharacters in value
+func escapeWildcard(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(value)
+}
@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestElasticsearchProvider_Query tests translating query parameters into a bool query
+func TestElasticsearchProvider_Query(t *testing.T) {
+	fields := DefaultElasticsearchFields
+	fields.LogSource = "log_source"
+	provider, err := NewElasticsearchProvider(ElasticsearchConfig{URL: "https://es:9200", Fields: fields})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	query := provider.Query(types.AuditQueryParams{
+		LogSource:      "oauth-server",
+		Timeframe:      "1h",
+		Usernames:      []string{"alice", "bob*"},
+		Verb:           "delete",
+		VerbMatch:      types.MatchModeExact,
+		Namespace:      "openshift-",
+		NamespaceMatch: types.MatchModePrefix,
+		Resource:       "^secrets$",
+		ResourceMatch:  types.MatchModeRegex,
+	}, now)
+	encoded, _ := json.Marshal(query)
+	expected := `{"bool":{"filter":[` +
+		`{"range":{"requestReceivedTimestamp":{"gte":"2026-03-10T11:00:00Z","lte":"2026-03-10T12:00:00Z"}}},` +
+		`{"term":{"log_source":"oauth-server"}},` +
+		`{"bool":{"minimum_should_match":1,"should":[` +
+		`{"wildcard":{"user.username":{"case_insensitive":true,"value":"*alice*"}}},` +
+		`{"wildcard":{"user.username":{"case_insensitive":true,"value":"*bob\\**"}}}]}},` +
+		`{"terms":{"verb":["delete"]}},` +
+		`{"bool":{"minimum_should_match":1,"should":[{"prefix":{"objectRef.namespace":{"value":"openshift-"}}}]}}]}}`
+	if string(encoded) != expected {
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+
+	if _, err := NewElasticsearchProvider(ElasticsearchConfig{URL: "es:9200"}); err == nil {
+		t.Error("Expected an error for a URL without a scheme")
+	}
+	if _, err := NewElasticsearchProvider(ElasticsearchConfig{URL: "http://es", Index: "audit/_delete_by_query"}); err == nil {
+		t.Error("Expected an error for an invalid index pattern")
+	}
+	if _, err := NewElasticsearchProvider(ElasticsearchConfig{URL: "http://es", Fields: ElasticsearchFields{Timestamp: "@timestamp", AuditID: "auditID", Verb: "verb\""}}); err == nil {
+		t.Error("Expected an error for an invalid field name")
+	}
+}
+
+// TestElasticsearchProvider_Execute tests paging through search results with search_after
+func TestElasticsearchProvider_Execute(t *testing.T) {
+	var requests []map[string]interface{}
+	var authorization, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		authorization, path = r.Header.Get("Authorization"), r.URL.Path
+
+		// Three events are served two per page
+		start := 0
+		if after, ok := body["search_after"].([]interface{}); ok {
+			start = int(after[0].(float64))
+		}
+		var hits []string
+		for i := start + 1; i <= 3 && len(hits) < int(body["size"].(float64)); i++ {
+			hits = append(hits, fmt.Sprintf(`{"_source":{"auditID":"a%d"},"sort":[%d,"a%d"]}`, i, i, i))
+		}
+		fmt.Fprintf(w, `{"hits":{"hits":[%s]}}`, strings.Join(hits, ","))
+	}))
+	defer server.Close()
+
+	provider, err := NewElasticsearchProvider(ElasticsearchConfig{URL: server.URL, Index: "audit-*", APIKey: "key", PageSize: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "{\"auditID\":\"a1\"}\n{\"auditID\":\"a2\"}\n{\"auditID\":\"a3\"}\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+	if len(requests) != 2 || path != "/audit-*/_search" || authorization != "ApiKey key" {
+		t.Errorf("Unexpected requests %v to %s with %q", requests, path, authorization)
+	}
+
+	// MaxEvents bounds the events fetched
+	requests = nil
+	provider.config.MaxEvents = 1
+	output, err = provider.Execute(context.Background(), types.AuditQueryParams{})
+	if err != nil || output != "{\"auditID\":\"a1\"}\n" || len(requests) != 1 {
+		t.Errorf("Expected one event from one request, got %q, %d requests, %v", output, len(requests), err)
+	}
+
+	command, err := provider.BuildQuery(types.AuditQueryParams{})
+	if err != nil || !strings.HasPrefix(command, "elasticsearch search audit-* {\"bool\"") {
+		t.Errorf("Unexpected command %q, %v", command, err)
+	}
+}
+
+// TestElasticsearchProvider_ExecuteError tests reporting failed searches
+func TestElasticsearchProvider_ExecuteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"index_not_found_exception"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider, err := NewElasticsearchProvider(ElasticsearchConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{}); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+}
+
+// TestElasticsearchProvider_Count tests counting matching events with the count API
+func TestElasticsearchProvider_Count(t *testing.T) {
+	var body map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		path = r.URL.Path
+		fmt.Fprint(w, `{"count":1234,"_shards":{"total":1,"successful":1}}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewElasticsearchProvider(ElasticsearchConfig{URL: server.URL, Index: "audit-*"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	count, err := provider.Count(context.Background(), types.AuditQueryParams{Verb: "delete"})
+	if err != nil || count != 1234 {
+		t.Fatalf("Expected 1234 events, got %d, %v", count, err)
+	}
+	if _, ok := body["query"]; !ok || path != "/audit-*/_count" || len(body) != 1 {
+		t.Errorf("Unexpected request %v to %s", body, path)
+	}
+}
+
+// TestElasticsearchConfigFromEnv tests reading the Elasticsearch configuration from the environment
+func TestElasticsearchConfigFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_ES_URL", "https://es:9200")
+	t.Setenv("AUDIT_ES_PAGE_SIZE", "500")
+	t.Setenv("AUDIT_ES_FIELDS", "timestamp=@timestamp, username=user.username.keyword")
+	config, err := ElasticsearchConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.PageSize != 500 || config.Fields.Timestamp != "@timestamp" || config.Fields.Username != "user.username.keyword" || config.Fields.Verb != "verb" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Setenv("AUDIT_ES_FIELDS", "user=user.name")
+	if _, err := ElasticsearchConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+	t.Setenv("AUDIT_ES_FIELDS", "")
+	t.Setenv("AUDIT_ES_MAX_EVENTS", "-1")
+	if _, err := ElasticsearchConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid event limit")
+	}
+}
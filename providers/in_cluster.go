@@ -0,0 +1,222 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// In-cluster provider defaults
+const (
+	// ServiceAccountDir holds the token and CA certificate Kubernetes mounts
+	// into pods for their ServiceAccount
+	ServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// DefaultInClusterNodeSelector selects the OpenShift master nodes, like
+	// oc adm node-logs --role=master
+	DefaultInClusterNodeSelector = "node-role.kubernetes.io/master"
+	// DefaultInClusterMaxBytes bounds the audit log bytes one query reads
+	DefaultInClusterMaxBytes = 512 * 1024 * 1024
+	// DefaultInClusterQueryTimeout bounds how long one query reads audit logs
+	DefaultInClusterQueryTimeout = 5 * time.Minute
+)
+
+// InClusterConfig configures the in-cluster provider, which reads audit logs
+// through the API server's node proxy with the pod's ServiceAccount token
+type InClusterConfig struct {
+	// Host is the API server URL, e.g. https://172.30.0.1:443
+	Host string
+	// TokenFile and CAFile are the mounted ServiceAccount credentials; client-go
+	// re-reads the token periodically, so rotated tokens are picked up
+	TokenFile string
+	CAFile    string
+	// Nodes are the nodes to read; when empty, nodes matching NodeSelector are used
+	Nodes        []string
+	NodeSelector string
+	// MaxBytes bounds the audit log bytes one query reads; a query reading more fails
+	MaxBytes int64
+	// QueryTimeout bounds how long one query reads audit logs
+	QueryTimeout time.Duration
+}
+
+// InClusterConfigFromEnv reads the in-cluster provider configuration from the
+// KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT variables Kubernetes sets
+// in every pod, AUDIT_IN_CLUSTER_NODES (comma-separated),
+// AUDIT_IN_CLUSTER_NODE_SELECTOR, AUDIT_IN_CLUSTER_MAX_BYTES and
+// AUDIT_IN_CLUSTER_QUERY_TIMEOUT
+func InClusterConfigFromEnv() (InClusterConfig, error) {
+	config := InClusterConfig{
+		TokenFile:    ServiceAccountDir + "/token",
+		CAFile:       ServiceAccountDir + "/ca.crt",
+		NodeSelector: os.Getenv("AUDIT_IN_CLUSTER_NODE_SELECTOR"),
+	}
+	if host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"); host != "" && port != "" {
+		config.Host = "https://" + net.JoinHostPort(host, port)
+	}
+	for _, node := range strings.Split(os.Getenv("AUDIT_IN_CLUSTER_NODES"), ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			config.Nodes = append(config.Nodes, node)
+		}
+	}
+	if value := os.Getenv("AUDIT_IN_CLUSTER_MAX_BYTES"); value != "" {
+		maxBytes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || maxBytes <= 0 {
+			return config, fmt.Errorf("invalid AUDIT_IN_CLUSTER_MAX_BYTES: %s", value)
+		}
+		config.MaxBytes = maxBytes
+	}
+	if value := os.Getenv("AUDIT_IN_CLUSTER_QUERY_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return config, fmt.Errorf("invalid AUDIT_IN_CLUSTER_QUERY_TIMEOUT: %s", value)
+		}
+		config.QueryTimeout = timeout
+	}
+	return config, nil
+}
+
+// InClusterProvider reads OpenShift audit logs from inside the cluster,
+// without oc or a kubeconfig
+type InClusterProvider struct {
+	config    InClusterConfig
+	clientset kubernetes.Interface
+}
+
+// NewInClusterProvider validates config, fills in defaults and creates the provider
+func NewInClusterProvider(config InClusterConfig) (*InClusterProvider, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("not running in a cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	parsed, err := url.Parse(config.Host)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid API server URL %q", config.Host)
+	}
+	config.Host = strings.TrimRight(config.Host, "/")
+	if config.NodeSelector == "" {
+		config.NodeSelector = DefaultInClusterNodeSelector
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultInClusterMaxBytes
+	}
+	if config.QueryTimeout <= 0 {
+		config.QueryTimeout = DefaultInClusterQueryTimeout
+	}
+	for _, node := range config.Nodes {
+		if !nodeNamePattern.MatchString(node) {
+			return nil, fmt.Errorf("invalid node name %q", node)
+		}
+	}
+	if _, err := os.Stat(config.TokenFile); err != nil {
+		return nil, fmt.Errorf("ServiceAccount token not mounted: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{
+		Host:            config.Host,
+		BearerTokenFile: config.TokenFile,
+		TLSClientConfig: rest.TLSClientConfig{CAFile: config.CAFile},
+		UserAgent:       "audit-query-mcp-server",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Kubernetes client: %w", err)
+	}
+	return &InClusterProvider{config: config, clientset: clientset}, nil
+}
+
+// Name returns the provider name
+func (p *InClusterProvider) Name() string {
+	return ProviderInCluster
+}
+
+// Capabilities reports that the whole current audit log of each log source is
+// read, and every filter is applied in Go
+func (p *InClusterProvider) Capabilities() Capabilities {
+	return Capabilities{LogSources: append([]string(nil), utils.ValidLogSources...)}
+}
+
+// ExecuteTimeout returns how long reading the audit logs of one query may take
+func (p *InClusterProvider) ExecuteTimeout() time.Duration {
+	return p.config.QueryTimeout
+}
+
+// BuildQuery describes how the audit log of the query's log source is fetched
+func (p *InClusterProvider) BuildQuery(params types.AuditQueryParams) (string, error) {
+	nodes := "nodes " + strings.Join(p.config.Nodes, ",")
+	if len(p.config.Nodes) == 0 {
+		nodes = "nodes -l " + p.config.NodeSelector
+	}
+	return fmt.Sprintf("GET %s%s (%s, at most %d bytes)", p.config.Host,
+		nodeLogURL("{node}", commands.LogSourcePath(params.LogSource)), nodes, p.config.MaxBytes), nil
+}
+
+// Execute returns the audit log lines of the query's log source from every
+// selected node, failing if any node cannot be read or the logs exceed MaxBytes
+func (p *InClusterProvider) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	nodes, err := p.nodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	logPath := commands.LogSourcePath(params.LogSource)
+	remaining := p.config.MaxBytes
+	var output strings.Builder
+	for _, node := range nodes {
+		data, err := p.readLog(ctx, nodeLogURL(node, logPath), remaining)
+		if err != nil {
+			return "", fmt.Errorf("failed to read audit log on node %s: %w", node, err)
+		}
+		if int64(len(data)) > remaining {
+			return "", fmt.Errorf("audit logs exceed the limit of %d bytes per query; raise AUDIT_IN_CLUSTER_MAX_BYTES or set AUDIT_IN_CLUSTER_NODES", p.config.MaxBytes)
+		}
+		remaining -= int64(len(data))
+		output.Write(data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			output.WriteString("\n")
+		}
+	}
+	return output.String(), nil
+}
+
+// nodes returns the configured nodes or the nodes matching the node selector
+func (p *InClusterProvider) nodes(ctx context.Context) ([]string, error) {
+	if len(p.config.Nodes) > 0 {
+		return p.config.Nodes, nil
+	}
+
+	list, err := p.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: p.config.NodeSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var nodes []string
+	for _, item := range list.Items {
+		if nodeNamePattern.MatchString(item.Name) {
+			nodes = append(nodes, item.Name)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes match %s; set AUDIT_IN_CLUSTER_NODES", p.config.NodeSelector)
+	}
+	return nodes, nil
+}
+
+// readLog streams an API server path, reading at most limit+1 bytes so a
+// caller can tell a log over the limit from one at it
+func (p *InClusterProvider) readLog(ctx context.Context, path string, limit int64) ([]byte, error) {
+	stream, err := p.clientset.CoreV1().RESTClient().Get().AbsPath(path).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return io.ReadAll(io.LimitReader(stream, limit+1))
+}
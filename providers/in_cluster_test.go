@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// newInClusterAPI serves two master nodes and their oauth-server audit logs
+// to requests carrying the token in tokenFile
+func newInClusterAPI(t *testing.T, tokenFile string) *httptest.Server {
+	t.Helper()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := os.ReadFile(tokenFile)
+		if r.Header.Get("Authorization") != "Bearer "+strings.TrimSpace(string(token)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			if r.URL.Query().Get("labelSelector") != "node-role.kubernetes.io/master" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"kind": "NodeList", "apiVersion": "v1", "items": [{"metadata": {"name": "master-0"}}, {"metadata": {"name": "master-1"}}]}`))
+		case "/api/v1/nodes/master-0/proxy/logs/oauth-server/audit.log":
+			w.Write([]byte("{\"auditID\":\"a1\"}\n"))
+		case "/api/v1/nodes/master-1/proxy/logs/oauth-server/audit.log":
+			w.Write([]byte(`{"auditID":"a2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// TestInClusterProvider tests reading audit logs through the node proxy with the ServiceAccount token
+func TestInClusterProvider(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("token-1\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+	api := newInClusterAPI(t, tokenFile)
+	provider, err := NewInClusterProvider(InClusterConfig{Host: api.URL, TokenFile: tokenFile})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider.ExecuteTimeout() != DefaultInClusterQueryTimeout {
+		t.Errorf("Unexpected timeout %s", provider.ExecuteTimeout())
+	}
+
+	params := types.AuditQueryParams{LogSource: "oauth-server"}
+	command, err := provider.BuildQuery(params)
+	if err != nil || !strings.Contains(command, "/api/v1/nodes/{node}/proxy/logs/oauth-server/audit.log (nodes -l node-role.kubernetes.io/master") {
+		t.Errorf("Unexpected command %q, error %v", command, err)
+	}
+	output, err := provider.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "{\"auditID\":\"a1\"}\n{\"auditID\":\"a2\"}\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	// A query reading more than MaxBytes fails instead of dropping events
+	provider.config.MaxBytes = 20
+	if _, err := provider.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "exceed the limit of 20 bytes") {
+		t.Errorf("Expected the byte limit to be enforced, got %v", err)
+	}
+
+	provider.config.MaxBytes = DefaultInClusterMaxBytes
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "kube-apiserver"}); err == nil || !strings.Contains(err.Error(), "node master-0") {
+		t.Errorf("Expected an error for a missing log, got %v", err)
+	}
+}
+
+// TestInClusterConfigFromEnv tests reading the in-cluster configuration and its validation
+func TestInClusterConfigFromEnv(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "172.30.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	t.Setenv("AUDIT_IN_CLUSTER_NODES", "master-0, master-1")
+	t.Setenv("AUDIT_IN_CLUSTER_MAX_BYTES", "1048576")
+	t.Setenv("AUDIT_IN_CLUSTER_QUERY_TIMEOUT", "90s")
+	config, err := InClusterConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Host != "https://172.30.0.1:443" || len(config.Nodes) != 2 || config.MaxBytes != 1048576 || config.QueryTimeout != 90*time.Second {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if config.TokenFile != ServiceAccountDir+"/token" {
+		t.Errorf("Unexpected token file %s", config.TokenFile)
+	}
+
+	t.Setenv("AUDIT_IN_CLUSTER_MAX_BYTES", "lots")
+	if _, err := InClusterConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid byte limit")
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("token"), 0600)
+	for _, config := range []InClusterConfig{
+		{TokenFile: tokenFile},
+		{Host: "ftp://api", TokenFile: tokenFile},
+		{Host: "https://api", TokenFile: tokenFile, Nodes: []string{"Master_0"}},
+		{Host: "https://api", TokenFile: filepath.Join(t.TempDir(), "missing")},
+	} {
+		if _, err := NewInClusterProvider(config); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// Kubernetes provider defaults
+const (
+	DefaultKubectl = "kubectl"
+	// DefaultNodeSelector selects the control plane nodes running kube-apiserver
+	DefaultNodeSelector = "node-role.kubernetes.io/control-plane"
+	// DefaultKubernetesLogPath is the audit log path below /var/log used by the
+	// kube-apiserver --audit-log-path=/var/log/kubernetes/audit/audit.log setup
+	// in the Kubernetes auditing documentation
+	DefaultKubernetesLogPath = "kubernetes/audit/audit.log"
+)
+
+var (
+	// nodeNamePattern matches Kubernetes node names (DNS subdomains)
+	nodeNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	// logPathPattern matches relative log paths without shell metacharacters
+	logPathPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+)
+
+// KubernetesConfig configures the Kubernetes provider. With AuditFile set,
+// events are read from that local file, written by a log-collector sidecar or
+// the webhook sink; otherwise LogPath is read from each node through the API
+// server's node proxy.
+type KubernetesConfig struct {
+	Kubectl string
+	// Nodes are the nodes to read; when empty, nodes matching NodeSelector are used
+	Nodes        []string
+	NodeSelector string
+	// LogPath is the audit log path relative to the node's /var/log
+	LogPath   string
+	AuditFile string
+}
+
+// KubernetesConfigFromEnv reads the Kubernetes provider configuration from
+// AUDIT_K8S_KUBECTL, AUDIT_K8S_NODES (comma-separated), AUDIT_K8S_NODE_SELECTOR,
+// AUDIT_K8S_LOG_PATH and AUDIT_K8S_AUDIT_FILE
+func KubernetesConfigFromEnv() KubernetesConfig {
+	config := KubernetesConfig{
+		Kubectl:      os.Getenv("AUDIT_K8S_KUBECTL"),
+		NodeSelector: os.Getenv("AUDIT_K8S_NODE_SELECTOR"),
+		LogPath:      os.Getenv("AUDIT_K8S_LOG_PATH"),
+		AuditFile:    os.Getenv("AUDIT_K8S_AUDIT_FILE"),
+	}
+	for _, node := range strings.Split(os.Getenv("AUDIT_K8S_NODES"), ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			config.Nodes = append(config.Nodes, node)
+		}
+	}
+	return config
+}
+
+// KubernetesProvider reads kube-apiserver audit logs on upstream Kubernetes
+type KubernetesProvider struct {
+	config KubernetesConfig
+}
+
+// NewKubernetesProvider validates config, fills in defaults and creates the provider
+func NewKubernetesProvider(config KubernetesConfig) (*KubernetesProvider, error) {
+	if config.Kubectl == "" {
+		config.Kubectl = DefaultKubectl
+	}
+	if config.NodeSelector == "" {
+		config.NodeSelector = DefaultNodeSelector
+	}
+	if config.LogPath == "" {
+		config.LogPath = DefaultKubernetesLogPath
+	}
+	config.LogPath = strings.TrimPrefix(config.LogPath, "/var/log/")
+
+	if !logPathPattern.MatchString(config.LogPath) || strings.Contains(config.LogPath, "..") {
+		return nil, fmt.Errorf("invalid audit log path %q: must be a path below /var/log", config.LogPath)
+	}
+	for _, node := range config.Nodes {
+		if !nodeNamePattern.MatchString(node) {
+			return nil, fmt.Errorf("invalid node name %q", node)
+		}
+	}
+	return &KubernetesProvider{config: config}, nil
+}
+
+// Name returns the provider name
+func (p *KubernetesProvider) Name() string {
+	return ProviderKubernetes
+}
+
+// Capabilities reports that the whole audit log is read: upstream Kubernetes only
+// writes kube-apiserver audit logs, and every filter is applied in Go
+func (p *KubernetesProvider) Capabilities() Capabilities {
+	return Capabilities{LogSources: []string{"kube-apiserver"}}
+}
+
+// BuildQuery describes how the audit log of the query's log source is fetched
+func (p *KubernetesProvider) BuildQuery(params types.AuditQueryParams) (string, error) {
+	if err := p.checkLogSource(params.LogSource); err != nil {
+		return "", err
+	}
+	if p.config.AuditFile != "" {
+		return "read " + p.config.AuditFile, nil
+	}
+
+	nodes := "nodes " + strings.Join(p.config.Nodes, ",")
+	if len(p.config.Nodes) == 0 {
+		nodes = "nodes -l " + p.config.NodeSelector
+	}
+	return fmt.Sprintf("%s get --raw %s (%s)", p.config.Kubectl, nodeLogURL("{node}", p.config.LogPath), nodes), nil
+}
+
+// Execute returns the audit log lines of the query's log source from the audit
+// file or from every selected node, failing if any node cannot be read
+func (p *KubernetesProvider) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	if err := p.checkLogSource(params.LogSource); err != nil {
+		return "", err
+	}
+	if p.config.AuditFile != "" {
+		data, err := os.ReadFile(p.config.AuditFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read audit file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	nodes, err := p.nodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	for _, node := range nodes {
+		data, err := p.kubectl(ctx, "get", "--raw", nodeLogURL(node, p.config.LogPath))
+		if err != nil {
+			return "", fmt.Errorf("failed to read audit log on node %s: %w", node, err)
+		}
+		output.WriteString(data)
+		if data != "" && !strings.HasSuffix(data, "\n") {
+			output.WriteString("\n")
+		}
+	}
+	return output.String(), nil
+}
+
+// checkLogSource rejects log sources that only exist on OpenShift
+func (p *KubernetesProvider) checkLogSource(logSource string) error {
+	if logSource == "" || logSource == "kube-apiserver" {
+		return nil
+	}
+	return fmt.Errorf("log source %s is not available on Kubernetes clusters; only kube-apiserver audit logs are supported", logSource)
+}
+
+// nodes returns the configured nodes or the nodes matching the node selector
+func (p *KubernetesProvider) nodes(ctx context.Context) ([]string, error) {
+	if len(p.config.Nodes) > 0 {
+		return p.config.Nodes, nil
+	}
+
+	output, err := p.kubectl(ctx, "get", "nodes", "-l", p.config.NodeSelector, "-o", "jsonpath={.items[*].metadata.name}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var nodes []string
+	for _, node := range strings.Fields(output) {
+		if nodeNamePattern.MatchString(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes match %s; set AUDIT_K8S_NODES", p.config.NodeSelector)
+	}
+	return nodes, nil
+}
+
+// kubectl runs kubectl with args and returns its standard output
+func (p *KubernetesProvider) kubectl(ctx context.Context, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, p.config.Kubectl, args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("%w, output: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// nodeLogURL returns the API server path serving a file below a node's /var/log
+func nodeLogURL(node, logPath string) string {
+	return "/api/v1/nodes/" + node + "/proxy/logs/" + logPath
+}
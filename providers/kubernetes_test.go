@@ -0,0 +1,189 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// fakeKubectl writes a kubectl script that lists two control plane nodes and
+// prints one audit event per node
+func fakeKubectl(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kubectl")
+	script := `#!/bin/sh
+case "$*" in
+"get nodes -l node-role.kubernetes.io/control-plane -o jsonpath={.items[*].metadata.name}") printf 'cp-1 cp-2' ;;
+"get --raw /api/v1/nodes/cp-1/proxy/logs/kubernetes/audit/audit.log") echo '{"auditID":"a1"}' ;;
+"get --raw /api/v1/nodes/cp-2/proxy/logs/kubernetes/audit/audit.log") printf '{"auditID":"a2"}' ;;
+*) echo "error: unexpected call: $*" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake kubectl: %v", err)
+	}
+	return path
+}
+
+// TestKubernetesProvider_NodeProxy tests reading the audit log from discovered control plane nodes
+func TestKubernetesProvider_NodeProxy(t *testing.T) {
+	provider, err := NewKubernetesProvider(KubernetesConfig{Kubectl: fakeKubectl(t)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	command, err := provider.BuildQuery(types.AuditQueryParams{LogSource: "kube-apiserver"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(command, "get --raw /api/v1/nodes/{node}/proxy/logs/kubernetes/audit/audit.log (nodes -l node-role.kubernetes.io/control-plane)") {
+		t.Errorf("Unexpected command %q", command)
+	}
+
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "{\"auditID\":\"a1\"}\n{\"auditID\":\"a2\"}\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	// A node that cannot be read fails the fetch
+	provider.config.Nodes = []string{"cp-1", "cp-3"}
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "kube-apiserver"}); err == nil || !strings.Contains(err.Error(), "node cp-3") {
+		t.Errorf("Expected an error for node cp-3, got %v", err)
+	}
+
+	if _, err := provider.BuildQuery(types.AuditQueryParams{LogSource: "oauth-server"}); err == nil {
+		t.Error("Expected an error for an OpenShift-only log source")
+	}
+}
+
+// TestKubernetesProvider_AuditFile tests reading events from a local audit file
+func TestKubernetesProvider_AuditFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(file, []byte("{\"auditID\":\"a1\"}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write audit file: %v", err)
+	}
+	provider, err := NewKubernetesProvider(KubernetesConfig{AuditFile: file})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if command, _ := provider.BuildQuery(types.AuditQueryParams{LogSource: "kube-apiserver"}); command != "read "+file {
+		t.Errorf("Unexpected command %q", command)
+	}
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "kube-apiserver"})
+	if err != nil || output != "{\"auditID\":\"a1\"}\n" {
+		t.Errorf("Unexpected output %q, error %v", output, err)
+	}
+}
+
+// TestNewKubernetesProvider_Validation tests that unsafe node names and log paths are rejected
+func TestNewKubernetesProvider_Validation(t *testing.T) {
+	for _, config := range []KubernetesConfig{
+		{LogPath: "../etc/shadow"},
+		{LogPath: "audit.log; rm -rf /"},
+		{Nodes: []string{"Node_1"}},
+	} {
+		if _, err := NewKubernetesProvider(config); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+
+	provider, err := NewKubernetesProvider(KubernetesConfig{LogPath: "/var/log/kube-apiserver/audit.log"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider.config.LogPath != "kube-apiserver/audit.log" {
+		t.Errorf("Expected the /var/log prefix to be removed, got %s", provider.config.LogPath)
+	}
+}
+
+// TestFromEnv tests selecting the provider from the environment
+func TestFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "")
+	t.Setenv("AUDIT_LOKI_URL", "")
+	if provider, backends, err := FromEnv(); provider != nil || len(backends) != 0 || err != nil {
+		t.Errorf("Expected no provider for OpenShift, got %v, %v, %v", provider, backends, err)
+	}
+
+	t.Setenv("AUDIT_PROVIDER", "kubernetes")
+	t.Setenv("AUDIT_K8S_NODES", "cp-1, cp-2")
+	provider, backends, err := FromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider.Name() != ProviderKubernetes || len(provider.(*KubernetesProvider).config.Nodes) != 2 {
+		t.Errorf("Unexpected provider %+v", provider)
+	}
+	if len(backends) != 1 || backends[ProviderKubernetes] != provider {
+		t.Errorf("Unexpected backends %v", backends)
+	}
+
+	// Loki is configured alongside the default provider when its URL is set
+	t.Setenv("AUDIT_PROVIDER", "")
+	t.Setenv("AUDIT_LOKI_URL", "http://loki:3100")
+	provider, backends, err = FromEnv()
+	if err != nil || provider != nil || backends[ProviderLoki] == nil {
+		t.Errorf("Expected a Loki backend with the OpenShift default, got %v, %v, %v", provider, backends, err)
+	}
+
+	t.Setenv("AUDIT_PROVIDER", "eks")
+	if _, _, err := FromEnv(); err == nil {
+		t.Error("Expected an error for an unsupported provider")
+	}
+}
+
+// TestWebhookSink tests appending webhook event batches to the audit file
+func TestWebhookSink(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	sink := NewWebhookSink(file, "secret")
+
+	batch := `{"kind":"EventList","apiVersion":"audit.k8s.io/v1","items":[{"auditID":"a1", "verb":"get"},{"auditID":"a2"}]}`
+	tests := []struct {
+		name   string
+		method string
+		auth   string
+		body   string
+		status int
+	}{
+		{name: "missing token", method: http.MethodPost, body: batch, status: http.StatusUnauthorized},
+		{name: "wrong method", method: http.MethodGet, auth: "Bearer secret", status: http.StatusMethodNotAllowed},
+		{name: "invalid body", method: http.MethodPost, auth: "Bearer secret", body: "not json", status: http.StatusBadRequest},
+		{name: "event batch", method: http.MethodPost, auth: "Bearer secret", body: batch, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, "/audit/webhook", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				request.Header.Set("Authorization", tt.auth)
+			}
+			recorder := httptest.NewRecorder()
+			sink.ServeHTTP(recorder, request)
+			if recorder.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, recorder.Code)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read audit file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[0] != `{"auditID":"a1","verb":"get"}` {
+		t.Errorf("Unexpected audit file content %q", data)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil || event["auditID"] != "a2" {
+		t.Errorf("Unexpected second event %q", lines[1])
+	}
+}
@@ -0,0 +1,287 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// Loki provider defaults
+const (
+	// DefaultLokiSelector is the stream selector of audit logs shipped by
+	// OpenShift Logging
+	DefaultLokiSelector = `{log_type="audit"}`
+	DefaultLokiLimit    = 5000
+	// maxLokiResponseBytes bounds the size of a query response
+	maxLokiResponseBytes = 256 * 1024 * 1024
+)
+
+// labelNamePattern matches Loki label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LokiConfig configures the Loki provider
+type LokiConfig struct {
+	// URL is the Loki base URL, e.g. http://loki:3100
+	URL string
+	// Selector is the LogQL stream selector of the audit logs
+	Selector string
+	// LogSourceLabel, when set, is a label whose value is the log source
+	// (e.g. kube-apiserver); it is added to the selector for each query
+	LogSourceLabel string
+	// Token is sent as a bearer token and TenantID as X-Scope-OrgID
+	Token    string
+	TenantID string
+	// Limit is the maximum number of events a query returns
+	Limit   int
+	Timeout time.Duration
+}
+
+// LokiConfigFromEnv reads the Loki provider configuration from AUDIT_LOKI_URL,
+// AUDIT_LOKI_SELECTOR, AUDIT_LOKI_LOG_SOURCE_LABEL, AUDIT_LOKI_TOKEN,
+// AUDIT_LOKI_TENANT and AUDIT_LOKI_LIMIT
+func LokiConfigFromEnv() (LokiConfig, error) {
+	config := LokiConfig{
+		URL:            os.Getenv("AUDIT_LOKI_URL"),
+		Selector:       os.Getenv("AUDIT_LOKI_SELECTOR"),
+		LogSourceLabel: os.Getenv("AUDIT_LOKI_LOG_SOURCE_LABEL"),
+		Token:          os.Getenv("AUDIT_LOKI_TOKEN"),
+		TenantID:       os.Getenv("AUDIT_LOKI_TENANT"),
+	}
+	if value := os.Getenv("AUDIT_LOKI_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return config, fmt.Errorf("invalid AUDIT_LOKI_LIMIT: %s", value)
+		}
+		config.Limit = limit
+	}
+	return config, nil
+}
+
+// LokiProvider queries audit events stored in Loki
+type LokiProvider struct {
+	config LokiConfig
+	client *http.Client
+}
+
+// NewLokiProvider validates config, fills in defaults and creates the provider
+func NewLokiProvider(config LokiConfig) (*LokiProvider, error) {
+	parsed, err := url.Parse(config.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Loki URL %q", config.URL)
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	if config.Selector == "" {
+		config.Selector = DefaultLokiSelector
+	}
+	config.Selector = strings.TrimSpace(config.Selector)
+	if !strings.HasPrefix(config.Selector, "{") || !strings.HasSuffix(config.Selector, "}") {
+		return nil, fmt.Errorf("invalid Loki stream selector %q", config.Selector)
+	}
+	if config.LogSourceLabel != "" && !labelNamePattern.MatchString(config.LogSourceLabel) {
+		return nil, fmt.Errorf("invalid Loki label name %q", config.LogSourceLabel)
+	}
+	if config.Limit <= 0 {
+		config.Limit = DefaultLokiLimit
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &LokiProvider{config: config, client: &http.Client{Timeout: config.Timeout}}, nil
+}
+
+// Name returns the provider name
+func (p *LokiProvider) Name() string {
+	return ProviderLoki
+}
+
+// Capabilities reports that line filters and the time range are applied by Loki,
+// up to Limit events; which log sources have events depends on what is shipped
+func (p *LokiProvider) Capabilities() Capabilities {
+	return Capabilities{
+		LogSources:        []string{"kube-apiserver", "oauth-server", "node", "openshift-apiserver", "oauth-apiserver"},
+		ServerSideFilters: true,
+		TimeRange:         true,
+		MaxEvents:         p.config.Limit,
+	}
+}
+
+// BuildQuery describes the LogQL query run for params
+func (p *LokiProvider) BuildQuery(params types.AuditQueryParams) (string, error) {
+	start, end := queryRange(params.Timeframe, time.Now())
+	return fmt.Sprintf("loki query_range %s (%s to %s, limit %d)", p.LogQL(params),
+		start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), p.config.Limit), nil
+}
+
+// Execute runs the LogQL query for params over the timeframe's window and returns
+// the matching events, oldest first
+func (p *LokiProvider) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	start, end := queryRange(params.Timeframe, time.Now())
+	query := url.Values{}
+	query.Set("query", p.LogQL(params))
+	query.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	query.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	query.Set("limit", strconv.Itoa(p.config.Limit))
+	query.Set("direction", "forward")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.URL+"/loki/api/v1/query_range?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Loki request: %w", err)
+	}
+	if p.config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+p.config.Token)
+	}
+	if p.config.TenantID != "" {
+		request.Header.Set("X-Scope-OrgID", p.config.TenantID)
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Loki query failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxLokiResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read Loki response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Loki query failed with status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return parseLokiStreams(body)
+}
+
+// LogQL translates params into a LogQL query. Line filters narrow the events
+// Loki returns; they select a superset of the matching events, and the server
+// applies the exact filters to the fetched events in Go.
+func (p *LokiProvider) LogQL(params types.AuditQueryParams) string {
+	selector := p.config.Selector
+	if p.config.LogSourceLabel != "" {
+		logSource := params.LogSource
+		if logSource == "" {
+			logSource = "kube-apiserver"
+		}
+		matcher := fmt.Sprintf("%s=%q", p.config.LogSourceLabel, logSource)
+		if inner := strings.TrimSpace(selector[1 : len(selector)-1]); inner != "" {
+			matcher = inner + ", " + matcher
+		}
+		selector = "{" + matcher + "}"
+	}
+
+	parts := []string{selector}
+	fields := []struct {
+		values []string
+		mode   types.MatchMode
+	}{
+		{fieldValues(params.Username, params.Usernames), params.UsernameMatch},
+		{fieldValues(params.Verb, params.Verbs), params.VerbMatch},
+		{fieldValues(params.Resource, params.Resources), params.ResourceMatch},
+		{fieldValues(params.Namespace, params.Namespaces), params.NamespaceMatch},
+		{fieldValues(params.UserAgent, nil), params.UserAgentMatch},
+	}
+	for _, field := range fields {
+		// A regex match mode cannot be checked against the whole line
+		if field.mode == types.MatchModeRegex {
+			continue
+		}
+		if filter := lineFilter("|~", field.values); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	for _, pattern := range params.Patterns {
+		if filter := lineFilter("|~", []string{pattern}); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	for _, exclusion := range params.Exclude {
+		if filter := lineFilter("!~", []string{exclusion}); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	if datePattern := commands.TimeframeDatePattern(params.Timeframe); datePattern != "" {
+		parts = append(parts, fmt.Sprintf("|= %q", datePattern))
+	}
+	return strings.Join(parts, " ")
+}
+
+// lineFilter returns a case-insensitive LogQL line filter matching any of
+// values, or "" when a value could be escaped differently in the JSON line
+func lineFilter(operator string, values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || strings.ContainsAny(value, "\"\\`") {
+			return ""
+		}
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	if len(quoted) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s `(?i)(%s)`", operator, strings.Join(quoted, "|"))
+}
+
+// fieldValues combines a single-value field and its list form
+func fieldValues(single string, list []string) []string {
+	var values []string
+	if single != "" {
+		values = append(values, single)
+	}
+	return append(values, list...)
+}
+
+// lokiResponse is the part of a query_range response holding log streams
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Values [][2]string `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// parseLokiStreams returns the log lines of every stream in a query_range
+// response, ordered by timestamp
+func parseLokiStreams(body []byte) (string, error) {
+	var response lokiResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse Loki response: %w", err)
+	}
+	if response.Status != "success" {
+		return "", fmt.Errorf("Loki query failed with status %q", response.Status)
+	}
+	if response.Data.ResultType != "streams" {
+		return "", fmt.Errorf("unexpected Loki result type %q", response.Data.ResultType)
+	}
+
+	type logLine struct {
+		timestamp int64
+		line      string
+	}
+	var lines []logLine
+	for _, stream := range response.Data.Result {
+		for _, value := range stream.Values {
+			timestamp, _ := strconv.ParseInt(value[0], 10, 64)
+			lines = append(lines, logLine{timestamp: timestamp, line: value[1]})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].timestamp < lines[j].timestamp })
+
+	var output strings.Builder
+	for _, line := range lines {
+		output.WriteString(strings.TrimRight(line.line, "\n"))
+		output.WriteString("\n")
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,128 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestLokiProvider_LogQL tests translating query parameters into LogQL
+func TestLokiProvider_LogQL(t *testing.T) {
+	provider, err := NewLokiProvider(LokiConfig{URL: "http://loki:3100/", LogSourceLabel: "log_source"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	query := provider.LogQL(types.AuditQueryParams{
+		LogSource: "oauth-server",
+		Usernames: []string{"alice", "bob.smith"},
+		Verb:      "delete",
+		Resource:  "secrets",
+		Exclude:   []string{"system:"},
+	})
+	expected := "{log_type=\"audit\", log_source=\"oauth-server\"} |~ `(?i)(alice|bob\\.smith)` |~ `(?i)(delete)` |~ `(?i)(secrets)` !~ `(?i)(system:)`"
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+
+	// Regex match modes and values that need escaping are left to the Go filters
+	query = provider.LogQL(types.AuditQueryParams{Username: "^admin", UsernameMatch: types.MatchModeRegex, Namespace: "a\"b"})
+	if query != "{log_type=\"audit\", log_source=\"kube-apiserver\"}" {
+		t.Errorf("Expected only the selector, got %s", query)
+	}
+
+	if _, err := NewLokiProvider(LokiConfig{URL: "loki:3100"}); err == nil {
+		t.Error("Expected an error for a URL without a scheme")
+	}
+	if _, err := NewLokiProvider(LokiConfig{URL: "http://loki", Selector: "log_type=audit"}); err == nil {
+		t.Error("Expected an error for a selector without braces")
+	}
+	if _, err := NewLokiProvider(LokiConfig{URL: "http://loki", LogSourceLabel: "log-source"}); err == nil {
+		t.Error("Expected an error for an invalid label name")
+	}
+}
+
+// TestLokiProvider_Execute tests querying Loki and merging streams in timestamp order
+func TestLokiProvider_Execute(t *testing.T) {
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[
+			{"stream":{"node":"cp-2"},"values":[["300","{\"auditID\":\"a3\"}"],["100","{\"auditID\":\"a1\"}"]]},
+			{"stream":{"node":"cp-1"},"values":[["200","{\"auditID\":\"a2\"}\n"]]}]}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewLokiProvider(LokiConfig{URL: server.URL, Token: "secret", TenantID: "audit", Limit: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{Verb: "delete", Timeframe: "1h"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "{\"auditID\":\"a1\"}\n{\"auditID\":\"a2\"}\n{\"auditID\":\"a3\"}\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	if request.URL.Path != "/loki/api/v1/query_range" {
+		t.Errorf("Unexpected path %s", request.URL.Path)
+	}
+	if got := request.URL.Query().Get("query"); got != "{log_type=\"audit\"} |~ `(?i)(delete)`" {
+		t.Errorf("Unexpected query %s", got)
+	}
+	if request.URL.Query().Get("limit") != "10" || request.URL.Query().Get("start") == "" {
+		t.Errorf("Unexpected query parameters %v", request.URL.Query())
+	}
+	if request.Header.Get("Authorization") != "Bearer secret" || request.Header.Get("X-Scope-OrgID") != "audit" {
+		t.Errorf("Unexpected headers %v", request.Header)
+	}
+}
+
+// TestLokiProvider_ExecuteErrors tests reporting failed Loki queries
+func TestLokiProvider_ExecuteErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") == "1" {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+			return
+		}
+		http.Error(w, "parse error", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	provider, err := NewLokiProvider(LokiConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{}); err == nil || !strings.Contains(err.Error(), "status 400: parse error") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+
+	provider.config.Limit = 1
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{}); err == nil || !strings.Contains(err.Error(), "unexpected Loki result type") {
+		t.Errorf("Expected a result type error, got %v", err)
+	}
+}
+
+// TestLokiConfigFromEnv tests reading the Loki configuration from the environment
+func TestLokiConfigFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_LOKI_URL", "https://loki.example.com")
+	t.Setenv("AUDIT_LOKI_TENANT", "audit")
+	t.Setenv("AUDIT_LOKI_LIMIT", "100")
+	config, err := LokiConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.URL != "https://loki.example.com" || config.TenantID != "audit" || config.Limit != 100 {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Setenv("AUDIT_LOKI_LIMIT", "none")
+	if _, err := LokiConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid limit")
+	}
+}
@@ -0,0 +1,216 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// mockEvent is a canned audit event served by the mock provider. Timestamps are
+// filled in when the event is served, so the events always fall inside today's
+// or yesterday's part of the last 24 hours.
+type mockEvent struct {
+	logSource string
+	yesterday bool
+	event     string
+}
+
+// mockEvents is the canned audit data: a small investigation's worth of
+// deletions, permission denials, RBAC changes, exec sessions, service account
+// traffic and OAuth logins
+var mockEvents = []mockEvent{
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0001","verb":"delete","requestURI":"/api/v1/namespaces/payments/secrets/db-password","user":{"username":"alice","groups":["developers","system:authenticated"]},"sourceIPs":["10.0.1.15"],"userAgent":"oc/4.15.0","objectRef":{"resource":"secrets","namespace":"payments","name":"db-password","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0002","verb":"delete","requestURI":"/apis/apiextensions.k8s.io/v1/customresourcedefinitions/widgets.example.com","user":{"username":"alice","groups":["developers","system:authenticated"]},"sourceIPs":["10.0.1.15"],"userAgent":"oc/4.15.0","objectRef":{"resource":"customresourcedefinitions","name":"widgets.example.com","apiGroup":"apiextensions.k8s.io","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0003","verb":"get","requestURI":"/api/v1/namespaces/default/pods","user":{"username":"bob","groups":["system:authenticated"]},"sourceIPs":["10.0.2.20"],"userAgent":"kubectl/v1.29.0","objectRef":{"resource":"pods","namespace":"default","apiVersion":"v1"},"responseStatus":{"code":403,"reason":"Forbidden","message":"pods is forbidden: User \"bob\" cannot get resource \"pods\" in API group \"\" in the namespace \"default\""},"annotations":{"authorization.k8s.io/decision":"forbid","authorization.k8s.io/reason":""}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0004","verb":"list","requestURI":"/api/v1/namespaces/payments/secrets","user":{"username":"bob","groups":["system:authenticated"]},"sourceIPs":["10.0.2.20"],"userAgent":"kubectl/v1.29.0","objectRef":{"resource":"secrets","namespace":"payments","apiVersion":"v1"},"responseStatus":{"code":403,"reason":"Forbidden","message":"secrets is forbidden: User \"bob\" cannot list resource \"secrets\" in API group \"\" in the namespace \"payments\""},"annotations":{"authorization.k8s.io/decision":"forbid","authorization.k8s.io/reason":""}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0005","verb":"create","requestURI":"/api/v1/namespaces/default/pods","user":{"username":"admin","groups":["system:authenticated"]},"sourceIPs":["10.0.1.5"],"userAgent":"oc/4.15.0","objectRef":{"resource":"pods","namespace":"default","name":"web-1","apiVersion":"v1"},"responseStatus":{"code":201}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0006","verb":"list","requestURI":"/api/v1/pods","user":{"username":"system:serviceaccount:openshift-monitoring:prometheus-k8s","groups":["system:serviceaccounts","system:serviceaccounts:openshift-monitoring","system:authenticated"]},"sourceIPs":["10.128.0.30"],"userAgent":"Prometheus/2.48.0","objectRef":{"resource":"pods","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0007","verb":"create","requestURI":"/apis/rbac.authorization.k8s.io/v1/clusterrolebindings","user":{"username":"kube:admin","groups":["system:cluster-admins","system:authenticated"]},"sourceIPs":["10.0.1.5"],"userAgent":"oc/4.15.0","objectRef":{"resource":"clusterrolebindings","name":"alice-cluster-admin","apiGroup":"rbac.authorization.k8s.io","apiVersion":"v1"},"responseStatus":{"code":201}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0008","verb":"create","requestURI":"/api/v1/namespaces/payments/pods/api-7d9f/exec?command=sh&stdin=true&tty=true","user":{"username":"alice","groups":["developers","system:authenticated"]},"sourceIPs":["10.0.1.15"],"userAgent":"oc/4.15.0","objectRef":{"resource":"pods","subresource":"exec","namespace":"payments","name":"api-7d9f","apiVersion":"v1"},"responseStatus":{"code":101}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0009","verb":"patch","requestURI":"/apis/apps/v1/namespaces/payments/deployments/api","user":{"username":"system:serviceaccount:payments:deployer","groups":["system:serviceaccounts","system:serviceaccounts:payments","system:authenticated"]},"sourceIPs":["10.128.2.14"],"userAgent":"argocd-application-controller/v2.9.0","objectRef":{"resource":"deployments","namespace":"payments","name":"api","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0010","verb":"update","requestURI":"/apis/coordination.k8s.io/v1/namespaces/kube-system/leases/kube-controller-manager","user":{"username":"system:kube-controller-manager","groups":["system:authenticated"]},"sourceIPs":["10.0.0.10"],"userAgent":"kube-controller-manager/v1.29.0","objectRef":{"resource":"leases","namespace":"kube-system","name":"kube-controller-manager","apiGroup":"coordination.k8s.io","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0011","verb":"get","requestURI":"/api/v1/namespaces/default/configmaps/app-config","user":{"username":"alice","groups":["developers","system:authenticated"]},"sourceIPs":["203.0.113.10"],"userAgent":"kubectl/v1.29.0","objectRef":{"resource":"configmaps","namespace":"default","name":"app-config","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", event: `{"auditID":"mock-0012","verb":"get","requestURI":"/api/v1/namespaces/payments/secrets/registry-token","user":{"username":"system:serviceaccount:payments:deployer","groups":["system:serviceaccounts","system:serviceaccounts:payments","system:authenticated"]},"sourceIPs":["198.51.100.7"],"userAgent":"curl/8.4.0","objectRef":{"resource":"secrets","namespace":"payments","name":"registry-token","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", yesterday: true, event: `{"auditID":"mock-0013","verb":"delete","requestURI":"/apis/apiextensions.k8s.io/v1/customresourcedefinitions/gadgets.example.com","user":{"username":"carol","groups":["platform","system:authenticated"]},"sourceIPs":["10.0.3.8"],"userAgent":"oc/4.15.0","objectRef":{"resource":"customresourcedefinitions","name":"gadgets.example.com","apiGroup":"apiextensions.k8s.io","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", yesterday: true, event: `{"auditID":"mock-0014","verb":"delete","requestURI":"/api/v1/namespaces/staging","user":{"username":"kube:admin","groups":["system:cluster-admins","system:authenticated"]},"sourceIPs":["10.0.1.5"],"userAgent":"oc/4.15.0","objectRef":{"resource":"namespaces","name":"staging","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", yesterday: true, event: `{"auditID":"mock-0015","verb":"get","requestURI":"/api/v1/namespaces/default/pods/web-0","user":{"username":"bob","groups":["system:authenticated"]},"sourceIPs":["10.0.2.20"],"userAgent":"kubectl/v1.29.0","objectRef":{"resource":"pods","namespace":"default","name":"web-0","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "kube-apiserver", yesterday: true, event: `{"auditID":"mock-0016","verb":"list","requestURI":"/api/v1/nodes","user":{"username":"system:serviceaccount:openshift-monitoring:prometheus-k8s","groups":["system:serviceaccounts","system:serviceaccounts:openshift-monitoring","system:authenticated"]},"sourceIPs":["10.128.0.30"],"userAgent":"Prometheus/2.48.0","objectRef":{"resource":"nodes","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+	{logSource: "openshift-apiserver", event: `{"auditID":"mock-0101","verb":"create","requestURI":"/apis/route.openshift.io/v1/namespaces/frontend/routes","user":{"username":"alice","groups":["developers","system:authenticated"]},"sourceIPs":["10.0.1.15"],"userAgent":"oc/4.15.0","objectRef":{"resource":"routes","namespace":"frontend","name":"shop","apiGroup":"route.openshift.io","apiVersion":"v1"},"responseStatus":{"code":201}}`},
+	{logSource: "openshift-apiserver", event: `{"auditID":"mock-0102","verb":"delete","requestURI":"/apis/project.openshift.io/v1/projects/sandbox","user":{"username":"bob","groups":["system:authenticated"]},"sourceIPs":["10.0.2.20"],"userAgent":"oc/4.15.0","objectRef":{"resource":"projects","name":"sandbox","apiGroup":"project.openshift.io","apiVersion":"v1"},"responseStatus":{"code":403,"reason":"Forbidden"},"annotations":{"authorization.k8s.io/decision":"forbid","authorization.k8s.io/reason":""}}`},
+	{logSource: "oauth-server", event: `{"auditID":"mock-0201","verb":"post","requestURI":"/login","user":{"username":"system:anonymous","groups":["system:unauthenticated"]},"sourceIPs":["203.0.113.50"],"userAgent":"Mozilla/5.0","responseStatus":{"code":401,"message":"authentication failed: invalid credentials"},"annotations":{"authentication.openshift.io/decision":"deny","authentication.openshift.io/username":"alice"}}`},
+	{logSource: "oauth-server", event: `{"auditID":"mock-0202","verb":"post","requestURI":"/login","user":{"username":"system:anonymous","groups":["system:unauthenticated"]},"sourceIPs":["203.0.113.50"],"userAgent":"Mozilla/5.0","responseStatus":{"code":401,"message":"authentication failed: invalid credentials"},"annotations":{"authentication.openshift.io/decision":"deny","authentication.openshift.io/username":"alice"}}`},
+	{logSource: "oauth-server", event: `{"auditID":"mock-0203","verb":"post","requestURI":"/login","user":{"username":"system:anonymous","groups":["system:unauthenticated"]},"sourceIPs":["203.0.113.50"],"userAgent":"Mozilla/5.0","responseStatus":{"code":401,"message":"authentication failed: invalid credentials"},"annotations":{"authentication.openshift.io/decision":"deny","authentication.openshift.io/username":"alice"}}`},
+	{logSource: "oauth-server", event: `{"auditID":"mock-0204","verb":"post","requestURI":"/login","user":{"username":"system:anonymous","groups":["system:unauthenticated"]},"sourceIPs":["10.0.2.20"],"userAgent":"Mozilla/5.0","responseStatus":{"code":302},"annotations":{"authentication.openshift.io/decision":"allow","authentication.openshift.io/username":"bob"}}`},
+	{logSource: "oauth-apiserver", event: `{"auditID":"mock-0301","verb":"create","requestURI":"/apis/oauth.openshift.io/v1/oauthaccesstokens","user":{"username":"system:serviceaccount:openshift-authentication:oauth-openshift","groups":["system:serviceaccounts","system:authenticated"]},"sourceIPs":["10.128.0.45"],"userAgent":"oauth-server/v0.0.0","objectRef":{"resource":"oauthaccesstokens","name":"sha256~bob","apiGroup":"oauth.openshift.io","apiVersion":"v1"},"responseStatus":{"code":201}}`},
+	{logSource: "oauth-apiserver", event: `{"auditID":"mock-0302","verb":"delete","requestURI":"/apis/oauth.openshift.io/v1/useroauthaccesstokens/sha256~alice","user":{"username":"alice","groups":["developers","system:authenticated"]},"sourceIPs":["10.0.1.15"],"userAgent":"oc/4.15.0","objectRef":{"resource":"useroauthaccesstokens","name":"sha256~alice","apiGroup":"oauth.openshift.io","apiVersion":"v1"},"responseStatus":{"code":200}}`},
+}
+
+// MockConfig configures the mock provider. With DataDir set, each log source's
+// events are read from <DataDir>/<log source>.log; otherwise the canned events
+// are served.
+type MockConfig struct {
+	DataDir string
+}
+
+// MockConfigFromEnv reads the mock provider configuration from AUDIT_MOCK_DATA_DIR
+func MockConfigFromEnv() MockConfig {
+	return MockConfig{DataDir: os.Getenv("AUDIT_MOCK_DATA_DIR")}
+}
+
+// MockProvider serves fixed audit data without a cluster, so integration tests
+// and demos run deterministically
+type MockProvider struct {
+	config MockConfig
+	now    func() time.Time
+}
+
+// NewMockProvider validates config and creates the provider
+func NewMockProvider(config MockConfig) (*MockProvider, error) {
+	if config.DataDir != "" {
+		info, err := os.Stat(config.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock data directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid mock data directory: %s is not a directory", config.DataDir)
+		}
+	}
+	return &MockProvider{config: config, now: time.Now}, nil
+}
+
+// Name returns the provider name
+func (p *MockProvider) Name() string {
+	return ProviderMock
+}
+
+// Capabilities reports the log sources with data; every filter is applied in Go
+func (p *MockProvider) Capabilities() Capabilities {
+	if p.config.DataDir != "" {
+		return Capabilities{LogSources: utils.ValidLogSources}
+	}
+	var logSources []string
+	for _, event := range mockEvents {
+		if !utils.Contains(logSources, event.logSource) {
+			logSources = append(logSources, event.logSource)
+		}
+	}
+	return Capabilities{LogSources: logSources}
+}
+
+// BuildQuery describes where the events of the query's log source come from
+func (p *MockProvider) BuildQuery(params types.AuditQueryParams) (string, error) {
+	logSource, err := p.logSource(params.LogSource)
+	if err != nil {
+		return "", err
+	}
+	if p.config.DataDir != "" {
+		return "read " + p.dataFile(logSource), nil
+	}
+	return "mock read " + logSource, nil
+}
+
+// Execute returns the events of the query's log source. A log source without a
+// data file has no events.
+func (p *MockProvider) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	logSource, err := p.logSource(params.LogSource)
+	if err != nil {
+		return "", err
+	}
+
+	if p.config.DataDir != "" {
+		data, err := os.ReadFile(p.dataFile(logSource))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", nil
+			}
+			return "", fmt.Errorf("failed to read mock data: %w", err)
+		}
+		return string(data), nil
+	}
+	return MockAuditLog(logSource, p.now())
+}
+
+// logSource returns the query's log source, rejecting those without data
+func (p *MockProvider) logSource(logSource string) (string, error) {
+	if logSource == "" {
+		logSource = "kube-apiserver"
+	}
+	if !utils.Contains(p.Capabilities().LogSources, logSource) {
+		return "", fmt.Errorf("log source %s has no mock data", logSource)
+	}
+	return logSource, nil
+}
+
+// dataFile returns the data file of a log source
+func (p *MockProvider) dataFile(logSource string) string {
+	return filepath.Join(p.config.DataDir, logSource+".log")
+}
+
+// MockAuditLog returns the canned events of a log source as JSON lines, timed
+// relative to now. Today's events are spread over the last hour, or since
+// midnight if that is later, and yesterday's over the part of yesterday within
+// the last 24 hours, so "1h", "today" and "24h" queries always find them.
+func MockAuditLog(logSource string, now time.Time) (string, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	todayStart := now.Add(-time.Hour)
+	if todayStart.Before(midnight) {
+		todayStart = midnight
+	}
+	yesterdayStart := now.Add(-24 * time.Hour)
+	if start := midnight.AddDate(0, 0, -1); yesterdayStart.Before(start) {
+		yesterdayStart = start
+	}
+
+	var today, yesterday []map[string]interface{}
+	for _, canned := range mockEvents {
+		if canned.logSource != logSource {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(canned.event), &event); err != nil {
+			return "", fmt.Errorf("invalid mock event: %w", err)
+		}
+		if canned.yesterday {
+			yesterday = append(yesterday, event)
+		} else {
+			today = append(today, event)
+		}
+	}
+
+	var output strings.Builder
+	for _, group := range []struct {
+		events     []map[string]interface{}
+		start, end time.Time
+	}{
+		{yesterday, yesterdayStart, midnight},
+		{today, todayStart, now},
+	} {
+		step := group.end.Sub(group.start) / time.Duration(len(group.events)+1)
+		for i, event := range group.events {
+			timestamp := group.start.Add(step * time.Duration(i+1))
+			event["kind"] = "Event"
+			event["apiVersion"] = "audit.k8s.io/v1"
+			event["level"] = "Metadata"
+			event["stage"] = "ResponseComplete"
+			event["requestReceivedTimestamp"] = timestamp.Format(time.RFC3339Nano)
+			event["stageTimestamp"] = timestamp.Add(5 * time.Millisecond).Format(time.RFC3339Nano)
+			line, err := json.Marshal(event)
+			if err != nil {
+				return "", err
+			}
+			output.Write(line)
+			output.WriteString("\n")
+		}
+	}
+	return output.String(), nil
+}
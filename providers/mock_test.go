@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestMockProvider_CannedEvents tests serving the canned events of each log source
+func TestMockProvider_CannedEvents(t *testing.T) {
+	provider, err := NewMockProvider(MockConfig{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Date(2026, 3, 10, 0, 20, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	command, err := provider.BuildQuery(types.AuditQueryParams{})
+	if err != nil || command != "mock read kube-apiserver" {
+		t.Errorf("Unexpected command %q, %v", command, err)
+	}
+
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "kube-apiserver"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 16 {
+		t.Fatalf("Expected 16 kube-apiserver events, got %d", len(lines))
+	}
+	var previous time.Time
+	for _, line := range lines {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Invalid event %q: %v", line, err)
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, event["requestReceivedTimestamp"].(string))
+		if err != nil {
+			t.Fatalf("Invalid timestamp: %v", err)
+		}
+		// Events are in order and within the last 24 hours, split at midnight
+		if !timestamp.After(previous) || timestamp.After(now) || timestamp.Before(now.Add(-24*time.Hour)) {
+			t.Errorf("Unexpected timestamp %s", timestamp)
+		}
+		previous = timestamp
+		day := "2026-03-10"
+		if event["auditID"].(string) >= "mock-0013" {
+			day = "2026-03-09"
+		}
+		if !strings.HasPrefix(event["requestReceivedTimestamp"].(string), day) {
+			t.Errorf("Event %s dated %s, expected %s", event["auditID"], event["requestReceivedTimestamp"], day)
+		}
+		if event["stage"] != "ResponseComplete" || event["kind"] != "Event" {
+			t.Errorf("Missing event metadata in %q", line)
+		}
+	}
+
+	// The same time serves the same events
+	again, _ := provider.Execute(context.Background(), types.AuditQueryParams{})
+	if again != output {
+		t.Error("Expected the same events for the default log source")
+	}
+
+	output, _ = provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "oauth-server"})
+	if strings.Count(output, `"deny"`) != 3 {
+		t.Errorf("Expected 3 failed logins, got %q", output)
+	}
+
+	// Log sources without canned events are rejected
+	if _, err := provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "node"}); err == nil {
+		t.Error("Expected an error for a log source without mock data")
+	}
+	capabilities := provider.Capabilities()
+	if len(capabilities.LogSources) != 4 || capabilities.ServerSideFilters {
+		t.Errorf("Unexpected capabilities %+v", capabilities)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.Execute(ctx, types.AuditQueryParams{}); err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+}
+
+// TestMockProvider_DataDir tests serving audit data from files
+func TestMockProvider_DataDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "node.log"), []byte("{\"auditID\":\"n1\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewMockProvider(MockConfig{DataDir: dir})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	command, _ := provider.BuildQuery(types.AuditQueryParams{LogSource: "node"})
+	if command != "read "+filepath.Join(dir, "node.log") {
+		t.Errorf("Unexpected command %q", command)
+	}
+	output, err := provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "node"})
+	if err != nil || output != "{\"auditID\":\"n1\"}\n" {
+		t.Errorf("Unexpected output %q, %v", output, err)
+	}
+
+	// A log source without a file has no events
+	output, err = provider.Execute(context.Background(), types.AuditQueryParams{LogSource: "kube-apiserver"})
+	if err != nil || output != "" {
+		t.Errorf("Unexpected output %q, %v", output, err)
+	}
+
+	if _, err := NewMockProvider(MockConfig{DataDir: filepath.Join(dir, "node.log")}); err == nil {
+		t.Error("Expected an error for a data directory that is a file")
+	}
+}
+
+// TestFromEnv_Mock tests selecting the mock provider
+func TestFromEnv_Mock(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "mock")
+	provider, backends, err := FromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider == nil || provider.Name() != ProviderMock || backends[ProviderMock] == nil {
+		t.Errorf("Expected the mock provider, got %v", provider)
+	}
+
+	t.Setenv("AUDIT_PROVIDER", "")
+	if _, backends, _ := FromEnv(); backends[ProviderMock] != nil {
+		t.Error("The mock provider should only be created when selected")
+	}
+}
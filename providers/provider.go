@@ -0,0 +1,194 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// Supported providers
+const (
+	ProviderOpenShift     = "openshift"
+	ProviderKubernetes    = "kubernetes"
+	ProviderLoki          = "loki"
+	ProviderElasticsearch = "elasticsearch"
+	ProviderCloudWatch    = "cloudwatch"
+	ProviderInCluster     = "in-cluster"
+	ProviderMock          = "mock"
+)
+
+// DefaultQueryRange is the time range log store backends query when the
+// timeframe has no window
+const DefaultQueryRange = 24 * time.Hour
+
+// QueryBackend reads the raw audit events for a query from a log store on
+// clusters, or for queries, that do not use oc adm node-logs. The server applies
+// every query filter to the returned events in Go, as with in-process filtering.
+type QueryBackend interface {
+	// Name returns the backend name, e.g. "kubernetes"
+	Name() string
+	// Capabilities describes what the backend can read and do itself
+	Capabilities() Capabilities
+	// BuildQuery describes how the events for params are read; it is reported
+	// as the query command
+	BuildQuery(params types.AuditQueryParams) (string, error)
+	// Execute returns the raw audit log lines for params; backends may narrow
+	// the events with the query filters but must not drop matching events
+	Execute(ctx context.Context, params types.AuditQueryParams) (string, error)
+}
+
+// Capabilities describes a query backend
+type Capabilities struct {
+	// LogSources are the log sources the backend can read
+	LogSources []string `json:"log_sources"`
+	// ServerSideFilters is set when the backend narrows events with the query
+	// filters before returning them
+	ServerSideFilters bool `json:"server_side_filters"`
+	// TimeRange is set when only events in the timeframe's window are read
+	TimeRange bool `json:"time_range"`
+	// Pagination is set when results larger than one response are paged
+	Pagination bool `json:"pagination"`
+	// MaxEvents is the most events a query returns; 0 means unbounded
+	MaxEvents int `json:"max_events,omitempty"`
+}
+
+// CountingBackend is implemented by backends that can count the events a query
+// matches without fetching them
+type CountingBackend interface {
+	// Count returns how many events Execute would read for params, before
+	// the limit on the events a query returns
+	Count(ctx context.Context, params types.AuditQueryParams) (int, error)
+}
+
+// TimeoutBackend is implemented by backends whose queries can take longer than
+// the server's default execution timeout
+type TimeoutBackend interface {
+	// ExecuteTimeout returns how long Execute may take
+	ExecuteTimeout() time.Duration
+}
+
+// registration describes how a backend is configured from the environment
+type registration struct {
+	name string
+	// configured reports whether the backend's settings are present
+	configured func() bool
+	create     func() (QueryBackend, error)
+}
+
+// registrations lists the backends created at startup. A backend is created
+// when its settings are present or AUDIT_PROVIDER selects it.
+var registrations = []registration{
+	{
+		name:       ProviderLoki,
+		configured: func() bool { return os.Getenv("AUDIT_LOKI_URL") != "" },
+		create: func() (QueryBackend, error) {
+			config, err := LokiConfigFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			return NewLokiProvider(config)
+		},
+	},
+	{
+		name:       ProviderElasticsearch,
+		configured: func() bool { return os.Getenv("AUDIT_ES_URL") != "" },
+		create: func() (QueryBackend, error) {
+			config, err := ElasticsearchConfigFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			return NewElasticsearchProvider(config)
+		},
+	},
+	{
+		name:       ProviderCloudWatch,
+		configured: func() bool { return os.Getenv("AUDIT_CLOUDWATCH_LOG_GROUPS") != "" },
+		create: func() (QueryBackend, error) {
+			config, err := CloudWatchConfigFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			return NewCloudWatchProvider(config)
+		},
+	},
+	{
+		// The mock serves test data, so it is only created when selected
+		name:       ProviderMock,
+		configured: func() bool { return false },
+		create: func() (QueryBackend, error) {
+			return NewMockProvider(MockConfigFromEnv())
+		},
+	},
+	{
+		// In-cluster reads the pod's own cluster, so it is only created when selected
+		name:       ProviderInCluster,
+		configured: func() bool { return false },
+		create: func() (QueryBackend, error) {
+			config, err := InClusterConfigFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			return NewInClusterProvider(config)
+		},
+	},
+	{
+		// Kubernetes has no required settings, so it is only created as the default
+		name:       ProviderKubernetes,
+		configured: func() bool { return false },
+		create: func() (QueryBackend, error) {
+			return NewKubernetesProvider(KubernetesConfigFromEnv())
+		},
+	},
+}
+
+// FromEnv creates the registered backends that are configured and returns the
+// default backend selected by AUDIT_PROVIDER along with every backend by name.
+// The default is nil for "openshift", the default: the server then builds
+// oc adm node-logs commands.
+func FromEnv() (QueryBackend, map[string]QueryBackend, error) {
+	name := strings.ToLower(os.Getenv("AUDIT_PROVIDER"))
+	supported := []string{ProviderOpenShift}
+	for _, entry := range registrations {
+		supported = append(supported, entry.name)
+	}
+	if name != "" && !containsName(supported, name) {
+		return nil, nil, fmt.Errorf("unsupported provider %q (supported: %s)", name, strings.Join(supported, ", "))
+	}
+
+	configured := make(map[string]QueryBackend)
+	for _, entry := range registrations {
+		if entry.name != name && !entry.configured() {
+			continue
+		}
+		backend, err := entry.create()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s backend: %w", entry.name, err)
+		}
+		configured[entry.name] = backend
+	}
+	return configured[name], configured, nil
+}
+
+// containsName reports whether names contains name
+func containsName(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// queryRange returns the time range to query for a timeframe, defaulting to the
+// last DefaultQueryRange
+func queryRange(timeframe string, now time.Time) (time.Time, time.Time) {
+	if start, end, ok := commands.TimeframeWindow(timeframe, now); ok {
+		return start, end
+	}
+	return now.Add(-DefaultQueryRange), now
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// maxWebhookBatchBytes bounds the size of one event batch the sink accepts
+const maxWebhookBatchBytes = 64 * 1024 * 1024
+
+// WebhookSink receives audit event batches from the kube-apiserver webhook
+// backend (--audit-webhook-config-file) and appends each event as a JSON line to
+// a file, which the Kubernetes provider reads with AUDIT_K8S_AUDIT_FILE
+type WebhookSink struct {
+	path string
+	// token, when set, must be sent as a bearer token
+	token string
+	mutex sync.Mutex
+}
+
+// NewWebhookSink creates a sink appending events to path
+func NewWebhookSink(path, token string) *WebhookSink {
+	return &WebhookSink{path: path, token: token}
+}
+
+// WebhookSinkFromEnv returns the sink enabled by AUDIT_K8S_WEBHOOK_SINK, writing
+// to AUDIT_K8S_AUDIT_FILE and requiring AUDIT_K8S_WEBHOOK_TOKEN when set; it
+// returns nil when the sink is disabled
+func WebhookSinkFromEnv() (*WebhookSink, error) {
+	enabled, _ := strconv.ParseBool(os.Getenv("AUDIT_K8S_WEBHOOK_SINK"))
+	if !enabled {
+		return nil, nil
+	}
+	path := os.Getenv("AUDIT_K8S_AUDIT_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("AUDIT_K8S_WEBHOOK_SINK requires AUDIT_K8S_AUDIT_FILE")
+	}
+	return NewWebhookSink(path, os.Getenv("AUDIT_K8S_WEBHOOK_TOKEN")), nil
+}
+
+// ServeHTTP accepts an audit.k8s.io EventList and appends its events
+func (s *WebhookSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBatchBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxWebhookBatchBytes {
+		http.Error(w, "event batch too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var events struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(body, &events); err != nil {
+		http.Error(w, "invalid event list", http.StatusBadRequest)
+		return
+	}
+	if err := s.Append(events.Items); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Append writes events to the sink file, one compact JSON object per line
+func (s *WebhookSink) Append(events []json.RawMessage) error {
+	var lines bytes.Buffer
+	for _, event := range events {
+		if err := json.Compact(&lines, event); err != nil {
+			return fmt.Errorf("invalid audit event: %w", err)
+		}
+		lines.WriteByte('\n')
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	if _, err := file.Write(lines.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit file: %w", err)
+	}
+	return file.Close()
+}
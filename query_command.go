@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"audit-query-mcp-server/reporting"
+	"audit-query-mcp-server/server"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	"github.com/spf13/pflag"
+)
+
+// defaultQueryColumns are the entry fields the query subcommand prints as a
+// table unless --columns selects others
+var defaultQueryColumns = []string{"timestamp", "username", "verb", "resource", "namespace", "name", "status_code"}
+
+// listFlag is a comma-separated flag value; setting it again replaces the list
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func (l *listFlag) Type() string {
+	return "strings"
+}
+
+// queryFormats and exportFormats are the output formats of the query and
+// export subcommands
+var (
+	queryFormats  = []string{"table", "json"}
+	exportFormats = []string{reporting.FormatMarkdown, reporting.FormatHTML, "csv", "json"}
+)
+
+// queryFlags are the flags of the query and export subcommands
+type queryFlags struct {
+	params     types.AuditQueryParams
+	paramsJSON string
+	format     string
+	columns    listFlag
+	progress   bool
+	// title and output are the report title and output file of export
+	title  string
+	output string
+}
+
+// addQueryFlags defines the flags of the query or export subcommand, whose
+// structured parameters are set in config.params
+func addQueryFlags(flags *pflag.FlagSet, name string, config *queryFlags) {
+	params := &config.params
+	flags.StringVar(&params.LogSource, "log-source", "", "Log source to query (default: kube-apiserver)")
+	flags.StringVar(&params.Timeframe, "timeframe", "", "Timeframe, e.g. 1h, 24h, today or 7d")
+	flags.StringVar(&params.Username, "username", "", "Filter by username")
+	flags.Var((*listFlag)(&params.Usernames), "usernames", "Filter by any of these comma-separated usernames")
+	flags.StringVar(&params.Verb, "verb", "", "Filter by verb")
+	flags.Var((*listFlag)(&params.Verbs), "verbs", "Filter by any of these comma-separated verbs")
+	flags.StringVar(&params.Resource, "resource", "", "Filter by resource")
+	flags.Var((*listFlag)(&params.Resources), "resources", "Filter by any of these comma-separated resources")
+	flags.StringVar(&params.Namespace, "namespace", "", "Filter by namespace")
+	flags.Var((*listFlag)(&params.Namespaces), "namespaces", "Filter by any of these comma-separated namespaces")
+	flags.Var((*listFlag)(&params.ExcludeUsers), "exclude-users", "Comma-separated users to leave out, e.g. system:*")
+	flags.Var((*listFlag)(&params.ExcludeNamespaces), "exclude-namespaces", "Comma-separated namespaces to leave out")
+	flags.Var((*listFlag)(&params.ExcludeVerbs), "exclude-verbs", "Comma-separated verbs to leave out")
+	flags.Var((*listFlag)(&params.ExcludeResources), "exclude-resources", "Comma-separated resources to leave out")
+	flags.IntVar(&params.StatusCode, "status-code", 0, "Filter by response status code")
+	flags.StringVar(&params.SourceIP, "source-ip", "", "Filter by source IP")
+	flags.StringVar(&params.UserAgent, "user-agent", "", "Filter by user agent")
+	flags.StringVar(&params.SortBy, "sort-by", "", "Sort entries by "+formatList(utils.ResultSortFields))
+	flags.StringVar(&params.SortOrder, "sort-order", "", "Sort order, "+formatList(utils.ResultSortOrders))
+	flags.IntVar(&params.Limit, "limit", 0, "Return at most this many entries")
+	flags.StringVar(&config.paramsJSON, "params", "", "Structured parameters as JSON, as passed to the MCP tools; other parameter flags override them")
+	columns := "Comma-separated entry fields printed as table columns"
+	if name == "export" {
+		flags.StringVar(&config.format, "format", reporting.FormatMarkdown, "Output format: "+formatList(exportFormats))
+		flags.StringVar(&config.title, "title", "", "Title of a markdown or html report (default: Audit Report)")
+		flags.StringVar(&config.output, "output", "", "File to write to instead of stdout")
+		columns = "Comma-separated entry fields written as CSV columns"
+	} else {
+		flags.StringVar(&config.format, "format", "table", "Output format: "+formatList(queryFormats))
+	}
+	flags.Var(&config.columns, "columns", columns+" (default: "+strings.Join(defaultQueryColumns, ",")+")")
+	flags.BoolVar(&config.progress, "progress", false, "Print the pipeline stages to stderr")
+}
+
+// formatList joins formats as "a, b or c"
+func formatList(formats []string) string {
+	if len(formats) < 2 {
+		return strings.Join(formats, "")
+	}
+	return strings.Join(formats[:len(formats)-1], ", ") + " or " + formats[len(formats)-1]
+}
+
+// finishQueryFlags checks the parsed flags of the query or export subcommand,
+// merges --params into config.params, and returns the natural-language
+// question of the arguments, empty unless given instead of parameter flags
+func finishQueryFlags(flags *pflag.FlagSet, name string, config *queryFlags, args []string) (string, error) {
+	formats := queryFormats
+	if name == "export" {
+		formats = exportFormats
+	}
+	if !utils.Contains(formats, config.format) {
+		return "", fmt.Errorf("unknown format %q, expected %s", config.format, formatList(formats))
+	}
+	for _, column := range config.columns {
+		if !utils.Contains(server.EntryFields, column) {
+			return "", fmt.Errorf("unknown column %q, expected entry fields such as %s", column, strings.Join(defaultQueryColumns, ", "))
+		}
+	}
+
+	parameterFlags := 0
+	flags.Visit(func(f *pflag.Flag) {
+		switch f.Name {
+		case "format", "columns", "progress", "title", "output":
+		default:
+			parameterFlags++
+		}
+	})
+	question := strings.TrimSpace(strings.Join(args, " "))
+	if question != "" && parameterFlags > 0 {
+		return "", fmt.Errorf("give either parameter flags or a question, not both")
+	}
+
+	if config.paramsJSON != "" {
+		// Parameter flags override the JSON parameters: set the changed
+		// flags again over the decoded ones, set after the flags reset them
+		// to defaults
+		var fromJSON types.AuditQueryParams
+		if err := json.Unmarshal([]byte(config.paramsJSON), &fromJSON); err != nil {
+			return "", fmt.Errorf("invalid --params: %w", err)
+		}
+		merged := &queryFlags{}
+		reparse := pflag.NewFlagSet(name, pflag.ContinueOnError)
+		addQueryFlags(reparse, name, merged)
+		merged.params = fromJSON
+		var err error
+		flags.Visit(func(f *pflag.Flag) {
+			if err == nil {
+				err = reparse.Set(f.Name, f.Value.String())
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+		config.params = merged.params
+	}
+	if config.params.LogSource == "" {
+		config.params.LogSource = "kube-apiserver"
+	}
+	utils.ResolveQueryResources(&config.params)
+	return question, nil
+}
+
+// runQuery runs the full query pipeline locally, from parameter flags or a
+// natural-language question, and prints the entries as a table or the
+// result as JSON
+func runQuery(srv *server.AuditQueryMCPServer, config *queryFlags, question string) {
+	result, err := executeQuery(srv, config, question)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.format == "json" {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s\n", result.Command)
+	writeEntryTable(os.Stdout, result.ParsedData, config.entryColumns())
+	fmt.Println(result.Summary)
+}
+
+// runExport runs the full query pipeline like runQuery and writes the result
+// as a markdown or HTML report, its entries as CSV, or the result as JSON, to
+// stdout or the --output file
+func runExport(srv *server.AuditQueryMCPServer, config *queryFlags, question string) {
+	result, err := executeQuery(srv, config, question)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	content, err := exportResult(srv, result, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if config.output == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := os.WriteFile(config.output, []byte(content), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Exported %d entries of query %s to %s\n", len(result.ParsedData), result.QueryID, config.output)
+}
+
+// executeQuery runs the query of the parameters, or answers the question
+// when one is given, printing its interpretation to stderr
+func executeQuery(srv *server.AuditQueryMCPServer, config *queryFlags, question string) (*types.AuditResult, error) {
+	if question != "" {
+		interpretation, result, err := srv.AskAuditQuestion(question)
+		if interpretation != nil {
+			interpreted, _ := json.Marshal(interpretation.Params)
+			fmt.Fprintf(os.Stderr, "Interpreted as %s\n", interpreted)
+			for _, note := range interpretation.Notes {
+				fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+			}
+		}
+		return result, err
+	}
+	var progress server.ProgressFunc
+	if config.progress {
+		progress = func(percent float64, message string) {
+			fmt.Fprintf(os.Stderr, "[%3.0f%%] %s\n", percent, message)
+		}
+	}
+	return srv.ExecuteCompleteAuditQueryWithProgress(config.params, progress)
+}
+
+// entryColumns returns the --columns, or the default columns without them
+func (config *queryFlags) entryColumns() []string {
+	if len(config.columns) == 0 {
+		return defaultQueryColumns
+	}
+	return config.columns
+}
+
+// exportResult renders a result in the export format
+func exportResult(srv *server.AuditQueryMCPServer, result *types.AuditResult, config *queryFlags) (string, error) {
+	switch config.format {
+	case "json":
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(output) + "\n", nil
+	case "csv":
+		var output strings.Builder
+		if err := writeEntryCSV(&output, result.ParsedData, config.entryColumns()); err != nil {
+			return "", err
+		}
+		return output.String(), nil
+	default:
+		content, _, err := srv.GenerateAuditReport(result, config.format, config.title, "")
+		return content, err
+	}
+}
+
+// writeEntryTable writes the columns of the entries as an aligned table, with
+// "-" for fields an entry does not have
+func writeEntryTable(w io.Writer, entries []map[string]interface{}, columns []string) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(table, strings.Join(headers, "\t"))
+	for _, entry := range entries {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = tableCell(entry[column])
+		}
+		fmt.Fprintln(table, strings.Join(cells, "\t"))
+	}
+	table.Flush()
+}
+
+// writeEntryCSV writes the columns of the entries as CSV with a header row,
+// leaving fields an entry does not have empty
+func writeEntryCSV(w io.Writer, entries []map[string]interface{}, columns []string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = formatEntryValue(entry[column])
+		}
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// tableCell formats an entry field for a table, keeping it on one line
+func tableCell(value interface{}) string {
+	cell := formatEntryValue(value)
+	if cell == "" {
+		return "-"
+	}
+	return strings.NewReplacer("\t", " ", "\n", " ").Replace(cell)
+}
+
+// formatEntryValue formats an entry field as text, with lists and objects as
+// JSON and nil as the empty string
+func formatEntryValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%g", v)
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// parseQueryArgs parses the arguments of the query or export subcommand as
+// its command does
+func parseQueryArgs(name string, args []string) (*queryFlags, string, error) {
+	config := &queryFlags{}
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	addQueryFlags(flags, name, config)
+	if err := flags.Parse(normalizeFlagArgs(args)); err != nil {
+		return nil, "", err
+	}
+	question, err := finishQueryFlags(flags, name, config, flags.Args())
+	if err != nil {
+		return nil, "", err
+	}
+	return config, question, nil
+}
+
+// TestParseQueryArgs tests reading structured parameters, JSON parameters and questions from the query arguments
+func TestParseQueryArgs(t *testing.T) {
+	config, question, err := parseQueryArgs("query", []string{"-verb", "delete", "-resources", "secrets,configmaps", "-exclude-users", "system:*", "-timeframe", "24h"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if question != "" || config.format != "table" {
+		t.Errorf("Unexpected question %q or format %q", question, config.format)
+	}
+	params := config.params
+	if params.LogSource != "kube-apiserver" || params.Verb != "delete" || params.Timeframe != "24h" {
+		t.Errorf("Unexpected parameters: %+v", params)
+	}
+	if !reflect.DeepEqual(params.Resources, []string{"secrets", "configmaps"}) || !reflect.DeepEqual(params.ExcludeUsers, []string{"system:*"}) {
+		t.Errorf("Unexpected list parameters: %+v", params)
+	}
+
+	// Flags override the JSON parameters
+	config, _, err = parseQueryArgs("query", []string{"-params", `{"log_source": "oauth-server", "verb": "get", "namespace": "payments"}`, "-verb", "delete"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.params.LogSource != "oauth-server" || config.params.Verb != "delete" || config.params.Namespace != "payments" {
+		t.Errorf("Unexpected merged parameters: %+v", config.params)
+	}
+
+	config, question, err = parseQueryArgs("query", []string{"-format", "json", "who", "deleted", "secrets", "today"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if question != "who deleted secrets today" || config.format != "json" {
+		t.Errorf("Unexpected question %q or format %q", question, config.format)
+	}
+
+	// The sort flags name the fields and orders validation accepts
+	config, _, err = parseQueryArgs("query", []string{"-sort-by", "statusCode", "-sort-order", "desc"})
+	if err != nil || config.params.SortBy != "statusCode" || config.params.SortOrder != "desc" {
+		t.Errorf("Unexpected sort parameters %+v, error %v", config, err)
+	}
+	flags := pflag.NewFlagSet("query", pflag.ContinueOnError)
+	addQueryFlags(flags, "query", &queryFlags{})
+	if sortBy := flags.Lookup("sort-by"); sortBy.Usage != "Sort entries by timestamp, user, resource or statusCode" {
+		t.Errorf("Unexpected --sort-by help %q", sortBy.Usage)
+	}
+
+	for _, args := range [][]string{
+		{"-verb", "delete", "who deleted secrets"},
+		{"-format", "yaml"},
+		{"-columns", "timestamp,bogus"},
+		{"-params", "not json"},
+	} {
+		if _, _, err := parseQueryArgs("query", args); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+
+	// Export takes report formats, a title and an output file
+	config, _, err = parseQueryArgs("export", []string{"-format", "csv", "-output", "secrets.csv", "-title", "Secrets", "-verb", "delete"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.format != "csv" || config.output != "secrets.csv" || config.title != "Secrets" || config.params.Verb != "delete" {
+		t.Errorf("Unexpected export flags: %+v", config)
+	}
+	if config, _, _ := parseQueryArgs("export", nil); config.format != "markdown" {
+		t.Errorf("Expected the markdown format by default, got %q", config.format)
+	}
+	if _, _, err := parseQueryArgs("export", []string{"-format", "table"}); err == nil {
+		t.Error("Expected an error for the table format of export")
+	}
+}
+
+// TestWriteEntryTable tests the aligned table of entries
+func TestWriteEntryTable(t *testing.T) {
+	var output bytes.Buffer
+	writeEntryTable(&output, []map[string]interface{}{
+		{"username": "alice", "verb": "delete", "status_code": float64(200)},
+		{"username": "system:serviceaccount:payments:deployer", "groups": []interface{}{"a", "b"}},
+	}, []string{"username", "verb", "status_code", "groups"})
+
+	lines := strings.Split(strings.TrimRight(output.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %q", output.String())
+	}
+	if fields := strings.Fields(lines[0]); !reflect.DeepEqual(fields, []string{"USERNAME", "VERB", "STATUS_CODE", "GROUPS"}) {
+		t.Errorf("Unexpected header: %q", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); !reflect.DeepEqual(fields, []string{"alice", "delete", "200", "-"}) {
+		t.Errorf("Unexpected row: %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[1] != "-" || fields[3] != `["a","b"]` {
+		t.Errorf("Unexpected row: %q", lines[2])
+	}
+	if strings.Index(lines[1], "delete") != strings.Index(lines[0], "VERB") {
+		t.Errorf("Columns are not aligned:\n%s", output.String())
+	}
+}
+
+// TestWriteEntryCSV tests the CSV of entries with a header row
+func TestWriteEntryCSV(t *testing.T) {
+	var output bytes.Buffer
+	err := writeEntryCSV(&output, []map[string]interface{}{
+		{"username": "alice", "verb": "delete", "status_code": float64(200)},
+		{"username": "bob, the admin", "groups": []interface{}{"a", "b"}},
+	}, []string{"username", "verb", "status_code", "groups"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "username,verb,status_code,groups\nalice,delete,200,\n\"bob, the admin\",,,\"[\"\"a\"\",\"\"b\"\"]\"\n"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+}
@@ -0,0 +1,137 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// FormatCSV renders each artifact of a compliance report as a CSV file
+const FormatCSV = "csv"
+
+// ComplianceFormats lists the supported compliance report formats
+var ComplianceFormats = []string{FormatMarkdown, FormatCSV}
+
+// RenderCompliance formats a compliance report as a single Markdown document,
+// laid out to convert to PDF, or as one CSV file per artifact. It returns the
+// documents by file name.
+func RenderCompliance(report types.ComplianceReport, format string) (map[string]string, error) {
+	base := "compliance-" + report.Month
+	switch format {
+	case "", FormatMarkdown:
+		return map[string]string{base + ".md": RenderComplianceMarkdown(report)}, nil
+	case FormatCSV:
+		documents := make(map[string]string)
+		for _, artifact := range complianceArtifacts(report) {
+			var buf bytes.Buffer
+			writer := csv.NewWriter(&buf)
+			if err := writer.WriteAll(append([][]string{artifact.header}, artifact.rows...)); err != nil {
+				return nil, fmt.Errorf("failed to render %s: %w", artifact.name, err)
+			}
+			documents[base+"-"+artifact.name+".csv"] = buf.String()
+		}
+		return documents, nil
+	default:
+		return nil, fmt.Errorf("unsupported compliance report format: %s", format)
+	}
+}
+
+// complianceArtifact is one table of a compliance report
+type complianceArtifact struct {
+	name   string
+	title  string
+	header []string
+	rows   [][]string
+}
+
+// complianceArtifacts lays out the tables of a compliance report, shared by
+// the Markdown and CSV formats
+func complianceArtifacts(report types.ComplianceReport) []complianceArtifact {
+	users := complianceArtifact{
+		name:   "users",
+		title:  "Human Users with Cluster Access",
+		header: []string{"User", "Groups", "Events", "Failed", "Verbs", "Namespaces", "First Seen", "Last Seen"},
+	}
+	for _, user := range report.Users {
+		users.rows = append(users.rows, []string{
+			user.Username, strings.Join(user.Groups, " "), strconv.Itoa(user.Events), strconv.Itoa(user.Failed),
+			formatVerbCounts(user.Verbs), strings.Join(user.Namespaces, " "), user.FirstSeen, user.LastSeen,
+		})
+	}
+
+	operations := complianceArtifact{
+		name:   "privileged-operations",
+		title:  "Privileged Operations",
+		header: []string{"Time", "User", "Category", "Verb", "Resource", "Namespace", "Name", "Status", "Source IPs", "Audit ID"},
+	}
+	for _, operation := range report.PrivilegedOperations {
+		resource := operation.Resource
+		if operation.Subresource != "" {
+			resource += "/" + operation.Subresource
+		}
+		operations.rows = append(operations.rows, []string{
+			operation.Timestamp, operation.Username, operation.Category, operation.Verb, resource, operation.Namespace,
+			operation.Name, strconv.Itoa(operation.StatusCode), strings.Join(operation.SourceIPs, " "), operation.AuditID,
+		})
+	}
+
+	access := complianceArtifact{
+		name:   "sensitive-namespace-access",
+		title:  "Sensitive Namespace Access",
+		header: []string{"Namespace", "User", "Verb", "Resource", "Events", "Failed", "First Seen", "Last Seen"},
+	}
+	for _, row := range report.SensitiveAccess {
+		access.rows = append(access.rows, []string{
+			row.Namespace, row.Username, row.Verb, row.Resource, strconv.Itoa(row.Events), strconv.Itoa(row.Failed), row.FirstSeen, row.LastSeen,
+		})
+	}
+	return []complianceArtifact{users, operations, access}
+}
+
+// formatVerbCounts formats verb counts in verb order, e.g. "get=12 list=3"
+func formatVerbCounts(verbs map[string]int) string {
+	names := make([]string, 0, len(verbs))
+	for verb := range verbs {
+		names = append(names, verb)
+	}
+	sort.Strings(names)
+	counts := make([]string, len(names))
+	for i, verb := range names {
+		counts[i] = fmt.Sprintf("%s=%d", verb, verbs[verb])
+	}
+	return strings.Join(counts, " ")
+}
+
+// RenderComplianceMarkdown formats a compliance report as Markdown with one
+// section per artifact
+func RenderComplianceMarkdown(report types.ComplianceReport) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Access Review %s\n\n", report.Month)
+	fmt.Fprintf(&b, "- **Period:** %s to %s\n", report.Start, report.End)
+	fmt.Fprintf(&b, "- **Events:** %d\n", report.TotalEvents)
+	fmt.Fprintf(&b, "- **Sensitive namespaces:** %s\n", strings.Join(report.SensitiveNamespaces, ", "))
+	fmt.Fprintf(&b, "\n## Summary\n\n%s\n", report.Summary)
+
+	for _, artifact := range complianceArtifacts(report) {
+		fmt.Fprintf(&b, "\n## %s\n\n", artifact.title)
+		if len(artifact.rows) == 0 {
+			b.WriteString("_None._\n")
+			continue
+		}
+		fmt.Fprintf(&b, "| %s |\n|%s\n", strings.Join(artifact.header, " | "), strings.Repeat(" --- |", len(artifact.header)))
+		for _, row := range artifact.rows {
+			cells := make([]string, len(row))
+			for i, value := range row {
+				cells[i] = markdownCell(value)
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	return b.String()
+}
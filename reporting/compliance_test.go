@@ -0,0 +1,63 @@
+package reporting
+
+import (
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+func testComplianceReport() types.ComplianceReport {
+	return types.ComplianceReport{
+		Month:               "2026-03",
+		Start:               "2026-03-01T00:00:00Z",
+		End:                 "2026-04-01T00:00:00Z",
+		SensitiveNamespaces: []string{"kube-system"},
+		TotalEvents:         3,
+		Users: []types.ComplianceUser{
+			{Username: "alice", Groups: []string{"developers"}, Events: 2, Verbs: map[string]int{"list": 1, "get": 1}, Namespaces: []string{"web"}},
+		},
+		PrivilegedOperations: []types.PrivilegedOperation{
+			{Timestamp: "2026-03-02T10:00:00Z", Username: "alice", Category: "pod_exec", Verb: "create", Resource: "pods", Subresource: "exec", Namespace: "kube-system", Name: "etcd", StatusCode: 101},
+		},
+		Summary: "2026-03: 3 events",
+	}
+}
+
+// TestRenderCompliance tests rendering compliance reports as Markdown and CSV documents
+func TestRenderCompliance(t *testing.T) {
+	documents, err := RenderCompliance(testComplianceReport(), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	markdown := documents["compliance-2026-03.md"]
+	for _, expected := range []string{
+		"# Access Review 2026-03",
+		"| alice | developers | 2 | 0 | get=1 list=1 | web |",
+		"| 2026-03-02T10:00:00Z | alice | pod_exec | create | pods/exec | kube-system | etcd | 101 |",
+		"## Sensitive Namespace Access\n\n_None._",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected Markdown to contain %q:\n%s", expected, markdown)
+		}
+	}
+
+	documents, err = RenderCompliance(testComplianceReport(), FormatCSV)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(documents) != 3 {
+		t.Fatalf("Expected one CSV per artifact, got %v", documents)
+	}
+	users := documents["compliance-2026-03-users.csv"]
+	if !strings.HasPrefix(users, "User,Groups,Events,Failed,Verbs,Namespaces,First Seen,Last Seen\nalice,developers,2,0,get=1 list=1,web,,\n") {
+		t.Errorf("Unexpected users CSV:\n%s", users)
+	}
+	if access := documents["compliance-2026-03-sensitive-namespace-access.csv"]; strings.Count(access, "\n") != 1 {
+		t.Errorf("Expected only a header row, got:\n%s", access)
+	}
+
+	if _, err := RenderCompliance(testComplianceReport(), FormatHTML); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
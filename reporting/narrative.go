@@ -0,0 +1,161 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// MaxDigestEvents limits how many notable events a narrative digest describes
+const MaxDigestEvents = 20
+
+// Completer sends a prompt to a language model and returns its reply
+type Completer interface {
+	Complete(ctx context.Context, system, prompt string) (string, error)
+}
+
+// narrativeInstructions tell the model how to answer
+const narrativeInstructions = `You are a security analyst reviewing Kubernetes and OpenShift API server audit events.
+You receive an aggregated digest of the events matching an audit query as JSON. User names such as "user-1" are pseudonyms; use them as they are.
+Reply with a JSON object with two fields:
+- "narrative": one paragraph of at most 120 words describing what happened, who was involved and whether anything looks suspicious
+- "findings": up to 5 short bullet sentences with the notable findings, most important first; an empty list if nothing stands out
+Only state what the digest supports. Do not wrap the JSON in Markdown.`
+
+// Digest is the aggregated view of a result that is sent to a language model.
+// It holds counts and notable events, never raw log lines, and users are
+// replaced with pseudonyms, except system identities such as service accounts.
+type Digest struct {
+	TotalEvents     int           `json:"total_events"`
+	DescribedEvents int           `json:"described_events"`
+	TopUsers        []Count       `json:"top_users,omitempty"`
+	TopResources    []Count       `json:"top_resources,omitempty"`
+	TopVerbs        []Count       `json:"top_verbs,omitempty"`
+	NotableEvents   []DigestEvent `json:"notable_events,omitempty"`
+	OmittedNotable  int           `json:"omitted_notable_events,omitempty"`
+}
+
+// DigestEvent is a notable event without its object name
+type DigestEvent struct {
+	Timestamp  string `json:"timestamp,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Verb       string `json:"verb,omitempty"`
+	Resource   string `json:"resource,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// pseudonymPattern matches the pseudonyms a digest uses for users
+var pseudonymPattern = regexp.MustCompile(`\buser-\d+\b`)
+
+// NewDigest builds the redacted digest of a report. It also returns the real
+// user behind each pseudonym, to restore names in the model's reply.
+func NewDigest(report Report, describedEvents int) (Digest, map[string]string) {
+	pseudonyms := make(map[string]string)
+	users := make(map[string]string)
+	redact := func(username string) string {
+		if username == "" || strings.HasPrefix(username, "system:") {
+			return username
+		}
+		if pseudonym, ok := pseudonyms[username]; ok {
+			return pseudonym
+		}
+		pseudonym := fmt.Sprintf("user-%d", len(pseudonyms)+1)
+		pseudonyms[username] = pseudonym
+		users[pseudonym] = username
+		return pseudonym
+	}
+
+	digest := Digest{
+		TotalEvents:     report.TotalEvents,
+		DescribedEvents: describedEvents,
+		TopResources:    report.TopResources,
+		TopVerbs:        report.TopVerbs,
+		OmittedNotable:  report.OmittedNotable,
+	}
+	for _, user := range report.TopUsers {
+		digest.TopUsers = append(digest.TopUsers, Count{Value: redact(user.Value), Count: user.Count})
+	}
+	for i, event := range report.NotableEvents {
+		if i >= MaxDigestEvents {
+			digest.OmittedNotable += len(report.NotableEvents) - i
+			break
+		}
+		digest.NotableEvents = append(digest.NotableEvents, DigestEvent{
+			Timestamp:  event.Timestamp,
+			Username:   redact(event.Username),
+			Verb:       event.Verb,
+			Resource:   event.Resource,
+			Namespace:  event.Namespace,
+			StatusCode: event.StatusCode,
+			Reason:     event.Reason,
+		})
+	}
+	return digest, users
+}
+
+// GenerateNarrative asks a language model for a narrative paragraph and the
+// notable findings of an audit result, sending only its redacted digest
+func GenerateNarrative(ctx context.Context, completer Completer, result *types.AuditResult) (*types.Narrative, error) {
+	if len(result.ParsedData) == 0 {
+		return nil, fmt.Errorf("no parsed entries to narrate")
+	}
+
+	digest, users := NewDigest(BuildReport(result, ""), len(result.ParsedData))
+	prompt, err := json.MarshalIndent(digest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the digest: %w", err)
+	}
+	reply, err := completer.Complete(ctx, narrativeInstructions, string(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("narrative generation failed: %w", err)
+	}
+
+	narrative := parseNarrative(reply)
+	if narrative.Text == "" {
+		return nil, fmt.Errorf("narrative generation returned an empty reply")
+	}
+	restore := func(text string) string {
+		return pseudonymPattern.ReplaceAllStringFunc(text, func(pseudonym string) string {
+			if user, ok := users[pseudonym]; ok {
+				return user
+			}
+			return pseudonym
+		})
+	}
+	narrative.Text = restore(narrative.Text)
+	for i, finding := range narrative.Findings {
+		narrative.Findings[i] = restore(finding)
+	}
+	narrative.DescribedEvents = digest.DescribedEvents
+	narrative.TotalEvents = digest.TotalEvents
+	return narrative, nil
+}
+
+// parseNarrative reads the model's JSON reply; a reply that is not JSON is
+// used as the narrative without findings
+func parseNarrative(reply string) *types.Narrative {
+	reply = strings.TrimSpace(reply)
+	unfenced := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(reply, "```json"), "```"), "```")
+
+	var parsed struct {
+		Narrative string   `json:"narrative"`
+		Findings  []string `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(unfenced)), &parsed); err != nil {
+		return &types.Narrative{Text: reply}
+	}
+
+	narrative := &types.Narrative{Text: strings.TrimSpace(parsed.Narrative)}
+	for _, finding := range parsed.Findings {
+		if finding = strings.TrimSpace(strings.TrimLeft(finding, "-*• ")); finding != "" {
+			narrative.Findings = append(narrative.Findings, finding)
+		}
+	}
+	return narrative
+}
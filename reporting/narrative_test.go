@@ -0,0 +1,125 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// fakeCompleter returns a canned reply and records the prompt it was sent
+type fakeCompleter struct {
+	reply  string
+	err    error
+	prompt string
+}
+
+func (c *fakeCompleter) Complete(ctx context.Context, system, prompt string) (string, error) {
+	c.prompt = prompt
+	return c.reply, c.err
+}
+
+// TestNewDigest tests that digests hold counts and notable events with users
+// replaced by pseudonyms
+func TestNewDigest(t *testing.T) {
+	result := testResult()
+	result.ParsedData = append(result.ParsedData, map[string]interface{}{
+		"username": "system:serviceaccount:kube-system:cleaner", "verb": "delete", "resource": "pods", "name": "web-2", "status_code": 200,
+	})
+	digest, users := NewDigest(BuildReport(result, ""), len(result.ParsedData))
+
+	if digest.TotalEvents != 6 || digest.DescribedEvents != 6 || len(digest.NotableEvents) != 4 {
+		t.Fatalf("Unexpected digest %+v", digest)
+	}
+	if users["user-1"] != "alice" || users["user-2"] != "bob" || len(users) != 3 {
+		t.Errorf("Unexpected pseudonyms %v", users)
+	}
+
+	encoded, _ := json.Marshal(digest)
+	for _, secret := range []string{"alice", "bob", "web-1", "web-2"} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("Digest leaks %q: %s", secret, encoded)
+		}
+	}
+	// System identities are not personal and stay readable
+	if digest.NotableEvents[3].Username != "system:serviceaccount:kube-system:cleaner" {
+		t.Errorf("Unexpected service account %q", digest.NotableEvents[3].Username)
+	}
+}
+
+// TestNewDigest_NotableLimit tests that digests describe at most MaxDigestEvents notable events
+func TestNewDigest_NotableLimit(t *testing.T) {
+	result := &types.AuditResult{}
+	for i := 0; i < MaxDigestEvents+5; i++ {
+		result.ParsedData = append(result.ParsedData, map[string]interface{}{"username": fmt.Sprintf("u%d", i), "verb": "delete", "resource": "pods"})
+	}
+	digest, _ := NewDigest(BuildReport(result, ""), len(result.ParsedData))
+	if len(digest.NotableEvents) != MaxDigestEvents || digest.OmittedNotable != 5 {
+		t.Errorf("Expected %d notable events and 5 omitted, got %d and %d", MaxDigestEvents, len(digest.NotableEvents), digest.OmittedNotable)
+	}
+}
+
+// TestGenerateNarrative tests narratives from JSON, fenced and plain replies
+func TestGenerateNarrative(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		text     string
+		findings []string
+	}{
+		{
+			name:     "JSON reply",
+			reply:    `{"narrative": "user-1 deleted web pods while user-2 was denied secrets.", "findings": ["- user-2 was denied access to secrets", " ", "user-2 bound a cluster role"]}`,
+			text:     "alice deleted web pods while bob was denied secrets.",
+			findings: []string{"bob was denied access to secrets", "bob bound a cluster role"},
+		},
+		{
+			name:  "Fenced JSON reply",
+			reply: "```json\n{\"narrative\": \"Routine activity by user-1.\", \"findings\": []}\n```",
+			text:  "Routine activity by alice.",
+		},
+		{
+			name:  "Plain reply",
+			reply: "user-3 listed pods; user-9 is unknown.",
+			text:  "alice|admin listed pods; user-9 is unknown.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completer := &fakeCompleter{reply: tt.reply}
+			narrative, err := GenerateNarrative(context.Background(), completer, testResult())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if narrative.Text != tt.text {
+				t.Errorf("Expected narrative %q, got %q", tt.text, narrative.Text)
+			}
+			if fmt.Sprint(narrative.Findings) != fmt.Sprint(tt.findings) {
+				t.Errorf("Expected findings %q, got %q", tt.findings, narrative.Findings)
+			}
+			if narrative.DescribedEvents != 5 || narrative.TotalEvents != 5 {
+				t.Errorf("Unexpected event counts %+v", narrative)
+			}
+			if strings.Contains(completer.prompt, "alice") || !strings.Contains(completer.prompt, `"total_events": 5`) {
+				t.Errorf("Unexpected prompt %s", completer.prompt)
+			}
+		})
+	}
+}
+
+// TestGenerateNarrative_Errors tests failed narratives
+func TestGenerateNarrative_Errors(t *testing.T) {
+	if _, err := GenerateNarrative(context.Background(), &fakeCompleter{reply: "text"}, &types.AuditResult{TotalEntries: 3}); err == nil {
+		t.Error("Expected an error for a result without parsed entries")
+	}
+	if _, err := GenerateNarrative(context.Background(), &fakeCompleter{err: errors.New("rate limited")}, testResult()); err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("Expected the model error, got %v", err)
+	}
+	if _, err := GenerateNarrative(context.Background(), &fakeCompleter{reply: `{"narrative": "", "findings": ["x"]}`}, testResult()); err == nil {
+		t.Error("Expected an error for an empty narrative")
+	}
+}
@@ -0,0 +1,432 @@
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// Supported report formats
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Formats lists the supported report formats
+var Formats = []string{FormatMarkdown, FormatHTML}
+
+// Report limits
+const (
+	TopN             = 10
+	MaxNotableEvents = 50
+)
+
+// Count is the number of events for a single value, such as a user or resource
+type Count struct {
+	Value string
+	Count int
+}
+
+// NotableEvent is an event worth calling out in a report, with the reason it was picked
+type NotableEvent struct {
+	Timestamp  string
+	Username   string
+	Verb       string
+	Resource   string
+	Namespace  string
+	Name       string
+	StatusCode int
+	Reason     string
+}
+
+// Report is the format-independent content of an audit report
+type Report struct {
+	Title          string
+	GeneratedAt    string
+	QueryID        string
+	Command        string
+	Summary        string
+	TotalEvents    int
+	Warnings       []string
+	TopUsers       []Count
+	TopResources   []Count
+	TopVerbs       []Count
+	NotableEvents  []NotableEvent
+	OmittedNotable int
+	Histogram      *types.Histogram
+}
+
+// BuildReport collects the summary, top users, resources and verbs, and notable
+// events of an AuditResult
+func BuildReport(result *types.AuditResult, title string) Report {
+	if title == "" {
+		title = "Audit Report"
+	}
+
+	report := Report{
+		Title:       title,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		QueryID:     result.QueryID,
+		Command:     result.Command,
+		Summary:     result.Summary,
+		TotalEvents: len(result.ParsedData),
+		Warnings:    result.Warnings,
+		Histogram:   result.Histogram,
+	}
+	if result.TotalEntries > report.TotalEvents {
+		report.TotalEvents = result.TotalEntries
+	}
+
+	users := make(map[string]int)
+	resources := make(map[string]int)
+	verbs := make(map[string]int)
+	for _, entry := range result.ParsedData {
+		event := toNotableEvent(entry)
+		if event.Username != "" {
+			users[event.Username]++
+		}
+		if event.Resource != "" {
+			resources[event.Resource]++
+		}
+		if event.Verb != "" {
+			verbs[event.Verb]++
+		}
+
+		if reason := notableReason(event); reason != "" {
+			if len(report.NotableEvents) >= MaxNotableEvents {
+				report.OmittedNotable++
+				continue
+			}
+			event.Reason = reason
+			report.NotableEvents = append(report.NotableEvents, event)
+		}
+	}
+
+	report.TopUsers = topCounts(users)
+	report.TopResources = topCounts(resources)
+	report.TopVerbs = topCounts(verbs)
+	return report
+}
+
+// toNotableEvent reads the fields a report shows from a parsed entry
+func toNotableEvent(entry map[string]interface{}) NotableEvent {
+	str := func(key string) string {
+		value, _ := entry[key].(string)
+		return value
+	}
+
+	event := NotableEvent{
+		Timestamp: str("timestamp"),
+		Username:  str("username"),
+		Verb:      str("verb"),
+		Resource:  str("resource"),
+		Namespace: str("namespace"),
+		Name:      str("name"),
+	}
+	switch code := entry["status_code"].(type) {
+	case int:
+		event.StatusCode = code
+	case float64:
+		event.StatusCode = int(code)
+	}
+	return event
+}
+
+// notableReason returns why an event deserves attention, or "" if it does not
+func notableReason(event NotableEvent) string {
+	switch {
+	case event.StatusCode == 401 || event.StatusCode == 403:
+		return "access denied"
+	case event.StatusCode >= 500:
+		return "server error"
+	case event.Verb == "delete" || event.Verb == "deletecollection":
+		return "deletion"
+	case event.Resource == "secrets":
+		return "secret access"
+	case strings.HasSuffix(event.Resource, "roles") || strings.HasSuffix(event.Resource, "rolebindings"):
+		if event.Verb != "get" && event.Verb != "list" && event.Verb != "watch" {
+			return "RBAC change"
+		}
+	}
+	return ""
+}
+
+// topCounts returns the TopN most frequent values, ties broken alphabetically
+func topCounts(counts map[string]int) []Count {
+	result := make([]Count, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, Count{Value: value, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	if len(result) > TopN {
+		result = result[:TopN]
+	}
+	return result
+}
+
+// Render formats a report as Markdown or HTML
+func Render(report Report, format string) (string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return RenderMarkdown(report), nil
+	case FormatHTML:
+		return RenderHTML(report)
+	default:
+		return "", fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+// markdownCell escapes a value for use in a Markdown table cell
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+// RenderMarkdown formats a report as Markdown
+func RenderMarkdown(report Report) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", report.Title)
+	fmt.Fprintf(&b, "- **Generated:** %s\n", report.GeneratedAt)
+	if report.QueryID != "" {
+		fmt.Fprintf(&b, "- **Query ID:** `%s`\n", report.QueryID)
+	}
+	fmt.Fprintf(&b, "- **Events:** %d\n", report.TotalEvents)
+	if report.Command != "" {
+		fmt.Fprintf(&b, "\n```\n%s\n```\n", report.Command)
+	}
+
+	if report.Summary != "" {
+		fmt.Fprintf(&b, "\n## Summary\n\n%s\n", report.Summary)
+	}
+	if len(report.Warnings) > 0 {
+		b.WriteString("\n## Warnings\n\n")
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(&b, "- %s\n", warning)
+		}
+	}
+
+	for _, section := range []struct {
+		title  string
+		column string
+		counts []Count
+	}{
+		{"Top Users", "User", report.TopUsers},
+		{"Top Resources", "Resource", report.TopResources},
+		{"Top Verbs", "Verb", report.TopVerbs},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n| %s | Events |\n| --- | ---: |\n", section.title, section.column)
+		for _, count := range section.counts {
+			fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(count.Value), count.Count)
+		}
+	}
+
+	if report.Histogram != nil && len(report.Histogram.Buckets) > 0 {
+		fmt.Fprintf(&b, "\n## Activity per %s\n\n| Start | Events |\n| --- | ---: |\n", report.Histogram.BucketSize)
+		for _, bucket := range report.Histogram.Buckets {
+			fmt.Fprintf(&b, "| %s | %d |\n", bucket.Start, bucket.Count)
+		}
+	}
+
+	if len(report.NotableEvents) > 0 {
+		b.WriteString("\n## Notable Events\n\n| Time | User | Verb | Resource | Namespace | Name | Status | Reason |\n| --- | --- | --- | --- | --- | --- | ---: | --- |\n")
+		for _, event := range report.NotableEvents {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %d | %s |\n",
+				markdownCell(event.Timestamp), markdownCell(event.Username), markdownCell(event.Verb),
+				markdownCell(event.Resource), markdownCell(event.Namespace), markdownCell(event.Name),
+				event.StatusCode, event.Reason)
+		}
+		if report.OmittedNotable > 0 {
+			fmt.Fprintf(&b, "\n_%d more notable events not shown._\n", report.OmittedNotable)
+		}
+	}
+
+	return b.String()
+}
+
+// htmlTemplate renders a report as a standalone HTML page; html/template escapes every value
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+pre { background: #f5f5f5; padding: 8px; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+<li><strong>Generated:</strong> {{.GeneratedAt}}</li>
+{{- if .QueryID}}
+<li><strong>Query ID:</strong> <code>{{.QueryID}}</code></li>
+{{- end}}
+<li><strong>Events:</strong> {{.TotalEvents}}</li>
+</ul>
+{{- if .Command}}
+<pre>{{.Command}}</pre>
+{{- end}}
+{{- if .Summary}}
+<h2>Summary</h2>
+<p>{{.Summary}}</p>
+{{- end}}
+{{- if .Warnings}}
+<h2>Warnings</h2>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- range .Sections}}
+{{- if .Counts}}
+<h2>{{.Title}}</h2>
+<table>
+<tr><th>{{.Column}}</th><th>Events</th></tr>
+{{- range .Counts}}
+<tr><td>{{.Value}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if .NotableEvents}}
+<h2>Notable Events</h2>
+<table>
+<tr><th>Time</th><th>User</th><th>Verb</th><th>Resource</th><th>Namespace</th><th>Name</th><th>Status</th><th>Reason</th></tr>
+{{- range .NotableEvents}}
+<tr><td>{{.Timestamp}}</td><td>{{.Username}}</td><td>{{.Verb}}</td><td>{{.Resource}}</td><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.StatusCode}}</td><td>{{.Reason}}</td></tr>
+{{- end}}
+</table>
+{{- if .OmittedNotable}}
+<p><em>{{.OmittedNotable}} more notable events not shown.</em></p>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// htmlSection is a top-N table in the HTML template
+type htmlSection struct {
+	Title  string
+	Column string
+	Counts []Count
+}
+
+// RenderHTML formats a report as a standalone HTML page
+func RenderHTML(report Report) (string, error) {
+	sections := []htmlSection{
+		{"Top Users", "User", report.TopUsers},
+		{"Top Resources", "Resource", report.TopResources},
+		{"Top Verbs", "Verb", report.TopVerbs},
+	}
+	if report.Histogram != nil && len(report.Histogram.Buckets) > 0 {
+		var buckets []Count
+		for _, bucket := range report.Histogram.Buckets {
+			buckets = append(buckets, Count{Value: bucket.Start, Count: bucket.Count})
+		}
+		sections = append(sections, htmlSection{"Activity per " + report.Histogram.BucketSize, "Start", buckets})
+	}
+
+	data := struct {
+		Report
+		Sections []htmlSection
+	}{report, sections}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// reportFilePattern restricts report file names to a single safe path component
+var reportFilePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+\.(md|html|csv)$`)
+
+// WriteReport writes report content to a file in dir, creating dir if needed, and
+// returns the file's path. The file name must be a plain name ending in .md,
+// .html or .csv so reports cannot be written outside dir.
+func WriteReport(dir, fileName, content string) (string, error) {
+	if !reportFilePattern.MatchString(fileName) || strings.HasPrefix(fileName, ".") {
+		return "", fmt.Errorf("invalid report file name: %s", fileName)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+// RenderFindings formats the findings of an investigation as a Markdown
+// section for the final report: each finding's note, the query it came from
+// and, for a flagged entry, the event
+func RenderFindings(findings []types.Finding) string {
+	var b strings.Builder
+	b.WriteString("## Findings\n\n")
+	if len(findings) == 0 {
+		b.WriteString("_No findings were flagged._\n")
+		return b.String()
+	}
+	for _, finding := range findings {
+		note := finding.Note
+		if note == "" {
+			note = "Flagged as notable"
+		}
+		fmt.Fprintf(&b, "### %s: %s\n\n", finding.ID, strings.ReplaceAll(note, "\n", " "))
+		fmt.Fprintf(&b, "- **Query ID:** `%s`\n", finding.QueryID)
+		if finding.Cluster != "" {
+			fmt.Fprintf(&b, "- **Cluster:** %s\n", finding.Cluster)
+		}
+		fmt.Fprintf(&b, "- **Flagged:** %s\n", finding.CreatedAt)
+		if finding.Entry != nil {
+			fmt.Fprintf(&b, "- **Event:** %s\n", describeEvent(toNotableEvent(finding.Entry)))
+		} else if finding.Summary != "" {
+			fmt.Fprintf(&b, "- **Result:** %s\n", strings.ReplaceAll(finding.Summary, "\n", " "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// describeEvent renders an event as a sentence such as
+// "alice delete secrets/db in prod at 2026-01-01T00:00:00Z (status 200)"
+func describeEvent(event NotableEvent) string {
+	object := event.Resource
+	if event.Name != "" {
+		object += "/" + event.Name
+	}
+	description := strings.TrimSpace(strings.Join([]string{event.Username, event.Verb, object}, " "))
+	if event.Namespace != "" {
+		description += " in " + event.Namespace
+	}
+	if event.Timestamp != "" {
+		description += " at " + event.Timestamp
+	}
+	if event.StatusCode != 0 {
+		description += fmt.Sprintf(" (status %d)", event.StatusCode)
+	}
+	return description
+}
@@ -0,0 +1,164 @@
+package reporting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+func testResult() *types.AuditResult {
+	return &types.AuditResult{
+		QueryID: "audit_query_test",
+		Command: "oc adm node-logs --role=master --path=kube-apiserver/audit.log",
+		Summary: "5 events",
+		ParsedData: []map[string]interface{}{
+			{"timestamp": "2024-01-15T10:00:00Z", "username": "alice", "verb": "get", "resource": "pods", "status_code": 200},
+			{"timestamp": "2024-01-15T10:01:00Z", "username": "alice", "verb": "delete", "resource": "pods", "namespace": "web", "name": "web-1", "status_code": 200},
+			{"timestamp": "2024-01-15T10:02:00Z", "username": "bob", "verb": "get", "resource": "secrets", "status_code": float64(403)},
+			{"timestamp": "2024-01-15T10:03:00Z", "username": "bob", "verb": "create", "resource": "clusterrolebindings", "status_code": 201},
+			{"timestamp": "2024-01-15T10:04:00Z", "username": "alice|admin", "verb": "list", "resource": "pods", "status_code": 200},
+		},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	report := BuildReport(testResult(), "")
+
+	if report.Title != "Audit Report" {
+		t.Errorf("Expected default title, got %q", report.Title)
+	}
+	if report.TotalEvents != 5 {
+		t.Errorf("Expected 5 events, got %d", report.TotalEvents)
+	}
+	if len(report.TopUsers) != 3 || report.TopUsers[0].Value != "alice" || report.TopUsers[0].Count != 2 {
+		t.Errorf("Unexpected top users: %+v", report.TopUsers)
+	}
+	if report.TopResources[0].Value != "pods" || report.TopResources[0].Count != 3 {
+		t.Errorf("Unexpected top resources: %+v", report.TopResources)
+	}
+
+	reasons := make(map[string]string)
+	for _, event := range report.NotableEvents {
+		reasons[event.Verb+" "+event.Resource] = event.Reason
+	}
+	expected := map[string]string{
+		"delete pods":                "deletion",
+		"get secrets":                "access denied",
+		"create clusterrolebindings": "RBAC change",
+	}
+	if len(reasons) != len(expected) {
+		t.Errorf("Expected %d notable events, got %+v", len(expected), report.NotableEvents)
+	}
+	for event, reason := range expected {
+		if reasons[event] != reason {
+			t.Errorf("Expected %s to be notable for %q, got %q", event, reason, reasons[event])
+		}
+	}
+}
+
+func TestBuildReport_NotableLimit(t *testing.T) {
+	result := &types.AuditResult{}
+	for i := 0; i < MaxNotableEvents+5; i++ {
+		result.ParsedData = append(result.ParsedData, map[string]interface{}{"verb": "delete", "resource": "pods"})
+	}
+
+	report := BuildReport(result, "Deletes")
+	if len(report.NotableEvents) != MaxNotableEvents || report.OmittedNotable != 5 {
+		t.Errorf("Expected %d notable events and 5 omitted, got %d and %d", MaxNotableEvents, len(report.NotableEvents), report.OmittedNotable)
+	}
+}
+
+func TestRender(t *testing.T) {
+	report := BuildReport(testResult(), "Incident <42>")
+
+	markdown, err := Render(report, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"# Incident <42>", "## Top Users", "| alice | 2 |", `| alice\|admin | 1 |`, "## Notable Events", "| access denied |"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected Markdown to contain %q:\n%s", want, markdown)
+		}
+	}
+
+	html, err := Render(report, FormatHTML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"<title>Incident &lt;42&gt;</title>", "<h2>Top Resources</h2>", "<td>clusterrolebindings</td>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected HTML to contain %q:\n%s", want, html)
+		}
+	}
+
+	if _, err := Render(report, "pdf"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestRender_Histogram(t *testing.T) {
+	result := &types.AuditResult{
+		TotalEntries: 3,
+		Histogram: &types.Histogram{
+			BucketSize: "hour",
+			Buckets:    []types.HistogramBucket{{Start: "2024-01-15T10:00:00Z", Count: 3}},
+		},
+	}
+
+	markdown := RenderMarkdown(BuildReport(result, ""))
+	if !strings.Contains(markdown, "## Activity per hour") || !strings.Contains(markdown, "| 2024-01-15T10:00:00Z | 3 |") {
+		t.Errorf("Expected histogram table, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "**Events:** 3") {
+		t.Errorf("Expected total from TotalEntries, got:\n%s", markdown)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+
+	path, err := WriteReport(dir, "incident-42.md", "# Report\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "# Report\n" {
+		t.Errorf("Unexpected file content %q (%v)", content, err)
+	}
+
+	for _, name := range []string{"../escape.md", "sub/dir.md", ".hidden.md", "report.txt", ""} {
+		if _, err := WriteReport(dir, name, "x"); err == nil {
+			t.Errorf("Expected error for file name %q", name)
+		}
+	}
+}
+
+// TestRenderFindings tests the Markdown findings section of the final report
+func TestRenderFindings(t *testing.T) {
+	index := 1
+	result := testResult()
+	markdown := RenderFindings([]types.Finding{
+		{ID: "finding-1", QueryID: result.QueryID, EntryIndex: &index, Entry: result.ParsedData[1], Note: "Pod deleted by hand", CreatedAt: "2024-01-15T11:00:00Z"},
+		{ID: "finding-2", QueryID: result.QueryID, Cluster: "prod", Summary: result.Summary, CreatedAt: "2024-01-15T11:05:00Z"},
+	})
+
+	for _, expected := range []string{
+		"## Findings",
+		"### finding-1: Pod deleted by hand",
+		"- **Event:** alice delete pods/web-1 in web at 2024-01-15T10:01:00Z (status 200)",
+		"### finding-2: Flagged as notable",
+		"- **Cluster:** prod",
+		"- **Result:** 5 events",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected findings to contain %q:\n%s", expected, markdown)
+		}
+	}
+
+	if markdown := RenderFindings(nil); !strings.Contains(markdown, "No findings were flagged") {
+		t.Errorf("Unexpected empty findings section: %s", markdown)
+	}
+}
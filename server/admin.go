@@ -0,0 +1,149 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"audit-query-mcp-server/utils"
+)
+
+// AdminPathPrefix is where the admin API is served in serve mode
+const AdminPathPrefix = "/admin/"
+
+// AdminHandler serves the admin API for day-2 operations without an MCP
+// client. Requests must carry "Authorization: Bearer <token>". The cache,
+// circuit breaker and audit trail endpoints act on the server of the cluster
+// query parameter, the default one without it.
+//
+//	GET    /admin/cache                  cache statistics
+//	DELETE /admin/cache                  clear the cache
+//	GET    /admin/circuit-breaker        circuit breaker state
+//	POST   /admin/circuit-breaker/reset  close the circuit breaker
+//	GET    /admin/queries                running query executions
+//	DELETE /admin/queries/{query_id}     cancel the executions of a query
+//	POST   /admin/reload                 reload query templates and indicators
+//	GET    /admin/audit-trail            latest audit trail entries
+func (s *AuditQueryMCPServer) AdminHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(AdminPathPrefix, "/"))
+		s.logger.Infof("Admin API: %s %s", r.Method, r.URL.RequestURI())
+
+		if queryID, ok := strings.CutPrefix(path, "/queries/"); ok && queryID != "" {
+			if !allowMethods(w, r, http.MethodDelete) {
+				return
+			}
+			cancelled := s.CancelQuery(queryID)
+			if cancelled == 0 {
+				writeAdminError(w, http.StatusNotFound, fmt.Sprintf("query %s is not running", queryID))
+				return
+			}
+			writeAdminJSON(w, map[string]interface{}{"query_id": queryID, "cancelled": cancelled})
+			return
+		}
+
+		switch path {
+		case "/queries":
+			if allowMethods(w, r, http.MethodGet) {
+				writeAdminJSON(w, map[string]interface{}{"queries": s.InFlightQueries()})
+			}
+		case "/reload":
+			if allowMethods(w, r, http.MethodPost) {
+				writeAdminJSON(w, s.ReloadConfig())
+			}
+		case "/cache", "/circuit-breaker", "/circuit-breaker/reset", "/audit-trail":
+			target, err := s.serverForCluster(r.URL.Query().Get("cluster"))
+			if err != nil {
+				writeAdminError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			target.serveClusterAdmin(w, r, path)
+		default:
+			writeAdminError(w, http.StatusNotFound, "unknown admin endpoint: "+r.URL.Path)
+		}
+	})
+}
+
+// serveClusterAdmin serves the admin endpoints acting on a single cluster's
+// server
+func (s *AuditQueryMCPServer) serveClusterAdmin(w http.ResponseWriter, r *http.Request, path string) {
+	switch path {
+	case "/cache":
+		if !allowMethods(w, r, http.MethodGet, http.MethodDelete) {
+			return
+		}
+		if r.Method == http.MethodDelete {
+			s.ClearCache()
+			writeAdminJSON(w, map[string]interface{}{"cleared": true, "cluster": s.cluster})
+			return
+		}
+		writeAdminJSON(w, s.GetCacheStats())
+	case "/circuit-breaker":
+		if allowMethods(w, r, http.MethodGet) {
+			writeAdminJSON(w, s.GetCircuitBreakerStatus())
+		}
+	case "/circuit-breaker/reset":
+		if allowMethods(w, r, http.MethodPost) {
+			writeAdminJSON(w, map[string]interface{}{"previous": s.ResetCircuitBreaker(), "current": s.GetCircuitBreakerStatus()})
+		}
+	case "/audit-trail":
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+		values := r.URL.Query()
+		query := utils.AuditTrailQuery{
+			UserID:  values.Get("user_id"),
+			QueryID: values.Get("query_id"),
+			Action:  values.Get("action"),
+		}
+		if limit := values.Get("limit"); limit != "" {
+			parsed, err := strconv.Atoi(limit)
+			if err != nil || parsed <= 0 {
+				writeAdminError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			query.Limit = parsed
+		}
+		result, err := s.QueryAuditTrail(query, false)
+		if err != nil {
+			writeAdminError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		writeAdminJSON(w, result)
+	}
+}
+
+// allowMethods reports whether the request uses one of methods, answering
+// 405 when it does not
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// writeAdminJSON writes an admin API response
+func writeAdminJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	data, _ := json.MarshalIndent(value, "", "  ")
+	w.Write(data)
+}
+
+// writeAdminError writes an admin API error with its status
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	data, _ := json.Marshal(map[string]string{"error": message})
+	w.Write(data)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adminRequest sends an admin API request with the token and decodes its JSON body
+func adminRequest(t *testing.T, handler http.Handler, method, target, token string) (int, map[string]interface{}) {
+	t.Helper()
+	request := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body), recorder.Body.String())
+	return recorder.Code, body
+}
+
+// TestAdminHandler tests authentication and the cache, circuit breaker and audit trail endpoints
+func TestAdminHandler(t *testing.T) {
+	server := newMockServer(t)
+	handler := server.AdminHandler("secret")
+
+	status, _ := adminRequest(t, handler, http.MethodGet, "/admin/cache", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = adminRequest(t, handler, http.MethodGet, "/admin/cache", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = adminRequest(t, server.AdminHandler(""), http.MethodGet, "/admin/cache", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	server.cache.Set("query-1", &types.AuditResult{QueryID: "query-1", Command: "mock read kube-apiserver"})
+	status, body := adminRequest(t, handler, http.MethodGet, "/admin/cache", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), body["size"])
+	status, _ = adminRequest(t, handler, http.MethodDelete, "/admin/cache", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0, server.cache.Size())
+
+	server.circuit.RecordFailure()
+	status, body = adminRequest(t, handler, http.MethodPost, "/admin/circuit-breaker/reset", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), body["previous"].(map[string]interface{})["failure_count"])
+	assert.Equal(t, 0, server.GetCircuitBreakerStatus().FailureCount)
+
+	status, _ = adminRequest(t, handler, http.MethodPost, "/admin/circuit-breaker", "secret")
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+	status, _ = adminRequest(t, handler, http.MethodGet, "/admin/cache?cluster=bogus", "secret")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = adminRequest(t, handler, http.MethodGet, "/admin/bogus", "secret")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Timeframe: "today"})
+	require.NoError(t, err)
+	status, body = adminRequest(t, handler, http.MethodGet, "/admin/audit-trail?limit=1", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, body["entries"], 1)
+	status, _ = adminRequest(t, handler, http.MethodGet, "/admin/audit-trail?limit=none", "secret")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+// TestAdminHandler_Queries tests listing and cancelling running query executions
+func TestAdminHandler_Queries(t *testing.T) {
+	server := newMockServer(t)
+	handler := server.AdminHandler("secret")
+
+	ctx, done := server.trackQuery("query-1", "oc adm node-logs --role=master --path=kube-apiserver/audit.log")
+	defer done()
+	status, body := adminRequest(t, handler, http.MethodGet, "/admin/queries", "secret")
+	require.Equal(t, http.StatusOK, status)
+	queries := body["queries"].([]interface{})
+	require.Len(t, queries, 1)
+	assert.Equal(t, "query-1", queries[0].(map[string]interface{})["query_id"])
+
+	status, _ = adminRequest(t, handler, http.MethodDelete, "/admin/queries/query-2", "secret")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, body = adminRequest(t, handler, http.MethodDelete, "/admin/queries/query-1", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), body["cancelled"])
+	<-ctx.Done()
+	assert.True(t, queryCancelled(ctx))
+
+	done()
+	assert.Empty(t, server.InFlightQueries())
+}
+
+// TestAdminHandler_Reload tests reloading query templates, keeping them when the file breaks
+func TestAdminHandler_Reload(t *testing.T) {
+	path := writeQueryTemplates(t, `{"templates": [{"name": "secret-deletions", "params": {"verb": "delete", "resource": "secrets"}}]}`)
+	t.Setenv("AUDIT_QUERY_TEMPLATES", path)
+	server := newMockServer(t)
+	handler := server.AdminHandler("secret")
+	require.Len(t, server.queryTemplates(), 1)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"templates": [{"name": "a", "params": {"verb": "get"}}, {"name": "b", "params": {"verb": "list"}}]}`), 0644))
+	status, body := adminRequest(t, handler, http.MethodPost, "/admin/reload", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(2), body["query_templates"])
+	_, ok := server.queryTemplate("b")
+	assert.True(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0644))
+	status, body = adminRequest(t, handler, http.MethodPost, "/admin/reload", "secret")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(2), body["query_templates"])
+	assert.Len(t, body["errors"], 1)
+}
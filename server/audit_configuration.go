@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// auditConfigurationTimeout bounds reading the APIServer configuration
+const auditConfigurationTimeout = 15 * time.Second
+
+// GetAuditConfiguration reads the cluster's APIServer audit profile and custom
+// rules and describes the detail audit events record under them
+func (s *AuditQueryMCPServer) GetAuditConfiguration() (*types.AuditConfiguration, error) {
+	s.logger.Info("Reading the APIServer audit configuration")
+	if s.provider != nil {
+		return nil, fmt.Errorf("the audit configuration is read from the OpenShift APIServer resource and is not available with the %s provider", s.provider.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditConfigurationTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "oc", s.ocArgs(commands.AuditConfigurationArgs())...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to read APIServer configuration: %w, output: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to read APIServer configuration: %w", err)
+	}
+
+	config, err := commands.ParseAuditConfiguration(output)
+	if err != nil {
+		return nil, err
+	}
+	config.CheckedAt = time.Now().Format(time.RFC3339)
+	s.logger.Infof("APIServer audit profile: %s", config.Profile)
+	return config, nil
+}
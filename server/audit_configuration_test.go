@@ -0,0 +1,45 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleGetAuditConfiguration tests reporting the cluster audit profile
+func TestHandleGetAuditConfiguration(t *testing.T) {
+	installFakeOc(t, `#!/bin/sh
+[ "$*" = "get apiserver cluster -o json" ] || exit 1
+echo '{"kind":"APIServer","spec":{"audit":{"profile":"WriteRequestBodies","customRules":[{"group":"system:authenticated:oauth","profile":"AllRequestBodies"}]}}}'
+`)
+	server := NewAuditQueryMCPServer()
+
+	response := server.handleToolCall(types.MCPRequest{
+		ID:     "test-id",
+		Method: "tools/call",
+		Params: map[string]interface{}{
+			"name":      "get_audit_configuration",
+			"arguments": map[string]interface{}{},
+		},
+	})
+	require.Nil(t, response.Error)
+	config := response.Result.(*types.AuditConfiguration)
+	assert.Equal(t, commands.AuditProfileWriteRequestBodies, config.Profile)
+	assert.True(t, config.Detail.WriteRequestBodies)
+	assert.False(t, config.Detail.ReadRequestBodies)
+	require.Len(t, config.CustomRules, 1)
+	assert.True(t, config.CustomRules[0].Detail.ReadRequestBodies)
+	assert.NotEmpty(t, config.CheckedAt)
+
+	// A failing oc is reported with its error type
+	installFakeOc(t, "#!/bin/sh\necho 'error: You must be logged in to the server' >&2\nexit 1\n")
+	response = server.handleGetAuditConfiguration("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32003, response.Error.Code)
+	assert.Equal(t, types.ErrorTypePermissionDenied, response.Error.Data.Type)
+	assert.Contains(t, response.Error.Message, "You must be logged in")
+}
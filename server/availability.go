@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// Log source probing defaults
+const (
+	// DefaultAvailabilityTTL is how long a probed log source availability is reused
+	DefaultAvailabilityTTL = 10 * time.Minute
+	// availabilityProbeTimeout bounds a single log source probe
+	availabilityProbeTimeout = 15 * time.Second
+)
+
+// missingPathMarkers are oc error messages meaning the probed directory does not exist
+var missingPathMarkers = []string{"no such file", "not found", "404"}
+
+// cachedAvailability is a probed log source availability and when it expires
+type cachedAvailability struct {
+	availability types.LogSourceAvailability
+	expires      time.Time
+}
+
+// CheckLogSourceAvailability reports whether logSource's audit logs exist on the
+// master nodes. A result cached within the availability TTL is reused unless
+// refresh is set; probes that fail are not cached.
+func (s *AuditQueryMCPServer) CheckLogSourceAvailability(logSource string, refresh bool) types.LogSourceAvailability {
+	if logSource == "" {
+		logSource = "kube-apiserver"
+	}
+	if !refresh {
+		if availability, ok := s.cachedLogSourceAvailability(logSource); ok {
+			return availability
+		}
+	}
+
+	availability := s.probeLogSource(logSource)
+	if availability.Error == "" {
+		s.availabilityMutex.Lock()
+		s.availability[logSource] = cachedAvailability{availability: availability, expires: time.Now().Add(s.availabilityTTL)}
+		s.availabilityMutex.Unlock()
+	}
+	if !availability.Available {
+		availability.Alternatives = s.availableAlternatives(logSource)
+	}
+	return availability
+}
+
+// CheckAllLogSources reports the availability of every supported log source
+func (s *AuditQueryMCPServer) CheckAllLogSources(refresh bool) []types.LogSourceAvailability {
+	results := make([]types.LogSourceAvailability, 0, len(utils.ValidLogSources))
+	for _, logSource := range utils.ValidLogSources {
+		results = append(results, s.CheckLogSourceAvailability(logSource, refresh))
+	}
+	return results
+}
+
+// cachedLogSourceAvailability returns the unexpired probe result for logSource
+func (s *AuditQueryMCPServer) cachedLogSourceAvailability(logSource string) (types.LogSourceAvailability, bool) {
+	if logSource == "" {
+		logSource = "kube-apiserver"
+	}
+
+	s.availabilityMutex.Lock()
+	defer s.availabilityMutex.Unlock()
+
+	cached, ok := s.availability[logSource]
+	if !ok || time.Now().After(cached.expires) {
+		return types.LogSourceAvailability{}, false
+	}
+	availability := cached.availability
+	if !availability.Available {
+		availability.Alternatives = s.availableAlternativesLocked(logSource)
+	}
+	return availability, true
+}
+
+// availableAlternatives returns the alternatives to logSource that are not known
+// to be unavailable
+func (s *AuditQueryMCPServer) availableAlternatives(logSource string) []string {
+	s.availabilityMutex.Lock()
+	defer s.availabilityMutex.Unlock()
+
+	return s.availableAlternativesLocked(logSource)
+}
+
+// availableAlternativesLocked is availableAlternatives for callers holding the
+// availability lock
+func (s *AuditQueryMCPServer) availableAlternativesLocked(logSource string) []string {
+	var alternatives []string
+	now := time.Now()
+	for _, alternative := range commands.LogSourceAlternatives(logSource) {
+		if cached, ok := s.availability[alternative]; ok && now.Before(cached.expires) && !cached.availability.Available {
+			continue
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	return alternatives
+}
+
+// probeLogSource lists logSource's directory on the master nodes
+func (s *AuditQueryMCPServer) probeLogSource(logSource string) types.LogSourceAvailability {
+	availability := types.LogSourceAvailability{
+		LogSource: logSource,
+		Path:      commands.LogSourceDirectory(logSource),
+		CheckedAt: time.Now().Format(time.RFC3339),
+	}
+
+	// A provider can fetch a fixed set of log sources
+	if s.provider != nil {
+		availability.Path, _ = s.provider.BuildQuery(types.AuditQueryParams{LogSource: logSource})
+		availability.Available = utils.Contains(s.provider.Capabilities().LogSources, logSource)
+		return availability
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), availabilityProbeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "oc", s.ocArgs(commands.LogSourceProbeArgs(logSource))...).CombinedOutput()
+	if err != nil {
+		message := strings.ToLower(string(output))
+		for _, marker := range missingPathMarkers {
+			if strings.Contains(message, marker) {
+				s.logger.Infof("Log source %s is not available: %s", logSource, strings.TrimSpace(string(output)))
+				return availability
+			}
+		}
+		availability.Error = fmt.Sprintf("probe failed: %v, output: %s", err, strings.TrimSpace(string(output)))
+		s.logger.Warnf("Could not probe log source %s: %s", logSource, availability.Error)
+		return availability
+	}
+
+	availability.Nodes = commands.ParseLogSourceProbe(logSource, string(output))
+	availability.Available = len(availability.Nodes) > 0
+	return availability
+}
+
+// unavailableLogSourceError returns an error when logSource is known to be missing
+// from the cluster, probing it if needed; it returns nil when the source is
+// available or its availability is unknown
+func (s *AuditQueryMCPServer) unavailableLogSourceError(logSource string, refresh bool) error {
+	availability := s.CheckLogSourceAvailability(logSource, refresh)
+	if availability.Available || availability.Error != "" {
+		return nil
+	}
+	return logSourceUnavailableError(availability)
+}
+
+// hasOcError reports whether output contains an error line printed by oc
+func hasOcError(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "error:") {
+			return true
+		}
+	}
+	return false
+}
+
+// logSourceUnavailableError describes an unavailable log source and what to use instead
+func logSourceUnavailableError(availability types.LogSourceAvailability) error {
+	message := fmt.Sprintf("log source %s not available on this cluster: no audit logs found under %s on any master node",
+		availability.LogSource, availability.Path)
+	if len(availability.Alternatives) > 0 {
+		message += "; try log_source " + strings.Join(availability.Alternatives, " or ")
+	}
+	return errors.New(message)
+}
+
+// availableLogSources returns the names of the available log sources, sorted
+func availableLogSources(results []types.LogSourceAvailability) []string {
+	var names []string
+	for _, availability := range results {
+		if availability.Available {
+			names = append(names, availability.LogSource)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOcScript lists kube-apiserver audit logs, fails like oc for a missing
+// oauth-server directory and fails every other call
+const fakeOcScript = `#!/bin/sh
+case "$*" in
+*--path=kube-apiserver/) printf 'master-0 audit.log\nmaster-1 audit.log\n' ;;
+*--path=oauth-server/*) echo "error: the server could not find the requested resource (404 page not found)" >&2; exit 1 ;;
+*--path=oauth-apiserver/) printf 'master-0 audit.log\n' ;;
+*) echo "error: unexpected call" >&2; exit 1 ;;
+esac
+`
+
+// withFakeOc puts fakeOcScript on PATH for the test
+func withFakeOc(t *testing.T) {
+	t.Helper()
+	installFakeOc(t, fakeOcScript)
+}
+
+// installFakeOc puts an oc running script on PATH for the test
+func installFakeOc(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oc"), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestCheckLogSourceAvailability tests probing available, missing and unknown log sources
+func TestCheckLogSourceAvailability(t *testing.T) {
+	withFakeOc(t)
+	server := NewAuditQueryMCPServer()
+
+	kube := server.CheckLogSourceAvailability("", false)
+	assert.True(t, kube.Available)
+	assert.Equal(t, "kube-apiserver", kube.LogSource)
+	assert.Equal(t, map[string][]string{"master-0": {"audit.log"}, "master-1": {"audit.log"}}, kube.Nodes)
+
+	oauth := server.CheckLogSourceAvailability("oauth-server", false)
+	assert.False(t, oauth.Available)
+	assert.Empty(t, oauth.Error)
+	assert.Equal(t, []string{"oauth-apiserver", "kube-apiserver"}, oauth.Alternatives)
+
+	// A failed probe means unknown availability and is not cached
+	node := server.CheckLogSourceAvailability("node", false)
+	assert.False(t, node.Available)
+	assert.Contains(t, node.Error, "probe failed")
+	_, cached := server.cachedLogSourceAvailability("node")
+	assert.False(t, cached)
+
+	// Alternatives known to be unavailable are not suggested
+	server.CheckLogSourceAvailability("oauth-apiserver", false)
+	server.availability["oauth-apiserver"] = cachedAvailability{
+		availability: types.LogSourceAvailability{LogSource: "oauth-apiserver"},
+		expires:      server.availability["oauth-apiserver"].expires,
+	}
+	oauth, cached = server.cachedLogSourceAvailability("oauth-server")
+	require.True(t, cached)
+	assert.Equal(t, []string{"kube-apiserver"}, oauth.Alternatives)
+}
+
+// TestExecuteCompleteAuditQuery_UnavailableLogSource tests the error for a log source the cluster does not have
+func TestExecuteCompleteAuditQuery_UnavailableLogSource(t *testing.T) {
+	withFakeOc(t)
+	server := NewAuditQueryMCPServer()
+	params := types.AuditQueryParams{LogSource: "oauth-server", Timeframe: "today"}
+
+	result, err := server.ExecuteCompleteAuditQuery(params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "log source oauth-server not available on this cluster")
+	assert.Contains(t, err.Error(), "try log_source oauth-apiserver or kube-apiserver")
+	assert.Equal(t, err.Error(), result.Error)
+
+	// The cached probe result fails the next query without running it
+	failures := server.GetCircuitBreakerStatus().TotalFailures
+	_, err = server.ExecuteCompleteAuditQuery(params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not available on this cluster")
+	assert.Equal(t, failures, server.GetCircuitBreakerStatus().TotalFailures)
+}
+
+// TestHandleCheckLogSources tests the check_log_sources tool
+func TestHandleCheckLogSources(t *testing.T) {
+	withFakeOc(t)
+	server := NewAuditQueryMCPServer()
+
+	response := server.handleToolCall(types.MCPRequest{
+		ID:     "test-id",
+		Method: "tools/call",
+		Params: map[string]interface{}{
+			"name":      "check_log_sources",
+			"arguments": map[string]interface{}{},
+		},
+	})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Len(t, result["log_sources"], 5)
+	assert.Equal(t, []string{"kube-apiserver", "oauth-apiserver"}, result["available"])
+
+	response = server.handleCheckLogSources("test-id", map[string]interface{}{"log_source": "oauth-server", "refresh": true})
+	require.Nil(t, response.Error)
+	sources := response.Result.(map[string]interface{})["log_sources"].([]types.LogSourceAvailability)
+	require.Len(t, sources, 1)
+	assert.False(t, sources[0].Available)
+
+	response = server.handleCheckLogSources("test-id", map[string]interface{}{"log_source": "bogus"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// MaxBatchQueries bounds the queries one execute_audit_query_batch call runs
+const MaxBatchQueries = 20
+
+// ExecuteAuditQueryBatch runs each query through the complete pipeline, at most
+// AUDIT_MAX_CONCURRENT_QUERIES at a time across all batches, and returns the
+// results in request order with a combined summary. A failed query is reported
+// in its item and does not stop the others.
+func (s *AuditQueryMCPServer) ExecuteAuditQueryBatch(batch []types.AuditQueryParams) (*types.BatchQueryResult, error) {
+	if len(batch) == 0 {
+		return nil, fmt.Errorf("at least one query required")
+	}
+	if len(batch) > MaxBatchQueries {
+		return nil, fmt.Errorf("batch has %d queries; at most %d are allowed", len(batch), MaxBatchQueries)
+	}
+	s.logger.Infof("Executing batch of %d audit queries", len(batch))
+
+	startTime := time.Now()
+	items := make([]types.BatchQueryItem, len(batch))
+	var wg sync.WaitGroup
+	for i, params := range batch {
+		wg.Add(1)
+		go func(i int, params types.AuditQueryParams) {
+			defer wg.Done()
+			s.querySlots <- struct{}{}
+			defer func() { <-s.querySlots }()
+
+			item := types.BatchQueryItem{Index: i, Params: params}
+			result, err := s.ExecuteCompleteAuditQuery(params)
+			if err != nil {
+				item.Error = err.Error()
+				item.ErrorType, _ = classifyError(err)
+			} else {
+				item.Result = result
+			}
+			items[i] = item
+		}(i, params)
+	}
+	wg.Wait()
+
+	result := &types.BatchQueryResult{Results: items}
+	var lines []string
+	for _, item := range items {
+		label := batchQueryLabel(item.Params)
+		if item.Error != "" {
+			result.Failed++
+			lines = append(lines, fmt.Sprintf("%d. %s: failed: %s", item.Index+1, label, item.Error))
+			continue
+		}
+		result.Succeeded++
+		result.TotalEntries += item.Result.TotalEntries
+		lines = append(lines, fmt.Sprintf("%d. %s: %d entries. %s", item.Index+1, label, item.Result.TotalEntries, item.Result.Summary))
+	}
+	result.Summary = fmt.Sprintf("Ran %d queries: %d succeeded, %d failed, %d entries in total.\n%s",
+		len(items), result.Succeeded, result.Failed, result.TotalEntries, strings.Join(lines, "\n"))
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.logger.Infof("Batch finished: %d succeeded, %d failed", result.Succeeded, result.Failed)
+	return result, nil
+}
+
+// batchQueryLabel names a batch query by its main filters, so the combined
+// summary tells queries that differ only in, say, namespace apart
+func batchQueryLabel(params types.AuditQueryParams) string {
+	var parts []string
+	for _, field := range []struct{ name, value string }{
+		{"log_source", params.LogSource},
+		{"namespace", params.Namespace},
+		{"username", params.Username},
+		{"verb", params.Verb},
+		{"resource", params.Resource},
+		{"timeframe", params.Timeframe},
+	} {
+		if field.value != "" {
+			parts = append(parts, field.name+"="+field.value)
+		}
+	}
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"namespaces", params.Namespaces},
+		{"usernames", params.Usernames},
+		{"verbs", params.Verbs},
+		{"resources", params.Resources},
+		{"subresources", params.Subresources},
+		{"patterns", params.Patterns},
+	} {
+		if len(field.values) > 0 {
+			parts = append(parts, field.name+"="+strings.Join(field.values, ","))
+		}
+	}
+	if len(parts) == 0 {
+		return "all events"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecuteAuditQueryBatch tests running queries together, in request order,
+// with a failed query reported in its item
+func TestExecuteAuditQueryBatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditEvents(t, file, time.Now().Add(-time.Hour), time.Now())
+	t.Setenv("AUDIT_PROVIDER", "kubernetes")
+	t.Setenv("AUDIT_K8S_AUDIT_FILE", file)
+	t.Setenv("AUDIT_MAX_CONCURRENT_QUERIES", "2")
+	server := NewAuditQueryMCPServer()
+	assert.Equal(t, 2, cap(server.querySlots))
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "execute_audit_query_batch",
+		"arguments": map[string]interface{}{"queries": []interface{}{
+			map[string]interface{}{"log_source": "kube-apiserver", "namespace": "default", "verb": "delete"},
+			map[string]interface{}{"log_source": "kube-apiserver", "namespace": "payments", "verb": "delete"},
+			map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "sometime"},
+			map[string]interface{}{"log_source": "kube-apiserver", "username": "alice"},
+		}},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(*types.BatchQueryResult)
+
+	require.Len(t, result.Results, 4)
+	assert.Equal(t, 3, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 4, result.TotalEntries)
+	assert.Equal(t, 2, result.Results[0].Result.TotalEntries)
+	assert.Equal(t, 0, result.Results[1].Result.TotalEntries)
+	assert.Nil(t, result.Results[2].Result)
+	assert.Contains(t, result.Results[2].Error, "invalid timeframe")
+	assert.Equal(t, types.ErrorTypeInvalidParams, result.Results[2].ErrorType)
+	assert.Equal(t, 2, result.Results[3].Result.TotalEntries)
+
+	queryIDs := make(map[string]bool)
+	for i, item := range result.Results {
+		assert.Equal(t, i, item.Index)
+		if item.Result != nil {
+			assert.False(t, queryIDs[item.Result.QueryID], "duplicate query ID %s", item.Result.QueryID)
+			queryIDs[item.Result.QueryID] = true
+		}
+	}
+	assert.Contains(t, result.Summary, "Ran 4 queries: 3 succeeded, 1 failed, 4 entries in total.")
+	assert.Contains(t, result.Summary, "2. log_source=kube-apiserver namespace=payments verb=delete: 0 entries.")
+	assert.Contains(t, result.Summary, "3. log_source=kube-apiserver timeframe=sometime: failed:")
+	assert.Len(t, server.querySlots, 0)
+}
+
+// TestHandleExecuteAuditQueryBatch_InvalidParams tests rejecting malformed batches
+func TestHandleExecuteAuditQueryBatch_InvalidParams(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	tooMany := make([]interface{}, MaxBatchQueries+1)
+	for i := range tooMany {
+		tooMany[i] = map[string]interface{}{"log_source": "kube-apiserver"}
+	}
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"queries": "kube-apiserver"},
+		{"queries": []interface{}{}},
+		{"queries": []interface{}{"kube-apiserver"}},
+		{"queries": tooMany},
+	} {
+		response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+			"name": "execute_audit_query_batch", "arguments": arguments,
+		}})
+		require.NotNil(t, response.Error, "%v", arguments)
+		assert.Equal(t, -32602, response.Error.Code)
+	}
+}
+
+// TestMaxConcurrentQueriesFromEnv tests reading AUDIT_MAX_CONCURRENT_QUERIES
+func TestMaxConcurrentQueriesFromEnv(t *testing.T) {
+	defaultLimit := types.DefaultAuditQueryConfig().MaxConcurrentQueries
+	for value, expected := range map[string]int{"": defaultLimit, "3": 3, "0": defaultLimit, "many": defaultLimit} {
+		t.Setenv("AUDIT_MAX_CONCURRENT_QUERIES", value)
+		assert.Equal(t, expected, maxConcurrentQueriesFromEnv(), value)
+	}
+}
+
+// TestGenerateRandomSuffix tests that query ID suffixes differ within the same second
+func TestGenerateRandomSuffix(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	suffixes := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		suffix := server.generateRandomSuffix()
+		assert.Len(t, suffix, 6)
+		assert.False(t, suffixes[suffix], "duplicate suffix %s", suffix)
+		suffixes[suffix] = true
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDetectChurn tests the detect_churn tool against the mock events
+func TestDetectChurn(t *testing.T) {
+	server := newMockServer(t)
+
+	response := server.handleDetectChurn("test-id", map[string]interface{}{"window": "soon"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	// No mock object changes often; with a threshold of one every change is listed
+	response = server.handleDetectChurn("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+	})
+	require.Nil(t, response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.ChurnReport)
+	assert.Empty(t, report.Objects)
+	assert.Equal(t, 6, report.TotalChanges)
+
+	response = server.handleDetectChurn("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+		"window":            "1h",
+		"min_changes":       float64(1),
+	})
+	require.Nil(t, response.Error)
+	report = response.Result.(map[string]interface{})["report"].(*types.ChurnReport)
+	require.Len(t, report.Objects, 6)
+	assert.Equal(t, "1h0m0s", report.Window)
+
+	var deleted []string
+	for _, object := range report.Objects {
+		if object.Verbs["delete"] > 0 {
+			deleted = append(deleted, object.Resource+"/"+object.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"secrets/db-password", "customresourcedefinitions/widgets.example.com"}, deleted)
+}
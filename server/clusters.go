@@ -0,0 +1,260 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// clusterNamePattern restricts cluster names to characters of kubeconfig
+// context names
+var clusterNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/@-]*$`)
+
+// fileNameUnsafe matches the characters of a cluster name replaced in the
+// names of its files
+var fileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// LoadClusterConfig reads registered clusters from a JSON file of the form
+// {"clusters": [{"name": "prod", "context": "prod-admin", "kubeconfig": "/path"}]}.
+// The context defaults to the cluster name.
+func LoadClusterConfig(path string) ([]types.ClusterConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster config: %w", err)
+	}
+
+	var config struct {
+		Clusters []types.ClusterConfig `json:"clusters"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster config: %w", err)
+	}
+
+	seen := make(map[string]bool, len(config.Clusters))
+	for i := range config.Clusters {
+		cluster := &config.Clusters[i]
+		if !clusterNamePattern.MatchString(cluster.Name) {
+			return nil, fmt.Errorf("invalid cluster name: %q", cluster.Name)
+		}
+		if seen[cluster.Name] {
+			return nil, fmt.Errorf("duplicate cluster: %s", cluster.Name)
+		}
+		seen[cluster.Name] = true
+		if cluster.Context == "" {
+			cluster.Context = cluster.Name
+		}
+	}
+	return config.Clusters, nil
+}
+
+// discoverClusters registers every context of the default kubeconfig as a
+// cluster named after the context
+func discoverClusters() ([]types.ClusterConfig, error) {
+	output, err := runOc(commands.ConfigContextsArgs(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kubeconfig contexts: %w", err)
+	}
+	var clusters []types.ClusterConfig
+	for _, context := range commands.ParseConfigContexts(output) {
+		if clusterNamePattern.MatchString(context) {
+			clusters = append(clusters, types.ClusterConfig{Name: context, Context: context})
+		}
+	}
+	return clusters, nil
+}
+
+// clustersFromEnv returns the clusters in AUDIT_CLUSTERS_CONFIG and, when
+// AUDIT_CLUSTER_DISCOVERY is set, the kubeconfig contexts not already
+// registered under another name
+func clustersFromEnv() []types.ClusterConfig {
+	var clusters []types.ClusterConfig
+	if path := os.Getenv("AUDIT_CLUSTERS_CONFIG"); path != "" {
+		configured, err := LoadClusterConfig(path)
+		if err != nil {
+			log.Printf("Warning: Failed to load cluster config: %v", err)
+		}
+		clusters = configured
+	}
+
+	if value := os.Getenv("AUDIT_CLUSTER_DISCOVERY"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("Warning: Invalid AUDIT_CLUSTER_DISCOVERY: %s", value)
+		}
+		if enabled {
+			discovered, err := discoverClusters()
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			registered := make(map[string]bool)
+			for _, cluster := range clusters {
+				registered[cluster.Name] = true
+				if cluster.Kubeconfig == "" {
+					registered[cluster.Context] = true
+				}
+			}
+			for _, cluster := range discovered {
+				if !registered[cluster.Name] {
+					clusters = append(clusters, cluster)
+				}
+			}
+		}
+	}
+	return clusters
+}
+
+// registerClusters creates a server for each registered cluster. Cluster
+// servers share the configuration of s but keep their own cache, circuit
+// breaker and audit trail, and run oc with the cluster's context.
+func (s *AuditQueryMCPServer) registerClusters(clusters []types.ClusterConfig, syslogWriter *utils.SyslogWriter, syslogOnly bool) {
+	s.clusters = make(map[string]*AuditQueryMCPServer, len(clusters))
+	for _, cluster := range clusters {
+		s.clusters[cluster.Name] = s.newClusterServer(cluster, syslogWriter, syslogOnly)
+	}
+	if len(clusters) > 0 {
+		log.Printf("Registered clusters: %s", strings.Join(s.ClusterNames(), ", "))
+	}
+}
+
+// newClusterServer creates the server of a registered cluster
+func (s *AuditQueryMCPServer) newClusterServer(cluster types.ClusterConfig, syslogWriter *utils.SyslogWriter, syslogOnly bool) *AuditQueryMCPServer {
+	fileName := fileNameUnsafe.ReplaceAllString(cluster.Name, "_")
+
+	cache := newCacheFromEnv()
+	cacheFile := ""
+	if s.cacheFile != "" {
+		cacheFile = s.cacheFile + "." + fileName
+		if loaded, err := cache.LoadFromFile(cacheFile); err != nil {
+			log.Printf("Warning: Failed to load cache of cluster %s from %s: %v", cluster.Name, cacheFile, err)
+		} else if loaded > 0 {
+			log.Printf("Restored %d cached results of cluster %s from %s", loaded, cluster.Name, cacheFile)
+		}
+	}
+	auditTrail, err := newAuditTrail(filepath.Join(filepath.Dir(AuditTrailFile()), "audit_trail_"+fileName+".json"), syslogWriter, syslogOnly)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize audit trail of cluster %s: %v", cluster.Name, err)
+		auditTrail = nil
+	}
+
+	return &AuditQueryMCPServer{
+		client:                s.client,
+		logger:                s.logger,
+		cache:                 cache,
+		auditTrail:            auditTrail,
+		inProcessFiltering:    s.inProcessFiltering,
+		omitRawOutput:         s.omitRawOutput,
+		hideLegacyTools:       s.hideLegacyTools,
+		inFlight:              s.inFlight,
+		quarantine:            s.quarantine,
+		reportDir:             s.reportDir,
+		localFileDir:          s.localFileDir,
+		forwarder:             s.forwarder,
+		cacheFile:             cacheFile,
+		incrementalQueries:    s.incrementalQueries,
+		coverage:              make(map[string]queryCoverage),
+		circuit:               newCircuitBreakerFromEnv(),
+		availability:          make(map[string]cachedAvailability),
+		availabilityTTL:       s.availabilityTTL,
+		clusterLookup:         s.clusterLookup,
+		lookupCache:           make(map[string]cachedLookup),
+		enrichmentTTL:         s.enrichmentTTL,
+		internalNetworks:      s.internalNetworks,
+		geoDatabase:           s.geoDatabase,
+		providerCommands:      make(map[string]providerQuery),
+		idempotency:           make(map[string]*idempotencyRecord),
+		idempotencyWindow:     s.idempotencyWindow,
+		resultSigningKey:      s.resultSigningKey,
+		templates:             s.queryTemplates(),
+		narrator:              s.narrator,
+		narrativeModel:        s.narrativeModel,
+		summaryTemplates:      s.summaryTemplates,
+		subscriptions:         make(map[string]bool),
+		querySlots:            s.querySlots,
+		findings:              s.findings,
+		indicators:            s.indicatorSet(),
+		secretAccessAllowlist: s.secretAccessAllowlist,
+		settings:              s.settings,
+		parser:                s.parserConfig(),
+		clusters:              s.clusters,
+		cluster:               cluster.Name,
+		ocFlags:               commands.ClusterFlags(cluster.Context, cluster.Kubeconfig),
+		parent:                s,
+	}
+}
+
+// ClusterNames returns the names of the registered clusters, sorted
+func (s *AuditQueryMCPServer) ClusterNames() []string {
+	names := make([]string, 0, len(s.clusters))
+	for name := range s.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// serverForCluster returns the server of the named cluster; an empty name
+// selects s
+func (s *AuditQueryMCPServer) serverForCluster(name string) (*AuditQueryMCPServer, error) {
+	if name == "" || name == s.cluster {
+		return s, nil
+	}
+	if server, ok := s.clusters[name]; ok {
+		return server, nil
+	}
+	if len(s.clusters) == 0 {
+		return nil, fmt.Errorf("unknown cluster: %s (no clusters are registered)", name)
+	}
+	return nil, fmt.Errorf("unknown cluster: %s (registered: %s)", name, strings.Join(s.ClusterNames(), ", "))
+}
+
+// ocArgs prepends the flags selecting the server's cluster to oc arguments
+func (s *AuditQueryMCPServer) ocArgs(args []string) []string {
+	if len(s.ocFlags) == 0 {
+		return args
+	}
+	return append(append([]string(nil), s.ocFlags...), args...)
+}
+
+// clustersSchema returns the input schema of the clusters argument of
+// query_all_clusters
+func (s *AuditQueryMCPServer) clustersSchema() map[string]interface{} {
+	items := map[string]interface{}{"type": "string"}
+	if len(s.clusters) > 0 {
+		items["enum"] = s.ClusterNames()
+	}
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Registered clusters to query; all registered clusters when omitted",
+		"items":       items,
+	}
+}
+
+// withClusterParameter adds the cluster argument to the tools' input schemas
+// when clusters are registered
+func (s *AuditQueryMCPServer) withClusterParameter(tools []types.MCPTool) []types.MCPTool {
+	if len(s.clusters) == 0 {
+		return tools
+	}
+	for _, tool := range tools {
+		properties, ok := tool.InputSchema["properties"].(map[string]interface{})
+		if !ok || tool.Name == "query_all_clusters" {
+			continue
+		}
+		properties["cluster"] = map[string]interface{}{
+			"type":        "string",
+			"description": "Registered cluster to run against, with its own cache, circuit breaker and audit trail; the cluster of the server's default kubeconfig context when omitted",
+			"enum":        s.ClusterNames(),
+		}
+	}
+	return tools
+}
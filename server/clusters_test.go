@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadClusterConfig tests reading and validating registered clusters
+func TestLoadClusterConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, fmt.Sprintf("clusters-%d.json", time.Now().UnixNano()))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	clusters, err := LoadClusterConfig(write(`{"clusters": [
+		{"name": "prod"},
+		{"name": "staging", "context": "staging-admin", "kubeconfig": "/etc/kube/staging.yaml"}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []types.ClusterConfig{
+		{Name: "prod", Context: "prod"},
+		{Name: "staging", Context: "staging-admin", Kubeconfig: "/etc/kube/staging.yaml"},
+	}, clusters)
+
+	_, err = LoadClusterConfig(write(`{"clusters": [{"name": "prod"}, {"name": "prod"}]}`))
+	assert.ErrorContains(t, err, "duplicate cluster")
+	_, err = LoadClusterConfig(write(`{"clusters": [{"name": "-prod"}]}`))
+	assert.ErrorContains(t, err, "invalid cluster name")
+	_, err = LoadClusterConfig(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+// TestClusters tests routing tool calls to the server of the named cluster
+func TestClusters(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	event := func(user string) string {
+		return fmt.Sprintf(`{"auditID":"%s-1","verb":"get","requestURI":"/api/v1/namespaces/default/pods","user":{"username":"%s"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200},"requestReceivedTimestamp":"%s"}`, user, user, now)
+	}
+	// Each cluster's oc context returns a different user's event
+	installFakeOc(t, fmt.Sprintf(`#!/bin/sh
+case "$*" in
+"--context=prod adm node-logs"*) echo '%s' ;;
+"--kubeconfig=/etc/kube/staging.yaml --context=staging-admin adm node-logs"*) echo '%s' ;;
+"adm node-logs"*) echo '%s' ;;
+*) echo "error: unexpected call: $*" >&2; exit 1 ;;
+esac
+`, event("alice"), event("bob"), event("carol")))
+
+	configPath := filepath.Join(t.TempDir(), "clusters.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"clusters": [
+		{"name": "prod"},
+		{"name": "staging", "context": "staging-admin", "kubeconfig": "/etc/kube/staging.yaml"}
+	]}`), 0644))
+	t.Setenv("AUDIT_PROVIDER", "")
+	t.Setenv("AUDIT_IN_PROCESS_FILTERING", "true")
+	t.Setenv("AUDIT_CLUSTERS_CONFIG", configPath)
+	server := NewAuditQueryMCPServer()
+	t.Cleanup(func() { server.Shutdown() })
+	assert.Equal(t, []string{"prod", "staging"}, server.ClusterNames())
+
+	query := func(cluster string) types.MCPResponse {
+		arguments := map[string]interface{}{
+			"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+		}
+		if cluster != "" {
+			arguments["cluster"] = cluster
+		}
+		return server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+			"name": "execute_complete_audit_query", "arguments": arguments,
+		}})
+	}
+	for cluster, user := range map[string]string{"prod": "alice", "staging": "bob", "": "carol"} {
+		response := query(cluster)
+		require.Nil(t, response.Error, cluster)
+		result := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+		assert.Equal(t, cluster, result.Cluster)
+		require.Len(t, result.ParsedData, 1, cluster)
+		assert.Equal(t, user, result.ParsedData[0]["username"], cluster)
+	}
+
+	// Each cluster keeps its own cache, circuit breaker and audit trail
+	prod, staging := server.clusters["prod"], server.clusters["staging"]
+	assert.Equal(t, 1, prod.cache.Size())
+	assert.Equal(t, 1, staging.cache.Size())
+	assert.Equal(t, 1, server.cache.Size())
+	assert.NotSame(t, prod.circuit, server.circuit)
+	require.NotNil(t, prod.auditTrail)
+	assert.NotSame(t, prod.auditTrail, server.auditTrail)
+
+	stats := server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "get_server_stats", "arguments": map[string]interface{}{"cluster": "staging"},
+	}})
+	require.Nil(t, stats.Error)
+	assert.Equal(t, "staging", stats.Result.(map[string]interface{})["server_stats"].(map[string]interface{})["cluster"])
+
+	response := query("qa")
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "unknown cluster: qa (registered: prod, staging)")
+
+	// Tools offer the registered clusters
+	for _, tool := range server.GetTools() {
+		if tool.Name == "query_all_clusters" {
+			continue
+		}
+		cluster := tool.InputSchema["properties"].(map[string]interface{})["cluster"].(map[string]interface{})
+		assert.Equal(t, []string{"prod", "staging"}, cluster["enum"], tool.Name)
+	}
+}
+
+// TestClusters_NoneRegistered tests that tools have no cluster argument without registered clusters
+func TestClusters_NoneRegistered(t *testing.T) {
+	server := newMockServer(t)
+	for _, tool := range server.GetTools() {
+		assert.NotContains(t, tool.InputSchema["properties"], "cluster", tool.Name)
+	}
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "get_cache_stats", "arguments": map[string]interface{}{"cluster": "prod"},
+	}})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "no clusters are registered")
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/reporting"
+	"audit-query-mcp-server/types"
+)
+
+// complianceMonth returns the start of the month a compliance report covers:
+// month in YYYY-MM form, or the previous month when it is empty. Months that
+// have not started are rejected.
+func complianceMonth(month string, now time.Time) (time.Time, error) {
+	now = now.UTC()
+	if month == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0), nil
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("month must be in YYYY-MM form, got %q", month)
+	}
+	if start.After(now) {
+		return time.Time{}, fmt.Errorf("month %s has not started", month)
+	}
+	return start, nil
+}
+
+// BuildComplianceReport fetches the events of the month starting at
+// monthStart matching params and builds its access review artifacts, with the
+// access to the default sensitive namespaces and those of extraNamespaces.
+// The month is in UTC, like the audit timestamps.
+func (s *AuditQueryMCPServer) BuildComplianceReport(params types.AuditQueryParams, monthStart time.Time, extraNamespaces []string) (*types.ComplianceReport, *types.AuditResult, error) {
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	month := monthStart.Format("2006-01")
+	s.logger.Infof("Building compliance report for %s", month)
+
+	params.Timeframe = "since " + monthStart.Format("2006-01-02")
+	fetched, result, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, result, err
+	}
+	// The timeframe runs until now, so drop the events after the month
+	var entries []parsing.AuditLogEntry
+	for _, entry := range fetched {
+		if timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil && !timestamp.Before(monthStart) && timestamp.Before(monthEnd) {
+			entries = append(entries, entry)
+		}
+	}
+
+	report := parsing.BuildComplianceReport(month, entries, append(append([]string{}, parsing.DefaultSensitiveNamespaces...), extraNamespaces...))
+	report.Start, report.End = monthStart.Format(time.RFC3339), monthEnd.Format(time.RFC3339)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Compliance report: %s", report.Summary)
+	return &report, result, nil
+}
+
+// WriteComplianceDocuments writes rendered compliance documents to the report
+// directory and returns their paths in file name order
+func (s *AuditQueryMCPServer) WriteComplianceDocuments(documents map[string]string) ([]string, error) {
+	names := make([]string, 0, len(documents))
+	for name := range documents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path, err := reporting.WriteReport(s.reportDir, name, documents[name])
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	s.logger.Infof("Wrote %d compliance documents to %s", len(paths), s.reportDir)
+	return paths, nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestComplianceMonth tests defaulting to the previous month and rejecting malformed and future months
+func TestComplianceMonth(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	start, err := complianceMonth("", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), start)
+
+	start, err = complianceMonth("2026-03", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), start)
+
+	_, err = complianceMonth("March 2026", now)
+	assert.Error(t, err)
+	_, err = complianceMonth("2026-04", now)
+	assert.Error(t, err)
+}
+
+// TestGenerateComplianceReport tests trimming events to the month and writing CSV documents
+func TestGenerateComplianceReport(t *testing.T) {
+	dir := t.TempDir()
+	monthStart := time.Date(time.Now().UTC().Year(), time.Now().UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	event := func(id string, timestamp time.Time, username, verb, resource, namespace string) string {
+		return fmt.Sprintf(`{"kind":"Event","level":"Metadata","stage":"ResponseComplete","auditID":%q,"requestReceivedTimestamp":%q,"verb":%q,"user":{"username":%q},"objectRef":{"resource":%q,"namespace":%q},"responseStatus":{"code":200}}`,
+			id, timestamp.Format(time.RFC3339Nano), verb, username, resource, namespace)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event("c1", monthStart.Add(time.Hour), "alice", "get", "secrets", "kube-system"),
+		event("c2", monthStart.Add(-time.Hour), "bob", "delete", "namespaces", ""),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+	server.reportDir = t.TempDir()
+
+	response := server.handleGenerateComplianceReport("test-id", map[string]interface{}{
+		"month":       monthStart.Format("2006-01"),
+		"format":      "csv",
+		"write_files": true,
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	result := response.Result.(map[string]interface{})
+	report := result["report"].(*types.ComplianceReport)
+	assert.Equal(t, 1, report.TotalEvents)
+	require.Len(t, report.Users, 1)
+	assert.Equal(t, "alice", report.Users[0].Username)
+	assert.Empty(t, report.PrivilegedOperations)
+	require.Len(t, report.SensitiveAccess, 1)
+
+	paths := result["paths"].([]string)
+	require.Len(t, paths, 3)
+	assert.Equal(t, filepath.Join(server.reportDir, "compliance-"+monthStart.Format("2006-01")+"-sensitive-namespace-access.csv"), paths[1])
+	content, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "kube-system,alice,get,secrets,1,0")
+
+	response = server.handleGenerateComplianceReport("test-id", map[string]interface{}{"month": "next month"})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "YYYY-MM")
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"os"
+
+	"audit-query-mcp-server/config"
+	"audit-query-mcp-server/types"
+)
+
+// queryTemplates returns the saved query templates
+func (s *AuditQueryMCPServer) queryTemplates() []types.QueryTemplate {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.templates
+}
+
+// configuration returns the resolved settings
+func (s *AuditQueryMCPServer) configuration() *config.Config {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.settings
+}
+
+// ReloadConfig re-reads the .env and YAML config files, then the parser
+// settings, the query templates of AUDIT_QUERY_TEMPLATES and the indicators
+// of AUDIT_INDICATORS, for the server and its cluster servers. Parser
+// changes made with ConfigureParser are replaced. A file that fails to load keeps
+// its previous contents and is reported. Other settings, such as watch rules
+// and backends, are read at start only.
+func (s *AuditQueryMCPServer) ReloadConfig() types.ConfigReloadResult {
+	var result types.ConfigReloadResult
+
+	settings, err := config.Load()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("configuration: %v", err))
+	}
+
+	templates, templatesOK := s.queryTemplates(), true
+	if path := os.Getenv("AUDIT_QUERY_TEMPLATES"); path != "" {
+		loaded, err := LoadQueryTemplates(path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("query templates: %v", err))
+			templatesOK = false
+		} else {
+			templates = loaded
+		}
+	} else {
+		templates = nil
+	}
+
+	indicatorSet, indicatorsOK := s.indicatorSet(), true
+	if value := os.Getenv("AUDIT_INDICATORS"); value != "" {
+		loaded, err := loadIndicators(value)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("indicators: %v", err))
+			indicatorsOK = false
+		} else {
+			indicatorSet = loaded
+		}
+	} else {
+		indicatorSet = nil
+	}
+
+	servers := []*AuditQueryMCPServer{s}
+	for _, name := range s.ClusterNames() {
+		servers = append(servers, s.clusters[name])
+	}
+	for _, server := range servers {
+		server.configMutex.Lock()
+		server.settings = settings
+		if templatesOK {
+			server.templates = templates
+		}
+		if indicatorsOK {
+			server.indicators = indicatorSet
+		}
+		server.configMutex.Unlock()
+	}
+
+	s.setParserConfig(s.parserConfig(), parserConfigFromEnv(), "reload")
+
+	result.QueryTemplates = len(s.queryTemplates())
+	result.Indicators = s.indicatorCount()
+	s.logger.Infof("Reloaded configuration: %d query templates, %d indicators", result.QueryTemplates, result.Indicators)
+	for _, message := range result.Errors {
+		s.logger.Warnf("Failed to reload %s", message)
+	}
+
+	// Templates are listed as resources
+	s.notify("notifications/resources/list_changed", nil)
+	return result
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditCSRActivity tests reading CSR decisions from request bodies and expected approvers
+func TestAuditCSRActivity(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	event := func(minutesAgo int, username, verb, subresource, body string) string {
+		return fmt.Sprintf(`{"kind":"Event","level":"Request","stage":"ResponseComplete","auditID":"csr-%d","requestReceivedTimestamp":%q,"verb":%q,"user":{"username":%q},"objectRef":{"resource":"certificatesigningrequests","name":"csr-abc","apiGroup":"certificates.k8s.io","subresource":%q},"responseStatus":{"code":200},"requestObject":%s}`,
+			minutesAgo, now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), verb, username, subresource, body)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event(30, "alice", "create", "", `{"spec":{"signerName":"kubernetes.io/kube-apiserver-client"}}`),
+		event(20, "bob", "update", "approval", `{"status":{"conditions":[{"type":"Approved","status":"True"}]}}`),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleAuditCSRActivity("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.CSRActivityReport)
+	require.Len(t, report.Requests, 1)
+	assert.Equal(t, "kubernetes.io/kube-apiserver-client", report.Requests[0].SignerName)
+	assert.Equal(t, types.CSRDecisionApproved, report.Requests[0].Decision)
+	assert.Equal(t, "bob", report.Requests[0].DecidedBy)
+	assert.True(t, report.Requests[0].UnusualApprover)
+	assert.Equal(t, 1, report.UnusualApprovals)
+
+	response = server.handleAuditCSRActivity("test-id", map[string]interface{}{
+		"structured_params":  map[string]interface{}{"timeframe": "2h"},
+		"expected_approvers": []interface{}{"bob"},
+	})
+	require.Nil(t, response.Error)
+	report = response.Result.(map[string]interface{})["report"].(*types.CSRActivityReport)
+	assert.Equal(t, 0, report.UnusualApprovals)
+	assert.Contains(t, report.ExpectedApprovers, "bob")
+}
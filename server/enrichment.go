@@ -0,0 +1,272 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// Enrichment defaults
+const (
+	// DefaultEnrichmentTTL is how long a cluster lookup is reused
+	DefaultEnrichmentTTL = 5 * time.Minute
+	// maxEnrichmentLookups bounds the cluster API calls one enrichment makes
+	maxEnrichmentLookups = 200
+)
+
+// errLookupSkipped marks lookups not made because the lookup limit was reached
+var errLookupSkipped = errors.New("skipped: lookup limit reached")
+
+// cachedLookup is the output of a cluster lookup and when it expires
+type cachedLookup struct {
+	output  string
+	expires time.Time
+}
+
+// lookupOutcome is the output or error of a lookup made during one enrichment
+type lookupOutcome struct {
+	output string
+	err    error
+}
+
+// enrichment runs the lookups of one EnrichResult call, making each distinct
+// lookup once
+type enrichment struct {
+	server   *AuditQueryMCPServer
+	outcomes map[string]lookupOutcome
+	stats    types.EnrichmentStats
+}
+
+// EnrichResult returns a copy of result whose parsed entries carry a
+// "cluster_context" with the current cluster state: whether the object still
+// exists, the service account behind the user and the workloads running as
+// it, and the namespace's labels. The summary names a service account user by
+// its workload when exactly one runs as it. Lookups are read-only oc get
+// commands, cached for the enrichment TTL.
+func (s *AuditQueryMCPServer) EnrichResult(result *types.AuditResult) (*types.AuditResult, *types.EnrichmentStats, error) {
+	if s.provider != nil {
+		return nil, nil, fmt.Errorf("enrichment looks up cluster state with oc and is not available with the %s provider", s.provider.Name())
+	}
+
+	e := &enrichment{server: s, outcomes: make(map[string]lookupOutcome)}
+	enriched := *result
+	// The annotated entries no longer match the hashes of the cached result
+	enriched.Integrity = nil
+	enriched.ParsedData = make([]map[string]interface{}, len(result.ParsedData))
+	for i, entry := range result.ParsedData {
+		copied := make(map[string]interface{}, len(entry)+1)
+		for key, value := range entry {
+			copied[key] = value
+		}
+		if clusterContext := e.clusterContext(entry); clusterContext != nil {
+			copied["cluster_context"] = clusterContext
+			e.stats.EnrichedEntries++
+		}
+		enriched.ParsedData[i] = copied
+	}
+
+	enriched.Summary = e.resolveIdentities(result.Summary, enriched.ParsedData)
+
+	s.logger.Infof("Enriched %d entries of query %s: %d lookups, %d cached, %d failed, %d skipped",
+		e.stats.EnrichedEntries, result.QueryID, e.stats.Lookups, e.stats.CachedLookups, e.stats.FailedLookups, e.stats.SkippedLookups)
+	return &enriched, &e.stats, nil
+}
+
+// clusterContext looks up the cluster state of an entry's object, user and
+// namespace; nil when none applies
+func (e *enrichment) clusterContext(entry map[string]interface{}) *types.ClusterContext {
+	str := func(key string) string {
+		value, _ := entry[key].(string)
+		return value
+	}
+	clusterContext := &types.ClusterContext{}
+	applied := false
+
+	resource, name, namespace := str("resource"), str("name"), str("namespace")
+	if resource != "" && name != "" {
+		applied = true
+		output, err := e.lookup(commands.ResourceExistsArgs(resource, str("api_group"), namespace, name))
+		if err != nil {
+			clusterContext.Errors = append(clusterContext.Errors, fmt.Sprintf("%s %s: %v", resource, name, err))
+		} else {
+			exists := commands.ParseResourceExists(output)
+			clusterContext.ResourceExists = &exists
+		}
+	}
+
+	if saNamespace, saName, ok := commands.ParseServiceAccountUser(str("username")); ok {
+		applied = true
+		clusterContext.ServiceAccount = &types.ServiceAccountRef{Namespace: saNamespace, Name: saName}
+		output, err := e.lookup(commands.ResourceExistsArgs("serviceaccounts", "", saNamespace, saName))
+		if err != nil {
+			clusterContext.Errors = append(clusterContext.Errors, fmt.Sprintf("service account %s/%s: %v", saNamespace, saName, err))
+		} else {
+			exists := commands.ParseResourceExists(output)
+			clusterContext.ServiceAccount.Exists = &exists
+		}
+		workloads, err := e.serviceAccountWorkloads(saNamespace, saName)
+		if err != nil {
+			clusterContext.Errors = append(clusterContext.Errors, fmt.Sprintf("workloads of service account %s/%s: %v", saNamespace, saName, err))
+		}
+		clusterContext.ServiceAccount.Workloads = workloads
+	}
+
+	if namespace != "" {
+		applied = true
+		output, err := e.lookup(commands.NamespaceArgs(namespace))
+		var exists bool
+		var labels map[string]string
+		if err == nil {
+			exists, labels, err = commands.ParseNamespace(output)
+		}
+		if err != nil {
+			clusterContext.Errors = append(clusterContext.Errors, fmt.Sprintf("namespace %s: %v", namespace, err))
+		} else {
+			clusterContext.NamespaceExists = &exists
+			clusterContext.NamespaceLabels = labels
+		}
+	}
+
+	if !applied {
+		return nil
+	}
+	return clusterContext
+}
+
+// serviceAccountWorkloads returns the workloads whose pods run as a service
+// account, with the operator that manages each Deployment
+func (e *enrichment) serviceAccountWorkloads(namespace, name string) ([]types.WorkloadRef, error) {
+	output, err := e.lookup(commands.ServiceAccountPodsArgs(namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	workloads, err := commands.ParsePodWorkloads(output)
+	if err != nil {
+		return nil, err
+	}
+	for i, workload := range workloads {
+		if workload.Kind != "Deployment" {
+			continue
+		}
+		output, err := e.lookup(commands.DeploymentArgs(workload.Namespace, workload.Name))
+		if err == nil {
+			workloads[i].Operator, err = commands.ParseDeploymentOperator(output)
+		}
+		if err != nil {
+			return workloads, fmt.Errorf("deployment %s: %w", workload.Name, err)
+		}
+	}
+	return workloads, nil
+}
+
+// resolveIdentities rewrites the service account users of enriched entries
+// in a summary as the single workload running as them, e.g.
+// "the gpu-operator deployment"; users that map to no or several workloads
+// are kept as they are
+func (e *enrichment) resolveIdentities(summary string, entries []map[string]interface{}) string {
+	for _, entry := range entries {
+		clusterContext, ok := entry["cluster_context"].(*types.ClusterContext)
+		if !ok || clusterContext.ServiceAccount == nil || len(clusterContext.ServiceAccount.Workloads) != 1 {
+			continue
+		}
+		username, _ := entry["username"].(string)
+		summary = replaceIdentity(summary, username, clusterContext.ServiceAccount.Workloads[0].String())
+	}
+	return summary
+}
+
+// replaceIdentity replaces whole occurrences of a username in text, so that
+// "system:serviceaccount:ci:deploy" leaves "system:serviceaccount:ci:deployer" alone
+func replaceIdentity(text, username, replacement string) string {
+	if username == "" {
+		return text
+	}
+	var replaced strings.Builder
+	for {
+		index := strings.Index(text, username)
+		if index < 0 {
+			replaced.WriteString(text)
+			return replaced.String()
+		}
+		end := index + len(username)
+		if continuesIdentity(text[end:]) {
+			replaced.WriteString(text[:end])
+		} else {
+			replaced.WriteString(text[:index])
+			replaced.WriteString(replacement)
+		}
+		text = text[end:]
+	}
+}
+
+// continuesIdentity reports whether rest continues a Kubernetes object name;
+// a dot only does when more of the name follows, so a sentence's full stop
+// ends the name
+func continuesIdentity(rest string) bool {
+	isNameChar := func(c byte) bool {
+		return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-'
+	}
+	if rest != "" && rest[0] == '.' {
+		rest = rest[1:]
+	}
+	return rest != "" && isNameChar(rest[0])
+}
+
+// lookup runs a read-only oc command once per enrichment, reusing output
+// cached within the enrichment TTL; failed lookups are not cached
+func (e *enrichment) lookup(args []string) (string, error) {
+	key := strings.Join(args, " ")
+	if outcome, ok := e.outcomes[key]; ok {
+		return outcome.output, outcome.err
+	}
+
+	s := e.server
+	s.lookupCacheMutex.Lock()
+	cached, ok := s.lookupCache[key]
+	s.lookupCacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		e.stats.CachedLookups++
+		e.outcomes[key] = lookupOutcome{output: cached.output}
+		return cached.output, nil
+	}
+
+	if e.stats.Lookups >= maxEnrichmentLookups {
+		e.stats.SkippedLookups++
+		e.outcomes[key] = lookupOutcome{err: errLookupSkipped}
+		return "", errLookupSkipped
+	}
+	e.stats.Lookups++
+	output, err := s.clusterLookup(s.ocArgs(args))
+	e.outcomes[key] = lookupOutcome{output: output, err: err}
+	if err != nil {
+		e.stats.FailedLookups++
+		return "", err
+	}
+
+	s.lookupCacheMutex.Lock()
+	s.lookupCache[key] = cachedLookup{output: output, expires: time.Now().Add(s.enrichmentTTL)}
+	s.lookupCacheMutex.Unlock()
+	return output, nil
+}
+
+// addEnrichment replaces the result in a tool response with its enriched copy
+// when the request asks for enrichment. A failed enrichment is reported next
+// to the unenriched result.
+func (s *AuditQueryMCPServer) addEnrichment(response map[string]interface{}, params map[string]interface{}, result *types.AuditResult) *types.AuditResult {
+	if requested, _ := params["enrich"].(bool); !requested {
+		return result
+	}
+	enriched, stats, err := s.EnrichResult(result)
+	if err != nil {
+		s.logger.Warnf("Enrichment failed: %v", err)
+		response["enrichment_error"] = err.Error()
+		return result
+	}
+	response["audit_result"] = enriched
+	response["enrichment"] = stats
+	return enriched
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster answers enrichment lookups from canned outputs and counts them
+type fakeCluster struct {
+	outputs map[string]string
+	calls   map[string]int
+}
+
+func (c *fakeCluster) lookup(args []string) (string, error) {
+	key := strings.Join(args, " ")
+	c.calls[key]++
+	output, ok := c.outputs[key]
+	if !ok {
+		return "", errors.New("exit status 1, output: Error from server (Forbidden)")
+	}
+	return output, nil
+}
+
+// TestEnrichResult tests annotating entries with cluster state
+func TestEnrichResult(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "")
+	server := NewAuditQueryMCPServer()
+	cluster := &fakeCluster{calls: make(map[string]int), outputs: map[string]string{
+		"get pods web-1 --ignore-not-found -o name -n web":                "",
+		"get deployments.apps frontend --ignore-not-found -o name -n web": "deployment.apps/frontend\n",
+		"get namespace web --ignore-not-found -o json":                    `{"metadata":{"labels":{"env":"prod"}}}`,
+		"get serviceaccounts deployer --ignore-not-found -o name -n ci":   "serviceaccount/deployer\n",
+		"get namespace gone --ignore-not-found -o json":                   "",
+		"get serviceaccounts old-bot --ignore-not-found -o name -n ci":    "",
+		"get pods -n ci --field-selector=spec.serviceAccountName=deployer -o json": `{"items":[{"metadata":{"name":"deployer-5f7c9-x2x","namespace":"ci",
+			"labels":{"pod-template-hash":"5f7c9"},"ownerReferences":[{"kind":"ReplicaSet","name":"deployer-5f7c9","controller":true}]}}]}`,
+		"get deployments.apps deployer -n ci --ignore-not-found -o json":          `{"metadata":{"labels":{"olm.owner":"ci-operator.v1.2.0","olm.owner.kind":"ClusterServiceVersion"}}}`,
+		"get pods -n ci --field-selector=spec.serviceAccountName=old-bot -o json": `{"items":[]}`,
+	}}
+	server.clusterLookup = cluster.lookup
+
+	result := &types.AuditResult{QueryID: "q1", Summary: "Found 5 audit entries. Users involved: alice (2), bob (1), system:serviceaccount:ci:deployer (1), system:serviceaccount:ci:old-bot (1)", ParsedData: []map[string]interface{}{
+		{"username": "alice", "verb": "delete", "resource": "pods", "namespace": "web", "name": "web-1"},
+		{"username": "system:serviceaccount:ci:deployer", "verb": "patch", "resource": "deployments", "api_group": "apps", "namespace": "web", "name": "frontend"},
+		{"username": "system:serviceaccount:ci:old-bot", "verb": "list", "resource": "pods", "namespace": "gone"},
+		{"username": "bob", "verb": "get", "resource": "configmaps", "namespace": "secret-ns", "name": "x"},
+		{"username": "alice", "verb": "list", "resource": "namespaces"},
+	}}
+
+	enriched, stats, err := server.EnrichResult(result)
+	require.NoError(t, err)
+	require.Len(t, enriched.ParsedData, 5)
+
+	deleted := enriched.ParsedData[0]["cluster_context"].(*types.ClusterContext)
+	assert.False(t, *deleted.ResourceExists)
+	assert.True(t, *deleted.NamespaceExists)
+	assert.Equal(t, map[string]string{"env": "prod"}, deleted.NamespaceLabels)
+	assert.Nil(t, deleted.ServiceAccount)
+
+	patched := enriched.ParsedData[1]["cluster_context"].(*types.ClusterContext)
+	assert.True(t, *patched.ResourceExists)
+	assert.Equal(t, "deployer", patched.ServiceAccount.Name)
+	assert.True(t, *patched.ServiceAccount.Exists)
+	assert.Equal(t, []types.WorkloadRef{{Kind: "Deployment", Namespace: "ci", Name: "deployer", Operator: "ci-operator.v1.2.0"}}, patched.ServiceAccount.Workloads)
+
+	removed := enriched.ParsedData[2]["cluster_context"].(*types.ClusterContext)
+	assert.Nil(t, removed.ResourceExists)
+	assert.False(t, *removed.ServiceAccount.Exists)
+	assert.Empty(t, removed.ServiceAccount.Workloads)
+	assert.False(t, *removed.NamespaceExists)
+
+	// Failed lookups are reported on the entry
+	failed := enriched.ParsedData[3]["cluster_context"].(*types.ClusterContext)
+	assert.Nil(t, failed.ResourceExists)
+	assert.Len(t, failed.Errors, 2)
+	assert.Contains(t, failed.Errors[0], "Forbidden")
+
+	// Entries without a named object, service account or namespace are left alone
+	assert.NotContains(t, enriched.ParsedData[4], "cluster_context")
+	assert.Equal(t, types.EnrichmentStats{EnrichedEntries: 4, Lookups: 11, FailedLookups: 2}, *stats)
+
+	// Service accounts with a single workload are named by it in the summary
+	assert.Equal(t, "Found 5 audit entries. Users involved: alice (2), bob (1), the deployer deployment of operator ci-operator.v1.2.0 (1), system:serviceaccount:ci:old-bot (1)", enriched.Summary)
+	assert.Contains(t, result.Summary, "system:serviceaccount:ci:deployer")
+
+	// The cached result is not modified
+	assert.NotContains(t, result.ParsedData[0], "cluster_context")
+
+	// Successful lookups are cached, failed ones are retried
+	_, stats, err = server.EnrichResult(result)
+	require.NoError(t, err)
+	assert.Equal(t, 9, stats.CachedLookups)
+	assert.Equal(t, 2, stats.Lookups)
+	assert.Equal(t, 1, cluster.calls["get namespace web --ignore-not-found -o json"])
+	assert.Equal(t, 2, cluster.calls["get configmaps x --ignore-not-found -o name -n secret-ns"])
+}
+
+// TestReplaceIdentity tests replacing whole usernames in summaries
+func TestReplaceIdentity(t *testing.T) {
+	text := "system:serviceaccount:ci:deploy (2), system:serviceaccount:ci:deployer (1), system:serviceaccount:ci:deploy."
+	assert.Equal(t, "the deploy deployment (2), system:serviceaccount:ci:deployer (1), the deploy deployment.",
+		replaceIdentity(text, "system:serviceaccount:ci:deploy", "the deploy deployment"))
+	assert.Equal(t, text, replaceIdentity(text, "", "nobody"))
+}
+
+// TestEnrichResult_LookupLimit tests that one enrichment makes a bounded number of lookups
+func TestEnrichResult_LookupLimit(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "")
+	server := NewAuditQueryMCPServer()
+	calls := 0
+	server.clusterLookup = func(args []string) (string, error) {
+		calls++
+		return "", nil
+	}
+
+	result := &types.AuditResult{}
+	for i := 0; i < maxEnrichmentLookups+10; i++ {
+		result.ParsedData = append(result.ParsedData, map[string]interface{}{"resource": "pods", "name": strings.Repeat("p", i+1)})
+	}
+	enriched, stats, err := server.EnrichResult(result)
+	require.NoError(t, err)
+	assert.Equal(t, maxEnrichmentLookups, calls)
+	assert.Equal(t, 10, stats.SkippedLookups)
+	last := enriched.ParsedData[len(enriched.ParsedData)-1]["cluster_context"].(*types.ClusterContext)
+	assert.Contains(t, last.Errors[0], "lookup limit")
+}
+
+// TestEnrichResult_Tool tests the enrich argument of execute_complete_audit_query
+func TestEnrichResult_Tool(t *testing.T) {
+	server := newMockServer(t)
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "execute_complete_audit_query",
+		"arguments": map[string]interface{}{
+			"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "username": "alice", "timeframe": "today"},
+			"enrich":            true,
+		},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.NotNil(t, result["audit_result"])
+	assert.Contains(t, result["enrichment_error"], "not available with the mock provider")
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnalyzeErrorRates tests comparing today's error rates of the mock events with yesterday's
+func TestAnalyzeErrorRates(t *testing.T) {
+	server := newMockServer(t)
+
+	response := server.handleAnalyzeErrorRates("test-id", map[string]interface{}{
+		"min_requests": float64(2),
+	})
+	require.Nil(t, response.Error)
+	report, ok := response.Result.(*types.ErrorRateReport)
+	require.True(t, ok)
+
+	assert.Equal(t, "today", report.Current.Timeframe)
+	assert.Equal(t, "yesterday", report.Previous.Timeframe)
+	assert.NotEmpty(t, report.Current.QueryID)
+	assert.Equal(t, 2, report.MinRequests)
+
+	// default: 1 of 3 requests denied today, none of 1 yesterday
+	require.NotEmpty(t, report.Namespaces)
+	assert.Equal(t, "default", report.Namespaces[0].Name)
+	assert.Equal(t, 3, report.Namespaces[0].Requests)
+	assert.Equal(t, 1, report.Namespaces[0].PreviousRequests)
+	assert.True(t, report.Namespaces[0].Spike)
+	assert.Equal(t, []string{"default", "payments"}, report.SpikingNamespaces)
+	assert.Equal(t, []string{"bob"}, report.SpikingUsers)
+	assert.Contains(t, report.Summary, "spiking namespaces: default")
+
+	response = server.handleAnalyzeErrorRates("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "since 2024-01-10"},
+	})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "has no previous window")
+}
+
+// TestAnalyzeErrorRates_RollingTimeframe tests that a rolling timeframe counts
+// only the events of each window, even though its query reads the whole log
+func TestAnalyzeErrorRates_RollingTimeframe(t *testing.T) {
+	now := time.Now()
+	event := func(minutesAgo, code int) string {
+		received := now.Add(-time.Duration(minutesAgo) * time.Minute).Format(time.RFC3339Nano)
+		return `{"kind":"Event","stage":"ResponseComplete","auditID":"e` + received + `","verb":"get","user":{"username":"bob"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":` + strconv.Itoa(code) + `},"requestReceivedTimestamp":"` + received + `"}`
+	}
+	dir := t.TempDir()
+	lines := []string{
+		// The current hour: 1 of 2 requests denied
+		event(10, 403), event(20, 200),
+		// The previous hour: 2 requests, none denied
+		event(70, 200), event(80, 200),
+		// Older events belong to neither window
+		event(150, 403), event(160, 403), event(170, 403),
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	report, err := server.AnalyzeErrorRates(types.AuditQueryParams{LogSource: "kube-apiserver", Timeframe: "1h"}, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "2h", report.Previous.Timeframe)
+	require.Len(t, report.Namespaces, 1)
+	assert.Equal(t, "default", report.Namespaces[0].Name)
+	assert.Equal(t, 2, report.Namespaces[0].Requests)
+	assert.Equal(t, 2, report.Namespaces[0].PreviousRequests)
+	assert.True(t, report.Namespaces[0].Spike)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// errorRules classify tool errors by their message, which for failed commands
+// includes the oc or backend output. Rules are checked in order and the first
+// with a matching pattern gives the error type and remediation.
+var errorRules = []struct {
+	errorType   types.ErrorType
+	patterns    []string
+	remediation string
+}{
+	{
+		errorType:   types.ErrorTypeInvalidParams,
+		patterns:    []string{"validation failed", "no filters recognised", "invalid comparison dimension", "outside the local audit file directory"},
+		remediation: "Correct the parameters named in the message; explain_audit_query shows how structured_params are interpreted",
+	},
+	{
+		errorType:   types.ErrorTypeInvalidParams,
+		patterns:    []string{"no cached result"},
+		remediation: "Cached results expire; pass structured_params to run the query again",
+	},
+	{
+		errorType:   types.ErrorTypeClusterUnreachable,
+		patterns:    []string{"unable to connect to the server", "connection refused", "no such host", "no route to host", "i/o timeout", "tls handshake", "x509:", "status 502", "status 503"},
+		remediation: "Check that the cluster API server or log backend is reachable from this host: the server URL in the kubeconfig or backend settings, network access and certificates",
+	},
+	{
+		errorType:   types.ErrorTypeTimeout,
+		patterns:    []string{"timed out", "deadline exceeded", "did not complete", "status 504"},
+		remediation: "Narrow the query with a shorter timeframe or more filters (username, namespace, resource, verb) so fewer events are read, or retry when the cluster is less busy",
+	},
+	{
+		errorType:   types.ErrorTypePermissionDenied,
+		patterns:    []string{"forbidden", "unauthorized", "must be logged in", "permission denied", "access denied", "accessdenied", "status 401", "status 403"},
+		remediation: "Run check_permissions to see which RBAC permissions are missing; log in with oc login as a user allowed to run oc adm node-logs on master nodes, or check the credentials configured for the log backend",
+	},
+	{
+		errorType:   types.ErrorTypeLogSourceUnavailable,
+		patterns:    []string{"no audit log files found", "cannot read "},
+		remediation: "Check that the path exists below AUDIT_LOCAL_FILE_DIR and holds audit log files",
+	},
+	{
+		errorType:   types.ErrorTypeLogSourceUnavailable,
+		patterns:    []string{"not available on", "no such file or directory", "could not find the requested resource"},
+		remediation: "Run check_log_sources to see which log sources this cluster writes, and query one that is available",
+	},
+	{
+		errorType:   types.ErrorTypeExecutionFailed,
+		patterns:    []string{"command not found", "executable file not found"},
+		remediation: "Install the oc CLI, and jq unless AUDIT_IN_PROCESS_FILTERING is true, on the server host and add them to PATH",
+	},
+}
+
+// classifyError returns the error type of err and a remediation hint
+func classifyError(err error) (types.ErrorType, string) {
+	message := strings.ToLower(err.Error())
+	for _, rule := range errorRules {
+		for _, pattern := range rule.patterns {
+			if strings.Contains(message, pattern) {
+				return rule.errorType, rule.remediation
+			}
+		}
+	}
+	return types.ErrorTypeExecutionFailed, "Retry the query; if it keeps failing, check the server log and the audit trail entry for its query ID"
+}
+
+// toolError converts an error returned while running a tool to an MCPError
+// with the code, type and remediation of its class
+func toolError(err error) *types.MCPError {
+	errorType, remediation := classifyError(err)
+	return &types.MCPError{
+		Code:    errorType.Code(),
+		Message: err.Error(),
+		Data:    &types.MCPErrorData{Type: errorType, Remediation: remediation},
+	}
+}
+
+// invalidParamsError returns the MCPError for a missing or malformed argument
+func invalidParamsError(message string) *types.MCPError {
+	return &types.MCPError{
+		Code:    types.ErrorTypeInvalidParams.Code(),
+		Message: message,
+		Data: &types.MCPErrorData{
+			Type:        types.ErrorTypeInvalidParams,
+			Remediation: "Pass the arguments described by the tool's input schema, or the prompt or resource's parameters",
+		},
+	}
+}
+
+// errorResponse returns the response of a tool that failed with err
+func errorResponse(requestID string, err error) types.MCPResponse {
+	return types.MCPResponse{
+		ID:      requestID,
+		Error:   toolError(err),
+		JSONRPC: "2.0",
+	}
+}
+
+// invalidParamsResponse returns the response of a request with a missing or
+// malformed argument
+func invalidParamsResponse(requestID string, message string) types.MCPResponse {
+	return types.MCPResponse{
+		ID:      requestID,
+		Error:   invalidParamsError(message),
+		JSONRPC: "2.0",
+	}
+}
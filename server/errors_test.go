@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassifyError tests classifying the errors of failed queries
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected types.ErrorType
+	}{
+		{"validation failed: invalid log source: bogus", types.ErrorTypeInvalidParams},
+		{"command validation failed: command contains dangerous pattern", types.ErrorTypeInvalidParams},
+		{"no cached result for query ID audit_query_1", types.ErrorTypeInvalidParams},
+		{"command execution failed: exit status 1, output: error: You must be logged in to the server (Unauthorized)", types.ErrorTypePermissionDenied},
+		{`command execution failed: exit status 1, output: Error from server (Forbidden): nodes "master-0" is forbidden`, types.ErrorTypePermissionDenied},
+		{"Loki query failed with status 403: access denied", types.ErrorTypePermissionDenied},
+		{"command execution timed out after 30 seconds", types.ErrorTypeTimeout},
+		{"CloudWatch Logs Insights query q-1 did not complete: context deadline exceeded", types.ErrorTypeTimeout},
+		{"command execution failed: exit status 1, output: Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout", types.ErrorTypeClusterUnreachable},
+		{`Elasticsearch search failed: Post "https://es:9200/audit-*/_search": dial tcp: lookup es: no such host`, types.ErrorTypeClusterUnreachable},
+		{"log source oauth-server not available on this cluster: no audit logs found under oauth-server/ on any master node", types.ErrorTypeLogSourceUnavailable},
+		{"no audit log files found under /audit-logs/empty", types.ErrorTypeLogSourceUnavailable},
+		{"command execution failed: exit status 127, output: bash: line 1: oc: command not found", types.ErrorTypeExecutionFailed},
+		{"failed to parse Loki response: unexpected end of JSON input", types.ErrorTypeExecutionFailed},
+	}
+
+	for _, tt := range tests {
+		errorType, remediation := classifyError(errors.New(tt.message))
+		assert.Equal(t, tt.expected, errorType, tt.message)
+		assert.NotEmpty(t, remediation, tt.message)
+	}
+
+	_, remediation := classifyError(errors.New("bash: line 1: oc: command not found"))
+	assert.Contains(t, remediation, "Install the oc CLI")
+}
+
+// TestToolError tests the code and data of tool errors
+func TestToolError(t *testing.T) {
+	mcpErr := toolError(errors.New("command execution timed out after 30 seconds"))
+	assert.Equal(t, -32005, mcpErr.Code)
+	assert.Equal(t, "command execution timed out after 30 seconds", mcpErr.Message)
+
+	data, err := json.Marshal(mcpErr)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "TIMEOUT", decoded["data"].(map[string]interface{})["type"])
+	assert.NotEmpty(t, decoded["data"].(map[string]interface{})["remediation"])
+
+	response := invalidParamsResponse("test-id", "query_id required")
+	assert.Equal(t, -32602, response.Error.Code)
+	assert.Equal(t, types.ErrorTypeInvalidParams, response.Error.Data.Type)
+
+	codes := make(map[int]types.ErrorType)
+	for _, errorType := range []types.ErrorType{
+		types.ErrorTypeInvalidParams, types.ErrorTypePermissionDenied, types.ErrorTypeLogSourceUnavailable,
+		types.ErrorTypeTimeout, types.ErrorTypeClusterUnreachable, types.ErrorTypeExecutionFailed,
+	} {
+		_, duplicate := codes[errorType.Code()]
+		assert.False(t, duplicate, "%s shares code %d", errorType, errorType.Code())
+		codes[errorType.Code()] = errorType
+	}
+}
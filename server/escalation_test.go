@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDetectEscalationChains tests correlating failed logins with RBAC changes across log sources
+func TestDetectEscalationChains(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	event := func(minutesAgo int, fields string) string {
+		return fmt.Sprintf(`{"kind":"Event","stage":"ResponseComplete","requestReceivedTimestamp":%q,%s}`,
+			now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), fields)
+	}
+	failedLogin := `"auditID":"login-%d","verb":"post","requestURI":"/login","user":{"username":"system:anonymous"},"sourceIPs":["203.0.113.50"],"responseStatus":{"code":401},"annotations":{"authentication.openshift.io/decision":"deny","authentication.openshift.io/username":"mallory"}`
+	var logins []string
+	for i := 0; i < 3; i++ {
+		logins = append(logins, event(40-i, fmt.Sprintf(failedLogin, i)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oauth-server.log"), []byte(strings.Join(logins, "\n")+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event(30, `"auditID":"denied","verb":"create","requestURI":"/apis/rbac.authorization.k8s.io/v1/clusterrolebindings","user":{"username":"mallory"},"objectRef":{"resource":"clusterrolebindings","apiGroup":"rbac.authorization.k8s.io"},"responseStatus":{"code":403},"annotations":{"authorization.k8s.io/decision":"forbid"}`),
+		event(20, `"auditID":"granted","verb":"create","requestURI":"/apis/rbac.authorization.k8s.io/v1/namespaces/payments/rolebindings","user":{"username":"mallory"},"objectRef":{"resource":"rolebindings","namespace":"payments","name":"mallory-admin","apiGroup":"rbac.authorization.k8s.io"},"responseStatus":{"code":201}`),
+		event(10, `"auditID":"routine","verb":"create","requestURI":"/apis/rbac.authorization.k8s.io/v1/namespaces/web/rolebindings","user":{"username":"alice"},"objectRef":{"resource":"rolebindings","namespace":"web","name":"viewers","apiGroup":"rbac.authorization.k8s.io"},"responseStatus":{"code":201}`),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleDetectEscalationChains("test-id", map[string]interface{}{"timeframe": "2h", "window": "later"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleDetectEscalationChains("test-id", map[string]interface{}{"timeframe": "2h"})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report, ok := response.Result.(*types.EscalationReport)
+	require.True(t, ok)
+	assert.Equal(t, 4, report.FailedAttempts)
+	assert.Equal(t, 2, report.RBACModifications)
+	require.Len(t, report.Chains, 1)
+
+	chain := report.Chains[0]
+	assert.Equal(t, "mallory", chain.Username)
+	assert.Equal(t, 3, chain.FailedAuthentications)
+	assert.Equal(t, 1, chain.Forbidden)
+	require.Len(t, chain.Events, 5)
+	assert.Equal(t, "login-0", chain.Events[0].AuditID)
+	assert.Equal(t, "oauth-server", chain.Events[0].LogSource)
+	assert.Equal(t, "granted", chain.Events[4].AuditID)
+	assert.Equal(t, types.ChainEventRBACModification, chain.Events[4].Kind)
+	assert.Equal(t, chain.Events[4].Timestamp, chain.End)
+
+	// Other users' chains are filtered out
+	response = server.handleDetectEscalationChains("test-id", map[string]interface{}{"timeframe": "2h", "username": "alice"})
+	require.Nil(t, response.Error)
+	assert.Empty(t, response.Result.(*types.EscalationReport).Chains)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/validation"
+)
+
+// DefaultEstimateMaxEntries is the matching entry count above which an
+// estimate recommends narrowing the query first
+const DefaultEstimateMaxEntries = 10000
+
+// EstimateQuery counts the events params would return, and the log read to
+// find them, without running the query. Backends with a count API count the
+// events themselves; otherwise the raw log is fetched and its lines matching
+// the query's filters are counted without being parsed, summarized or cached.
+// Paging is not applied. Estimates above maxEntries, DefaultEstimateMaxEntries
+// when zero, recommend narrowing the query first.
+func (s *AuditQueryMCPServer) EstimateQuery(params types.AuditQueryParams, maxEntries int) (*types.QueryEstimate, error) {
+	s.logger.Info("Estimating audit query")
+
+	if maxEntries <= 0 {
+		maxEntries = DefaultEstimateMaxEntries
+	}
+	if params.LogSource == "" {
+		params.LogSource = "kube-apiserver"
+	}
+	if err := validation.ValidateQueryParams(params); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	provider, err := s.providerFor(params)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	estimate := &types.QueryEstimate{Backend: backendName(provider), MaxEntries: maxEntries}
+	if counter, ok := provider.(providers.CountingBackend); ok {
+		if err := s.countWithBackend(provider, counter, params, estimate); err != nil {
+			return nil, err
+		}
+	} else if err := s.countFetchedLines(params, estimate); err != nil {
+		return nil, err
+	}
+	estimate.ExecutionTime = time.Since(startTime).Milliseconds()
+
+	estimate.NarrowFirst = estimate.MatchingEntries > maxEntries
+	if estimate.NarrowFirst {
+		estimate.Suggestions = narrowingSuggestions(params)
+	}
+	if provider != nil {
+		if max := provider.Capabilities().MaxEvents; max > 0 && estimate.MatchingEntries > max {
+			estimate.Suggestions = append(estimate.Suggestions,
+				fmt.Sprintf("the %s backend returns at most %d events, so narrow the query to see every match", provider.Name(), max))
+		}
+	}
+	estimate.Summary = summarizeEstimate(estimate)
+
+	s.logger.Infof("Query estimate: %s", estimate.Summary)
+	return estimate, nil
+}
+
+// countWithBackend counts the events params match with a backend's count API
+func (s *AuditQueryMCPServer) countWithBackend(provider providers.QueryBackend, counter providers.CountingBackend, params types.AuditQueryParams, estimate *types.QueryEstimate) error {
+	command, err := provider.BuildQuery(params)
+	if err != nil {
+		return err
+	}
+	timeout := providerFetchTimeout
+	if slow, ok := provider.(providers.TimeoutBackend); ok {
+		timeout = slow.ExecuteTimeout()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	count, err := counter.Count(ctx, params)
+	if err != nil {
+		s.circuit.RecordFailure()
+		return fmt.Errorf("count failed: %w", err)
+	}
+	s.circuit.RecordSuccess()
+	estimate.Command, estimate.Method = command, types.EstimateMethodBackendCount
+	estimate.MatchingEntries = count
+	// Backends only narrow events with the query filters
+	estimate.UpperBound = true
+	return nil
+}
+
+// countFetchedLines fetches the raw log for params and counts its lines, and
+// the lines matching the query's filters
+func (s *AuditQueryMCPServer) countFetchedLines(params types.AuditQueryParams, estimate *types.QueryEstimate) error {
+	filter, err := parsing.NewEventFilter(params)
+	if err != nil {
+		return err
+	}
+	rawOutput, result, err := s.fetchRawLog(params)
+	if err != nil {
+		return err
+	}
+
+	estimate.Command, estimate.Method = result.Command, types.EstimateMethodFetchCount
+	estimate.ScannedBytes = int64(len(rawOutput))
+	for _, line := range strings.Split(rawOutput, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		estimate.ScannedLines++
+		if filter.Match(line) {
+			estimate.MatchingEntries++
+			estimate.MatchingBytes += int64(len(line))
+		}
+	}
+	return nil
+}
+
+// narrowingSuggestions lists ways to narrow a query returning too many events
+func narrowingSuggestions(params types.AuditQueryParams) []string {
+	var suggestions []string
+	if params.Timeframe == "" {
+		suggestions = append(suggestions, "set a timeframe such as \"1h\"")
+	}
+	if params.Username == "" && len(params.Usernames) == 0 && params.Verb == "" && len(params.Verbs) == 0 &&
+		params.Resource == "" && len(params.Resources) == 0 && params.Namespace == "" && len(params.Namespaces) == 0 {
+		suggestions = append(suggestions, "filter on a username, verb, resource or namespace")
+	}
+	suggestions = append(suggestions, "run find_top_talkers for exclude_users and exclude values that drop controller noise")
+	if params.Limit == 0 {
+		suggestions = append(suggestions, "set limit to page through the results")
+	}
+	return suggestions
+}
+
+// summarizeEstimate produces a short human-readable description of an estimate
+func summarizeEstimate(estimate *types.QueryEstimate) string {
+	matching := fmt.Sprintf("%d", estimate.MatchingEntries)
+	if estimate.UpperBound {
+		matching = "at most " + matching
+	}
+	summary := fmt.Sprintf("%s matching entries", matching)
+	if estimate.Method == types.EstimateMethodFetchCount {
+		summary += fmt.Sprintf(" (%d bytes) in %d lines (%d bytes) read", estimate.MatchingBytes, estimate.ScannedLines, estimate.ScannedBytes)
+	} else {
+		summary += fmt.Sprintf(" counted by the %s backend", estimate.Backend)
+	}
+	if estimate.NarrowFirst {
+		return summary + fmt.Sprintf("; more than %d, narrow the query first", estimate.MaxEntries)
+	}
+	return summary
+}
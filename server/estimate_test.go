@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBackend is a backend whose count API reports a fixed count
+type countingBackend struct {
+	count int
+}
+
+func (b countingBackend) Name() string { return "counting" }
+func (b countingBackend) Capabilities() providers.Capabilities {
+	return providers.Capabilities{LogSources: []string{"kube-apiserver"}, MaxEvents: 100}
+}
+func (b countingBackend) BuildQuery(params types.AuditQueryParams) (string, error) {
+	return "counting search", nil
+}
+func (b countingBackend) Execute(ctx context.Context, params types.AuditQueryParams) (string, error) {
+	return "", nil
+}
+func (b countingBackend) Count(ctx context.Context, params types.AuditQueryParams) (int, error) {
+	return b.count, nil
+}
+
+// TestEstimateQuery tests counting fetched lines against the query's result
+func TestEstimateQuery(t *testing.T) {
+	server := newMockServer(t)
+	params := types.AuditQueryParams{LogSource: "kube-apiserver", Username: "bob", StatusCodeRange: "4xx", Timeframe: "today"}
+
+	response := server.handleEstimateQuery("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleEstimateQuery("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "username": "bob", "status_code_range": "4xx", "timeframe": "today"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	estimate := response.Result.(map[string]interface{})["estimate"].(*types.QueryEstimate)
+	assert.Equal(t, types.EstimateMethodFetchCount, estimate.Method)
+	assert.Equal(t, "mock read kube-apiserver", estimate.Command)
+	assert.False(t, estimate.UpperBound)
+	assert.False(t, estimate.NarrowFirst)
+	assert.Greater(t, estimate.ScannedLines, estimate.MatchingEntries)
+	assert.Greater(t, estimate.ScannedBytes, estimate.MatchingBytes)
+
+	result, err := server.ExecuteCompleteAuditQuery(params)
+	require.NoError(t, err)
+	assert.Equal(t, result.TotalEntries, estimate.MatchingEntries)
+
+	params.Timeframe = ""
+	estimate, err = server.EstimateQuery(params, 1)
+	require.NoError(t, err)
+	assert.True(t, estimate.NarrowFirst)
+	assert.Contains(t, estimate.Suggestions, "set a timeframe such as \"1h\"")
+	assert.Contains(t, estimate.Summary, "narrow the query first")
+}
+
+// TestEstimateQuery_BackendCount tests counting with a backend's count API
+func TestEstimateQuery_BackendCount(t *testing.T) {
+	server := newMockServer(t)
+	server.provider = countingBackend{count: 250}
+
+	estimate, err := server.EstimateQuery(types.AuditQueryParams{Verb: "delete"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, types.EstimateMethodBackendCount, estimate.Method)
+	assert.Equal(t, "counting search", estimate.Command)
+	assert.Equal(t, 250, estimate.MatchingEntries)
+	assert.True(t, estimate.UpperBound)
+	assert.False(t, estimate.NarrowFirst)
+	assert.Equal(t, DefaultEstimateMaxEntries, estimate.MaxEntries)
+	require.Len(t, estimate.Suggestions, 1)
+	assert.Contains(t, estimate.Suggestions[0], "returns at most 100 events")
+	assert.Contains(t, estimate.Summary, "at most 250 matching entries counted by the counting backend")
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/validation"
+)
+
+// QueryAllClusters runs the same query through the complete pipeline on each
+// named registered cluster, or on all of them when clusters is empty. Clusters
+// are queried concurrently within the AUDIT_MAX_CONCURRENT_QUERIES limit, and
+// a cluster that fails is reported in its item without affecting the others.
+// The entries of all clusters are merged in time order and tagged with their
+// cluster.
+func (s *AuditQueryMCPServer) QueryAllClusters(params types.AuditQueryParams, clusters []string) (*types.ClusterFanOutResult, error) {
+	if len(s.clusters) == 0 {
+		return nil, fmt.Errorf("no clusters are registered")
+	}
+	if len(clusters) == 0 {
+		clusters = s.ClusterNames()
+	}
+	targets := make([]*AuditQueryMCPServer, 0, len(clusters))
+	seen := make(map[string]bool, len(clusters))
+	for _, name := range clusters {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		target, ok := s.clusters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cluster: %s (registered: %s)", name, strings.Join(s.ClusterNames(), ", "))
+		}
+		targets = append(targets, target)
+	}
+	// Invalid parameters would fail on every cluster alike
+	if err := validation.ValidateQueryParams(params); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	s.logger.Infof("Querying %d clusters", len(targets))
+
+	startTime := time.Now()
+	items := make([]types.ClusterQueryItem, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *AuditQueryMCPServer) {
+			defer wg.Done()
+			s.querySlots <- struct{}{}
+			defer func() { <-s.querySlots }()
+
+			item := types.ClusterQueryItem{Cluster: target.cluster}
+			defer func() {
+				// A failure on one cluster must not lose the others' results
+				if recovered := recover(); recovered != nil {
+					item.Result = nil
+					item.Error = fmt.Sprintf("query failed: %v", recovered)
+					item.ErrorType = types.ErrorTypeExecutionFailed
+				}
+				items[i] = item
+			}()
+
+			result, err := target.ExecuteCompleteAuditQuery(params)
+			if err != nil {
+				item.Error = err.Error()
+				item.ErrorType, _ = classifyError(err)
+			} else {
+				item.Result = result
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	fanOut := &types.ClusterFanOutResult{Params: params, Clusters: items, Entries: []map[string]interface{}{}}
+	var lines []string
+	for i, item := range items {
+		if item.Error != "" {
+			fanOut.Failed++
+			lines = append(lines, fmt.Sprintf("%s: failed: %s", item.Cluster, item.Error))
+			continue
+		}
+		fanOut.Succeeded++
+		fanOut.TotalEntries += item.Result.TotalEntries
+		lines = append(lines, fmt.Sprintf("%s: %d entries. %s", item.Cluster, item.Result.TotalEntries, item.Result.Summary))
+
+		for _, entry := range item.Result.ParsedData {
+			tagged := make(map[string]interface{}, len(entry)+1)
+			for key, value := range entry {
+				tagged[key] = value
+			}
+			tagged["cluster"] = item.Cluster
+			fanOut.Entries = append(fanOut.Entries, tagged)
+		}
+		// The entries are in the merged list and the result stays cached on its cluster
+		trimmed := *item.Result
+		trimmed.ParsedData = nil
+		trimmed.RawOutput = ""
+		fanOut.Clusters[i].Result = &trimmed
+	}
+	sortEntriesByTime(fanOut.Entries)
+
+	fanOut.Summary = fmt.Sprintf("Ran the query on %d clusters: %d succeeded, %d failed, %d entries in total.\n%s",
+		len(items), fanOut.Succeeded, fanOut.Failed, fanOut.TotalEntries, strings.Join(lines, "\n"))
+	fanOut.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.logger.Infof("Cluster fan-out finished: %d succeeded, %d failed", fanOut.Succeeded, fanOut.Failed)
+	return fanOut, nil
+}
+
+// sortEntriesByTime orders parsed entries by their timestamp, keeping the
+// order of entries without a parsable timestamp, which go last
+func sortEntriesByTime(entries []map[string]interface{}) {
+	timestamp := func(entry map[string]interface{}) (time.Time, bool) {
+		value, _ := entry["timestamp"].(string)
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		return parsed, err == nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, okA := timestamp(entries[i])
+		b, okB := timestamp(entries[j])
+		if okA != okB {
+			return okA
+		}
+		return okA && a.Before(b)
+	})
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryAllClusters tests running one query on several clusters with per-cluster errors
+func TestQueryAllClusters(t *testing.T) {
+	now := time.Now().UTC()
+	event := func(user string, at time.Time) string {
+		return fmt.Sprintf(`{"auditID":"%s-1","verb":"get","requestURI":"/api/v1/namespaces/default/pods","user":{"username":"%s"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200},"requestReceivedTimestamp":"%s"}`,
+			user, user, at.Format(time.RFC3339Nano))
+	}
+	// staging's event is the earlier one; qa's credentials have expired
+	installFakeOc(t, fmt.Sprintf(`#!/bin/sh
+case "$*" in
+"--context=prod adm node-logs"*) echo '%s' ;;
+"--context=staging adm node-logs"*) echo '%s' ;;
+"--context=qa "*) echo "error: You must be logged in to the server (Unauthorized)" >&2; exit 1 ;;
+*) echo "error: unexpected call: $*" >&2; exit 1 ;;
+esac
+`, event("alice", now), event("bob", now.Add(-time.Minute))))
+
+	configPath := filepath.Join(t.TempDir(), "clusters.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"clusters": [{"name": "prod"}, {"name": "staging"}, {"name": "qa"}]}`), 0644))
+	t.Setenv("AUDIT_PROVIDER", "")
+	t.Setenv("AUDIT_IN_PROCESS_FILTERING", "true")
+	t.Setenv("AUDIT_CLUSTERS_CONFIG", configPath)
+	server := NewAuditQueryMCPServer()
+	t.Cleanup(func() { server.Shutdown() })
+
+	params := types.AuditQueryParams{LogSource: "kube-apiserver", Timeframe: "today"}
+	result, err := server.QueryAllClusters(params, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 2, result.TotalEntries)
+
+	require.Len(t, result.Clusters, 3)
+	items := make(map[string]types.ClusterQueryItem)
+	for _, item := range result.Clusters {
+		items[item.Cluster] = item
+	}
+	assert.NotEmpty(t, items["qa"].Error)
+	assert.Nil(t, items["qa"].Result)
+	require.NotNil(t, items["prod"].Result)
+	assert.Equal(t, "prod", items["prod"].Result.Cluster)
+	assert.Nil(t, items["prod"].Result.ParsedData)
+
+	// Entries are merged in time order and tagged with their cluster
+	require.Len(t, result.Entries, 2)
+	assert.Equal(t, "bob", result.Entries[0]["username"])
+	assert.Equal(t, "staging", result.Entries[0]["cluster"])
+	assert.Equal(t, "alice", result.Entries[1]["username"])
+	assert.Equal(t, "prod", result.Entries[1]["cluster"])
+	assert.Contains(t, result.Summary, "2 succeeded, 1 failed")
+
+	// The merged entries leave the clusters' cached results untouched
+	cached, ok := server.clusters["prod"].cache.Get(items["prod"].Result.QueryID)
+	require.True(t, ok)
+	assert.NotContains(t, cached.ParsedData[0], "cluster")
+
+	// Selected clusters only
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "query_all_clusters",
+		"arguments": map[string]interface{}{
+			"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+			"clusters":          []interface{}{"staging"},
+		},
+	}})
+	require.Nil(t, response.Error)
+	selected := response.Result.(*types.ClusterFanOutResult)
+	require.Len(t, selected.Clusters, 1)
+	assert.Equal(t, "staging", selected.Clusters[0].Cluster)
+
+	_, err = server.QueryAllClusters(params, []string{"dev"})
+	assert.ErrorContains(t, err, "unknown cluster: dev")
+	_, err = server.QueryAllClusters(types.AuditQueryParams{}, nil)
+	assert.ErrorContains(t, err, "validation failed")
+}
+
+// TestQueryAllClusters_NoneRegistered tests that fan-out queries need registered clusters
+func TestQueryAllClusters_NoneRegistered(t *testing.T) {
+	server := newMockServer(t)
+	_, err := server.QueryAllClusters(types.AuditQueryParams{LogSource: "kube-apiserver"}, nil)
+	assert.ErrorContains(t, err, "no clusters are registered")
+}
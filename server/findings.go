@@ -0,0 +1,73 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// DefaultFindingsFile is where findings are saved unless AUDIT_FINDINGS_FILE is set
+const DefaultFindingsFile = "./logs/findings.json"
+
+// AnnotateResult flags a cached result, or its entry at entryIndex when set,
+// as notable with a note. The finding keeps a copy of the entry and the
+// result's summary, so it outlives the cached result.
+func (s *AuditQueryMCPServer) AnnotateResult(queryID string, entryIndex *int, note string) (types.Finding, error) {
+	if s.findings == nil {
+		return types.Finding{}, fmt.Errorf("findings are not available: the findings file could not be loaded")
+	}
+	result, found := s.cache.Get(queryID)
+	if !found {
+		return types.Finding{}, fmt.Errorf("cached result not found: %s", queryID)
+	}
+
+	finding := types.Finding{
+		QueryID:   queryID,
+		Cluster:   s.cluster,
+		Note:      note,
+		Command:   result.Command,
+		Summary:   result.Summary,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if entryIndex != nil {
+		if *entryIndex < 0 || *entryIndex >= len(result.ParsedData) {
+			return types.Finding{}, fmt.Errorf("entry_index %d is out of range: the result has %d entries", *entryIndex, len(result.ParsedData))
+		}
+		index := *entryIndex
+		finding.EntryIndex = &index
+		finding.Entry = result.ParsedData[index]
+	}
+
+	finding, err := s.findings.Add(finding)
+	if err != nil {
+		return types.Finding{}, err
+	}
+	s.logger.Infof("Flagged %s of query %s as notable", finding.ID, queryID)
+	return finding, nil
+}
+
+// ListFindings returns the findings flagged during the investigation, in the
+// order they were flagged; only those of queryID when it is set
+func (s *AuditQueryMCPServer) ListFindings(queryID string) ([]types.Finding, error) {
+	if s.findings == nil {
+		return nil, fmt.Errorf("findings are not available: the findings file could not be loaded")
+	}
+	return s.findings.List(queryID), nil
+}
+
+// DeleteFinding removes a finding by ID
+func (s *AuditQueryMCPServer) DeleteFinding(id string) error {
+	if s.findings == nil {
+		return fmt.Errorf("findings are not available: the findings file could not be loaded")
+	}
+	removed, err := s.findings.Remove(id)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("finding not found: %s", id)
+	}
+	s.logger.Infof("Deleted %s", id)
+	return nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindings tests flagging results and entries as notable and listing them across restarts
+func TestFindings(t *testing.T) {
+	t.Setenv("AUDIT_FINDINGS_FILE", filepath.Join(t.TempDir(), "findings.json"))
+	server := newMockServer(t)
+	call := func(name string, arguments map[string]interface{}) types.MCPResponse {
+		return server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+			"name": name, "arguments": arguments,
+		}})
+	}
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Username: "bob", StatusCodeRange: "4xx", Timeframe: "today"})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ParsedData)
+
+	response := call("annotate_audit_result", map[string]interface{}{
+		"query_id": result.QueryID, "entry_index": float64(0), "note": "Denied access to the default namespace",
+	})
+	require.Nil(t, response.Error)
+	entryFinding := response.Result.(map[string]interface{})["finding"].(types.Finding)
+	assert.Equal(t, "finding-1", entryFinding.ID)
+	require.NotNil(t, entryFinding.EntryIndex)
+	assert.Equal(t, 0, *entryFinding.EntryIndex)
+	assert.Equal(t, result.ParsedData[0]["username"], entryFinding.Entry["username"])
+
+	response = call("annotate_audit_result", map[string]interface{}{"query_id": result.QueryID, "note": "All of bob's denials today"})
+	require.Nil(t, response.Error)
+	resultFinding := response.Result.(map[string]interface{})["finding"].(types.Finding)
+	assert.Nil(t, resultFinding.EntryIndex)
+	assert.Equal(t, result.Summary, resultFinding.Summary)
+
+	response = call("annotate_audit_result", map[string]interface{}{"query_id": result.QueryID, "entry_index": float64(len(result.ParsedData))})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "out of range")
+	response = call("annotate_audit_result", map[string]interface{}{"query_id": "missing"})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "cached result not found")
+
+	response = call("list_findings", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	listed := response.Result.(map[string]interface{})
+	assert.Equal(t, 2, listed["count"])
+	assert.Contains(t, listed["report"], "finding-1: Denied access to the default namespace")
+
+	// Findings survive a restart and new findings continue the numbering
+	restarted := newMockServer(t)
+	findings, err := restarted.ListFindings(result.QueryID)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "Denied access to the default namespace", findings[0].Note)
+
+	response = call("delete_finding", map[string]interface{}{"finding_id": "finding-1"})
+	require.Nil(t, response.Error)
+	response = call("delete_finding", map[string]interface{}{"finding_id": "finding-1"})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "finding not found")
+
+	finding, err := server.AnnotateResult(result.QueryID, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "finding-3", finding.ID)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionEncodings are the content codings CompressHandler offers, in the
+// server's order of preference
+var compressionEncodings = []string{"zstd", "gzip"}
+
+// compressResponseWriter compresses the body written to an HTTP response
+type compressResponseWriter struct {
+	http.ResponseWriter
+	writer io.Writer
+}
+
+// WriteHeader drops the length of the uncompressed body before sending the headers
+func (w *compressResponseWriter) WriteHeader(status int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write compresses data into the response body
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	return w.writer.Write(data)
+}
+
+// negotiateEncoding returns the coding of compressionEncodings that an
+// Accept-Encoding header gives the highest non-zero quality, named or through
+// "*", preferring zstd on ties; "" when the header allows neither
+func negotiateEncoding(header string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range compressionEncodings {
+		// An explicit preference for a coding overrides the wildcard
+		quality, named := qualities[encoding]
+		if !named {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// newCompressWriter returns a writer compressing into w with encoding
+func newCompressWriter(w io.Writer, encoding string) (io.WriteCloser, error) {
+	if encoding == "zstd" {
+		// One goroutine per response; the default starts one per CPU
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	}
+	return gzip.NewWriter(w), nil
+}
+
+// CompressHandler compresses the responses of next with zstd or gzip for
+// clients whose Accept-Encoding allows it. HEAD requests, which have no body,
+// are passed through.
+func CompressHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if r.Method == http.MethodHead || encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writer, err := newCompressWriter(w, encoding)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", encoding)
+		defer writer.Close()
+		next.ServeHTTP(&compressResponseWriter{ResponseWriter: w, writer: writer}, r)
+	})
+}
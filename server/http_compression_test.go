@@ -0,0 +1,70 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNegotiateEncoding tests Accept-Encoding negotiation
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"gzip":                    "gzip",
+		"deflate, gzip;q=0.5":     "gzip",
+		"GZIP":                    "gzip",
+		"gzip;q=0":                "",
+		"*":                       "zstd",
+		"*;q=0":                   "",
+		"*, zstd;q=0":             "gzip",
+		"*;q=0.5, gzip":           "gzip",
+		"zstd, br":                "zstd",
+		"gzip, zstd":              "zstd",
+		"gzip, zstd;q=0.5":        "gzip",
+		"identity, gzip; q=0.001": "gzip",
+	}
+	for header, expected := range tests {
+		assert.Equal(t, expected, negotiateEncoding(header), "Accept-Encoding: %q", header)
+	}
+}
+
+// TestCompressHandler tests compressing responses for clients that accept zstd or gzip
+func TestCompressHandler(t *testing.T) {
+	body := strings.Repeat(`{"name":"execute_complete_audit_query"}`, 100)
+	handler := CompressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+
+	for encoding, decompress := range map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/tools", nil)
+		request.Header.Set("Accept-Encoding", encoding)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, encoding, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+		assert.Less(t, recorder.Body.Len(), len(body))
+		reader, err := decompress(recorder.Body)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(decompressed))
+	}
+
+	// Clients that accept neither get the plain body
+	request := httptest.NewRequest(http.MethodGet, "/tools", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, recorder.Body.String())
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// Idempotency key settings
+const (
+	// DefaultIdempotencyWindow is how long the response to an idempotency key
+	// is replayed to re-submissions
+	DefaultIdempotencyWindow = 10 * time.Minute
+	// maxIdempotencyKeys bounds the idempotency keys remembered at once
+	maxIdempotencyKeys = 1000
+)
+
+// idempotentTools are the tools running queries, whose calls may carry an
+// idempotency_key
+var idempotentTools = map[string]bool{
+	"execute_audit_query_with_result": true,
+	"execute_complete_audit_query":    true,
+	"execute_audit_query_batch":       true,
+	"ask_audit_question":              true,
+}
+
+// idempotencyRecord is the call made with an idempotency key. done is closed
+// once response is set, or failed when the call returned an error, which is
+// not remembered so that a retry runs again.
+type idempotencyRecord struct {
+	fingerprint string
+	created     time.Time
+	done        chan struct{}
+	response    types.MCPResponse
+	failed      bool
+}
+
+// idempotencyKeySchema is the JSON schema of the idempotency_key tool argument
+func idempotencyKeySchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": fmt.Sprintf("Client-chosen key of this call. Re-submitting the same call with the same key within %s returns the first response, with the same query_id, instead of running the query again; the same key with different arguments is rejected", DefaultIdempotencyWindow),
+	}
+}
+
+// idempotencyFingerprint identifies a tool call by its name and arguments,
+// other than the idempotency key. Arguments are JSON encoded, which orders
+// object keys.
+func idempotencyFingerprint(toolName string, params map[string]interface{}) (string, error) {
+	arguments := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		if name != "idempotency_key" {
+			arguments[name] = value
+		}
+	}
+	encoded, err := json.Marshal(arguments)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(toolName+"\n"), encoded...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// callIdempotently runs call, the handler of a tool call made with an
+// idempotency key, unless the same call was made with that key within the
+// idempotency window: its response is then returned for the new request
+// without running the query or logging it to the audit trail again. A
+// duplicate arriving while the first call runs waits for its response.
+// Failed calls, including panicking ones, are forgotten, so a retry runs the
+// query again.
+func (s *AuditQueryMCPServer) callIdempotently(requestID, toolName, key string, params map[string]interface{}, call func() types.MCPResponse) types.MCPResponse {
+	fingerprint, err := idempotencyFingerprint(toolName, params)
+	if err != nil {
+		return invalidParamsResponse(requestID, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	for {
+		s.idempotencyMutex.Lock()
+		now := time.Now()
+		for stored, record := range s.idempotency {
+			if now.Sub(record.created) > s.idempotencyWindow {
+				delete(s.idempotency, stored)
+			}
+		}
+		record, found := s.idempotency[key]
+		if !found {
+			break
+		}
+		s.idempotencyMutex.Unlock()
+
+		if record.fingerprint != fingerprint {
+			return invalidParamsResponse(requestID, fmt.Sprintf("idempotency key %s was used for a different call within the last %s", key, s.idempotencyWindow))
+		}
+		<-record.done
+		if !record.failed {
+			s.logger.Infof("Replaying the response to idempotency key %s", key)
+			return withIdempotency(record.response, requestID, key, true)
+		}
+	}
+
+	// The mutex is held from the loop, where the key was not found and
+	// expired keys were dropped
+	if len(s.idempotency) >= maxIdempotencyKeys {
+		s.evictOldestIdempotencyKey()
+	}
+	record := &idempotencyRecord{fingerprint: fingerprint, created: time.Now(), done: make(chan struct{})}
+	s.idempotency[key] = record
+	s.idempotencyMutex.Unlock()
+
+	// Waiting duplicates are released even when call panics, which fails it
+	var response types.MCPResponse
+	completed := false
+	defer func() {
+		s.idempotencyMutex.Lock()
+		defer s.idempotencyMutex.Unlock()
+		if !completed || response.Error != nil {
+			record.failed = true
+			if s.idempotency[key] == record {
+				delete(s.idempotency, key)
+			}
+		} else {
+			record.response = response
+		}
+		close(record.done)
+	}()
+
+	response = call()
+	completed = true
+	if response.Error != nil {
+		return response
+	}
+	return withIdempotency(response, requestID, key, false)
+}
+
+// evictOldestIdempotencyKey forgets the key of the oldest call, so the other
+// keys are still replayed; the caller holds idempotencyMutex
+func (s *AuditQueryMCPServer) evictOldestIdempotencyKey() {
+	var oldest string
+	var oldestCreated time.Time
+	for key, record := range s.idempotency {
+		if oldestCreated.IsZero() || record.created.Before(oldestCreated) {
+			oldest, oldestCreated = key, record.created
+		}
+	}
+	delete(s.idempotency, oldest)
+}
+
+// idempotentQueryID derives the query ID of a query run by a call with an
+// idempotency key from the key and the query's canonical parameters, so a
+// retry that runs the query again, after the window or a restart, gets the
+// query ID of the first run
+func idempotentQueryID(params types.AuditQueryParams) string {
+	canonical, _ := json.Marshal(commands.CanonicalizeParams(params))
+	sum := sha256.Sum256(append([]byte(params.IdempotencyKey+"\n"), canonical...))
+	return "audit_query_" + hex.EncodeToString(sum[:12])
+}
+
+// withIdempotency returns a copy of a tool response for requestID, reporting
+// the idempotency key and whether the response is replayed
+func withIdempotency(response types.MCPResponse, requestID, key string, replayed bool) types.MCPResponse {
+	response.ID = requestID
+	result, ok := response.Result.(map[string]interface{})
+	if !ok {
+		return response
+	}
+	copied := make(map[string]interface{}, len(result)+1)
+	for name, value := range result {
+		copied[name] = value
+	}
+	copied["idempotency"] = map[string]interface{}{
+		"key":      key,
+		"replayed": replayed,
+	}
+	response.Result = copied
+	return response
+}
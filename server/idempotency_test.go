@@ -0,0 +1,180 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotentCall builds an execute_complete_audit_query call with an idempotency key
+func idempotentCall(id, key, verb string) types.MCPRequest {
+	return types.MCPRequest{
+		ID:     id,
+		Method: "tools/call",
+		Params: map[string]interface{}{
+			"name": "execute_complete_audit_query",
+			"arguments": map[string]interface{}{
+				"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "verb": verb},
+				"idempotency_key":   key,
+			},
+		},
+	}
+}
+
+// TestHandleToolCall_IdempotencyKey tests replaying the response to a re-submitted call
+func TestHandleToolCall_IdempotencyKey(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(
+		`{"kind":"Event","stage":"ResponseComplete","auditID":"a1","verb":"get","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200}}`+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	first := server.handleToolCall(idempotentCall("1", "retry-me", "get"))
+	require.Nil(t, first.Error)
+	firstResult := first.Result.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"key": "retry-me", "replayed": false}, firstResult["idempotency"])
+	queryID := firstResult["audit_result"].(*types.AuditResult).QueryID
+
+	// A replay returns the first response even when the cache no longer has it
+	server.cache.Clear()
+	replay := server.handleToolCall(idempotentCall("2", "retry-me", "get"))
+	require.Nil(t, replay.Error)
+	assert.Equal(t, "2", replay.ID)
+	replayResult := replay.Result.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"key": "retry-me", "replayed": true}, replayResult["idempotency"])
+	assert.Equal(t, queryID, replayResult["audit_result"].(*types.AuditResult).QueryID)
+	assert.Equal(t, 0, server.cache.Size(), "a replay does not run the query")
+
+	// The same key with different arguments is rejected
+	conflict := server.handleToolCall(idempotentCall("3", "retry-me", "list"))
+	require.NotNil(t, conflict.Error)
+	assert.Equal(t, -32602, conflict.Error.Code)
+	assert.Contains(t, conflict.Error.Message, "different call")
+
+	// Another key runs the query again
+	other := server.handleToolCall(idempotentCall("4", "another", "get"))
+	require.Nil(t, other.Error)
+	assert.NotEqual(t, queryID, other.Result.(map[string]interface{})["audit_result"].(*types.AuditResult).QueryID)
+
+	// Keys are forgotten after the window, and a retry running the query
+	// again gets the query ID derived from the key
+	server.idempotencyWindow = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	server.cache.Clear()
+	rerun := server.handleToolCall(idempotentCall("5", "retry-me", "get"))
+	require.Nil(t, rerun.Error)
+	rerunResult := rerun.Result.(map[string]interface{})
+	assert.Equal(t, false, rerunResult["idempotency"].(map[string]interface{})["replayed"])
+	assert.Equal(t, queryID, rerunResult["audit_result"].(*types.AuditResult).QueryID)
+	assert.Equal(t, 1, server.cache.Size())
+
+	time.Sleep(time.Millisecond)
+	expired := server.handleToolCall(idempotentCall("6", "retry-me", "list"))
+	require.Nil(t, expired.Error)
+	assert.Equal(t, false, expired.Result.(map[string]interface{})["idempotency"].(map[string]interface{})["replayed"])
+}
+
+// TestHandleToolCall_IdempotencyKeyFailure tests that failed calls are not replayed
+func TestHandleToolCall_IdempotencyKeyFailure(t *testing.T) {
+	server := newMockServer(t)
+	request := types.MCPRequest{
+		ID:     "1",
+		Method: "tools/call",
+		Params: map[string]interface{}{
+			"name":      "execute_audit_query_with_result",
+			"arguments": map[string]interface{}{"command": "rm -rf /", "query_id": "q1", "idempotency_key": "k"},
+		},
+	}
+
+	response := server.handleToolCall(request)
+	require.NotNil(t, response.Error)
+	assert.Empty(t, server.idempotency)
+
+	response = server.handleToolCall(request)
+	require.NotNil(t, response.Error)
+}
+
+// TestCallIdempotently_Panic tests that a panicking call releases the
+// duplicates waiting for it and forgets its key
+func TestCallIdempotently_Panic(t *testing.T) {
+	server := newMockServer(t)
+	params := map[string]interface{}{"question": "who deleted pods"}
+	started, release, panicked := make(chan struct{}), make(chan struct{}), make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		server.callIdempotently("1", "ask_audit_question", "k", params, func() types.MCPResponse {
+			close(started)
+			<-release
+			panic("handler failed")
+		})
+	}()
+	<-started
+
+	duplicate := make(chan types.MCPResponse)
+	go func() {
+		duplicate <- server.callIdempotently("2", "ask_audit_question", "k", params, func() types.MCPResponse {
+			return types.MCPResponse{ID: "2", Result: map[string]interface{}{}}
+		})
+	}()
+	close(release)
+	assert.Equal(t, "handler failed", <-panicked)
+
+	select {
+	case response := <-duplicate:
+		require.Nil(t, response.Error)
+		assert.Equal(t, false, response.Result.(map[string]interface{})["idempotency"].(map[string]interface{})["replayed"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("The duplicate call still waits for the panicked one")
+	}
+}
+
+// TestCallIdempotently_EvictsOldestKey tests that a full key store forgets only its oldest key
+func TestCallIdempotently_EvictsOldestKey(t *testing.T) {
+	server := newMockServer(t)
+	created := time.Now().Add(-time.Minute)
+	for i := 0; i < maxIdempotencyKeys; i++ {
+		done := make(chan struct{})
+		close(done)
+		server.idempotency[fmt.Sprintf("key-%d", i)] = &idempotencyRecord{fingerprint: "f", created: created.Add(time.Duration(i) * time.Millisecond), done: done}
+	}
+
+	response := server.callIdempotently("1", "execute_complete_audit_query", "new", map[string]interface{}{}, func() types.MCPResponse {
+		return types.MCPResponse{ID: "1", Result: map[string]interface{}{}}
+	})
+	require.Nil(t, response.Error)
+	assert.Len(t, server.idempotency, maxIdempotencyKeys)
+	assert.NotContains(t, server.idempotency, "key-0")
+	assert.Contains(t, server.idempotency, "key-1")
+	assert.Contains(t, server.idempotency, "new")
+}
+
+// TestIdempotentQueryID tests that query IDs derive from the key and the canonical parameters
+func TestIdempotentQueryID(t *testing.T) {
+	params := types.AuditQueryParams{Verbs: []string{"delete", "create"}, IdempotencyKey: "a"}
+	reordered := types.AuditQueryParams{LogSource: "kube-apiserver", Verbs: []string{"create", "delete"}, IdempotencyKey: "a"}
+	otherKey := types.AuditQueryParams{Verbs: []string{"delete", "create"}, IdempotencyKey: "b"}
+
+	assert.Equal(t, idempotentQueryID(params), idempotentQueryID(reordered))
+	assert.NotEqual(t, idempotentQueryID(params), idempotentQueryID(otherKey))
+	assert.Regexp(t, `^audit_query_[0-9a-f]{24}$`, idempotentQueryID(params))
+}
+
+// TestIdempotencyFingerprint tests that fingerprints ignore the key and argument order
+func TestIdempotencyFingerprint(t *testing.T) {
+	a, err := idempotencyFingerprint("ask_audit_question", map[string]interface{}{"question": "who deleted pods", "narrative": true, "idempotency_key": "a"})
+	require.NoError(t, err)
+	b, err := idempotencyFingerprint("ask_audit_question", map[string]interface{}{"narrative": true, "question": "who deleted pods", "idempotency_key": "b"})
+	require.NoError(t, err)
+	c, err := idempotencyFingerprint("execute_complete_audit_query", map[string]interface{}{"narrative": true, "question": "who deleted pods"})
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
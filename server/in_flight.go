@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// errQueryCancelled is the cause of executions cancelled with CancelQuery
+var errQueryCancelled = errors.New("query cancelled by an operator")
+
+// inFlightQuery is a running query execution and the function cancelling it
+type inFlightQuery struct {
+	queryID string
+	command string
+	cluster string
+	started time.Time
+	cancel  context.CancelCauseFunc
+}
+
+// inFlightQueries tracks the running query executions of a server and its
+// cluster servers, which share it. Executions are numbered because retries
+// and watch rules may run the same query ID concurrently.
+type inFlightQueries struct {
+	mutex   sync.Mutex
+	queries map[int64]*inFlightQuery
+	next    int64
+}
+
+// newInFlightQueries creates an empty registry of running executions
+func newInFlightQueries() *inFlightQueries {
+	return &inFlightQueries{queries: make(map[int64]*inFlightQuery)}
+}
+
+// trackQuery registers the execution of command for queryID and returns the
+// context it runs with, cancelled by CancelQuery, and the function
+// unregistering it once it is done
+func (s *AuditQueryMCPServer) trackQuery(queryID, command string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	registry := s.inFlight
+	registry.mutex.Lock()
+	registry.next++
+	id := registry.next
+	registry.queries[id] = &inFlightQuery{queryID: queryID, command: command, cluster: s.cluster, started: time.Now(), cancel: cancel}
+	registry.mutex.Unlock()
+
+	return ctx, func() {
+		registry.mutex.Lock()
+		delete(registry.queries, id)
+		registry.mutex.Unlock()
+		cancel(nil)
+	}
+}
+
+// InFlightQueries returns the running query executions of the server and its
+// cluster servers, oldest first
+func (s *AuditQueryMCPServer) InFlightQueries() []types.InFlightQuery {
+	s.inFlight.mutex.Lock()
+	defer s.inFlight.mutex.Unlock()
+
+	now := time.Now()
+	queries := make([]types.InFlightQuery, 0, len(s.inFlight.queries))
+	ids := make([]int64, 0, len(s.inFlight.queries))
+	for id := range s.inFlight.queries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		query := s.inFlight.queries[id]
+		queries = append(queries, types.InFlightQuery{
+			QueryID:   query.queryID,
+			Command:   query.command,
+			Cluster:   query.cluster,
+			StartedAt: query.started.Format(time.RFC3339),
+			RunningMs: now.Sub(query.started).Milliseconds(),
+		})
+	}
+	return queries
+}
+
+// CancelQuery cancels the running executions of queryID and returns how many
+// it cancelled. Cancelled executions fail without counting against the
+// circuit breaker.
+func (s *AuditQueryMCPServer) CancelQuery(queryID string) int {
+	s.inFlight.mutex.Lock()
+	defer s.inFlight.mutex.Unlock()
+
+	cancelled := 0
+	for _, query := range s.inFlight.queries {
+		if query.queryID == queryID {
+			query.cancel(errQueryCancelled)
+			cancelled++
+		}
+	}
+	if cancelled > 0 {
+		s.logger.Warnf("Cancelled %d running executions of query %s", cancelled, queryID)
+	}
+	return cancelled
+}
+
+// queryCancelled reports whether ctx, from trackQuery, was cancelled with
+// CancelQuery
+func queryCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errQueryCancelled)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// queryCoverage records the window of events whose raw lines a cached result holds
+type queryCoverage struct {
+	queryID string
+	start   time.Time
+	end     time.Time
+}
+
+// recordCoverage remembers that the cached result holds every event matching
+// params between start and end, replacing older coverage of the same events.
+// Histogram results keep no raw lines and sampled results only some, so
+// neither is recorded.
+func (s *AuditQueryMCPServer) recordCoverage(params types.AuditQueryParams, result *types.AuditResult, start, end time.Time) {
+	if result.Histogram != nil || result.Sampling != nil {
+		return
+	}
+
+	s.coverageMutex.Lock()
+	defer s.coverageMutex.Unlock()
+	s.coverage[commands.CoverageKey(params)] = queryCoverage{queryID: result.QueryID, start: start, end: end}
+}
+
+// reusableCoverage reports whether coverage can serve a request for the window
+// [start, end] at now. The coverage must include the start of the window; the
+// part after the coverage, if any, is fetched with the "today" date filter, so
+// the coverage must reach into today.
+func reusableCoverage(coverage queryCoverage, start, end, now time.Time) bool {
+	if coverage.start.After(start) || coverage.end.Before(start) {
+		return false
+	}
+	if !coverage.end.Before(end) {
+		return true
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return !coverage.end.Before(midnight)
+}
+
+// executeIncrementalQuery answers params from a cached result of the same
+// events for an overlapping window, fetching only the events after it from the
+// cluster and parsing the merged lines. It reports false when no cached result
+// can be reused or the fetch fails, and the caller then runs the full query.
+func (s *AuditQueryMCPServer) executeIncrementalQuery(params types.AuditQueryParams, cacheKey string) (*types.AuditResult, bool) {
+	startTime := time.Now()
+	start, end, ok := commands.TimeframeWindow(params.Timeframe, startTime)
+	if !ok {
+		return nil, false
+	}
+
+	coverageKey := commands.CoverageKey(params)
+	s.coverageMutex.Lock()
+	coverage, exists := s.coverage[coverageKey]
+	s.coverageMutex.Unlock()
+	if !exists || !reusableCoverage(coverage, start, end, startTime) {
+		return nil, false
+	}
+
+	base, found := s.cache.Get(coverage.queryID)
+	if !found {
+		// The cached result expired, was evicted or was invalidated
+		s.coverageMutex.Lock()
+		if s.coverage[coverageKey] == coverage {
+			delete(s.coverage, coverageKey)
+		}
+		s.coverageMutex.Unlock()
+		return nil, false
+	}
+
+	reused := parsing.FilterLinesByTime(parsing.SplitAuditRecords(base.RawOutput), start.Add(-time.Nanosecond), coverage.end)
+	var fetched []string
+	command := base.Command
+	coveredUntil := coverage.end
+	if coverage.end.Before(end) {
+		deltaParams := params
+		deltaParams.Timeframe = "today"
+		lines, deltaCommand, err := s.fetchAuditLines(deltaParams)
+		if err != nil {
+			s.logger.Warnf("Incremental %v, running the full query", err)
+			return nil, false
+		}
+		fetched = parsing.FilterLinesByTime(lines, coverage.end, end)
+		command = deltaCommand
+		coveredUntil = end
+	}
+
+	queryID := s.queryIDFor(params)
+	merged := append(reused, fetched...)
+	result, err := s.ParseAuditResultsWithResult(strings.Join(merged, "\n"), queryContextFor(params), queryID)
+	if err != nil {
+		s.logger.Warnf("Incremental result parsing failed, running the full query: %v", err)
+		return nil, false
+	}
+	result.Command = command
+	result.Backend = base.Backend
+	// The reused lines were read from the base result's log files
+	result.Provenance = s.provenanceFor(command)
+	if base.Provenance != nil {
+		logFiles := append([]string(nil), base.Provenance.LogFiles...)
+		for _, file := range result.Provenance.LogFiles {
+			if !utils.Contains(logFiles, file) {
+				logFiles = append(logFiles, file)
+			}
+		}
+		result.Provenance.LogFiles = logFiles
+	}
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	result.Incremental = &types.IncrementalInfo{
+		BaseQueryID:  base.QueryID,
+		WindowStart:  start.UTC().Format(time.RFC3339),
+		WindowEnd:    end.UTC().Format(time.RFC3339),
+		CachedUntil:  coverage.end.UTC().Format(time.RFC3339),
+		ReusedLines:  len(reused),
+		FetchedLines: len(fetched),
+	}
+	s.logger.Infof("Incremental query reused %d lines from %s and fetched %d", len(reused), base.QueryID, len(fetched))
+
+	s.cache.SetForParams(queryID, cacheKey, params, result)
+	s.resultsChanged(queryID)
+	s.recordCoverage(params, result, start, coveredUntil)
+	if s.auditTrail != nil {
+		s.auditTrail.LogCompleteQuery(queryID, params, result, "", "", "")
+	}
+	return result, true
+}
+
+// fetchAuditLines runs the query of params and returns its audit records,
+// filtered in Go when the command only fetched the raw log, and the command
+func (s *AuditQueryMCPServer) fetchAuditLines(params types.AuditQueryParams) ([]string, string, error) {
+	generateResult, err := s.GenerateAuditQueryWithResult(params)
+	if err != nil {
+		return nil, "", fmt.Errorf("query generation failed: %w", err)
+	}
+	executeResult, err := s.ExecuteAuditQueryWithResult(generateResult.Command, generateResult.QueryID)
+	if err != nil {
+		return nil, "", fmt.Errorf("query execution failed: %w", err)
+	}
+	lines := strings.Split(executeResult.RawOutput, "\n")
+	if provider, _ := s.providerFor(params); s.filtersInProcess(provider) {
+		if lines, err = parsing.FilterAuditLines(lines, params); err != nil {
+			return nil, "", fmt.Errorf("in-process filtering failed: %w", err)
+		}
+	}
+	return parsing.SplitAuditRecords(strings.Join(lines, "\n")), generateResult.Command, nil
+}
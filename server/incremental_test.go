@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReusableCoverage tests which requested windows a cached window can serve
+func TestReusableCoverage(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	midnight := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		coverage queryCoverage
+		start    time.Time
+		end      time.Time
+		expected bool
+	}{
+		{
+			name:     "covers the start and reaches into today",
+			coverage: queryCoverage{start: now.Add(-24 * time.Hour), end: now.Add(-time.Hour)},
+			start:    now.Add(-23 * time.Hour),
+			end:      now,
+			expected: true,
+		},
+		{
+			name:     "covers the whole window",
+			coverage: queryCoverage{start: midnight, end: now},
+			start:    now.Add(-2 * time.Hour),
+			end:      now.Add(-time.Hour),
+			expected: true,
+		},
+		{
+			name:     "starts after the window",
+			coverage: queryCoverage{start: midnight, end: now.Add(-time.Hour)},
+			start:    midnight.Add(-time.Hour),
+			end:      now,
+			expected: false,
+		},
+		{
+			name:     "ends before the window",
+			coverage: queryCoverage{start: midnight, end: midnight.Add(time.Hour)},
+			start:    now.Add(-time.Hour),
+			end:      now,
+			expected: false,
+		},
+		{
+			name:     "missing part starts before today",
+			coverage: queryCoverage{start: midnight.Add(-48 * time.Hour), end: midnight.Add(-time.Hour)},
+			start:    midnight.Add(-24 * time.Hour),
+			end:      now,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, reusableCoverage(tt.coverage, tt.start, tt.end, now))
+		})
+	}
+}
+
+// writeAuditEvents writes audit events received at the given times for the fake oc
+func writeAuditEvents(t *testing.T, path string, times ...time.Time) {
+	t.Helper()
+	var lines []string
+	for i, received := range times {
+		lines = append(lines, fmt.Sprintf(`{"kind":"Event","auditID":"event-%d","verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"secrets","namespace":"default"},"responseStatus":{"code":200},"requestReceivedTimestamp":"%s"}`,
+			i, received.UTC().Format("2006-01-02T15:04:05.000000Z")))
+	}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+}
+
+// TestExecuteCompleteAuditQuery_Incremental tests that an overlapping window
+// reuses the cached events and only adds the newer ones
+func TestExecuteCompleteAuditQuery_Incremental(t *testing.T) {
+	now := time.Now()
+	if now.UTC().Hour() < 1 || now.Hour() < 1 || now.UTC().Day() != now.Day() {
+		t.Skip("needs an hour of today in both UTC and local time")
+	}
+
+	// A fake oc prints the events file; the generated grep stages filter it
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events.json")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oc"), []byte("#!/bin/sh\ncat \"$FAKE_OC_EVENTS\"\n"), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_OC_EVENTS", events)
+
+	writeAuditEvents(t, events, now.Add(-50*time.Minute), now.Add(-20*time.Minute), now.Add(-time.Minute))
+	server := NewAuditQueryMCPServer()
+	params := types.AuditQueryParams{LogSource: "kube-apiserver", Patterns: []string{"secrets"}, Timeframe: "today"}
+	first, err := server.ExecuteCompleteAuditQuery(params)
+	require.NoError(t, err)
+	assert.Nil(t, first.Incremental)
+
+	// One more event arrives after the cached result was fetched
+	time.Sleep(10 * time.Millisecond)
+	writeAuditEvents(t, events, now.Add(-50*time.Minute), now.Add(-20*time.Minute), now.Add(-time.Minute), time.Now())
+	time.Sleep(10 * time.Millisecond)
+
+	params.Timeframe = "30m"
+	second, err := server.ExecuteCompleteAuditQuery(params)
+	require.NoError(t, err)
+	require.NotNil(t, second.Incremental)
+	assert.Equal(t, first.QueryID, second.Incremental.BaseQueryID)
+	assert.Equal(t, 2, second.Incremental.ReusedLines)
+	assert.Equal(t, 1, second.Incremental.FetchedLines)
+	assert.Equal(t, 3, second.TotalEntries)
+
+	// With incremental querying disabled the full window is fetched
+	t.Setenv("AUDIT_INCREMENTAL_QUERIES", "false")
+	server = NewAuditQueryMCPServer()
+	_, err = server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Patterns: []string{"secrets"}, Timeframe: "today"})
+	require.NoError(t, err)
+	third, err := server.ExecuteCompleteAuditQuery(params)
+	require.NoError(t, err)
+	assert.Nil(t, third.Incremental)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/indicators"
+	"audit-query-mcp-server/types"
+)
+
+// defaultIndicatorMatchEvents bounds the matched events returned per indicator
+const defaultIndicatorMatchEvents = 20
+
+// loadIndicators loads the indicators of the comma-separated MISP feed and
+// STIX bundle paths of AUDIT_INDICATORS
+func loadIndicators(value string) (*indicators.Set, error) {
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	loaded, err := indicators.Load(paths, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return indicators.NewSet(loaded)
+}
+
+// indicatorSet returns the loaded indicators, nil when none are loaded
+func (s *AuditQueryMCPServer) indicatorSet() *indicators.Set {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.indicators
+}
+
+// indicatorCount returns the number of loaded indicators
+func (s *AuditQueryMCPServer) indicatorCount() int {
+	set := s.indicatorSet()
+	if set == nil {
+		return 0
+	}
+	return set.Len()
+}
+
+// MatchIndicators runs the query of params, kube-apiserver by default, and
+// returns its events matching the loaded indicators grouped by indicator,
+// the most matched first. Each match keeps the latest maxEvents events.
+func (s *AuditQueryMCPServer) MatchIndicators(params types.AuditQueryParams, maxEvents int) (*types.IndicatorMatchResult, error) {
+	set := s.indicatorSet()
+	if set == nil || set.Len() == 0 {
+		return nil, fmt.Errorf("no indicators are loaded: set AUDIT_INDICATORS to MISP feeds or STIX bundles")
+	}
+	if params.LogSource == "" {
+		params.LogSource = "kube-apiserver"
+	}
+	if maxEvents <= 0 {
+		maxEvents = defaultIndicatorMatchEvents
+	}
+	s.logger.Infof("Matching %d indicators against %s audit events", set.Len(), params.LogSource)
+
+	result, err := s.ExecuteCompleteAuditQuery(params)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[int]*types.IndicatorMatch)
+	matchedEntries := 0
+	for _, entry := range result.ParsedData {
+		indexes := set.Match(entry)
+		if len(indexes) == 0 {
+			continue
+		}
+		matchedEntries++
+		timestamp, _ := entry["timestamp"].(string)
+		for _, index := range indexes {
+			match, ok := matches[index]
+			if !ok {
+				match = &types.IndicatorMatch{Indicator: set.Indicator(index)}
+				matches[index] = match
+			}
+			match.Count++
+			if timestamp != "" && (match.FirstSeen == "" || timestamp < match.FirstSeen) {
+				match.FirstSeen = timestamp
+			}
+			if timestamp > match.LastSeen {
+				match.LastSeen = timestamp
+			}
+			match.Events = append(match.Events, entry)
+		}
+	}
+
+	report := &types.IndicatorMatchResult{
+		QueryID:        result.QueryID,
+		Params:         params,
+		Indicators:     set.Len(),
+		ScannedEntries: len(result.ParsedData),
+		MatchedEntries: matchedEntries,
+		Matches:        make([]types.IndicatorMatch, 0, len(matches)),
+	}
+	for _, match := range matches {
+		sort.SliceStable(match.Events, func(i, j int) bool {
+			first, _ := match.Events[i]["timestamp"].(string)
+			second, _ := match.Events[j]["timestamp"].(string)
+			return first > second
+		})
+		if len(match.Events) > maxEvents {
+			match.Events = match.Events[:maxEvents]
+		}
+		report.Matches = append(report.Matches, *match)
+	}
+	sort.Slice(report.Matches, func(i, j int) bool {
+		if report.Matches[i].Count != report.Matches[j].Count {
+			return report.Matches[i].Count > report.Matches[j].Count
+		}
+		return report.Matches[i].Indicator.Value < report.Matches[j].Indicator.Value
+	})
+
+	report.Summary = fmt.Sprintf("%d of %d audit events matched %d of %d indicators", matchedEntries, report.ScannedEntries, len(report.Matches), report.Indicators)
+	s.logger.Infof("Indicator matching: %s", report.Summary)
+	return report, nil
+}
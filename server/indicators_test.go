@@ -0,0 +1,60 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatchIndicators tests matching loaded indicators against mock audit events
+func TestMatchIndicators(t *testing.T) {
+	dir := t.TempDir()
+	feed := filepath.Join(dir, "event.json")
+	require.NoError(t, os.WriteFile(feed, []byte(`{"Event": {"info": "Exfiltration", "Attribute": [
+		{"uuid": "a1", "type": "ip-src", "value": "198.51.100.0/24"},
+		{"uuid": "a2", "type": "user-agent", "value": "curl/8.4.0"},
+		{"uuid": "a3", "type": "ip-src", "value": "192.0.2.1"}]}}`), 0644))
+	bundle := filepath.Join(dir, "bundle.json")
+	require.NoError(t, os.WriteFile(bundle, []byte(`{"type": "bundle", "objects": [
+		{"type": "indicator", "id": "indicator--1", "name": "Scanner", "pattern": "[ipv4-addr:value = '203.0.113.10']"}]}`), 0644))
+	t.Setenv("AUDIT_INDICATORS", feed+", "+bundle)
+	server := newMockServer(t)
+
+	result, err := server.MatchIndicators(types.AuditQueryParams{Timeframe: "today"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Indicators)
+	assert.Equal(t, "kube-apiserver", result.Params.LogSource)
+	assert.Equal(t, 2, result.MatchedEntries)
+	require.Len(t, result.Matches, 3)
+
+	// Both indicators of the curl request match it; ties are ordered by value
+	assert.Equal(t, "198.51.100.0/24", result.Matches[0].Indicator.Value)
+	assert.Equal(t, "203.0.113.10", result.Matches[1].Indicator.Value)
+	assert.Equal(t, "Scanner", result.Matches[1].Indicator.Description)
+	assert.Equal(t, "curl/8.4.0", result.Matches[2].Indicator.Value)
+	for _, match := range result.Matches {
+		assert.Equal(t, 1, match.Count)
+		require.Len(t, match.Events, 1)
+		assert.Equal(t, match.FirstSeen, match.LastSeen)
+	}
+	assert.Equal(t, "mock-0012", result.Matches[0].Events[0]["audit_id"])
+	assert.Contains(t, result.Summary, "matched 3 of 4 indicators")
+
+	config := server.Configuration()
+	assert.Equal(t, 4, config["indicators"])
+}
+
+// TestMatchIndicators_NotConfigured tests that matching requires loaded indicators
+func TestMatchIndicators_NotConfigured(t *testing.T) {
+	t.Setenv("AUDIT_INDICATORS", filepath.Join(t.TempDir(), "missing.json"))
+	server := newMockServer(t)
+
+	_, err := server.MatchIndicators(types.AuditQueryParams{Timeframe: "today"}, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no indicators are loaded")
+}
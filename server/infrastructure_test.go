@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditInfrastructureChanges tests the infrastructure change log with and without controllers
+func TestAuditInfrastructureChanges(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	event := func(minutesAgo int, username, verb, objectRef string) string {
+		return fmt.Sprintf(`{"kind":"Event","stage":"ResponseComplete","requestReceivedTimestamp":%q,"verb":%q,"user":{"username":%q},"objectRef":%s,"responseStatus":{"code":200}}`,
+			now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), verb, username, objectRef)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event(30, "alice", "create", `{"resource":"machineconfigs","name":"99-worker-ssh","apiGroup":"machineconfiguration.openshift.io"}`),
+		event(20, "kube:admin", "patch", `{"resource":"apiservers","name":"cluster","apiGroup":"config.openshift.io"}`),
+		event(15, "system:serviceaccount:openshift-machine-config-operator:machine-config-daemon", "patch", `{"resource":"nodes","name":"worker-0"}`),
+		event(10, "alice", "patch", `{"resource":"nodes","name":"worker-0","subresource":"status"}`),
+		event(5, "alice", "patch", `{"resource":"deployments","namespace":"web","name":"shop","apiGroup":"apps"}`),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleAuditInfrastructureChanges("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.InfrastructureChangeReport)
+	assert.Equal(t, 2, report.TotalChanges)
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, "99-worker-ssh", report.Changes[0].Name)
+	assert.Equal(t, "config.openshift.io", report.Changes[1].APIGroup)
+	assert.Equal(t, 1, report.ByCategory["apiserver"])
+
+	response = server.handleAuditInfrastructureChanges("test-id", map[string]interface{}{
+		"structured_params":   map[string]interface{}{"timeframe": "2h"},
+		"include_controllers": true,
+	})
+	require.Nil(t, response.Error)
+	report = response.Result.(map[string]interface{})["report"].(*types.InfrastructureChangeReport)
+	assert.Equal(t, 3, report.TotalChanges)
+	assert.Equal(t, 1, report.ByCategory["node"])
+}
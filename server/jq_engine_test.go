@@ -0,0 +1,45 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJQEngine tests selecting the jq engine with AUDIT_JQ_ENGINE
+func TestJQEngine(t *testing.T) {
+	t.Cleanup(func() { commands.SetJQEngine(commands.JQEngineAuto) })
+	t.Setenv("AUDIT_JQ_ENGINE", commands.JQEngineBuiltin)
+	installFakeOc(t, `#!/bin/sh
+echo '{"auditID":"1","verb":"delete","user":{"username":"alice"},"requestReceivedTimestamp":"2024-01-01T00:00:00Z"}'
+echo '{"auditID":"2","verb":"get","user":{"username":"bob"},"requestReceivedTimestamp":"2024-01-01T00:00:00Z"}'
+`)
+	server := NewAuditQueryMCPServer()
+	assert.Equal(t, commands.JQEngineBuiltin, server.Configuration()["active_jq_engine"])
+
+	// jq stages run in process and the result records the engine
+	params := types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete"}
+	generated, err := server.GenerateAuditQueryWithResult(params)
+	require.NoError(t, err)
+	require.Contains(t, generated.Command, "| jq")
+
+	result, err := server.ExecuteAuditQueryWithResult(generated.Command, generated.QueryID)
+	require.NoError(t, err)
+	assert.Equal(t, commands.JQEngineBuiltin, result.JQEngine)
+	assert.Contains(t, result.RawOutput, "alice")
+	assert.NotContains(t, result.RawOutput, "bob")
+
+	// Commands without a jq stage do not report an engine
+	result, err = server.ExecuteAuditQueryWithResult("oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i 'alice'", "test-query-123")
+	require.NoError(t, err)
+	assert.Empty(t, result.JQEngine)
+
+	// Invalid engines fall back to auto
+	t.Setenv("AUDIT_JQ_ENGINE", "gojq")
+	NewAuditQueryMCPServer()
+	assert.Equal(t, commands.JQEngineAuto, commands.JQEngineMode())
+}
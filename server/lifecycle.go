@@ -0,0 +1,102 @@
+package server
+
+import (
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// SupportedProtocolVersions are the MCP protocol versions the server speaks,
+// newest first
+var SupportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// mcpSession is the MCP session negotiated with the client by initialize
+type mcpSession struct {
+	protocolVersion    string
+	clientInfo         map[string]interface{}
+	clientCapabilities map[string]interface{}
+	// initialized is set by the client's notifications/initialized
+	initialized bool
+}
+
+// negotiateProtocolVersion returns the protocol version requested by the
+// client when the server speaks it, and the newest one the server speaks
+// otherwise; the client disconnects if it does not speak that one
+func negotiateProtocolVersion(requested string) string {
+	if utils.Contains(SupportedProtocolVersions, requested) {
+		return requested
+	}
+	return SupportedProtocolVersions[0]
+}
+
+// handleInitialize handles the initialize method: it negotiates the protocol
+// version, records the client's info and capabilities for the session and
+// announces the server's capabilities
+func (s *AuditQueryMCPServer) handleInitialize(request types.MCPRequest) types.MCPResponse {
+	requested, ok := request.Params["protocolVersion"].(string)
+	if _, present := request.Params["protocolVersion"]; present && !ok {
+		return invalidParamsResponse(request.ID, "protocolVersion must be a string")
+	}
+	clientInfo, _ := request.Params["clientInfo"].(map[string]interface{})
+	clientCapabilities, _ := request.Params["capabilities"].(map[string]interface{})
+
+	protocolVersion := negotiateProtocolVersion(requested)
+	if requested != "" && requested != protocolVersion {
+		s.logger.Warnf("Client requested MCP protocol version %s, offering %s", requested, protocolVersion)
+	}
+	s.sessionMutex.Lock()
+	s.session = mcpSession{
+		protocolVersion:    protocolVersion,
+		clientInfo:         clientInfo,
+		clientCapabilities: clientCapabilities,
+	}
+	s.sessionMutex.Unlock()
+	if name, _ := clientInfo["name"].(string); name != "" {
+		s.logger.Infof("Initializing MCP session with %s %v (protocol %s)", name, clientInfo["version"], protocolVersion)
+	}
+
+	return types.MCPResponse{
+		ID: request.ID,
+		Result: map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools":     map[string]interface{}{"listChanged": false},
+				"resources": map[string]interface{}{"subscribe": true, "listChanged": true},
+				"prompts":   map[string]interface{}{"listChanged": false},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    "audit-query-mcp-server",
+				"version": ServerVersion,
+			},
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleInitialized handles notifications/initialized, with which the client
+// confirms the session
+func (s *AuditQueryMCPServer) handleInitialized() {
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	s.session.initialized = true
+}
+
+// SessionInfo describes the MCP session negotiated with the client: its
+// protocol version, the client's info and capabilities, and whether the
+// client confirmed it. It is empty before initialize. Cluster servers report
+// their parent's session.
+func (s *AuditQueryMCPServer) SessionInfo() map[string]interface{} {
+	if s.parent != nil {
+		return s.parent.SessionInfo()
+	}
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	if s.session.protocolVersion == "" {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"protocol_version":    s.session.protocolVersion,
+		"client_info":         s.session.clientInfo,
+		"client_capabilities": s.session.clientCapabilities,
+		"initialized":         s.session.initialized,
+	}
+}
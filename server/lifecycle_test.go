@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleMCPRequest_Lifecycle tests the initialize handshake, ping and notifications
+func TestHandleMCPRequest_Lifecycle(t *testing.T) {
+	server := newMockServer(t)
+	assert.Empty(t, server.SessionInfo())
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "initialize", JSONRPC: "2.0", Params: map[string]interface{}{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    map[string]interface{}{"roots": map[string]interface{}{"listChanged": true}},
+		"clientInfo":      map[string]interface{}{"name": "inspector", "version": "0.9.0"},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, "2025-03-26", result["protocolVersion"])
+	capabilities := result["capabilities"].(map[string]interface{})
+	assert.Contains(t, capabilities, "tools")
+	assert.Contains(t, capabilities, "prompts")
+
+	session := server.SessionInfo()
+	assert.Equal(t, "2025-03-26", session["protocol_version"])
+	assert.Equal(t, "inspector", session["client_info"].(map[string]interface{})["name"])
+	assert.Contains(t, session["client_capabilities"], "roots")
+	assert.Equal(t, false, session["initialized"])
+
+	notification := types.MCPRequest{Method: "notifications/initialized", JSONRPC: "2.0"}
+	assert.True(t, notification.IsNotification())
+	assert.Equal(t, types.MCPResponse{}, server.HandleMCPRequest(notification))
+	assert.Equal(t, true, server.SessionInfo()["initialized"])
+
+	// Unknown notifications are ignored, unknown requests are not
+	assert.Equal(t, types.MCPResponse{}, server.HandleMCPRequest(types.MCPRequest{Method: "notifications/cancelled", JSONRPC: "2.0"}))
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "logging/setLevel", JSONRPC: "2.0"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32601, response.Error.Code)
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "3", Method: "ping", JSONRPC: "2.0"})
+	assert.Nil(t, response.Error)
+	assert.Equal(t, "3", response.ID)
+	assert.Equal(t, map[string]interface{}{}, response.Result)
+}
+
+// TestHandleInitialize_VersionNegotiation tests answering unsupported protocol versions with the newest supported one
+func TestHandleInitialize_VersionNegotiation(t *testing.T) {
+	server := newMockServer(t)
+
+	for requested, expected := range map[string]string{"2024-11-05": "2024-11-05", "2099-01-01": SupportedProtocolVersions[0], "": SupportedProtocolVersions[0]} {
+		response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "initialize", JSONRPC: "2.0", Params: map[string]interface{}{"protocolVersion": requested}})
+		require.Nil(t, response.Error)
+		assert.Equal(t, expected, response.Result.(map[string]interface{})["protocolVersion"], "requested %q", requested)
+	}
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "initialize", JSONRPC: "2.0", Params: map[string]interface{}{"protocolVersion": 20241105.0}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.ErrorTypeInvalidParams.Code(), response.Error.Code)
+}
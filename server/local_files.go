@@ -0,0 +1,197 @@
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/validation"
+)
+
+// Local audit file analysis defaults
+const (
+	// DefaultLocalFileDir is where analyze_local_audit_file reads files from
+	DefaultLocalFileDir = "./audit-logs"
+	// maxLocalAuditBytes bounds the uncompressed audit logs read for one analysis
+	maxLocalAuditBytes = 1 << 30
+	// localBackend is the backend reported for results read from local files
+	localBackend = "local"
+)
+
+// AnalyzeLocalAuditFile runs the filter, parse and summary pipeline on an audit
+// log file or directory below the local file directory. Relative paths are
+// resolved against that directory.
+func (s *AuditQueryMCPServer) AnalyzeLocalAuditFile(path string, params types.AuditQueryParams, progress ProgressFunc) (*types.AuditResult, error) {
+	resolved, err := resolveLocalPath(s.localFileDir, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.AnalyzeAuditFiles(resolved, params, progress)
+}
+
+// AnalyzeAuditFiles runs the filter, parse and summary pipeline on an audit log
+// file or a directory of audit logs, such as the audit_logs directory of a
+// must-gather bundle. Rotated and gzip-compressed logs are read; when the
+// directory has a subdirectory named after the log source, only it is read.
+// Each stage is reported to progress, which may be nil.
+func (s *AuditQueryMCPServer) AnalyzeAuditFiles(path string, params types.AuditQueryParams, progress ProgressFunc) (*types.AuditResult, error) {
+	if params.LogSource == "" {
+		params.LogSource = "kube-apiserver"
+	}
+	s.logger.Infof("Analyzing local audit logs in %s", path)
+
+	startTime := time.Now()
+	result := &types.AuditResult{
+		QueryID:   s.generateQueryID(),
+		Timestamp: startTime.Format(time.RFC3339),
+		Backend:   localBackend,
+	}
+
+	if err := validation.ValidateQueryParams(params); err != nil {
+		result.Error = fmt.Sprintf("validation failed: %v", err)
+		return result, fmt.Errorf("validation failed: %w", err)
+	}
+
+	progress.report(0, "Listing audit log files")
+	files, err := localAuditFiles(path, params.LogSource)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Command = fmt.Sprintf("read %s (%d files)", path, len(files))
+
+	var lines []string
+	var total int64
+	for i, file := range files {
+		progress.report(5+float64(45*i/len(files)), fmt.Sprintf("Reading file %d of %d: %s", i+1, len(files), filepath.Base(file)))
+		data, err := readLocalAuditFile(file, maxLocalAuditBytes-total)
+		if err != nil {
+			result.Error = err.Error()
+			return result, err
+		}
+		total += int64(len(data))
+		lines = append(lines, strings.Split(data, "\n")...)
+	}
+
+	progress.report(50, fmt.Sprintf("Filtering %d lines", len(lines)))
+	lines, err = parsing.FilterAuditLines(lines, params)
+	if err != nil {
+		result.Error = fmt.Sprintf("in-process filtering failed: %v", err)
+		return result, fmt.Errorf("in-process filtering failed: %w", err)
+	}
+
+	parsed, err := s.parseAuditResults(strings.Join(lines, "\n"), queryContextFor(params), result.QueryID, progress)
+	if err != nil {
+		return parsed, err
+	}
+	parsed.Command = result.Command
+	parsed.Backend = localBackend
+	parsed.Provenance = localProvenance(result.Command, files)
+	parsed.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.logger.Infof("Local analysis of %d files kept %d events", len(files), len(lines))
+
+	// Cache the result so reports and forwarding can use its query ID
+	s.cache.Set(parsed.QueryID, parsed)
+	s.resultsChanged(parsed.QueryID)
+	if s.auditTrail != nil {
+		s.auditTrail.LogCompleteQuery(parsed.QueryID, params, parsed, "", "", "")
+	}
+	progress.report(100, fmt.Sprintf("Completed with %d entries", parsed.TotalEntries))
+	return parsed, nil
+}
+
+// resolveLocalPath resolves path against dir and rejects paths outside it,
+// following symbolic links
+func resolveLocalPath(dir, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path required")
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid local file directory: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = realRoot
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the local audit file directory %s", path, dir)
+	}
+	return resolved, nil
+}
+
+// localAuditFiles returns the audit log files at path, sorted by name
+func localAuditFiles(path, logSource string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	if sub, err := os.Stat(filepath.Join(path, logSource)); err == nil && sub.IsDir() {
+		path = filepath.Join(path, logSource)
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() && strings.Contains(entry.Name(), ".log") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no audit log files found under %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readLocalAuditFile returns the contents of an audit log file, decompressing
+// .gz files, failing when it is larger than limit bytes
+func readLocalAuditFile(path string, limit int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return "", fmt.Errorf("cannot decompress %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if int64(len(data)) > limit {
+		return "", fmt.Errorf("audit logs exceed %d bytes; analyze fewer files or a single log source", maxLocalAuditBytes)
+	}
+	return string(data), nil
+}
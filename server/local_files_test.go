@@ -0,0 +1,116 @@
+package server
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeMustGatherAuditLogs lays out audit logs the way must-gather does, with a
+// rotated gzip file next to the current log of one node; the kube-apiserver
+// logs hold three distinct events
+func writeMustGatherAuditLogs(t *testing.T, dir string) {
+	t.Helper()
+	now := time.Now()
+	apiserver := filepath.Join(dir, "audit_logs", "kube-apiserver")
+	require.NoError(t, os.MkdirAll(apiserver, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "audit_logs", "oauth-server"), 0755))
+
+	writeAuditEvents(t, filepath.Join(apiserver, "master-0-audit.log"), now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour))
+	writeAuditEvents(t, filepath.Join(dir, "audit_logs", "oauth-server", "master-0-audit.log"), now)
+
+	// The compressed rotated log repeats the two older events, which are deduplicated
+	rotated := filepath.Join(t.TempDir(), "rotated.log")
+	writeAuditEvents(t, rotated, now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	data, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	file, err := os.Create(filepath.Join(apiserver, "master-0-audit-2026-01-01T00-00-00.000.log.gz"))
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+}
+
+// TestAnalyzeAuditFiles tests analyzing a must-gather directory without cluster access
+func TestAnalyzeAuditFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeMustGatherAuditLogs(t, dir)
+	server := NewAuditQueryMCPServer()
+
+	// Only the log source's subdirectory is read, including the rotated file
+	result, err := server.AnalyzeAuditFiles(filepath.Join(dir, "audit_logs"), types.AuditQueryParams{Username: "alice", Verb: "delete"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalEntries)
+	assert.Equal(t, "local", result.Backend)
+	assert.Contains(t, result.Command, "(2 files)")
+	require.NotNil(t, result.Provenance)
+	assert.Len(t, result.Provenance.LogFiles, 2)
+	assert.Empty(t, result.Provenance.Identity)
+
+	// The result is cached for reports and forwarding
+	cached, ok := server.cache.Get(result.QueryID)
+	assert.True(t, ok)
+	assert.NotNil(t, cached)
+
+	// Filters apply to the local events
+	result, err = server.AnalyzeAuditFiles(filepath.Join(dir, "audit_logs"), types.AuditQueryParams{Username: "bob"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.TotalEntries)
+
+	result, err = server.AnalyzeAuditFiles(filepath.Join(dir, "audit_logs"), types.AuditQueryParams{LogSource: "oauth-server"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TotalEntries)
+
+	_, err = server.AnalyzeAuditFiles(t.TempDir(), types.AuditQueryParams{}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no audit log files found")
+
+	_, err = server.AnalyzeAuditFiles(dir, types.AuditQueryParams{Verb: "destroy"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation failed")
+}
+
+// TestHandleAnalyzeLocalAuditFile tests the analyze_local_audit_file tool
+func TestHandleAnalyzeLocalAuditFile(t *testing.T) {
+	dir := t.TempDir()
+	writeMustGatherAuditLogs(t, dir)
+	server := NewAuditQueryMCPServer()
+	server.localFileDir = dir
+
+	response := server.handleAnalyzeLocalAuditFile("local-1", map[string]interface{}{
+		"path":              "audit_logs",
+		"structured_params": map[string]interface{}{"verb": "delete"},
+	}, nil)
+	require.Nil(t, response.Error)
+	result, ok := response.Result.(*types.AuditResult)
+	require.True(t, ok)
+	assert.Equal(t, 3, result.TotalEntries)
+
+	response = server.handleAnalyzeLocalAuditFile("local-2", map[string]interface{}{}, nil)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	// Paths outside the local file directory are rejected
+	outside := t.TempDir()
+	writeAuditEvents(t, filepath.Join(outside, "audit.log"), time.Now())
+	for _, path := range []string{filepath.Join(outside, "audit.log"), "../" + filepath.Base(outside)} {
+		response = server.handleAnalyzeLocalAuditFile("local-3", map[string]interface{}{"path": path}, nil)
+		require.NotNil(t, response.Error)
+		assert.Equal(t, -32602, response.Error.Code)
+		assert.Contains(t, response.Error.Message, "outside the local audit file directory")
+	}
+
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	response = server.handleAnalyzeLocalAuditFile("local-4", map[string]interface{}{"path": "link"}, nil)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "outside the local audit file directory")
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomLogSources tests querying a log source from AUDIT_LOG_SOURCES_CONFIG
+func TestCustomLogSources(t *testing.T) {
+	validLogSources := utils.ValidLogSources
+	t.Cleanup(func() { utils.ValidLogSources = validLogSources })
+
+	configPath := filepath.Join(t.TempDir(), "log-sources.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"log_sources": [
+		{"name": "metrics-apiserver", "path": "metrics-apiserver/audit.log", "description": "Aggregated metrics API server"}
+	]}`), 0644))
+	t.Setenv("AUDIT_LOG_SOURCES_CONFIG", configPath)
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+*--path=metrics-apiserver/) printf 'master-0 audit.log\n' ;;
+*--path=metrics-apiserver/audit.log) echo '{"auditID":"1","verb":"get","user":{"username":"alice"},"requestReceivedTimestamp":"2024-01-01T00:00:00Z"}' ;;
+*) echo "error: unexpected call" >&2; exit 1 ;;
+esac
+`)
+	server := NewAuditQueryMCPServer()
+
+	// Tool schemas enumerate the custom log source
+	for _, tool := range server.GetTools() {
+		if tool.Name != "generate_audit_query" && tool.Name != "check_log_sources" {
+			continue
+		}
+		properties := tool.InputSchema["properties"].(map[string]interface{})
+		if structured, ok := properties["structured_params"]; ok {
+			properties = structured.(map[string]interface{})["properties"].(map[string]interface{})
+		}
+		assert.Contains(t, properties["log_source"].(map[string]interface{})["enum"], "metrics-apiserver", tool.Name)
+	}
+
+	// Queries read the custom path and pass command validation
+	params := types.AuditQueryParams{LogSource: "metrics-apiserver", Username: "alice"}
+	generated, err := server.GenerateAuditQueryWithResult(params)
+	require.NoError(t, err)
+	assert.Contains(t, generated.Command, "--path=metrics-apiserver/audit.log")
+
+	result, err := server.ExecuteAuditQueryWithResult(generated.Command, generated.QueryID)
+	require.NoError(t, err)
+	assert.Contains(t, result.RawOutput, "alice")
+
+	availability := server.CheckLogSourceAvailability("metrics-apiserver", false)
+	assert.True(t, availability.Available)
+	assert.Equal(t, map[string][]string{"master-0": {"audit.log"}}, availability.Nodes)
+
+	config := server.Configuration()
+	assert.Contains(t, config["log_sources"], "metrics-apiserver")
+	assert.Equal(t, "Aggregated metrics API server", config["custom_log_sources"].([]types.LogSourceDefinition)[0].Description)
+
+	// Unknown log sources are still rejected
+	_, err = server.GenerateAuditQueryWithResult(types.AuditQueryParams{LogSource: "other-apiserver"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnalyzeLoginFailures tests reading failed logins from the OAuth server's log
+func TestAnalyzeLoginFailures(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	var logins []string
+	for i := 0; i < 3; i++ {
+		logins = append(logins, fmt.Sprintf(`{"kind":"Event","stage":"ResponseComplete","auditID":"login-%d","requestReceivedTimestamp":%q,"verb":"post","requestURI":"/login/htpasswd","user":{"username":"system:anonymous"},"sourceIPs":["203.0.113.50"],"responseStatus":{"code":401},"annotations":{"authentication.openshift.io/decision":"deny","authentication.openshift.io/username":"mallory"}}`,
+			i, now.Add(-time.Duration(30-i)*time.Minute).UTC().Format(time.RFC3339Nano)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oauth-server.log"), []byte(strings.Join(logins, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleAnalyzeLoginFailures("test-id", map[string]interface{}{"burst_window": "soon"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleAnalyzeLoginFailures("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+		"burst_threshold":   float64(3),
+		"burst_window":      "10m",
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.LoginFailureReport)
+	assert.Equal(t, 3, report.TotalFailures)
+	require.Len(t, report.Users, 1)
+	assert.Equal(t, "mallory", report.Users[0].Username)
+	assert.True(t, report.Users[0].Burst)
+	assert.Len(t, report.Bursts, 2)
+	assert.Equal(t, "10m0s", report.BurstWindow)
+}
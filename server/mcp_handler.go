@@ -1,19 +1,51 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/reporting"
 	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
 )
 
-// HandleMCPRequest handles incoming MCP requests
-func (s *AuditQueryMCPServer) HandleMCPRequest(request types.MCPRequest) types.MCPResponse {
-	s.logger.Infof("Handling MCP request: %s", request.Method)
-
+// handleMCPRequest dispatches an MCP request to the handler of its method
+func (s *AuditQueryMCPServer) handleMCPRequest(request types.MCPRequest) types.MCPResponse {
 	switch request.Method {
+	case "initialize":
+		return s.handleInitialize(request)
+	case "notifications/initialized":
+		s.handleInitialized()
+		return types.MCPResponse{}
+	case "ping":
+		return types.MCPResponse{ID: request.ID, Result: map[string]interface{}{}, JSONRPC: "2.0"}
 	case "tools/list":
 		return s.handleListTools(request)
 	case "tools/call":
+		if response := s.validateToolCall(request); response != nil {
+			return *response
+		}
 		return s.handleToolCall(request)
+	case "resources/list":
+		return s.handleListResources(request)
+	case "resources/templates/list":
+		return s.handleListResourceTemplates(request)
+	case "resources/read":
+		return s.handleReadResource(request)
+	case "resources/subscribe", "resources/unsubscribe":
+		return s.handleResourceSubscription(request)
+	case "prompts/list":
+		return s.handleListPrompts(request)
+	case "prompts/get":
+		return s.handleGetPrompt(request)
 	default:
+		// Other notifications, such as notifications/cancelled, need no answer
+		if request.IsNotification() {
+			return types.MCPResponse{}
+		}
 		return types.MCPResponse{
 			ID: request.ID,
 			Error: &types.MCPError{
@@ -25,42 +57,208 @@ func (s *AuditQueryMCPServer) HandleMCPRequest(request types.MCPRequest) types.M
 	}
 }
 
-// handleListTools handles the tools/list method
+// handleListTools handles the tools/list method. The tools can be filtered on
+// their category and on whether they require the cluster, and are returned
+// in pages of ToolsPageSize with a nextCursor fetching the following page.
 func (s *AuditQueryMCPServer) handleListTools(request types.MCPRequest) types.MCPResponse {
-	tools := s.GetTools()
+	category, _ := request.Params["category"].(string)
+	cursor, _ := request.Params["cursor"].(string)
+	var requiresCluster *bool
+	if value, ok := request.Params["requires_cluster"].(bool); ok {
+		requiresCluster = &value
+	}
+
+	tools, nextCursor, err := s.listTools(category, requiresCluster, cursor, ToolsPageSize)
+	if err != nil {
+		return invalidParamsResponse(request.ID, err.Error())
+	}
+	result := map[string]interface{}{"tools": tools}
+	if nextCursor != "" {
+		result["nextCursor"] = nextCursor
+	}
 	return types.MCPResponse{
 		ID:      request.ID,
-		Result:  map[string]interface{}{"tools": tools},
+		Result:  result,
 		JSONRPC: "2.0",
 	}
 }
 
-// handleToolCall handles the tools/call method
-func (s *AuditQueryMCPServer) handleToolCall(request types.MCPRequest) types.MCPResponse {
-	params, ok := request.Params["arguments"].(map[string]interface{})
-	if !ok {
+// handleListResources handles the resources/list method
+func (s *AuditQueryMCPServer) handleListResources(request types.MCPRequest) types.MCPResponse {
+	return types.MCPResponse{
+		ID:      request.ID,
+		Result:  map[string]interface{}{"resources": s.GetResources()},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleListResourceTemplates handles the resources/templates/list method
+func (s *AuditQueryMCPServer) handleListResourceTemplates(request types.MCPRequest) types.MCPResponse {
+	return types.MCPResponse{
+		ID:      request.ID,
+		Result:  map[string]interface{}{"resourceTemplates": s.GetResourceTemplates()},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleReadResource handles the resources/read method
+func (s *AuditQueryMCPServer) handleReadResource(request types.MCPRequest) types.MCPResponse {
+	uri, ok := request.Params["uri"].(string)
+	if !ok || uri == "" {
+		return invalidParamsResponse(request.ID, "uri required")
+	}
+
+	contents, err := s.ReadResource(uri)
+	if err != nil {
+		return types.MCPResponse{
+			ID: request.ID,
+			Error: &types.MCPError{
+				Code:    -32002,
+				Message: err.Error(),
+			},
+			JSONRPC: "2.0",
+		}
+	}
+
+	return types.MCPResponse{
+		ID:      request.ID,
+		Result:  map[string]interface{}{"contents": []types.MCPResourceContents{*contents}},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleResourceSubscription handles the resources/subscribe and resources/unsubscribe methods
+func (s *AuditQueryMCPServer) handleResourceSubscription(request types.MCPRequest) types.MCPResponse {
+	uri, ok := request.Params["uri"].(string)
+	if !ok || uri == "" {
+		return invalidParamsResponse(request.ID, "uri required")
+	}
+
+	if request.Method == "resources/unsubscribe" {
+		s.UnsubscribeResource(uri)
+	} else if err := s.SubscribeResource(uri); err != nil {
 		return types.MCPResponse{
 			ID: request.ID,
 			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "Invalid params",
+				Code:    -32002,
+				Message: err.Error(),
 			},
 			JSONRPC: "2.0",
 		}
 	}
 
+	return types.MCPResponse{
+		ID:      request.ID,
+		Result:  map[string]interface{}{},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleListPrompts handles the prompts/list method
+func (s *AuditQueryMCPServer) handleListPrompts(request types.MCPRequest) types.MCPResponse {
+	return types.MCPResponse{
+		ID:      request.ID,
+		Result:  map[string]interface{}{"prompts": s.GetPrompts()},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGetPrompt handles the prompts/get method
+func (s *AuditQueryMCPServer) handleGetPrompt(request types.MCPRequest) types.MCPResponse {
+	name, ok := request.Params["name"].(string)
+	if !ok || name == "" {
+		return invalidParamsResponse(request.ID, "name required")
+	}
+
+	args := make(map[string]string)
+	if arguments, ok := request.Params["arguments"].(map[string]interface{}); ok {
+		for key, value := range arguments {
+			if text, ok := value.(string); ok {
+				args[key] = text
+			}
+		}
+	}
+
+	description, messages, err := s.GetPrompt(name, args)
+	if err != nil {
+		return invalidParamsResponse(request.ID, err.Error())
+	}
+
+	return types.MCPResponse{
+		ID:      request.ID,
+		Result:  map[string]interface{}{"description": description, "messages": messages},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleToolCall handles the tools/call method
+func (s *AuditQueryMCPServer) handleToolCall(request types.MCPRequest) types.MCPResponse {
+	params, ok := request.Params["arguments"].(map[string]interface{})
+	if !ok {
+		return invalidParamsResponse(request.ID, "Invalid params")
+	}
+
 	toolName, ok := request.Params["name"].(string)
 	if !ok {
+		return invalidParamsResponse(request.ID, "Tool name required")
+	}
+
+	// Deprecated aliases run their replacement, whose response carries a
+	// deprecation warning
+	if replacement, alias := s.resolveToolAlias(toolName); alias != nil {
+		s.logger.Warnf("Deprecated tool %s called; use %s", toolName, replacement)
+		renamed := make(map[string]interface{}, len(request.Params))
+		for key, value := range request.Params {
+			renamed[key] = value
+		}
+		renamed["name"] = replacement
+		request.Params = renamed
+		return withDeprecation(s.handleToolCall(request), toolName, *alias)
+	}
+	if alias, ok := toolAliases[toolName]; ok {
 		return types.MCPResponse{
 			ID: request.ID,
 			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "Tool name required",
+				Code:    -32601,
+				Message: fmt.Sprintf("Tool not found: %s is hidden by AUDIT_HIDE_LEGACY_TOOLS; call %s instead", toolName, alias.replacedBy),
 			},
 			JSONRPC: "2.0",
 		}
 	}
 
+	// Calls naming a registered cluster are handled by that cluster's server
+	if cluster, ok := params["cluster"].(string); ok {
+		target, err := s.serverForCluster(cluster)
+		if err != nil {
+			return invalidParamsResponse(request.ID, err.Error())
+		}
+		if target != s {
+			return target.handleToolCall(request)
+		}
+	}
+
+	// Resource names the dictionary does not know are looked up in the
+	// cluster's API discovery before the parameters are read
+	s.discoverResourcesFor(params)
+
+	// Entry fields are checked before the query runs
+	if queryTools[toolName] {
+		if _, err := fieldsParam(params); err != nil {
+			return invalidParamsResponse(request.ID, err.Error())
+		}
+	}
+
+	// Re-submitted queries with the same idempotency key replay the response
+	if key, _ := params["idempotency_key"].(string); key != "" && idempotentTools[toolName] {
+		return s.shapeToolResponse(toolName, params, s.callIdempotently(request.ID, toolName, key, params, func() types.MCPResponse {
+			return s.callTool(request, toolName, params)
+		}))
+	}
+	return s.shapeToolResponse(toolName, params, s.callTool(request, toolName, params))
+}
+
+// callTool dispatches a tool call to the handler of toolName
+func (s *AuditQueryMCPServer) callTool(request types.MCPRequest, toolName string, params map[string]interface{}) types.MCPResponse {
 	switch toolName {
 	case "generate_audit_query_with_result":
 		return s.handleGenerateAuditQueryWithResult(request.ID, params)
@@ -69,17 +267,95 @@ func (s *AuditQueryMCPServer) handleToolCall(request types.MCPRequest) types.MCP
 	case "parse_audit_results_with_result":
 		return s.handleParseAuditResultsWithResult(request.ID, params)
 	case "execute_complete_audit_query":
-		return s.handleExecuteCompleteAuditQuery(request.ID, params)
+		return s.handleExecuteCompleteAuditQuery(request.ID, params, s.progressNotifier(request))
+	case "explain_audit_query":
+		return s.handleExplainAuditQuery(request.ID, params)
+	case "estimate_query":
+		return s.handleEstimateQuery(request.ID, params)
+	case "ask_audit_question":
+		return s.handleAskAuditQuestion(request.ID, params)
+	case "find_permission_denials":
+		return s.handleFindPermissionDenials(request.ID, params)
+	case "compare_audit_activity":
+		return s.handleCompareAuditActivity(request.ID, params)
+	case "build_user_timeline":
+		return s.handleBuildUserTimeline(request.ID, params)
+	case "generate_audit_report":
+		return s.handleGenerateAuditReport(request.ID, params)
+	case "forward_audit_results":
+		return s.handleForwardAuditResults(request.ID, params)
+	case "get_audit_trail":
+		return s.handleGetAuditTrail(request.ID, params)
+	case "verify_audit_trail":
+		return s.handleVerifyAuditTrail(request.ID, params)
+	case "verify_result":
+		return s.handleVerifyResult(request.ID, params)
 	case "get_cache_stats":
 		return s.handleGetCacheStats(request.ID, params)
 	case "clear_cache":
 		return s.handleClearCache(request.ID, params)
+	case "invalidate_cache":
+		return s.handleInvalidateCache(request.ID, params)
 	case "get_cached_result":
 		return s.handleGetCachedResult(request.ID, params)
 	case "delete_cached_result":
 		return s.handleDeleteCachedResult(request.ID, params)
 	case "get_server_stats":
 		return s.handleGetServerStats(request.ID, params)
+	case "reset_circuit_breaker":
+		return s.handleResetCircuitBreaker(request.ID, params)
+	case "check_log_sources":
+		return s.handleCheckLogSources(request.ID, params)
+	case "get_audit_configuration":
+		return s.handleGetAuditConfiguration(request.ID, params)
+	case "analyze_local_audit_file":
+		return s.handleAnalyzeLocalAuditFile(request.ID, params, s.progressNotifier(request))
+	case "execute_audit_query_batch":
+		return s.handleExecuteAuditQueryBatch(request.ID, params)
+	case "match_indicators":
+		return s.handleMatchIndicators(request.ID, params)
+	case "analyze_error_rates":
+		return s.handleAnalyzeErrorRates(request.ID, params)
+	case "detect_churn":
+		return s.handleDetectChurn(request.ID, params)
+	case "detect_escalation_chains":
+		return s.handleDetectEscalationChains(request.ID, params)
+	case "audit_secret_access":
+		return s.handleAuditSecretAccess(request.ID, params)
+	case "audit_pod_exec":
+		return s.handleAuditPodExec(request.ID, params)
+	case "audit_infrastructure_changes":
+		return s.handleAuditInfrastructureChanges(request.ID, params)
+	case "audit_csr_activity":
+		return s.handleAuditCSRActivity(request.ID, params)
+	case "audit_webhook_changes":
+		return s.handleAuditWebhookChanges(request.ID, params)
+	case "rbac_change_report":
+		return s.handleRBACChangeReport(request.ID, params)
+	case "generate_compliance_report":
+		return s.handleGenerateComplianceReport(request.ID, params)
+	case "analyze_login_failures":
+		return s.handleAnalyzeLoginFailures(request.ID, params)
+	case "audit_token_creation":
+		return s.handleAuditTokenCreation(request.ID, params)
+	case "find_top_talkers":
+		return s.handleFindTopTalkers(request.ID, params)
+	case "check_permissions":
+		return s.handleCheckPermissions(request.ID, params)
+	case "run_preflight":
+		return s.handleRunPreflight(request.ID, params)
+	case "configure_parser":
+		return s.handleConfigureParser(request.ID, params)
+	case "get_parse_errors":
+		return s.handleGetParseErrors(request.ID, params)
+	case "query_all_clusters":
+		return s.handleQueryAllClusters(request.ID, params)
+	case "annotate_audit_result":
+		return s.handleAnnotateAuditResult(request.ID, params)
+	case "list_findings":
+		return s.handleListFindings(request.ID, params)
+	case "delete_finding":
+		return s.handleDeleteFinding(request.ID, params)
 	default:
 		return types.MCPResponse{
 			ID: request.ID,
@@ -96,61 +372,14 @@ func (s *AuditQueryMCPServer) handleToolCall(request types.MCPRequest) types.MCP
 func (s *AuditQueryMCPServer) handleGenerateAuditQueryWithResult(requestID string, params map[string]interface{}) types.MCPResponse {
 	structuredParams, ok := params["structured_params"].(map[string]interface{})
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "structured_params required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "structured_params required")
 	}
 
-	// Convert to AuditQueryParams
-	auditParams := types.AuditQueryParams{}
-	if logSource, ok := structuredParams["log_source"].(string); ok {
-		auditParams.LogSource = logSource
-	}
-	if patterns, ok := structuredParams["patterns"].([]interface{}); ok {
-		for _, p := range patterns {
-			if pattern, ok := p.(string); ok {
-				auditParams.Patterns = append(auditParams.Patterns, pattern)
-			}
-		}
-	}
-	if timeframe, ok := structuredParams["timeframe"].(string); ok {
-		auditParams.Timeframe = timeframe
-	}
-	if exclude, ok := structuredParams["exclude"].([]interface{}); ok {
-		for _, e := range exclude {
-			if ex, ok := e.(string); ok {
-				auditParams.Exclude = append(auditParams.Exclude, ex)
-			}
-		}
-	}
-	if username, ok := structuredParams["username"].(string); ok {
-		auditParams.Username = username
-	}
-	if resource, ok := structuredParams["resource"].(string); ok {
-		auditParams.Resource = resource
-	}
-	if verb, ok := structuredParams["verb"].(string); ok {
-		auditParams.Verb = verb
-	}
-	if namespace, ok := structuredParams["namespace"].(string); ok {
-		auditParams.Namespace = namespace
-	}
+	auditParams := parseStructuredParams(structuredParams)
 
 	result, err := s.GenerateAuditQueryWithResult(auditParams)
 	if err != nil {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32000,
-				Message: err.Error(),
-			},
-			JSONRPC: "2.0",
-		}
+		return errorResponse(requestID, err)
 	}
 
 	return types.MCPResponse{
@@ -166,38 +395,17 @@ func (s *AuditQueryMCPServer) handleGenerateAuditQueryWithResult(requestID strin
 func (s *AuditQueryMCPServer) handleExecuteAuditQueryWithResult(requestID string, params map[string]interface{}) types.MCPResponse {
 	command, ok := params["command"].(string)
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "command required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "command required")
 	}
 
 	queryID, ok := params["query_id"].(string)
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "query_id required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "query_id required")
 	}
 
 	result, err := s.ExecuteAuditQueryWithResult(command, queryID)
 	if err != nil {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32000,
-				Message: err.Error(),
-			},
-			JSONRPC: "2.0",
-		}
+		return errorResponse(requestID, err)
 	}
 
 	return types.MCPResponse{
@@ -213,50 +421,22 @@ func (s *AuditQueryMCPServer) handleExecuteAuditQueryWithResult(requestID string
 func (s *AuditQueryMCPServer) handleParseAuditResultsWithResult(requestID string, params map[string]interface{}) types.MCPResponse {
 	rawOutput, ok := params["raw_output"].(string)
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "raw_output required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "raw_output required")
 	}
 
 	queryContext, ok := params["query_context"].(map[string]interface{})
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "query_context required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "query_context required")
 	}
 
 	queryID, ok := params["query_id"].(string)
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "query_id required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "query_id required")
 	}
 
 	result, err := s.ParseAuditResultsWithResult(rawOutput, queryContext, queryID)
 	if err != nil {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32000,
-				Message: err.Error(),
-			},
-			JSONRPC: "2.0",
-		}
+		return errorResponse(requestID, err)
 	}
 
 	return types.MCPResponse{
@@ -268,92 +448,404 @@ func (s *AuditQueryMCPServer) handleParseAuditResultsWithResult(requestID string
 	}
 }
 
-// handleExecuteCompleteAuditQuery handles the complete audit query pipeline
-func (s *AuditQueryMCPServer) handleExecuteCompleteAuditQuery(requestID string, params map[string]interface{}) types.MCPResponse {
+// handleExecuteCompleteAuditQuery handles the complete audit query pipeline,
+// reporting its stages to progress
+func (s *AuditQueryMCPServer) handleExecuteCompleteAuditQuery(requestID string, params map[string]interface{}, progress ProgressFunc) types.MCPResponse {
 	structuredParams, ok := params["structured_params"].(map[string]interface{})
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "structured_params required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "structured_params required")
 	}
 
-	// Convert to AuditQueryParams
-	auditParams := types.AuditQueryParams{}
-	if logSource, ok := structuredParams["log_source"].(string); ok {
-		auditParams.LogSource = logSource
+	auditParams := parseStructuredParams(structuredParams)
+	auditParams.IdempotencyKey, _ = params["idempotency_key"].(string)
+
+	result, err := s.ExecuteCompleteAuditQueryWithProgress(auditParams, progress)
+	if err != nil {
+		return errorResponse(requestID, err)
 	}
-	if patterns, ok := structuredParams["patterns"].([]interface{}); ok {
-		for _, p := range patterns {
-			if pattern, ok := p.(string); ok {
-				auditParams.Patterns = append(auditParams.Patterns, pattern)
-			}
-		}
+
+	response := map[string]interface{}{
+		"audit_result": result,
 	}
-	if timeframe, ok := structuredParams["timeframe"].(string); ok {
-		auditParams.Timeframe = timeframe
+	result = s.addEnrichment(response, params, result)
+	result = s.addSourceClassification(response, params, result)
+	s.addNarrative(response, params, result)
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  response,
+		JSONRPC: "2.0",
 	}
-	if exclude, ok := structuredParams["exclude"].([]interface{}); ok {
-		for _, e := range exclude {
-			if ex, ok := e.(string); ok {
-				auditParams.Exclude = append(auditParams.Exclude, ex)
-			}
-		}
+}
+
+// handleAskAuditQuestion handles the ask_audit_question tool
+func (s *AuditQueryMCPServer) handleAskAuditQuestion(requestID string, params map[string]interface{}) types.MCPResponse {
+	question, ok := params["question"].(string)
+	if !ok {
+		return invalidParamsResponse(requestID, "question required")
 	}
-	if username, ok := structuredParams["username"].(string); ok {
-		auditParams.Username = username
+
+	idempotencyKey, _ := params["idempotency_key"].(string)
+	interp, result, err := s.askAuditQuestion(question, idempotencyKey)
+	if err != nil {
+		message := err.Error()
+		if interp != nil && len(interp.Matched) > 0 {
+			message = fmt.Sprintf("%s (question interpreted as: %s)", message, strings.Join(interp.Matched, ", "))
+		}
+		response := errorResponse(requestID, err)
+		response.Error.Message = message
+		return response
 	}
-	if resource, ok := structuredParams["resource"].(string); ok {
-		auditParams.Resource = resource
+
+	response := map[string]interface{}{
+		"interpretation": interp,
+		"audit_result":   result,
 	}
-	if verb, ok := structuredParams["verb"].(string); ok {
-		auditParams.Verb = verb
+	s.addNarrative(response, params, result)
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  response,
+		JSONRPC: "2.0",
 	}
-	if namespace, ok := structuredParams["namespace"].(string); ok {
-		auditParams.Namespace = namespace
+}
+
+// handleFindPermissionDenials handles the find_permission_denials tool
+func (s *AuditQueryMCPServer) handleFindPermissionDenials(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
 	}
 
-	result, err := s.ExecuteCompleteAuditQuery(auditParams)
+	report, result, err := s.FindPermissionDenials(auditParams)
 	if err != nil {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32000,
-				Message: err.Error(),
-			},
-			JSONRPC: "2.0",
-		}
+		return errorResponse(requestID, err)
 	}
 
 	return types.MCPResponse{
 		ID: requestID,
 		Result: map[string]interface{}{
+			"report":       report,
 			"audit_result": result,
 		},
 		JSONRPC: "2.0",
 	}
 }
 
-// handleGetCacheStats handles the get_cache_stats tool
-func (s *AuditQueryMCPServer) handleGetCacheStats(requestID string, params map[string]interface{}) types.MCPResponse {
-	stats := s.GetCacheStats()
+// handleCompareAuditActivity handles the compare_audit_activity tool
+func (s *AuditQueryMCPServer) handleCompareAuditActivity(requestID string, params map[string]interface{}) types.MCPResponse {
+	compareBy, _ := params["compare_by"].(string)
+	baseline, _ := params["baseline"].(string)
+	comparison, _ := params["comparison"].(string)
+	if compareBy == "" || baseline == "" || comparison == "" {
+		return invalidParamsResponse(requestID, "compare_by, baseline and comparison required")
+	}
+
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	result, err := s.CompareAuditActivity(auditParams, compareBy, baseline, comparison)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
 
 	return types.MCPResponse{
-		ID: requestID,
-		Result: map[string]interface{}{
-			"cache_stats": stats,
-		},
+		ID:      requestID,
+		Result:  result,
 		JSONRPC: "2.0",
 	}
 }
 
-// handleClearCache handles the clear_cache tool
-func (s *AuditQueryMCPServer) handleClearCache(requestID string, params map[string]interface{}) types.MCPResponse {
-	s.ClearCache()
+// handleBuildUserTimeline handles the build_user_timeline tool
+func (s *AuditQueryMCPServer) handleBuildUserTimeline(requestID string, params map[string]interface{}) types.MCPResponse {
+	username, ok := params["username"].(string)
+	if !ok || username == "" {
+		return invalidParamsResponse(requestID, "username required")
+	}
+
+	var durations [2]time.Duration
+	for i, name := range []string{"burst_window", "gap_threshold"} {
+		value, ok := params[name].(string)
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid %s: %s", name, value))
+		}
+		durations[i] = duration
+	}
+
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	timeline, result, err := s.BuildUserTimeline(auditParams, username, durations[0], durations[1])
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"timeline":     timeline,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGenerateAuditReport handles the generate_audit_report tool
+func (s *AuditQueryMCPServer) handleGenerateAuditReport(requestID string, params map[string]interface{}) types.MCPResponse {
+	result, mcpErr := s.resolveAuditResult(params)
+	if mcpErr != nil {
+		return types.MCPResponse{
+			ID:      requestID,
+			Error:   mcpErr,
+			JSONRPC: "2.0",
+		}
+	}
+
+	format, _ := params["format"].(string)
+	title, _ := params["title"].(string)
+	outputFile, _ := params["output_file"].(string)
+
+	content, path, err := s.GenerateAuditReport(result, format, title, outputFile)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	response := map[string]interface{}{
+		"query_id": result.QueryID,
+		"format":   format,
+		"report":   content,
+	}
+	if format == "" {
+		response["format"] = reporting.FormatMarkdown
+	}
+	if path != "" {
+		response["path"] = path
+	}
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  response,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleForwardAuditResults handles the forward_audit_results tool
+func (s *AuditQueryMCPServer) handleForwardAuditResults(requestID string, params map[string]interface{}) types.MCPResponse {
+	destination, ok := params["destination"].(string)
+	if !ok || destination == "" {
+		return invalidParamsResponse(requestID, "destination parameter required")
+	}
+
+	result, mcpErr := s.resolveAuditResult(params)
+	if mcpErr != nil {
+		return types.MCPResponse{
+			ID:      requestID,
+			Error:   mcpErr,
+			JSONRPC: "2.0",
+		}
+	}
+
+	forwardResult, err := s.ForwardAuditResults(destination, result)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"query_id": result.QueryID,
+			"forward":  forwardResult,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGetAuditTrail handles the get_audit_trail tool
+func (s *AuditQueryMCPServer) handleGetAuditTrail(requestID string, params map[string]interface{}) types.MCPResponse {
+	query := utils.AuditTrailQuery{
+		Limit: intParam(params["limit"]),
+	}
+	query.UserID, _ = params["user_id"].(string)
+	query.QueryID, _ = params["query_id"].(string)
+	query.Action, _ = params["action"].(string)
+
+	now := time.Now()
+	for key, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		value, ok := params[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		parsed, err := parseTrailTime(value, now)
+		if err != nil {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid %s: %v", key, err))
+		}
+		*target = parsed
+	}
+
+	includeResults, _ := params["include_results"].(bool)
+	result, err := s.QueryAuditTrail(query, includeResults)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  result,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleVerifyAuditTrail handles the verify_audit_trail tool
+func (s *AuditQueryMCPServer) handleVerifyAuditTrail(requestID string, params map[string]interface{}) types.MCPResponse {
+	result, err := s.VerifyAuditTrail()
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  result,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleVerifyResult handles the verify_result tool
+func (s *AuditQueryMCPServer) handleVerifyResult(requestID string, params map[string]interface{}) types.MCPResponse {
+	var verification *types.ResultVerification
+	if queryID, ok := params["query_id"].(string); ok {
+		var err error
+		if verification, err = s.VerifyResult(queryID); err != nil {
+			return errorResponse(requestID, err)
+		}
+	} else if stored, ok := params["audit_result"].(map[string]interface{}); ok {
+		encoded, err := json.Marshal(stored)
+		if err != nil {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid audit_result: %v", err))
+		}
+		var result types.AuditResult
+		if err := json.Unmarshal(encoded, &result); err != nil {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid audit_result: %v", err))
+		}
+		verification = s.VerifyStoredResult(&result)
+	} else {
+		return invalidParamsResponse(requestID, "query_id or audit_result required")
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"verification": verification,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// parseTrailTime parses an RFC3339 time, or a duration meaning that long before now
+func parseTrailTime(value string, now time.Time) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 time or a duration, got %q", value)
+	}
+	return now.Add(-duration), nil
+}
+
+// resolveAuditResult returns the cached result named by query_id, or runs the
+// complete pipeline for structured_params
+func (s *AuditQueryMCPServer) resolveAuditResult(params map[string]interface{}) (*types.AuditResult, *types.MCPError) {
+	if queryID, ok := params["query_id"].(string); ok {
+		cached, found := s.GetCachedResult(queryID)
+		if !found {
+			return nil, toolError(fmt.Errorf("no cached result for query ID %s", queryID))
+		}
+		return cached, nil
+	}
+
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		result, err := s.ExecuteCompleteAuditQuery(parseStructuredParams(structuredParams))
+		if err != nil {
+			return nil, toolError(err)
+		}
+		return result, nil
+	}
+
+	return nil, invalidParamsError("query_id or structured_params required")
+}
+
+// handleExplainAuditQuery handles the explain_audit_query tool
+func (s *AuditQueryMCPServer) handleExplainAuditQuery(requestID string, params map[string]interface{}) types.MCPResponse {
+	var explanation *types.QueryExplanation
+	var err error
+
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		explanation, err = s.ExplainAuditQuery(parseStructuredParams(structuredParams))
+	} else if command, ok := params["command"].(string); ok {
+		explanation, err = s.ExplainAuditCommand(command)
+	} else {
+		return invalidParamsResponse(requestID, "structured_params or command required")
+	}
+
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"explanation": explanation,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleEstimateQuery handles the estimate_query tool
+func (s *AuditQueryMCPServer) handleEstimateQuery(requestID string, params map[string]interface{}) types.MCPResponse {
+	structuredParams, ok := params["structured_params"].(map[string]interface{})
+	if !ok {
+		return invalidParamsResponse(requestID, "structured_params required")
+	}
+	maxEntries := intParam(params["max_entries"])
+	if maxEntries < 0 {
+		return invalidParamsResponse(requestID, fmt.Sprintf("invalid max_entries: %d", maxEntries))
+	}
+
+	estimate, err := s.EstimateQuery(parseStructuredParams(structuredParams), maxEntries)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"estimate": estimate,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGetCacheStats handles the get_cache_stats tool
+func (s *AuditQueryMCPServer) handleGetCacheStats(requestID string, params map[string]interface{}) types.MCPResponse {
+	stats := s.GetCacheStats()
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"cache_stats": stats,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleClearCache handles the clear_cache tool
+func (s *AuditQueryMCPServer) handleClearCache(requestID string, params map[string]interface{}) types.MCPResponse {
+	s.ClearCache()
 
 	return types.MCPResponse{
 		ID: requestID,
@@ -364,18 +856,36 @@ func (s *AuditQueryMCPServer) handleClearCache(requestID string, params map[stri
 	}
 }
 
+// handleInvalidateCache handles the invalidate_cache tool
+func (s *AuditQueryMCPServer) handleInvalidateCache(requestID string, params map[string]interface{}) types.MCPResponse {
+	filter := utils.CacheFilter{}
+	filter.LogSource, _ = params["log_source"].(string)
+	filter.Username, _ = params["username"].(string)
+	filter.Namespace, _ = params["namespace"].(string)
+	filter.Resource, _ = params["resource"].(string)
+	filter.Verb, _ = params["verb"].(string)
+	filter.Pattern, _ = params["pattern"].(string)
+	if filter.IsEmpty() {
+		return invalidParamsResponse(requestID, "at least one filter required (use clear_cache to remove every entry)")
+	}
+
+	removed := s.InvalidateCache(filter)
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"invalidated": removed,
+			"message":     fmt.Sprintf("Invalidated %d cached results", removed),
+		},
+		JSONRPC: "2.0",
+	}
+}
+
 // handleGetCachedResult handles the get_cached_result tool
 func (s *AuditQueryMCPServer) handleGetCachedResult(requestID string, params map[string]interface{}) types.MCPResponse {
 	queryID, ok := params["query_id"].(string)
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "query_id required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "query_id required")
 	}
 
 	result, found := s.GetCachedResult(queryID)
@@ -403,14 +913,7 @@ func (s *AuditQueryMCPServer) handleGetCachedResult(requestID string, params map
 func (s *AuditQueryMCPServer) handleDeleteCachedResult(requestID string, params map[string]interface{}) types.MCPResponse {
 	queryID, ok := params["query_id"].(string)
 	if !ok {
-		return types.MCPResponse{
-			ID: requestID,
-			Error: &types.MCPError{
-				Code:    -32602,
-				Message: "query_id required",
-			},
-			JSONRPC: "2.0",
-		}
+		return invalidParamsResponse(requestID, "query_id required")
 	}
 
 	s.DeleteCachedResult(queryID)
@@ -436,3 +939,809 @@ func (s *AuditQueryMCPServer) handleGetServerStats(requestID string, params map[
 		JSONRPC: "2.0",
 	}
 }
+
+// handleResetCircuitBreaker handles the reset_circuit_breaker tool
+func (s *AuditQueryMCPServer) handleResetCircuitBreaker(requestID string, params map[string]interface{}) types.MCPResponse {
+	previous := s.ResetCircuitBreaker()
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"message":         "Circuit breaker reset",
+			"previous_state":  previous,
+			"circuit_breaker": s.GetCircuitBreakerStatus(),
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleCheckLogSources handles the check_log_sources tool
+func (s *AuditQueryMCPServer) handleCheckLogSources(requestID string, params map[string]interface{}) types.MCPResponse {
+	refresh, _ := params["refresh"].(bool)
+
+	var results []types.LogSourceAvailability
+	if logSource, _ := params["log_source"].(string); logSource != "" {
+		if !utils.Contains(utils.ValidLogSources, logSource) {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid log_source: %s", logSource))
+		}
+		results = []types.LogSourceAvailability{s.CheckLogSourceAvailability(logSource, refresh)}
+	} else {
+		results = s.CheckAllLogSources(refresh)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"log_sources": results,
+			"available":   availableLogSources(results),
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGetAuditConfiguration handles the get_audit_configuration tool
+func (s *AuditQueryMCPServer) handleGetAuditConfiguration(requestID string, params map[string]interface{}) types.MCPResponse {
+	config, err := s.GetAuditConfiguration()
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  config,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleCheckPermissions handles the check_permissions tool
+func (s *AuditQueryMCPServer) handleCheckPermissions(requestID string, params map[string]interface{}) types.MCPResponse {
+	report, err := s.CheckPermissions()
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  report,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleRunPreflight handles the run_preflight tool
+func (s *AuditQueryMCPServer) handleRunPreflight(requestID string, params map[string]interface{}) types.MCPResponse {
+	report := s.RunPreflight()
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  &report,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleConfigureParser handles the configure_parser tool; without arguments
+// it returns the parser configuration unchanged
+func (s *AuditQueryMCPServer) handleConfigureParser(requestID string, params map[string]interface{}) types.MCPResponse {
+	var update ParserConfigUpdate
+	if value, ok := params["max_parse_errors"]; ok {
+		maxParseErrors := intParam(value)
+		update.MaxParseErrors = &maxParseErrors
+	}
+	if value, ok := params["max_line_length"]; ok {
+		maxLineLength := intParam(value)
+		update.MaxLineLength = &maxLineLength
+	}
+	if value, ok := params["fallback"].(bool); ok {
+		update.Fallback = &value
+	}
+	if value, ok := params["strict"].(bool); ok {
+		update.Strict = &value
+	}
+	if value, ok := params["quarantine_lines"]; ok {
+		quarantineLines := intParam(value)
+		update.QuarantineLines = &quarantineLines
+	}
+	update.Reset, _ = params["reset"].(bool)
+
+	previous, current, err := s.ConfigureParser(update)
+	if err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+	message := "Parser configuration unchanged"
+	if current != previous {
+		message = "Parser configuration updated"
+	}
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"message":       message,
+			"previous":      previous,
+			"parser_config": current,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGetParseErrors handles the get_parse_errors tool
+func (s *AuditQueryMCPServer) handleGetParseErrors(requestID string, params map[string]interface{}) types.MCPResponse {
+	queryID, _ := params["query_id"].(string)
+	clearQuarantine, _ := params["clear"].(bool)
+	report := s.GetParseErrors(queryID, intParam(params["limit"]), clearQuarantine)
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  &report,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAnalyzeLocalAuditFile handles the analyze_local_audit_file tool,
+// reporting its stages to progress
+func (s *AuditQueryMCPServer) handleAnalyzeLocalAuditFile(requestID string, params map[string]interface{}, progress ProgressFunc) types.MCPResponse {
+	path, ok := params["path"].(string)
+	if !ok || path == "" {
+		return invalidParamsResponse(requestID, "path required")
+	}
+
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	result, err := s.AnalyzeLocalAuditFile(path, auditParams, progress)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  result,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleExecuteAuditQueryBatch handles the execute_audit_query_batch tool
+func (s *AuditQueryMCPServer) handleExecuteAuditQueryBatch(requestID string, params map[string]interface{}) types.MCPResponse {
+	queries, ok := params["queries"].([]interface{})
+	if !ok {
+		return invalidParamsResponse(requestID, "queries required")
+	}
+
+	batch := make([]types.AuditQueryParams, len(queries))
+	for i, query := range queries {
+		structuredParams, ok := query.(map[string]interface{})
+		if !ok {
+			return invalidParamsResponse(requestID, fmt.Sprintf("query %d is not an object", i+1))
+		}
+		batch[i] = parseStructuredParams(structuredParams)
+		batch[i].IdempotencyKey, _ = params["idempotency_key"].(string)
+	}
+
+	result, err := s.ExecuteAuditQueryBatch(batch)
+	if err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  result,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleQueryAllClusters handles the query_all_clusters tool
+func (s *AuditQueryMCPServer) handleQueryAllClusters(requestID string, params map[string]interface{}) types.MCPResponse {
+	structuredParams, ok := params["structured_params"].(map[string]interface{})
+	if !ok {
+		return invalidParamsResponse(requestID, "structured_params required")
+	}
+
+	result, err := s.QueryAllClusters(parseStructuredParams(structuredParams), stringList(params["clusters"]))
+	if err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  result,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAnnotateAuditResult handles the annotate_audit_result tool
+func (s *AuditQueryMCPServer) handleAnnotateAuditResult(requestID string, params map[string]interface{}) types.MCPResponse {
+	queryID, ok := params["query_id"].(string)
+	if !ok {
+		return invalidParamsResponse(requestID, "query_id required")
+	}
+	var entryIndex *int
+	if value, ok := params["entry_index"]; ok && value != nil {
+		index := intParam(value)
+		entryIndex = &index
+	}
+	note, _ := params["note"].(string)
+
+	finding, err := s.AnnotateResult(queryID, entryIndex, note)
+	if err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"finding": finding,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleListFindings handles the list_findings tool
+func (s *AuditQueryMCPServer) handleListFindings(requestID string, params map[string]interface{}) types.MCPResponse {
+	queryID, _ := params["query_id"].(string)
+	findings, err := s.ListFindings(queryID)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"findings": findings,
+			"count":    len(findings),
+			"report":   reporting.RenderFindings(findings),
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleMatchIndicators handles the match_indicators tool
+func (s *AuditQueryMCPServer) handleMatchIndicators(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	result, err := s.MatchIndicators(auditParams, intParam(params["max_events"]))
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  result,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAnalyzeErrorRates handles the analyze_error_rates tool
+func (s *AuditQueryMCPServer) handleAnalyzeErrorRates(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+	spikeThreshold, _ := params["spike_threshold"].(float64)
+
+	report, err := s.AnalyzeErrorRates(auditParams, spikeThreshold, intParam(params["min_requests"]))
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  report,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleDetectChurn handles the detect_churn tool
+func (s *AuditQueryMCPServer) handleDetectChurn(requestID string, params map[string]interface{}) types.MCPResponse {
+	var window time.Duration
+	if value, ok := params["window"].(string); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid window: %s", value))
+		}
+		window = duration
+	}
+
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.DetectChurn(auditParams, window, intParam(params["min_changes"]))
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleDetectEscalationChains handles the detect_escalation_chains tool
+func (s *AuditQueryMCPServer) handleDetectEscalationChains(requestID string, params map[string]interface{}) types.MCPResponse {
+	var window time.Duration
+	if value, ok := params["window"].(string); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid window: %s", value))
+		}
+		window = duration
+	}
+	timeframe, _ := params["timeframe"].(string)
+	username, _ := params["username"].(string)
+
+	report, err := s.DetectEscalationChains(timeframe, username, intParam(params["min_failures"]), window)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  report,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAuditSecretAccess handles the audit_secret_access tool
+func (s *AuditQueryMCPServer) handleAuditSecretAccess(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+	baselineTimeframe, _ := params["baseline_timeframe"].(string)
+
+	report, result, err := s.AuditSecretAccess(auditParams, baselineTimeframe, stringList(params["allowlist"]))
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAuditPodExec handles the audit_pod_exec tool
+func (s *AuditQueryMCPServer) handleAuditPodExec(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+	if subresources := stringList(params["subresources"]); len(subresources) > 0 {
+		for _, subresource := range subresources {
+			if !utils.Contains(parsing.PodExecSubresources, subresource) {
+				return invalidParamsResponse(requestID, fmt.Sprintf("invalid subresource: %s", subresource))
+			}
+		}
+		auditParams.Subresources = subresources
+	}
+
+	report, result, err := s.AuditPodExec(auditParams)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAuditInfrastructureChanges handles the audit_infrastructure_changes tool
+func (s *AuditQueryMCPServer) handleAuditInfrastructureChanges(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+	includeControllers, _ := params["include_controllers"].(bool)
+
+	report, result, err := s.AuditInfrastructureChanges(auditParams, includeControllers)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAuditCSRActivity handles the audit_csr_activity tool
+func (s *AuditQueryMCPServer) handleAuditCSRActivity(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.AuditCSRActivity(auditParams, stringList(params["expected_approvers"]))
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAuditWebhookChanges handles the audit_webhook_changes tool
+func (s *AuditQueryMCPServer) handleAuditWebhookChanges(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.AuditWebhookChanges(auditParams)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleRBACChangeReport handles the rbac_change_report tool
+func (s *AuditQueryMCPServer) handleRBACChangeReport(requestID string, params map[string]interface{}) types.MCPResponse {
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.BuildRBACChangeReport(auditParams)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleGenerateComplianceReport handles the generate_compliance_report tool
+func (s *AuditQueryMCPServer) handleGenerateComplianceReport(requestID string, params map[string]interface{}) types.MCPResponse {
+	month, _ := params["month"].(string)
+	monthStart, err := complianceMonth(month, time.Now())
+	if err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+	format, _ := params["format"].(string)
+	if format == "" {
+		format = reporting.FormatMarkdown
+	}
+
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.BuildComplianceReport(auditParams, monthStart, stringList(params["sensitive_namespaces"]))
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+	documents, err := reporting.RenderCompliance(*report, format)
+	if err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"report":       report,
+		"format":       format,
+		"documents":    documents,
+		"audit_result": result,
+	}
+	if writeFiles, _ := params["write_files"].(bool); writeFiles {
+		paths, err := s.WriteComplianceDocuments(documents)
+		if err != nil {
+			return errorResponse(requestID, err)
+		}
+		response["paths"] = paths
+	}
+	return types.MCPResponse{
+		ID:      requestID,
+		Result:  response,
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAnalyzeLoginFailures handles the analyze_login_failures tool
+func (s *AuditQueryMCPServer) handleAnalyzeLoginFailures(requestID string, params map[string]interface{}) types.MCPResponse {
+	var window time.Duration
+	if value, ok := params["burst_window"].(string); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid burst_window: %s", value))
+		}
+		window = duration
+	}
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.AnalyzeLoginFailures(auditParams, intParam(params["burst_threshold"]), window)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleAuditTokenCreation handles the audit_token_creation tool
+func (s *AuditQueryMCPServer) handleAuditTokenCreation(requestID string, params map[string]interface{}) types.MCPResponse {
+	var maxLifetime time.Duration
+	if value, ok := params["max_lifetime"].(string); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return invalidParamsResponse(requestID, fmt.Sprintf("invalid max_lifetime: %s", value))
+		}
+		maxLifetime = duration
+	}
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.AuditTokenCreation(auditParams, maxLifetime)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleFindTopTalkers handles the find_top_talkers tool
+func (s *AuditQueryMCPServer) handleFindTopTalkers(requestID string, params map[string]interface{}) types.MCPResponse {
+	limit := intParam(params["limit"])
+	if limit < 0 {
+		return invalidParamsResponse(requestID, fmt.Sprintf("invalid limit: %d", limit))
+	}
+	minShare, _ := params["min_share"].(float64)
+	if minShare < 0 || minShare > 1 {
+		return invalidParamsResponse(requestID, fmt.Sprintf("invalid min_share: %g", minShare))
+	}
+	var auditParams types.AuditQueryParams
+	if structuredParams, ok := params["structured_params"].(map[string]interface{}); ok {
+		auditParams = parseStructuredParams(structuredParams)
+	}
+
+	report, result, err := s.FindTopTalkers(auditParams, limit, minShare)
+	if err != nil {
+		return errorResponse(requestID, err)
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"report":       report,
+			"audit_result": result,
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// handleDeleteFinding handles the delete_finding tool
+func (s *AuditQueryMCPServer) handleDeleteFinding(requestID string, params map[string]interface{}) types.MCPResponse {
+	id, ok := params["finding_id"].(string)
+	if !ok {
+		return invalidParamsResponse(requestID, "finding_id required")
+	}
+	if err := s.DeleteFinding(id); err != nil {
+		return invalidParamsResponse(requestID, err.Error())
+	}
+
+	return types.MCPResponse{
+		ID: requestID,
+		Result: map[string]interface{}{
+			"message": "Finding deleted successfully",
+		},
+		JSONRPC: "2.0",
+	}
+}
+
+// stringList converts a JSON array argument to a string slice, skipping non-string items
+func stringList(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// intParam returns a numeric argument as an int; JSON numbers arrive as float64
+func intParam(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// parseStructuredParams converts the structured_params argument to AuditQueryParams
+func parseStructuredParams(structuredParams map[string]interface{}) types.AuditQueryParams {
+	auditParams := types.AuditQueryParams{}
+	if logSource, ok := structuredParams["log_source"].(string); ok {
+		auditParams.LogSource = logSource
+	}
+	if patterns, ok := structuredParams["patterns"].([]interface{}); ok {
+		for _, p := range patterns {
+			if pattern, ok := p.(string); ok {
+				auditParams.Patterns = append(auditParams.Patterns, pattern)
+			}
+		}
+	}
+	if timeframe, ok := structuredParams["timeframe"].(string); ok {
+		auditParams.Timeframe = timeframe
+	}
+	if exclude, ok := structuredParams["exclude"].([]interface{}); ok {
+		for _, e := range exclude {
+			if ex, ok := e.(string); ok {
+				auditParams.Exclude = append(auditParams.Exclude, ex)
+			}
+		}
+	}
+	if username, ok := structuredParams["username"].(string); ok {
+		auditParams.Username = username
+	}
+	if resource, ok := structuredParams["resource"].(string); ok {
+		auditParams.Resource = resource
+	}
+	if verb, ok := structuredParams["verb"].(string); ok {
+		auditParams.Verb = verb
+	}
+	if namespace, ok := structuredParams["namespace"].(string); ok {
+		auditParams.Namespace = namespace
+	}
+	switch statusCode := structuredParams["status_code"].(type) {
+	case float64:
+		auditParams.StatusCode = int(statusCode)
+	case int:
+		auditParams.StatusCode = statusCode
+	}
+	if statusCodeRange, ok := structuredParams["status_code_range"].(string); ok {
+		auditParams.StatusCodeRange = statusCodeRange
+	}
+	auditParams.Usernames = stringList(structuredParams["username"])
+	auditParams.Resources = stringList(structuredParams["resource"])
+	auditParams.Verbs = stringList(structuredParams["verb"])
+	auditParams.Namespaces = stringList(structuredParams["namespace"])
+	auditParams.Subresources = stringList(structuredParams["subresource"])
+	auditParams.ExcludeUsers = stringList(structuredParams["exclude_users"])
+	auditParams.ExcludeNamespaces = stringList(structuredParams["exclude_namespaces"])
+	auditParams.ExcludeVerbs = stringList(structuredParams["exclude_verbs"])
+	auditParams.ExcludeResources = stringList(structuredParams["exclude_resources"])
+	auditParams.Groups = stringList(structuredParams["groups"])
+	if impersonatedUser, ok := structuredParams["impersonated_user"].(string); ok {
+		auditParams.ImpersonatedUser = impersonatedUser
+	}
+	if userAgent, ok := structuredParams["user_agent"].(string); ok {
+		auditParams.UserAgent = userAgent
+	}
+	if mode, ok := structuredParams["user_agent_match"].(string); ok {
+		auditParams.UserAgentMatch = types.MatchMode(mode)
+	}
+	if stage, ok := structuredParams["stage"].(string); ok {
+		auditParams.Stage = stage
+	}
+	if level, ok := structuredParams["level"].(string); ok {
+		auditParams.Level = level
+	}
+	if sourceIP, ok := structuredParams["source_ip"].(string); ok {
+		auditParams.SourceIP = sourceIP
+	}
+	if sourceCIDR, ok := structuredParams["source_cidr"].(string); ok {
+		auditParams.SourceCIDR = sourceCIDR
+	}
+	if sortBy, ok := structuredParams["sort_by"].(string); ok {
+		auditParams.SortBy = sortBy
+	}
+	if sortOrder, ok := structuredParams["sort_order"].(string); ok {
+		auditParams.SortOrder = sortOrder
+	}
+	auditParams.Limit = intParam(structuredParams["limit"])
+	auditParams.Offset = intParam(structuredParams["offset"])
+	auditParams.SampleRate = intParam(structuredParams["sample_rate"])
+	auditParams.SampleSize = intParam(structuredParams["sample_size"])
+	if outputMode, ok := structuredParams["output_mode"].(string); ok {
+		auditParams.OutputMode = outputMode
+	}
+	if bucketSize, ok := structuredParams["bucket_size"].(string); ok {
+		auditParams.BucketSize = bucketSize
+	}
+	if summaryMode, ok := structuredParams["summary_mode"].(string); ok {
+		auditParams.SummaryMode = summaryMode
+	}
+	if backend, ok := structuredParams["backend"].(string); ok {
+		auditParams.Backend = backend
+	}
+	if mode, ok := structuredParams["username_match"].(string); ok {
+		auditParams.UsernameMatch = types.MatchMode(mode)
+	}
+	if mode, ok := structuredParams["resource_match"].(string); ok {
+		auditParams.ResourceMatch = types.MatchMode(mode)
+	}
+	if mode, ok := structuredParams["verb_match"].(string); ok {
+		auditParams.VerbMatch = types.MatchMode(mode)
+	}
+	if mode, ok := structuredParams["namespace_match"].(string); ok {
+		auditParams.NamespaceMatch = types.MatchMode(mode)
+	}
+	if filter, ok := structuredParams["filter"].(map[string]interface{}); ok {
+		expr := parseFilterExpression(filter)
+		auditParams.Filter = &expr
+	}
+	utils.ResolveQueryResources(&auditParams)
+
+	return auditParams
+}
+
+// parseFilterExpression converts a filter argument to a FilterExpression
+func parseFilterExpression(filter map[string]interface{}) types.FilterExpression {
+	expr := types.FilterExpression{}
+	if operator, ok := filter["operator"].(string); ok {
+		expr.Operator = operator
+	}
+	if pattern, ok := filter["pattern"].(string); ok {
+		expr.Pattern = pattern
+	}
+	if children, ok := filter["children"].([]interface{}); ok {
+		for _, c := range children {
+			if child, ok := c.(map[string]interface{}); ok {
+				expr.Children = append(expr.Children, parseFilterExpression(child))
+			}
+		}
+	}
+	return expr
+}
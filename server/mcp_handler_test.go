@@ -2,11 +2,20 @@ package server
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"audit-query-mcp-server/forwarding"
 	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestHandleMCPRequest_ValidMethods tests the main request handler with valid methods
@@ -141,11 +150,27 @@ func TestHandleListTools(t *testing.T) {
 		"execute_audit_query_with_result",
 		"parse_audit_results_with_result",
 		"execute_complete_audit_query",
+		"explain_audit_query",
+		"ask_audit_question",
+		"find_permission_denials",
+		"compare_audit_activity",
+		"build_user_timeline",
+		"generate_audit_report",
+		"forward_audit_results",
+		"get_audit_trail",
+		"verify_audit_trail",
 		"get_cache_stats",
 		"clear_cache",
+		"invalidate_cache",
 		"get_cached_result",
 		"delete_cached_result",
 		"get_server_stats",
+		"reset_circuit_breaker",
+		"check_log_sources",
+		"get_audit_configuration",
+		"analyze_local_audit_file",
+		"execute_audit_query_batch",
+		"check_permissions",
 	}
 
 	for _, expectedTool := range expectedTools {
@@ -516,7 +541,7 @@ func TestHandleExecuteCompleteAuditQuery(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			response := server.handleExecuteCompleteAuditQuery("test-id", tt.params)
+			response := server.handleExecuteCompleteAuditQuery("test-id", tt.params, nil)
 
 			assert.Equal(t, "test-id", response.ID)
 			assert.Equal(t, "2.0", response.JSONRPC)
@@ -538,6 +563,77 @@ func TestHandleExecuteCompleteAuditQuery(t *testing.T) {
 	}
 }
 
+// TestHandleExplainAuditQuery tests the explain audit query handler
+func TestHandleExplainAuditQuery(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expectedError int
+	}{
+		{
+			name: "Valid structured params",
+			params: map[string]interface{}{
+				"structured_params": map[string]interface{}{
+					"log_source": "kube-apiserver",
+					"patterns":   []interface{}{"pods", "create", "delete", "secrets"},
+					"timeframe":  "today",
+				},
+			},
+		},
+		{
+			name: "Valid command",
+			params: map[string]interface{}{
+				"command": "oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i 'pods'",
+			},
+		},
+		{
+			name: "Invalid structured params",
+			params: map[string]interface{}{
+				"structured_params": map[string]interface{}{
+					"log_source": "invalid-source",
+				},
+			},
+			expectedError: -32602,
+		},
+		{
+			name: "Unsafe command",
+			params: map[string]interface{}{
+				"command": "rm -rf /",
+			},
+			expectedError: -32602,
+		},
+		{
+			name:          "Missing params",
+			params:        map[string]interface{}{},
+			expectedError: -32602,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := server.handleExplainAuditQuery("test-id", tt.params)
+
+			assert.Equal(t, "test-id", response.ID)
+			assert.Equal(t, "2.0", response.JSONRPC)
+
+			if tt.expectedError != 0 {
+				require.NotNil(t, response.Error)
+				assert.Equal(t, tt.expectedError, response.Error.Code)
+				return
+			}
+
+			require.Nil(t, response.Error)
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+			explanation, ok := result["explanation"].(*types.QueryExplanation)
+			require.True(t, ok)
+			assert.NotEmpty(t, explanation.Filters)
+		})
+	}
+}
+
 // TestHandleGetCacheStats tests the get cache stats handler
 func TestHandleGetCacheStats(t *testing.T) {
 	server := NewAuditQueryMCPServer()
@@ -678,6 +774,58 @@ func TestHandleDeleteCachedResult(t *testing.T) {
 	}
 }
 
+// TestHandleInvalidateCache tests the invalidate cache handler
+func TestHandleInvalidateCache(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	server.cache.SetForParams("oauth-query", "key-oauth", types.AuditQueryParams{LogSource: "oauth-server"}, &types.AuditResult{QueryID: "oauth-query"})
+	server.cache.SetForParams("kube-query", "key-kube", types.AuditQueryParams{LogSource: "kube-apiserver"}, &types.AuditResult{QueryID: "kube-query"})
+
+	response := server.handleToolCall(types.MCPRequest{
+		ID:     "test-id",
+		Method: "tools/call",
+		Params: map[string]interface{}{
+			"name":      "invalidate_cache",
+			"arguments": map[string]interface{}{"log_source": "oauth-server"},
+		},
+	})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, 1, result["invalidated"])
+
+	_, found := server.GetCachedResult("oauth-query")
+	assert.False(t, found)
+	_, found = server.GetCachedResult("kube-query")
+	assert.True(t, found)
+
+	response = server.handleInvalidateCache("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+}
+
+// TestHandleResetCircuitBreaker tests closing an open circuit breaker
+func TestHandleResetCircuitBreaker(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	server.circuit.Configure(1, time.Hour)
+	server.circuit.RecordFailure()
+	require.Equal(t, types.CircuitStateOpen, server.GetCircuitBreakerStatus().State)
+
+	response := server.handleToolCall(types.MCPRequest{
+		ID:     "test-id",
+		Method: "tools/call",
+		Params: map[string]interface{}{
+			"name":      "reset_circuit_breaker",
+			"arguments": map[string]interface{}{},
+		},
+	})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, types.CircuitStateOpen, result["previous_state"].(types.CircuitBreakerStatus).State)
+	status := result["circuit_breaker"].(types.CircuitBreakerStatus)
+	assert.Equal(t, types.CircuitStateClosed, status.State)
+	assert.Equal(t, 0, status.FailureCount)
+	assert.Equal(t, int64(1), status.Trips)
+}
+
 // TestHandleGetServerStats tests the get server stats handler
 func TestHandleGetServerStats(t *testing.T) {
 	server := NewAuditQueryMCPServer()
@@ -726,6 +874,324 @@ func TestParameterTypeConversion(t *testing.T) {
 	}
 }
 
+// TestParseStructuredParams_FilterExpression tests conversion of nested filter expressions
+func TestParseStructuredParams_FilterExpression(t *testing.T) {
+	auditParams := parseStructuredParams(map[string]interface{}{
+		"log_source": "kube-apiserver",
+		"filter": map[string]interface{}{
+			"operator": "and",
+			"children": []interface{}{
+				map[string]interface{}{
+					"operator": "or",
+					"children": []interface{}{
+						map[string]interface{}{"pattern": "delete"},
+						map[string]interface{}{"pattern": "patch"},
+					},
+				},
+				map[string]interface{}{
+					"operator": "not",
+					"children": []interface{}{
+						map[string]interface{}{"pattern": "system:"},
+					},
+				},
+			},
+		},
+	})
+
+	require.NotNil(t, auditParams.Filter)
+	assert.Equal(t, `(("delete" OR "patch") AND NOT ("system:"))`, auditParams.Filter.String())
+}
+
+// TestHandleAskAuditQuestion tests the ask_audit_question tool
+func TestHandleAskAuditQuestion(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	response := server.handleAskAuditQuestion("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleAskAuditQuestion("test-id", map[string]interface{}{"question": "what is going on"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "no filters recognised")
+
+	// Without a cluster the query fails, but the error still reports the interpretation
+	response = server.handleAskAuditQuestion("test-id", map[string]interface{}{"question": "who deleted secrets yesterday"})
+	if response.Error != nil {
+		assert.Equal(t, -32000, response.Error.Code)
+		assert.Contains(t, response.Error.Message, "verb delete")
+		return
+	}
+	result, ok := response.Result.(map[string]interface{})
+	require.True(t, ok)
+	interp, ok := result["interpretation"].(*types.QuestionInterpretation)
+	require.True(t, ok)
+	assert.Equal(t, "delete", interp.Params.Verb)
+	assert.Equal(t, "secrets", interp.Params.Resource)
+}
+
+// TestHandleFindPermissionDenials tests the find_permission_denials tool
+func TestHandleFindPermissionDenials(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	response := server.handleFindPermissionDenials("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{
+			"log_source": "invalid-source",
+		},
+	})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "validation failed")
+
+	// Without a cluster the fetch fails; with one, a report is returned
+	response = server.handleFindPermissionDenials("test-id", map[string]interface{}{})
+	if response.Error != nil {
+		assert.Equal(t, -32000, response.Error.Code)
+		return
+	}
+	result, ok := response.Result.(map[string]interface{})
+	require.True(t, ok)
+	_, ok = result["report"].(*types.PermissionDenialReport)
+	assert.True(t, ok)
+}
+
+// TestHandleCompareAuditActivity tests the compare_audit_activity tool
+func TestHandleCompareAuditActivity(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	response := server.handleCompareAuditActivity("test-id", map[string]interface{}{
+		"compare_by": "timeframe",
+		"baseline":   "yesterday",
+	})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleCompareAuditActivity("test-id", map[string]interface{}{
+		"compare_by": "namespace",
+		"baseline":   "a",
+		"comparison": "b",
+	})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "invalid comparison dimension")
+
+	response = server.handleCompareAuditActivity("test-id", map[string]interface{}{
+		"compare_by": "timeframe",
+		"baseline":   "not a timeframe",
+		"comparison": "today",
+	})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "baseline query failed")
+}
+
+// TestHandleBuildUserTimeline tests the build_user_timeline tool
+func TestHandleBuildUserTimeline(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	response := server.handleBuildUserTimeline("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleBuildUserTimeline("test-id", map[string]interface{}{
+		"username":     "alice",
+		"burst_window": "soon",
+	})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "invalid burst_window")
+
+	response = server.handleBuildUserTimeline("test-id", map[string]interface{}{
+		"username": "bad user;rm",
+	})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "validation failed")
+}
+
+// TestHandleGenerateAuditReport tests the generate_audit_report tool
+func TestHandleGenerateAuditReport(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	server.reportDir = t.TempDir()
+
+	response := server.handleGenerateAuditReport("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleGenerateAuditReport("test-id", map[string]interface{}{"query_id": "missing"})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "no cached result")
+
+	server.cache.Set("report-query", &types.AuditResult{
+		QueryID: "report-query",
+		Summary: "2 events",
+		ParsedData: []map[string]interface{}{
+			{"username": "alice", "verb": "delete", "resource": "secrets", "status_code": 200},
+			{"username": "bob", "verb": "get", "resource": "pods", "status_code": 403},
+		},
+	})
+
+	response = server.handleGenerateAuditReport("test-id", map[string]interface{}{
+		"query_id":    "report-query",
+		"format":      "html",
+		"output_file": "incident.html",
+	})
+	require.Nil(t, response.Error)
+	result, ok := response.Result.(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, result["report"], "<h2>Notable Events</h2>")
+	path, ok := result["path"].(string)
+	require.True(t, ok)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, result["report"], string(content))
+
+	response = server.handleGenerateAuditReport("test-id", map[string]interface{}{
+		"query_id":    "report-query",
+		"output_file": "../escape.md",
+	})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "invalid report file name")
+}
+
+// TestHandleForwardAuditResults tests the forward_audit_results tool
+func TestHandleForwardAuditResults(t *testing.T) {
+	received := 0
+	hec := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Splunk token", r.Header.Get("Authorization"))
+		received++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer hec.Close()
+
+	server := NewAuditQueryMCPServer()
+	forwarder, err := forwarding.NewForwarder([]forwarding.Destination{
+		{Name: "splunk", Type: forwarding.TypeSplunkHEC, URL: hec.URL, Token: "token"},
+	})
+	require.NoError(t, err)
+	server.forwarder = forwarder
+
+	response := server.handleForwardAuditResults("test-id", map[string]interface{}{"query_id": "forward-query"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	server.cache.Set("forward-query", &types.AuditResult{
+		QueryID: "forward-query",
+		ParsedData: []map[string]interface{}{
+			{"username": "alice", "verb": "delete", "resource": "secrets"},
+			{"username": "bob", "verb": "get", "resource": "pods"},
+		},
+	})
+
+	response = server.handleForwardAuditResults("test-id", map[string]interface{}{
+		"destination": "elastic",
+		"query_id":    "forward-query",
+	})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "unknown forwarding destination")
+
+	response = server.handleForwardAuditResults("test-id", map[string]interface{}{
+		"destination": "splunk",
+		"query_id":    "forward-query",
+	})
+	require.Nil(t, response.Error)
+	result, ok := response.Result.(map[string]interface{})
+	require.True(t, ok)
+	forwardResult, ok := result["forward"].(*types.ForwardResult)
+	require.True(t, ok)
+	assert.Equal(t, 2, forwardResult.Sent)
+	assert.Equal(t, 0, forwardResult.Failed)
+	assert.Equal(t, 1, received)
+}
+
+// TestHandleGetAuditTrail tests the get_audit_trail tool
+func TestHandleGetAuditTrail(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	trail, err := utils.NewAuditTrail(filepath.Join(t.TempDir(), "audit_trail.json"))
+	require.NoError(t, err)
+	defer trail.Close()
+	server.auditTrail = trail
+
+	old := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	require.NoError(t, trail.LogQuery(utils.AuditTrailEntry{Timestamp: old, QueryID: "old-query", Action: "complete_query"}))
+	require.NoError(t, trail.LogQuery(utils.AuditTrailEntry{
+		QueryID: "new-query",
+		UserID:  "alice",
+		Action:  "complete_query",
+		Result:  &types.AuditResult{QueryID: "new-query", RawOutput: "raw"},
+	}))
+
+	response := server.handleGetAuditTrail("test-id", map[string]interface{}{"since": "24h"})
+	require.Nil(t, response.Error)
+	result, ok := response.Result.(*utils.AuditTrailQueryResult)
+	require.True(t, ok)
+	require.Len(t, result.Entries, 1)
+	assert.Equal(t, "new-query", result.Entries[0].QueryID)
+	assert.Nil(t, result.Entries[0].Result)
+
+	response = server.handleGetAuditTrail("test-id", map[string]interface{}{"user_id": "alice", "include_results": true})
+	require.Nil(t, response.Error)
+	result = response.Result.(*utils.AuditTrailQueryResult)
+	require.Len(t, result.Entries, 1)
+	require.NotNil(t, result.Entries[0].Result)
+	assert.Equal(t, "raw", result.Entries[0].Result.RawOutput)
+
+	response = server.handleGetAuditTrail("test-id", map[string]interface{}{"until": "yesterday"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	server.auditTrail = nil
+	response = server.handleGetAuditTrail("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "not enabled")
+}
+
+// TestHandleVerifyAuditTrail tests the verify_audit_trail tool
+func TestHandleVerifyAuditTrail(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+	trail, err := utils.NewAuditTrail(filePath)
+	require.NoError(t, err)
+	defer trail.Close()
+	server.auditTrail = trail
+
+	require.NoError(t, trail.LogCacheAccess("q1", "hit", "", "", ""))
+	require.NoError(t, trail.LogCacheAccess("q2", "hit", "", "", ""))
+
+	response := server.handleVerifyAuditTrail("test-id", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	result, ok := response.Result.(*utils.AuditTrailVerification)
+	require.True(t, ok)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 2, result.RecordsChecked)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filePath, []byte(strings.Replace(string(content), `"q1"`, `"q9"`, 1)), 0644))
+
+	response = server.handleVerifyAuditTrail("test-id", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	result = response.Result.(*utils.AuditTrailVerification)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, utils.IntegrityModified, result.Issues[0].Type)
+}
+
+// TestParseStructuredParams_MultiValue tests that field filters accept a string or a list
+func TestParseStructuredParams_MultiValue(t *testing.T) {
+	auditParams := parseStructuredParams(map[string]interface{}{
+		"log_source": "kube-apiserver",
+		"username":   "admin",
+		"namespace":  []interface{}{"database", "customer-service"},
+		"verb":       []interface{}{"delete", 42},
+	})
+
+	assert.Equal(t, "admin", auditParams.Username)
+	assert.Empty(t, auditParams.Usernames)
+	assert.Empty(t, auditParams.Namespace)
+	assert.Equal(t, []string{"database", "customer-service"}, auditParams.Namespaces)
+	assert.Equal(t, []string{"delete"}, auditParams.Verbs)
+}
+
 // TestEdgeCases tests various edge cases in the MCP handler
 func TestEdgeCases(t *testing.T) {
 	server := NewAuditQueryMCPServer()
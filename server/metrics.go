@@ -0,0 +1,55 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// PrometheusMetrics renders the circuit breaker, cache and request metrics in the
+// Prometheus text exposition format
+func (s *AuditQueryMCPServer) PrometheusMetrics() string {
+	var b strings.Builder
+
+	circuit := s.GetCircuitBreakerStatus()
+	writeMetricHeader(&b, "audit_query_circuit_breaker_state", "gauge", "Current circuit breaker state (1 for the active state)")
+	for _, state := range []types.CircuitState{types.CircuitStateClosed, types.CircuitStateOpen, types.CircuitStateHalfOpen} {
+		value := 0
+		if circuit.State == state {
+			value = 1
+		}
+		fmt.Fprintf(&b, "audit_query_circuit_breaker_state{state=%q} %d\n", string(state), value)
+	}
+	writeMetric(&b, "audit_query_circuit_breaker_failure_count", "gauge", "Consecutive command failures", circuit.FailureCount)
+	writeMetric(&b, "audit_query_circuit_breaker_failure_threshold", "gauge", "Consecutive failures that open the circuit breaker", circuit.FailureThreshold)
+	writeMetric(&b, "audit_query_circuit_breaker_trips_total", "counter", "Times the circuit breaker opened", circuit.Trips)
+	writeMetric(&b, "audit_query_circuit_breaker_rejections_total", "counter", "Calls rejected while the circuit breaker was open", circuit.Rejections)
+	writeMetric(&b, "audit_query_circuit_breaker_failures_total", "counter", "Command failures recorded by the circuit breaker", circuit.TotalFailures)
+	writeMetric(&b, "audit_query_circuit_breaker_successes_total", "counter", "Command successes recorded by the circuit breaker", circuit.TotalSuccesses)
+
+	cache := s.GetCacheStats()
+	writeMetric(&b, "audit_query_cache_entries", "gauge", "Cached results", cache["size"])
+	writeMetric(&b, "audit_query_cache_bytes", "gauge", "Estimated size of cached results in bytes", cache["bytes"])
+	writeMetric(&b, "audit_query_cache_hits_total", "counter", "Cache hits", cache["hits"])
+	writeMetric(&b, "audit_query_cache_misses_total", "counter", "Cache misses", cache["misses"])
+	writeMetric(&b, "audit_query_cache_evictions_total", "counter", "Cache entries evicted to stay within bounds", cache["evictions"])
+
+	requests, errors, slow := s.requests.totals()
+	writeMetric(&b, "audit_query_requests_total", "counter", "MCP requests handled", requests)
+	writeMetric(&b, "audit_query_request_errors_total", "counter", "MCP requests answered with an error", errors)
+	writeMetric(&b, "audit_query_slow_requests_total", "counter", "MCP requests written to the slow-query log", slow)
+
+	return b.String()
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a metric
+func writeMetricHeader(b *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// writeMetric writes a metric without labels
+func writeMetric(b *strings.Builder, name, metricType, help string, value interface{}) {
+	writeMetricHeader(b, name, metricType, help)
+	fmt.Fprintf(b, "%s %v\n", name, value)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain writes the audit trails, slow-query logs and findings of the servers the tests create to a
+// temporary directory rather than ./logs
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "audit-query-logs")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("AUDIT_TRAIL_FILE", filepath.Join(dir, "audit_trail.json"))
+	os.Setenv("AUDIT_SLOW_QUERY_LOG", filepath.Join(dir, "slow_queries.json"))
+	os.Setenv("AUDIT_FINDINGS_FILE", filepath.Join(dir, "findings.json"))
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newMockServer creates a server reading the mock provider's canned events
+func newMockServer(t *testing.T) *AuditQueryMCPServer {
+	t.Helper()
+	t.Setenv("AUDIT_PROVIDER", "mock")
+	server := NewAuditQueryMCPServer()
+	require.Equal(t, "mock", server.ProviderName())
+	return server
+}
+
+// TestMockBackend_IntegrationScenarios runs the integration scenarios of the
+// test subcommand against the canned events
+func TestMockBackend_IntegrationScenarios(t *testing.T) {
+	server := newMockServer(t)
+
+	tests := []struct {
+		name     string
+		params   types.AuditQueryParams
+		entries  int
+		username string
+	}{
+		{
+			name:     "security investigation",
+			params:   types.AuditQueryParams{LogSource: "kube-apiserver", Resource: "customresourcedefinitions", Verb: "delete", Timeframe: "24h", ExcludeUsers: []string{"system:*", "kube:*"}},
+			entries:  1,
+			username: "carol",
+		},
+		{
+			name:     "authentication analysis",
+			params:   types.AuditQueryParams{LogSource: "oauth-server", Patterns: []string{"authentication", "failed"}, Timeframe: "today"},
+			entries:  3,
+			username: "system:anonymous",
+		},
+		{
+			name:     "performance monitoring",
+			params:   types.AuditQueryParams{LogSource: "kube-apiserver", Patterns: []string{"pods", "create"}, Timeframe: "1h", Namespace: "default"},
+			entries:  1,
+			username: "admin",
+		},
+		{
+			name:     "permission denials",
+			params:   types.AuditQueryParams{LogSource: "kube-apiserver", Username: "bob", StatusCodeRange: "4xx", Timeframe: "today"},
+			entries:  2,
+			username: "bob",
+		},
+		{
+			name:    "no matches",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Username: "mallory", Timeframe: "today"},
+			entries: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.ExecuteCompleteAuditQuery(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, "mock", result.Backend)
+			assert.Equal(t, "mock read "+tt.params.LogSource, result.Command)
+			assert.Equal(t, tt.entries, result.TotalEntries)
+			require.Len(t, result.ParsedData, tt.entries)
+			for _, entry := range result.ParsedData {
+				assert.Equal(t, tt.username, entry["username"])
+			}
+		})
+	}
+
+	// The repeated query is served from the cache
+	params := tests[0].params
+	first, err := server.ExecuteCompleteAuditQuery(params)
+	require.NoError(t, err)
+	second, err := server.ExecuteCompleteAuditQuery(params)
+	require.NoError(t, err)
+	assert.Equal(t, first.QueryID, second.QueryID)
+	assert.GreaterOrEqual(t, server.GetCacheStats()["hits"], int64(1))
+
+	// Log sources without mock data fail like unavailable log sources
+	_, err = server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "node"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no mock data")
+}
+
+// TestMockBackend_MCPProtocol runs the MCP protocol tests of the test
+// subcommand against the canned events
+func TestMockBackend_MCPProtocol(t *testing.T) {
+	server := newMockServer(t)
+
+	call := func(name string, arguments map[string]interface{}) types.MCPResponse {
+		return server.HandleMCPRequest(types.MCPRequest{ID: name, Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+		}})
+	}
+	query := map[string]interface{}{
+		"structured_params": map[string]interface{}{
+			"log_source": "kube-apiserver",
+			"username":   "alice",
+			"verb":       "delete",
+			"timeframe":  "today",
+		},
+	}
+
+	response := call("generate_audit_query_with_result", query)
+	require.Nil(t, response.Error)
+	generated := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+	assert.Equal(t, "mock read kube-apiserver", generated.Command)
+
+	response = call("execute_audit_query_with_result", map[string]interface{}{"command": generated.Command, "query_id": generated.QueryID})
+	require.Nil(t, response.Error)
+	executed := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+	assert.Contains(t, executed.RawOutput, "mock-0001")
+
+	response = call("execute_complete_audit_query", query)
+	require.Nil(t, response.Error)
+	complete := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+	assert.Equal(t, 2, complete.TotalEntries)
+	assert.Contains(t, complete.Summary, "Found 2 audit entries")
+	assert.Contains(t, complete.Summary, "alice (2)")
+
+	response = call("find_permission_denials", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+	})
+	require.Nil(t, response.Error)
+	denials := response.Result.(map[string]interface{})["report"].(*types.PermissionDenialReport)
+	assert.Equal(t, 2, denials.TotalDenials)
+
+	response = call("get_cache_stats", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	response = call("get_server_stats", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	stats := response.Result.(map[string]interface{})["server_stats"].(map[string]interface{})
+	assert.Equal(t, "mock", stats["provider"])
+}
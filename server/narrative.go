@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"audit-query-mcp-server/reporting"
+	"audit-query-mcp-server/types"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Narrative summary defaults
+const (
+	// DefaultNarrativeModel writes narratives unless AUDIT_NARRATIVE_MODEL is set
+	DefaultNarrativeModel = "gpt-4o-mini"
+	// narrativeTimeout bounds one narrative request to the model
+	narrativeTimeout = 60 * time.Second
+)
+
+// openAICompleter sends prompts to an OpenAI chat model
+type openAICompleter struct {
+	client *openai.Client
+	model  string
+}
+
+// Complete asks the chat model for a reply to prompt
+func (c *openAICompleter) Complete(ctx context.Context, system, prompt string) (string, error) {
+	response, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: system},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("the model returned no choices")
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+// GenerateNarrative writes a narrative summary of a result with the configured
+// language model. Only a redacted digest of the parsed entries is sent.
+func (s *AuditQueryMCPServer) GenerateNarrative(result *types.AuditResult) (*types.Narrative, error) {
+	if s.narrator == nil {
+		return nil, fmt.Errorf("narrative summaries are disabled: set OPENAI_API_KEY to enable them")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), narrativeTimeout)
+	defer cancel()
+	s.logger.Infof("Generating a narrative summary of %d entries for query %s", len(result.ParsedData), result.QueryID)
+	narrative, err := reporting.GenerateNarrative(ctx, s.narrator, result)
+	if err != nil {
+		return nil, err
+	}
+	narrative.Model = s.narrativeModel
+	return narrative, nil
+}
+
+// narrativeSchema is the JSON schema of the narrative tool argument
+func narrativeSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Also return a narrative paragraph and notable findings written by the configured language model from a redacted digest of the entries (requires OPENAI_API_KEY)",
+	}
+}
+
+// addNarrative adds a narrative of the result to a tool response when the
+// request asks for one. A failed narrative is reported next to the result
+// rather than failing the query.
+func (s *AuditQueryMCPServer) addNarrative(response map[string]interface{}, params map[string]interface{}, result *types.AuditResult) {
+	if requested, _ := params["narrative"].(bool); !requested {
+		return
+	}
+	narrative, err := s.GenerateNarrative(result)
+	if err != nil {
+		s.logger.Warnf("Narrative summary failed: %v", err)
+		response["narrative_error"] = err.Error()
+		return
+	}
+	response["narrative"] = narrative
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCompleter answers narrative requests with a canned reply
+type stubCompleter struct {
+	reply string
+	err   error
+	calls int
+}
+
+func (c *stubCompleter) Complete(ctx context.Context, system, prompt string) (string, error) {
+	c.calls++
+	return c.reply, c.err
+}
+
+// TestNarrative_Tools tests the narrative argument of the query tools
+func TestNarrative_Tools(t *testing.T) {
+	server := newMockServer(t)
+	completer := &stubCompleter{reply: `{"narrative": "user-1 deleted a pod.", "findings": ["user-1 deleted a pod"]}`}
+	server.narrator = completer
+	server.narrativeModel = "test-model"
+
+	call := func(name string, arguments map[string]interface{}) map[string]interface{} {
+		response := server.HandleMCPRequest(types.MCPRequest{ID: name, Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+		}})
+		require.Nil(t, response.Error)
+		return response.Result.(map[string]interface{})
+	}
+	query := map[string]interface{}{"log_source": "kube-apiserver", "username": "alice", "verb": "delete", "timeframe": "today"}
+
+	// Narratives are opt-in
+	result := call("execute_complete_audit_query", map[string]interface{}{"structured_params": query})
+	assert.NotContains(t, result, "narrative")
+	assert.Equal(t, 0, completer.calls)
+
+	result = call("execute_complete_audit_query", map[string]interface{}{"structured_params": query, "narrative": true})
+	narrative := result["narrative"].(*types.Narrative)
+	assert.Equal(t, "alice deleted a pod.", narrative.Text)
+	assert.Equal(t, []string{"alice deleted a pod"}, narrative.Findings)
+	assert.Equal(t, "test-model", narrative.Model)
+	assert.Equal(t, 2, narrative.TotalEvents)
+
+	result = call("ask_audit_question", map[string]interface{}{"question": "what did user alice do today", "narrative": true})
+	assert.Contains(t, result, "narrative")
+	assert.Contains(t, result, "interpretation")
+
+	// A failed narrative keeps the audit result
+	completer.err = errors.New("model unavailable")
+	result = call("execute_complete_audit_query", map[string]interface{}{"structured_params": query, "narrative": true})
+	assert.NotNil(t, result["audit_result"])
+	assert.Contains(t, result["narrative_error"], "model unavailable")
+
+	server.narrator = nil
+	_, err := server.GenerateNarrative(result["audit_result"].(*types.AuditResult))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OPENAI_API_KEY")
+}
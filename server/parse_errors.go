@@ -0,0 +1,101 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+)
+
+// DefaultParseErrorsLimit is the number of quarantined lines get_parse_errors
+// returns when no limit is given
+const DefaultParseErrorsLimit = 50
+
+// parseQuarantine keeps the most recent lines the parser rejected, shared by
+// the cluster servers
+type parseQuarantine struct {
+	mutex sync.Mutex
+	lines []types.QuarantinedLine
+	total int
+}
+
+// add appends rejected lines, keeping the most recent capacity lines
+func (q *parseQuarantine) add(lines []types.QuarantinedLine, capacity int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.lines = append(q.lines, lines...)
+	q.total += len(lines)
+	if len(q.lines) > capacity {
+		q.lines = append([]types.QuarantinedLine(nil), q.lines[len(q.lines)-capacity:]...)
+	}
+}
+
+// list returns the most recent lines read by queryID, or by any query when
+// it is empty, at most limit of them in the order they were rejected
+func (q *parseQuarantine) list(queryID string, limit int) ([]types.QuarantinedLine, int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	lines := make([]types.QuarantinedLine, 0)
+	for i := len(q.lines) - 1; i >= 0 && len(lines) < limit; i-- {
+		if queryID == "" || q.lines[i].QueryID == queryID {
+			lines = append(lines, q.lines[i])
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines, q.total
+}
+
+// clear empties the quarantine and returns the number of lines removed
+func (q *parseQuarantine) clear() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	cleared := len(q.lines)
+	q.lines = nil
+	q.total = 0
+	return cleared
+}
+
+// quarantineRejectedLines keeps the lines a parse of queryID rejected, when
+// the parser is configured to quarantine them
+func (s *AuditQueryMCPServer) quarantineRejectedLines(queryID string, rejected []parsing.RejectedLine) {
+	capacity := s.parserConfig().QuarantineLines
+	if len(rejected) == 0 || capacity == 0 {
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	lines := make([]types.QuarantinedLine, len(rejected))
+	for i, line := range rejected {
+		lines[i] = types.QuarantinedLine{
+			QueryID:       queryID,
+			Cluster:       s.cluster,
+			LineNumber:    line.LineNumber,
+			Error:         line.Error,
+			Line:          line.Line,
+			Length:        line.Length,
+			Truncated:     line.Truncated,
+			QuarantinedAt: now,
+		}
+	}
+	s.quarantine.add(lines, capacity)
+	s.logger.Infof("Quarantined %d rejected lines of query %s", len(lines), queryID)
+}
+
+// GetParseErrors returns the quarantined lines read by queryID, or by any
+// query when it is empty, at most limit of the most recent ones. With
+// clearQuarantine, the quarantine is emptied after it is read.
+func (s *AuditQueryMCPServer) GetParseErrors(queryID string, limit int, clearQuarantine bool) types.ParseErrorsReport {
+	if limit <= 0 {
+		limit = DefaultParseErrorsLimit
+	}
+	capacity := s.parserConfig().QuarantineLines
+	report := types.ParseErrorsReport{Enabled: capacity > 0, Capacity: capacity}
+	report.Lines, report.Total = s.quarantine.list(queryID, limit)
+	if clearQuarantine {
+		report.Cleared = s.quarantine.clear()
+		s.logger.Infof("Cleared %d quarantined lines", report.Cleared)
+	}
+	return report
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getParseErrors calls the get_parse_errors tool
+func getParseErrors(t *testing.T, server *AuditQueryMCPServer, arguments map[string]interface{}) *types.ParseErrorsReport {
+	t.Helper()
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "get_parse_errors", "arguments": arguments,
+	}})
+	require.Nil(t, response.Error)
+	return response.Result.(*types.ParseErrorsReport)
+}
+
+// TestGetParseErrors tests quarantining rejected lines and listing them by query
+func TestGetParseErrors(t *testing.T) {
+	server := newMockServer(t)
+	rawOutput := `{"verb":"get","user":{"username":"admin"},"token":"abc123"
+{"requestReceivedTimestamp":"2024-01-15T10:30:00Z","user":{"username":"admin"},"verb":"get"}
+{"verb": }`
+
+	// Nothing is kept until quarantine is enabled
+	_, err := server.ParseAuditResultsWithResult(rawOutput, map[string]interface{}{}, "before")
+	require.NoError(t, err)
+	report := getParseErrors(t, server, map[string]interface{}{})
+	assert.False(t, report.Enabled)
+	assert.Empty(t, report.Lines)
+
+	response := configureParser(server, map[string]interface{}{"quarantine_lines": float64(3)})
+	require.Nil(t, response.Error)
+	_, err = server.ParseAuditResultsWithResult(rawOutput, map[string]interface{}{}, "first")
+	require.NoError(t, err)
+	_, err = server.ParseAuditResultsWithResult(rawOutput, map[string]interface{}{}, "second")
+	require.NoError(t, err)
+
+	report = getParseErrors(t, server, map[string]interface{}{})
+	assert.True(t, report.Enabled)
+	assert.Equal(t, 3, report.Capacity)
+	assert.Equal(t, 4, report.Total)
+	require.Len(t, report.Lines, 3)
+	assert.Equal(t, "first", report.Lines[0].QueryID)
+	assert.Equal(t, 3, report.Lines[0].LineNumber)
+	assert.Equal(t, "second", report.Lines[2].QueryID)
+	assert.NotContains(t, report.Lines[1].Line, "abc123")
+	assert.Contains(t, report.Lines[1].Error, "malformed JSON")
+
+	report = getParseErrors(t, server, map[string]interface{}{"query_id": "second", "limit": float64(1)})
+	require.Len(t, report.Lines, 1)
+	assert.Equal(t, 3, report.Lines[0].LineNumber)
+
+	report = getParseErrors(t, server, map[string]interface{}{"clear": true})
+	assert.Equal(t, 3, report.Cleared)
+	report = getParseErrors(t, server, map[string]interface{}{})
+	assert.Empty(t, report.Lines)
+	assert.Equal(t, 0, report.Total)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+)
+
+// ParserConfigUpdate changes settings of the parser configuration; nil
+// fields keep their value. Reset first restores the configured settings.
+type ParserConfigUpdate struct {
+	MaxParseErrors *int
+	MaxLineLength  *int
+	Fallback       *bool
+	Strict         *bool
+	// QuarantineLines is the number of most recent rejected lines kept for
+	// get_parse_errors; 0 disables the quarantine
+	QuarantineLines *int
+	Reset           bool
+}
+
+// parserConfigFromEnv returns the default parser configuration with the
+// AUDIT_PARSER_MAX_PARSE_ERRORS, AUDIT_PARSER_MAX_LINE_LENGTH,
+// AUDIT_PARSER_FALLBACK, AUDIT_PARSER_STRICT and
+// AUDIT_PARSER_QUARANTINE_LINES settings; invalid values keep the default
+func parserConfigFromEnv() parsing.ParserConfig {
+	config := parsing.DefaultParserConfig()
+
+	if value := os.Getenv("AUDIT_PARSER_MAX_PARSE_ERRORS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			config.MaxParseErrors = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_PARSER_MAX_PARSE_ERRORS: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_PARSER_MAX_LINE_LENGTH"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			config.MaxLineLength = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_PARSER_MAX_LINE_LENGTH: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_PARSER_FALLBACK"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			config.DisableFallback = !parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_PARSER_FALLBACK: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_PARSER_STRICT"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			config.Strict = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_PARSER_STRICT: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_PARSER_QUARANTINE_LINES"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			config.QuarantineLines = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_PARSER_QUARANTINE_LINES: %s", value)
+		}
+	}
+	return config
+}
+
+// parserConfig returns the configuration audit log lines are parsed with
+func (s *AuditQueryMCPServer) parserConfig() parsing.ParserConfig {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.parser
+}
+
+// ParserConfig returns the parser configuration
+func (s *AuditQueryMCPServer) ParserConfig() types.ParserConfig {
+	return parsing.ConvertToTypesParserConfig(s.parserConfig())
+}
+
+// ConfigureParser applies an update to the parser configuration of the
+// server and its cluster servers and returns the configuration before and
+// after it. A change is recorded in the audit trail.
+func (s *AuditQueryMCPServer) ConfigureParser(update ParserConfigUpdate) (types.ParserConfig, types.ParserConfig, error) {
+	current := s.parserConfig()
+	previous := current
+	if update.Reset {
+		current = parserConfigFromEnv()
+	}
+	if update.MaxParseErrors != nil {
+		current.MaxParseErrors = *update.MaxParseErrors
+	}
+	if update.MaxLineLength != nil {
+		current.MaxLineLength = *update.MaxLineLength
+	}
+	if update.Fallback != nil {
+		current.DisableFallback = !*update.Fallback
+	}
+	if update.Strict != nil {
+		current.Strict = *update.Strict
+	}
+	if update.QuarantineLines != nil {
+		current.QuarantineLines = *update.QuarantineLines
+	}
+	if err := parsing.ValidateParserConfig(current); err != nil {
+		return parsing.ConvertToTypesParserConfig(previous), parsing.ConvertToTypesParserConfig(previous), fmt.Errorf("invalid parser configuration: %w", err)
+	}
+
+	s.setParserConfig(previous, current, "configure_parser")
+	return parsing.ConvertToTypesParserConfig(previous), parsing.ConvertToTypesParserConfig(current), nil
+}
+
+// setParserConfig replaces the parser configuration of the server and its
+// cluster servers, recording a change from previous in the audit trail
+func (s *AuditQueryMCPServer) setParserConfig(previous, current parsing.ParserConfig, trigger string) {
+	servers := []*AuditQueryMCPServer{s}
+	for _, name := range s.ClusterNames() {
+		servers = append(servers, s.clusters[name])
+	}
+	for _, server := range servers {
+		server.configMutex.Lock()
+		server.parser = current
+		server.configMutex.Unlock()
+	}
+	if current == previous {
+		return
+	}
+
+	s.logger.Infof("Parser configuration changed by %s: max_parse_errors=%d max_line_length=%d fallback=%t strict=%t quarantine_lines=%d",
+		trigger, current.MaxParseErrors, current.MaxLineLength, !current.DisableFallback, current.Strict, current.QuarantineLines)
+	if s.auditTrail != nil {
+		err := s.auditTrail.LogConfigChange("parser", trigger, parsing.ConvertToTypesParserConfig(previous), parsing.ConvertToTypesParserConfig(current), "", "", "")
+		if err != nil {
+			s.logger.Warnf("Failed to record the parser configuration change: %v", err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configureParser calls the configure_parser tool
+func configureParser(server *AuditQueryMCPServer, arguments map[string]interface{}) types.MCPResponse {
+	return server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "configure_parser", "arguments": arguments,
+	}})
+}
+
+// TestConfigureParser tests changing the parser configuration, its effect on
+// parsing and its record in the audit trail
+func TestConfigureParser(t *testing.T) {
+	server := newMockServer(t)
+	userless := `{"requestReceivedTimestamp":"2024-01-15T10:30:00Z","verb":"get"}`
+
+	response := configureParser(server, map[string]interface{}{})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, "Parser configuration unchanged", result["message"])
+	assert.False(t, result["parser_config"].(types.ParserConfig).Strict)
+
+	parsed, err := server.ParseAuditResultsWithResult(userless, map[string]interface{}{}, "lenient")
+	require.NoError(t, err)
+	assert.Len(t, parsed.ParsedData, 1)
+
+	response = configureParser(server, map[string]interface{}{"strict": true, "max_parse_errors": float64(5), "fallback": false})
+	require.Nil(t, response.Error)
+	result = response.Result.(map[string]interface{})
+	assert.Equal(t, "Parser configuration updated", result["message"])
+	current := result["parser_config"].(types.ParserConfig)
+	assert.True(t, current.Strict)
+	assert.True(t, current.DisableFallback)
+	assert.Equal(t, 5, current.MaxParseErrors)
+	assert.Equal(t, 1000, result["previous"].(types.ParserConfig).MaxParseErrors)
+
+	parsed, err = server.ParseAuditResultsWithResult(userless, map[string]interface{}{}, "strict")
+	require.NoError(t, err)
+	assert.Empty(t, parsed.ParsedData)
+
+	if server.auditTrail != nil {
+		trail, err := server.QueryAuditTrail(utils.AuditTrailQuery{Action: "config_change", Limit: 1}, false)
+		require.NoError(t, err)
+		require.Len(t, trail.Entries, 1)
+		assert.Equal(t, "parser", trail.Entries[0].Parameters["component"])
+		assert.Equal(t, "configure_parser", trail.Entries[0].Parameters["trigger"])
+	}
+
+	// Reset restores the configured settings
+	response = configureParser(server, map[string]interface{}{"reset": true})
+	require.Nil(t, response.Error)
+	assert.False(t, server.ParserConfig().Strict)
+	assert.Equal(t, 1000, server.ParserConfig().MaxParseErrors)
+}
+
+// TestConfigureParser_Invalid tests rejecting invalid settings
+func TestConfigureParser_Invalid(t *testing.T) {
+	server := newMockServer(t)
+
+	response := configureParser(server, map[string]interface{}{"max_line_length": float64(0)})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = configureParser(server, map[string]interface{}{"max_parse_errors": "many"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, 100000, server.ParserConfig().MaxLineLength)
+}
+
+// TestConfigureParser_LongLines tests that a multi-megabyte event is parsed
+// with a truncated raw line, up to the configured maximum length
+func TestConfigureParser_LongLines(t *testing.T) {
+	server := newMockServer(t)
+	large := `{"requestReceivedTimestamp":"2024-01-15T10:30:00Z","user":{"username":"admin"},"verb":"create","objectRef":{"resource":"configmaps","name":"bundle"},"requestObject":{"data":{"bundle":"` +
+		strings.Repeat("x", 5<<20) + `"}}}`
+
+	response := configureParser(server, map[string]interface{}{"max_line_length": float64(1024)})
+	require.Nil(t, response.Error)
+	parsed, err := server.ParseAuditResultsWithResult(large, map[string]interface{}{}, "long")
+	require.NoError(t, err)
+	require.Len(t, parsed.ParsedData, 1)
+	entry := parsed.ParsedData[0]
+	assert.Equal(t, "admin", entry["username"])
+	assert.Equal(t, "bundle", entry["name"])
+	assert.Equal(t, true, entry["truncated"])
+	assert.Equal(t, len(large), entry["line_length"])
+	assert.Len(t, entry["raw_line"], 1024)
+
+	response = configureParser(server, map[string]interface{}{"max_line_length": float64(6 << 20)})
+	require.Nil(t, response.Error)
+	parsed, err = server.ParseAuditResultsWithResult(large, map[string]interface{}{}, "whole")
+	require.NoError(t, err)
+	require.Len(t, parsed.ParsedData, 1)
+	assert.NotContains(t, parsed.ParsedData[0], "truncated")
+	assert.Len(t, parsed.ParsedData[0]["raw_line"], len(large))
+}
+
+// TestParserConfigFromEnv tests the parser settings of the environment
+func TestParserConfigFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_PARSER_MAX_PARSE_ERRORS", "10")
+	t.Setenv("AUDIT_PARSER_MAX_LINE_LENGTH", "2048")
+	t.Setenv("AUDIT_PARSER_FALLBACK", "false")
+	t.Setenv("AUDIT_PARSER_STRICT", "yes")
+
+	config := parserConfigFromEnv()
+	assert.Equal(t, 10, config.MaxParseErrors)
+	assert.Equal(t, 2048, config.MaxLineLength)
+	assert.True(t, config.DisableFallback)
+	assert.False(t, config.Strict, "invalid values keep the default")
+
+	server := newMockServer(t)
+	assert.Equal(t, 2048, server.ParserConfig().MaxLineLength)
+}
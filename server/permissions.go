@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// permissionCheckTimeout bounds each oc command of a permission check
+const permissionCheckTimeout = 15 * time.Second
+
+// CheckPermissions reports which RBAC permissions needed for audit queries the
+// identity oc runs as has, checked with SelfSubjectAccessReviews, and whether a
+// minimal oc adm node-logs read succeeds when the node permissions are granted
+func (s *AuditQueryMCPServer) CheckPermissions() (*types.PermissionReport, error) {
+	s.logger.Info("Checking audit query permissions")
+	if s.provider != nil {
+		return nil, fmt.Errorf("permissions are checked with oc and are not available with the %s provider", s.provider.Name())
+	}
+
+	identity, err := runOc(s.ocArgs(commands.WhoAmIArgs()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify the current user: %w", err)
+	}
+
+	report := &types.PermissionReport{
+		Identity:  strings.TrimSpace(identity),
+		CheckedAt: time.Now().Format(time.RFC3339),
+	}
+	nodesAllowed := true
+	for _, check := range commands.RequiredPermissions() {
+		output, err := runOc(s.ocArgs(commands.CanIArgs(check)))
+		allowed, parseErr := commands.ParseCanI(output)
+		if parseErr != nil {
+			if err != nil {
+				parseErr = err
+			}
+			check.Error = parseErr.Error()
+		}
+		check.Allowed = allowed
+		if !allowed {
+			report.Missing = append(report.Missing, commands.PermissionName(check))
+			if check.Resource == "nodes" {
+				nodesAllowed = false
+			}
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	if nodesAllowed {
+		if _, err := runOc(s.ocArgs(commands.LogSourceProbeArgs("kube-apiserver"))); err != nil {
+			report.NodeLogsError = err.Error()
+		} else {
+			report.NodeLogsReadable = true
+		}
+	} else {
+		report.NodeLogsError = "not attempted without permission to list nodes and get nodes/proxy"
+	}
+	report.CanQuery = report.NodeLogsReadable
+
+	if len(report.Missing) > 0 {
+		report.Remediation = fmt.Sprintf("Ask a cluster administrator to grant %s to %s: create a ClusterRole with these rules and bind it with oc adm policy add-cluster-role-to-user <role> %s",
+			strings.Join(report.Missing, ", "), report.Identity, report.Identity)
+	} else if report.NodeLogsError != "" {
+		_, report.Remediation = classifyError(errors.New(report.NodeLogsError))
+	}
+	s.logger.Infof("Permission check for %s: %d missing, node logs readable: %t", report.Identity, len(report.Missing), report.NodeLogsReadable)
+	return report, nil
+}
+
+// runOc runs oc with args and returns its output; a failed command's error
+// includes the output
+func runOc(args []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), permissionCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "oc", args...).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), fmt.Errorf("oc %s timed out after %s", strings.Join(args, " "), permissionCheckTimeout)
+	}
+	if err != nil {
+		return string(output), fmt.Errorf("%w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckPermissions tests reporting granted and missing permissions
+func TestCheckPermissions(t *testing.T) {
+	// All permissions granted and the node logs readable
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+whoami) echo "audit-reader" ;;
+"auth can-i "*) echo "yes" ;;
+"adm node-logs --role=master --path=kube-apiserver/") printf 'master-0 audit.log\n' ;;
+*) echo "error: unexpected call" >&2; exit 1 ;;
+esac
+`)
+	server := NewAuditQueryMCPServer()
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "check_permissions", "arguments": map[string]interface{}{},
+	}})
+	require.Nil(t, response.Error)
+	report := response.Result.(*types.PermissionReport)
+	assert.Equal(t, "audit-reader", report.Identity)
+	assert.Len(t, report.Checks, 3)
+	assert.Empty(t, report.Missing)
+	assert.True(t, report.NodeLogsReadable)
+	assert.True(t, report.CanQuery)
+	assert.Empty(t, report.Remediation)
+
+	// Without get nodes/proxy the node logs are not read
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+whoami) echo "developer" ;;
+"auth can-i get nodes --subresource=proxy") echo "no"; exit 1 ;;
+"auth can-i get apiservers.config.openshift.io") echo "Warning: resource 'apiservers' is not namespace scoped"; echo "no"; exit 1 ;;
+"auth can-i "*) echo "yes" ;;
+*) echo "error: unexpected call" >&2; exit 1 ;;
+esac
+`)
+	report, err := server.CheckPermissions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"get nodes/proxy", "get apiservers.config.openshift.io"}, report.Missing)
+	assert.True(t, report.Checks[0].Allowed)
+	assert.False(t, report.Checks[1].Allowed)
+	assert.False(t, report.NodeLogsReadable)
+	assert.False(t, report.CanQuery)
+	assert.Contains(t, report.NodeLogsError, "not attempted")
+	assert.Contains(t, report.Remediation, "grant get nodes/proxy, get apiservers.config.openshift.io to developer")
+
+	// Granted permissions but a failing read report the read error
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+whoami) echo "audit-reader" ;;
+"auth can-i "*) echo "yes" ;;
+*) echo "Unable to connect to the server: dial tcp 10.0.0.1:6443: connect: connection refused" >&2; exit 1 ;;
+esac
+`)
+	report, err = server.CheckPermissions()
+	require.NoError(t, err)
+	assert.Empty(t, report.Missing)
+	assert.False(t, report.CanQuery)
+	assert.Contains(t, report.NodeLogsError, "connection refused")
+	assert.Contains(t, report.Remediation, "reachable")
+
+	// An unparseable answer is reported on its check
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+whoami) echo "audit-reader" ;;
+"auth can-i list nodes") echo "error: the server has asked for the client to provide credentials" >&2; exit 1 ;;
+*) echo "yes" ;;
+esac
+`)
+	report, err = server.CheckPermissions()
+	require.NoError(t, err)
+	assert.Contains(t, report.Checks[0].Error, "provide credentials")
+	assert.Equal(t, []string{"list nodes"}, report.Missing)
+
+	// Without a logged in user the tool fails with a permission error
+	installFakeOc(t, "#!/bin/sh\necho 'error: You must be logged in to the server (Unauthorized)' >&2\nexit 1\n")
+	response = server.handleCheckPermissions("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.ErrorTypePermissionDenied, response.Error.Data.Type)
+	assert.Contains(t, response.Error.Message, "failed to identify the current user")
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditPodExec tests the audit_pod_exec tool against the mock events
+func TestAuditPodExec(t *testing.T) {
+	server := newMockServer(t)
+
+	response := server.handleAuditPodExec("test-id", map[string]interface{}{"subresources": []interface{}{"log"}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleAuditPodExec("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	result := response.Result.(map[string]interface{})
+	report := result["report"].(*types.PodExecReport)
+	assert.Equal(t, 1, report.TotalSessions)
+	require.Len(t, report.Sessions, 1)
+	assert.Equal(t, "alice", report.Sessions[0].Username)
+	assert.Equal(t, "payments", report.Sessions[0].Namespace)
+	assert.Equal(t, "api-7d9f", report.Sessions[0].Pod)
+	assert.Equal(t, "sh", report.Sessions[0].Command)
+	assert.True(t, report.Sessions[0].Interactive)
+	assert.Equal(t, report.Summary, result["audit_result"].(*types.AuditResult).Summary)
+
+	// Port-forwards only
+	response = server.handleAuditPodExec("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today"},
+		"subresources":      []interface{}{"portforward"},
+	})
+	require.Nil(t, response.Error)
+	assert.Empty(t, response.Result.(map[string]interface{})["report"].(*types.PodExecReport).Sessions)
+}
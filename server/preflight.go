@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/preflight"
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/types"
+)
+
+// RunPreflight checks that the server can answer queries and returns the
+// report: the audit trail, then oc, its login, cluster access and the RBAC
+// permissions of audit queries, or a query through the configured provider.
+// The extra checks, such as the development checks of the setup command,
+// run first.
+func (s *AuditQueryMCPServer) RunPreflight(extra ...preflight.Check) types.PreflightReport {
+	s.logger.Info("Running preflight checks")
+	checks := append([]preflight.Check(nil), extra...)
+	checks = append(checks, s.auditTrailCheck())
+	if s.provider == nil {
+		checks = append(checks, preflight.OcChecks(func(args []string) (string, error) {
+			return runOc(s.ocArgs(args))
+		})...)
+		checks = append(checks, s.permissionsCheck())
+	} else {
+		checks = append(checks, s.backendCheck())
+	}
+
+	report := preflight.Run(checks)
+	s.logger.Infof("Preflight %s: %s", report.Status, report.Summary)
+	return report
+}
+
+// auditTrailCheck checks that query executions are recorded
+func (s *AuditQueryMCPServer) auditTrailCheck() preflight.Check {
+	return preflight.Check{
+		Name:        "audit_trail",
+		Description: "Query executions are recorded in the audit trail",
+		Run: func() types.PreflightCheck {
+			if s.auditTrail == nil {
+				return preflight.Warn("the audit trail could not be opened, so queries are not recorded", "Check that ./logs is writable, or the syslog endpoint in AUDIT_SYSLOG_ADDRESS is reachable, and restart the server")
+			}
+			return preflight.Pass("recording to the audit trail")
+		},
+	}
+}
+
+// permissionsCheck checks the RBAC permissions audit queries need with
+// CheckPermissions
+func (s *AuditQueryMCPServer) permissionsCheck() preflight.Check {
+	return preflight.Check{
+		Name:        "audit_log_access",
+		Description: "The oc identity may read audit logs through the node proxy",
+		Requires:    []string{"oc_login"},
+		Run: func() types.PreflightCheck {
+			report, err := s.CheckPermissions()
+			if err != nil {
+				_, remediation := classifyError(err)
+				return preflight.Fail(err.Error(), remediation)
+			}
+			if !report.CanQuery {
+				detail := "cannot read audit logs: " + report.NodeLogsError
+				if len(report.Missing) > 0 {
+					detail = "missing permissions: " + strings.Join(report.Missing, ", ")
+				}
+				return preflight.Fail(detail, report.Remediation)
+			}
+			if len(report.Missing) > 0 {
+				return preflight.Warn("audit logs are readable; missing permissions: "+strings.Join(report.Missing, ", "), report.Remediation)
+			}
+			return preflight.Pass("audit logs are readable as " + report.Identity)
+		},
+	}
+}
+
+// backendCheck checks that the configured provider answers a query for the
+// last hour
+func (s *AuditQueryMCPServer) backendCheck() preflight.Check {
+	return preflight.Check{
+		Name:        "backend",
+		Description: "The audit log backend answers queries",
+		Run: func() types.PreflightCheck {
+			ctx, cancel := context.WithTimeout(context.Background(), providerFetchTimeout)
+			defer cancel()
+			params := types.AuditQueryParams{LogSource: "kube-apiserver", Timeframe: "1h", Limit: 1}
+			if _, err := s.provider.Execute(ctx, params); err != nil {
+				_, remediation := classifyError(err)
+				return preflight.Fail(fmt.Sprintf("the %s backend failed: %v", s.provider.Name(), err), remediation)
+			}
+			detail := fmt.Sprintf("the %s backend answered a query for the last hour", s.provider.Name())
+			if s.provider.Name() == providers.ProviderMock {
+				detail += "; it returns canned events, not the cluster's"
+			}
+			return preflight.Pass(detail)
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// preflightStatuses returns the status of each check of a report by name
+func preflightStatuses(report types.PreflightReport) map[string]types.PreflightStatus {
+	statuses := make(map[string]types.PreflightStatus, len(report.Checks))
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+// TestRunPreflight tests the oc checks, skipping those after a failed login
+func TestRunPreflight(t *testing.T) {
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+"version --client") echo "Client Version: 4.16.0" ;;
+whoami) echo "audit-reader" ;;
+"get nodes -o json") echo '{"items": [{}, {}, {}]}' ;;
+"auth can-i "*) echo "yes" ;;
+"adm node-logs --role=master --path=kube-apiserver/") printf 'master-0 audit.log\n' ;;
+*) echo "error: unexpected call" >&2; exit 1 ;;
+esac
+`)
+	server := NewAuditQueryMCPServer()
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "run_preflight", "arguments": map[string]interface{}{},
+	}})
+	require.Nil(t, response.Error)
+	report := response.Result.(*types.PreflightReport)
+	assert.Equal(t, types.PreflightPass, report.Status, report.Checks)
+	assert.Equal(t, "5 passed, 0 warned, 0 failed, 0 skipped", report.Summary)
+	assert.Equal(t, "3 nodes", report.Checks[3].Detail)
+	assert.Equal(t, "audit logs are readable as audit-reader", report.Checks[4].Detail)
+
+	installFakeOc(t, `#!/bin/sh
+case "$*" in
+"version --client") echo "Client Version: 4.16.0" ;;
+*) echo "error: You must be logged in to the server (Unauthorized)" >&2; exit 1 ;;
+esac
+`)
+	failed := server.RunPreflight()
+	assert.Equal(t, types.PreflightFail, failed.Status)
+	assert.Equal(t, map[string]types.PreflightStatus{
+		"audit_trail":      types.PreflightPass,
+		"oc_cli":           types.PreflightPass,
+		"oc_login":         types.PreflightFail,
+		"cluster_access":   types.PreflightSkip,
+		"audit_log_access": types.PreflightSkip,
+	}, preflightStatuses(failed))
+	assert.Equal(t, "Run oc login <cluster-url>", failed.Checks[2].Remediation)
+}
+
+// TestRunPreflight_Provider tests checking a configured backend instead of oc
+func TestRunPreflight_Provider(t *testing.T) {
+	server := newMockServer(t)
+	report := server.RunPreflight()
+	assert.Equal(t, types.PreflightPass, report.Status)
+	assert.Equal(t, map[string]types.PreflightStatus{
+		"audit_trail": types.PreflightPass,
+		"backend":     types.PreflightPass,
+	}, preflightStatuses(report))
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/types"
+)
+
+// ProgressFunc receives a long-running query's progress as a percentage with a
+// description of the current stage. Percentages only increase.
+type ProgressFunc func(percent float64, message string)
+
+// report calls the progress function when one is set
+func (p ProgressFunc) report(percent float64, message string) {
+	if p != nil {
+		p(percent, message)
+	}
+}
+
+// progressNotifier returns a ProgressFunc sending notifications/progress for the
+// request's progress token, or nil when the client did not ask for progress
+func (s *AuditQueryMCPServer) progressNotifier(request types.MCPRequest) ProgressFunc {
+	meta, _ := request.Params["_meta"].(map[string]interface{})
+	token, ok := meta["progressToken"]
+	if !ok || token == nil {
+		return nil
+	}
+	return func(percent float64, message string) {
+		s.notify("notifications/progress", map[string]interface{}{
+			"progressToken": token,
+			"progress":      percent,
+			"total":         100,
+			"message":       message,
+		})
+	}
+}
+
+// executingMessage describes the execution stage of a query: the number of log
+// files an oc command reads on the master nodes, or the backend fetching events
+func executingMessage(provider providers.QueryBackend, command string) string {
+	if provider != nil {
+		return fmt.Sprintf("Fetching events from the %s backend", provider.Name())
+	}
+	files := strings.Count(command, "--path=")
+	if files <= 1 {
+		return "Executing on 1 log file per master node"
+	}
+	return fmt.Sprintf("Executing on %d log files per master node", files)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressMessages calls a tool with a progress token and returns the progress
+// messages, checking that progress only increases and ends at 100
+func progressMessages(t *testing.T, server *AuditQueryMCPServer, tool string, arguments map[string]interface{}) []string {
+	t.Helper()
+	var notifications []types.MCPNotification
+	server.SetNotifier(func(notification types.MCPNotification) {
+		if notification.Method == "notifications/progress" {
+			notifications = append(notifications, notification)
+		}
+	})
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "progress", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name":      tool,
+		"arguments": arguments,
+		"_meta":     map[string]interface{}{"progressToken": "token-1"},
+	}})
+	require.Nil(t, response.Error)
+
+	var messages []string
+	last := -1.0
+	for _, notification := range notifications {
+		assert.Equal(t, "token-1", notification.Params["progressToken"])
+		assert.Equal(t, 100, notification.Params["total"])
+		percent := notification.Params["progress"].(float64)
+		assert.Greater(t, percent, last, "progress must increase")
+		last = percent
+		messages = append(messages, notification.Params["message"].(string))
+	}
+	assert.Equal(t, 100.0, last)
+	return messages
+}
+
+// TestProgressNotifications tests reporting query stages to clients that pass a progress token
+func TestProgressNotifications(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditEvents(t, file, time.Now().Add(-time.Hour), time.Now())
+	t.Setenv("AUDIT_PROVIDER", "kubernetes")
+	t.Setenv("AUDIT_K8S_AUDIT_FILE", file)
+	server := NewAuditQueryMCPServer()
+
+	arguments := map[string]interface{}{"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "verb": "delete"}}
+	assert.Equal(t, []string{
+		"Building command",
+		"Fetching events from the kubernetes backend",
+		"Filtering 3 lines",
+		"Parsing 2 lines",
+		"Summarizing 2 entries",
+		"Completed with 2 entries",
+	}, progressMessages(t, server, "execute_complete_audit_query", arguments))
+	assert.Equal(t, []string{"Building command", "Served from cache"}, progressMessages(t, server, "execute_complete_audit_query", arguments))
+
+	server.localFileDir = filepath.Dir(file)
+	messages := progressMessages(t, server, "analyze_local_audit_file", map[string]interface{}{"path": "audit.log"})
+	assert.Equal(t, "Listing audit log files", messages[0])
+	assert.Equal(t, "Reading file 1 of 1: audit.log", messages[1])
+
+	// Without a progress token no progress is sent
+	var notifications []types.MCPNotification
+	server.SetNotifier(func(notification types.MCPNotification) {
+		if notification.Method == "notifications/progress" {
+			notifications = append(notifications, notification)
+		}
+	})
+	server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "analyze_local_audit_file", "arguments": map[string]interface{}{"path": "audit.log"},
+	}})
+	assert.Empty(t, notifications)
+}
+
+// TestExecutingMessage tests describing the files an oc command reads
+func TestExecutingMessage(t *testing.T) {
+	assert.Equal(t, "Executing on 1 log file per master node", executingMessage(nil, "oc adm node-logs --role=master --path=kube-apiserver/audit.log"))
+	assert.Equal(t, "Executing on 2 log files per master node",
+		executingMessage(nil, "(oc adm node-logs --role=master --path=kube-apiserver/audit.log; oc adm node-logs --role=master --path=kube-apiserver/audit-1.log)"))
+}
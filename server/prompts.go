@@ -0,0 +1,495 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"audit-query-mcp-server/types"
+)
+
+// maxPromptArgumentLength bounds prompt argument values
+const maxPromptArgumentLength = 256
+
+// promptArgument is a prompt argument with the value used when it is omitted
+type promptArgument struct {
+	types.MCPPromptArgument
+	defaultValue string
+}
+
+// investigationPrompt is a canned investigation offered through prompts/list.
+// render turns the arguments, with defaults applied, into the instructions
+// sent to the model.
+type investigationPrompt struct {
+	name        string
+	description string
+	arguments   []promptArgument
+	render      func(args map[string]string) string
+}
+
+// required returns a required prompt argument
+func required(name, description string) promptArgument {
+	return promptArgument{MCPPromptArgument: types.MCPPromptArgument{Name: name, Description: description, Required: true}}
+}
+
+// optional returns an optional prompt argument with a default value
+func optional(name, description, defaultValue string) promptArgument {
+	if defaultValue != "" {
+		description = fmt.Sprintf("%s (default: %s)", description, defaultValue)
+	}
+	return promptArgument{MCPPromptArgument: types.MCPPromptArgument{Name: name, Description: description}, defaultValue: defaultValue}
+}
+
+// timeframeArgument returns the optional timeframe argument most prompts take
+func timeframeArgument(defaultValue string) promptArgument {
+	return optional("timeframe", "Timeframe to search, such as today, 24h, 7d or last week", defaultValue)
+}
+
+// structuredParams renders query parameters as the structured_params JSON of a
+// tool call, leaving out empty values
+func structuredParams(params map[string]interface{}) string {
+	for key, value := range params {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				delete(params, key)
+			}
+		case []string:
+			if len(v) == 0 {
+				delete(params, key)
+			}
+		}
+	}
+	data, _ := json.Marshal(params)
+	return string(data)
+}
+
+// splitList splits a comma-separated prompt argument into its trimmed values
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// investigationPrompts are the natural-language investigation patterns from
+// the product requirements, one prompt per pattern
+var investigationPrompts = []investigationPrompt{
+	// 1.1 "Who deleted the customer CRD?"
+	{
+		name:        "who-deleted-resource",
+		description: "Find who deleted a resource, and from where",
+		arguments: []promptArgument{
+			required("resource", "Resource type, such as customresourcedefinitions or secrets"),
+			optional("name", "Name of the deleted object", ""),
+			optional("namespace", "Namespace of the deleted object", ""),
+			timeframeArgument("7d"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Find out who deleted %s%s%s during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"For each deletion, report the user, any impersonated user, the source IPs, the user agent, the time and the response code, "+
+				"and say whether the deletion succeeded.",
+				args["resource"], describeName(args["name"]), describeNamespace(args["namespace"]), args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"verb": "delete", "resource": args["resource"], "namespace": args["namespace"],
+					"patterns": splitList(args["name"]), "timeframe": args["timeframe"],
+				}))
+		},
+	},
+	// 1.2 "Show me all actions by user john.doe today"
+	{
+		name:        "user-activity",
+		description: "Show everything a user did, as a timeline",
+		arguments: []promptArgument{
+			required("username", "User to investigate, such as john.doe or system:serviceaccount:ns:name"),
+			timeframeArgument("today"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show all actions by user %s during %s.\n\n"+
+				"Call build_user_timeline with username %q and structured_params %s. "+
+				"Summarize the timeline: what the user changed, which namespaces and resources they touched, bursts of repeated operations, "+
+				"idle gaps and any failed requests.",
+				args["username"], args["timeframe"], args["username"],
+				structuredParams(map[string]interface{}{"timeframe": args["timeframe"]}))
+		},
+	},
+	// 1.3 "List all failed authentication attempts in the last hour"
+	{
+		name:        "failed-authentication",
+		description: "List failed authentication attempts against the OAuth server",
+		arguments: []promptArgument{
+			timeframeArgument("last hour"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("List all failed authentication attempts during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"Group the failures by username and source IP, and point out users or addresses with many failures, "+
+				"which may indicate password guessing.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{"log_source": "oauth-server", "status_code_range": "4xx", "timeframe": args["timeframe"]}))
+		},
+	},
+	// 2.1 "Find all CustomResourceDefinition modifications this week"
+	{
+		name:        "crd-modifications",
+		description: "Find CustomResourceDefinition creations, updates and deletions",
+		arguments: []promptArgument{
+			timeframeArgument("this week"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Find all CustomResourceDefinition modifications during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"List each change with the CRD name, the verb, the user and the time, and call out deletions, "+
+				"since deleting a CRD deletes every custom resource of that type.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"resource": "customresourcedefinitions", "verbs": []string{"create", "update", "patch", "delete"}, "timeframe": args["timeframe"],
+				}))
+		},
+	},
+	// 2.2 "Show me all namespace deletions by non-system users"
+	{
+		name:        "namespace-deletions",
+		description: "Show namespace deletions by users other than system components",
+		arguments: []promptArgument{
+			timeframeArgument("7d"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show all namespace deletions by non-system users during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"For each deletion, report the namespace, the user, the source IPs and the time.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"verb": "delete", "resource": "namespaces", "exclude_users": []string{"system:*"}, "timeframe": args["timeframe"],
+				}))
+		},
+	},
+	// 2.3 "Who created or modified ClusterRoles in the security namespace?"
+	{
+		name:        "rbac-role-changes",
+		description: "Find who created or modified ClusterRoles, and Roles in a namespace",
+		arguments: []promptArgument{
+			optional("namespace", "Namespace whose Roles and RoleBindings to include", ""),
+			timeframeArgument("7d"),
+		},
+		render: func(args map[string]string) string {
+			text := fmt.Sprintf("Find who created or modified ClusterRoles and ClusterRoleBindings during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"resources": []string{"clusterroles", "clusterrolebindings"}, "verbs": []string{"create", "update", "patch"},
+					"exclude_users": []string{"system:*"}, "timeframe": args["timeframe"],
+				}))
+			if args["namespace"] != "" {
+				text += fmt.Sprintf(" Then call it again with structured_params %s for the Roles and RoleBindings in namespace %s.",
+					structuredParams(map[string]interface{}{
+						"resources": []string{"roles", "rolebindings"}, "verbs": []string{"create", "update", "patch"},
+						"namespace": args["namespace"], "timeframe": args["timeframe"],
+					}), args["namespace"])
+			}
+			return text + " For each change, report the role, the user and the time, and highlight roles granting wildcard verbs or resources."
+		},
+	},
+	// 3.1 "Find potential privilege escalation attempts with failed permissions"
+	{
+		name:        "privilege-escalation",
+		description: "Find permission denials that look like privilege escalation attempts",
+		arguments: []promptArgument{
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Find potential privilege escalation attempts during %s.\n\n"+
+				"Call find_permission_denials with structured_params %s. "+
+				"Focus on the denials it flags as likely escalation attempts: requests for RBAC resources, secrets, impersonation or pods/exec. "+
+				"For the users involved, call execute_complete_audit_query with their username to check whether any related request later succeeded.",
+				args["timeframe"], structuredParams(map[string]interface{}{"timeframe": args["timeframe"]}))
+		},
+	},
+	// 3.2 "Show unusual API access patterns outside business hours"
+	{
+		name:        "after-hours-access",
+		description: "Show API access by people outside business hours",
+		arguments: []promptArgument{
+			optional("business_hours", "Business hours in UTC, as HH:MM-HH:MM", "08:00-18:00"),
+			timeframeArgument("7d"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show unusual API access outside business hours (%s UTC) during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s to see when activity happened, "+
+				"then call it again with output_mode entries for the hours outside %s. "+
+				"Report the users active outside business hours, what they changed and whether that is normal for them.",
+				args["business_hours"], args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"exclude_users": []string{"system:*"}, "output_mode": "histogram", "bucket_size": "hour", "timeframe": args["timeframe"],
+				}), args["business_hours"])
+		},
+	},
+	// 4.1 "Correlate CRD deletions with subsequent pod creation failures"
+	{
+		name:        "crd-deletion-impact",
+		description: "Correlate CustomResourceDefinition deletions with later pod creation failures",
+		arguments: []promptArgument{
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Correlate CustomResourceDefinition deletions with subsequent pod creation failures during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s to find the deletions, "+
+				"then with structured_params %s to find failed pod creations. "+
+				"Match failures that started after a deletion, in namespaces that used the deleted type, and report each deletion with the failures that followed it.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{"verb": "delete", "resource": "customresourcedefinitions", "timeframe": args["timeframe"]}),
+				structuredParams(map[string]interface{}{"verb": "create", "resource": "pods", "status_code_range": "4xx", "timeframe": args["timeframe"]}))
+		},
+	},
+	// 4.2 "Find coordinated attacks: multiple failed authentications followed by successful privilege escalation"
+	{
+		name:        "coordinated-attack",
+		description: "Find failed authentications followed by successful privilege escalation",
+		arguments: []promptArgument{
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Look for coordinated attacks during %s: multiple failed authentications followed by successful privilege escalation.\n\n"+
+				"1. Call execute_complete_audit_query with structured_params %s to find failed logins.\n"+
+				"2. Call execute_complete_audit_query with structured_params %s to find new role bindings.\n"+
+				"3. Call find_permission_denials with structured_params %s.\n\n"+
+				"Report users or source IPs that appear in the failed logins and then created role bindings or were denied and later succeeded, in time order.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{"log_source": "oauth-server", "status_code_range": "4xx", "timeframe": args["timeframe"]}),
+				structuredParams(map[string]interface{}{
+					"resources": []string{"rolebindings", "clusterrolebindings"}, "verb": "create", "status_code_range": "2xx", "timeframe": args["timeframe"],
+				}),
+				structuredParams(map[string]interface{}{"timeframe": args["timeframe"]}))
+		},
+	},
+	// 5.1 "Show me all admin activities during the maintenance window last Tuesday"
+	{
+		name:        "maintenance-window-admin",
+		description: "Show cluster administrator activity during a maintenance window",
+		arguments: []promptArgument{
+			required("window", "Maintenance window, such as \"last Tuesday 22:00-02:00 UTC\""),
+			optional("admin_group", "Group of the administrators", "system:cluster-admins"),
+			timeframeArgument("7d"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show all admin activities during the maintenance window %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s, and again with structured_params %s for the kubeadmin user. "+
+				"Keep only events inside the window and summarize what each administrator changed, in time order.",
+				args["window"],
+				structuredParams(map[string]interface{}{"groups": []string{args["admin_group"]}, "timeframe": args["timeframe"]}),
+				structuredParams(map[string]interface{}{"username": "kube:admin", "timeframe": args["timeframe"]}))
+		},
+	},
+	// 5.2 "Find API calls that happened between 2 AM and 4 AM this week"
+	{
+		name:        "calls-in-time-window",
+		description: "Find API calls made between two times of day",
+		arguments: []promptArgument{
+			optional("start", "Start of the daily window in UTC, as HH:MM", "02:00"),
+			optional("end", "End of the daily window in UTC, as HH:MM", "04:00"),
+			timeframeArgument("this week"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Find API calls made between %s and %s UTC on any day during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s, "+
+				"keep the events whose requestReceivedTimestamp falls between %s and %s, "+
+				"and summarize them by user, verb and resource.",
+				args["start"], args["end"], args["timeframe"],
+				structuredParams(map[string]interface{}{"exclude_users": []string{"system:*"}, "timeframe": args["timeframe"]}),
+				args["start"], args["end"])
+		},
+	},
+	// 6.1 "Which users accessed both the database and customer service namespaces?"
+	{
+		name:        "users-in-both-namespaces",
+		description: "Find users who accessed both of two namespaces",
+		arguments: []promptArgument{
+			required("first_namespace", "First namespace, such as database"),
+			required("second_namespace", "Second namespace, such as customer-service"),
+			timeframeArgument("7d"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Find the users who accessed both namespace %s and namespace %s during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"List the users with events in both namespaces, with what they did in each.",
+				args["first_namespace"], args["second_namespace"], args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"namespaces": []string{args["first_namespace"], args["second_namespace"]}, "timeframe": args["timeframe"],
+				}))
+		},
+	},
+	// 6.2 "Show me pod deletions followed by immediate recreations by the same user"
+	{
+		name:        "pod-delete-recreate",
+		description: "Find pods deleted and then quickly recreated by the same user",
+		arguments: []promptArgument{
+			optional("window", "How soon a recreation counts as immediate", "5m"),
+			optional("namespace", "Namespace to search", ""),
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show pod deletions followed within %s by a recreation by the same user%s during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"Pair each delete with a create of the same pod name, or the same name prefix for generated names, by the same user within %s, "+
+				"and report the pairs.",
+				args["window"], describeNamespace(args["namespace"]), args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"resource": "pods", "verbs": []string{"delete", "create"}, "namespace": args["namespace"], "timeframe": args["timeframe"],
+				}), args["window"])
+		},
+	},
+	// 7.1 "Identify users with unusual API access patterns compared to their baseline"
+	{
+		name:        "unusual-user-activity",
+		description: "Compare recent activity with a baseline period to find unusual behaviour",
+		arguments: []promptArgument{
+			optional("username", "User to compare; all non-system users when omitted", ""),
+			optional("baseline", "Baseline timeframe", "last week"),
+			optional("comparison", "Timeframe to compare with the baseline", "today"),
+		},
+		render: func(args map[string]string) string {
+			params := map[string]interface{}{"username": args["username"]}
+			if args["username"] == "" {
+				params["exclude_users"] = []string{"system:*"}
+			}
+			return fmt.Sprintf("Identify unusual API access %s compared with the baseline %s.\n\n"+
+				"Call compare_audit_activity with compare_by \"timeframe\", baseline %q, comparison %q and structured_params %s. "+
+				"Explain which new resources, namespaces and source IPs appeared and which verbs changed in frequency, and which of them look suspicious.",
+				describeComparison(args["username"], args["comparison"]), args["baseline"], args["baseline"], args["comparison"], structuredParams(params))
+		},
+	},
+	// 7.2 "Show me service accounts being used from unexpected IP addresses"
+	{
+		name:        "service-account-unexpected-ips",
+		description: "Find service accounts used from outside the cluster network",
+		arguments: []promptArgument{
+			optional("expected_cidrs", "Comma-separated networks service accounts should call from", "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16"),
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show service accounts used from unexpected IP addresses during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s and classify_sources true. "+
+				"List the source IPs each service account used and report those outside %s and those classified as external, with what the service account did from them.",
+				args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"username": "system:serviceaccount:", "username_match": "prefix", "timeframe": args["timeframe"],
+				}), args["expected_cidrs"])
+		},
+	},
+	// 8.1 "Correlate resource deletion events with subsequent access attempts to those resources"
+	{
+		name:        "deleted-resource-access",
+		description: "Correlate deletions with later attempts to access the deleted objects",
+		arguments: []promptArgument{
+			required("resource", "Resource type, such as secrets or configmaps"),
+			optional("namespace", "Namespace to search", ""),
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Correlate %s deletions%s during %s with later attempts to access the deleted objects.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s to find the deletions, "+
+				"then with structured_params %s to find failed accesses. "+
+				"Match accesses to the same object names after each deletion, and report who kept using the deleted objects.",
+				args["resource"], describeNamespace(args["namespace"]), args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"verb": "delete", "resource": args["resource"], "namespace": args["namespace"], "timeframe": args["timeframe"],
+				}),
+				structuredParams(map[string]interface{}{
+					"resource": args["resource"], "namespace": args["namespace"], "status_code": 404, "timeframe": args["timeframe"],
+				}))
+		},
+	},
+	// 8.2 "Show me users who accessed multiple sensitive namespaces within a short time window"
+	{
+		name:        "sensitive-namespace-hopping",
+		description: "Find users who accessed several sensitive namespaces in a short time",
+		arguments: []promptArgument{
+			required("namespaces", "Comma-separated sensitive namespaces"),
+			optional("window", "Time window", "15m"),
+			timeframeArgument("24h"),
+		},
+		render: func(args map[string]string) string {
+			return fmt.Sprintf("Show users who accessed more than one of the namespaces %s within %s during %s.\n\n"+
+				"Call execute_complete_audit_query with structured_params %s. "+
+				"For each user, find %s windows with events in two or more of these namespaces and report them with the resources accessed.",
+				args["namespaces"], args["window"], args["timeframe"],
+				structuredParams(map[string]interface{}{
+					"namespaces": splitList(args["namespaces"]), "exclude_users": []string{"system:*"}, "timeframe": args["timeframe"],
+				}), args["window"])
+		},
+	},
+}
+
+// describeName returns " named <name>", or nothing without a name
+func describeName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " named " + name
+}
+
+// describeNamespace returns " in namespace <namespace>", or nothing without a namespace
+func describeNamespace(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return " in namespace " + namespace
+}
+
+// describeComparison describes whose activity during which timeframe is compared
+func describeComparison(username, timeframe string) string {
+	if username == "" {
+		return "by users during " + timeframe
+	}
+	return "by " + username + " during " + timeframe
+}
+
+// GetPrompts returns the investigation prompts in MCP form
+func (s *AuditQueryMCPServer) GetPrompts() []types.MCPPrompt {
+	prompts := make([]types.MCPPrompt, 0, len(investigationPrompts))
+	for _, prompt := range investigationPrompts {
+		arguments := make([]types.MCPPromptArgument, 0, len(prompt.arguments))
+		for _, argument := range prompt.arguments {
+			arguments = append(arguments, argument.MCPPromptArgument)
+		}
+		prompts = append(prompts, types.MCPPrompt{Name: prompt.name, Description: prompt.description, Arguments: arguments})
+	}
+	return prompts
+}
+
+// GetPrompt renders the named investigation prompt with the given arguments,
+// applying defaults for omitted optional arguments
+func (s *AuditQueryMCPServer) GetPrompt(name string, args map[string]string) (string, []types.MCPPromptMessage, error) {
+	for _, prompt := range investigationPrompts {
+		if prompt.name != name {
+			continue
+		}
+
+		values := make(map[string]string, len(prompt.arguments))
+		for _, argument := range prompt.arguments {
+			value := strings.TrimSpace(args[argument.Name])
+			if len(value) > maxPromptArgumentLength || strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				return "", nil, fmt.Errorf("invalid value for argument %s", argument.Name)
+			}
+			if value == "" {
+				if argument.Required {
+					return "", nil, fmt.Errorf("missing required argument: %s", argument.Name)
+				}
+				value = argument.defaultValue
+			}
+			values[argument.Name] = value
+		}
+
+		messages := []types.MCPPromptMessage{{
+			Role:    "user",
+			Content: types.MCPPromptContent{Type: "text", Text: prompt.render(values)},
+		}}
+		return prompt.description, messages, nil
+	}
+	return "", nil, fmt.Errorf("unknown prompt: %s", name)
+}
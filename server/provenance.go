@@ -0,0 +1,70 @@
+package server
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+)
+
+// provenanceFor records where the output of command was read: the log files
+// it names and, for oc commands, the API server, the nodes of the role it
+// reads and the identity oc runs as. Cluster lookups are cached like
+// enrichment lookups; a failed lookup leaves its field empty rather than
+// failing the query.
+func (s *AuditQueryMCPServer) provenanceFor(command string) *types.ResultProvenance {
+	provenance := &types.ResultProvenance{
+		LogFiles:      commands.CommandLogPaths(command),
+		ServerVersion: ServerVersion,
+		CommandHash:   commands.CommandHash(command),
+	}
+	provenance.ServerHost, _ = os.Hostname()
+	if !strings.HasPrefix(command, "oc ") && !strings.HasPrefix(command, "(oc ") {
+		return provenance
+	}
+
+	provenance.ClusterAPIURL = s.provenanceLookup(commands.ServerURLArgs())
+	provenance.Identity = s.provenanceLookup(commands.WhoAmIArgs())
+	if provenance.NodeRole = commands.CommandNodeRole(command); provenance.NodeRole != "" {
+		provenance.Nodes = commands.ParseNodeNames(s.provenanceLookup(commands.RoleNodesArgs(provenance.NodeRole)))
+	}
+	return provenance
+}
+
+// localProvenance records the local audit log files a result was read from
+func localProvenance(command string, files []string) *types.ResultProvenance {
+	provenance := &types.ResultProvenance{
+		LogFiles:      append([]string(nil), files...),
+		ServerVersion: ServerVersion,
+		CommandHash:   commands.CommandHash(command),
+	}
+	provenance.ServerHost, _ = os.Hostname()
+	return provenance
+}
+
+// provenanceLookup runs a read-only oc command on the server's cluster and
+// returns its trimmed output, or "" when it fails. Outputs and failures are
+// kept for the enrichment TTL, so queries do not repeat the lookups.
+func (s *AuditQueryMCPServer) provenanceLookup(args []string) string {
+	key := "provenance " + strings.Join(args, " ")
+	s.lookupCacheMutex.Lock()
+	cached, ok := s.lookupCache[key]
+	s.lookupCacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.output
+	}
+
+	output, err := s.clusterLookup(s.ocArgs(args))
+	if err != nil {
+		s.logger.Warnf("Provenance lookup oc %s failed: %v", strings.Join(args, " "), err)
+		output = ""
+	}
+	output = strings.TrimSpace(output)
+
+	s.lookupCacheMutex.Lock()
+	s.lookupCache[key] = cachedLookup{output: output, expires: time.Now().Add(s.enrichmentTTL)}
+	s.lookupCacheMutex.Unlock()
+	return output
+}
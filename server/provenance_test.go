@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProvenanceFor tests the cluster lookups recorded for oc commands and their caching
+func TestProvenanceFor(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	calls := 0
+	server.clusterLookup = func(args []string) (string, error) {
+		calls++
+		switch strings.Join(args, " ") {
+		case "whoami --show-server":
+			return "https://api.prod.example.com:6443\n", nil
+		case "whoami":
+			return "alice\n", nil
+		case "get nodes -l node-role.kubernetes.io/master -o name":
+			return "node/master-0\nnode/master-1\n", nil
+		}
+		return "", errors.New("unexpected lookup")
+	}
+
+	command := "oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i delete"
+	provenance := server.provenanceFor(command)
+	assert.Equal(t, &types.ResultProvenance{
+		ClusterAPIURL: "https://api.prod.example.com:6443",
+		NodeRole:      "master",
+		Nodes:         []string{"master-0", "master-1"},
+		LogFiles:      []string{"kube-apiserver/audit.log"},
+		Identity:      "alice",
+		ServerVersion: ServerVersion,
+		ServerHost:    provenance.ServerHost,
+		CommandHash:   commands.CommandHash(command),
+	}, provenance)
+	assert.Equal(t, 3, calls)
+
+	// Lookups are reused by later queries
+	server.provenanceFor(command)
+	assert.Equal(t, 3, calls)
+}
+
+// TestProvenanceFor_FailedLookups tests that failed lookups leave fields empty
+func TestProvenanceFor_FailedLookups(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	server.clusterLookup = func(args []string) (string, error) {
+		return "error: You must be logged in to the server (Unauthorized)", errors.New("exit status 1")
+	}
+
+	provenance := server.provenanceFor("oc adm node-logs --role=master --path=oauth-server/audit.log")
+	assert.Empty(t, provenance.ClusterAPIURL)
+	assert.Empty(t, provenance.Identity)
+	assert.Empty(t, provenance.Nodes)
+	assert.Equal(t, []string{"oauth-server/audit.log"}, provenance.LogFiles)
+}
+
+// TestExecuteCompleteAuditQuery_Provenance tests the provenance of results read by a provider
+func TestExecuteCompleteAuditQuery_Provenance(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(
+		`{"kind":"Event","stage":"ResponseComplete","auditID":"a1","verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200}}`+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+	server.clusterLookup = func(args []string) (string, error) {
+		t.Errorf("Unexpected cluster lookup for a provider query: %v", args)
+		return "", nil
+	}
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Provenance)
+	assert.Equal(t, commands.CommandHash(result.Command), result.Provenance.CommandHash)
+	assert.Equal(t, ServerVersion, result.Provenance.ServerVersion)
+	assert.Empty(t, result.Provenance.Identity)
+}
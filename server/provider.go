@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// Provider execution limits
+const (
+	// providerFetchTimeout bounds fetching audit events through a provider
+	providerFetchTimeout = 30 * time.Second
+	// maxProviderCommands bounds the remembered provider commands
+	maxProviderCommands = 1000
+)
+
+// providerQuery is a backend command and the query it fetches events for
+type providerQuery struct {
+	provider providers.QueryBackend
+	params   types.AuditQueryParams
+}
+
+// ProviderName returns the default backend the server reads audit logs from
+func (s *AuditQueryMCPServer) ProviderName() string {
+	if s.provider == nil {
+		return providers.ProviderOpenShift
+	}
+	return s.provider.Name()
+}
+
+// BackendCapabilities returns the capabilities of every backend queries can
+// select, by name
+func (s *AuditQueryMCPServer) BackendCapabilities() map[string]providers.Capabilities {
+	capabilities := map[string]providers.Capabilities{
+		// oc adm node-logs reads whole log files; the date filter and, unless
+		// filtering runs in-process, the query filters run in the jq pipeline
+		providers.ProviderOpenShift: {
+			LogSources:        utils.ValidLogSources,
+			ServerSideFilters: !s.inProcessFiltering,
+		},
+	}
+	for name, backend := range s.backends {
+		capabilities[name] = backend.Capabilities()
+	}
+	return capabilities
+}
+
+// backendName returns the name of a query backend, where nil is OpenShift
+func backendName(provider providers.QueryBackend) string {
+	if provider == nil {
+		return providers.ProviderOpenShift
+	}
+	return provider.Name()
+}
+
+// providerFor returns the provider that fetches events for params: the backend
+// the query selects, or the default. A nil provider means oc commands.
+func (s *AuditQueryMCPServer) providerFor(params types.AuditQueryParams) (providers.QueryBackend, error) {
+	switch params.Backend {
+	case "":
+		return s.provider, nil
+	case providers.ProviderOpenShift:
+		return nil, nil
+	}
+	if provider, ok := s.backends[params.Backend]; ok {
+		return provider, nil
+	}
+	return nil, fmt.Errorf("backend %s is not configured", params.Backend)
+}
+
+// filtersInProcess reports whether commands only fetch raw logs, with every
+// filter applied in Go after execution
+func (s *AuditQueryMCPServer) filtersInProcess(provider providers.QueryBackend) bool {
+	return s.inProcessFiltering || provider != nil
+}
+
+// rememberProviderCommand records the query a provider command was generated
+// for, so executing the command fetches the same events
+func (s *AuditQueryMCPServer) rememberProviderCommand(command string, provider providers.QueryBackend, params types.AuditQueryParams) {
+	s.providerCommandsMutex.Lock()
+	defer s.providerCommandsMutex.Unlock()
+
+	if len(s.providerCommands) >= maxProviderCommands {
+		s.providerCommands = make(map[string]providerQuery)
+	}
+	s.providerCommands[command] = providerQuery{provider: provider, params: params}
+}
+
+// lookupProviderCommand returns the query a provider command was generated for
+func (s *AuditQueryMCPServer) lookupProviderCommand(command string) (providerQuery, bool) {
+	s.providerCommandsMutex.Lock()
+	defer s.providerCommandsMutex.Unlock()
+
+	query, ok := s.providerCommands[command]
+	return query, ok
+}
+
+// executeProviderCommand fetches the events for a provider command within
+// queryCtx, the context of its tracked execution
+func (s *AuditQueryMCPServer) executeProviderCommand(queryCtx context.Context, query providerQuery, result *types.AuditResult) error {
+	timeout := providerFetchTimeout
+	if slow, ok := query.provider.(providers.TimeoutBackend); ok {
+		timeout = slow.ExecuteTimeout()
+	}
+	ctx, cancel := context.WithTimeout(queryCtx, timeout)
+	defer cancel()
+
+	output, err := query.provider.Execute(ctx, query.params)
+	if queryCancelled(queryCtx) {
+		return fmt.Errorf("command execution cancelled by an operator")
+	}
+	if err != nil {
+		s.circuit.RecordFailure()
+		return fmt.Errorf("command execution failed: %w", err)
+	}
+	s.circuit.RecordSuccess()
+	result.RawOutput = output
+	return nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKubernetesProvider tests running queries against a Kubernetes audit file
+func TestKubernetesProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	now := time.Now()
+	writeAuditEvents(t, file, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	t.Setenv("AUDIT_PROVIDER", "kubernetes")
+	t.Setenv("AUDIT_K8S_AUDIT_FILE", file)
+	server := NewAuditQueryMCPServer()
+	assert.Equal(t, "kubernetes", server.GetServerStats()["provider"])
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Username: "alice", Verb: "delete"})
+	require.NoError(t, err)
+	assert.Equal(t, "read "+file, result.Command)
+	assert.Equal(t, 2, result.TotalEntries)
+	assert.Equal(t, "kubernetes", result.Backend)
+
+	// Analysis tools read events through the backend too
+	_, denials, err := server.FindPermissionDenials(types.AuditQueryParams{LogSource: "kube-apiserver"})
+	require.NoError(t, err)
+	assert.Equal(t, "read "+file, denials.Command)
+	assert.Equal(t, "kubernetes", denials.Backend)
+
+	result, err = server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Username: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.TotalEntries)
+
+	// OpenShift-only log sources are reported as unavailable
+	_, err = server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "oauth-server"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not available on Kubernetes clusters")
+	assert.False(t, server.CheckLogSourceAvailability("oauth-server", true).Available)
+	assert.True(t, server.CheckLogSourceAvailability("kube-apiserver", true).Available)
+
+	// Only commands the provider generated can be executed
+	executeResult, err := server.ExecuteAuditQueryWithResult("read "+file, "q1")
+	require.NoError(t, err)
+	assert.Contains(t, executeResult.RawOutput, "alice")
+	_, err = server.ExecuteAuditQueryWithResult("read /etc/passwd", "q2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not generated by the kubernetes provider")
+}
+
+// TestLokiBackend tests selecting the Loki backend per query
+func TestLokiBackend(t *testing.T) {
+	var queries []string
+	loki := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		timestamp := strconv.FormatInt(time.Now().Add(-time.Hour).UnixNano(), 10)
+		event := fmt.Sprintf(`{"auditID":"l1","stage":"ResponseComplete","requestReceivedTimestamp":%q,"verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"secrets","namespace":"default"},"responseStatus":{"code":200}}`,
+			time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano))
+		body, _ := json.Marshal(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": "streams",
+				"result":     []interface{}{map[string]interface{}{"values": [][2]string{{timestamp, event}}}},
+			},
+		})
+		w.Write(body)
+	}))
+	defer loki.Close()
+	t.Setenv("AUDIT_PROVIDER", "")
+	t.Setenv("AUDIT_LOKI_URL", loki.URL)
+	server := NewAuditQueryMCPServer()
+	assert.Equal(t, "openshift", server.GetServerStats()["provider"])
+	backends := server.GetServerStats()["backends"].(map[string]providers.Capabilities)
+	assert.Len(t, backends, 2)
+	assert.True(t, backends["loki"].TimeRange)
+	assert.Equal(t, utils.ValidLogSources, backends["openshift"].LogSources)
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Username: "alice", Backend: "loki"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result.Command, "loki query_range {log_type=\"audit\"} |~ `(?i)(alice)`"))
+	assert.Equal(t, 1, result.TotalEntries)
+	assert.Equal(t, "loki", result.Backend)
+	require.Len(t, queries, 1)
+
+	// The exact filters are applied to the fetched events
+	result, err = server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "get", Backend: "loki"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.TotalEntries)
+
+	_, err = server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Backend: "kubernetes"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backend kubernetes is not configured")
+}
+
+// TestElasticsearchBackend tests querying Elasticsearch as the default backend
+func TestElasticsearchBackend(t *testing.T) {
+	var searches int
+	elasticsearch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		event := fmt.Sprintf(`{"auditID":"e1","stage":"ResponseComplete","requestReceivedTimestamp":%q,"verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"secrets","namespace":"default"},"responseStatus":{"code":200}}`,
+			time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano))
+		fmt.Fprintf(w, `{"hits":{"hits":[{"_source":%s,"sort":[1,"e1"]}]}}`, event)
+	}))
+	defer elasticsearch.Close()
+	t.Setenv("AUDIT_PROVIDER", "elasticsearch")
+	t.Setenv("AUDIT_LOKI_URL", "")
+	t.Setenv("AUDIT_ES_URL", elasticsearch.URL)
+	server := NewAuditQueryMCPServer()
+	assert.Equal(t, "elasticsearch", server.GetServerStats()["provider"])
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Username: "alice"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result.Command, "elasticsearch search audit-* "))
+	assert.Equal(t, 1, result.TotalEntries)
+	assert.Equal(t, 1, searches)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildRBACChangeReport tests consolidating binding changes read from response bodies
+func TestBuildRBACChangeReport(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	event := func(minutesAgo int, verb, subject string) string {
+		return fmt.Sprintf(`{"kind":"Event","level":"RequestResponse","stage":"ResponseComplete","auditID":"rbac-%d","requestReceivedTimestamp":%q,"verb":%q,"user":{"username":"mallory"},"objectRef":{"resource":"rolebindings","namespace":"prod","name":"ops","apiGroup":"rbac.authorization.k8s.io"},"responseStatus":{"code":200},"responseObject":{"roleRef":{"kind":"ClusterRole","name":"admin"},"subjects":[{"kind":"User","name":%q}]}}`,
+			minutesAgo, now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), verb, subject)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event(30, "create", "alice"),
+		event(20, "update", "bob"),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleRBACChangeReport("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.RBACChangeReport)
+	assert.Equal(t, 2, report.TotalChanges)
+	require.Len(t, report.Subjects, 1)
+	assert.Equal(t, "bob", report.Subjects[0].Name)
+	assert.Equal(t, []types.RBACGrant{{RoleKind: "ClusterRole", RoleName: "admin", Namespace: "prod", Binding: "rolebinding prod/ops"}}, report.Subjects[0].Gained)
+	assert.Contains(t, report.Summary, "User bob gained ClusterRole/admin in prod")
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"audit-query-mcp-server/types"
+)
+
+// DefaultSlowQueryThreshold is the duration from which requests are written
+// to the slow-query log unless AUDIT_SLOW_QUERY_THRESHOLD is set
+const DefaultSlowQueryThreshold = 10 * time.Second
+
+// DefaultSlowQueryLogFile is where slow requests are logged unless
+// AUDIT_SLOW_QUERY_LOG is set
+const DefaultSlowQueryLogFile = "./logs/slow_queries.json"
+
+// Request outcomes recorded by the request middleware
+const (
+	RequestOutcomeOK    = "ok"
+	RequestOutcomeError = "error"
+)
+
+// requestStats are the counters of the requests handled for one method or
+// tool
+type requestStats struct {
+	count       int64
+	errors      int64
+	slow        int64
+	total       time.Duration
+	max         time.Duration
+	resultBytes int64
+}
+
+// requestMetrics aggregates requestStats by method, and by tool for tool
+// calls
+type requestMetrics struct {
+	mutex sync.Mutex
+	stats map[string]*requestStats
+}
+
+// HandleMCPRequest handles incoming MCP requests. Notifications, which
+// IsNotification reports, return an empty MCPResponse that transports must not
+// send. Every request is logged with its method, tool, duration, result size
+// and outcome; those taking at least the slow-query threshold are also
+// written to the slow-query log.
+func (s *AuditQueryMCPServer) HandleMCPRequest(request types.MCPRequest) types.MCPResponse {
+	start := time.Now()
+	response := s.handleMCPRequest(request)
+	if !request.IsNotification() {
+		s.recordRequest(request, response, time.Since(start))
+	}
+	return response
+}
+
+// recordRequest logs a handled request, counts it and writes it to the
+// slow-query log when it took at least the threshold
+func (s *AuditQueryMCPServer) recordRequest(request types.MCPRequest, response types.MCPResponse, duration time.Duration) {
+	entry := types.RequestLogEntry{
+		Timestamp:  time.Now().Add(-duration),
+		RequestID:  request.ID,
+		Method:     request.Method,
+		DurationMs: duration.Milliseconds(),
+		Outcome:    RequestOutcomeOK,
+	}
+	if request.Method == "tools/call" {
+		entry.Tool, _ = request.Params["name"].(string)
+	}
+	if response.Result != nil {
+		if data, err := json.Marshal(response.Result); err == nil {
+			entry.ResultBytes = len(data)
+		}
+	}
+	if response.Error != nil {
+		entry.Outcome = RequestOutcomeError
+		entry.ErrorCode = response.Error.Code
+		if response.Error.Data != nil {
+			entry.ErrorType = response.Error.Data.Type
+		}
+	}
+
+	fields := logrus.Fields{
+		"method":       entry.Method,
+		"duration_ms":  entry.DurationMs,
+		"result_bytes": entry.ResultBytes,
+		"outcome":      entry.Outcome,
+	}
+	if entry.Tool != "" {
+		fields["tool"] = entry.Tool
+	}
+	if entry.ErrorCode != 0 {
+		fields["error_code"] = entry.ErrorCode
+	}
+	s.logger.WithFields(fields).Info("Handled MCP request")
+
+	slow := false
+	if s.slowQueries != nil && duration >= s.slowQueries.Threshold() {
+		// The arguments show which investigation made the slow request
+		if arguments, ok := request.Params["arguments"].(map[string]interface{}); ok {
+			entry.Arguments = arguments
+			entry.Cluster, _ = arguments["cluster"].(string)
+		}
+		if _, err := s.slowQueries.Record(entry, duration); err != nil {
+			s.logger.Warnf("Failed to write slow query log: %v", err)
+		} else {
+			slow = true
+			s.logger.WithFields(fields).Warnf("Slow MCP request took %v", duration.Round(time.Millisecond))
+		}
+	}
+	s.requests.add(entry, duration, slow)
+}
+
+// add counts a request under its method and, for tool calls, its tool
+func (m *requestMetrics) add(entry types.RequestLogEntry, duration time.Duration, slow bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stats == nil {
+		m.stats = make(map[string]*requestStats)
+	}
+	key := entry.Method
+	if entry.Tool != "" {
+		key = entry.Method + ":" + entry.Tool
+	}
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &requestStats{}
+		m.stats[key] = stats
+	}
+	stats.count++
+	if entry.Outcome == RequestOutcomeError {
+		stats.errors++
+	}
+	if slow {
+		stats.slow++
+	}
+	stats.total += duration
+	if duration > stats.max {
+		stats.max = duration
+	}
+	stats.resultBytes += int64(entry.ResultBytes)
+}
+
+// totals returns the number of requests, failed requests and slow requests
+func (m *requestMetrics) totals() (count, errors, slow int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, stats := range m.stats {
+		count += stats.count
+		errors += stats.errors
+		slow += stats.slow
+	}
+	return count, errors, slow
+}
+
+// RequestStats describes the requests handled so far: their totals, the
+// slow-query log settings and, by method and tool sorted by total duration,
+// their count, errors, slow requests, durations and result size. Cluster
+// servers report their parent's requests.
+func (s *AuditQueryMCPServer) RequestStats() map[string]interface{} {
+	if s.parent != nil {
+		return s.parent.RequestStats()
+	}
+	count, errors, slow := s.requests.totals()
+
+	s.requests.mutex.Lock()
+	keys := make([]string, 0, len(s.requests.stats))
+	for key := range s.requests.stats {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		left, right := s.requests.stats[keys[i]], s.requests.stats[keys[j]]
+		if left.total != right.total {
+			return left.total > right.total
+		}
+		return keys[i] < keys[j]
+	})
+	byRequest := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		stats := s.requests.stats[key]
+		byRequest = append(byRequest, map[string]interface{}{
+			"request":      key,
+			"count":        stats.count,
+			"errors":       stats.errors,
+			"slow":         stats.slow,
+			"total_ms":     stats.total.Milliseconds(),
+			"average_ms":   (stats.total / time.Duration(stats.count)).Milliseconds(),
+			"max_ms":       stats.max.Milliseconds(),
+			"result_bytes": stats.resultBytes,
+		})
+	}
+	s.requests.mutex.Unlock()
+
+	result := map[string]interface{}{
+		"total":      count,
+		"errors":     errors,
+		"slow":       slow,
+		"by_request": byRequest,
+	}
+	if s.slowQueries != nil {
+		result["slow_query_log"] = s.slowQueries.Path()
+		result["slow_query_threshold"] = s.slowQueries.Threshold().String()
+	}
+	return result
+}
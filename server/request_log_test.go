@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleMCPRequest_SlowQueryLog tests that requests are counted and slow ones logged with their arguments
+func TestHandleMCPRequest_SlowQueryLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slow_queries.json")
+	t.Setenv("AUDIT_SLOW_QUERY_LOG", path)
+	t.Setenv("AUDIT_SLOW_QUERY_THRESHOLD", "1ns")
+	server := newMockServer(t)
+
+	arguments := map[string]interface{}{"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today", "verb": "delete"}}
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "generate_audit_query_with_result", "arguments": arguments,
+	}})
+	require.Nil(t, response.Error)
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "bogus/method", JSONRPC: "2.0"})
+	require.NotNil(t, response.Error)
+	server.HandleMCPRequest(types.MCPRequest{Method: "notifications/cancelled", JSONRPC: "2.0"})
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var entries []types.RequestLogEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry types.RequestLogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+	assert.Equal(t, "generate_audit_query_with_result", entries[0].Tool)
+	assert.Equal(t, RequestOutcomeOK, entries[0].Outcome)
+	assert.Positive(t, entries[0].ResultBytes)
+	assert.Equal(t, "delete", entries[0].Arguments["structured_params"].(map[string]interface{})["verb"])
+	assert.Equal(t, "bogus/method", entries[1].Method)
+	assert.Equal(t, RequestOutcomeError, entries[1].Outcome)
+	assert.Equal(t, -32601, entries[1].ErrorCode)
+
+	stats := server.RequestStats()
+	assert.Equal(t, int64(2), stats["total"])
+	assert.Equal(t, int64(1), stats["errors"])
+	assert.Equal(t, int64(2), stats["slow"])
+	assert.Equal(t, path, stats["slow_query_log"])
+	requests := make(map[string]int64)
+	for _, request := range stats["by_request"].([]map[string]interface{}) {
+		requests[request["request"].(string)] = request["count"].(int64)
+	}
+	assert.Equal(t, map[string]int64{"tools/call:generate_audit_query_with_result": 1, "bogus/method": 1}, requests)
+	assert.Contains(t, server.PrometheusMetrics(), "audit_query_slow_requests_total 2\n")
+}
+
+// TestHandleMCPRequest_SlowQueryLogDisabled tests that a zero threshold disables the slow-query log
+func TestHandleMCPRequest_SlowQueryLogDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slow_queries.json")
+	t.Setenv("AUDIT_SLOW_QUERY_LOG", path)
+	t.Setenv("AUDIT_SLOW_QUERY_THRESHOLD", "0")
+	server := newMockServer(t)
+	assert.Nil(t, server.slowQueries)
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "ping", JSONRPC: "2.0"})
+	require.Nil(t, response.Error)
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	stats := server.RequestStats()
+	assert.Equal(t, int64(1), stats["total"])
+	assert.NotContains(t, stats, "slow_query_log")
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/utils"
+)
+
+// discoveryLookupKey keeps the API discovery output in the lookup cache
+const discoveryLookupKey = "discovery api-resources"
+
+// discoverResourcesFor looks up the cluster's API resources when the
+// structured parameters of a tool call name a resource the dictionary does
+// not know, such as a custom resource or its short name. Only queries of
+// OpenShift, where oc reaches the cluster, look them up.
+func (s *AuditQueryMCPServer) discoverResourcesFor(params map[string]interface{}) {
+	structuredParams, ok := params["structured_params"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if backend, _ := structuredParams["backend"].(string); backend != "" {
+		if backend != providers.ProviderOpenShift {
+			return
+		}
+	} else if s.provider != nil {
+		return
+	}
+
+	names := stringList(structuredParams["exclude_resources"])
+	if resource, ok := structuredParams["resource"].(string); ok {
+		names = append(names, resource)
+	}
+	names = append(names, stringList(structuredParams["resource"])...)
+	for _, name := range names {
+		name = strings.TrimSuffix(name, "*")
+		if name == "" {
+			continue
+		}
+		if _, ok := utils.ResolveResource(name); !ok {
+			s.discoverResources()
+			return
+		}
+	}
+}
+
+// discoverResources registers the resources listed by the cluster's API
+// discovery with the resource dictionary. The output, or the failure, is kept
+// for the enrichment TTL, so unknown names do not repeat the lookup.
+func (s *AuditQueryMCPServer) discoverResources() {
+	s.lookupCacheMutex.Lock()
+	cached, ok := s.lookupCache[discoveryLookupKey]
+	s.lookupCacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return
+	}
+
+	output, err := s.clusterLookup(s.ocArgs(commands.APIResourcesArgs()))
+	if err != nil {
+		s.logger.Warnf("API resource discovery failed: %v", err)
+		output = ""
+	} else {
+		registered := utils.RegisterResources(commands.ParseAPIResources(output))
+		s.logger.Infof("Registered %d resources from API discovery", registered)
+	}
+
+	s.lookupCacheMutex.Lock()
+	s.lookupCache[discoveryLookupKey] = cachedLookup{output: output, expires: time.Now().Add(s.enrichmentTTL)}
+	s.lookupCacheMutex.Unlock()
+}
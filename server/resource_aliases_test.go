@@ -0,0 +1,68 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// explainResource calls the explain_audit_query tool for a resource filter
+func explainResource(t *testing.T, server *AuditQueryMCPServer, resource string) *types.QueryExplanation {
+	t.Helper()
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "explain_audit_query",
+		"arguments": map[string]interface{}{"structured_params": map[string]interface{}{
+			"log_source": "kube-apiserver", "resource": resource,
+		}},
+	}})
+	require.Nil(t, response.Error)
+	return response.Result.(map[string]interface{})["explanation"].(*types.QueryExplanation)
+}
+
+// TestResourceAliases tests translating resource names with the dictionary
+// and looking unknown ones up in API discovery once
+func TestResourceAliases(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	calls := 0
+	server.clusterLookup = func(args []string) (string, error) {
+		calls++
+		if strings.Join(args, " ") != "api-resources" {
+			return "", errors.New("unexpected lookup")
+		}
+		return "NAME      SHORTNAMES   APIVERSION           NAMESPACED   KIND\n" +
+			"widgets   wdg          example.com/v1beta1  true         Widget\n", nil
+	}
+
+	// Known names need no discovery
+	explanation := explainResource(t, server, "crds")
+	assert.Contains(t, explanation.Command, "customresourcedefinitions")
+	assert.Equal(t, 0, calls)
+
+	explanation = explainResource(t, server, "wdg")
+	assert.Contains(t, explanation.Command, "widgets")
+	assert.NotContains(t, explanation.Command, "wdg")
+	assert.Equal(t, 1, calls)
+
+	// Discovery is reused until the TTL expires, even for names it lacks
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name":      "explain_audit_query",
+		"arguments": map[string]interface{}{"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "resource": "gizmos"}},
+	}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, 1, calls)
+}
+
+// TestResourceAliases_OtherBackends tests that discovery only runs for OpenShift
+func TestResourceAliases_OtherBackends(t *testing.T) {
+	server := newMockServer(t)
+	server.clusterLookup = func(args []string) (string, error) {
+		t.Errorf("Unexpected lookup oc %s", strings.Join(args, " "))
+		return "", nil
+	}
+	server.discoverResourcesFor(map[string]interface{}{"structured_params": map[string]interface{}{"resource": "sprockets"}})
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+	"audit-query-mcp-server/validation"
+)
+
+// MCP resource URIs
+const (
+	resourceScheme       = "auditquery://"
+	resultsResourceURI   = resourceScheme + "results"
+	templatesResourceURI = resourceScheme + "templates"
+	configResourceURI    = resourceScheme + "config"
+	jsonMimeType         = "application/json"
+
+	// maxListedResults bounds the recent results listed by resources/list
+	maxListedResults = 50
+)
+
+// templateNamePattern matches names usable in auditquery://templates/{name}
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// LoadQueryTemplates reads saved query templates from a JSON file of the form
+// {"templates": [{"name": ..., "description": ..., "params": {...}}, ...]}.
+// Template parameters use the structured_params field names and are validated
+// like a query; log_source defaults to kube-apiserver.
+func LoadQueryTemplates(path string) ([]types.QueryTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query templates: %w", err)
+	}
+
+	var config struct {
+		Templates []types.QueryTemplate `json:"templates"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse query templates: %w", err)
+	}
+
+	seen := make(map[string]bool, len(config.Templates))
+	for i := range config.Templates {
+		template := &config.Templates[i]
+		if !templateNamePattern.MatchString(template.Name) {
+			return nil, fmt.Errorf("invalid query template name: %q", template.Name)
+		}
+		if seen[template.Name] {
+			return nil, fmt.Errorf("duplicate query template: %s", template.Name)
+		}
+		seen[template.Name] = true
+
+		if template.Params.LogSource == "" {
+			template.Params.LogSource = "kube-apiserver"
+		}
+		if err := validation.ValidateQueryParams(template.Params); err != nil {
+			return nil, fmt.Errorf("invalid query template %s: %w", template.Name, err)
+		}
+	}
+	return config.Templates, nil
+}
+
+// GetResources returns the resources clients can read: the recent results
+// index and each recent result, the saved templates, the watch rule alerts and
+// the server configuration
+func (s *AuditQueryMCPServer) GetResources() []types.MCPResource {
+	resources := []types.MCPResource{
+		{
+			URI:         resultsResourceURI,
+			Name:        "Recent audit results",
+			Description: "Query ID, command, backend, entry count and summary of the most recently used cached results",
+			MimeType:    jsonMimeType,
+		},
+	}
+	for _, result := range s.cache.Recent(maxListedResults) {
+		resources = append(resources, types.MCPResource{
+			URI:         resultsResourceURI + "/" + result.QueryID,
+			Name:        "Audit result " + result.QueryID,
+			Description: result.Summary,
+			MimeType:    jsonMimeType,
+		})
+	}
+
+	resources = append(resources, types.MCPResource{
+		URI:         templatesResourceURI,
+		Name:        "Saved query templates",
+		Description: "Named query parameters from AUDIT_QUERY_TEMPLATES",
+		MimeType:    jsonMimeType,
+	})
+	for _, template := range s.queryTemplates() {
+		resources = append(resources, types.MCPResource{
+			URI:         templatesResourceURI + "/" + template.Name,
+			Name:        "Query template " + template.Name,
+			Description: template.Description,
+			MimeType:    jsonMimeType,
+		})
+	}
+
+	resources = append(resources, types.MCPResource{
+		URI:         alertsResourceURI,
+		Name:        "Watch rule alerts",
+		Description: "The most recent alerts raised by the watch rules from AUDIT_WATCH_RULES, newest first",
+		MimeType:    jsonMimeType,
+	})
+
+	return append(resources, types.MCPResource{
+		URI:         configResourceURI,
+		Name:        "Server configuration",
+		Description: "Query backends, cache, circuit breaker, filtering, forwarding and file locations",
+		MimeType:    jsonMimeType,
+	})
+}
+
+// GetResourceTemplates returns the URI templates of the parameterized resources
+func (s *AuditQueryMCPServer) GetResourceTemplates() []types.MCPResourceTemplate {
+	return []types.MCPResourceTemplate{
+		{
+			URITemplate: resultsResourceURI + "/{query_id}",
+			Name:        "Audit result",
+			Description: "A cached AuditResult by query ID",
+			MimeType:    jsonMimeType,
+		},
+		{
+			URITemplate: templatesResourceURI + "/{name}",
+			Name:        "Query template",
+			Description: "A saved query template by name; its params can be passed as structured_params",
+			MimeType:    jsonMimeType,
+		},
+	}
+}
+
+// ReadResource returns the JSON contents of the resource at uri
+func (s *AuditQueryMCPServer) ReadResource(uri string) (*types.MCPResourceContents, error) {
+	var value interface{}
+	switch {
+	case uri == resultsResourceURI:
+		index := []map[string]interface{}{}
+		for _, result := range s.cache.Recent(maxListedResults) {
+			index = append(index, map[string]interface{}{
+				"uri":           resultsResourceURI + "/" + result.QueryID,
+				"query_id":      result.QueryID,
+				"timestamp":     result.Timestamp,
+				"command":       result.Command,
+				"backend":       result.Backend,
+				"total_entries": result.TotalEntries,
+				"summary":       result.Summary,
+				"error":         result.Error,
+			})
+		}
+		value = index
+	case strings.HasPrefix(uri, resultsResourceURI+"/"):
+		result, ok := s.GetCachedResult(strings.TrimPrefix(uri, resultsResourceURI+"/"))
+		if !ok {
+			return nil, fmt.Errorf("resource not found: %s", uri)
+		}
+		value = result
+	case uri == templatesResourceURI:
+		templates := s.queryTemplates()
+		if templates == nil {
+			templates = []types.QueryTemplate{}
+		}
+		value = templates
+	case strings.HasPrefix(uri, templatesResourceURI+"/"):
+		template, ok := s.queryTemplate(strings.TrimPrefix(uri, templatesResourceURI+"/"))
+		if !ok {
+			return nil, fmt.Errorf("resource not found: %s", uri)
+		}
+		value = template
+	case uri == alertsResourceURI:
+		value = s.RecentAlerts()
+	case uri == configResourceURI:
+		value = s.Configuration()
+	default:
+		return nil, fmt.Errorf("resource not found: %s", uri)
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource %s: %w", uri, err)
+	}
+	return &types.MCPResourceContents{URI: uri, MimeType: jsonMimeType, Text: string(data)}, nil
+}
+
+// queryTemplate returns the saved query template with the given name
+func (s *AuditQueryMCPServer) queryTemplate(name string) (types.QueryTemplate, bool) {
+	for _, template := range s.queryTemplates() {
+		if template.Name == name {
+			return template, true
+		}
+	}
+	return types.QueryTemplate{}, false
+}
+
+// Configuration returns the server's effective configuration, without secrets
+func (s *AuditQueryMCPServer) Configuration() map[string]interface{} {
+	cacheStats := s.cache.GetStats()
+	circuit := s.circuit.Status()
+	return map[string]interface{}{
+		"provider": s.ProviderName(),
+		"backends": s.BackendCapabilities(),
+		"clusters": s.ClusterNames(),
+		"cache": map[string]interface{}{
+			"default_ttl":  cacheStats["default_ttl"],
+			"negative_ttl": cacheStats["negative_ttl"],
+			"max_entries":  cacheStats["max_entries"],
+			"max_bytes":    cacheStats["max_bytes"],
+			"cache_file":   s.cacheFile,
+		},
+		"circuit_breaker": map[string]interface{}{
+			"failure_threshold": circuit.FailureThreshold,
+			"reset_timeout":     circuit.ResetTimeout,
+		},
+		"in_process_filtering":    s.inProcessFiltering,
+		"jq_engine":               commands.JQEngineMode(),
+		"active_jq_engine":        commands.ActiveJQEngine(),
+		"incremental_queries":     s.incrementalQueries,
+		"parser":                  s.ParserConfig(),
+		"availability_ttl":        s.availabilityTTL.String(),
+		"audit_trail":             s.auditTrail != nil,
+		"forwarding":              s.forwarder.Destinations(),
+		"report_dir":              s.reportDir,
+		"local_file_dir":          s.localFileDir,
+		"query_templates":         len(s.queryTemplates()),
+		"watch_rules":             s.WatchRuleNames(),
+		"watch_interval":          s.watchInterval.String(),
+		"indicators":              s.indicatorCount(),
+		"secret_access_allowlist": s.secretAccessAllowlist,
+		"log_sources":             utils.ValidLogSources,
+		"custom_log_sources":      utils.CustomLogSources(),
+	}
+}
+
+// SubscribeResource records a client subscription to updates of the resource at uri
+func (s *AuditQueryMCPServer) SubscribeResource(uri string) error {
+	if !strings.HasPrefix(uri, resourceScheme) {
+		return fmt.Errorf("resource not found: %s", uri)
+	}
+	s.subscriptionsMutex.Lock()
+	defer s.subscriptionsMutex.Unlock()
+	s.subscriptions[uri] = true
+	return nil
+}
+
+// UnsubscribeResource removes a subscription recorded by SubscribeResource
+func (s *AuditQueryMCPServer) UnsubscribeResource(uri string) {
+	s.subscriptionsMutex.Lock()
+	defer s.subscriptionsMutex.Unlock()
+	delete(s.subscriptions, uri)
+}
+
+// SetNotifier sets the function the transport delivers server notifications
+// with; nil drops them
+func (s *AuditQueryMCPServer) SetNotifier(notify func(types.MCPNotification)) {
+	s.subscriptionsMutex.Lock()
+	defer s.subscriptionsMutex.Unlock()
+	s.notifier = notify
+}
+
+// notify sends a notification through the notifier, if one is set; cluster
+// servers notify through their parent's
+func (s *AuditQueryMCPServer) notify(method string, params map[string]interface{}) {
+	if s.parent != nil {
+		s.parent.notify(method, params)
+		return
+	}
+	s.subscriptionsMutex.Lock()
+	notify := s.notifier
+	s.subscriptionsMutex.Unlock()
+	if notify != nil {
+		notify(types.MCPNotification{Method: method, Params: params, JSONRPC: "2.0"})
+	}
+}
+
+// resultsChanged notifies clients that cached results were added or removed:
+// the resource list changed, as did the results index and the given results
+// for subscribed clients
+func (s *AuditQueryMCPServer) resultsChanged(queryIDs ...string) {
+	s.notify("notifications/resources/list_changed", nil)
+
+	uris := []string{resultsResourceURI}
+	for _, queryID := range queryIDs {
+		uris = append(uris, resultsResourceURI+"/"+queryID)
+	}
+	s.subscriptionsMutex.Lock()
+	var updated []string
+	for _, uri := range uris {
+		if s.subscriptions[uri] {
+			updated = append(updated, uri)
+		}
+	}
+	s.subscriptionsMutex.Unlock()
+
+	for _, uri := range updated {
+		s.notify("notifications/resources/updated", map[string]interface{}{"uri": uri})
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeQueryTemplates writes a query templates file and returns its path
+func writeQueryTemplates(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// TestLoadQueryTemplates tests reading and validating saved query templates
+func TestLoadQueryTemplates(t *testing.T) {
+	templates, err := LoadQueryTemplates(writeQueryTemplates(t, `{"templates": [
+		{"name": "secret-deletions", "description": "Deleted secrets", "params": {"verb": "delete", "resource": "secrets"}},
+		{"name": "oauth-failures", "params": {"log_source": "oauth-server", "status_code_range": "4xx"}}]}`))
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "kube-apiserver", templates[0].Params.LogSource)
+	assert.Equal(t, "secrets", templates[0].Params.Resource)
+	assert.Equal(t, "oauth-server", templates[1].Params.LogSource)
+
+	for content, expected := range map[string]string{
+		`{"templates": [{"name": "a/b", "params": {}}]}`:                "invalid query template name",
+		`{"templates": [{"name": "a", "params": {}}, {"name": "a"}]}`:   "duplicate query template",
+		`{"templates": [{"name": "a", "params": {"verb": "destroy"}}]}`: "invalid query template a",
+		`{"templates": [`: "failed to parse query templates",
+	} {
+		_, err := LoadQueryTemplates(writeQueryTemplates(t, content))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+// TestResources tests listing, reading and subscribing to MCP resources
+func TestResources(t *testing.T) {
+	t.Setenv("AUDIT_QUERY_TEMPLATES", writeQueryTemplates(t, `{"templates": [{"name": "secret-deletions", "params": {"verb": "delete", "resource": "secrets"}}]}`))
+	server := NewAuditQueryMCPServer()
+	server.cache.Set("query-1", &types.AuditResult{QueryID: "query-1", Command: "oc adm node-logs", TotalEntries: 2, Summary: "Found 2 audit entries"})
+
+	var notifications []types.MCPNotification
+	server.SetNotifier(func(notification types.MCPNotification) {
+		notifications = append(notifications, notification)
+	})
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "resources/list", JSONRPC: "2.0"})
+	require.Nil(t, response.Error)
+	var uris []string
+	for _, resource := range response.Result.(map[string]interface{})["resources"].([]types.MCPResource) {
+		uris = append(uris, resource.URI)
+	}
+	assert.Equal(t, []string{
+		"auditquery://results",
+		"auditquery://results/query-1",
+		"auditquery://templates",
+		"auditquery://templates/secret-deletions",
+		"auditquery://alerts",
+		"auditquery://config",
+	}, uris)
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "resources/templates/list", JSONRPC: "2.0"})
+	require.Nil(t, response.Error)
+	assert.Len(t, response.Result.(map[string]interface{})["resourceTemplates"], 2)
+
+	// Results, templates and the configuration are read as JSON
+	read := func(uri string) map[string]interface{} {
+		response := server.HandleMCPRequest(types.MCPRequest{ID: "3", Method: "resources/read", Params: map[string]interface{}{"uri": uri}, JSONRPC: "2.0"})
+		require.Nil(t, response.Error)
+		contents := response.Result.(map[string]interface{})["contents"].([]types.MCPResourceContents)
+		require.Len(t, contents, 1)
+		assert.Equal(t, uri, contents[0].URI)
+		assert.Equal(t, "application/json", contents[0].MimeType)
+		var value map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(contents[0].Text), &value))
+		return value
+	}
+	assert.Equal(t, float64(2), read("auditquery://results/query-1")["total_entries"])
+	assert.Equal(t, "secrets", read("auditquery://templates/secret-deletions")["params"].(map[string]interface{})["resource"])
+	config := read("auditquery://config")
+	assert.Equal(t, "openshift", config["provider"])
+	assert.Equal(t, float64(1), config["query_templates"])
+
+	for _, uri := range []string{"auditquery://results/missing", "auditquery://templates/missing", "auditquery://other"} {
+		response = server.HandleMCPRequest(types.MCPRequest{ID: "4", Method: "resources/read", Params: map[string]interface{}{"uri": uri}, JSONRPC: "2.0"})
+		require.NotNil(t, response.Error)
+		assert.Equal(t, -32002, response.Error.Code)
+	}
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "5", Method: "resources/read", Params: map[string]interface{}{}, JSONRPC: "2.0"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	// Subscribers to the results index and a result are told when results change
+	for _, uri := range []string{"auditquery://results", "auditquery://results/query-1"} {
+		response = server.HandleMCPRequest(types.MCPRequest{ID: "6", Method: "resources/subscribe", Params: map[string]interface{}{"uri": uri}, JSONRPC: "2.0"})
+		require.Nil(t, response.Error)
+	}
+	server.DeleteCachedResult("query-1")
+	require.Len(t, notifications, 3)
+	assert.Equal(t, "notifications/resources/list_changed", notifications[0].Method)
+	assert.Equal(t, "notifications/resources/updated", notifications[1].Method)
+	assert.Equal(t, "auditquery://results", notifications[1].Params["uri"])
+	assert.Equal(t, "auditquery://results/query-1", notifications[2].Params["uri"])
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "7", Method: "resources/unsubscribe", Params: map[string]interface{}{"uri": "auditquery://results"}, JSONRPC: "2.0"})
+	require.Nil(t, response.Error)
+	notifications = nil
+	_, err := server.AnalyzeAuditFiles(writeLocalAuditLog(t), types.AuditQueryParams{}, nil)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "notifications/resources/list_changed", notifications[0].Method)
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "8", Method: "resources/subscribe", Params: map[string]interface{}{"uri": "file:///etc/passwd"}, JSONRPC: "2.0"})
+	require.NotNil(t, response.Error)
+}
+
+// writeLocalAuditLog writes an audit log with one event and returns its path
+func writeLocalAuditLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditEvents(t, path, time.Now())
+	return path
+}
+
+// TestHandleInitialize tests that initialize announces the resources capability
+func TestHandleInitialize(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "init", Method: "initialize", JSONRPC: "2.0"})
+	require.Nil(t, response.Error)
+	capabilities := response.Result.(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"subscribe": true, "listChanged": true}, capabilities["resources"])
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// queryTools are the tools running queries, whose results keep their raw
+// output only when called with include_raw_output and whose entries can be
+// projected on fields
+var queryTools = map[string]bool{
+	"execute_audit_query_with_result": true,
+	"execute_complete_audit_query":    true,
+	"execute_audit_query_batch":       true,
+	"ask_audit_question":              true,
+}
+
+// EntryFields are the fields of parsed entries, including those added by
+// enrichment and source IP classification, that the fields argument can select
+var EntryFields = []string{
+	"annotations", "api_group", "api_version", "audit_id", "auth_decision",
+	"authz_decision", "authz_reason", "cluster_context", "external_source",
+	"extra", "groups", "headers", "impersonated_user", "is_service_account",
+	"is_system_user", "level", "line_length", "name", "namespace",
+	"omitted_objects", "parse_errors", "parse_time",
+	"raw_line", "request_object", "request_uri", "resource", "response_object",
+	"source_ip_info", "source_ips", "stage", "stage_timestamp", "status_code",
+	"status_message", "status_reason", "subresource", "timestamp", "truncated",
+	"uid", "user_agent", "username", "verb",
+}
+
+// includeRawOutputSchema is the JSON schema of the include_raw_output tool argument
+func includeRawOutputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Return the raw command output of results that have parsed_data, whose entries already carry each event's raw line (default: false)",
+	}
+}
+
+// fieldsSchema is the JSON schema of the fields tool argument
+func fieldsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Fields returned for each parsed entry, for example [\"timestamp\", \"username\", \"verb\", \"resource\"] (default: all fields)",
+		"items":       map[string]interface{}{"type": "string", "enum": EntryFields},
+	}
+}
+
+// fieldsParam returns the entry fields selected by the fields argument, or nil
+// when it is absent. Unknown or non-string fields are rejected.
+func fieldsParam(params map[string]interface{}) ([]string, error) {
+	value, ok := params["fields"]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("fields must be a non-empty array of entry field names")
+	}
+
+	var fields []string
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		field, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("fields must be a non-empty array of entry field names")
+		}
+		if index := sort.SearchStrings(EntryFields, field); index == len(EntryFields) || EntryFields[index] != field {
+			return nil, fmt.Errorf("unknown entry field %q, expected one of: %s", field, strings.Join(EntryFields, ", "))
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// shapeResult returns result with its raw output dropped when omitRawOutput is
+// set and it has parsed entries, which carry each event's raw line, and its
+// entries projected on fields when some are given. result itself is returned
+// when nothing changes, otherwise a copy.
+func shapeResult(result *types.AuditResult, omitRawOutput bool, fields []string) *types.AuditResult {
+	if result == nil {
+		return nil
+	}
+	stripRawOutput := omitRawOutput && result.RawOutput != "" && len(result.ParsedData) > 0
+	if !stripRawOutput && len(fields) == 0 {
+		return result
+	}
+
+	shaped := *result
+	if stripRawOutput {
+		shaped.RawOutput = ""
+	}
+	if len(fields) > 0 && result.ParsedData != nil {
+		shaped.ParsedData = make([]map[string]interface{}, len(result.ParsedData))
+		for i, entry := range result.ParsedData {
+			projected := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				if value, ok := entry[field]; ok {
+					projected[field] = value
+				}
+			}
+			shaped.ParsedData[i] = projected
+		}
+	}
+	return &shaped
+}
+
+// shapeToolResponse drops the raw output of the results in a tool response:
+// for the query tools unless they were called with include_raw_output, and for
+// the other tools when AUDIT_OMIT_RAW_OUTPUT is set. Entries of query tool
+// results are projected on the fields argument. Results are copied, so cached
+// results are unchanged.
+func (s *AuditQueryMCPServer) shapeToolResponse(toolName string, params map[string]interface{}, response types.MCPResponse) types.MCPResponse {
+	omit := s.omitRawOutput
+	var fields []string
+	if queryTools[toolName] {
+		include, _ := params["include_raw_output"].(bool)
+		omit = !include
+		// The fields were validated before the tool ran
+		fields, _ = fieldsParam(params)
+	}
+	if (!omit && len(fields) == 0) || response.Error != nil {
+		return response
+	}
+
+	switch result := response.Result.(type) {
+	case map[string]interface{}:
+		if auditResult, ok := result["audit_result"].(*types.AuditResult); ok {
+			shaped := make(map[string]interface{}, len(result))
+			for name, value := range result {
+				shaped[name] = value
+			}
+			shaped["audit_result"] = shapeResult(auditResult, omit, fields)
+			response.Result = shaped
+		}
+	case *types.BatchQueryResult:
+		shaped := *result
+		shaped.Results = make([]types.BatchQueryItem, len(result.Results))
+		for i, item := range result.Results {
+			item.Result = shapeResult(item.Result, omit, fields)
+			shaped.Results[i] = item
+		}
+		response.Result = &shaped
+	}
+	return response
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeShapingLog writes a one-event kube-apiserver log to a mock data directory
+func writeShapingLog(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(
+		`{"kind":"Event","stage":"ResponseComplete","auditID":"a1","verb":"get","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200}}`+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+}
+
+// TestHandleToolCall_IncludeRawOutput tests that query tools return the raw output only when asked, without changing cached results
+func TestHandleToolCall_IncludeRawOutput(t *testing.T) {
+	writeShapingLog(t)
+	server := newMockServer(t)
+
+	structuredParams := map[string]interface{}{"log_source": "kube-apiserver", "verb": "get"}
+	response := server.handleToolCall(types.MCPRequest{ID: "1", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "execute_complete_audit_query",
+		"arguments": map[string]interface{}{"structured_params": structuredParams},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+	assert.Empty(t, result.RawOutput)
+	assert.Len(t, result.ParsedData, 1)
+
+	cached, ok := server.cache.Get(result.QueryID)
+	require.True(t, ok)
+	assert.NotEmpty(t, cached.RawOutput)
+
+	response = server.handleToolCall(types.MCPRequest{ID: "2", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "execute_complete_audit_query",
+		"arguments": map[string]interface{}{"structured_params": structuredParams, "include_raw_output": true},
+	}})
+	require.Nil(t, response.Error)
+	assert.NotEmpty(t, response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult).RawOutput)
+
+	response = server.handleToolCall(types.MCPRequest{ID: "3", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "execute_audit_query_batch",
+		"arguments": map[string]interface{}{"queries": []interface{}{structuredParams}},
+	}})
+	require.Nil(t, response.Error)
+	batch := response.Result.(*types.BatchQueryResult)
+	require.Len(t, batch.Results, 1)
+	assert.Empty(t, batch.Results[0].Result.RawOutput)
+
+	// Other tools return the raw output unless AUDIT_OMIT_RAW_OUTPUT is set
+	response = server.handleToolCall(types.MCPRequest{ID: "4", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "get_cached_result",
+		"arguments": map[string]interface{}{"query_id": result.QueryID},
+	}})
+	require.Nil(t, response.Error)
+	assert.NotEmpty(t, response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult).RawOutput)
+}
+
+// TestHandleToolCall_OmitRawOutput tests dropping the raw output of other tools' responses with AUDIT_OMIT_RAW_OUTPUT
+func TestHandleToolCall_OmitRawOutput(t *testing.T) {
+	writeShapingLog(t)
+	t.Setenv("AUDIT_OMIT_RAW_OUTPUT", "true")
+	server := newMockServer(t)
+
+	response := server.handleToolCall(types.MCPRequest{ID: "1", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "execute_complete_audit_query",
+		"arguments": map[string]interface{}{"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "verb": "get"}},
+	}})
+	require.Nil(t, response.Error)
+	queryID := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult).QueryID
+
+	response = server.handleToolCall(types.MCPRequest{ID: "2", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "get_cached_result",
+		"arguments": map[string]interface{}{"query_id": queryID},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+	assert.Empty(t, result.RawOutput)
+	assert.Len(t, result.ParsedData, 1)
+}
+
+// TestShapeResult tests that results without parsed entries keep their raw output
+func TestShapeResult(t *testing.T) {
+	executed := &types.AuditResult{QueryID: "q1", RawOutput: "raw"}
+	assert.Same(t, executed, shapeResult(executed, true, nil))
+	assert.Nil(t, shapeResult(nil, true, []string{"verb"}))
+}
+
+// TestHandleToolCall_Fields tests projecting the entries of query results on the fields argument
+func TestHandleToolCall_Fields(t *testing.T) {
+	writeShapingLog(t)
+	server := newMockServer(t)
+
+	structuredParams := map[string]interface{}{"log_source": "kube-apiserver", "verb": "get"}
+	response := server.handleToolCall(types.MCPRequest{ID: "1", Method: "tools/call", Params: map[string]interface{}{
+		"name":      "execute_complete_audit_query",
+		"arguments": map[string]interface{}{"structured_params": structuredParams, "fields": []interface{}{"timestamp", "username", "verb", "verb"}},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult)
+	require.Len(t, result.ParsedData, 1)
+	assert.Len(t, result.ParsedData[0], 3)
+	assert.Equal(t, "alice", result.ParsedData[0]["username"])
+
+	cached, ok := server.cache.Get(result.QueryID)
+	require.True(t, ok)
+	assert.Greater(t, len(cached.ParsedData[0]), 3)
+
+	// Every field of a parsed entry can be selected
+	for field := range cached.ParsedData[0] {
+		assert.Contains(t, EntryFields, field)
+	}
+	assert.True(t, sort.StringsAreSorted(EntryFields))
+
+	for _, fields := range []interface{}{[]interface{}{"verb", "user"}, []interface{}{}, "verb", []interface{}{1.0}} {
+		response = server.handleToolCall(types.MCPRequest{ID: "2", Method: "tools/call", Params: map[string]interface{}{
+			"name":      "execute_audit_query_batch",
+			"arguments": map[string]interface{}{"queries": []interface{}{structuredParams}, "fields": fields},
+		}})
+		require.NotNil(t, response.Error, "fields %v", fields)
+		assert.Equal(t, -32602, response.Error.Code)
+	}
+}
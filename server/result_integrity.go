@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// sealResult sets the integrity hashes of a parsed result, signed with the
+// server's result signing key when one is configured. A result that cannot be
+// hashed is returned without them.
+func (s *AuditQueryMCPServer) sealResult(result *types.AuditResult) {
+	integrity, err := utils.ComputeResultIntegrity(result, s.resultSigningKey)
+	if err != nil {
+		s.logger.Warnf("Failed to hash result %s: %v", result.QueryID, err)
+		return
+	}
+	result.Integrity = integrity
+}
+
+// VerifyResult re-checks the integrity of the cached result of queryID
+func (s *AuditQueryMCPServer) VerifyResult(queryID string) (*types.ResultVerification, error) {
+	result, found := s.GetCachedResult(queryID)
+	if !found {
+		return nil, fmt.Errorf("no cached result for query ID %s", queryID)
+	}
+	return s.VerifyStoredResult(result), nil
+}
+
+// VerifyStoredResult re-checks the integrity of a result, such as one exported
+// as evidence, against its hashes and, when signed, the server's signing key
+func (s *AuditQueryMCPServer) VerifyStoredResult(result *types.AuditResult) *types.ResultVerification {
+	verification := utils.VerifyResultIntegrity(result, s.resultSigningKey)
+	if verification.Valid {
+		s.logger.Infof("Result verification: %s", verification.Summary)
+	} else {
+		s.logger.Warnf("Result verification failed: %s", verification.Summary)
+	}
+	return &verification
+}
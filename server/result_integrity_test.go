@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleVerifyResult tests verifying cached and stored results of a signing server
+func TestHandleVerifyResult(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(
+		`{"kind":"Event","stage":"ResponseComplete","auditID":"a1","verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"secrets","namespace":"payments"},"responseStatus":{"code":200}}`+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	t.Setenv("AUDIT_RESULT_SIGNING_KEY", "evidence-key")
+	server := newMockServer(t)
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Integrity)
+	assert.Equal(t, types.IntegritySignatureHMAC, result.Integrity.SignatureAlgorithm)
+
+	response := server.handleVerifyResult("test-id", map[string]interface{}{"query_id": result.QueryID})
+	require.Nil(t, response.Error)
+	verification := response.Result.(map[string]interface{})["verification"].(*types.ResultVerification)
+	assert.True(t, verification.Valid, verification.Summary)
+	assert.True(t, verification.SignatureValid)
+
+	// An exported result verifies on its own
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	var stored map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &stored))
+	response = server.handleVerifyResult("test-id", map[string]interface{}{"audit_result": stored})
+	require.Nil(t, response.Error)
+	assert.True(t, response.Result.(map[string]interface{})["verification"].(*types.ResultVerification).Valid)
+
+	stored["raw_output"] = `{"auditID":"a1","verb":"get"}`
+	response = server.handleVerifyResult("test-id", map[string]interface{}{"audit_result": stored})
+	require.Nil(t, response.Error)
+	verification = response.Result.(map[string]interface{})["verification"].(*types.ResultVerification)
+	assert.False(t, verification.Valid)
+	assert.False(t, verification.RawOutputValid)
+
+	response = server.handleVerifyResult("test-id", map[string]interface{}{"query_id": "missing"})
+	require.NotNil(t, response.Error)
+	response = server.handleVerifyResult("test-id", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+}
+
+// TestEnrichedResults_DropIntegrity tests that annotated copies do not carry the cached result's hashes
+func TestEnrichedResults_DropIntegrity(t *testing.T) {
+	server := newMockServer(t)
+	result := &types.AuditResult{QueryID: "q1", ParsedData: []map[string]interface{}{{"source_ips": []string{"10.0.0.1"}}}}
+	server.sealResult(result)
+	require.NotNil(t, result.Integrity)
+	assert.Empty(t, result.Integrity.Signature)
+
+	classified, _ := server.ClassifySourceIPs(result)
+	assert.Nil(t, classified.Integrity)
+	assert.NotNil(t, result.Integrity)
+}
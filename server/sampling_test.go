@@ -0,0 +1,40 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecuteCompleteAuditQuery_Sampling tests marking sampled results and keeping only the sampled raw lines
+func TestExecuteCompleteAuditQuery_Sampling(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf(`{"kind":"Event","stage":"ResponseComplete","auditID":"sample-%d","verb":"get","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200}}`, i))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "get", SampleSize: 20})
+	require.NoError(t, err)
+	require.NotNil(t, result.Sampling)
+	assert.Equal(t, types.SamplingInfo{Size: 20, MatchingEntries: 200, SampledEntries: 20}, *result.Sampling)
+	assert.Equal(t, 20, result.TotalEntries)
+	assert.Len(t, result.ParsedData, 20)
+	assert.Len(t, strings.Split(result.RawOutput, "\n"), 20)
+	assert.Contains(t, result.Summary, "(sampled 20 of 200 matching entries)")
+
+	unsampled, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "get"})
+	require.NoError(t, err)
+	assert.Nil(t, unsampled.Sampling)
+	assert.Equal(t, 200, unsampled.TotalEntries)
+}
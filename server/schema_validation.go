@@ -0,0 +1,239 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// validateToolCall checks the arguments of a tools/call request against the
+// tool's input schema. It returns the INVALID_PARAMS response listing every
+// violated constraint, or nil when the arguments are valid or the tool is
+// unknown, which handleToolCall reports.
+func (s *AuditQueryMCPServer) validateToolCall(request types.MCPRequest) *types.MCPResponse {
+	toolName, _ := request.Params["name"].(string)
+	toolName, _ = s.resolveToolAlias(toolName)
+	arguments, ok := request.Params["arguments"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, tool := range s.GetTools() {
+		if tool.Name != toolName {
+			continue
+		}
+		// handleToolCall reports unknown clusters with the registered ones
+		checked := arguments
+		if _, ok := arguments["cluster"]; ok {
+			checked = make(map[string]interface{}, len(arguments))
+			for name, value := range arguments {
+				if name != "cluster" {
+					checked[name] = value
+				}
+			}
+		}
+		violations := validateSchema(tool.InputSchema, checked, "")
+		if len(violations) == 0 {
+			return nil
+		}
+		messages := make([]string, len(violations))
+		for i, violation := range violations {
+			messages[i] = violation.Message
+		}
+		mcpError := invalidParamsError(fmt.Sprintf("Invalid arguments for %s: %s", toolName, strings.Join(messages, "; ")))
+		mcpError.Data.Violations = violations
+		return &types.MCPResponse{ID: request.ID, Error: mcpError, JSONRPC: "2.0"}
+	}
+	return nil
+}
+
+// validateSchema returns the constraints of schema that value breaks. It
+// supports the keywords the tool schemas use: type, enum, minimum, maximum,
+// items, properties, required and oneOf. Objects declaring properties reject
+// other properties, so misspelled arguments are reported instead of ignored.
+func validateSchema(schema map[string]interface{}, value interface{}, path string) []types.SchemaViolation {
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		var names []string
+		for _, alternative := range alternatives {
+			alternativeSchema, ok := alternative.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if len(validateSchema(alternativeSchema, value, path)) == 0 {
+				matches++
+			}
+			if name, ok := alternativeSchema["type"].(string); ok {
+				names = append(names, name)
+			}
+		}
+		if matches != 1 {
+			return []types.SchemaViolation{violation(path, "oneOf", "must be exactly one of: "+strings.Join(names, ", "))}
+		}
+	}
+
+	if expected, ok := schema["type"].(string); ok && !hasSchemaType(value, expected) {
+		return []types.SchemaViolation{violation(path, "type", fmt.Sprintf("must be %s %s, got %s", article(expected), expected, jsonTypeOf(value)))}
+	}
+
+	var violations []types.SchemaViolation
+	if enum, ok := schema["enum"]; ok && !inEnum(enum, value) {
+		violations = append(violations, violation(path, "enum", fmt.Sprintf("must be one of: %s", enumList(enum))))
+	}
+	if number, ok := schemaNumber(value); ok {
+		if minimum, ok := schemaNumber(schema["minimum"]); ok && number < minimum {
+			violations = append(violations, violation(path, "minimum", fmt.Sprintf("must be at least %v", schema["minimum"])))
+		}
+		if maximum, ok := schemaNumber(schema["maximum"]); ok && number > maximum {
+			violations = append(violations, violation(path, "maximum", fmt.Sprintf("must be at most %v", schema["maximum"])))
+		}
+	}
+
+	if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+		if items := reflect.ValueOf(value); items.Kind() == reflect.Slice {
+			for i := 0; i < items.Len(); i++ {
+				violations = append(violations, validateSchema(itemSchema, items.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, name := range requiredProperties(schema["required"]) {
+			if _, present := object[name]; !present {
+				violations = append(violations, violation(joinPath(path, name), "required", "is required"))
+			}
+		}
+		if properties, ok := schema["properties"].(map[string]interface{}); ok {
+			names := make([]string, 0, len(object))
+			for name := range object {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				propertySchema, declared := properties[name].(map[string]interface{})
+				if !declared {
+					violations = append(violations, violation(joinPath(path, name), "additionalProperties", "is not a known argument"))
+					continue
+				}
+				violations = append(violations, validateSchema(propertySchema, object[name], joinPath(path, name))...)
+			}
+		}
+	}
+	return violations
+}
+
+// violation returns the violation of constraint at path, with a message naming the path
+func violation(path, constraint, message string) types.SchemaViolation {
+	if path == "" {
+		path = "arguments"
+	}
+	return types.SchemaViolation{Path: path, Constraint: constraint, Message: path + " " + message}
+}
+
+// joinPath returns the path of the property name of the object at path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// hasSchemaType reports whether value has the JSON schema type expected.
+// Values decoded from JSON and Go values passed by callers are both accepted.
+func hasSchemaType(value interface{}, expected string) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := schemaNumber(value)
+		return ok
+	case "integer":
+		number, ok := schemaNumber(value)
+		return ok && number == float64(int64(number))
+	case "array":
+		return value != nil && reflect.ValueOf(value).Kind() == reflect.Slice
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// jsonTypeOf names the JSON type of value for violation messages
+func jsonTypeOf(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	for _, name := range []string{"string", "boolean", "integer", "number", "array", "object"} {
+		if hasSchemaType(value, name) {
+			return name
+		}
+	}
+	return reflect.TypeOf(value).String()
+}
+
+// article returns the indefinite article of a JSON type name
+func article(typeName string) string {
+	if strings.IndexByte("aeiou", typeName[0]) >= 0 {
+		return "an"
+	}
+	return "a"
+}
+
+// schemaNumber returns value as a float64 when it is a number
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// inEnum reports whether value is one of the values of enum, a slice
+func inEnum(enum interface{}, value interface{}) bool {
+	values := reflect.ValueOf(enum)
+	if values.Kind() != reflect.Slice {
+		return true
+	}
+	for i := 0; i < values.Len(); i++ {
+		if values.Index(i).Interface() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// enumList joins the values of enum for violation messages
+func enumList(enum interface{}) string {
+	values := reflect.ValueOf(enum)
+	names := make([]string, values.Len())
+	for i := range names {
+		names[i] = fmt.Sprint(values.Index(i).Interface())
+	}
+	return strings.Join(names, ", ")
+}
+
+// requiredProperties returns the names listed by a required keyword
+func requiredProperties(required interface{}) []string {
+	switch names := required.(type) {
+	case []string:
+		return names
+	case []interface{}:
+		return stringList(names)
+	}
+	return nil
+}
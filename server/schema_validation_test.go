@@ -0,0 +1,103 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleMCPRequest_SchemaValidation tests that tool arguments breaking the input schema are rejected with each violation
+func TestHandleMCPRequest_SchemaValidation(t *testing.T) {
+	server := newMockServer(t)
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "execute_complete_audit_query",
+		"arguments": map[string]interface{}{
+			"structured_params": map[string]interface{}{
+				"log_source":  "kube-apiserver",
+				"usernmae":    "alice",
+				"verb":        []interface{}{"get", 1.0},
+				"status_code": 700.0,
+				"limit":       2.5,
+				"stage":       "Done",
+			},
+			"enrich": "yes",
+		},
+	}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.ErrorTypeInvalidParams.Code(), response.Error.Code)
+	assert.Contains(t, response.Error.Message, "Invalid arguments for execute_complete_audit_query")
+	assert.Contains(t, response.Error.Message, "structured_params.usernmae is not a known argument")
+
+	constraints := make(map[string]string)
+	for _, violation := range response.Error.Data.Violations {
+		constraints[violation.Path] = violation.Constraint
+	}
+	assert.Equal(t, map[string]string{
+		"enrich":                        "type",
+		"structured_params.usernmae":    "additionalProperties",
+		"structured_params.verb":        "oneOf",
+		"structured_params.status_code": "maximum",
+		"structured_params.limit":       "type",
+		"structured_params.stage":       "enum",
+	}, constraints)
+
+	// Missing required arguments and invalid batch items are reported before any query runs
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name":      "execute_audit_query_batch",
+		"arguments": map[string]interface{}{"queries": []interface{}{map[string]interface{}{"log_source": "bogus"}}},
+	}})
+	require.NotNil(t, response.Error)
+	require.Len(t, response.Error.Data.Violations, 1)
+	assert.Equal(t, "queries[0].log_source", response.Error.Data.Violations[0].Path)
+	assert.Equal(t, "enum", response.Error.Data.Violations[0].Constraint)
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "3", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name":      "get_cached_result",
+		"arguments": map[string]interface{}{},
+	}})
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "query_id is required")
+
+	// Valid arguments reach the tool
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "4", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "generate_audit_query_with_result",
+		"arguments": map[string]interface{}{"structured_params": map[string]interface{}{
+			"log_source": "kube-apiserver", "timeframe": "today", "verb": "delete", "username": []interface{}{"alice", "bob"}, "limit": 10.0,
+		}},
+	}})
+	assert.Nil(t, response.Error)
+}
+
+// TestGetTools_SchemaKeywords tests that tool schemas only use keywords the validation layer enforces
+func TestGetTools_SchemaKeywords(t *testing.T) {
+	supported := map[string]bool{
+		"type": true, "description": true, "properties": true, "required": true,
+		"enum": true, "items": true, "minimum": true, "maximum": true, "oneOf": true,
+	}
+	var check func(tool string, schema map[string]interface{})
+	check = func(tool string, schema map[string]interface{}) {
+		for keyword, value := range schema {
+			assert.True(t, supported[keyword], "%s uses unsupported schema keyword %s", tool, keyword)
+			switch keyword {
+			case "properties":
+				for _, property := range value.(map[string]interface{}) {
+					check(tool, property.(map[string]interface{}))
+				}
+			case "items":
+				check(tool, value.(map[string]interface{}))
+			case "oneOf":
+				for _, alternative := range value.([]interface{}) {
+					check(tool, alternative.(map[string]interface{}))
+				}
+			}
+		}
+	}
+
+	for _, tool := range newMockServer(t).GetTools() {
+		check(tool.Name, tool.InputSchema)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+)
+
+// defaultSecretAccessBaseline is the timeframe searched for earlier reads of
+// secrets when none is given
+const defaultSecretAccessBaseline = "7d"
+
+// secretAccessAllowlistFromEnv returns the users expected to read secrets from
+// the comma-separated AUDIT_SECRET_ACCESS_ALLOWLIST, or the default allowlist
+func secretAccessAllowlistFromEnv() []string {
+	value := os.Getenv("AUDIT_SECRET_ACCESS_ALLOWLIST")
+	if value == "" {
+		return parsing.DefaultSecretAccessAllowlist
+	}
+	allowlist := []string{}
+	for _, user := range strings.Split(value, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			allowlist = append(allowlist, user)
+		}
+	}
+	return allowlist
+}
+
+// AuditSecretAccess fetches the get, list and watch requests on secrets
+// matching params, today by default, leaving out the allowlisted users and
+// those of extraAllowlist, and groups them by user and namespace. Users who
+// read a namespace's secrets without having done so in baselineTimeframe
+// before the timeframe are flagged as first-time accessors.
+func (s *AuditQueryMCPServer) AuditSecretAccess(params types.AuditQueryParams, baselineTimeframe string, extraAllowlist []string) (*types.SecretAccessReport, *types.AuditResult, error) {
+	if params.Timeframe == "" {
+		params.Timeframe = "today"
+	}
+	if baselineTimeframe == "" {
+		baselineTimeframe = defaultSecretAccessBaseline
+	}
+	currentStart, _, ok := commands.TimeframeWindow(params.Timeframe, time.Now())
+	if !ok {
+		return nil, nil, fmt.Errorf("timeframe %q has no start to compare the baseline with; use a timeframe such as 24h or today", params.Timeframe)
+	}
+	s.logger.Infof("Auditing secret access for %s against %s", params.Timeframe, baselineTimeframe)
+
+	allowlist := append(append([]string{}, s.secretAccessAllowlist...), extraAllowlist...)
+	params.Resource, params.Resources = "secrets", nil
+	params.Verb, params.Verbs = "", parsing.SecretReadVerbs
+	params.ExcludeUsers = append(params.ExcludeUsers, allowlist...)
+
+	fetched, result, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, result, err
+	}
+	// The first-time flags rely on the windows not overlapping, so reads
+	// before the timeframe are left to the baseline
+	var entries []parsing.AuditLogEntry
+	for _, entry := range fetched {
+		if timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err != nil || !timestamp.Before(currentStart) {
+			entries = append(entries, entry)
+		}
+	}
+
+	baselineParams := params
+	baselineParams.Timeframe = baselineTimeframe
+	fetched, _, err = s.fetchParsedEntries(baselineParams)
+	if err != nil {
+		return nil, result, fmt.Errorf("baseline query for %s failed: %w", baselineTimeframe, err)
+	}
+	// The baseline usually covers the timeframe too; keep the reads before it
+	var baseline []parsing.AuditLogEntry
+	for _, entry := range fetched {
+		if timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil && timestamp.Before(currentStart) {
+			baseline = append(baseline, entry)
+		}
+	}
+
+	report := parsing.AnalyzeSecretAccess(entries, baseline)
+	report.Timeframe, report.BaselineTimeframe, report.QueryID = params.Timeframe, baselineTimeframe, result.QueryID
+	report.Allowlist = allowlist
+	result.Summary = report.Summary
+
+	s.logger.Infof("Secret access: %s", report.Summary)
+	return &report, result, nil
+}
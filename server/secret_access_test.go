@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditSecretAccess tests the audit_secret_access tool against a baseline and the allowlist
+func TestAuditSecretAccess(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	read := func(minutesAgo int, username, namespace, name string) string {
+		return fmt.Sprintf(`{"kind":"Event","stage":"ResponseComplete","requestReceivedTimestamp":%q,"verb":"get","user":{"username":%q},"objectRef":{"resource":"secrets","namespace":%q,"name":%q},"responseStatus":{"code":200}}`,
+			now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), username, namespace, name)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		read(300, "alice", "payments", "db-password"),
+		read(30, "alice", "payments", "db-password"),
+		read(20, "mallory", "payments", "db-password"),
+		read(10, "system:serviceaccount:openshift-monitoring:prometheus", "payments", "db-password"),
+		read(5, "system:serviceaccount:ci:builder", "ci", "registry-token"),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleAuditSecretAccess("test-id", map[string]interface{}{
+		"structured_params":  map[string]interface{}{"timeframe": "2h"},
+		"baseline_timeframe": "1d",
+		"allowlist":          []interface{}{"system:serviceaccount:ci:*"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.SecretAccessReport)
+	assert.Equal(t, 2, report.TotalReads)
+	assert.Equal(t, 1, report.FirstTimeAccessors)
+	require.Len(t, report.Accessors, 2)
+	assert.Equal(t, "mallory", report.Accessors[0].Username)
+	assert.True(t, report.Accessors[0].NewUser)
+	assert.Equal(t, "alice", report.Accessors[1].Username)
+	assert.False(t, report.Accessors[1].FirstTime)
+	assert.Contains(t, report.Allowlist, "system:serviceaccount:ci:*")
+	assert.Equal(t, "2h", report.Timeframe)
+
+	// The allowlist replaces the default one
+	t.Setenv("AUDIT_SECRET_ACCESS_ALLOWLIST", "alice, mallory")
+	server = newMockServer(t)
+	assert.Equal(t, []string{"alice", "mallory"}, server.Configuration()["secret_access_allowlist"])
+	report, _, err := server.AuditSecretAccess(types.AuditQueryParams{Timeframe: "2h"}, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "7d", report.BaselineTimeframe)
+	require.Len(t, report.Accessors, 2)
+	assert.True(t, report.Accessors[0].FirstTime)
+}
@@ -1,46 +1,223 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/sashabaranov/go-openai"
 	"github.com/sirupsen/logrus"
 
 	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/config"
+	"audit-query-mcp-server/detection"
+	"audit-query-mcp-server/forwarding"
+	"audit-query-mcp-server/indicators"
+	"audit-query-mcp-server/nlp"
 	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/providers"
+	"audit-query-mcp-server/reporting"
+	"audit-query-mcp-server/sourceip"
 	"audit-query-mcp-server/types"
 	"audit-query-mcp-server/utils"
 	"audit-query-mcp-server/validation"
 )
 
+// ServerVersion is the version the server reports to clients and records in
+// result provenance
+const ServerVersion = "1.0.0"
+
 // AuditQueryMCPServer represents the MCP server for OpenShift audit queries
 type AuditQueryMCPServer struct {
 	client     *openai.Client
 	logger     *logrus.Logger
 	cache      *utils.Cache
 	auditTrail *utils.AuditTrail
+
+	// inProcessFiltering fetches raw log lines and filters them in Go instead of jq
+	inProcessFiltering bool
+
+	// hideLegacyTools removes the deprecated tool aliases, from
+	// AUDIT_HIDE_LEGACY_TOOLS
+	hideLegacyTools bool
+
+	// omitRawOutput drops the raw output from the responses of tools other
+	// than the query tools, from AUDIT_OMIT_RAW_OUTPUT
+	omitRawOutput bool
+
+	// reportDir is where generate_audit_report writes report files
+	reportDir string
+
+	// localFileDir is where analyze_local_audit_file reads audit logs from
+	localFileDir string
+
+	// forwarder pushes results to the SIEM destinations in AUDIT_FORWARD_CONFIG
+	forwarder *forwarding.Forwarder
+
+	// cacheFile is where the cache is saved on shutdown and restored on start
+	cacheFile string
+
+	// incrementalQueries reuses cached results for overlapping windows; coverage
+	// maps commands.CoverageKey values to the window a cached result holds
+	incrementalQueries bool
+	coverage           map[string]queryCoverage
+	coverageMutex      sync.Mutex
+
+	// circuit counts command execution failures; while it is open, generated
+	// commands use the builder's fallback command
+	circuit *types.CircuitBreaker
+
+	// availability caches probed log source availability for availabilityTTL
+	availability      map[string]cachedAvailability
+	availabilityTTL   time.Duration
+	availabilityMutex sync.Mutex
+
+	// clusterLookup runs the read-only oc commands of result enrichment, whose
+	// outputs lookupCache keeps for enrichmentTTL
+	clusterLookup    func(args []string) (string, error)
+	lookupCache      map[string]cachedLookup
+	lookupCacheMutex sync.Mutex
+	enrichmentTTL    time.Duration
+
+	// internalNetworks and geoDatabase are the configured source IP
+	// classification hooks from AUDIT_INTERNAL_CIDRS and AUDIT_GEOIP_DATABASE
+	internalNetworks *sourceip.NetworkHook
+	geoDatabase      *sourceip.GeoDatabase
+
+	// provider is the default query backend, reading raw audit logs on
+	// clusters without oc adm node-logs; nil builds oc commands for OpenShift.
+	// backends holds every configured backend by name, for queries that select one
+	provider providers.QueryBackend
+	backends map[string]providers.QueryBackend
+
+	// providerCommands maps generated provider commands to their queries
+	providerCommands      map[string]providerQuery
+	providerCommandsMutex sync.Mutex
+
+	// idempotency holds the execute tool calls made with an idempotency key,
+	// whose responses are replayed to re-submissions within idempotencyWindow
+	idempotency       map[string]*idempotencyRecord
+	idempotencyWindow time.Duration
+	idempotencyMutex  sync.Mutex
+
+	// resultSigningKey signs the integrity hashes of results, from
+	// AUDIT_RESULT_SIGNING_KEY; results are only hashed without it
+	resultSigningKey []byte
+
+	// templates are the saved query templates from AUDIT_QUERY_TEMPLATES,
+	// settings the resolved settings and the files they were loaded from, and
+	// parser the configuration audit log lines are parsed with. They and
+	// indicators are replaced by ReloadConfig, and parser by ConfigureParser,
+	// under configMutex.
+	templates   []types.QueryTemplate
+	settings    *config.Config
+	parser      parsing.ParserConfig
+	configMutex sync.RWMutex
+
+	// narrator writes narrative summaries with narrativeModel; nil without
+	// OPENAI_API_KEY
+	narrator       reporting.Completer
+	narrativeModel string
+
+	// summaryTemplates render result summaries, customized by
+	// AUDIT_SUMMARY_TEMPLATE and AUDIT_SUMMARY_VERBOSE_TEMPLATE
+	summaryTemplates *parsing.SummaryTemplates
+
+	// querySlots schedules batch queries, bounding how many run at once to
+	// AUDIT_MAX_CONCURRENT_QUERIES across all batches
+	querySlots chan struct{}
+
+	// findings holds the results and entries flagged as notable, shared by
+	// the cluster servers; nil when the findings file cannot be loaded
+	findings *utils.FindingStore
+
+	// watchRules are the detections from AUDIT_WATCH_RULES, evaluated every
+	// watchInterval from the high-water marks in watchStates. alerts keeps the
+	// most recent alerts they raised and lastAlerts when each rule and group
+	// last raised one
+	watchRules    []detection.Rule
+	watchInterval time.Duration
+	watchStates   map[string]*watchState
+	watchMutex    sync.Mutex
+	alerts        []types.Alert
+	lastAlerts    map[string]time.Time
+	alertSequence int
+	alertsMutex   sync.Mutex
+
+	// indicators are the threat intelligence indicators from
+	// AUDIT_INDICATORS matched by match_indicators, shared by the cluster
+	// servers; nil when none are configured or they cannot be loaded
+	indicators *indicators.Set
+
+	// secretAccessAllowlist are the users audit_secret_access expects to
+	// read secrets, from AUDIT_SECRET_ACCESS_ALLOWLIST
+	secretAccessAllowlist []string
+
+	// slowQueries logs the requests taking at least AUDIT_SLOW_QUERY_THRESHOLD
+	// to AUDIT_SLOW_QUERY_LOG; nil when the threshold is 0. requests counts
+	// the handled requests by method and tool.
+	slowQueries *utils.SlowQueryLog
+	requests    requestMetrics
+
+	// inFlight tracks the running query executions, shared by the cluster
+	// servers so operators can list and cancel all of them
+	inFlight *inFlightQueries
+
+	// quarantine keeps the lines the parser rejected when parser
+	// quarantining is on, shared by the cluster servers
+	quarantine *parseQuarantine
+
+	// session is the MCP session negotiated by initialize
+	session      mcpSession
+	sessionMutex sync.Mutex
+
+	// subscriptions holds the resource URIs clients subscribed to, and
+	// notifier delivers notifications through the transport
+	subscriptions      map[string]bool
+	notifier           func(types.MCPNotification)
+	subscriptionsMutex sync.Mutex
+
+	// clusters holds the server of each registered cluster by name. A cluster
+	// server keeps its own cache, circuit breaker and audit trail, runs oc
+	// with ocFlags selecting its cluster, and sends notifications through its
+	// parent; cluster is empty for the default server
+	clusters map[string]*AuditQueryMCPServer
+	cluster  string
+	ocFlags  []string
+	parent   *AuditQueryMCPServer
 }
 
 // NewAuditQueryMCPServer creates a new MCP server instance
 func NewAuditQueryMCPServer() *AuditQueryMCPServer {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	// Settings come from the environment, then the .env file, then the YAML
+	// config file
+	settings, err := config.Load()
+	if err != nil {
+		log.Printf("Warning: Failed to load configuration: %v", err)
+	}
+	if !settings.EnvFileLoaded {
+		log.Printf("No %s file found, using system environment variables", settings.EnvFile)
 	}
 
 	// Initialize OpenAI client (optional for current implementation)
 	var client *openai.Client
+	var narrator reporting.Completer
+	narrativeModel := os.Getenv("AUDIT_NARRATIVE_MODEL")
+	if narrativeModel == "" {
+		narrativeModel = DefaultNarrativeModel
+	}
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey != "" && apiKey != "dummy_key_for_testing" {
 		client = openai.NewClient(apiKey)
-		log.Println("OpenAI client initialized for future LLM integration")
+		narrator = &openAICompleter{client: client, model: narrativeModel}
+		log.Printf("OpenAI client initialized - narrative summaries use %s", narrativeModel)
 	} else {
 		log.Println("OpenAI API key not provided - LLM features will be disabled")
 		log.Println("This is normal for the current rule-based implementation")
@@ -53,27 +230,562 @@ func NewAuditQueryMCPServer() *AuditQueryMCPServer {
 		FullTimestamp: true,
 	})
 
-	// Initialize cache with 1 hour default TTL
-	cache := utils.NewCache(1 * time.Hour)
+	// Initialize cache with 1 hour default TTL, restoring entries saved at the last shutdown
+	cache := newCacheFromEnv()
+	cacheFile := os.Getenv("AUDIT_CACHE_FILE")
+	if cacheFile != "" {
+		loaded, err := cache.LoadFromFile(cacheFile)
+		if err != nil {
+			log.Printf("Warning: Failed to load cache from %s: %v", cacheFile, err)
+		} else if loaded > 0 {
+			log.Printf("Restored %d cached results from %s", loaded, cacheFile)
+		}
+	}
 
-	// Initialize audit trail
-	auditTrail, err := utils.NewAuditTrail("./logs/audit_trail.json")
+	// Initialize audit trail, optionally mirrored to (or replaced by) syslog
+	syslogWriter := newSyslogWriterFromEnv()
+	syslogOnly, _ := strconv.ParseBool(os.Getenv("AUDIT_SYSLOG_ONLY"))
+	auditTrail, err := newAuditTrail(AuditTrailFile(), syslogWriter, syslogOnly)
 	if err != nil {
 		log.Printf("Warning: Failed to initialize audit trail: %v", err)
 		auditTrail = nil
 	}
 
-	return &AuditQueryMCPServer{
-		client:     client,
-		logger:     logger,
-		cache:      cache,
-		auditTrail: auditTrail,
+	// In-process filtering removes the jq dependency on the host running the server
+	inProcessFiltering, _ := strconv.ParseBool(os.Getenv("AUDIT_IN_PROCESS_FILTERING"))
+	if inProcessFiltering {
+		log.Println("In-process filtering enabled - commands fetch raw logs and filters run in Go")
+	}
+
+	// jq programs run with the jq binary when it is installed, or with the
+	// built-in engine, unless AUDIT_JQ_ENGINE selects one
+	jqEngine := os.Getenv("AUDIT_JQ_ENGINE")
+	if jqEngine == "" {
+		jqEngine = commands.JQEngineAuto
+	}
+	if err := commands.SetJQEngine(jqEngine); err != nil {
+		log.Printf("Warning: Invalid AUDIT_JQ_ENGINE: %s", jqEngine)
+		commands.SetJQEngine(commands.JQEngineAuto)
+	}
+
+	// Patterns and exclusions beyond AUDIT_MAX_PATTERNS and
+	// AUDIT_MAX_EXCLUSIONS are dropped from generated commands
+	configureComplexityLimitsFromEnv()
+
+	// Reports requested with an output file are written below this directory
+	reportDir := os.Getenv("AUDIT_REPORT_DIR")
+	if reportDir == "" {
+		reportDir = "./reports"
+	}
+
+	// Exported audit logs analyzed without cluster access are read below this directory
+	localFileDir := os.Getenv("AUDIT_LOCAL_FILE_DIR")
+	if localFileDir == "" {
+		localFileDir = DefaultLocalFileDir
+	}
+
+	// SIEM destinations are optional; without a config nothing can be forwarded
+	var destinations []forwarding.Destination
+	if configPath := os.Getenv("AUDIT_FORWARD_CONFIG"); configPath != "" {
+		destinations, err = forwarding.LoadConfig(configPath)
+		if err != nil {
+			log.Printf("Warning: Failed to load forwarding config: %v", err)
+			destinations = nil
+		}
+	}
+	forwarder, err := forwarding.NewForwarder(destinations)
+	if err != nil {
+		log.Printf("Warning: Invalid forwarding config: %v", err)
+		forwarder, _ = forwarding.NewForwarder(nil)
+	}
+	if len(destinations) > 0 {
+		log.Printf("Forwarding destinations configured: %s", strings.Join(forwarder.Destinations(), ", "))
+	}
+
+	// Tool responses keep the raw output unless AUDIT_OMIT_RAW_OUTPUT drops it
+	omitRawOutput := false
+	if value := os.Getenv("AUDIT_OMIT_RAW_OUTPUT"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			omitRawOutput = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_OMIT_RAW_OUTPUT: %s", value)
+		}
+	}
+
+	// Deprecated tool aliases are answered unless AUDIT_HIDE_LEGACY_TOOLS hides them
+	hideLegacyTools := false
+	if value := os.Getenv("AUDIT_HIDE_LEGACY_TOOLS"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			hideLegacyTools = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_HIDE_LEGACY_TOOLS: %s", value)
+		}
+	}
+
+	// Incremental querying is on unless AUDIT_INCREMENTAL_QUERIES disables it
+	incrementalQueries := true
+	if value := os.Getenv("AUDIT_INCREMENTAL_QUERIES"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			incrementalQueries = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_INCREMENTAL_QUERIES: %s", value)
+		}
+	}
+
+	// Probed log source availability is reused for AUDIT_AVAILABILITY_TTL
+	availabilityTTL := DefaultAvailabilityTTL
+	if value := os.Getenv("AUDIT_AVAILABILITY_TTL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			availabilityTTL = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_AVAILABILITY_TTL: %s", value)
+		}
+	}
+
+	// Enrichment lookups are reused for AUDIT_ENRICHMENT_TTL
+	enrichmentTTL := DefaultEnrichmentTTL
+	if value := os.Getenv("AUDIT_ENRICHMENT_TTL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			enrichmentTTL = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_ENRICHMENT_TTL: %s", value)
+		}
+	}
+
+	// Source IPs are classified as internal by AUDIT_INTERNAL_CIDRS and located
+	// by the offline AUDIT_GEOIP_DATABASE
+	var internalNetworks *sourceip.NetworkHook
+	if value := os.Getenv("AUDIT_INTERNAL_CIDRS"); value != "" {
+		if networks, err := sourceip.ParseNetworks(value); err == nil {
+			internalNetworks = sourceip.NewNetworkHook("internal-networks", sourceip.CategoryInternal, networks)
+		} else {
+			log.Printf("Warning: Invalid AUDIT_INTERNAL_CIDRS: %v", err)
+		}
+	}
+	var geoDatabase *sourceip.GeoDatabase
+	if path := os.Getenv("AUDIT_GEOIP_DATABASE"); path != "" {
+		geoDatabase, err = sourceip.LoadGeoDatabase(path)
+		if err != nil {
+			log.Printf("Warning: Failed to load GeoIP database: %v", err)
+			geoDatabase = nil
+		} else {
+			log.Printf("Loaded GeoIP database with %d networks", geoDatabase.Len())
+		}
+	}
+
+	// A non-OpenShift provider replaces oc commands with fetches filtered in Go;
+	// queries can also select any configured backend
+	provider, backends, err := providers.FromEnv()
+	if err != nil {
+		log.Printf("Warning: Invalid provider configuration, using OpenShift: %v", err)
+		provider, backends = nil, nil
+	}
+	if provider != nil {
+		log.Printf("Reading audit logs with the %s provider", provider.Name())
+	}
+
+	// Custom log sources are registered before templates, which may use them
+	if logSourcesPath := os.Getenv("AUDIT_LOG_SOURCES_CONFIG"); logSourcesPath != "" {
+		sources, err := utils.LoadLogSources(logSourcesPath)
+		if err == nil {
+			err = utils.RegisterLogSources(sources)
+		}
+		if err != nil {
+			log.Printf("Warning: Failed to load log sources: %v", err)
+		} else if len(sources) > 0 {
+			log.Printf("Registered %d custom log sources", len(sources))
+		}
+	}
+
+	// Saved query templates are exposed as MCP resources
+	var templates []types.QueryTemplate
+	if templatesPath := os.Getenv("AUDIT_QUERY_TEMPLATES"); templatesPath != "" {
+		templates, err = LoadQueryTemplates(templatesPath)
+		if err != nil {
+			log.Printf("Warning: Failed to load query templates: %v", err)
+			templates = nil
+		}
+	}
+
+	// Findings flagged during investigations are saved next to the audit trail
+	findingsFile := os.Getenv("AUDIT_FINDINGS_FILE")
+	if findingsFile == "" {
+		findingsFile = DefaultFindingsFile
+	}
+	findings, err := utils.NewFindingStore(findingsFile)
+	if err != nil {
+		log.Printf("Warning: Failed to load findings, annotations are disabled: %v", err)
+		findings = nil
+	}
+
+	// Watch rules raise alerts on new events matching their filters
+	var watchRules []detection.Rule
+	if rulesPath := os.Getenv("AUDIT_WATCH_RULES"); rulesPath != "" {
+		watchRules, err = detection.LoadRules(rulesPath)
+		if err != nil {
+			log.Printf("Warning: Failed to load watch rules: %v", err)
+			watchRules = nil
+		}
+		for _, rule := range watchRules {
+			for _, destination := range rule.Notify {
+				if !utils.Contains(forwarder.Destinations(), destination) {
+					log.Printf("Warning: Watch rule %s notifies unknown forwarding destination %s", rule.Name, destination)
+				}
+			}
+		}
+	}
+	var indicatorSet *indicators.Set
+	if value := os.Getenv("AUDIT_INDICATORS"); value != "" {
+		indicatorSet, err = loadIndicators(value)
+		if err != nil {
+			log.Printf("Warning: Failed to load indicators: %v", err)
+			indicatorSet = nil
+		}
+	}
+	watchInterval := DefaultWatchInterval
+	if value := os.Getenv("AUDIT_WATCH_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			watchInterval = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_WATCH_INTERVAL: %s", value)
+		}
+	}
+
+	// Responses to idempotency keys are replayed for AUDIT_IDEMPOTENCY_WINDOW
+	idempotencyWindow := DefaultIdempotencyWindow
+	if value := os.Getenv("AUDIT_IDEMPOTENCY_WINDOW"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			idempotencyWindow = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_IDEMPOTENCY_WINDOW: %s", value)
+		}
+	}
+
+	// Requests taking AUDIT_SLOW_QUERY_THRESHOLD are logged to AUDIT_SLOW_QUERY_LOG
+	slowQueryThreshold := DefaultSlowQueryThreshold
+	if value := os.Getenv("AUDIT_SLOW_QUERY_THRESHOLD"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			slowQueryThreshold = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_SLOW_QUERY_THRESHOLD: %s", value)
+		}
+	}
+	var slowQueries *utils.SlowQueryLog
+	if slowQueryThreshold > 0 {
+		slowQueryFile := os.Getenv("AUDIT_SLOW_QUERY_LOG")
+		if slowQueryFile == "" {
+			slowQueryFile = DefaultSlowQueryLogFile
+		}
+		slowQueries, err = utils.NewSlowQueryLog(slowQueryFile, slowQueryThreshold)
+		if err != nil {
+			log.Printf("Warning: Failed to initialize slow query log: %v", err)
+			slowQueries = nil
+		}
+	}
+
+	// Custom summary templates replace the built-in sentence styles
+	summaryTemplates, err := parsing.LoadSummaryTemplates(os.Getenv("AUDIT_SUMMARY_TEMPLATE"), os.Getenv("AUDIT_SUMMARY_VERBOSE_TEMPLATE"))
+	if err != nil {
+		log.Printf("Warning: Failed to load summary templates, using the built-in ones: %v", err)
+		summaryTemplates = parsing.DefaultSummaryTemplates()
+	}
+
+	s := &AuditQueryMCPServer{
+		client:                client,
+		logger:                logger,
+		cache:                 cache,
+		auditTrail:            auditTrail,
+		inProcessFiltering:    inProcessFiltering,
+		omitRawOutput:         omitRawOutput,
+		hideLegacyTools:       hideLegacyTools,
+		slowQueries:           slowQueries,
+		inFlight:              newInFlightQueries(),
+		quarantine:            &parseQuarantine{},
+		reportDir:             reportDir,
+		localFileDir:          localFileDir,
+		forwarder:             forwarder,
+		cacheFile:             cacheFile,
+		incrementalQueries:    incrementalQueries,
+		coverage:              make(map[string]queryCoverage),
+		circuit:               newCircuitBreakerFromEnv(),
+		availability:          make(map[string]cachedAvailability),
+		availabilityTTL:       availabilityTTL,
+		clusterLookup:         runOc,
+		lookupCache:           make(map[string]cachedLookup),
+		enrichmentTTL:         enrichmentTTL,
+		internalNetworks:      internalNetworks,
+		geoDatabase:           geoDatabase,
+		provider:              provider,
+		backends:              backends,
+		providerCommands:      make(map[string]providerQuery),
+		idempotency:           make(map[string]*idempotencyRecord),
+		idempotencyWindow:     idempotencyWindow,
+		resultSigningKey:      []byte(os.Getenv("AUDIT_RESULT_SIGNING_KEY")),
+		templates:             templates,
+		narrator:              narrator,
+		narrativeModel:        narrativeModel,
+		summaryTemplates:      summaryTemplates,
+		subscriptions:         make(map[string]bool),
+		querySlots:            make(chan struct{}, maxConcurrentQueriesFromEnv()),
+		findings:              findings,
+		watchRules:            watchRules,
+		watchInterval:         watchInterval,
+		watchStates:           make(map[string]*watchState),
+		lastAlerts:            make(map[string]time.Time),
+		indicators:            indicatorSet,
+		secretAccessAllowlist: secretAccessAllowlistFromEnv(),
+		settings:              settings,
+		parser:                parserConfigFromEnv(),
+	}
+
+	// Registered clusters get their own server, selected by the cluster argument
+	s.registerClusters(clustersFromEnv(), syslogWriter, syslogOnly)
+	return s
+}
+
+// newCacheFromEnv creates a result cache with a 1 hour default TTL and the
+// limits configured in the environment
+func newCacheFromEnv() *utils.Cache {
+	cache := utils.NewCache(1 * time.Hour)
+	configureCacheFromEnv(cache)
+	return cache
+}
+
+// DefaultAuditTrailFile is where the audit trail is written unless
+// AUDIT_TRAIL_FILE is set
+const DefaultAuditTrailFile = "./logs/audit_trail.json"
+
+// AuditTrailFile returns the audit trail file, from AUDIT_TRAIL_FILE or the
+// default. The trails of registered clusters are written next to it.
+func AuditTrailFile() string {
+	if path := os.Getenv("AUDIT_TRAIL_FILE"); path != "" {
+		return path
+	}
+	return DefaultAuditTrailFile
+}
+
+// newAuditTrail creates an audit trail written to path with the configured
+// retention, mirrored to syslogWriter when it is set or written only to it
+// when syslogOnly is set
+func newAuditTrail(path string, syslogWriter *utils.SyslogWriter, syslogOnly bool) (*utils.AuditTrail, error) {
+	if syslogWriter != nil && syslogOnly {
+		return utils.NewSyslogAuditTrail(syslogWriter)
+	}
+	auditTrail, err := utils.NewAuditTrail(path)
+	if err != nil {
+		return nil, err
+	}
+	if syslogWriter != nil {
+		auditTrail.SetSyslog(syslogWriter)
+	}
+	if err := auditTrail.SetRetention(auditTrailRetentionFromEnv()); err != nil {
+		return nil, err
+	}
+	return auditTrail, nil
+}
+
+// newCircuitBreakerFromEnv creates the command circuit breaker with the failure
+// threshold from AUDIT_CIRCUIT_FAILURE_THRESHOLD and the reset timeout from
+// AUDIT_CIRCUIT_RESET_TIMEOUT; invalid values keep the default
+func newCircuitBreakerFromEnv() *types.CircuitBreaker {
+	threshold := types.DefaultCircuitFailureThreshold
+	resetTimeout := types.DefaultCircuitResetTimeout
+
+	if value := os.Getenv("AUDIT_CIRCUIT_FAILURE_THRESHOLD"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			threshold = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_CIRCUIT_FAILURE_THRESHOLD: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_CIRCUIT_RESET_TIMEOUT"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			resetTimeout = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_CIRCUIT_RESET_TIMEOUT: %s", value)
+		}
+	}
+
+	return types.NewCircuitBreaker(threshold, resetTimeout)
+}
+
+// configureComplexityLimitsFromEnv sets the pattern and exclusion limits of
+// generated commands from AUDIT_MAX_PATTERNS and AUDIT_MAX_EXCLUSIONS, where 0
+// disables a limit; invalid values keep the default
+func configureComplexityLimitsFromEnv() {
+	maxPatterns := commands.DefaultMaxPatterns
+	maxExclusions := commands.DefaultMaxExclusions
+
+	if value := os.Getenv("AUDIT_MAX_PATTERNS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			maxPatterns = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_MAX_PATTERNS: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_MAX_EXCLUSIONS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			maxExclusions = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_MAX_EXCLUSIONS: %s", value)
+		}
+	}
+
+	commands.SetComplexityLimits(maxPatterns, maxExclusions)
+}
+
+// maxConcurrentQueriesFromEnv returns how many batch queries may run at once,
+// from AUDIT_MAX_CONCURRENT_QUERIES; invalid values keep the default
+func maxConcurrentQueriesFromEnv() int {
+	maxQueries := types.DefaultAuditQueryConfig().MaxConcurrentQueries
+	if value := os.Getenv("AUDIT_MAX_CONCURRENT_QUERIES"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			maxQueries = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_MAX_CONCURRENT_QUERIES: %s", value)
+		}
+	}
+	return maxQueries
+}
+
+// configureCacheFromEnv applies the cache bounds from AUDIT_CACHE_MAX_ENTRIES and
+// AUDIT_CACHE_MAX_MB, where 0 disables a bound, the empty result TTL from
+// AUDIT_CACHE_NEGATIVE_TTL, where 0 disables negative caching, and the raw
+// output size from which it is compressed from AUDIT_CACHE_COMPRESS_MIN_KB,
+// where 0 disables compression. Invalid values keep the default.
+func configureCacheFromEnv(cache *utils.Cache) {
+	maxEntries := utils.DefaultCacheMaxEntries
+	maxBytes := int64(utils.DefaultCacheMaxBytes)
+
+	if value := os.Getenv("AUDIT_CACHE_MAX_ENTRIES"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			maxEntries = parsed
+		} else {
+			log.Printf("Warning: Invalid AUDIT_CACHE_MAX_ENTRIES: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_CACHE_MAX_MB"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			maxBytes = int64(parsed) * 1024 * 1024
+		} else {
+			log.Printf("Warning: Invalid AUDIT_CACHE_MAX_MB: %s", value)
+		}
+	}
+
+	cache.SetLimits(maxEntries, maxBytes)
+
+	if value := os.Getenv("AUDIT_CACHE_NEGATIVE_TTL"); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl >= 0 {
+			cache.SetNegativeTTL(ttl)
+		} else {
+			log.Printf("Warning: Invalid AUDIT_CACHE_NEGATIVE_TTL: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_CACHE_COMPRESS_MIN_KB"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			cache.SetCompression(parsed * 1024)
+		} else {
+			log.Printf("Warning: Invalid AUDIT_CACHE_COMPRESS_MIN_KB: %s", value)
+		}
+	}
+}
+
+// Shutdown saves the cache when AUDIT_CACHE_FILE is set and closes the audit
+// trail, also for each registered cluster
+func (s *AuditQueryMCPServer) Shutdown() error {
+	var firstErr error
+	if s.parent == nil {
+		for _, name := range s.ClusterNames() {
+			if err := s.clusters[name].Shutdown(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("cluster %s: %w", name, err)
+			}
+		}
+	}
+	if s.cacheFile != "" {
+		if err := s.cache.SaveToFile(s.cacheFile); err != nil {
+			firstErr = err
+		} else {
+			s.logger.Infof("Saved %d cached results to %s", s.cache.Size(), s.cacheFile)
+		}
+	}
+	if s.auditTrail != nil {
+		if err := s.auditTrail.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// newSyslogWriterFromEnv connects to the syslog endpoint in AUDIT_SYSLOG_ADDRESS,
+// returning nil when syslog output is not configured or the endpoint is unusable
+func newSyslogWriterFromEnv() *utils.SyslogWriter {
+	address := os.Getenv("AUDIT_SYSLOG_ADDRESS")
+	if address == "" {
+		return nil
+	}
+
+	writer, err := utils.NewSyslogWriter(utils.SyslogConfig{
+		Network:  os.Getenv("AUDIT_SYSLOG_NETWORK"),
+		Address:  address,
+		Facility: os.Getenv("AUDIT_SYSLOG_FACILITY"),
+		CAFile:   os.Getenv("AUDIT_SYSLOG_CA_FILE"),
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to initialize syslog output: %v", err)
+		return nil
+	}
+	log.Printf("Audit trail entries will be sent to syslog at %s", address)
+	return writer
+}
+
+// auditTrailRetentionFromEnv reads the audit trail rotation and retention policy.
+// Invalid values are logged and leave the corresponding limit disabled.
+func auditTrailRetentionFromEnv() utils.AuditTrailRetention {
+	retention := utils.AuditTrailRetention{Compress: true}
+
+	if value := os.Getenv("AUDIT_TRAIL_MAX_SIZE_MB"); value != "" {
+		if sizeMB, err := strconv.Atoi(value); err == nil && sizeMB > 0 {
+			retention.MaxSizeBytes = int64(sizeMB) * 1024 * 1024
+		} else {
+			log.Printf("Warning: Invalid AUDIT_TRAIL_MAX_SIZE_MB: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_TRAIL_MAX_AGE"); value != "" {
+		if maxAge, err := time.ParseDuration(value); err == nil {
+			retention.MaxAge = maxAge
+		} else {
+			log.Printf("Warning: Invalid AUDIT_TRAIL_MAX_AGE: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_TRAIL_MAX_BACKUPS"); value != "" {
+		if maxBackups, err := strconv.Atoi(value); err == nil && maxBackups >= 0 {
+			retention.MaxBackups = maxBackups
+		} else {
+			log.Printf("Warning: Invalid AUDIT_TRAIL_MAX_BACKUPS: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_TRAIL_RETENTION"); value != "" {
+		if retentionAge, err := time.ParseDuration(value); err == nil {
+			retention.RetentionAge = retentionAge
+		} else {
+			log.Printf("Warning: Invalid AUDIT_TRAIL_RETENTION: %s", value)
+		}
+	}
+	if value := os.Getenv("AUDIT_TRAIL_COMPRESS"); value != "" {
+		retention.Compress, _ = strconv.ParseBool(value)
 	}
+
+	return retention
+}
+
+// SetInProcessFiltering enables or disables filtering raw log lines in Go instead of jq
+func (s *AuditQueryMCPServer) SetInProcessFiltering(enabled bool) {
+	s.inProcessFiltering = enabled
 }
 
 // GetTools returns the list of available MCP tools
 func (s *AuditQueryMCPServer) GetTools() []types.MCPTool {
-	return []types.MCPTool{
+	return s.withToolMetadata(s.withClusterParameter([]types.MCPTool{
 		// New AuditResult-based tools
 		{
 			Name:        "generate_audit_query_with_result",
@@ -81,43 +793,7 @@ func (s *AuditQueryMCPServer) GetTools() []types.MCPTool {
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"structured_params": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"log_source": map[string]interface{}{
-								"type": "string",
-								"enum": []string{"kube-apiserver", "oauth-server", "node", "openshift-apiserver", "oauth-apiserver"},
-							},
-							"patterns": map[string]interface{}{
-								"type": "array",
-								"items": map[string]interface{}{
-									"type": "string",
-								},
-							},
-							"timeframe": map[string]interface{}{
-								"type": "string",
-							},
-							"username": map[string]interface{}{
-								"type": "string",
-							},
-							"resource": map[string]interface{}{
-								"type": "string",
-							},
-							"verb": map[string]interface{}{
-								"type": "string",
-							},
-							"namespace": map[string]interface{}{
-								"type": "string",
-							},
-							"exclude": map[string]interface{}{
-								"type": "array",
-								"items": map[string]interface{}{
-									"type": "string",
-								},
-							},
-						},
-						"required": []string{"log_source", "timeframe"},
-					},
+					"structured_params": structuredParamsSchema(),
 				},
 				"required": []string{"structured_params"},
 			},
@@ -134,6 +810,9 @@ func (s *AuditQueryMCPServer) GetTools() []types.MCPTool {
 					"query_id": map[string]interface{}{
 						"type": "string",
 					},
+					"idempotency_key":    idempotencyKeySchema(),
+					"include_raw_output": includeRawOutputSchema(),
+					"fields":             fieldsSchema(),
 				},
 				"required": []string{"command", "query_id"},
 			},
@@ -163,359 +842,2077 @@ func (s *AuditQueryMCPServer) GetTools() []types.MCPTool {
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"structured_params": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"log_source": map[string]interface{}{
-								"type": "string",
-								"enum": []string{"kube-apiserver", "oauth-server", "node", "openshift-apiserver", "oauth-apiserver"},
-							},
-							"patterns": map[string]interface{}{
-								"type": "array",
-								"items": map[string]interface{}{
-									"type": "string",
-								},
-							},
-							"timeframe": map[string]interface{}{
-								"type": "string",
-							},
-							"username": map[string]interface{}{
-								"type": "string",
-							},
-							"resource": map[string]interface{}{
-								"type": "string",
-							},
-							"verb": map[string]interface{}{
-								"type": "string",
-							},
-							"namespace": map[string]interface{}{
-								"type": "string",
-							},
-							"exclude": map[string]interface{}{
-								"type": "array",
-								"items": map[string]interface{}{
-									"type": "string",
-								},
-							},
-						},
-						"required": []string{"log_source", "timeframe"},
+					"structured_params": structuredParamsSchema(),
+					"narrative":         narrativeSchema(),
+					"enrich": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Annotate each parsed entry with a cluster_context from read-only oc lookups: whether the object still exists, the service account behind the user and the workloads running as it, and the namespace's labels",
 					},
+					"classify_sources": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Classify each parsed entry's source IPs as internal, cluster-node, cluster-pod, cluster-service, private or external, flagging entries with an external source",
+					},
+					"idempotency_key":    idempotencyKeySchema(),
+					"include_raw_output": includeRawOutputSchema(),
+					"fields":             fieldsSchema(),
 				},
 				"required": []string{"structured_params"},
 			},
 		},
-		// Cache management tools
 		{
-			Name:        "get_cache_stats",
-			Description: "Get cache statistics and performance metrics",
+			Name:        "explain_audit_query",
+			Description: "Explain which fields, patterns and timeframe a generated audit command filters on, including patterns dropped by complexity limits",
 			InputSchema: map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"command": map[string]interface{}{
+						"type": "string",
+					},
+				},
 			},
 		},
 		{
-			Name:        "clear_cache",
-			Description: "Clear all cached audit results",
+			Name:        "estimate_query",
+			Description: "Estimate how many entries a query would return and how much log it reads before running it: backends with a count API count the matches, otherwise the raw log is fetched and the matching lines counted without parsing. Recommends narrowing the query when the estimate is large",
 			InputSchema: map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"max_entries": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Matching entries above which narrowing the query is recommended (default %d)", DefaultEstimateMaxEntries),
+					},
+				},
+				"required": []string{"structured_params"},
 			},
 		},
 		{
-			Name:        "get_cached_result",
-			Description: "Retrieve a cached audit result by query ID",
+			Name:        "ask_audit_question",
+			Description: "Answer a natural-language audit question in one call: translate it to query parameters, then generate, execute, parse and summarize. Returns the interpreted parameters alongside the results",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"query_id": map[string]interface{}{
-						"type": "string",
+					"question": map[string]interface{}{
+						"type":        "string",
+						"description": "Question such as \"who deleted secrets in the payments namespace yesterday\"",
 					},
+					"narrative":          narrativeSchema(),
+					"idempotency_key":    idempotencyKeySchema(),
+					"include_raw_output": includeRawOutputSchema(),
+					"fields":             fieldsSchema(),
 				},
-				"required": []string{"query_id"},
+				"required": []string{"question"},
 			},
 		},
 		{
-			Name:        "delete_cached_result",
-			Description: "Delete a specific cached audit result by query ID",
+			Name:        "find_permission_denials",
+			Description: "Find requests refused by the authorizer (authorization.k8s.io/decision=forbid or HTTP 403), grouped by user, resource and the RBAC verb/resource rule being hit, with likely privilege escalation attempts flagged",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"query_id": map[string]interface{}{
-						"type": "string",
+					"structured_params": structuredParamsSchema(),
+				},
+			},
+		},
+		{
+			Name:        "compare_audit_activity",
+			Description: "Run the same filters over two timeframes or for two users and return the difference: resources, namespaces and source IPs seen only in the comparison, and verbs whose frequency changed. Useful for before/after incident analysis",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"compare_by": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter that differs between the two runs",
+						"enum":        []string{"timeframe", "username"},
+					},
+					"baseline": map[string]interface{}{
+						"type":        "string",
+						"description": "Timeframe or username of the baseline run, such as \"yesterday\"",
+					},
+					"comparison": map[string]interface{}{
+						"type":        "string",
+						"description": "Timeframe or username of the comparison run, such as \"today\"",
 					},
 				},
-				"required": []string{"query_id"},
+				"required": []string{"compare_by", "baseline", "comparison"},
 			},
 		},
 		{
-			Name:        "get_server_stats",
-			Description: "Get comprehensive server statistics and feature information",
+			Name:        "build_user_timeline",
+			Description: "Reconstruct a user's session as a chronological timeline: events in time order, bursts of identical operations collapsed, and idle gaps highlighted, with a narrative suitable for incident reports",
 			InputSchema: map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
+				"type": "object",
+				"properties": map[string]interface{}{
+					"username": map[string]interface{}{
+						"type":        "string",
+						"description": "User whose activity to reconstruct",
+					},
+					"structured_params": structuredParamsSchema(),
+					"burst_window": map[string]interface{}{
+						"type":        "string",
+						"description": "Identical operations closer together than this are collapsed, as a Go duration such as \"30s\"; 1m by default",
+					},
+					"gap_threshold": map[string]interface{}{
+						"type":        "string",
+						"description": "Idle periods longer than this are reported as gaps, such as \"15m\"; 30m by default",
+					},
+				},
+				"required": []string{"username"},
 			},
 		},
-	}
-}
-
-// GetLogger returns the logger instance
-func (s *AuditQueryMCPServer) GetLogger() *logrus.Logger {
-	return s.logger
-}
-
-// GenerateAuditQueryWithResult converts JSON parameters to safe oc audit commands and returns AuditResult
-func (s *AuditQueryMCPServer) GenerateAuditQueryWithResult(params types.AuditQueryParams) (*types.AuditResult, error) {
-	s.logger.Info("Generating audit query from parameters with result tracking")
-
-	startTime := time.Now()
-	queryID := s.generateQueryID()
-
-	result := &types.AuditResult{
-		QueryID:   queryID,
+		{
+			Name:        "generate_audit_report",
+			Description: "Turn an audit result into a Markdown or HTML report with the summary, top users, resources and verbs, and notable events such as denials, deletions and RBAC changes. Uses a cached result by query_id or runs structured_params; optionally writes the report to a file",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of a cached result to report on",
+					},
+					"structured_params": structuredParamsSchema(),
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Report format, markdown by default",
+						"enum":        reporting.Formats,
+					},
+					"title": map[string]interface{}{
+						"type": "string",
+					},
+					"output_file": map[string]interface{}{
+						"type":        "string",
+						"description": "File name ending in .md or .html to write the report to, inside the server's report directory",
+					},
+				},
+			},
+		},
+		{
+			Name:        "forward_audit_results",
+			Description: "Push the parsed events of an audit result to a configured SIEM destination (Splunk HTTP Event Collector or Elasticsearch bulk API) in batches with retry. Uses a cached result by query_id or runs structured_params",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"destination": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of a destination from the server's forwarding config",
+					},
+					"query_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of a cached result to forward",
+					},
+					"structured_params": structuredParamsSchema(),
+				},
+				"required": []string{"destination"},
+			},
+		},
+		{
+			Name:        "get_audit_trail",
+			Description: "Search the server's own audit trail, including rotated and compressed trail files, by user, query ID, action and time range",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"user_id": map[string]interface{}{
+						"type": "string",
+					},
+					"query_id": map[string]interface{}{
+						"type": "string",
+					},
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "Trail action such as query_generation, query_execution, query_parsing, complete_query or cache_hit",
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "RFC3339 time, or a duration such as \"24h\" meaning that long ago",
+					},
+					"until": map[string]interface{}{
+						"type":        "string",
+						"description": "RFC3339 time, or a duration such as \"1h\" meaning that long ago",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of most recent matching entries to return, 100 by default",
+					},
+					"include_results": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include the full audit result stored with each entry",
+					},
+				},
+			},
+		},
+		{
+			Name:        "verify_audit_trail",
+			Description: "Validate the audit trail's SHA-256 hash chain across rotated files and report modified, missing or inserted records",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "verify_result",
+			Description: "Re-check a result's integrity: recompute the SHA-256 hashes of its raw output and parsed data and, for signed results, check the signature with the server's signing key. Verifies a cached result by query_id or a stored result, such as one exported as evidence",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Query ID of a cached result",
+					},
+					"audit_result": map[string]interface{}{
+						"type":        "object",
+						"description": "Stored AuditResult with its integrity field, verified instead of a cached result",
+					},
+				},
+			},
+		},
+		// Cache management tools
+		{
+			Name:        "get_cache_stats",
+			Description: "Get cache statistics and performance metrics",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "clear_cache",
+			Description: "Clear all cached audit results",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "invalidate_cache",
+			Description: "Remove cached audit results whose query parameters match all given filters, e.g. every result for a log source",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"log_source": map[string]interface{}{
+						"type": "string",
+					},
+					"username": map[string]interface{}{
+						"type": "string",
+					},
+					"namespace": map[string]interface{}{
+						"type": "string",
+					},
+					"resource": map[string]interface{}{
+						"type": "string",
+					},
+					"verb": map[string]interface{}{
+						"type": "string",
+					},
+					"pattern": map[string]interface{}{
+						"type": "string",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_cached_result",
+			Description: "Retrieve a cached audit result by query ID",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"required": []string{"query_id"},
+			},
+		},
+		{
+			Name:        "delete_cached_result",
+			Description: "Delete a specific cached audit result by query ID",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"required": []string{"query_id"},
+			},
+		},
+		{
+			Name:        "get_server_stats",
+			Description: "Get comprehensive server statistics and feature information",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "reset_circuit_breaker",
+			Description: "Close the command circuit breaker after the cluster issue that opened it is fixed, so queries stop using the fallback command",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "check_log_sources",
+			Description: "Check which audit log sources exist on the cluster's master nodes, with the log files per node and alternatives for missing sources",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"log_source": map[string]interface{}{
+						"type":        "string",
+						"enum":        utils.ValidLogSources,
+						"description": "Check only this log source (default: all)",
+					},
+					"refresh": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Probe the cluster again instead of reusing recent results",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_audit_configuration",
+			Description: "Get the cluster's APIServer audit profile (Default, WriteRequestBodies, AllRequestBodies) and custom rules, and what detail audit events record under them, e.g. why requestObject is missing",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "analyze_local_audit_file",
+			Description: "Filter, parse and summarize an exported audit.log, or a directory of rotated or gzipped logs such as a must-gather audit_logs directory, without cluster access",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File or directory below the server's local audit file directory; relative paths are resolved against it",
+					},
+					"structured_params": structuredParamsSchema(),
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "execute_audit_query_batch",
+			Description: "Run several complete audit queries at once, e.g. the same question across namespaces, with bounded concurrency, and return each AuditResult plus a combined summary",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"queries": map[string]interface{}{
+						"type":        "array",
+						"description": fmt.Sprintf("Structured parameters of each query, at most %d", MaxBatchQueries),
+						"items":       structuredParamsSchema(),
+					},
+					"idempotency_key":    idempotencyKeySchema(),
+					"include_raw_output": includeRawOutputSchema(),
+					"fields":             fieldsSchema(),
+				},
+				"required": []string{"queries"},
+			},
+		},
+		{
+			Name:        "query_all_clusters",
+			Description: "Run the same complete audit query concurrently on several registered clusters and return the entries of all of them merged in time order and tagged by cluster, with each cluster's result or error; a failing cluster does not affect the others",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"clusters":          s.clustersSchema(),
+				},
+				"required": []string{"structured_params"},
+			},
+		},
+		{
+			Name:        "annotate_audit_result",
+			Description: "Flag a cached audit result, or one of its parsed entries, as notable with a free-text note; findings are saved and collected by list_findings for the final report",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Query ID of the cached result",
+					},
+					"entry_index": map[string]interface{}{
+						"type":        "integer",
+						"description": "Index of the entry in the result's parsed_data to flag; the whole result is flagged when omitted",
+						"minimum":     0,
+					},
+					"note": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the result or entry is notable",
+					},
+				},
+				"required": []string{"query_id"},
+			},
+		},
+		{
+			Name:        "list_findings",
+			Description: "List everything flagged as notable during the investigation, with the notes and flagged entries, and a Markdown findings section for the final report",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list the findings of this query",
+					},
+				},
+			},
+		},
+		{
+			Name:        "delete_finding",
+			Description: "Delete a finding flagged by mistake",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"finding_id": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"required": []string{"finding_id"},
+			},
+		},
+		{
+			Name:        "match_indicators",
+			Description: "Scan audit events for hits on the threat intelligence indicators loaded from MISP feeds and STIX bundles (AUDIT_INDICATORS): source IPs matching indicator addresses or networks, and user agents matching indicator user agents. Returns the matched events grouped by indicator",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"max_events": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum matched events returned per indicator (default %d)", defaultIndicatorMatchEvents),
+					},
+				},
+			},
+		},
+		{
+			Name:        "analyze_error_rates",
+			Description: "Heatmap of 4xx and 5xx response rates per namespace and per user over a timeframe, compared with the previous equivalent window (yesterday for today, the preceding hour for 1h). Namespaces whose error rate spiked are highlighted: an early warning for misbehaving controllers or RBAC probing",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"spike_threshold": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Increase of the error rate, in percentage points, flagged as a spike (default %g)", parsing.DefaultSpikeThreshold),
+					},
+					"min_requests": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Responses a namespace or user needs in the timeframe to be flagged (default %d)", parsing.DefaultSpikeMinRequests),
+					},
+				},
+			},
+		},
+		{
+			Name:        "detect_churn",
+			Description: "Find objects created, deleted, patched or updated unusually often, by kind and name: CRD or secret churn is often the first visible sign of a misconfigured operator or an attack. Status updates and failed requests are not counted",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"window": map[string]interface{}{
+						"type":        "string",
+						"description": "Changes are counted within windows of this length, as a Go duration such as \"5m\"; 10m by default",
+					},
+					"min_changes": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Changes to an object within one window reported as churn (default %d)", parsing.DefaultChurnMinChanges),
+					},
+				},
+			},
+		},
+		{
+			Name:        "detect_escalation_chains",
+			Description: "Detect privilege escalation chains: users with several failed logins (oauth-server) or 403s followed within a window by successful role, cluster role or binding changes. Returns each chain's events in time order with timestamps",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"timeframe": map[string]interface{}{
+						"type":        "string",
+						"description": "Timeframe to search, such as \"24h\"; today by default",
+					},
+					"username": map[string]interface{}{
+						"type":        "string",
+						"description": "Only report the chains of this user; failed logins are attributed to the username tried",
+					},
+					"min_failures": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Failed logins and denied requests needed before an RBAC change (default %d)", parsing.DefaultEscalationMinFailures),
+					},
+					"window": map[string]interface{}{
+						"type":        "string",
+						"description": "Failures count when within this Go duration before the RBAC change, such as \"30m\"; 1h by default",
+					},
+				},
+			},
+		},
+		{
+			Name:        "audit_secret_access",
+			Description: "Audit who read secrets: get, list and watch requests on secrets grouped by user and namespace, leaving out the control plane and operators expected to read them (AUDIT_SECRET_ACCESS_ALLOWLIST). Users reading a namespace's secrets for the first time compared with a baseline are flagged",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"baseline_timeframe": map[string]interface{}{
+						"type":        "string",
+						"description": "Timeframe searched for earlier reads; users without reads in it before the timeframe are first-time accessors. 7d by default",
+					},
+					"allowlist": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Further users expected to read secrets, left out of the audit; a trailing * matches any suffix",
+					},
+				},
+			},
+		},
+		{
+			Name:        "audit_pod_exec",
+			Description: "Audit who shelled into which pods and when: exec, attach and port-forward requests grouped by user, with each session's pod, container, command, ports and time",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"subresources": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string", "enum": parsing.PodExecSubresources},
+						"description": "Only audit these kinds of session; all three by default",
+					},
+				},
+			},
+		},
+		{
+			Name:        "audit_infrastructure_changes",
+			Description: "Audit who altered cluster infrastructure: a change log of machine config, machine config pool, kubelet config, node, scheduler and API server configuration creates, updates, patches and deletes, counted by category and user. Changes kubelets and operators make in normal operation are left out by default",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"include_controllers": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include the changes of kubelets, the controller manager and the kube-system and openshift-* service accounts",
+					},
+				},
+			},
+		},
+		{
+			Name:        "audit_csr_activity",
+			Description: "Track certificate signing requests created, approved and denied, flagging unusual approvers: users other than the machine approver and controller manager, and requesters approving their own requests. Helps detect credential minting through the certificates API",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"expected_approvers": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Further users expected to approve requests; a trailing * matches any suffix",
+					},
+				},
+			},
+		},
+		{
+			Name:        "audit_webhook_changes",
+			Description: "Track changes of validating and mutating webhook configurations and CRD conversion webhooks, a common persistence technique. Changes logged with their bodies are diffed with the previous version of the object",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+				},
+			},
+		},
+		{
+			Name:        "rbac_change_report",
+			Description: "Consolidate the role, cluster role and binding changes of a timeframe into a before/after summary per subject: the role bindings each user, group or service account gained and lost, and the rules each role gained and lost, rather than a raw event list",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+				},
+			},
+		},
+		{
+			Name:        "generate_compliance_report",
+			Description: "Produce the access review artifacts of a calendar month for PCI or SOC 2 style reviews: the human users with cluster access and their verbs, all privileged operations such as RBAC changes, exec sessions and secret changes, and all access to sensitive namespaces. Rendered as Markdown ready for PDF conversion or as CSV files; optionally written to the report directory",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"month": map[string]interface{}{
+						"type":        "string",
+						"description": "Month to report on in YYYY-MM form, in UTC; the previous month by default",
+					},
+					"sensitive_namespaces": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Further namespaces whose access is reviewed, besides kube-system and the OpenShift control plane and configuration namespaces; a trailing * matches any suffix",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Document format, markdown by default",
+						"enum":        reporting.ComplianceFormats,
+					},
+					"write_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also write the documents to the server's report directory",
+					},
+					"structured_params": structuredParamsSchema(),
+				},
+			},
+		},
+		{
+			Name:        "analyze_login_failures",
+			Description: "Analyze the OAuth server's failed logins: grouped by the username tried and by source IP, classified as password, token or identity provider failures, with the bursts exceeding a threshold that a lockout policy would act on and the users who logged in after failing",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"burst_threshold": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Failed logins of one user or source IP within the burst window that make a burst (default %d)", parsing.DefaultLoginBurstThreshold),
+					},
+					"burst_window": map[string]interface{}{
+						"type":        "string",
+						"description": fmt.Sprintf("Go duration the burst threshold applies to, such as \"10m\"; %s by default", parsing.DefaultLoginBurstWindow),
+					},
+				},
+			},
+		},
+		{
+			Name:        "audit_token_creation",
+			Description: "Track service account token creation: TokenRequests on the serviceaccounts/token subresource and service account token secrets, which never expire. Highlights long-lived tokens created by human users, which most security baselines forbid",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"max_lifetime": map[string]interface{}{
+						"type":        "string",
+						"description": fmt.Sprintf("Go duration above which a token is long-lived, such as \"1h\"; %s by default", parsing.DefaultMaxTokenLifetime),
+					},
+				},
+			},
+		},
+		{
+			Name:        "find_top_talkers",
+			Description: "Rank the users and user agents with the most events over a window and suggest exclude_users and exclude values, ready to paste into structured_params, that drop the noisy controllers from later queries",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"structured_params": structuredParamsSchema(),
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Users and user agents to list (default %d)", parsing.DefaultTopTalkersLimit),
+					},
+					"min_share": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Share of the events, between 0 and 1, from which a controller is suggested for exclusion (default %g)", parsing.DefaultNoisyShare),
+					},
+				},
+			},
+		},
+		{
+			Name:        "check_permissions",
+			Description: "Check whether the identity the server runs oc as may read audit logs: lists the RBAC permissions it lacks, checked with SelfSubjectAccessReviews, and tries a minimal oc adm node-logs read",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "run_preflight",
+			Description: "Check the environment the server runs in: the audit trail, then oc, its login, cluster access and audit log permissions, or a query through the configured backend. Returns each check's status (pass, warn, fail or skip) with how to fix it",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "get_parse_errors",
+			Description: "List the most recent audit log lines the parser rejected, redacted and truncated, with the error and the query that read them, to diagnose malformed or truncated log formats. Lines are only kept after configure_parser sets quarantine_lines",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list the lines read by this query",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"description": "Maximum number of lines, the most recent ones (default: 50)",
+					},
+					"clear": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Empty the quarantine after listing it",
+					},
+				},
+			},
+		},
+		{
+			Name:        "configure_parser",
+			Description: "Adjust how audit log lines are parsed until the server restarts or reloads its configuration: the parse errors reported, the maximum line length, whether non-JSON lines fall back to text parsing and whether entries failing validation are rejected. Changes are recorded in the audit trail; without arguments the current configuration is returned",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"max_parse_errors": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     0,
+						"description": "Parse errors reported per result; lines beyond it are still counted as errors",
+					},
+					"max_line_length": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"description": "Longest line in bytes kept whole; longer lines are parsed as JSON only and keep a truncated raw_line",
+					},
+					"fallback": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Parse lines that are not JSON as structured text instead of rejecting them",
+					},
+					"strict": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Reject entries that fail validation, such as a missing user or invalid timestamp, instead of keeping them with their validation errors",
+					},
+					"quarantine_lines": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     0,
+						"description": "Number of the most recent rejected lines kept, redacted, for get_parse_errors; 0 disables the quarantine",
+					},
+					"reset": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Restore the configured settings before applying the other arguments",
+					},
+				},
+			},
+		},
+	}))
+}
+
+// structuredParamsSchema returns the input schema shared by tools that accept
+// structured_params. No field is required: tools default log_source and
+// timeframe or reject a missing log source themselves.
+func structuredParamsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"log_source": map[string]interface{}{
+				"type": "string",
+				"enum": utils.ValidLogSources,
+			},
+			"patterns": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"timeframe": map[string]interface{}{
+				"type": "string",
+			},
+			"username":    stringOrListSchema(),
+			"resource":    stringOrListSchema(),
+			"verb":        stringOrListSchema(),
+			"namespace":   stringOrListSchema(),
+			"subresource": stringOrListSchema(),
+			"exclude": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"exclude_users":      exclusionListSchema("Usernames to exclude; a trailing * excludes a prefix such as system:serviceaccount:ci:*"),
+			"exclude_namespaces": exclusionListSchema("Namespaces to exclude; a trailing * excludes a prefix"),
+			"exclude_verbs":      exclusionListSchema("API verbs to exclude"),
+			"exclude_resources":  exclusionListSchema("Resource types to exclude"),
+			"status_code": map[string]interface{}{
+				"type":    "integer",
+				"minimum": 100,
+				"maximum": 599,
+			},
+			"status_code_range": map[string]interface{}{
+				"type":        "string",
+				"description": "Named range (success, client_error, server_error, auth_error, not_found, conflict), a class such as 4xx, or a span such as 400-499",
+			},
+			"groups": map[string]interface{}{
+				"type":        "array",
+				"description": "Match events by users belonging to any of these groups",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"impersonated_user": map[string]interface{}{
+				"type":        "string",
+				"description": "Match events performed while impersonating this user",
+			},
+			"user_agent": map[string]interface{}{
+				"type":        "string",
+				"description": "Client user agent to match, such as kubectl or an operator name; substring match unless user_agent_match is set",
+			},
+			"user_agent_match": matchModeSchema(),
+			"stage": map[string]interface{}{
+				"type": "string",
+				"enum": utils.AuditStages,
+			},
+			"level": map[string]interface{}{
+				"type":        "string",
+				"description": "Audit level; RequestResponse selects events that include request and response bodies",
+				"enum":        utils.AuditEventLevels,
+			},
+			"source_ip": map[string]interface{}{
+				"type":        "string",
+				"description": "Client IP address that must appear in sourceIPs",
+			},
+			"source_cidr": map[string]interface{}{
+				"type":        "string",
+				"description": "Client network in CIDR notation, such as 10.0.0.0/8; checked after parsing",
+			},
+			"sort_by": map[string]interface{}{
+				"type":        "string",
+				"description": "Field to sort parsed entries by",
+				"enum":        utils.ResultSortFields,
+			},
+			"sort_order": map[string]interface{}{
+				"type":        "string",
+				"description": "Sort direction, asc by default",
+				"enum":        utils.ResultSortOrders,
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of entries to return after sorting; 0 returns all",
+				"minimum":     0,
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of sorted entries to skip before applying limit",
+				"minimum":     0,
+			},
+			"sample_rate": map[string]interface{}{
+				"type":        "integer",
+				"description": "Keep about one in this many matching entries, picked by audit ID, for exploratory queries over large windows; the result is marked as sampled",
+				"minimum":     0,
+			},
+			"sample_size": map[string]interface{}{
+				"type":        "integer",
+				"description": "Keep at most this many matching entries, a uniform sample picked by audit ID; the result is marked as sampled",
+				"minimum":     0,
+			},
+			"output_mode": map[string]interface{}{
+				"type":        "string",
+				"description": "entries (default) returns parsed entries; histogram returns counts per time bucket with per-verb and per-user breakdowns instead",
+				"enum":        utils.OutputModes,
+			},
+			"bucket_size": map[string]interface{}{
+				"type":        "string",
+				"description": "Histogram bucket size, hour by default",
+				"enum":        utils.HistogramBucketSizes,
+			},
+			"summary_mode": map[string]interface{}{
+				"type":        "string",
+				"description": "brief (default) summarizes in one sentence; verbose adds the top users, verbs, namespaces and resources and the error rate",
+				"enum":        utils.SummaryModes,
+			},
+			"backend": map[string]interface{}{
+				"type":        "string",
+				"description": "Log backend to query; the server's configured default when omitted",
+				"enum":        utils.ValidBackends,
+			},
+			"username_match":  matchModeSchema(),
+			"resource_match":  matchModeSchema(),
+			"verb_match":      matchModeSchema(),
+			"namespace_match": matchModeSchema(),
+			"filter":          filterExpressionSchema(),
+		},
+	}
+}
+
+// stringOrListSchema returns the JSON schema for a field filter that accepts one value
+// or a list of values, any of which may match
+func stringOrListSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+		},
+	}
+}
+
+// exclusionListSchema returns the JSON schema for a structured exclusion list
+func exclusionListSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": description,
+		"items": map[string]interface{}{
+			"type": "string",
+		},
+	}
+}
+
+// matchModeSchema returns the input schema for a field match mode
+func matchModeSchema() map[string]interface{} {
+	modes := make([]string, len(types.ValidMatchModes))
+	for i, mode := range types.ValidMatchModes {
+		modes[i] = string(mode)
+	}
+	return map[string]interface{}{
+		"type": "string",
+		"enum": modes,
+	}
+}
+
+// filterExpressionSchema returns the input schema for a boolean filter expression node
+func filterExpressionSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Boolean pattern expression: a leaf sets pattern; a group sets operator and children",
+		"properties": map[string]interface{}{
+			"operator": map[string]interface{}{
+				"type": "string",
+				"enum": []string{types.FilterOperatorAnd, types.FilterOperatorOr, types.FilterOperatorNot},
+			},
+			"pattern": map[string]interface{}{
+				"type": "string",
+			},
+			"children": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+				},
+			},
+		},
+	}
+}
+
+// GetLogger returns the logger instance
+func (s *AuditQueryMCPServer) GetLogger() *logrus.Logger {
+	return s.logger
+}
+
+// GenerateAuditQueryWithResult converts JSON parameters to safe oc audit commands and returns AuditResult
+func (s *AuditQueryMCPServer) GenerateAuditQueryWithResult(params types.AuditQueryParams) (*types.AuditResult, error) {
+	s.logger.Info("Generating audit query from parameters with result tracking")
+
+	startTime := time.Now()
+	queryID := s.queryIDFor(params)
+
+	result := &types.AuditResult{
+		QueryID:   queryID,
+		Timestamp: startTime.Format(time.RFC3339),
+		Error:     "",
+		Cluster:   s.cluster,
+	}
+
+	// Safety validation
+	if err := validation.ValidateQueryParams(params); err != nil {
+		result.Error = fmt.Sprintf("validation failed: %v", err)
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Build the oc command based on parameters; in-process filtering only fetches
+	// the raw log and applies every filter after execution
+	provider, err := s.providerFor(params)
+	if err != nil {
+		result.Error = err.Error()
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, err
+	}
+	result.Backend = backendName(provider)
+	var command string
+	if provider != nil {
+		providerCommand, err := provider.BuildQuery(params)
+		if err != nil {
+			result.Error = err.Error()
+			result.ExecutionTime = time.Since(startTime).Milliseconds()
+			return result, err
+		}
+		command = providerCommand
+		s.rememberProviderCommand(command, provider, params)
+	} else if s.inProcessFiltering {
+		command = commands.BuildFetchCommand(params)
+	} else {
+		command = commands.BuildOcCommandWithCircuit(params, s.circuit, s.ocFlags...)
+		result.Warnings = commands.BuildCommandWarnings(params)
+		if s.circuit.Status().State == types.CircuitStateOpen {
+			result.Warnings = append(result.Warnings, "circuit breaker is open after repeated command failures; "+
+				"the fallback command reads only the current log file and ignores the timeframe")
+		}
+	}
+	result.Command = command
+	for _, warning := range result.Warnings {
+		s.logger.Warnf("Query %s: %s", queryID, warning)
+	}
+
+	// Additional safety check; provider commands are not run by a shell
+	if provider == nil {
+		if err := validation.ValidateGeneratedCommand(command); err != nil {
+			result.Error = fmt.Sprintf("command validation failed: %v", err)
+			result.ExecutionTime = time.Since(startTime).Milliseconds()
+			return result, fmt.Errorf("command validation failed: %w", err)
+		}
+	}
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.logger.Infof("Generated command: %s", command)
+	return result, nil
+}
+
+// ExecuteAuditQueryWithResult safely executes the oc command and returns AuditResult
+func (s *AuditQueryMCPServer) ExecuteAuditQueryWithResult(command string, queryID string) (*types.AuditResult, error) {
+	s.logger.Info("Executing audit query with result tracking")
+
+	startTime := time.Now()
+
+	result := &types.AuditResult{
+		QueryID:   queryID,
+		Timestamp: startTime.Format(time.RFC3339),
+		Command:   command,
+		Error:     "",
+		Backend:   providers.ProviderOpenShift,
+		Cluster:   s.cluster,
+	}
+
+	// Running executions are listed and can be cancelled by operators
+	queryCtx, done := s.trackQuery(queryID, command)
+	defer done()
+
+	// Provider commands are fetched by the provider rather than a shell
+	if query, ok := s.lookupProviderCommand(command); ok {
+		result.Backend = query.provider.Name()
+		err := s.executeProviderCommand(queryCtx, query, result)
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		if err != nil {
+			result.Error = err.Error()
+			return result, err
+		}
+		result.Provenance = s.provenanceFor(command)
+		s.logger.Infof("Fetched audit logs with the %s provider, output length: %d", query.provider.Name(), len(result.RawOutput))
+		return result, nil
+	}
+	// Other commands must be oc commands, e.g. from a query selecting the
+	// openshift backend
+	if s.provider != nil && !strings.HasPrefix(command, "oc ") {
+		err := fmt.Errorf("command validation failed: command was not generated by the %s provider", s.provider.Name())
+		result.Error = err.Error()
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, err
+	}
+
+	// Final safety validation
+	if err := validation.ValidateGeneratedCommand(command); err != nil {
+		result.Error = fmt.Sprintf("command validation failed: %v", err)
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, fmt.Errorf("command validation failed: %w", err)
+	}
+
+	// Run the command without a shell; syntax or programs the executor does
+	// not support are rejected like unsafe commands
+	parsed, err := commands.ParseCommand(command)
+	if err != nil {
+		result.Error = fmt.Sprintf("command validation failed: %v", err)
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, fmt.Errorf("command validation failed: %w", err)
+	}
+
+	if parsed.UsesJQ() {
+		result.JQEngine = commands.ActiveJQEngine()
+	}
+	parsed = parsed.WithOcFlags(s.ocFlags)
+
+	// Execute with timeout
+	ctx, cancel := context.WithTimeout(queryCtx, 30*time.Second)
+	defer cancel()
+
+	var combined bytes.Buffer
+	err = parsed.Run(ctx, &combined, &combined)
+	output := combined.Bytes()
+
+	if queryCancelled(queryCtx) {
+		result.Error = "command execution cancelled by an operator"
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, fmt.Errorf("command execution cancelled by an operator")
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		s.circuit.RecordFailure()
+		result.Error = "command execution timed out after 30 seconds"
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, fmt.Errorf("command execution timed out after 30 seconds")
+	}
+
+	if err != nil {
+		s.circuit.RecordFailure()
+		result.Error = fmt.Sprintf("command execution failed: %v, output: %s", err, string(output))
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		return result, fmt.Errorf("command execution failed: %w, output: %s", err, string(output))
+	}
+
+	s.circuit.RecordSuccess()
+	result.RawOutput = string(output)
+	result.Provenance = s.provenanceFor(command)
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.logger.Infof("Command executed successfully, output length: %d", len(output))
+	return result, nil
+}
+
+// ParseAuditResultsWithResult parses oc output into structured AuditResult format
+func (s *AuditQueryMCPServer) ParseAuditResultsWithResult(rawOutput string, queryContext map[string]interface{}, queryID string) (*types.AuditResult, error) {
+	return s.parseAuditResults(rawOutput, queryContext, queryID, nil)
+}
+
+// parseAuditResults parses oc output into an AuditResult, reporting the parsing
+// and summarizing stages to progress
+func (s *AuditQueryMCPServer) parseAuditResults(rawOutput string, queryContext map[string]interface{}, queryID string, progress ProgressFunc) (*types.AuditResult, error) {
+	s.logger.Info("Parsing audit results with enhanced parser")
+
+	startTime := time.Now()
+
+	result := &types.AuditResult{
+		QueryID:   queryID,
 		Timestamp: startTime.Format(time.RFC3339),
+		RawOutput: rawOutput,
 		Error:     "",
+		Cluster:   s.cluster,
+	}
+
+	// Split output into lines
+	lines := strings.Split(rawOutput, "\n")
+	var validLines []string
+
+	// Filter out empty lines
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			validLines = append(validLines, line)
+		}
+	}
+
+	// Use enhanced parser
+	progress.report(60, fmt.Sprintf("Parsing %d lines", len(validLines)))
+	parserConfig := s.parserConfig()
+	parseResult := parsing.ParseAuditLogs(validLines, parserConfig)
+	s.quarantineRejectedLines(queryID, parseResult.RejectedLines)
+	if parseResult.TruncatedLines > 0 {
+		s.logger.Infof("Truncated the raw lines of %d entries longer than %d bytes", parseResult.TruncatedLines, parserConfig.MaxLineLength)
+	}
+
+	// Apply source address filters the generated command could not express
+	sourceIP, _ := queryContext["source_ip"].(string)
+	sourceCIDR, _ := queryContext["source_cidr"].(string)
+	if sourceIP != "" || sourceCIDR != "" {
+		entries, err := parsing.FilterEntriesBySource(parseResult.Entries, sourceIP, sourceCIDR)
+		if err != nil {
+			result.Error = err.Error()
+			return result, err
+		}
+		s.logger.Infof("Source filter kept %d of %d entries", len(entries), len(parseResult.Entries))
+		parseResult.Entries = entries
+	}
+
+	// Drop events read twice from overlapping log files
+	entries, duplicates := parsing.DeduplicateEntries(parseResult.Entries)
+	if duplicates > 0 {
+		s.logger.Infof("Removed %d duplicate entries", duplicates)
+	}
+	parseResult.Entries = entries
+	result.DuplicatesRemoved = duplicates
+
+	// Sample exploratory queries, keeping only the sampled raw lines
+	sampleRate, sampleSize := intParam(queryContext["sample_rate"]), intParam(queryContext["sample_size"])
+	if sampleRate > 1 || sampleSize > 0 {
+		matching := len(parseResult.Entries)
+		parseResult.Entries = parsing.SampleEntries(parseResult.Entries, sampleRate, sampleSize)
+		result.Sampling = &types.SamplingInfo{Rate: sampleRate, Size: sampleSize, MatchingEntries: matching, SampledEntries: len(parseResult.Entries)}
+		sampledLines := make([]string, len(parseResult.Entries))
+		for i, entry := range parseResult.Entries {
+			sampledLines[i] = entry.RawLine
+		}
+		result.RawOutput = strings.Join(sampledLines, "\n")
+		s.logger.Infof("Sampled %d of %d entries", len(parseResult.Entries), matching)
+	}
+
+	// Summarize every matching entry, then sort and page the returned ones
+	progress.report(90, fmt.Sprintf("Summarizing %d entries", len(parseResult.Entries)))
+	summary, err := s.summaryTemplates.Generate(parseResult.Entries, queryContext)
+	result.Summary = summary
+	if err != nil {
+		s.logger.Warnf("Falling back to the built-in summary: %v", err)
+		result.Summary = parsing.GenerateSummary(parseResult.Entries, queryContext)
+	}
+	if result.Sampling != nil {
+		result.Summary += fmt.Sprintf(" (sampled %d of %d matching entries)", result.Sampling.SampledEntries, result.Sampling.MatchingEntries)
+	}
+	result.TotalEntries = len(parseResult.Entries)
+
+	// Histogram mode returns bucket counts in place of the entries
+	if outputMode, _ := queryContext["output_mode"].(string); outputMode == utils.OutputModeHistogram {
+		bucketSize, _ := queryContext["bucket_size"].(string)
+		histogram, err := parsing.BuildHistogram(parseResult.Entries, bucketSize)
+		if err != nil {
+			result.Error = err.Error()
+			return result, err
+		}
+		result.Histogram = &histogram
+		result.RawOutput = ""
+		parseResult.Entries = nil
+	}
+
+	sortBy, _ := queryContext["sort_by"].(string)
+	sortOrder, _ := queryContext["sort_order"].(string)
+	if err := parsing.SortEntries(parseResult.Entries, sortBy, sortOrder); err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	parseResult.Entries = parsing.PageEntries(parseResult.Entries, intParam(queryContext["offset"]), intParam(queryContext["limit"]))
+
+	// Convert to legacy format for backward compatibility
+	var parsedEntries []map[string]interface{}
+	for _, entry := range parseResult.Entries {
+		legacyEntry := map[string]interface{}{
+			"timestamp":          entry.Timestamp,
+			"username":           entry.Username,
+			"uid":                entry.UID,
+			"groups":             entry.Groups,
+			"verb":               entry.Verb,
+			"resource":           entry.Resource,
+			"namespace":          entry.Namespace,
+			"name":               entry.Name,
+			"api_group":          entry.APIGroup,
+			"api_version":        entry.APIVersion,
+			"request_uri":        entry.RequestURI,
+			"user_agent":         entry.UserAgent,
+			"source_ips":         entry.SourceIPs,
+			"audit_id":           entry.AuditID,
+			"stage":              entry.Stage,
+			"level":              entry.Level,
+			"stage_timestamp":    entry.StageTimestamp,
+			"subresource":        entry.Subresource,
+			"status_code":        entry.StatusCode,
+			"status_message":     entry.StatusMessage,
+			"status_reason":      entry.StatusReason,
+			"auth_decision":      entry.AuthDecision,
+			"authz_decision":     entry.AuthzDecision,
+			"authz_reason":       entry.AuthzReason,
+			"impersonated_user":  entry.ImpersonatedUser,
+			"is_system_user":     entry.IsSystemUser,
+			"is_service_account": entry.IsServiceAccount,
+			"request_object":     entry.RequestObject,
+			"response_object":    entry.ResponseObject,
+			"omitted_objects":    entry.OmittedObjects,
+			"annotations":        entry.Annotations,
+			"extra":              entry.Extra,
+			"headers":            entry.Headers,
+			"raw_line":           entry.RawLine,
+			"parse_errors":       entry.ParseErrors,
+			"parse_time":         entry.ParseTime.Format(time.RFC3339),
+		}
+		if entry.Truncated {
+			legacyEntry["truncated"] = true
+			legacyEntry["line_length"] = entry.LineLength
+		}
+		parsedEntries = append(parsedEntries, legacyEntry)
+	}
+
+	result.ParsedData = parsedEntries
+	s.sealResult(result)
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+
+	s.logger.Infof("Parsed %d entries (enhanced parser)", len(parsedEntries))
+	s.logger.Infof("Parse performance: %.2f lines/second", parseResult.Performance.LinesPerSecond)
+
+	return result, nil
+}
+
+// ExecuteCompleteAuditQuery executes the full audit query pipeline and returns AuditResult
+func (s *AuditQueryMCPServer) ExecuteCompleteAuditQuery(params types.AuditQueryParams) (*types.AuditResult, error) {
+	return s.ExecuteCompleteAuditQueryWithProgress(params, nil)
+}
+
+// ExecuteCompleteAuditQueryWithProgress executes the full audit query pipeline,
+// reporting each stage to progress, which may be nil
+func (s *AuditQueryMCPServer) ExecuteCompleteAuditQueryWithProgress(params types.AuditQueryParams, progress ProgressFunc) (*types.AuditResult, error) {
+	s.logger.Info("Executing complete audit query pipeline")
+
+	// Step 1: Generate query
+	progress.report(0, "Building command")
+	generateResult, err := s.GenerateAuditQueryWithResult(params)
+	if err != nil {
+		// Log audit trail for failed generation
+		if s.auditTrail != nil {
+			s.auditTrail.LogQueryGeneration(generateResult.QueryID, params, generateResult, "", "", "")
+		}
+		return generateResult, err
+	}
+
+	// Check cache for a result of equivalent parameters
+	cacheKey := commands.CacheKey(params, time.Now(), commands.DefaultCacheKeyGranularity)
+	if cachedResult, found := s.cache.GetByKey(cacheKey); found {
+		s.logger.Infof("Cache hit for query ID: %s", cachedResult.QueryID)
+		// Log cache access
+		if s.auditTrail != nil {
+			s.auditTrail.LogCacheAccess(cachedResult.QueryID, "hit", "", "", "")
+		}
+		progress.report(100, "Served from cache")
+		return cachedResult, nil
+	}
+
+	// Reuse a cached result for an overlapping window, fetching only newer events
+	if s.incrementalQueries {
+		if result, ok := s.executeIncrementalQuery(params, cacheKey); ok {
+			progress.report(100, "Added newer events to a cached result")
+			return result, nil
+		}
+	}
+
+	// Fail fast for a log source a recent probe found missing
+	provider, _ := s.providerFor(params)
+	if availability, known := s.cachedLogSourceAvailability(params.LogSource); provider == nil && known && !availability.Available {
+		err := logSourceUnavailableError(availability)
+		generateResult.Error = err.Error()
+		return generateResult, err
+	}
+
+	// Step 2: Execute query
+	progress.report(10, executingMessage(provider, generateResult.Command))
+	fetchTime := time.Now()
+	executeResult, err := s.ExecuteAuditQueryWithResult(generateResult.Command, generateResult.QueryID)
+	if err != nil {
+		// Merge error information
+		generateResult.Error = executeResult.Error
+		generateResult.ExecutionTime += executeResult.ExecutionTime
+		// Explain a failure caused by a log source the cluster does not have
+		if provider == nil {
+			if unavailable := s.unavailableLogSourceError(params.LogSource, true); unavailable != nil {
+				err = unavailable
+				generateResult.Error = err.Error()
+			}
+		}
+		// Log audit trail for failed execution
+		if s.auditTrail != nil {
+			s.auditTrail.LogQueryExecution(generateResult.QueryID, generateResult.Command, executeResult, "", "", "")
+		}
+		return generateResult, err
+	}
+
+	// Piped commands hide oc's exit status, so empty output or an oc error in the
+	// output may mean the cluster does not have the log source
+	if provider == nil && (strings.TrimSpace(executeResult.RawOutput) == "" || hasOcError(executeResult.RawOutput)) {
+		if err := s.unavailableLogSourceError(params.LogSource, false); err != nil {
+			generateResult.Error = err.Error()
+			generateResult.ExecutionTime += executeResult.ExecutionTime
+			return generateResult, err
+		}
+	}
+
+	// Apply filters in Go when the command only fetched the raw log
+	if s.filtersInProcess(provider) {
+		lines := strings.Split(executeResult.RawOutput, "\n")
+		progress.report(50, fmt.Sprintf("Filtering %d lines", len(lines)))
+		lines, err := parsing.FilterAuditLines(lines, params)
+		if err != nil {
+			generateResult.Error = fmt.Sprintf("in-process filtering failed: %v", err)
+			return generateResult, fmt.Errorf("in-process filtering failed: %w", err)
+		}
+		s.logger.Infof("In-process filtering kept %d events", len(lines))
+		executeResult.RawOutput = strings.Join(lines, "\n")
+	}
+
+	// Step 3: Parse results
+	queryContext := queryContextFor(params)
+	parseResult, err := s.parseAuditResults(executeResult.RawOutput, queryContext, generateResult.QueryID, progress)
+	if err != nil {
+		// Merge error information
+		executeResult.Error = parseResult.Error
+		executeResult.ExecutionTime += parseResult.ExecutionTime
+		// Log audit trail for failed parsing
+		if s.auditTrail != nil {
+			s.auditTrail.LogQueryParsing(generateResult.QueryID, queryContext, parseResult, "", "", "")
+		}
+		return executeResult, err
+	}
+
+	// Combine all results
+	finalResult := &types.AuditResult{
+		QueryID:           generateResult.QueryID,
+		Timestamp:         generateResult.Timestamp,
+		Command:           generateResult.Command,
+		RawOutput:         parseResult.RawOutput,
+		ParsedData:        parseResult.ParsedData,
+		Summary:           parseResult.Summary,
+		Error:             "",
+		Warnings:          generateResult.Warnings,
+		DuplicatesRemoved: parseResult.DuplicatesRemoved,
+		TotalEntries:      parseResult.TotalEntries,
+		Histogram:         parseResult.Histogram,
+		Sampling:          parseResult.Sampling,
+		Integrity:         parseResult.Integrity,
+		Provenance:        executeResult.Provenance,
+		ExecutionTime:     generateResult.ExecutionTime + executeResult.ExecutionTime + parseResult.ExecutionTime,
+		Backend:           generateResult.Backend,
+		Cluster:           generateResult.Cluster,
+		JQEngine:          executeResult.JQEngine,
+	}
+
+	// Cache the result
+	s.cache.SetForParams(generateResult.QueryID, cacheKey, params, finalResult)
+	s.resultsChanged(generateResult.QueryID)
+	s.logger.Infof("Cached result for query ID: %s", generateResult.QueryID)
+	if start, end, ok := commands.CommandWindow(params.Timeframe, fetchTime); ok {
+		s.recordCoverage(params, finalResult, start, end)
+	}
+
+	// Log complete query execution
+	if s.auditTrail != nil {
+		s.auditTrail.LogCompleteQuery(generateResult.QueryID, params, finalResult, "", "", "")
+	}
+
+	progress.report(100, fmt.Sprintf("Completed with %d entries", finalResult.TotalEntries))
+	return finalResult, nil
+}
+
+// queryContextFor builds the parsing context for params: the fields summaries
+// mention, the summary mode and the filters, sorting, paging and output mode
+// applied after parsing
+func queryContextFor(params types.AuditQueryParams) map[string]interface{} {
+	queryContext := map[string]interface{}{
+		"log_source": params.LogSource,
+		"timeframe":  params.Timeframe,
+		"username":   params.Username,
+		"resource":   params.Resource,
+		"verb":       params.Verb,
+		"namespace":  params.Namespace,
+	}
+	if params.SourceIP != "" {
+		queryContext["source_ip"] = params.SourceIP
+	}
+	if params.SourceCIDR != "" {
+		queryContext["source_cidr"] = params.SourceCIDR
+	}
+	if params.SortBy != "" {
+		queryContext["sort_by"] = params.SortBy
+		queryContext["sort_order"] = params.SortOrder
+	}
+	if params.Limit > 0 || params.Offset > 0 {
+		queryContext["limit"] = params.Limit
+		queryContext["offset"] = params.Offset
+	}
+	if params.OutputMode != "" {
+		queryContext["output_mode"] = params.OutputMode
+		queryContext["bucket_size"] = params.BucketSize
+	}
+	if params.SummaryMode != "" {
+		queryContext["summary_mode"] = params.SummaryMode
+	}
+	if params.SampleRate > 0 || params.SampleSize > 0 {
+		queryContext["sample_rate"] = params.SampleRate
+		queryContext["sample_size"] = params.SampleSize
+	}
+	return queryContext
+}
+
+// queryIDFor returns the query ID of a query of params: derived from the
+// idempotency key of the call running it, if any, and unique otherwise
+func (s *AuditQueryMCPServer) queryIDFor(params types.AuditQueryParams) string {
+	if params.IdempotencyKey != "" {
+		return idempotentQueryID(params)
+	}
+	return s.generateQueryID()
+}
+
+// generateQueryID creates a unique query identifier
+func (s *AuditQueryMCPServer) generateQueryID() string {
+	return fmt.Sprintf("audit_query_%s_%s",
+		time.Now().Format("20060102_150405"),
+		s.generateRandomSuffix())
+}
+
+// generateRandomSuffix creates a random suffix for query IDs, so queries
+// started in the same second, such as those of a batch, get distinct IDs
+func (s *AuditQueryMCPServer) generateRandomSuffix() string {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to the clock, which is unique enough for sequential queries
+		for i := range b {
+			b[i] = byte(time.Now().UnixNano() >> (8 * i))
+		}
+	}
+	for i := range b {
+		b[i] = charset[int(b[i])%len(charset)]
+	}
+	return string(b)
+}
+
+// ExplainAuditQuery describes how the command for the given parameters filters audit events
+func (s *AuditQueryMCPServer) ExplainAuditQuery(params types.AuditQueryParams) (*types.QueryExplanation, error) {
+	s.logger.Info("Explaining audit query from parameters")
+
+	if err := validation.ValidateQueryParams(params); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	explanation := commands.ExplainQuery(params)
+	return &explanation, nil
+}
+
+// AskAuditQuestion translates a natural-language question to query parameters and runs
+// the complete pipeline. The interpretation is returned even when execution fails.
+func (s *AuditQueryMCPServer) AskAuditQuestion(question string) (*types.QuestionInterpretation, *types.AuditResult, error) {
+	return s.askAuditQuestion(question, "")
+}
+
+// askAuditQuestion is AskAuditQuestion for a tool call with idempotencyKey
+func (s *AuditQueryMCPServer) askAuditQuestion(question, idempotencyKey string) (*types.QuestionInterpretation, *types.AuditResult, error) {
+	s.logger.Infof("Answering audit question: %s", question)
+
+	interp, err := nlp.TranslateQuestion(question)
+	if err != nil {
+		return interp, nil, err
+	}
+	s.logger.Infof("Interpreted question as: %s", strings.Join(interp.Matched, ", "))
+
+	params := interp.Params
+	params.IdempotencyKey = idempotencyKey
+	result, err := s.ExecuteCompleteAuditQuery(params)
+	return interp, result, err
+}
+
+// fetchParsedEntries fetches the whole audit log for params, applies every filter
+// in-process and parses the matching events. Analysis tools use this rather than
+// the jq pipeline because the jq projection drops fields such as annotations.
+// The returned result carries the query ID, command and matching raw lines.
+func (s *AuditQueryMCPServer) fetchParsedEntries(params types.AuditQueryParams) ([]parsing.AuditLogEntry, *types.AuditResult, error) {
+	return s.fetchParsedEntriesWithConfig(params, s.parserConfig())
+}
+
+// fetchParsedEntriesWithConfig is fetchParsedEntries with the given parser
+// configuration, for analyses that read request or response bodies
+func (s *AuditQueryMCPServer) fetchParsedEntriesWithConfig(params types.AuditQueryParams, parserConfig parsing.ParserConfig) ([]parsing.AuditLogEntry, *types.AuditResult, error) {
+	if params.LogSource == "" {
+		params.LogSource = "kube-apiserver"
+	}
+
+	startTime := time.Now()
+	rawOutput, result, err := s.fetchRawLog(params)
+	if err != nil {
+		return nil, result, err
+	}
+
+	lines, err := parsing.FilterAuditLines(strings.Split(rawOutput, "\n"), params)
+	if err != nil {
+		result.Error = fmt.Sprintf("in-process filtering failed: %v", err)
+		return nil, result, fmt.Errorf("in-process filtering failed: %w", err)
+	}
+
+	parseResult := parsing.ParseAuditLogs(lines, parserConfig)
+	s.quarantineRejectedLines(result.QueryID, parseResult.RejectedLines)
+	entries, duplicates := parsing.DeduplicateEntries(parseResult.Entries)
+	result.DuplicatesRemoved = duplicates
+	result.TotalEntries = len(entries)
+	result.RawOutput = strings.Join(lines, "\n")
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+
+	return entries, result, nil
+}
+
+// fetchRawLog validates params and fetches the unfiltered audit log for them:
+// the whole log file with oc, or the events a backend returns. The returned
+// result carries the query ID, backend and command.
+func (s *AuditQueryMCPServer) fetchRawLog(params types.AuditQueryParams) (string, *types.AuditResult, error) {
+	result := &types.AuditResult{
+		QueryID:   s.queryIDFor(params),
+		Timestamp: time.Now().Format(time.RFC3339),
+		Cluster:   s.cluster,
 	}
 
-	// Safety validation
 	if err := validation.ValidateQueryParams(params); err != nil {
 		result.Error = fmt.Sprintf("validation failed: %v", err)
-		result.ExecutionTime = time.Since(startTime).Milliseconds()
-		return result, fmt.Errorf("validation failed: %w", err)
+		return "", result, fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Build the oc command based on parameters
-	command := commands.BuildOcCommand(params)
-	result.Command = command
+	provider, err := s.providerFor(params)
+	if err != nil {
+		result.Error = err.Error()
+		return "", result, err
+	}
+	result.Backend = backendName(provider)
+	if provider != nil {
+		if result.Command, err = provider.BuildQuery(params); err != nil {
+			result.Error = err.Error()
+			return "", result, err
+		}
+		s.rememberProviderCommand(result.Command, provider, params)
+	} else {
+		result.Command = commands.BuildFetchCommand(params)
+	}
+	executeResult, err := s.ExecuteAuditQueryWithResult(result.Command, result.QueryID)
+	if err != nil {
+		return "", executeResult, err
+	}
+	return executeResult.RawOutput, result, nil
+}
 
-	// Additional safety check
-	if err := validation.ValidateGeneratedCommand(command); err != nil {
-		result.Error = fmt.Sprintf("command validation failed: %v", err)
-		result.ExecutionTime = time.Since(startTime).Milliseconds()
-		return result, fmt.Errorf("command validation failed: %w", err)
+// FindPermissionDenials reports the requests matching params that the authorizer refused
+func (s *AuditQueryMCPServer) FindPermissionDenials(params types.AuditQueryParams) (*types.PermissionDenialReport, *types.AuditResult, error) {
+	s.logger.Info("Finding permission denials")
+
+	entries, result, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, result, err
 	}
 
-	result.ExecutionTime = time.Since(startTime).Milliseconds()
-	s.logger.Infof("Generated command: %s", command)
-	return result, nil
+	report := parsing.AnalyzePermissionDenials(entries)
+
+	var denied []string
+	for _, entry := range entries {
+		if parsing.IsPermissionDenial(entry) {
+			denied = append(denied, entry.RawLine)
+		}
+	}
+	result.RawOutput = strings.Join(denied, "\n")
+	result.Summary = report.Summary
+
+	s.logger.Infof("Found %d permission denials", report.TotalDenials)
+	return &report, result, nil
 }
 
-// ExecuteAuditQueryWithResult safely executes the oc command and returns AuditResult
-func (s *AuditQueryMCPServer) ExecuteAuditQueryWithResult(command string, queryID string) (*types.AuditResult, error) {
-	s.logger.Info("Executing audit query with result tracking")
+// CompareAuditActivity runs the same filters twice, varying only the timeframe or
+// the username, and reports how the second run's activity differs from the first
+func (s *AuditQueryMCPServer) CompareAuditActivity(params types.AuditQueryParams, dimension, baseline, comparison string) (*types.ActivityComparison, error) {
+	s.logger.Infof("Comparing audit activity by %s: %s vs %s", dimension, baseline, comparison)
 
-	startTime := time.Now()
+	var baselineParams, comparisonParams types.AuditQueryParams
+	switch dimension {
+	case "timeframe":
+		baselineParams, comparisonParams = params, params
+		baselineParams.Timeframe = baseline
+		comparisonParams.Timeframe = comparison
+	case "username":
+		baselineParams, comparisonParams = params, params
+		baselineParams.Username, baselineParams.Usernames = baseline, nil
+		comparisonParams.Username, comparisonParams.Usernames = comparison, nil
+	default:
+		return nil, fmt.Errorf("invalid comparison dimension: %s", dimension)
+	}
 
-	result := &types.AuditResult{
-		QueryID:   queryID,
-		Timestamp: startTime.Format(time.RFC3339),
-		Command:   command,
-		Error:     "",
+	baselineEntries, _, err := s.fetchParsedEntries(baselineParams)
+	if err != nil {
+		return nil, fmt.Errorf("baseline query failed: %w", err)
+	}
+	comparisonEntries, _, err := s.fetchParsedEntries(comparisonParams)
+	if err != nil {
+		return nil, fmt.Errorf("comparison query failed: %w", err)
 	}
 
-	// Final safety validation
-	if err := validation.ValidateGeneratedCommand(command); err != nil {
-		result.Error = fmt.Sprintf("command validation failed: %v", err)
-		result.ExecutionTime = time.Since(startTime).Milliseconds()
-		return result, fmt.Errorf("command validation failed: %w", err)
+	comparisonResult := parsing.CompareActivity(
+		dimension+" "+baseline, baselineEntries,
+		dimension+" "+comparison, comparisonEntries,
+	)
+	return &comparisonResult, nil
+}
+
+// AnalyzeErrorRates computes the 4xx and 5xx rates per namespace and per user
+// of the events matching params, today by default, and compares them with the
+// previous equivalent window, flagging the rates that spiked
+func (s *AuditQueryMCPServer) AnalyzeErrorRates(params types.AuditQueryParams, spikeThreshold float64, minRequests int) (*types.ErrorRateReport, error) {
+	if params.Timeframe == "" {
+		params.Timeframe = "today"
+	}
+	previousTimeframe, ok := commands.PreviousTimeframe(params.Timeframe)
+	if !ok {
+		return nil, fmt.Errorf("timeframe %q has no previous window to compare with; use a rolling timeframe such as 1h or today, this week or this month", params.Timeframe)
 	}
+	s.logger.Infof("Analyzing error rates for %s against %s", params.Timeframe, previousTimeframe)
 
-	// Execute with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	now := time.Now()
+	currentStart, currentEnd, _ := commands.TimeframeWindow(params.Timeframe, now)
+	previousStart, previousEnd, _ := commands.TimeframeWindow(previousTimeframe, now)
+	if previousEnd.After(currentStart) {
+		previousEnd = currentStart
+	}
+	currentEntries, currentResult, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, fmt.Errorf("query for %s failed: %w", params.Timeframe, err)
+	}
 
-	cmd := exec.CommandContext(ctx, "bash", "-c", command)
-	output, err := cmd.CombinedOutput()
+	previousParams := params
+	previousParams.Timeframe = previousTimeframe
+	fetched, previousResult, err := s.fetchParsedEntries(previousParams)
+	if err != nil {
+		return nil, fmt.Errorf("query for the previous window failed: %w", err)
+	}
+	// Timeframes without a date filter, such as 1h, fetch the whole log, and
+	// rolling previous windows include the current one; keep each window's events
+	currentEntries = entriesBetween(currentEntries, currentStart, currentEnd.Add(time.Nanosecond))
+	previousEntries := entriesBetween(fetched, previousStart, previousEnd)
 
-	if ctx.Err() == context.DeadlineExceeded {
-		result.Error = "command execution timed out after 30 seconds"
-		result.ExecutionTime = time.Since(startTime).Milliseconds()
-		return result, fmt.Errorf("command execution timed out after 30 seconds")
+	report := parsing.AnalyzeErrorRates(currentEntries, previousEntries, spikeThreshold, minRequests)
+	report.Current.Timeframe, report.Current.QueryID = params.Timeframe, currentResult.QueryID
+	report.Current.Start, report.Current.End = currentStart.Format(time.RFC3339), currentEnd.Format(time.RFC3339)
+	report.Previous.Timeframe, report.Previous.QueryID = previousTimeframe, previousResult.QueryID
+	report.Previous.Start, report.Previous.End = previousStart.Format(time.RFC3339), previousEnd.Format(time.RFC3339)
+
+	s.logger.Infof("Error rates: %s", report.Summary)
+	return &report, nil
+}
+
+// entriesBetween returns the entries received at or after start and before
+// end; entries without a parsable timestamp are dropped
+func entriesBetween(entries []parsing.AuditLogEntry, start, end time.Time) []parsing.AuditLogEntry {
+	var kept []parsing.AuditLogEntry
+	for _, entry := range entries {
+		if timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil && !timestamp.Before(start) && timestamp.Before(end) {
+			kept = append(kept, entry)
+		}
 	}
+	return kept
+}
 
+// DetectChurn fetches the events matching params and reports the objects
+// changed at least minChanges times within any window
+func (s *AuditQueryMCPServer) DetectChurn(params types.AuditQueryParams, window time.Duration, minChanges int) (*types.ChurnReport, *types.AuditResult, error) {
+	s.logger.Info("Detecting object churn")
+
+	entries, result, err := s.fetchParsedEntries(params)
 	if err != nil {
-		result.Error = fmt.Sprintf("command execution failed: %v, output: %s", err, string(output))
-		result.ExecutionTime = time.Since(startTime).Milliseconds()
-		return result, fmt.Errorf("command execution failed: %w, output: %s", err, string(output))
+		return nil, result, err
 	}
 
-	result.RawOutput = string(output)
-	result.ExecutionTime = time.Since(startTime).Milliseconds()
-	s.logger.Infof("Command executed successfully, output length: %d", len(output))
-	return result, nil
+	report := parsing.DetectChurn(entries, window, minChanges)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Found %d churning objects", len(report.Objects))
+	return &report, result, nil
 }
 
-// ParseAuditResultsWithResult parses oc output into structured AuditResult format
-func (s *AuditQueryMCPServer) ParseAuditResultsWithResult(rawOutput string, queryContext map[string]interface{}, queryID string) (*types.AuditResult, error) {
-	s.logger.Info("Parsing audit results with enhanced parser")
+// AuditPodExec fetches the exec, attach and port-forward requests on pods
+// matching params, all three unless params selects some, and summarizes who
+// opened sessions into which pods and when
+func (s *AuditQueryMCPServer) AuditPodExec(params types.AuditQueryParams) (*types.PodExecReport, *types.AuditResult, error) {
+	s.logger.Info("Auditing pod exec, attach and port-forward sessions")
 
-	startTime := time.Now()
+	params.Resource, params.Resources = "pods", nil
+	if len(params.Subresources) == 0 {
+		params.Subresources = parsing.PodExecSubresources
+	}
+	entries, result, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, result, err
+	}
 
-	result := &types.AuditResult{
-		QueryID:   queryID,
-		Timestamp: startTime.Format(time.RFC3339),
-		RawOutput: rawOutput,
-		Error:     "",
+	report := parsing.AnalyzePodExec(entries)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Found %d pod sessions by %d users", report.TotalSessions, len(report.Users))
+	return &report, result, nil
+}
+
+// AuditInfrastructureChanges fetches the changes of machine configs, nodes and
+// the scheduler and API server configuration matching params and builds their
+// change log. The changes controllers make in normal operation are left out
+// unless includeControllers is set.
+func (s *AuditQueryMCPServer) AuditInfrastructureChanges(params types.AuditQueryParams, includeControllers bool) (*types.InfrastructureChangeReport, *types.AuditResult, error) {
+	s.logger.Info("Auditing cluster infrastructure changes")
+
+	params.Resource, params.Resources, params.ResourceMatch = "", parsing.InfrastructureResources, types.MatchModeExact
+	params.Verb, params.Verbs = "", parsing.InfrastructureChangeVerbs
+	if !includeControllers {
+		params.ExcludeUsers = append(params.ExcludeUsers, parsing.InfrastructureControllers...)
+	}
+	entries, result, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, result, err
 	}
 
-	// Split output into lines
-	lines := strings.Split(rawOutput, "\n")
-	var validLines []string
+	report := parsing.AnalyzeInfrastructureChanges(entries)
+	result.Summary = report.Summary
 
-	// Filter out empty lines
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			validLines = append(validLines, line)
-		}
+	s.logger.Infof("Found %d infrastructure changes by %d users", report.TotalChanges, len(report.Users))
+	return &report, result, nil
+}
+
+// AuditCSRActivity fetches the certificate signing requests created, approved
+// and denied matching params and flags unusual approvers: users other than
+// the default approvers and those of extraApprovers, and requesters approving
+// their own requests. The events are parsed with their bodies, which carry
+// the decisions and signer names.
+func (s *AuditQueryMCPServer) AuditCSRActivity(params types.AuditQueryParams, extraApprovers []string) (*types.CSRActivityReport, *types.AuditResult, error) {
+	s.logger.Info("Auditing certificate signing request activity")
+
+	params.Resource, params.Resources = "certificatesigningrequests", nil
+	params.Verb, params.Verbs = "", []string{"create", "update", "patch"}
+	parserConfig := s.parserConfig()
+	parserConfig.IncludeObjects = true
+	entries, result, err := s.fetchParsedEntriesWithConfig(params, parserConfig)
+	if err != nil {
+		return nil, result, err
 	}
 
-	// Use enhanced parser
-	config := parsing.DefaultParserConfig()
-	parseResult := parsing.ParseAuditLogs(validLines, config)
+	report := parsing.AnalyzeCSRActivity(entries, append(append([]string{}, parsing.DefaultCSRApprovers...), extraApprovers...))
+	result.Summary = report.Summary
 
-	// Convert to legacy format for backward compatibility
-	var parsedEntries []map[string]interface{}
-	for _, entry := range parseResult.Entries {
-		legacyEntry := map[string]interface{}{
-			"timestamp":         entry.Timestamp,
-			"username":          entry.Username,
-			"uid":               entry.UID,
-			"groups":            entry.Groups,
-			"verb":              entry.Verb,
-			"resource":          entry.Resource,
-			"namespace":         entry.Namespace,
-			"name":              entry.Name,
-			"api_group":         entry.APIGroup,
-			"api_version":       entry.APIVersion,
-			"request_uri":       entry.RequestURI,
-			"user_agent":        entry.UserAgent,
-			"source_ips":        entry.SourceIPs,
-			"status_code":       entry.StatusCode,
-			"status_message":    entry.StatusMessage,
-			"status_reason":     entry.StatusReason,
-			"auth_decision":     entry.AuthDecision,
-			"authz_decision":    entry.AuthzDecision,
-			"impersonated_user": entry.ImpersonatedUser,
-			"annotations":       entry.Annotations,
-			"extra":             entry.Extra,
-			"headers":           entry.Headers,
-			"raw_line":          entry.RawLine,
-			"parse_errors":      entry.ParseErrors,
-			"parse_time":        entry.ParseTime.Format(time.RFC3339),
-		}
-		parsedEntries = append(parsedEntries, legacyEntry)
+	s.logger.Infof("Found %d unusual CSR approvals", report.UnusualApprovals)
+	return &report, result, nil
+}
+
+// AuditWebhookChanges fetches the changes of admission webhook configurations
+// and CRDs matching params, the objects an attacker reconfigures to intercept
+// or rewrite API requests. The events are parsed with their bodies, so changes
+// logged at the RequestResponse level are diffed with the object's previous
+// version.
+func (s *AuditQueryMCPServer) AuditWebhookChanges(params types.AuditQueryParams) (*types.WebhookChangeReport, *types.AuditResult, error) {
+	s.logger.Info("Auditing webhook configuration changes")
+
+	params.Resource, params.Resources, params.ResourceMatch = "", parsing.WebhookResources, types.MatchModeExact
+	params.Verb, params.Verbs = "", parsing.WebhookChangeVerbs
+	parserConfig := s.parserConfig()
+	parserConfig.IncludeObjects = true
+	entries, result, err := s.fetchParsedEntriesWithConfig(params, parserConfig)
+	if err != nil {
+		return nil, result, err
 	}
 
-	result.ParsedData = parsedEntries
+	report := parsing.AnalyzeWebhookChanges(entries)
+	result.Summary = report.Summary
 
-	// Generate summary using enhanced parser
-	result.Summary = parsing.GenerateSummary(parseResult.Entries, queryContext)
-	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	s.logger.Infof("Found %d webhook configuration changes", report.TotalChanges)
+	return &report, result, nil
+}
 
-	s.logger.Infof("Parsed %d entries (enhanced parser)", len(parsedEntries))
-	s.logger.Infof("Parse performance: %.2f lines/second", parseResult.Performance.LinesPerSecond)
+// BuildRBACChangeReport fetches the role and binding changes matching params and
+// consolidates them into the permissions each subject gained and lost. The
+// events are parsed with their bodies, which carry the subjects and rules.
+func (s *AuditQueryMCPServer) BuildRBACChangeReport(params types.AuditQueryParams) (*types.RBACChangeReport, *types.AuditResult, error) {
+	s.logger.Info("Building RBAC change report")
 
-	return result, nil
+	params.Resource, params.Resources, params.ResourceMatch = "", parsing.RBACResources, types.MatchModeExact
+	params.Verb, params.Verbs = "", parsing.RBACChangeVerbs
+	parserConfig := s.parserConfig()
+	parserConfig.IncludeObjects = true
+	entries, result, err := s.fetchParsedEntriesWithConfig(params, parserConfig)
+	if err != nil {
+		return nil, result, err
+	}
+
+	report := parsing.AnalyzeRBACChanges(entries)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Found %d RBAC changes affecting %d subjects", report.TotalChanges, len(report.Subjects))
+	return &report, result, nil
 }
 
-// ExecuteCompleteAuditQuery executes the full audit query pipeline and returns AuditResult
-func (s *AuditQueryMCPServer) ExecuteCompleteAuditQuery(params types.AuditQueryParams) (*types.AuditResult, error) {
-	s.logger.Info("Executing complete audit query pipeline")
+// AnalyzeLoginFailures fetches the OAuth server's login events matching
+// params and groups the failed logins by the username tried and by source IP,
+// reporting the bursts of at least threshold failures within window
+func (s *AuditQueryMCPServer) AnalyzeLoginFailures(params types.AuditQueryParams, threshold int, window time.Duration) (*types.LoginFailureReport, *types.AuditResult, error) {
+	s.logger.Info("Analyzing OAuth login failures")
 
-	// Step 1: Generate query
-	generateResult, err := s.GenerateAuditQueryWithResult(params)
+	params.LogSource = "oauth-server"
+	entries, result, err := s.fetchParsedEntries(params)
 	if err != nil {
-		// Log audit trail for failed generation
-		if s.auditTrail != nil {
-			s.auditTrail.LogQueryGeneration(generateResult.QueryID, params, generateResult, "", "", "")
+		return nil, result, err
+	}
+
+	report := parsing.AnalyzeLoginFailures(entries, threshold, window)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Found %d failed logins in %d bursts", report.TotalFailures, len(report.Bursts))
+	return &report, result, nil
+}
+
+// AuditTokenCreation fetches the TokenRequests and secret creations matching
+// params and reports the service account tokens created, flagging those
+// living longer than maxLifetime that human users created. The events are
+// parsed with their bodies, which carry the lifetimes and secret types.
+func (s *AuditQueryMCPServer) AuditTokenCreation(params types.AuditQueryParams, maxLifetime time.Duration) (*types.TokenCreationReport, *types.AuditResult, error) {
+	s.logger.Info("Auditing service account token creation")
+
+	params.Resource, params.Resources, params.ResourceMatch = "", parsing.TokenResources, types.MatchModeExact
+	params.Verb, params.Verbs = "create", nil
+	parserConfig := s.parserConfig()
+	parserConfig.IncludeObjects = true
+	entries, result, err := s.fetchParsedEntriesWithConfig(params, parserConfig)
+	if err != nil {
+		return nil, result, err
+	}
+
+	report := parsing.AnalyzeTokenCreation(entries, maxLifetime)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Found %d long-lived tokens created by human users", report.HumanLongLived)
+	return &report, result, nil
+}
+
+// FindTopTalkers fetches the events matching params and ranks their users and
+// user agents by volume, suggesting exclusions for the noisy controllers
+func (s *AuditQueryMCPServer) FindTopTalkers(params types.AuditQueryParams, limit int, minShare float64) (*types.TopTalkersReport, *types.AuditResult, error) {
+	s.logger.Info("Finding top talkers")
+
+	entries, result, err := s.fetchParsedEntries(params)
+	if err != nil {
+		return nil, result, err
+	}
+
+	report := parsing.AnalyzeTopTalkers(entries, limit, minShare)
+	result.Summary = report.Summary
+
+	s.logger.Infof("Suggested %d user and %d user agent exclusions", len(report.SuggestedExclusions.ExcludeUsers), len(report.SuggestedExclusions.Exclude))
+	return &report, result, nil
+}
+
+// escalationLogSources are the log sources searched for escalation chains:
+// failed logins are in the OAuth server's log, denials and RBAC changes in
+// the API servers'
+var escalationLogSources = []string{"oauth-server", "kube-apiserver", "openshift-apiserver"}
+
+// DetectEscalationChains fetches the events of timeframe, today by default,
+// from the OAuth and API servers and reports the users whose failed logins or
+// denied requests were followed within window by successful RBAC changes,
+// only username's when it is set
+func (s *AuditQueryMCPServer) DetectEscalationChains(timeframe, username string, minFailures int, window time.Duration) (*types.EscalationReport, error) {
+	if timeframe == "" {
+		timeframe = "today"
+	}
+	s.logger.Infof("Detecting privilege escalation chains for %s", timeframe)
+
+	entriesBySource := make(map[string][]parsing.AuditLogEntry)
+	for _, logSource := range escalationLogSources {
+		entries, _, err := s.fetchParsedEntries(types.AuditQueryParams{LogSource: logSource, Timeframe: timeframe})
+		if err != nil {
+			return nil, fmt.Errorf("%s query failed: %w", logSource, err)
 		}
-		return generateResult, err
+		entriesBySource[logSource] = entries
 	}
 
-	// Check cache for existing result
-	if cachedResult, found := s.cache.Get(generateResult.QueryID); found {
-		s.logger.Infof("Cache hit for query ID: %s", generateResult.QueryID)
-		// Log cache access
-		if s.auditTrail != nil {
-			s.auditTrail.LogCacheAccess(generateResult.QueryID, "hit", "", "", "")
+	report := parsing.DetectEscalationChains(entriesBySource, minFailures, window)
+	if username != "" {
+		chains := []types.EscalationChain{}
+		for _, chain := range report.Chains {
+			if chain.Username == username {
+				chains = append(chains, chain)
+			}
 		}
-		return cachedResult, nil
+		report.Chains = chains
 	}
 
-	// Step 2: Execute query
-	executeResult, err := s.ExecuteAuditQueryWithResult(generateResult.Command, generateResult.QueryID)
+	s.logger.Infof("Found %d privilege escalation chains", len(report.Chains))
+	return &report, nil
+}
+
+// BuildUserTimeline fetches the events matching params for a single user and
+// reconstructs them as a timeline
+func (s *AuditQueryMCPServer) BuildUserTimeline(params types.AuditQueryParams, username string, burstWindow, gapThreshold time.Duration) (*types.UserTimeline, *types.AuditResult, error) {
+	s.logger.Infof("Building timeline for user %s", username)
+
+	params.Username, params.Usernames = username, nil
+	entries, result, err := s.fetchParsedEntries(params)
 	if err != nil {
-		// Merge error information
-		generateResult.Error = executeResult.Error
-		generateResult.ExecutionTime += executeResult.ExecutionTime
-		// Log audit trail for failed execution
-		if s.auditTrail != nil {
-			s.auditTrail.LogQueryExecution(generateResult.QueryID, generateResult.Command, executeResult, "", "", "")
-		}
-		return generateResult, err
+		return nil, result, err
 	}
 
-	// Step 3: Parse results
-	queryContext := map[string]interface{}{
-		"log_source": params.LogSource,
-		"timeframe":  params.Timeframe,
-		"username":   params.Username,
-		"resource":   params.Resource,
-		"verb":       params.Verb,
-		"namespace":  params.Namespace,
+	timeline := parsing.BuildTimeline(username, entries, burstWindow, gapThreshold)
+	result.Summary = fmt.Sprintf("%d events for %s in %d timeline entries with %d gaps",
+		timeline.TotalEvents, username, len(timeline.Events), len(timeline.Gaps))
+	result.RawOutput = ""
+
+	return &timeline, result, nil
+}
+
+// GenerateAuditReport renders an audit result as a Markdown or HTML report. When
+// outputFile is set the report is also written to the report directory and the
+// file's path is returned.
+func (s *AuditQueryMCPServer) GenerateAuditReport(result *types.AuditResult, format, title, outputFile string) (string, string, error) {
+	s.logger.Infof("Generating %s report for query %s", format, result.QueryID)
+
+	content, err := reporting.Render(reporting.BuildReport(result, title), format)
+	if err != nil {
+		return "", "", err
 	}
 
-	parseResult, err := s.ParseAuditResultsWithResult(executeResult.RawOutput, queryContext, generateResult.QueryID)
+	if outputFile == "" {
+		return content, "", nil
+	}
+	path, err := reporting.WriteReport(s.reportDir, outputFile, content)
 	if err != nil {
-		// Merge error information
-		executeResult.Error = parseResult.Error
-		executeResult.ExecutionTime += parseResult.ExecutionTime
-		// Log audit trail for failed parsing
-		if s.auditTrail != nil {
-			s.auditTrail.LogQueryParsing(generateResult.QueryID, queryContext, parseResult, "", "", "")
-		}
-		return executeResult, err
+		return "", "", err
 	}
+	s.logger.Infof("Wrote report to %s", path)
+	return content, path, nil
+}
 
-	// Combine all results
-	finalResult := &types.AuditResult{
-		QueryID:       generateResult.QueryID,
-		Timestamp:     generateResult.Timestamp,
-		Command:       generateResult.Command,
-		RawOutput:     executeResult.RawOutput,
-		ParsedData:    parseResult.ParsedData,
-		Summary:       parseResult.Summary,
-		Error:         "",
-		ExecutionTime: generateResult.ExecutionTime + executeResult.ExecutionTime + parseResult.ExecutionTime,
+// ForwardAuditResults pushes the parsed events of an audit result to a SIEM
+// destination. Each event is tagged with the result's query ID so forwarded
+// events can be traced back to the investigation that produced them.
+func (s *AuditQueryMCPServer) ForwardAuditResults(destination string, result *types.AuditResult) (*types.ForwardResult, error) {
+	s.logger.Infof("Forwarding %d events from query %s to %s", len(result.ParsedData), result.QueryID, destination)
+
+	events := make([]map[string]interface{}, 0, len(result.ParsedData))
+	for _, entry := range result.ParsedData {
+		event := make(map[string]interface{}, len(entry)+1)
+		for key, value := range entry {
+			event[key] = value
+		}
+		event["query_id"] = result.QueryID
+		events = append(events, event)
 	}
 
-	// Cache the result
-	s.cache.Set(generateResult.QueryID, finalResult)
-	s.logger.Infof("Cached result for query ID: %s", generateResult.QueryID)
+	forwardResult, err := s.forwarder.Forward(context.Background(), destination, events)
+	if err != nil {
+		return nil, err
+	}
+	if forwardResult.Failed > 0 {
+		s.logger.Warnf("Failed to forward %d of %d events to %s", forwardResult.Failed, forwardResult.Events, destination)
+	}
+	return forwardResult, nil
+}
 
-	// Log complete query execution
-	if s.auditTrail != nil {
-		s.auditTrail.LogCompleteQuery(generateResult.QueryID, params, finalResult, "", "", "")
+// QueryAuditTrail searches the audit trail. Results stored with the entries are
+// dropped unless includeResults is set, since they can hold the full raw output.
+func (s *AuditQueryMCPServer) QueryAuditTrail(query utils.AuditTrailQuery, includeResults bool) (*utils.AuditTrailQueryResult, error) {
+	if s.auditTrail == nil {
+		return nil, fmt.Errorf("audit trail is not enabled")
 	}
 
-	return finalResult, nil
+	result, err := s.auditTrail.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	if !includeResults {
+		for i := range result.Entries {
+			result.Entries[i].Result = nil
+		}
+	}
+	return result, nil
 }
 
-// generateQueryID creates a unique query identifier
-func (s *AuditQueryMCPServer) generateQueryID() string {
-	return fmt.Sprintf("audit_query_%s_%s",
-		time.Now().Format("20060102_150405"),
-		s.generateRandomSuffix())
+// VerifyAuditTrail validates the audit trail's hash chain
+func (s *AuditQueryMCPServer) VerifyAuditTrail() (*utils.AuditTrailVerification, error) {
+	if s.auditTrail == nil {
+		return nil, fmt.Errorf("audit trail is not enabled")
+	}
+
+	result, err := s.auditTrail.Verify()
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		s.logger.Warnf("Audit trail verification failed: %s", result.Summary)
+	}
+	return result, nil
 }
 
-// generateRandomSuffix creates a random suffix for query IDs
-func (s *AuditQueryMCPServer) generateRandomSuffix() string {
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, 6)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+// ExplainAuditCommand describes how an already generated command filters audit events
+func (s *AuditQueryMCPServer) ExplainAuditCommand(command string) (*types.QueryExplanation, error) {
+	s.logger.Info("Explaining audit command")
+
+	if err := validation.ValidateGeneratedCommand(command); err != nil {
+		return nil, fmt.Errorf("command validation failed: %w", err)
 	}
-	return string(b)
+
+	explanation := commands.ExplainCommand(command)
+	return &explanation, nil
 }
 
 // GetCacheStats returns cache statistics
@@ -526,7 +2923,22 @@ func (s *AuditQueryMCPServer) GetCacheStats() map[string]interface{} {
 // ClearCache clears all cached results
 func (s *AuditQueryMCPServer) ClearCache() {
 	s.cache.Clear()
+	s.coverageMutex.Lock()
+	s.coverage = make(map[string]queryCoverage)
+	s.coverageMutex.Unlock()
 	s.logger.Info("Cache cleared")
+	s.resultsChanged()
+}
+
+// InvalidateCache removes the cached results of queries matching filter and
+// returns the number removed
+func (s *AuditQueryMCPServer) InvalidateCache(filter utils.CacheFilter) int {
+	removed := s.cache.Invalidate(filter)
+	s.logger.Infof("Invalidated %d cached results", removed)
+	if removed > 0 {
+		s.resultsChanged()
+	}
+	return removed
 }
 
 // GetCachedResult retrieves a cached result by query ID
@@ -534,33 +2946,70 @@ func (s *AuditQueryMCPServer) GetCachedResult(queryID string) (*types.AuditResul
 	return s.cache.Get(queryID)
 }
 
+// RecentResults returns up to limit cached results, most recently used
+// first, without counting as cache hits
+func (s *AuditQueryMCPServer) RecentResults(limit int) []*types.AuditResult {
+	return s.cache.Recent(limit)
+}
+
 // DeleteCachedResult removes a specific cached result
 func (s *AuditQueryMCPServer) DeleteCachedResult(queryID string) {
 	s.cache.Delete(queryID)
 	s.logger.Infof("Deleted cached result for query ID: %s", queryID)
+	s.resultsChanged(queryID)
+}
+
+// GetCircuitBreakerStatus returns the state and counters of the command circuit breaker
+func (s *AuditQueryMCPServer) GetCircuitBreakerStatus() types.CircuitBreakerStatus {
+	return s.circuit.Status()
+}
+
+// ResetCircuitBreaker closes the command circuit breaker and returns its status
+// from before the reset
+func (s *AuditQueryMCPServer) ResetCircuitBreaker() types.CircuitBreakerStatus {
+	previous := s.circuit.Status()
+	s.circuit.Reset()
+	s.logger.Infof("Circuit breaker reset (was %s with %d failures)", previous.State, previous.FailureCount)
+	return previous
 }
 
 // GetServerStats returns comprehensive server statistics
 func (s *AuditQueryMCPServer) GetServerStats() map[string]interface{} {
 	stats := map[string]interface{}{
 		"server_info": map[string]interface{}{
-			"version":      "1.0.0",
+			"version":      ServerVersion,
 			"phase":        "2",
 			"audit_result": true,
 			"caching":      true,
 			"audit_trail":  s.auditTrail != nil,
 		},
-		"cache_stats": s.GetCacheStats(),
+		"cache_stats":     s.GetCacheStats(),
+		"circuit_breaker": s.GetCircuitBreakerStatus(),
+		"provider":        s.ProviderName(),
+		"backends":        s.BackendCapabilities(),
+		"cluster":         s.cluster,
+		"clusters":        s.ClusterNames(),
+		"mcp_session":     s.SessionInfo(),
+		"requests":        s.RequestStats(),
+		"configuration":   s.configuration(),
 		"tools": map[string]interface{}{
-			"audit_result_tools": 4,
-			"cache_tools":        5,
-			"total_tools":        9,
+			"audit_result_tools": 7,
+			"analysis_tools":     22,
+			"integration_tools":  1,
+			"audit_trail_tools":  2,
+			"cache_tools":        6,
+			"management_tools":   4,
+			"finding_tools":      3,
+			"total_tools":        len(s.GetTools()),
+			"by_category":        s.ToolCategoryCounts(),
 		},
 		"features": map[string]interface{}{
 			"query_id_generation":    true,
 			"execution_tracking":     true,
 			"error_handling":         true,
 			"performance_monitoring": true,
+			"in_process_filtering":   s.inProcessFiltering,
+			"forwarding":             s.forwarder.Destinations(),
 		},
 	}
 
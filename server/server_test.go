@@ -2,10 +2,15 @@ package server
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/config"
 	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,7 +31,7 @@ func TestGetTools(t *testing.T) {
 	server := NewAuditQueryMCPServer()
 	tools := server.GetTools()
 
-	assert.Len(t, tools, 9) // Should have 9 tools total
+	assert.Len(t, tools, 48) // Should have 48 tools total
 
 	// Check for specific tools
 	toolNames := make(map[string]bool)
@@ -39,11 +44,50 @@ func TestGetTools(t *testing.T) {
 		"execute_audit_query_with_result",
 		"parse_audit_results_with_result",
 		"execute_complete_audit_query",
+		"explain_audit_query",
+		"ask_audit_question",
+		"find_permission_denials",
+		"compare_audit_activity",
+		"build_user_timeline",
+		"generate_audit_report",
+		"forward_audit_results",
+		"get_audit_trail",
+		"verify_audit_trail",
+		"verify_result",
 		"get_cache_stats",
 		"clear_cache",
+		"invalidate_cache",
 		"get_cached_result",
 		"delete_cached_result",
 		"get_server_stats",
+		"reset_circuit_breaker",
+		"check_log_sources",
+		"get_audit_configuration",
+		"analyze_local_audit_file",
+		"execute_audit_query_batch",
+		"check_permissions",
+		"run_preflight",
+		"configure_parser",
+		"get_parse_errors",
+		"query_all_clusters",
+		"annotate_audit_result",
+		"list_findings",
+		"delete_finding",
+		"match_indicators",
+		"analyze_error_rates",
+		"detect_churn",
+		"detect_escalation_chains",
+		"audit_secret_access",
+		"audit_pod_exec",
+		"audit_infrastructure_changes",
+		"audit_csr_activity",
+		"audit_webhook_changes",
+		"rbac_change_report",
+		"generate_compliance_report",
+		"analyze_login_failures",
+		"audit_token_creation",
+		"find_top_talkers",
+		"estimate_query",
 	}
 
 	for _, expected := range expectedTools {
@@ -76,6 +120,29 @@ func TestGenerateAuditQueryWithResult(t *testing.T) {
 	assert.Contains(t, result.Command, "oc adm node-logs")
 	assert.Contains(t, result.Command, "kube-apiserver")
 	assert.Contains(t, result.Command, "admin")
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, "openshift", result.Backend)
+}
+
+// TestGenerateAuditQueryWithResult_TruncationWarning tests that dropped patterns are reported
+func TestGenerateAuditQueryWithResult_TruncationWarning(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	var patterns []string
+	for i := 1; i <= types.DefaultMaxPatterns+2; i++ {
+		patterns = append(patterns, fmt.Sprintf("pattern%d", i))
+	}
+
+	result, err := server.GenerateAuditQueryWithResult(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Timeframe: "today",
+		Patterns:  patterns,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "pattern11")
+	assert.NotContains(t, result.Command, "pattern11")
 }
 
 // TestGenerateAuditQueryWithResult_InvalidParams tests validation
@@ -143,30 +210,39 @@ func TestExecuteAuditQueryWithResult_InvalidCommand(t *testing.T) {
 
 // TestExecuteAuditQueryWithResult_Timeout tests timeout handling
 func TestExecuteAuditQueryWithResult_Timeout(t *testing.T) {
+	// An oc that never finishes is killed when the command times out
+	installFakeOc(t, "#!/bin/sh\nexec sleep 35\n")
 	server := NewAuditQueryMCPServer()
 
-	// Use a valid oc command that will timeout (this will fail if oc is not available, which is expected)
-	command := "oc adm node-logs --role=master --path=kube-apiserver/audit.log | sleep 35"
+	command := "oc adm node-logs --role=master --path=kube-apiserver/audit.log"
 	queryID := "test-query-123"
 
 	result, err := server.ExecuteAuditQueryWithResult(command, queryID)
+	require.Error(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, queryID, result.QueryID)
+	assert.Equal(t, command, result.Command)
+	assert.NotContains(t, result.Error, "command validation failed")
+	assert.Contains(t, result.Error, "timed out")
+}
 
-	// This test might fail if oc command is not available, which is expected
-	if err != nil {
-		// If it fails, it should be due to command execution or timeout, not validation
-		assert.NotNil(t, result)
-		assert.Equal(t, queryID, result.QueryID)
-		assert.Equal(t, command, result.Command)
-		// The error should be related to command execution or timeout, not validation
-		assert.NotContains(t, result.Error, "command validation failed")
-	} else {
-		// If it succeeds, it should have timed out
-		assert.NotNil(t, result)
-		assert.Equal(t, queryID, result.QueryID)
-		assert.Equal(t, command, result.Command)
-		assert.NotEmpty(t, result.Error)
-		assert.Contains(t, result.Error, "timed out")
+// TestExecuteAuditQueryWithResult_NoShell tests that commands are run without a
+// shell, rejecting programs and syntax generated commands do not use
+func TestExecuteAuditQueryWithResult_NoShell(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	for _, command := range []string{
+		"oc adm node-logs --role=master --path=kube-apiserver/audit.log | sleep 35",
+		"oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i $HOME",
+		"oc adm node-logs --role=master --path=kube-apiserver/audit.log | grep -i \"`id`\"",
+		"oc adm node-logs --role=master --path=kube-apiserver/audit.log > /tmp/audit.log",
+		"oc adm node-logs --role=master --path=kube-apiserver/*.log",
+	} {
+		result, err := server.ExecuteAuditQueryWithResult(command, "test-query-123")
+		require.Error(t, err, command)
+		assert.Contains(t, result.Error, "command validation failed", command)
 	}
+	assert.Equal(t, types.CircuitStateClosed, server.GetCircuitBreakerStatus().State)
 }
 
 // TestParseAuditResultsWithResult tests result parsing
@@ -196,6 +272,121 @@ func TestParseAuditResultsWithResult(t *testing.T) {
 	assert.GreaterOrEqual(t, result.ExecutionTime, int64(0))
 }
 
+// TestGenerateAuditQueryWithResult_InProcessFiltering tests that in-process filtering
+// generates a fetch-only command
+func TestGenerateAuditQueryWithResult_InProcessFiltering(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+	server.SetInProcessFiltering(true)
+
+	result, err := server.GenerateAuditQueryWithResult(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Verb:      "delete",
+		Timeframe: "today",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "oc adm node-logs --role=master --path=kube-apiserver/audit.log", result.Command)
+	assert.NotContains(t, result.Command, "jq")
+	assert.NotContains(t, result.Command, "grep")
+}
+
+// TestParseAuditResultsWithResult_SourceCIDR tests CIDR post-filtering of parsed entries
+func TestParseAuditResultsWithResult_SourceCIDR(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	rawOutput := `{"verb":"get","user":{"username":"system:serviceaccount:ci:deployer"},"sourceIPs":["10.128.0.12"],"requestReceivedTimestamp":"2023-01-01T00:00:00.000000Z"}
+{"verb":"get","user":{"username":"system:serviceaccount:ci:deployer"},"sourceIPs":["203.0.113.7"],"requestReceivedTimestamp":"2023-01-01T00:00:01.000000Z"}`
+
+	queryContext := map[string]interface{}{
+		"log_source":  "kube-apiserver",
+		"source_cidr": "203.0.113.0/24",
+	}
+
+	result, err := server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-cidr")
+	require.NoError(t, err)
+	require.Len(t, result.ParsedData, 1)
+	assert.Equal(t, []string{"203.0.113.7"}, result.ParsedData[0]["source_ips"])
+
+	queryContext["source_cidr"] = "not-a-cidr"
+	result, err = server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-cidr")
+	assert.Error(t, err)
+	assert.Contains(t, result.Error, "invalid source CIDR")
+}
+
+// TestParseAuditResultsWithResult_Deduplication tests that events read from overlapping
+// log files are reported once
+func TestParseAuditResultsWithResult_Deduplication(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	rawOutput := `{"auditID":"a1","stage":"ResponseComplete","verb":"delete","user":{"username":"admin"}}
+{"auditID":"b2","stage":"ResponseComplete","verb":"get","user":{"username":"admin"}}
+{"auditID":"a1","stage":"ResponseComplete","verb":"delete","user":{"username":"admin"}}`
+
+	result, err := server.ParseAuditResultsWithResult(rawOutput, map[string]interface{}{"log_source": "kube-apiserver"}, "test-query-dedup")
+	require.NoError(t, err)
+	assert.Len(t, result.ParsedData, 2)
+	assert.Equal(t, 1, result.DuplicatesRemoved)
+	assert.Equal(t, "a1", result.ParsedData[0]["audit_id"])
+}
+
+// TestParseAuditResultsWithResult_SortAndLimit tests that entries are sorted and paged
+// after parsing while the total and summary cover every match
+func TestParseAuditResultsWithResult_SortAndLimit(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	rawOutput := `{"verb":"delete","user":{"username":"alice"},"requestReceivedTimestamp":"2024-01-15T10:00:01.000000Z"}
+{"verb":"delete","user":{"username":"bob"},"requestReceivedTimestamp":"2024-01-15T10:00:03.000000Z"}
+{"verb":"delete","user":{"username":"carol"},"requestReceivedTimestamp":"2024-01-15T10:00:02.000000Z"}`
+
+	queryContext := map[string]interface{}{
+		"log_source": "kube-apiserver",
+		"sort_by":    "timestamp",
+		"sort_order": "desc",
+		"limit":      float64(2),
+	}
+
+	result, err := server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-sort")
+	require.NoError(t, err)
+	require.Len(t, result.ParsedData, 2)
+	assert.Equal(t, "bob", result.ParsedData[0]["username"])
+	assert.Equal(t, "carol", result.ParsedData[1]["username"])
+	assert.Equal(t, 3, result.TotalEntries)
+
+	queryContext["sort_by"] = "verb"
+	_, err = server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-sort")
+	assert.Error(t, err)
+}
+
+// TestParseAuditResultsWithResult_Histogram tests that histogram mode returns bucket
+// counts instead of entries
+func TestParseAuditResultsWithResult_Histogram(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	rawOutput := `{"verb":"get","user":{"username":"alice"},"requestReceivedTimestamp":"2024-01-15T10:05:00.000000Z"}
+{"verb":"delete","user":{"username":"bob"},"requestReceivedTimestamp":"2024-01-15T10:45:00.000000Z"}
+{"verb":"get","user":{"username":"alice"},"requestReceivedTimestamp":"2024-01-15T11:15:00.000000Z"}`
+
+	queryContext := map[string]interface{}{
+		"log_source":  "kube-apiserver",
+		"output_mode": "histogram",
+	}
+
+	result, err := server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-histogram")
+	require.NoError(t, err)
+	require.NotNil(t, result.Histogram)
+	assert.Empty(t, result.ParsedData)
+	assert.Empty(t, result.RawOutput)
+	assert.Equal(t, 3, result.TotalEntries)
+	assert.Equal(t, "hour", result.Histogram.BucketSize)
+	require.Len(t, result.Histogram.Buckets, 2)
+	assert.Equal(t, 2, result.Histogram.Buckets[0].Count)
+	assert.Equal(t, 1, result.Histogram.Buckets[0].ByVerb["delete"])
+
+	queryContext["bucket_size"] = "week"
+	_, err = server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-histogram")
+	assert.Error(t, err)
+}
+
 // TestParseAuditResultsWithResult_EmptyOutput tests empty output handling
 func TestParseAuditResultsWithResult_EmptyOutput(t *testing.T) {
 	server := NewAuditQueryMCPServer()
@@ -274,6 +465,49 @@ func TestGetCacheStats(t *testing.T) {
 	assert.Contains(t, stats, "hits")
 	assert.Contains(t, stats, "misses")
 	assert.Contains(t, stats, "hit_rate")
+	assert.Contains(t, stats, "bytes")
+	assert.Contains(t, stats, "evictions")
+}
+
+// TestCachePersistenceAcrossRestart tests that the cache is saved on shutdown and restored on start
+func TestCachePersistenceAcrossRestart(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+	t.Setenv("AUDIT_CACHE_FILE", cacheFile)
+	t.Setenv("AUDIT_CACHE_MAX_ENTRIES", "10")
+	t.Setenv("AUDIT_CACHE_NEGATIVE_TTL", "30s")
+
+	server := NewAuditQueryMCPServer()
+	assert.Equal(t, 10, server.GetCacheStats()["max_entries"])
+	assert.Equal(t, "30s", server.GetCacheStats()["negative_ttl"])
+	server.cache.Set("persisted-query", &types.AuditResult{QueryID: "persisted-query", Summary: "3 events"})
+	require.NoError(t, server.Shutdown())
+
+	restarted := NewAuditQueryMCPServer()
+	result, found := restarted.GetCachedResult("persisted-query")
+	require.True(t, found)
+	assert.Equal(t, "3 events", result.Summary)
+}
+
+// TestExecuteCompleteAuditQuery_CacheKey tests that equivalent parameters are served from the cache
+func TestExecuteCompleteAuditQuery_CacheKey(t *testing.T) {
+	server := NewAuditQueryMCPServer()
+
+	cached := types.AuditQueryParams{Patterns: []string{"secrets", "delete"}, Timeframe: "24h"}
+	key := commands.CacheKey(cached, time.Now(), commands.DefaultCacheKeyGranularity)
+	server.cache.SetForParams("cached-query", key, cached, &types.AuditResult{QueryID: "cached-query", Summary: "2 events"})
+
+	result, err := server.ExecuteCompleteAuditQuery(types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Patterns:  []string{"delete", "secrets"},
+		Timeframe: "24h",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cached-query", result.QueryID)
+	assert.Equal(t, "2 events", result.Summary)
+
+	assert.Equal(t, 1, server.InvalidateCache(utils.CacheFilter{Pattern: "secrets"}))
+	_, found := server.GetCachedResult("cached-query")
+	assert.False(t, found)
 }
 
 // TestClearCache tests cache clearing
@@ -373,32 +607,60 @@ func TestGetServerStats(t *testing.T) {
 	// Convert to int for comparison (JSON unmarshaling can produce either type)
 	auditResultTools := tools["audit_result_tools"]
 	if auditResultToolsFloat, ok := auditResultTools.(float64); ok {
-		assert.Equal(t, 4, int(auditResultToolsFloat))
+		assert.Equal(t, 7, int(auditResultToolsFloat))
 	} else if auditResultToolsInt, ok := auditResultTools.(int); ok {
-		assert.Equal(t, 4, auditResultToolsInt)
+		assert.Equal(t, 7, auditResultToolsInt)
 	} else {
 		t.Errorf("Unexpected type for audit_result_tools: %T", auditResultTools)
 	}
 
 	cacheTools := tools["cache_tools"]
 	if cacheToolsFloat, ok := cacheTools.(float64); ok {
-		assert.Equal(t, 5, int(cacheToolsFloat))
+		assert.Equal(t, 6, int(cacheToolsFloat))
 	} else if cacheToolsInt, ok := cacheTools.(int); ok {
-		assert.Equal(t, 5, cacheToolsInt)
+		assert.Equal(t, 6, cacheToolsInt)
 	} else {
 		t.Errorf("Unexpected type for cache_tools: %T", cacheTools)
 	}
 
 	totalTools := tools["total_tools"]
 	if totalToolsFloat, ok := totalTools.(float64); ok {
-		assert.Equal(t, 9, int(totalToolsFloat))
+		assert.Equal(t, 48, int(totalToolsFloat))
 	} else if totalToolsInt, ok := totalTools.(int); ok {
-		assert.Equal(t, 9, totalToolsInt)
+		assert.Equal(t, 48, totalToolsInt)
 	} else {
 		t.Errorf("Unexpected type for total_tools: %T", totalTools)
 	}
 }
 
+// TestGetServerStats_Configuration tests that settings loaded from the .env
+// file are applied and reported with their source, secrets masked
+func TestGetServerStats_Configuration(t *testing.T) {
+	for _, name := range []string{"AUDIT_RESULT_SIGNING_KEY", "AUDIT_OMIT_RAW_OUTPUT"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("AUDIT_RESULT_SIGNING_KEY=s3cret\nAUDIT_OMIT_RAW_OUTPUT=true\n"), 0644))
+	t.Setenv(config.EnvFileVariable, envFile)
+	t.Setenv("AUDIT_HIDE_LEGACY_TOOLS", "false")
+
+	server := newMockServer(t)
+	assert.True(t, server.omitRawOutput)
+	assert.Equal(t, []byte("s3cret"), server.resultSigningKey)
+
+	configuration, ok := server.GetServerStats()["configuration"].(*config.Config)
+	require.True(t, ok)
+	assert.True(t, configuration.EnvFileLoaded)
+	settings := make(map[string]config.Setting)
+	for _, setting := range configuration.Settings {
+		settings[setting.Name] = setting
+	}
+	assert.Equal(t, config.Setting{Name: "AUDIT_RESULT_SIGNING_KEY", Value: config.Mask, Source: config.SourceEnvFile}, settings["AUDIT_RESULT_SIGNING_KEY"])
+	assert.Equal(t, config.Setting{Name: "AUDIT_OMIT_RAW_OUTPUT", Value: "true", Source: config.SourceEnvFile}, settings["AUDIT_OMIT_RAW_OUTPUT"])
+	assert.Equal(t, config.SourceEnvironment, settings["AUDIT_HIDE_LEGACY_TOOLS"].Source)
+}
+
 // TestGetLogger tests logger retrieval
 func TestGetLogger(t *testing.T) {
 	server := NewAuditQueryMCPServer()
@@ -485,3 +747,61 @@ func TestPerformance(t *testing.T) {
 	// Should complete 100 queries in reasonable time (less than 1 second)
 	assert.Less(t, duration, time.Second)
 }
+
+// TestCircuitBreakerConfigAndMetrics tests configuring the circuit breaker from the environment and exporting its state
+func TestCircuitBreakerConfigAndMetrics(t *testing.T) {
+	t.Setenv("AUDIT_CIRCUIT_FAILURE_THRESHOLD", "1")
+	t.Setenv("AUDIT_CIRCUIT_RESET_TIMEOUT", "10m")
+	server := NewAuditQueryMCPServer()
+
+	status := server.GetCircuitBreakerStatus()
+	assert.Equal(t, 1, status.FailureThreshold)
+	assert.Equal(t, "10m0s", status.ResetTimeout)
+
+	server.circuit.RecordFailure()
+	stats := server.GetServerStats()
+	assert.Equal(t, types.CircuitStateOpen, stats["circuit_breaker"].(types.CircuitBreakerStatus).State)
+
+	// An open breaker makes generated commands fall back and says so
+	result, err := server.GenerateAuditQueryWithResult(types.AuditQueryParams{LogSource: "kube-apiserver", Timeframe: "today"})
+	require.NoError(t, err)
+	assert.Contains(t, fmt.Sprint(result.Warnings), "circuit breaker is open")
+
+	metrics := server.PrometheusMetrics()
+	assert.Contains(t, metrics, `audit_query_circuit_breaker_state{state="open"} 1`)
+	assert.Contains(t, metrics, `audit_query_circuit_breaker_state{state="closed"} 0`)
+	assert.Contains(t, metrics, "audit_query_circuit_breaker_trips_total 1")
+	assert.Contains(t, metrics, "# TYPE audit_query_cache_hits_total counter")
+}
+
+// TestParseAuditResultsWithResult_SummaryTemplates tests verbose summaries and
+// custom summary templates
+func TestParseAuditResultsWithResult_SummaryTemplates(t *testing.T) {
+	rawOutput := `{"verb":"get","user":{"username":"alice"},"objectRef":{"resource":"secrets","namespace":"payments"},"responseStatus":{"code":403}}
+{"verb":"delete","user":{"username":"bob"},"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200}}`
+	queryContext := map[string]interface{}{
+		"log_source":   "kube-apiserver",
+		"summary_mode": "verbose",
+	}
+
+	server := NewAuditQueryMCPServer()
+	result, err := server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-summary")
+	require.NoError(t, err)
+	assert.Contains(t, result.Summary, "Top namespaces: default (1), payments (1)")
+	assert.Contains(t, result.Summary, "Error rate: 50.0% (1 of 2 responses failed)")
+
+	path := filepath.Join(t.TempDir(), "verbose.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`{{.Errors}} denied in {{.Context.log_source}}`), 0644))
+	t.Setenv("AUDIT_SUMMARY_VERBOSE_TEMPLATE", path)
+	server = NewAuditQueryMCPServer()
+	result, err = server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-summary")
+	require.NoError(t, err)
+	assert.Equal(t, "1 denied in kube-apiserver", result.Summary)
+
+	// Invalid templates keep the built-in ones
+	require.NoError(t, os.WriteFile(path, []byte(`{{.Errors`), 0644))
+	server = NewAuditQueryMCPServer()
+	result, err = server.ParseAuditResultsWithResult(rawOutput, queryContext, "test-query-summary")
+	require.NoError(t, err)
+	assert.Contains(t, result.Summary, "Top users: alice (1), bob (1)")
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/commands"
+	"audit-query-mcp-server/sourceip"
+	"audit-query-mcp-server/types"
+)
+
+// ClassifySourceIPs returns a copy of result whose parsed entries carry a
+// "source_ip_info" classifying each source IP, and "external_source" when one
+// of them is external. IPs are matched against AUDIT_INTERNAL_CIDRS, then
+// against the cluster's node addresses and pod and service networks, looked
+// up with oc and cached for the enrichment TTL, and finally against the
+// AUDIT_GEOIP_DATABASE for their country.
+func (s *AuditQueryMCPServer) ClassifySourceIPs(result *types.AuditResult) (*types.AuditResult, *types.SourceIPStats) {
+	stats := &types.SourceIPStats{Categories: make(map[string]int), ExternalIPs: []string{}}
+	var hooks []sourceip.Hook
+	if s.internalNetworks != nil {
+		hooks = append(hooks, s.internalNetworks)
+	}
+	if s.provider == nil {
+		clusterHooks, errs := s.clusterNetworkHooks()
+		hooks = append(hooks, clusterHooks...)
+		stats.Errors = errs
+	}
+	if s.geoDatabase != nil {
+		hooks = append(hooks, s.geoDatabase)
+	}
+	classifier := sourceip.NewClassifier(hooks...)
+	stats.Hooks = classifier.Hooks()
+
+	infos := make(map[string]types.SourceIPInfo)
+	classified := *result
+	// The annotated entries no longer match the hashes of the cached result
+	classified.Integrity = nil
+	classified.ParsedData = make([]map[string]interface{}, len(result.ParsedData))
+	for i, entry := range result.ParsedData {
+		copied := make(map[string]interface{}, len(entry)+2)
+		for key, value := range entry {
+			copied[key] = value
+		}
+		classified.ParsedData[i] = copied
+
+		ips := entrySourceIPs(entry)
+		if len(ips) == 0 {
+			continue
+		}
+		entryInfos := make([]types.SourceIPInfo, len(ips))
+		external := false
+		for j, ip := range ips {
+			info, ok := infos[ip]
+			if !ok {
+				info = classifier.Classify(ip)
+				infos[ip] = info
+				if info.Category != "" {
+					stats.ClassifiedIPs++
+					stats.Categories[info.Category]++
+				}
+				if info.External {
+					stats.ExternalIPs = append(stats.ExternalIPs, ip)
+				}
+			}
+			entryInfos[j] = info
+			external = external || info.External
+		}
+		copied["source_ip_info"] = entryInfos
+		if external {
+			copied["external_source"] = true
+			stats.ExternalEntries++
+		}
+	}
+	sort.Strings(stats.ExternalIPs)
+
+	s.logger.Infof("Classified %d source IPs of query %s: %d external in %d entries",
+		stats.ClassifiedIPs, result.QueryID, len(stats.ExternalIPs), stats.ExternalEntries)
+	return &classified, stats
+}
+
+// clusterNetworkHooks looks up the cluster's node addresses and pod and
+// service networks; a failed lookup only leaves out its hook
+func (s *AuditQueryMCPServer) clusterNetworkHooks() ([]sourceip.Hook, []string) {
+	e := &enrichment{server: s, outcomes: make(map[string]lookupOutcome)}
+	var hooks []sourceip.Hook
+	var errs []string
+
+	output, err := e.lookup(commands.NodesArgs())
+	var addresses []string
+	if err == nil {
+		addresses, err = commands.ParseNodeAddresses(output)
+	}
+	if err == nil {
+		var nodes []sourceip.Network
+		if nodes, err = sourceip.ParseNetworks(strings.Join(addresses, ",")); err == nil {
+			hooks = append(hooks, sourceip.NewNetworkHook("cluster-nodes", sourceip.CategoryClusterNode, nodes))
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("node addresses: %v", err))
+	}
+
+	output, err = e.lookup(commands.ClusterNetworkArgs())
+	var podCIDRs, serviceCIDRs []string
+	if err == nil {
+		podCIDRs, serviceCIDRs, err = commands.ParseClusterNetwork(output)
+	}
+	if err == nil {
+		var pods, services []sourceip.Network
+		if pods, err = sourceip.ParseNetworks(strings.Join(podCIDRs, ",")); err == nil {
+			services, err = sourceip.ParseNetworks(strings.Join(serviceCIDRs, ","))
+		}
+		if err == nil {
+			hooks = append(hooks,
+				sourceip.NewNetworkHook("cluster-pod-network", sourceip.CategoryClusterPod, pods),
+				sourceip.NewNetworkHook("cluster-service-network", sourceip.CategoryClusterService, services))
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("cluster networks: %v", err))
+	}
+	return hooks, errs
+}
+
+// entrySourceIPs returns the source IPs of a parsed entry, which are strings
+// in fresh results and generic values in results restored from disk
+func entrySourceIPs(entry map[string]interface{}) []string {
+	switch ips := entry["source_ips"].(type) {
+	case []string:
+		return ips
+	case []interface{}:
+		values := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			if value, ok := ip.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// addSourceClassification replaces the result in a tool response with a copy
+// whose source IPs are classified when the request asks for it
+func (s *AuditQueryMCPServer) addSourceClassification(response map[string]interface{}, params map[string]interface{}, result *types.AuditResult) *types.AuditResult {
+	if requested, _ := params["classify_sources"].(bool); !requested {
+		return result
+	}
+	classified, stats := s.ClassifySourceIPs(result)
+	response["audit_result"] = classified
+	response["source_ips"] = stats
+	return classified
+}
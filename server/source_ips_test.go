@@ -0,0 +1,97 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/sourceip"
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassifySourceIPs tests classifying source IPs with configured and cluster networks
+func TestClassifySourceIPs(t *testing.T) {
+	geoPath := filepath.Join(t.TempDir(), "geoip.csv")
+	require.NoError(t, os.WriteFile(geoPath, []byte("203.0.113.0/24,NL,Example ISP\n"), 0644))
+	t.Setenv("AUDIT_PROVIDER", "")
+	t.Setenv("AUDIT_INTERNAL_CIDRS", "vpn=10.8.0.0/16")
+	t.Setenv("AUDIT_GEOIP_DATABASE", geoPath)
+	server := NewAuditQueryMCPServer()
+	cluster := &fakeCluster{calls: make(map[string]int), outputs: map[string]string{
+		"get nodes -o json": `{"items":[{"status":{"addresses":[{"type":"InternalIP","address":"10.0.0.10"}]}}]}`,
+		"get network.config.openshift.io cluster -o json": `{"spec":{"clusterNetwork":[{"cidr":"10.128.0.0/14"}],"serviceNetwork":["172.30.0.0/16"]}}`,
+	}}
+	server.clusterLookup = cluster.lookup
+
+	result := &types.AuditResult{QueryID: "q1", ParsedData: []map[string]interface{}{
+		{"username": "alice", "source_ips": []string{"10.8.1.2"}},
+		{"username": "system:serviceaccount:ci:deployer", "source_ips": []interface{}{"10.128.2.14"}},
+		{"username": "system:kube-controller-manager", "source_ips": []string{"10.0.0.10"}},
+		{"username": "bob", "source_ips": []string{"10.8.1.2", "203.0.113.10"}},
+		{"username": "carol"},
+	}}
+	classified, stats := server.ClassifySourceIPs(result)
+
+	assert.Equal(t, []string{"internal-networks", "cluster-nodes", "cluster-pod-network", "cluster-service-network", "geoip"}, stats.Hooks)
+	assert.Equal(t, "vpn", classified.ParsedData[0]["source_ip_info"].([]types.SourceIPInfo)[0].Label)
+	assert.Equal(t, sourceip.CategoryClusterPod, classified.ParsedData[1]["source_ip_info"].([]types.SourceIPInfo)[0].Category)
+	assert.Equal(t, sourceip.CategoryClusterNode, classified.ParsedData[2]["source_ip_info"].([]types.SourceIPInfo)[0].Category)
+
+	external := classified.ParsedData[3]["source_ip_info"].([]types.SourceIPInfo)[1]
+	assert.Equal(t, types.SourceIPInfo{IP: "203.0.113.10", Category: sourceip.CategoryExternal, Country: "NL", Organization: "Example ISP", External: true}, external)
+	assert.Equal(t, true, classified.ParsedData[3]["external_source"])
+	assert.NotContains(t, classified.ParsedData[0], "external_source")
+	assert.NotContains(t, classified.ParsedData[4], "source_ip_info")
+
+	assert.Equal(t, 4, stats.ClassifiedIPs)
+	assert.Equal(t, map[string]int{"internal": 1, "cluster-pod": 1, "cluster-node": 1, "external": 1}, stats.Categories)
+	assert.Equal(t, []string{"203.0.113.10"}, stats.ExternalIPs)
+	assert.Equal(t, 1, stats.ExternalEntries)
+	assert.Empty(t, stats.Errors)
+
+	// The cached result is not modified
+	assert.NotContains(t, result.ParsedData[3], "source_ip_info")
+}
+
+// TestClassifySourceIPs_LookupFailure tests classifying without cluster networks when oc lookups fail
+func TestClassifySourceIPs_LookupFailure(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "")
+	server := NewAuditQueryMCPServer()
+	server.clusterLookup = (&fakeCluster{calls: make(map[string]int)}).lookup
+
+	classified, stats := server.ClassifySourceIPs(&types.AuditResult{ParsedData: []map[string]interface{}{
+		{"source_ips": []string{"10.128.0.30"}},
+	}})
+	assert.Len(t, stats.Errors, 2)
+	assert.Empty(t, stats.Hooks)
+	assert.Equal(t, sourceip.CategoryPrivate, classified.ParsedData[0]["source_ip_info"].([]types.SourceIPInfo)[0].Category)
+}
+
+// TestClassifySourceIPs_Tool tests the classify_sources argument of execute_complete_audit_query
+func TestClassifySourceIPs_Tool(t *testing.T) {
+	server := newMockServer(t)
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "execute_complete_audit_query",
+		"arguments": map[string]interface{}{
+			"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "username": "alice", "timeframe": "today"},
+			"classify_sources":  true,
+		},
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	stats := result["source_ips"].(*types.SourceIPStats)
+	assert.Equal(t, []string{"203.0.113.10"}, stats.ExternalIPs)
+	assert.Empty(t, stats.Hooks, "cluster networks are not looked up with the mock provider")
+
+	external := 0
+	for _, entry := range result["audit_result"].(*types.AuditResult).ParsedData {
+		if entry["external_source"] == true {
+			external++
+		}
+	}
+	assert.Equal(t, stats.ExternalEntries, external)
+	assert.Positive(t, external)
+}
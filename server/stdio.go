@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"audit-query-mcp-server/types"
+)
+
+// stdioMessage is a JSON-RPC message read by the stdio transport. Its ID is
+// kept raw so responses echo numeric and string IDs as the client sent them.
+type stdioMessage struct {
+	JSONRPC string                 `json:"jsonrpc"`
+	ID      json.RawMessage        `json:"id,omitempty"`
+	Method  string                 `json:"method"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// stdioResponse is a JSON-RPC response written by the stdio transport, with
+// either a result or an error
+type stdioResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *types.MCPError `json:"error,omitempty"`
+}
+
+// stdioWriter writes one JSON message per line, from concurrent requests and
+// notifications
+type stdioWriter struct {
+	mutex sync.Mutex
+	out   io.Writer
+	err   error
+}
+
+// write encodes a message on its own line, keeping the first write error
+func (w *stdioWriter) write(message interface{}) {
+	data, err := json.Marshal(message)
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.err != nil {
+		return
+	}
+	if err == nil {
+		_, err = w.out.Write(append(data, '\n'))
+	}
+	w.err = err
+}
+
+// ServeStdio serves the MCP stdio transport: newline-delimited JSON-RPC
+// requests read from in are handled concurrently, and their responses and
+// the server's notifications are written to out. It returns when in ends,
+// after the requests read have been answered.
+func (s *AuditQueryMCPServer) ServeStdio(in io.Reader, out io.Writer) error {
+	writer := &stdioWriter{out: out}
+	s.SetNotifier(func(notification types.MCPNotification) {
+		writer.write(notification)
+	})
+	defer s.SetNotifier(nil)
+
+	var requests sync.WaitGroup
+	defer requests.Wait()
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var message stdioMessage
+			if decodeErr := json.Unmarshal(line, &message); decodeErr != nil {
+				writer.write(stdioResponse{
+					JSONRPC: "2.0",
+					ID:      json.RawMessage("null"),
+					Error:   &types.MCPError{Code: -32700, Message: "Parse error: " + decodeErr.Error()},
+				})
+			} else if message.Method == "" {
+				id := message.ID
+				if len(id) == 0 {
+					id = json.RawMessage("null")
+				}
+				writer.write(stdioResponse{
+					JSONRPC: "2.0",
+					ID:      id,
+					Error:   &types.MCPError{Code: -32600, Message: "Invalid Request: method is required"},
+				})
+			} else {
+				requests.Add(1)
+				go func() {
+					defer requests.Done()
+					s.serveStdioMessage(message, writer)
+				}()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	requests.Wait()
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	return writer.err
+}
+
+// serveStdioMessage handles a request read by the stdio transport and writes
+// its response; messages without an ID are notifications and get none
+func (s *AuditQueryMCPServer) serveStdioMessage(message stdioMessage, writer *stdioWriter) {
+	request := types.MCPRequest{Method: message.Method, Params: message.Params, JSONRPC: message.JSONRPC}
+	notification := len(message.ID) == 0 || string(message.ID) == "null"
+	if !notification {
+		request.ID = string(message.ID)
+		if id, err := strconv.Unquote(request.ID); err == nil {
+			request.ID = id
+		}
+	}
+	response := s.HandleMCPRequest(request)
+	if notification || request.IsNotification() {
+		return
+	}
+	reply := stdioResponse{JSONRPC: "2.0", ID: message.ID, Error: response.Error}
+	if response.Error == nil {
+		reply.Result = response.Result
+		if reply.Result == nil {
+			reply.Result = map[string]interface{}{}
+		}
+	}
+	writer.write(reply)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServeStdio tests answering newline-delimited requests with their IDs, skipping notifications
+func TestServeStdio(t *testing.T) {
+	server := newMockServer(t)
+	input := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18"}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		``,
+		`{"jsonrpc": "2.0", "id": "two", "method": "ping"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "bogus_tool", "arguments": {}}}`,
+		`not json`,
+		`{"jsonrpc": "2.0", "id": 4}`,
+	}, "\n")
+
+	var output bytes.Buffer
+	require.NoError(t, server.ServeStdio(strings.NewReader(input), &output))
+
+	responses := make(map[string]map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &response), line)
+		assert.Equal(t, "2.0", response["jsonrpc"])
+		id, _ := json.Marshal(response["id"])
+		responses[string(id)] = response
+	}
+	require.Len(t, responses, 5, output.String())
+
+	assert.Equal(t, "2025-06-18", responses["1"]["result"].(map[string]interface{})["protocolVersion"])
+	assert.Equal(t, map[string]interface{}{}, responses[`"two"`]["result"])
+	assert.NotNil(t, responses["3"]["error"])
+	assert.NotContains(t, responses["3"], "result")
+	assert.Equal(t, float64(-32700), responses["null"]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(-32600), responses["4"]["error"].(map[string]interface{})["code"])
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditTokenCreation tests reading token lifetimes from request bodies
+func TestAuditTokenCreation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	event := func(minutesAgo int, username string, expirationSeconds int) string {
+		return fmt.Sprintf(`{"kind":"Event","level":"Request","stage":"ResponseComplete","auditID":"token-%d","requestReceivedTimestamp":%q,"verb":"create","user":{"username":%q},"objectRef":{"resource":"serviceaccounts","namespace":"ci","name":"deployer","subresource":"token"},"responseStatus":{"code":201},"requestObject":{"spec":{"expirationSeconds":%d}}}`,
+			minutesAgo, now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), username, expirationSeconds)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event(30, "alice", 3600),
+		event(20, "alice", 30*24*3600),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleAuditTokenCreation("test-id", map[string]interface{}{"max_lifetime": "forever"})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleAuditTokenCreation("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.TokenCreationReport)
+	require.Len(t, report.Tokens, 2)
+	assert.False(t, report.Tokens[0].Flagged)
+	assert.True(t, report.Tokens[1].Flagged)
+	assert.Equal(t, "720h0m0s", report.Tokens[1].Lifetime)
+
+	response = server.handleAuditTokenCreation("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+		"max_lifetime":      "30m",
+	})
+	require.Nil(t, response.Error)
+	report = response.Result.(map[string]interface{})["report"].(*types.TokenCreationReport)
+	assert.Equal(t, 2, report.HumanLongLived)
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"audit-query-mcp-server/types"
+	"audit-query-mcp-server/utils"
+)
+
+// Tool categories reported by tools/list
+const (
+	ToolCategoryQuery      = "query"
+	ToolCategoryAnalytics  = "analytics"
+	ToolCategoryDetection  = "detection"
+	ToolCategoryManagement = "management"
+)
+
+// ToolCategories are the categories tools/list can be filtered on
+var ToolCategories = []string{ToolCategoryQuery, ToolCategoryAnalytics, ToolCategoryDetection, ToolCategoryManagement}
+
+// ToolsPageSize is the number of tools returned by one tools/list request;
+// the nextCursor of a page fetches the following one
+const ToolsPageSize = 50
+
+// toolCategories assigns each tool its category
+var toolCategories = map[string]string{
+	"generate_audit_query_with_result": ToolCategoryQuery,
+	"execute_audit_query_with_result":  ToolCategoryQuery,
+	"parse_audit_results_with_result":  ToolCategoryQuery,
+	"execute_complete_audit_query":     ToolCategoryQuery,
+	"explain_audit_query":              ToolCategoryQuery,
+	"estimate_query":                   ToolCategoryQuery,
+	"ask_audit_question":               ToolCategoryQuery,
+	"analyze_local_audit_file":         ToolCategoryQuery,
+	"execute_audit_query_batch":        ToolCategoryQuery,
+	"query_all_clusters":               ToolCategoryQuery,
+
+	"compare_audit_activity":       ToolCategoryAnalytics,
+	"build_user_timeline":          ToolCategoryAnalytics,
+	"generate_audit_report":        ToolCategoryAnalytics,
+	"analyze_error_rates":          ToolCategoryAnalytics,
+	"detect_churn":                 ToolCategoryAnalytics,
+	"audit_infrastructure_changes": ToolCategoryAnalytics,
+	"rbac_change_report":           ToolCategoryAnalytics,
+	"generate_compliance_report":   ToolCategoryAnalytics,
+	"find_top_talkers":             ToolCategoryAnalytics,
+
+	"find_permission_denials":  ToolCategoryDetection,
+	"match_indicators":         ToolCategoryDetection,
+	"detect_escalation_chains": ToolCategoryDetection,
+	"audit_secret_access":      ToolCategoryDetection,
+	"audit_pod_exec":           ToolCategoryDetection,
+	"audit_csr_activity":       ToolCategoryDetection,
+	"audit_webhook_changes":    ToolCategoryDetection,
+	"analyze_login_failures":   ToolCategoryDetection,
+	"audit_token_creation":     ToolCategoryDetection,
+
+	"forward_audit_results":   ToolCategoryManagement,
+	"get_audit_trail":         ToolCategoryManagement,
+	"verify_audit_trail":      ToolCategoryManagement,
+	"verify_result":           ToolCategoryManagement,
+	"get_cache_stats":         ToolCategoryManagement,
+	"clear_cache":             ToolCategoryManagement,
+	"invalidate_cache":        ToolCategoryManagement,
+	"get_cached_result":       ToolCategoryManagement,
+	"delete_cached_result":    ToolCategoryManagement,
+	"get_server_stats":        ToolCategoryManagement,
+	"reset_circuit_breaker":   ToolCategoryManagement,
+	"check_log_sources":       ToolCategoryManagement,
+	"get_audit_configuration": ToolCategoryManagement,
+	"check_permissions":       ToolCategoryManagement,
+	"run_preflight":           ToolCategoryManagement,
+	"configure_parser":        ToolCategoryManagement,
+	"get_parse_errors":        ToolCategoryManagement,
+	"annotate_audit_result":   ToolCategoryManagement,
+	"list_findings":           ToolCategoryManagement,
+	"delete_finding":          ToolCategoryManagement,
+}
+
+// offlineTools only work on their arguments, cached results, findings, the
+// audit trail, local files or the server's own state
+var offlineTools = map[string]bool{
+	"generate_audit_query_with_result": true,
+	"parse_audit_results_with_result":  true,
+	"explain_audit_query":              true,
+	"analyze_local_audit_file":         true,
+	"get_audit_trail":                  true,
+	"verify_audit_trail":               true,
+	"verify_result":                    true,
+	"get_cache_stats":                  true,
+	"clear_cache":                      true,
+	"invalidate_cache":                 true,
+	"get_cached_result":                true,
+	"delete_cached_result":             true,
+	"get_server_stats":                 true,
+	"reset_circuit_breaker":            true,
+	"configure_parser":                 true,
+	"get_parse_errors":                 true,
+	"annotate_audit_result":            true,
+	"list_findings":                    true,
+	"delete_finding":                   true,
+}
+
+// jqTools run the commands built by the query builder, which filter with jq
+// unless AUDIT_IN_PROCESS_FILTERING is set. Analysis tools fetch whole logs
+// and filter them in Go.
+var jqTools = map[string]bool{
+	"execute_audit_query_with_result": true,
+	"execute_complete_audit_query":    true,
+	"ask_audit_question":              true,
+	"execute_audit_query_batch":       true,
+	"query_all_clusters":              true,
+}
+
+// withToolMetadata sets the category, capabilities and version of the tools
+func (s *AuditQueryMCPServer) withToolMetadata(tools []types.MCPTool) []types.MCPTool {
+	for i := range tools {
+		tools[i].Category = toolCategories[tools[i].Name]
+		tools[i].Version = toolVersion(tools[i].Name)
+		tools[i].Capabilities = &types.ToolCapabilities{
+			RequiresCluster: !offlineTools[tools[i].Name],
+			RequiresJQ:      jqTools[tools[i].Name] && !s.inProcessFiltering,
+		}
+	}
+	return tools
+}
+
+// ToolCategoryCounts returns the number of tools in each category
+func (s *AuditQueryMCPServer) ToolCategoryCounts() map[string]int {
+	counts := make(map[string]int, len(ToolCategories))
+	for _, tool := range s.GetTools() {
+		counts[tool.Category]++
+	}
+	return counts
+}
+
+// listTools returns the page of at most pageSize tools, followed by the
+// deprecated aliases, starting at cursor among those in category, or all
+// categories when it is empty, and requiring the cluster as requiresCluster
+// says when it is set. The returned cursor fetches the next page and is empty
+// on the last one.
+func (s *AuditQueryMCPServer) listTools(category string, requiresCluster *bool, cursor string, pageSize int) ([]types.MCPTool, string, error) {
+	if category != "" && !utils.Contains(ToolCategories, category) {
+		return nil, "", fmt.Errorf("unknown tool category: %s, expected one of: %s", category, strings.Join(ToolCategories, ", "))
+	}
+	offset, err := decodeToolsCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	matching := []types.MCPTool{}
+	for _, tool := range append(s.GetTools(), s.legacyTools()...) {
+		if category != "" && tool.Category != category {
+			continue
+		}
+		if requiresCluster != nil && tool.Capabilities.RequiresCluster != *requiresCluster {
+			continue
+		}
+		matching = append(matching, tool)
+	}
+	if offset > len(matching) {
+		return nil, "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+
+	end := offset + pageSize
+	if end >= len(matching) {
+		return matching[offset:], "", nil
+	}
+	return matching[offset:end], encodeToolsCursor(end), nil
+}
+
+// encodeToolsCursor returns the opaque cursor of the tools page starting at offset
+func encodeToolsCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("tools:" + strconv.Itoa(offset)))
+}
+
+// decodeToolsCursor returns the offset of a cursor made by encodeToolsCursor,
+// or 0 for an empty cursor
+func decodeToolsCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if value, ok := strings.CutPrefix(string(decoded), "tools:"); err == nil && ok {
+		if offset, err := strconv.Atoi(value); err == nil && offset >= 0 {
+			return offset, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor: %s", cursor)
+}
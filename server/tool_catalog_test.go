@@ -0,0 +1,96 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetTools_Metadata tests that every tool has a category and capabilities
+func TestGetTools_Metadata(t *testing.T) {
+	server := newMockServer(t)
+
+	tools := server.GetTools()
+	names := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		names[tool.Name] = true
+		assert.Contains(t, ToolCategories, tool.Category, "tool %s", tool.Name)
+		require.NotNil(t, tool.Capabilities, "tool %s", tool.Name)
+	}
+	for name := range toolCategories {
+		assert.True(t, names[name], "category assigned to unknown tool %s", name)
+	}
+	for name := range offlineTools {
+		assert.True(t, names[name], "unknown offline tool %s", name)
+	}
+
+	capabilities := make(map[string]*types.ToolCapabilities)
+	for _, tool := range tools {
+		capabilities[tool.Name] = tool.Capabilities
+	}
+	assert.Equal(t, &types.ToolCapabilities{RequiresCluster: true, RequiresJQ: true}, capabilities["execute_complete_audit_query"])
+	assert.Equal(t, &types.ToolCapabilities{RequiresCluster: true}, capabilities["find_permission_denials"])
+	assert.Equal(t, &types.ToolCapabilities{}, capabilities["get_cached_result"])
+
+	// In-process filtering needs no jq
+	server.inProcessFiltering = true
+	for _, tool := range server.GetTools() {
+		assert.False(t, tool.Capabilities.RequiresJQ, "tool %s", tool.Name)
+	}
+
+	counts := server.ToolCategoryCounts()
+	assert.Equal(t, 10, counts[ToolCategoryQuery])
+	assert.Equal(t, len(tools), counts[ToolCategoryQuery]+counts[ToolCategoryAnalytics]+counts[ToolCategoryDetection]+counts[ToolCategoryManagement])
+}
+
+// TestHandleListTools_FiltersAndPages tests filtering tools/list and following its cursors
+func TestHandleListTools_FiltersAndPages(t *testing.T) {
+	server := newMockServer(t)
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/list", JSONRPC: "2.0", Params: map[string]interface{}{"category": "detection"}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.NotContains(t, result, "nextCursor")
+	detection := result["tools"].([]types.MCPTool)
+	assert.NotEmpty(t, detection)
+	for _, tool := range detection {
+		assert.Equal(t, ToolCategoryDetection, tool.Category)
+	}
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "tools/list", JSONRPC: "2.0", Params: map[string]interface{}{"requires_cluster": false}})
+	require.Nil(t, response.Error)
+	for _, tool := range response.Result.(map[string]interface{})["tools"].([]types.MCPTool) {
+		assert.False(t, tool.Capabilities.RequiresCluster, "tool %s", tool.Name)
+	}
+
+	// Pages chain through their cursors
+	var listed []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		tools, next, err := server.listTools("", nil, cursor, 20)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(tools), 20)
+		for _, tool := range tools {
+			listed = append(listed, tool.Name)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	all := append(server.GetTools(), server.legacyTools()...)
+	require.Len(t, listed, len(all))
+	for i, tool := range all {
+		assert.Equal(t, tool.Name, listed[i])
+	}
+
+	for _, params := range []map[string]interface{}{{"category": "bogus"}, {"cursor": "not-a-cursor"}, {"cursor": encodeToolsCursor(1000)}} {
+		response = server.HandleMCPRequest(types.MCPRequest{ID: "3", Method: "tools/list", JSONRPC: "2.0", Params: params})
+		require.NotNil(t, response.Error, "params %v", params)
+		assert.Equal(t, types.ErrorTypeInvalidParams.Code(), response.Error.Code)
+	}
+}
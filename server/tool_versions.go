@@ -0,0 +1,109 @@
+package server
+
+import (
+	"fmt"
+
+	"audit-query-mcp-server/types"
+)
+
+// DefaultToolVersion is the version of tools whose interface has not changed
+// incompatibly since they were added
+const DefaultToolVersion = "1.0.0"
+
+// toolVersions are the versions of tools whose interface changed
+// incompatibly. The query tools return results without raw_output unless
+// called with include_raw_output since 2.0.0.
+var toolVersions = map[string]string{
+	"execute_audit_query_with_result": "2.0.0",
+	"execute_complete_audit_query":    "2.0.0",
+	"execute_audit_query_batch":       "2.0.0",
+	"ask_audit_question":              "2.0.0",
+}
+
+// toolAlias is a deprecated tool name still answered by its replacement
+type toolAlias struct {
+	replacedBy string
+	since      string
+}
+
+// toolAliases are the legacy tool names, superseded by the AuditResult-based
+// tools, that calls may still use unless AUDIT_HIDE_LEGACY_TOOLS is set
+var toolAliases = map[string]toolAlias{
+	"generate_audit_query": {replacedBy: "generate_audit_query_with_result", since: "1.0.0"},
+	"execute_audit_query":  {replacedBy: "execute_audit_query_with_result", since: "1.0.0"},
+	"parse_audit_results":  {replacedBy: "parse_audit_results_with_result", since: "1.0.0"},
+}
+
+// toolAliasNames lists toolAliases in the order tools/list returns them
+var toolAliasNames = []string{"generate_audit_query", "execute_audit_query", "parse_audit_results"}
+
+// deprecation describes a deprecated alias for tools/list and tool responses
+func (alias toolAlias) deprecation(name string) *types.ToolDeprecation {
+	return &types.ToolDeprecation{
+		Since:      alias.since,
+		ReplacedBy: alias.replacedBy,
+		Message:    fmt.Sprintf("%s is deprecated since %s and will be removed; call %s instead", name, alias.since, alias.replacedBy),
+	}
+}
+
+// toolVersion returns the version of a tool
+func toolVersion(name string) string {
+	if version, ok := toolVersions[name]; ok {
+		return version
+	}
+	return DefaultToolVersion
+}
+
+// legacyTools returns the deprecated aliases as tools/list entries, which
+// share their replacement's schema, category and capabilities, or none when
+// AUDIT_HIDE_LEGACY_TOOLS is set
+func (s *AuditQueryMCPServer) legacyTools() []types.MCPTool {
+	if s.hideLegacyTools {
+		return nil
+	}
+	replacements := make(map[string]types.MCPTool)
+	for _, tool := range s.GetTools() {
+		replacements[tool.Name] = tool
+	}
+
+	var tools []types.MCPTool
+	for _, name := range toolAliasNames {
+		alias := toolAliases[name]
+		replacement, ok := replacements[alias.replacedBy]
+		if !ok {
+			continue
+		}
+		legacy := replacement
+		legacy.Name = name
+		legacy.Description = fmt.Sprintf("Deprecated: use %s. %s", alias.replacedBy, replacement.Description)
+		legacy.Deprecated = alias.deprecation(name)
+		tools = append(tools, legacy)
+	}
+	return tools
+}
+
+// resolveToolAlias returns the tool a call to name runs and the alias used, if
+// any. Aliases are unknown when AUDIT_HIDE_LEGACY_TOOLS is set.
+func (s *AuditQueryMCPServer) resolveToolAlias(name string) (string, *toolAlias) {
+	alias, ok := toolAliases[name]
+	if !ok || s.hideLegacyTools {
+		return name, nil
+	}
+	return alias.replacedBy, &alias
+}
+
+// withDeprecation returns a tool response reporting that it answers a call to
+// the deprecated alias name
+func withDeprecation(response types.MCPResponse, name string, alias toolAlias) types.MCPResponse {
+	result, ok := response.Result.(map[string]interface{})
+	if !ok {
+		return response
+	}
+	copied := make(map[string]interface{}, len(result)+1)
+	for key, value := range result {
+		copied[key] = value
+	}
+	copied["deprecation"] = alias.deprecation(name)
+	response.Result = copied
+	return response
+}
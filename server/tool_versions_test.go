@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetTools_Versions tests that tools carry versions and aliases name existing tools
+func TestGetTools_Versions(t *testing.T) {
+	server := newMockServer(t)
+
+	versions := make(map[string]string)
+	for _, tool := range server.GetTools() {
+		versions[tool.Name] = tool.Version
+		assert.Nil(t, tool.Deprecated, "tool %s", tool.Name)
+	}
+	assert.Equal(t, "2.0.0", versions["execute_complete_audit_query"])
+	assert.Equal(t, DefaultToolVersion, versions["get_cache_stats"])
+	for name := range toolVersions {
+		assert.Contains(t, versions, name)
+	}
+	require.Len(t, toolAliasNames, len(toolAliases))
+	for _, name := range toolAliasNames {
+		assert.Contains(t, versions, toolAliases[name].replacedBy)
+		assert.NotContains(t, versions, name)
+	}
+}
+
+// TestHandleToolCall_DeprecatedAlias tests that legacy tool names are listed and answered with a deprecation warning
+func TestHandleToolCall_DeprecatedAlias(t *testing.T) {
+	server := newMockServer(t)
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/list", JSONRPC: "2.0", Params: map[string]interface{}{}})
+	require.Nil(t, response.Error)
+	listed := make(map[string]types.MCPTool)
+	for _, tool := range response.Result.(map[string]interface{})["tools"].([]types.MCPTool) {
+		listed[tool.Name] = tool
+	}
+	legacy, ok := listed["generate_audit_query"]
+	require.True(t, ok)
+	require.NotNil(t, legacy.Deprecated)
+	assert.Equal(t, "generate_audit_query_with_result", legacy.Deprecated.ReplacedBy)
+	assert.Equal(t, listed["generate_audit_query_with_result"].InputSchema, legacy.InputSchema)
+	assert.Equal(t, ToolCategoryQuery, legacy.Category)
+
+	arguments := map[string]interface{}{"structured_params": map[string]interface{}{"log_source": "kube-apiserver", "timeframe": "today", "verb": "delete"}}
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "generate_audit_query", "arguments": arguments,
+	}})
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.NotNil(t, result["audit_result"])
+	deprecation := result["deprecation"].(*types.ToolDeprecation)
+	assert.Equal(t, "generate_audit_query_with_result", deprecation.ReplacedBy)
+	assert.Contains(t, deprecation.Message, "generate_audit_query is deprecated")
+
+	// Aliases are validated against their replacement's schema
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "3", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "generate_audit_query", "arguments": map[string]interface{}{"structured_param": map[string]interface{}{}},
+	}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.ErrorTypeInvalidParams.Code(), response.Error.Code)
+}
+
+// TestHandleToolCall_HiddenLegacyTools tests that AUDIT_HIDE_LEGACY_TOOLS removes the aliases
+func TestHandleToolCall_HiddenLegacyTools(t *testing.T) {
+	t.Setenv("AUDIT_HIDE_LEGACY_TOOLS", "true")
+	server := newMockServer(t)
+
+	response := server.HandleMCPRequest(types.MCPRequest{ID: "1", Method: "tools/list", JSONRPC: "2.0", Params: map[string]interface{}{}})
+	require.Nil(t, response.Error)
+	for _, tool := range response.Result.(map[string]interface{})["tools"].([]types.MCPTool) {
+		assert.Nil(t, tool.Deprecated, "tool %s", tool.Name)
+	}
+
+	response = server.HandleMCPRequest(types.MCPRequest{ID: "2", Method: "tools/call", JSONRPC: "2.0", Params: map[string]interface{}{
+		"name": "execute_audit_query", "arguments": map[string]interface{}{"command": "oc whoami", "query_id": "q1"},
+	}})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32601, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "execute_audit_query_with_result")
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindTopTalkers tests ranking users and suggesting exclusions for controllers
+func TestFindTopTalkers(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	var lines []string
+	event := func(i int, username, userAgent string) {
+		lines = append(lines, fmt.Sprintf(`{"kind":"Event","level":"Metadata","stage":"ResponseComplete","auditID":"talk-%d","requestReceivedTimestamp":%q,"verb":"watch","user":{"username":%q},"userAgent":%q,"objectRef":{"resource":"pods","namespace":"default"},"responseStatus":{"code":200}}`,
+			i, now.Add(-time.Duration(i)*time.Minute).UTC().Format(time.RFC3339Nano), username, userAgent))
+	}
+	for i := 0; i < 12; i++ {
+		event(i, "system:kube-controller-manager", "kube-controller-manager/v1.28.3 (linux/amd64) kubernetes/a1b2c3d")
+	}
+	for i := 12; i < 15; i++ {
+		event(i, "alice", "oc/4.14.0 (linux/amd64) kubernetes/a1b2c3d")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleFindTopTalkers("test-id", map[string]interface{}{"min_share": 1.5})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, -32602, response.Error.Code)
+
+	response = server.handleFindTopTalkers("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+		"min_share":         0.5,
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.TopTalkersReport)
+	require.Len(t, report.Users, 2)
+	assert.Equal(t, "system:kube-controller-manager", report.Users[0].Name)
+	assert.True(t, report.Users[0].Suggested)
+	assert.False(t, report.Users[1].Suggested)
+	assert.Equal(t, []string{"system:kube-controller-manager"}, report.SuggestedExclusions.ExcludeUsers)
+	assert.Empty(t, report.SuggestedExclusions.Exclude)
+	assert.Equal(t, 12, report.SuggestedExclusions.DroppedEvents)
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"audit-query-mcp-server/detection"
+	"audit-query-mcp-server/parsing"
+	"audit-query-mcp-server/types"
+)
+
+// Watch rule defaults
+const (
+	// DefaultWatchInterval is how often watch rules are evaluated
+	DefaultWatchInterval = time.Minute
+	// maxRecentAlerts bounds the alerts kept for the alerts resource
+	maxRecentAlerts = 100
+)
+
+// alertsResourceURI lists the recent alerts of the watch rules
+const alertsResourceURI = resourceScheme + "alerts"
+
+// watchState is a watch rule's progress through the audit log: the
+// high-water mark of the events it evaluated and its events still in the window
+type watchState struct {
+	// highWater is the latest receive time of an evaluated event and
+	// atHighWater the audit IDs of the events received at that time
+	highWater   time.Time
+	atHighWater map[string]bool
+	// entries are the parsed events within the rule's window, and queryID
+	// the cached result of the latest new events
+	entries []map[string]interface{}
+	queryID string
+}
+
+// EvaluateWatchRules fetches the events of every watch rule's query newer
+// than its high-water mark and raises the alerts of events within each rule's
+// window at now. Older events are not parsed again; the rule keeps those
+// within its window. An alert for the same rule and group is raised at most
+// once per window. Rules whose query fails are logged and skipped. New alerts
+// are returned.
+func (s *AuditQueryMCPServer) EvaluateWatchRules(now time.Time) []types.Alert {
+	s.watchMutex.Lock()
+	defer s.watchMutex.Unlock()
+
+	var raised []types.Alert
+	for _, rule := range s.watchRules {
+		state, err := s.advanceWatchRule(rule, now)
+		if err != nil {
+			s.logger.Warnf("Watch rule %s failed: %v", rule.Name, err)
+			continue
+		}
+		for _, alert := range detection.Evaluate(rule, state.entries, now) {
+			if !s.recordAlert(rule, &alert, now) {
+				continue
+			}
+			alert.QueryID = state.queryID
+			alert.Cluster = s.cluster
+			s.deliverAlert(rule, alert)
+			raised = append(raised, alert)
+		}
+	}
+	return raised
+}
+
+// advanceWatchRule parses the events of a rule's query received after its
+// high-water mark and no later than now, and drops the events that left the
+// window ending at now. The first evaluation starts at the window's start.
+// New events are cached as one result.
+func (s *AuditQueryMCPServer) advanceWatchRule(rule detection.Rule, now time.Time) (*watchState, error) {
+	state, ok := s.watchStates[rule.Name]
+	if !ok {
+		state = &watchState{}
+		s.watchStates[rule.Name] = state
+	}
+	lines, command, err := s.fetchAuditLines(rule.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	windowStart := now.Add(-rule.Window)
+	after := windowStart
+	if !state.highWater.Before(windowStart) {
+		// Events received at the mark may have been logged after the last fetch
+		after = state.highWater.Add(-time.Nanosecond)
+	}
+	var fresh []string
+	highWater := state.highWater
+	atHighWater := make(map[string]bool, len(state.atHighWater))
+	for auditID := range state.atHighWater {
+		atHighWater[auditID] = true
+	}
+	for _, line := range parsing.FilterLinesByTime(lines, after, now) {
+		timestamp, _ := parsing.LineTimestamp(line)
+		auditID := lineAuditID(line)
+		switch {
+		case timestamp.Equal(state.highWater) && state.atHighWater[auditID]:
+			continue
+		case timestamp.After(highWater):
+			highWater, atHighWater = timestamp, map[string]bool{auditID: true}
+		case timestamp.Equal(highWater):
+			atHighWater[auditID] = true
+		}
+		fresh = append(fresh, line)
+	}
+
+	if len(fresh) > 0 {
+		queryID := s.generateQueryID()
+		result, err := s.ParseAuditResultsWithResult(strings.Join(fresh, "\n"), queryContextFor(rule.Params), queryID)
+		if err != nil {
+			return nil, err
+		}
+		result.Command = command
+		s.cache.Set(queryID, result)
+		state.entries = append(state.entries, result.ParsedData...)
+		state.queryID = queryID
+		state.highWater, state.atHighWater = highWater, atHighWater
+	}
+
+	kept := state.entries[:0]
+	for _, entry := range state.entries {
+		value, _ := entry["timestamp"].(string)
+		if timestamp, err := time.Parse(time.RFC3339Nano, value); err == nil && timestamp.After(windowStart) {
+			kept = append(kept, entry)
+		}
+	}
+	state.entries = kept
+	return state, nil
+}
+
+// lineAuditID returns the audit ID of an audit record, or "" when it has none
+func lineAuditID(line string) string {
+	var record struct {
+		AuditID string `json:"auditID"`
+	}
+	json.Unmarshal([]byte(line), &record)
+	return record.AuditID
+}
+
+// recordAlert assigns the alert an ID and keeps it with the recent alerts,
+// unless the same rule and group raised an alert within the rule's window
+func (s *AuditQueryMCPServer) recordAlert(rule detection.Rule, alert *types.Alert, now time.Time) bool {
+	key := rule.Name + "\x00" + alert.Group
+	s.alertsMutex.Lock()
+	defer s.alertsMutex.Unlock()
+	if last, ok := s.lastAlerts[key]; ok && now.Sub(last) < rule.Window {
+		return false
+	}
+	s.lastAlerts[key] = now
+	s.alertSequence++
+	alert.ID = fmt.Sprintf("alert-%d", s.alertSequence)
+	s.alerts = append(s.alerts, *alert)
+	if len(s.alerts) > maxRecentAlerts {
+		s.alerts = s.alerts[len(s.alerts)-maxRecentAlerts:]
+	}
+	return true
+}
+
+// deliverAlert logs an alert, forwards it to the rule's destinations and
+// notifies clients subscribed to the alerts resource
+func (s *AuditQueryMCPServer) deliverAlert(rule detection.Rule, alert types.Alert) {
+	if alert.Group != "" {
+		s.logger.Warnf("Watch rule %s (%s): %d events for %s %s within %s", alert.Rule, alert.Severity, alert.Count, alert.GroupBy, alert.Group, alert.Window)
+	} else {
+		s.logger.Warnf("Watch rule %s (%s): %d events within %s", alert.Rule, alert.Severity, alert.Count, alert.Window)
+	}
+
+	if len(rule.Notify) > 0 {
+		event, err := alertEvent(alert)
+		if err != nil {
+			s.logger.Warnf("Failed to encode alert %s: %v", alert.ID, err)
+		} else {
+			for _, destination := range rule.Notify {
+				result, err := s.forwarder.Forward(context.Background(), destination, []map[string]interface{}{event})
+				if err != nil {
+					s.logger.Warnf("Failed to forward alert %s to %s: %v", alert.ID, destination, err)
+				} else if result.Failed > 0 {
+					s.logger.Warnf("Failed to forward alert %s to %s: %v", alert.ID, destination, result.Errors)
+				}
+			}
+		}
+	}
+
+	s.subscriptionsMutex.Lock()
+	subscribed := s.subscriptions[alertsResourceURI]
+	s.subscriptionsMutex.Unlock()
+	if subscribed {
+		s.notify("notifications/resources/updated", map[string]interface{}{"uri": alertsResourceURI})
+	}
+}
+
+// alertEvent returns an alert as a forwarding event, timestamped when it was raised
+func alertEvent(alert types.Alert) (map[string]interface{}, error) {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return nil, err
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	event["timestamp"] = alert.RaisedAt
+	event["event_type"] = "audit_alert"
+	return event, nil
+}
+
+// RecentAlerts returns the most recent alerts raised by watch rules, newest first
+func (s *AuditQueryMCPServer) RecentAlerts() []types.Alert {
+	s.alertsMutex.Lock()
+	defer s.alertsMutex.Unlock()
+	alerts := make([]types.Alert, 0, len(s.alerts))
+	for i := len(s.alerts) - 1; i >= 0; i-- {
+		alerts = append(alerts, s.alerts[i])
+	}
+	return alerts
+}
+
+// WatchRuleNames returns the names of the loaded watch rules
+func (s *AuditQueryMCPServer) WatchRuleNames() []string {
+	names := make([]string, 0, len(s.watchRules))
+	for _, rule := range s.watchRules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+// StartWatching evaluates the watch rules every watch interval until ctx is
+// done; it returns immediately when no rules are loaded
+func (s *AuditQueryMCPServer) StartWatching(ctx context.Context) {
+	if len(s.watchRules) == 0 {
+		return
+	}
+	s.logger.Infof("Evaluating %d watch rules every %s", len(s.watchRules), s.watchInterval)
+	go func() {
+		ticker := time.NewTicker(s.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.EvaluateWatchRules(now)
+			}
+		}
+	}()
+}
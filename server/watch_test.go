@@ -0,0 +1,164 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEvaluateWatchRules tests raising, suppressing, forwarding and listing watch rule alerts
+func TestEvaluateWatchRules(t *testing.T) {
+	var mutex sync.Mutex
+	var forwarded []string
+	siem := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		forwarded = append(forwarded, string(body))
+		mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer siem.Close()
+
+	dir := t.TempDir()
+	forwardConfig := filepath.Join(dir, "forwarding.json")
+	require.NoError(t, os.WriteFile(forwardConfig, []byte(`{"destinations": [
+		{"name": "siem", "type": "splunk_hec", "url": "`+siem.URL+`/services/collector/event", "token": "test", "max_retries": 0}]}`), 0644))
+	rules := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, os.WriteFile(rules, []byte(`rules:
+  - name: bob-denials
+    description: Repeated denied requests by bob
+    severity: high
+    filters:
+      username: bob
+      status_code_range: 4xx
+    threshold: 2
+    window: 2h
+    group_by: username
+    notify: [siem]
+  - name: noisy-deletions
+    filters:
+      verb: delete
+    threshold: 1000
+`), 0644))
+	t.Setenv("AUDIT_FORWARD_CONFIG", forwardConfig)
+	t.Setenv("AUDIT_WATCH_RULES", rules)
+	server := newMockServer(t)
+	assert.Equal(t, []string{"bob-denials", "noisy-deletions"}, server.WatchRuleNames())
+
+	var notifications []types.MCPNotification
+	server.SetNotifier(func(notification types.MCPNotification) {
+		notifications = append(notifications, notification)
+	})
+	require.NoError(t, server.SubscribeResource("auditquery://alerts"))
+
+	now := time.Now()
+	alerts := server.EvaluateWatchRules(now)
+	require.Len(t, alerts, 1)
+	alert := alerts[0]
+	assert.Equal(t, "alert-1", alert.ID)
+	assert.Equal(t, "bob-denials", alert.Rule)
+	assert.Equal(t, "high", alert.Severity)
+	assert.Equal(t, "username", alert.GroupBy)
+	assert.Equal(t, "bob", alert.Group)
+	assert.GreaterOrEqual(t, alert.Count, 2)
+	assert.NotEmpty(t, alert.QueryID)
+	assert.Len(t, alert.Events, alert.Count)
+
+	mutex.Lock()
+	require.Len(t, forwarded, 1)
+	assert.Contains(t, forwarded[0], `"rule":"bob-denials"`)
+	mutex.Unlock()
+	var updated bool
+	for _, notification := range notifications {
+		if notification.Method == "notifications/resources/updated" && notification.Params["uri"] == "auditquery://alerts" {
+			updated = true
+		}
+	}
+	assert.True(t, updated)
+
+	// The same rule and group raise no new alert within the window
+	assert.Empty(t, server.EvaluateWatchRules(now.Add(time.Minute)))
+	assert.Len(t, server.RecentAlerts(), 1)
+
+	contents, err := server.ReadResource("auditquery://alerts")
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(contents.Text, `"id": "alert-1"`))
+	assert.Equal(t, []string{"bob-denials", "noisy-deletions"}, server.Configuration()["watch_rules"])
+}
+
+// TestEvaluateWatchRules_HighWaterMark tests that each evaluation parses only
+// the events after the rule's high-water mark and keeps those within the window
+func TestEvaluateWatchRules_HighWaterMark(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 1, 0, 0, 0, now.Location())
+	denial := func(auditID string, received time.Time) string {
+		return `{"auditID":"` + auditID + `","verb":"get","requestURI":"/api/v1/pods","user":{"username":"bob"},"objectRef":{"resource":"pods"},"responseStatus":{"code":403},"requestReceivedTimestamp":"` + received.Format(time.RFC3339Nano) + `"}` + "\n"
+	}
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "kube-apiserver.log")
+	require.NoError(t, os.WriteFile(logFile, []byte(denial("a1", start.Add(time.Minute))+denial("a2", start.Add(2*time.Minute))), 0644))
+	rules := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, os.WriteFile(rules, []byte(`rules:
+  - name: bob-denials
+    filters:
+      username: bob
+      status_code_range: 4xx
+    threshold: 3
+    window: 1h
+`), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	t.Setenv("AUDIT_WATCH_RULES", rules)
+	server := newMockServer(t)
+
+	assert.Empty(t, server.EvaluateWatchRules(start.Add(3*time.Minute)))
+	state := server.watchStates["bob-denials"]
+	require.NotNil(t, state)
+	assert.Len(t, state.entries, 2)
+	assert.True(t, state.highWater.Equal(start.Add(2*time.Minute)))
+
+	// An event logged late at the high-water mark is new; the others are not parsed again
+	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(denial("a3", start.Add(2*time.Minute)))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	alerts := server.EvaluateWatchRules(start.Add(4 * time.Minute))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 3, alerts[0].Count)
+	assert.Len(t, state.entries, 3)
+	cached, found := server.cache.Get(alerts[0].QueryID)
+	require.True(t, found)
+	require.Len(t, cached.ParsedData, 1)
+	assert.Equal(t, "a3", cached.ParsedData[0]["audit_id"])
+
+	assert.Empty(t, server.EvaluateWatchRules(start.Add(5*time.Minute)))
+	assert.Len(t, state.entries, 3)
+
+	// Events leave the window
+	assert.Empty(t, server.EvaluateWatchRules(start.Add(2*time.Hour)))
+	assert.Empty(t, state.entries)
+}
+
+// TestWatchRules_InvalidConfig tests that invalid watch rules leave watching disabled
+func TestWatchRules_InvalidConfig(t *testing.T) {
+	rules := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rules, []byte("rules:\n  - name: bad\n    severity: urgent\n"), 0644))
+	t.Setenv("AUDIT_WATCH_RULES", rules)
+	t.Setenv("AUDIT_WATCH_INTERVAL", "soon")
+	server := newMockServer(t)
+	assert.Empty(t, server.WatchRuleNames())
+	assert.Equal(t, DefaultWatchInterval, server.watchInterval)
+	assert.Empty(t, server.EvaluateWatchRules(time.Now()))
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditWebhookChanges tests diffing webhook configurations from response bodies
+func TestAuditWebhookChanges(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	event := func(minutesAgo int, verb, failurePolicy string) string {
+		return fmt.Sprintf(`{"kind":"Event","level":"RequestResponse","stage":"ResponseComplete","auditID":"hook-%d","requestReceivedTimestamp":%q,"verb":%q,"user":{"username":"mallory"},"objectRef":{"resource":"validatingwebhookconfigurations","name":"policy","apiGroup":"admissionregistration.k8s.io"},"responseStatus":{"code":200},"responseObject":{"webhooks":[{"name":"policy.example.com","failurePolicy":%q}]}}`,
+			minutesAgo, now.Add(-time.Duration(minutesAgo)*time.Minute).UTC().Format(time.RFC3339Nano), verb, failurePolicy)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kube-apiserver.log"), []byte(strings.Join([]string{
+		event(30, "create", "Fail"),
+		event(20, "update", "Ignore"),
+	}, "\n")+"\n"), 0644))
+	t.Setenv("AUDIT_MOCK_DATA_DIR", dir)
+	server := newMockServer(t)
+
+	response := server.handleAuditWebhookChanges("test-id", map[string]interface{}{
+		"structured_params": map[string]interface{}{"timeframe": "2h"},
+	})
+	require.Nil(t, response.Error, "%+v", response.Error)
+	report := response.Result.(map[string]interface{})["report"].(*types.WebhookChangeReport)
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, []string{"policy.example.com"}, report.Changes[0].Webhooks)
+	assert.Equal(t, []types.FieldChange{{Path: "webhooks[0].failurePolicy", Old: "Fail", New: "Ignore"}}, report.Changes[1].Diff)
+	assert.Equal(t, report.Summary, response.Result.(map[string]interface{})["audit_result"].(*types.AuditResult).Summary)
+}
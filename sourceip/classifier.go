@@ -0,0 +1,155 @@
+package sourceip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// Source IP categories, from most to least trusted
+const (
+	CategoryInternal       = "internal"
+	CategoryClusterNode    = "cluster-node"
+	CategoryClusterPod     = "cluster-pod"
+	CategoryClusterService = "cluster-service"
+	CategoryPrivate        = "private"
+	CategoryExternal       = "external"
+)
+
+// Hook adds what it knows about a source IP to its classification. Hooks run
+// in order; a hook sets the category only when no earlier hook has.
+type Hook interface {
+	Name() string
+	Classify(ip net.IP, info *types.SourceIPInfo)
+}
+
+// Classifier classifies source IPs with a chain of hooks. IPs no hook
+// categorizes are private when in a private, loopback or link-local range and
+// external otherwise.
+type Classifier struct {
+	hooks []Hook
+}
+
+// NewClassifier creates a classifier running hooks in order
+func NewClassifier(hooks ...Hook) *Classifier {
+	return &Classifier{hooks: hooks}
+}
+
+// Hooks returns the names of the classifier's hooks
+func (c *Classifier) Hooks() []string {
+	names := make([]string, len(c.hooks))
+	for i, hook := range c.hooks {
+		names[i] = hook.Name()
+	}
+	return names
+}
+
+// Classify classifies one source IP; a value that is not an IP address gets
+// no category
+func (c *Classifier) Classify(value string) types.SourceIPInfo {
+	info := types.SourceIPInfo{IP: value}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return info
+	}
+	for _, hook := range c.hooks {
+		hook.Classify(ip, &info)
+	}
+	if info.Category == "" {
+		if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			info.Category = CategoryPrivate
+		} else {
+			info.Category = CategoryExternal
+		}
+	}
+	info.External = info.Category == CategoryExternal
+	return info
+}
+
+// Network is a labeled network
+type Network struct {
+	Label string
+	Net   *net.IPNet
+}
+
+// ParseNetworks reads a comma-separated list of networks, each a CIDR or a
+// single IP address optionally labeled as "label=cidr", such as
+// "corp=10.0.0.0/8,vpn=172.20.0.0/16,192.168.1.10"
+func ParseNetworks(list string) ([]Network, error) {
+	var networks []Network
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		label, cidr := "", item
+		if index := strings.Index(item, "="); index >= 0 {
+			label, cidr = strings.TrimSpace(item[:index]), strings.TrimSpace(item[index+1:])
+		}
+		network, err := parseNetwork(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, Network{Label: label, Net: network})
+	}
+	return networks, nil
+}
+
+// parseNetwork reads a CIDR, or a single IP address as a host network
+func parseNetwork(value string) (*net.IPNet, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network: %s", value)
+	}
+	return network, nil
+}
+
+// NetworkHook assigns its category to IPs within its networks, reporting the
+// most specific matching network
+type NetworkHook struct {
+	name     string
+	category string
+	networks []Network
+}
+
+// NewNetworkHook creates a hook assigning category to IPs within networks
+func NewNetworkHook(name, category string, networks []Network) *NetworkHook {
+	return &NetworkHook{name: name, category: category, networks: networks}
+}
+
+// Name returns the hook's name
+func (h *NetworkHook) Name() string {
+	return h.name
+}
+
+// Classify sets the category and network of an IP within the hook's networks
+func (h *NetworkHook) Classify(ip net.IP, info *types.SourceIPInfo) {
+	if info.Category != "" {
+		return
+	}
+	var best *Network
+	bestBits := -1
+	for i, network := range h.networks {
+		if !network.Net.Contains(ip) {
+			continue
+		}
+		if bits, _ := network.Net.Mask.Size(); bits > bestBits {
+			best, bestBits = &h.networks[i], bits
+		}
+	}
+	if best == nil {
+		return
+	}
+	info.Category = h.category
+	info.Network = best.Net.String()
+	info.Label = best.Label
+}
@@ -0,0 +1,85 @@
+package sourceip
+
+import (
+	"net"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestParseNetworks tests reading labeled network lists
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("corp=10.0.0.0/8, vpn = 172.20.0.0/16,192.168.1.10,,2001:db8::/32")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []struct{ label, network string }{
+		{"corp", "10.0.0.0/8"}, {"vpn", "172.20.0.0/16"}, {"", "192.168.1.10/32"}, {"", "2001:db8::/32"},
+	}
+	if len(networks) != len(expected) {
+		t.Fatalf("Unexpected networks %v", networks)
+	}
+	for i, network := range networks {
+		if network.Label != expected[i].label || network.Net.String() != expected[i].network {
+			t.Errorf("Unexpected network %s=%s, expected %s=%s", network.Label, network.Net, expected[i].label, expected[i].network)
+		}
+	}
+
+	if _, err := ParseNetworks("corp=10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for an invalid network")
+	}
+}
+
+// TestClassifier tests classifying source IPs with network hooks
+func TestClassifier(t *testing.T) {
+	internal, _ := ParseNetworks("corp=10.0.0.0/8,office=10.20.0.0/16")
+	pods, _ := ParseNetworks("10.128.0.0/14")
+	classifier := NewClassifier(
+		NewNetworkHook("internal-networks", CategoryInternal, internal),
+		NewNetworkHook("cluster-pod-network", CategoryClusterPod, pods),
+	)
+
+	tests := []struct {
+		ip       string
+		expected types.SourceIPInfo
+	}{
+		{"10.20.1.5", types.SourceIPInfo{IP: "10.20.1.5", Category: CategoryInternal, Network: "10.20.0.0/16", Label: "office"}},
+		{"10.1.1.1", types.SourceIPInfo{IP: "10.1.1.1", Category: CategoryInternal, Network: "10.0.0.0/8", Label: "corp"}},
+		// Earlier hooks win: the pod network lies within the internal network
+		{"10.128.0.30", types.SourceIPInfo{IP: "10.128.0.30", Category: CategoryInternal, Network: "10.0.0.0/8", Label: "corp"}},
+		{"192.168.0.7", types.SourceIPInfo{IP: "192.168.0.7", Category: CategoryPrivate}},
+		{"::1", types.SourceIPInfo{IP: "::1", Category: CategoryPrivate}},
+		{"203.0.113.10", types.SourceIPInfo{IP: "203.0.113.10", Category: CategoryExternal, External: true}},
+		{"not-an-ip", types.SourceIPInfo{IP: "not-an-ip"}},
+	}
+	for _, test := range tests {
+		if info := classifier.Classify(test.ip); info != test.expected {
+			t.Errorf("Unexpected classification of %s: %+v", test.ip, info)
+		}
+	}
+
+	if hooks := classifier.Hooks(); len(hooks) != 2 || hooks[1] != "cluster-pod-network" {
+		t.Errorf("Unexpected hooks %v", hooks)
+	}
+}
+
+// hookFunc adapts a function to the Hook interface
+type hookFunc func(ip net.IP, info *types.SourceIPInfo)
+
+func (f hookFunc) Name() string                                 { return "custom" }
+func (f hookFunc) Classify(ip net.IP, info *types.SourceIPInfo) { f(ip, info) }
+
+// TestClassifier_CustomHook tests plugging in a hook that is not a network list
+func TestClassifier_CustomHook(t *testing.T) {
+	classifier := NewClassifier(hookFunc(func(ip net.IP, info *types.SourceIPInfo) {
+		if ip.Equal(net.ParseIP("198.51.100.1")) {
+			info.Category, info.Label = CategoryInternal, "bastion"
+		}
+	}))
+	if info := classifier.Classify("198.51.100.1"); info.Category != CategoryInternal || info.Label != "bastion" || info.External {
+		t.Errorf("Unexpected classification %+v", info)
+	}
+	if info := classifier.Classify("198.51.100.2"); !info.External {
+		t.Errorf("Expected an external IP, got %+v", info)
+	}
+}
@@ -0,0 +1,116 @@
+package sourceip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// geoRange is a network of a GeoIP database as an address range
+type geoRange struct {
+	first, last  net.IP
+	country      string
+	organization string
+}
+
+// GeoDatabase is an offline GeoIP database read from a CSV file. It only adds
+// the country and organization of an IP and never changes its category.
+type GeoDatabase struct {
+	ranges []geoRange
+}
+
+// LoadGeoDatabase reads a GeoIP database from a CSV file whose rows are
+// "network,country[,organization]", such as "203.0.113.0/24,NL,Example ISP".
+// Lines starting with # and a header row starting with "network" are skipped.
+// Networks must not overlap.
+func LoadGeoDatabase(path string) (*GeoDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+	}
+	defer file.Close()
+	return ReadGeoDatabase(file)
+}
+
+// ReadGeoDatabase reads a GeoIP database in the CSV format of LoadGeoDatabase
+func ReadGeoDatabase(r io.Reader) (*GeoDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	database := &GeoDatabase{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid GeoIP database: %w", err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("invalid GeoIP database row %d: expected network and country", line)
+		}
+		if strings.EqualFold(record[0], "network") {
+			continue
+		}
+		network, err := parseNetwork(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GeoIP database row %d: %w", line, err)
+		}
+		entry := geoRange{first: network.IP.To16(), last: lastAddress(network), country: record[1]}
+		if len(record) > 2 {
+			entry.organization = record[2]
+		}
+		database.ranges = append(database.ranges, entry)
+	}
+	sort.Slice(database.ranges, func(i, j int) bool {
+		return bytes.Compare(database.ranges[i].first, database.ranges[j].first) < 0
+	})
+	return database, nil
+}
+
+// lastAddress returns the last address of a network in 16-byte form
+func lastAddress(network *net.IPNet) net.IP {
+	ip := network.IP.To16()
+	mask := network.Mask
+	if len(mask) == net.IPv4len {
+		mask = append(net.CIDRMask(96, 128)[:12], mask...)
+	}
+	last := make(net.IP, net.IPv6len)
+	for i := range last {
+		last[i] = ip[i] | ^mask[i]
+	}
+	return last
+}
+
+// Len returns the number of networks in the database
+func (d *GeoDatabase) Len() int {
+	return len(d.ranges)
+}
+
+// Name returns the hook's name
+func (d *GeoDatabase) Name() string {
+	return "geoip"
+}
+
+// Classify sets the country and organization of an IP found in the database
+func (d *GeoDatabase) Classify(ip net.IP, info *types.SourceIPInfo) {
+	ip = ip.To16()
+	// The last network starting at or before the IP is the only candidate
+	index := sort.Search(len(d.ranges), func(i int) bool {
+		return bytes.Compare(d.ranges[i].first, ip) > 0
+	}) - 1
+	if index < 0 || bytes.Compare(ip, d.ranges[index].last) > 0 {
+		return
+	}
+	info.Country = d.ranges[index].country
+	info.Organization = d.ranges[index].organization
+}
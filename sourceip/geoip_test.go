@@ -0,0 +1,64 @@
+package sourceip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestReadGeoDatabase tests locating IPs with a CSV GeoIP database
+func TestReadGeoDatabase(t *testing.T) {
+	database, err := ReadGeoDatabase(strings.NewReader(`network,country,organization
+# Documentation ranges
+203.0.113.0/24,NL,Example ISP
+198.51.100.0/25,DE
+2001:db8::/32,US,Example IPv6
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if database.Len() != 3 {
+		t.Errorf("Expected 3 networks, got %d", database.Len())
+	}
+
+	tests := map[string][2]string{
+		"203.0.113.10":   {"NL", "Example ISP"},
+		"203.0.113.255":  {"NL", "Example ISP"},
+		"198.51.100.1":   {"DE", ""},
+		"198.51.100.200": {"", ""},
+		"2001:db8::1":    {"US", "Example IPv6"},
+		"10.0.0.1":       {"", ""},
+	}
+	for ip, expected := range tests {
+		info := types.SourceIPInfo{Category: CategoryExternal}
+		database.Classify(net.ParseIP(ip), &info)
+		if info.Country != expected[0] || info.Organization != expected[1] || info.Category != CategoryExternal {
+			t.Errorf("Unexpected location of %s: %+v", ip, info)
+		}
+	}
+}
+
+// TestLoadGeoDatabase tests reading a GeoIP database file and rejecting invalid rows
+func TestLoadGeoDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geoip.csv")
+	if err := os.WriteFile(path, []byte("203.0.113.0/24,NL\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if database, err := LoadGeoDatabase(path); err != nil || database.Len() != 1 {
+		t.Errorf("Unexpected database %v, %v", database, err)
+	}
+
+	if _, err := LoadGeoDatabase(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if _, err := ReadGeoDatabase(strings.NewReader("203.0.113.0/24\n")); err == nil {
+		t.Error("Expected an error for a row without a country")
+	}
+	if _, err := ReadGeoDatabase(strings.NewReader("203.0.113.0/33,NL\n")); err == nil {
+		t.Error("Expected an error for an invalid network")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain writes the audit trails, slow-query logs and findings of the scenarios and the servers the tests
+// create to a temporary directory rather than ./logs
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "audit-query-logs")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("AUDIT_TRAIL_FILE", filepath.Join(dir, "audit_trail.json"))
+	os.Setenv("AUDIT_SLOW_QUERY_LOG", filepath.Join(dir, "slow_queries.json"))
+	os.Setenv("AUDIT_FINDINGS_FILE", filepath.Join(dir, "findings.json"))
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// TestScenarios tests every scenario of the test subcommand, so that go test
+// fails when a scenario records a failure
+func TestScenarios(t *testing.T) {
+	t.Setenv("AUDIT_PROVIDER", "")
+	for _, name := range scenarioNames() {
+		t.Run(name, func(t *testing.T) {
+			run := runScenario(name)
+			if run.skipped != "" {
+				t.Skip(run.skipped)
+			}
+			for _, failure := range run.failures {
+				t.Error(failure)
+			}
+		})
+	}
+}
+
+// TestRunTests tests the exit status of the test subcommand
+func TestRunTests(t *testing.T) {
+	if !runTests(&TestConfig{TestNames: []string{"validation"}, Compact: true}) {
+		t.Error("Expected the validation test to pass")
+	}
+	if runTests(&TestConfig{TestNames: []string{"missing"}, Compact: true}) {
+		t.Error("Expected an unknown test to fail the run")
+	}
+	if runTests(&TestConfig{TestNames: []string{"mcp-protocol"}, SkipSlow: true}) {
+		t.Error("Expected a run without tests to fail")
+	}
+}
+
+// TestWriteJUnitReport tests the JUnit XML report of a test run
+func TestWriteJUnitReport(t *testing.T) {
+	runs := []*testRun{
+		{name: "passed", duration: time.Second},
+		{name: "failed", failures: []string{"first", "second"}},
+		{name: "skipped", skipped: "no cluster"},
+	}
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := writeJUnitReport(path, runs, 2*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("Missing XML header in %q", data)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Invalid report: %v", err)
+	}
+	if len(report.Suites) != 1 {
+		t.Fatalf("Expected 1 test suite, got %d", len(report.Suites))
+	}
+	suite := report.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 || suite.Time != "2.000" {
+		t.Errorf("Unexpected test suite %+v", suite)
+	}
+	if len(suite.Cases[1].Failures) != 2 || suite.Cases[1].Failures[1].Message != "second" {
+		t.Errorf("Unexpected failures %+v", suite.Cases[1].Failures)
+	}
+	if suite.Cases[2].Skipped == nil || suite.Cases[2].Skipped.Message != "no cluster" {
+		t.Errorf("Expected a skipped test case, got %+v", suite.Cases[2])
+	}
+
+	if err := writeJUnitReport(filepath.Join(path, "report.xml"), runs, 0); err == nil {
+		t.Error("Expected an error for an unwritable path")
+	}
+}
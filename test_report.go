@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// testRun records the outcome of one scenario test. Failures and skips are
+// printed as they happen, between the scenario's own output.
+type testRun struct {
+	name     string
+	failures []string
+	skipped  string
+	duration time.Duration
+}
+
+// Errorf prints and records a failure; the scenario keeps running
+func (t *testRun) Errorf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	t.failures = append(t.failures, message)
+	fmt.Printf("❌ %s\n", message)
+}
+
+// Skipf prints and records why the scenario cannot run here; the scenario
+// should return afterwards
+func (t *testRun) Skipf(format string, args ...interface{}) {
+	t.skipped = fmt.Sprintf(format, args...)
+	fmt.Printf("⏭️  Skipped: %s\n", t.skipped)
+}
+
+// Failed reports whether the scenario recorded a failure
+func (t *testRun) Failed() bool {
+	return len(t.failures) > 0
+}
+
+// scenarioNames returns the names of all scenario tests in a stable order
+func scenarioNames() []string {
+	names := make([]string, 0, len(availableTests))
+	for name := range availableTests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runScenario runs the named scenario test and returns its outcome
+func runScenario(name string) *testRun {
+	run := &testRun{name: name}
+	start := time.Now()
+	availableTests[name](run)
+	run.duration = time.Since(start)
+	return run
+}
+
+// JUnit XML report elements, as read by CI systems
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failures  []junitResult `xml:"failure,omitempty"`
+	Skipped   *junitResult  `xml:"skipped,omitempty"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitReport writes the outcomes of a test run as a JUnit XML report
+func writeJUnitReport(path string, runs []*testRun, total time.Duration) error {
+	suite := junitTestSuite{
+		Name:  "audit-query-mcp-server",
+		Tests: len(runs),
+		Time:  fmt.Sprintf("%.3f", total.Seconds()),
+	}
+	for _, run := range runs {
+		testCase := junitTestCase{
+			Name:      run.name,
+			ClassName: "audit-query-mcp-server.test",
+			Time:      fmt.Sprintf("%.3f", run.duration.Seconds()),
+		}
+		for _, failure := range run.failures {
+			testCase.Failures = append(testCase.Failures, junitResult{Message: failure})
+		}
+		if run.Failed() {
+			suite.Failures++
+		} else if run.skipped != "" {
+			testCase.Skipped = &junitResult{Message: run.skipped}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
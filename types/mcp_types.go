@@ -1,10 +1,40 @@
 package types
 
+import (
+	"strings"
+	"time"
+)
+
 // MCPTool represents an MCP tool definition
 type MCPTool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// Category groups the tool in tools/list: query, analytics, detection or
+	// management
+	Category     string            `json:"category,omitempty"`
+	Capabilities *ToolCapabilities `json:"capabilities,omitempty"`
+	// Version changes major number when the tool's interface changes
+	// incompatibly
+	Version    string           `json:"version,omitempty"`
+	Deprecated *ToolDeprecation `json:"deprecated,omitempty"`
+}
+
+// ToolDeprecation describes a deprecated tool and its replacement
+type ToolDeprecation struct {
+	Since      string `json:"since"`
+	ReplacedBy string `json:"replaced_by"`
+	Message    string `json:"message"`
+}
+
+// ToolCapabilities tells clients what a tool needs to run
+type ToolCapabilities struct {
+	// RequiresCluster is set when the tool can read the cluster or a log
+	// backend; tools without it work offline on cached results or local files
+	RequiresCluster bool `json:"requires_cluster"`
+	// RequiresJQ is set when the tool runs generated commands with jq stages,
+	// executed by the engine AUDIT_JQ_ENGINE selects
+	RequiresJQ bool `json:"requires_jq"`
 }
 
 // MCPRequest represents an MCP tool call request
@@ -15,6 +45,12 @@ type MCPRequest struct {
 	JSONRPC string                 `json:"jsonrpc"`
 }
 
+// IsNotification reports whether the request is a notification, which gets
+// no response
+func (r MCPRequest) IsNotification() bool {
+	return strings.HasPrefix(r.Method, "notifications/")
+}
+
 // MCPResponse represents an MCP tool call response
 type MCPResponse struct {
 	ID      string      `json:"id"`
@@ -25,6 +61,130 @@ type MCPResponse struct {
 
 // MCPError represents an MCP error response
 type MCPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    *MCPErrorData `json:"data,omitempty"`
+}
+
+// MCPErrorData classifies an MCP error for clients and says how to resolve it
+type MCPErrorData struct {
+	Type        ErrorType `json:"type"`
+	Remediation string    `json:"remediation"`
+	// Violations lists the input schema constraints that tool arguments break
+	Violations []SchemaViolation `json:"violations,omitempty"`
+}
+
+// SchemaViolation is a tool argument that breaks a constraint of the tool's
+// input schema
+type SchemaViolation struct {
+	// Path locates the argument, such as structured_params.verb or queries[1]
+	Path string `json:"path"`
+	// Constraint is the schema keyword broken: type, enum, required, minimum,
+	// maximum, oneOf or additionalProperties
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// ErrorType is the machine-readable class of a tool error
+type ErrorType string
+
+// Error types reported in MCPErrorData
+const (
+	ErrorTypeInvalidParams        ErrorType = "INVALID_PARAMS"
+	ErrorTypePermissionDenied     ErrorType = "PERMISSION_DENIED"
+	ErrorTypeLogSourceUnavailable ErrorType = "LOG_SOURCE_UNAVAILABLE"
+	ErrorTypeTimeout              ErrorType = "TIMEOUT"
+	ErrorTypeClusterUnreachable   ErrorType = "CLUSTER_UNREACHABLE"
+	ErrorTypeExecutionFailed      ErrorType = "EXECUTION_FAILED"
+)
+
+// Code returns the JSON-RPC error code of the error type: the standard invalid
+// params code, or one in the server error range
+func (t ErrorType) Code() int {
+	switch t {
+	case ErrorTypeInvalidParams:
+		return -32602
+	case ErrorTypePermissionDenied:
+		return -32003
+	case ErrorTypeLogSourceUnavailable:
+		return -32004
+	case ErrorTypeTimeout:
+		return -32005
+	case ErrorTypeClusterUnreachable:
+		return -32006
+	default:
+		return -32000
+	}
+}
+
+// MCPResource represents an MCP resource a client can read or subscribe to
+type MCPResource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPResourceTemplate represents a parameterized MCP resource URI
+type MCPResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPResourceContents represents the contents of a read MCP resource
+type MCPResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// MCPNotification represents an MCP notification sent from the server
+type MCPNotification struct {
+	Method  string                 `json:"method"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	JSONRPC string                 `json:"jsonrpc"`
+}
+
+// MCPPrompt represents an MCP prompt template a client can offer its user
+type MCPPrompt struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Arguments   []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument represents an argument of an MCP prompt
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// MCPPromptMessage represents a message of a rendered MCP prompt
+type MCPPromptMessage struct {
+	Role    string           `json:"role"`
+	Content MCPPromptContent `json:"content"`
+}
+
+// MCPPromptContent represents the text content of an MCP prompt message
+type MCPPromptContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// RequestLogEntry records an MCP request handled by the server, written to
+// the slow-query log when it took at least the configured threshold
+type RequestLogEntry struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	RequestID   string                 `json:"request_id,omitempty"`
+	Method      string                 `json:"method"`
+	Tool        string                 `json:"tool,omitempty"`
+	Cluster     string                 `json:"cluster,omitempty"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	DurationMs  int64                  `json:"duration_ms"`
+	ResultBytes int                    `json:"result_bytes"`
+	Outcome     string                 `json:"outcome"`
+	ErrorCode   int                    `json:"error_code,omitempty"`
+	ErrorType   ErrorType              `json:"error_type,omitempty"`
 }
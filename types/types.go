@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
 
 // AuditQueryParams represents the structured parameters for audit queries
 type AuditQueryParams struct {
@@ -12,20 +17,1339 @@ type AuditQueryParams struct {
 	Resource  string   `json:"resource,omitempty"`
 	Verb      string   `json:"verb,omitempty"`
 	Namespace string   `json:"namespace,omitempty"`
+
+	// Structured exclusions drop events whose field equals any listed value;
+	// a trailing "*" excludes every value with that prefix
+	ExcludeUsers      []string `json:"exclude_users,omitempty"`
+	ExcludeNamespaces []string `json:"exclude_namespaces,omitempty"`
+	ExcludeVerbs      []string `json:"exclude_verbs,omitempty"`
+	ExcludeResources  []string `json:"exclude_resources,omitempty"`
+
+	// Usernames, Resources, Verbs and Namespaces select events matching any of
+	// several values, combined with the single-value fields above and their match modes
+	Usernames  []string `json:"usernames,omitempty"`
+	Resources  []string `json:"resources,omitempty"`
+	Verbs      []string `json:"verbs,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Subresources selects events on any of the listed subresources, such as
+	// "exec" or "portforward", matched exactly
+	Subresources []string `json:"subresources,omitempty"`
+
+	// StatusCode selects a single response status code; StatusCodeRange selects a
+	// named range from utils.StatusCodeRanges, a class such as "4xx" or a span such as "400-499"
+	StatusCode      int    `json:"status_code,omitempty"`
+	StatusCodeRange string `json:"status_code_range,omitempty"`
+
+	// Groups selects events by users in any of the listed groups; ImpersonatedUser
+	// selects events performed while impersonating the given user
+	Groups           []string `json:"groups,omitempty"`
+	ImpersonatedUser string   `json:"impersonated_user,omitempty"`
+
+	// UserAgent selects events by client user agent, such as "kubectl" or an
+	// operator name; UserAgentMatch defaults to a case-insensitive substring match
+	UserAgent      string    `json:"user_agent,omitempty"`
+	UserAgentMatch MatchMode `json:"user_agent_match,omitempty"`
+
+	// Stage and Level select events by audit stage (e.g. ResponseComplete) and
+	// level (e.g. RequestResponse for events that carry request bodies)
+	Stage string `json:"stage,omitempty"`
+	Level string `json:"level,omitempty"`
+
+	// SourceIP selects a single client address and SourceCIDR a client network.
+	// CIDR membership is checked after parsing, since jq and grep cannot do the math
+	SourceIP   string `json:"source_ip,omitempty"`
+	SourceCIDR string `json:"source_cidr,omitempty"`
+
+	// SortBy orders the parsed entries by timestamp, user, resource or statusCode
+	// in SortOrder ("asc" by default, or "desc"); Offset and Limit then select a
+	// page of entries. All four are applied after parsing, not in the command
+	SortBy    string `json:"sort_by,omitempty"`
+	SortOrder string `json:"sort_order,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+
+	// SampleRate keeps one in SampleRate of the matching entries and SampleSize
+	// at most SampleSize of them, for exploratory queries over large windows.
+	// Both are applied after parsing, before summarizing, sorting and paging;
+	// zero keeps every entry
+	SampleRate int `json:"sample_rate,omitempty"`
+	SampleSize int `json:"sample_size,omitempty"`
+
+	// OutputMode "histogram" returns entry counts per BucketSize ("minute", "hour"
+	// or "day") instead of the entries themselves; "entries" is the default
+	OutputMode string `json:"output_mode,omitempty"`
+	BucketSize string `json:"bucket_size,omitempty"`
+
+	// SummaryMode "verbose" adds the top users, verbs, namespaces and resources
+	// and the error rate to the summary; "brief" is the default
+	SummaryMode string `json:"summary_mode,omitempty"`
+
+	// Match modes for the field filters above; empty keeps the legacy behaviour
+	UsernameMatch  MatchMode `json:"username_match,omitempty"`
+	ResourceMatch  MatchMode `json:"resource_match,omitempty"`
+	VerbMatch      MatchMode `json:"verb_match,omitempty"`
+	NamespaceMatch MatchMode `json:"namespace_match,omitempty"`
+
+	// Filter is an optional boolean pattern expression applied in addition to Patterns
+	Filter *FilterExpression `json:"filter,omitempty"`
+
+	// Backend selects where events are read from: "openshift" (oc adm
+	// node-logs) or one of the providers, such as "loki"; empty uses the
+	// server's default
+	Backend string `json:"backend,omitempty"`
+
+	// IdempotencyKey is the idempotency key of the tool call running the
+	// query, from which the query ID is derived; it does not select events
+	IdempotencyKey string `json:"-"`
+}
+
+// MatchMode controls how a field filter value is compared with the audit event
+type MatchMode string
+
+// Supported match modes. Exact, prefix and regex are case-sensitive; substring is not.
+const (
+	MatchModeExact     MatchMode = "exact"
+	MatchModePrefix    MatchMode = "prefix"
+	MatchModeRegex     MatchMode = "regex"
+	MatchModeSubstring MatchMode = "substring"
+)
+
+// ValidMatchModes lists the supported match modes
+var ValidMatchModes = []MatchMode{MatchModeExact, MatchModePrefix, MatchModeRegex, MatchModeSubstring}
+
+// Filter expression operators
+const (
+	FilterOperatorAnd = "and"
+	FilterOperatorOr  = "or"
+	FilterOperatorNot = "not"
+)
+
+// FilterExpression is a boolean expression of case-insensitive patterns matched
+// against the whole audit event. A node with only Pattern set is a leaf; "and"
+// and "or" combine their children, and "not" matches when none of its children match.
+type FilterExpression struct {
+	Operator string             `json:"operator,omitempty"`
+	Pattern  string             `json:"pattern,omitempty"`
+	Children []FilterExpression `json:"children,omitempty"`
+}
+
+// IsLeaf reports whether the expression is a single pattern
+func (f FilterExpression) IsLeaf() bool {
+	return f.Operator == ""
+}
+
+// String renders the expression in a readable infix form
+func (f FilterExpression) String() string {
+	if f.IsLeaf() {
+		return fmt.Sprintf("%q", f.Pattern)
+	}
+
+	parts := make([]string, len(f.Children))
+	for i, child := range f.Children {
+		parts[i] = child.String()
+	}
+
+	switch f.Operator {
+	case FilterOperatorNot:
+		return "NOT (" + strings.Join(parts, " OR ") + ")"
+	default:
+		return "(" + strings.Join(parts, " "+strings.ToUpper(f.Operator)+" ") + ")"
+	}
+}
+
+// QuestionInterpretation describes how a natural-language question was translated
+// to query parameters, so callers can check what was actually queried
+type QuestionInterpretation struct {
+	Question string           `json:"question"`
+	Params   AuditQueryParams `json:"params"`
+	Matched  []string         `json:"matched"`
+	Notes    []string         `json:"notes,omitempty"`
+}
+
+// PermissionDenialReport summarizes authorization failures grouped by user,
+// resource and the permission each request was missing
+type PermissionDenialReport struct {
+	TotalDenials       int           `json:"total_denials"`
+	ByUser             []DenialCount `json:"by_user"`
+	ByResource         []DenialCount `json:"by_resource"`
+	Rules              []DeniedRule  `json:"rules"`
+	EscalationAttempts []DeniedRule  `json:"escalation_attempts,omitempty"`
+	Summary            string        `json:"summary"`
+}
+
+// DenialCount is the number of denials for a single user or resource
+type DenialCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// DeniedRule is a verb/resource combination that RBAC refused, with the users
+// who attempted it and the authorizer's reasons
+type DeniedRule struct {
+	Verb        string   `json:"verb"`
+	APIGroup    string   `json:"api_group,omitempty"`
+	Resource    string   `json:"resource"`
+	Subresource string   `json:"subresource,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Count       int      `json:"count"`
+	Users       []string `json:"users"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// ActivityComparison describes how activity in a comparison query differs from a
+// baseline query run with the same filters
+type ActivityComparison struct {
+	Baseline      ActivityTotals `json:"baseline"`
+	Comparison    ActivityTotals `json:"comparison"`
+	NewResources  []string       `json:"new_resources"`
+	NewNamespaces []string       `json:"new_namespaces"`
+	NewSourceIPs  []string       `json:"new_source_ips"`
+	VerbChanges   []VerbChange   `json:"verb_changes"`
+	Summary       string         `json:"summary"`
+}
+
+// ActivityTotals identifies one side of a comparison and its event count
+type ActivityTotals struct {
+	Label  string `json:"label"`
+	Events int    `json:"events"`
+}
+
+// VerbChange is a verb whose event count differs between baseline and comparison
+type VerbChange struct {
+	Verb       string `json:"verb"`
+	Baseline   int    `json:"baseline"`
+	Comparison int    `json:"comparison"`
+	Delta      int    `json:"delta"`
+}
+
+// FieldChange is a field that differs between two versions of an object.
+// Path addresses the field as in "webhooks[0].clientConfig.url"; Old is
+// absent for added fields and New for removed ones.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// WebhookChangeReport tracks the changes of admission webhook configurations
+// and custom resource definitions, whose conversion webhooks also see every
+// object of the resource
+type WebhookChangeReport struct {
+	TotalChanges int `json:"total_changes"`
+	// Failed counts the change requests that were refused or failed
+	Failed     int             `json:"failed"`
+	ByResource map[string]int  `json:"by_resource"`
+	Changes    []WebhookChange `json:"changes"`
+	Summary    string          `json:"summary"`
+}
+
+// WebhookChange is one change of a webhook configuration or CRD. Diff compares
+// the object with its previous version in the timeframe, and is only available
+// when both were logged with their bodies; Patch is the body of a patch whose
+// result was not logged.
+type WebhookChange struct {
+	Timestamp string   `json:"timestamp"`
+	Username  string   `json:"username"`
+	Verb      string   `json:"verb"`
+	Resource  string   `json:"resource"`
+	Name      string   `json:"name"`
+	SourceIPs []string `json:"source_ips,omitempty"`
+	UserAgent string   `json:"user_agent,omitempty"`
+	AuditID   string   `json:"audit_id,omitempty"`
+	// Webhooks are the names of the configuration's webhooks after the
+	// change; ConversionWebhook is the service or URL a CRD converts through
+	Webhooks          []string               `json:"webhooks,omitempty"`
+	ConversionWebhook string                 `json:"conversion_webhook,omitempty"`
+	BodyLogged        bool                   `json:"body_logged"`
+	Diff              []FieldChange          `json:"diff,omitempty"`
+	Patch             map[string]interface{} `json:"patch,omitempty"`
+}
+
+// RBACChangeReport consolidates the role and binding changes of a timeframe
+// into the permissions each subject gained and lost
+type RBACChangeReport struct {
+	TotalChanges int `json:"total_changes"`
+	// Failed counts the change requests that were refused or failed
+	Failed   int                 `json:"failed"`
+	Subjects []RBACSubjectChange `json:"subjects"`
+	Roles    []RBACRoleChange    `json:"roles"`
+	// Unresolved are the changes whose effect is unknown because their
+	// bodies, or the previous version of the object, were not logged
+	Unresolved []RBACUnresolvedChange `json:"unresolved"`
+	Summary    string                 `json:"summary"`
+}
+
+// RBACSubjectChange is the net change of a subject's role bindings over a
+// timeframe: a binding granted and revoked again appears in neither list
+type RBACSubjectChange struct {
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Gained    []RBACGrant `json:"gained"`
+	Lost      []RBACGrant `json:"lost"`
+	ChangedBy []string    `json:"changed_by"`
+}
+
+// RBACGrant is a role granted through a binding; Namespace is empty for
+// cluster-wide grants
+type RBACGrant struct {
+	RoleKind  string `json:"role_kind"`
+	RoleName  string `json:"role_name"`
+	Namespace string `json:"namespace,omitempty"`
+	Binding   string `json:"binding"`
+}
+
+// RBACRoleChange is the net change of a role's rules over a timeframe, with
+// the subjects bound to it by the bindings seen in the timeframe
+type RBACRoleChange struct {
+	Kind          string   `json:"kind"`
+	Namespace     string   `json:"namespace,omitempty"`
+	Name          string   `json:"name"`
+	Deleted       bool     `json:"deleted,omitempty"`
+	AddedRules    []string `json:"added_rules"`
+	RemovedRules  []string `json:"removed_rules"`
+	BoundSubjects []string `json:"bound_subjects,omitempty"`
+	ChangedBy     []string `json:"changed_by"`
+}
+
+// RBACUnresolvedChange is a role or binding change whose effect is unknown
+type RBACUnresolvedChange struct {
+	Timestamp string `json:"timestamp"`
+	Username  string `json:"username"`
+	Verb      string `json:"verb"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	AuditID   string `json:"audit_id,omitempty"`
+}
+
+// ComplianceReport holds the access review artifacts of one calendar month:
+// the human users with cluster access and their verbs, the privileged
+// operations, and the access to sensitive namespaces
+type ComplianceReport struct {
+	Month                string                     `json:"month"`
+	Start                string                     `json:"start"`
+	End                  string                     `json:"end"`
+	SensitiveNamespaces  []string                   `json:"sensitive_namespaces"`
+	TotalEvents          int                        `json:"total_events"`
+	Users                []ComplianceUser           `json:"users"`
+	PrivilegedOperations []PrivilegedOperation      `json:"privileged_operations"`
+	SensitiveAccess      []SensitiveNamespaceAccess `json:"sensitive_namespace_access"`
+	Summary              string                     `json:"summary"`
+}
+
+// ComplianceUser is a human user's activity over a compliance period
+type ComplianceUser struct {
+	Username   string         `json:"username"`
+	Groups     []string       `json:"groups,omitempty"`
+	Events     int            `json:"events"`
+	Failed     int            `json:"failed"`
+	Verbs      map[string]int `json:"verbs"`
+	Namespaces []string       `json:"namespaces"`
+	FirstSeen  string         `json:"first_seen"`
+	LastSeen   string         `json:"last_seen"`
+}
+
+// PrivilegedOperation is a request that grants, uses or changes elevated
+// access, such as an RBAC change or an exec session into a pod
+type PrivilegedOperation struct {
+	Timestamp   string   `json:"timestamp"`
+	Username    string   `json:"username"`
+	Category    string   `json:"category"`
+	Verb        string   `json:"verb"`
+	Resource    string   `json:"resource"`
+	Subresource string   `json:"subresource,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	StatusCode  int      `json:"status_code"`
+	SourceIPs   []string `json:"source_ips,omitempty"`
+	AuditID     string   `json:"audit_id,omitempty"`
+}
+
+// SensitiveNamespaceAccess counts a user's requests with one verb on one
+// resource of a sensitive namespace
+type SensitiveNamespaceAccess struct {
+	Namespace string `json:"namespace"`
+	Username  string `json:"username"`
+	Verb      string `json:"verb"`
+	Resource  string `json:"resource"`
+	Events    int    `json:"events"`
+	Failed    int    `json:"failed"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// Login failure kinds
+const (
+	LoginFailurePassword = "password"
+	LoginFailureToken    = "token"
+	LoginFailureIdP      = "idp"
+)
+
+// Login failure burst scopes
+const (
+	LoginBurstUser     = "user"
+	LoginBurstSourceIP = "source_ip"
+)
+
+// LoginFailureReport groups the failed logins of the OAuth server by the
+// username tried and by source IP, with the bursts that exceeded the threshold
+type LoginFailureReport struct {
+	TotalFailures    int                  `json:"total_failures"`
+	SuccessfulLogins int                  `json:"successful_logins"`
+	ByKind           map[string]int       `json:"by_kind"`
+	BurstThreshold   int                  `json:"burst_threshold"`
+	BurstWindow      string               `json:"burst_window"`
+	Users            []LoginFailureUser   `json:"users"`
+	SourceIPs        []LoginFailureSource `json:"source_ips"`
+	Bursts           []LoginFailureBurst  `json:"bursts"`
+	Summary          string               `json:"summary"`
+}
+
+// LoginFailureUser is the failed logins of one username
+type LoginFailureUser struct {
+	Username          string         `json:"username"`
+	Failures          int            `json:"failures"`
+	Kinds             map[string]int `json:"kinds"`
+	SourceIPs         []string       `json:"source_ips"`
+	IdentityProviders []string       `json:"identity_providers,omitempty"`
+	FirstFailure      string         `json:"first_failure"`
+	LastFailure       string         `json:"last_failure"`
+	// Burst is set when the failures exceeded the burst threshold, as a
+	// lockout policy would; SucceededAfterFailures when a login of the user
+	// then succeeded, which after a burst may be a guessed password
+	Burst                  bool `json:"burst"`
+	SucceededAfterFailures bool `json:"succeeded_after_failures"`
+}
+
+// LoginFailureSource is the failed logins from one source IP; many usernames
+// from one source suggest password spraying
+type LoginFailureSource struct {
+	SourceIP     string         `json:"source_ip"`
+	Failures     int            `json:"failures"`
+	Usernames    []string       `json:"usernames"`
+	Kinds        map[string]int `json:"kinds"`
+	FirstFailure string         `json:"first_failure"`
+	LastFailure  string         `json:"last_failure"`
+	Burst        bool           `json:"burst"`
+}
+
+// LoginFailureBurst is a run of failed logins of one user or source IP with
+// at least the threshold within the burst window
+type LoginFailureBurst struct {
+	Scope    string `json:"scope"`
+	Key      string `json:"key"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Failures int    `json:"failures"`
+}
+
+// Token creation kinds
+const (
+	TokenKindRequest = "token_request"
+	TokenKindSecret  = "secret"
+)
+
+// TokenCreationReport tracks the creation of service account tokens, through
+// the TokenRequest API or as service account token secrets, which never expire
+type TokenCreationReport struct {
+	TotalTokens   int    `json:"total_tokens"`
+	TokenRequests int    `json:"token_requests"`
+	SecretTokens  int    `json:"secret_tokens"`
+	Failed        int    `json:"failed"`
+	MaxLifetime   string `json:"max_lifetime"`
+	LongLived     int    `json:"long_lived"`
+	// HumanLongLived counts the long-lived tokens created by human users,
+	// which most security baselines forbid
+	HumanLongLived  int `json:"human_long_lived"`
+	UnknownLifetime int `json:"unknown_lifetime"`
+	// SecretsWithoutBody counts the secrets created without a logged body,
+	// whose type, and so whether they are tokens, is unknown
+	SecretsWithoutBody int             `json:"secrets_without_body"`
+	Tokens             []TokenCreation `json:"tokens"`
+	Summary            string          `json:"summary"`
+}
+
+// TokenCreation is one created service account token. Lifetime is "never"
+// for secret tokens and "unknown" when the request body was not logged.
+type TokenCreation struct {
+	Timestamp         string   `json:"timestamp"`
+	Username          string   `json:"username"`
+	Human             bool     `json:"human"`
+	Kind              string   `json:"kind"`
+	Namespace         string   `json:"namespace"`
+	ServiceAccount    string   `json:"service_account"`
+	SecretName        string   `json:"secret_name,omitempty"`
+	ExpirationSeconds int64    `json:"expiration_seconds,omitempty"`
+	Lifetime          string   `json:"lifetime"`
+	Audiences         []string `json:"audiences,omitempty"`
+	BoundObject       string   `json:"bound_object,omitempty"`
+	LongLived         bool     `json:"long_lived"`
+	Flagged           bool     `json:"flagged"`
+	SourceIPs         []string `json:"source_ips,omitempty"`
+	UserAgent         string   `json:"user_agent,omitempty"`
+	AuditID           string   `json:"audit_id,omitempty"`
+}
+
+// TopTalkersReport ranks the users and user agents by event volume and
+// suggests exclusions for the noisy controllers among them
+type TopTalkersReport struct {
+	TotalEvents int `json:"total_events"`
+	// MinShare is the share of the events above which a controller is noisy
+	MinShare   float64     `json:"min_share"`
+	Users      []TopTalker `json:"users"`
+	UserAgents []TopTalker `json:"user_agents"`
+	// Suggested exclusions, ready to paste into structured_params
+	SuggestedExclusions ExclusionSuggestion `json:"suggested_exclusions"`
+	Summary             string              `json:"summary"`
+}
+
+// TopTalker is a user, or a user agent product such as
+// "kube-controller-manager", with its event count and share of the events.
+// Human is set for users, and for agents used by a human user.
+type TopTalker struct {
+	Name      string         `json:"name"`
+	Events    int            `json:"events"`
+	Share     float64        `json:"share"`
+	Human     bool           `json:"human"`
+	Verbs     map[string]int `json:"verbs"`
+	Suggested bool           `json:"suggested"`
+}
+
+// ExclusionSuggestion holds exclusions dropping noisy controllers, in the form
+// of the exclude_users and exclude query parameters, with the events and share
+// of the events they drop
+type ExclusionSuggestion struct {
+	ExcludeUsers  []string `json:"exclude_users"`
+	Exclude       []string `json:"exclude"`
+	DroppedEvents int      `json:"dropped_events"`
+	DroppedShare  float64  `json:"dropped_share"`
+}
+
+// CSR decisions
+const (
+	CSRDecisionApproved = "approved"
+	CSRDecisionDenied   = "denied"
+	// CSRDecisionUnknown is an approval request logged without its body, as
+	// at the Metadata audit level
+	CSRDecisionUnknown = "unknown"
+)
+
+// CSRActivityReport tracks the certificate signing requests created and the
+// approvals and denials of them, flagging unusual approvers: users outside
+// ExpectedApprovers, and requesters approving their own requests
+type CSRActivityReport struct {
+	ExpectedApprovers []string `json:"expected_approvers"`
+	Created           int      `json:"created"`
+	Approved          int      `json:"approved"`
+	Denied            int      `json:"denied"`
+	UnknownDecisions  int      `json:"unknown_decisions"`
+	// Failed counts the create and approval requests that were refused or failed
+	Failed           int           `json:"failed"`
+	UnusualApprovals int           `json:"unusual_approvals"`
+	Approvers        []CSRApprover `json:"approvers"`
+	Requests         []CSRRequest  `json:"requests"`
+	Summary          string        `json:"summary"`
+}
+
+// CSRApprover counts one user's decisions on signing requests
+type CSRApprover struct {
+	Username string `json:"username"`
+	Approved int    `json:"approved"`
+	Denied   int    `json:"denied"`
+	Unknown  int    `json:"unknown"`
+	Unusual  bool   `json:"unusual"`
+}
+
+// CSRRequest is one certificate signing request. Requester and CreatedAt are
+// empty when the request was created before the timeframe; Decision is empty
+// while it is pending.
+type CSRRequest struct {
+	Name       string `json:"name"`
+	Requester  string `json:"requester,omitempty"`
+	SignerName string `json:"signer_name,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	Decision   string `json:"decision,omitempty"`
+	DecidedBy  string `json:"decided_by,omitempty"`
+	DecidedAt  string `json:"decided_at,omitempty"`
+	// UnusualApprover is set when the request was approved by a user outside
+	// the expected approvers or by its requester, SelfApproved for the latter
+	UnusualApprover bool `json:"unusual_approver"`
+	SelfApproved    bool `json:"self_approved"`
+}
+
+// InfrastructureChangeReport is the change log of the cluster's
+// infrastructure: machine configs, nodes and the scheduler and API server
+// configuration
+type InfrastructureChangeReport struct {
+	TotalChanges int `json:"total_changes"`
+	// Failed counts the change requests that were refused or failed
+	Failed     int                        `json:"failed"`
+	ByCategory map[string]int             `json:"by_category"`
+	Users      []InfrastructureChangeUser `json:"users"`
+	// Changes are the successful changes in time order
+	Changes []InfrastructureChange `json:"changes"`
+	Summary string                 `json:"summary"`
+}
+
+// InfrastructureChangeUser counts one user's changes by category
+type InfrastructureChangeUser struct {
+	Username   string         `json:"username"`
+	Changes    int            `json:"changes"`
+	Categories map[string]int `json:"categories"`
+}
+
+// InfrastructureChange is one change of an infrastructure object
+type InfrastructureChange struct {
+	Timestamp  string   `json:"timestamp"`
+	Username   string   `json:"username"`
+	Category   string   `json:"category"`
+	Verb       string   `json:"verb"`
+	Resource   string   `json:"resource"`
+	APIGroup   string   `json:"api_group,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	StatusCode int      `json:"status_code"`
+	SourceIPs  []string `json:"source_ips,omitempty"`
+	UserAgent  string   `json:"user_agent,omitempty"`
+	AuditID    string   `json:"audit_id,omitempty"`
+}
+
+// PodExecReport summarizes who opened exec, attach and port-forward sessions
+// into which pods and when
+type PodExecReport struct {
+	TotalSessions int `json:"total_sessions"`
+	TotalFailed   int `json:"total_failed"`
+	Pods          int `json:"pods"`
+	// Users are the users who opened sessions, the most sessions first
+	Users []PodExecUser `json:"users"`
+	// Sessions are the requests in time order
+	Sessions []PodExecSession `json:"sessions"`
+	Summary  string           `json:"summary"`
+}
+
+// PodExecUser is one user's sessions. Pods are namespace/name; Failed counts
+// the requests refused or failed, which opened no session.
+type PodExecUser struct {
+	Username     string         `json:"username"`
+	Sessions     int            `json:"sessions"`
+	Failed       int            `json:"failed"`
+	Subresources map[string]int `json:"subresources"`
+	Pods         []string       `json:"pods"`
+	FirstSeen    string         `json:"first_seen"`
+	LastSeen     string         `json:"last_seen"`
+}
+
+// PodExecSession is one exec, attach or port-forward request, with the
+// command, container and ports taken from its request URI
+type PodExecSession struct {
+	Timestamp   string   `json:"timestamp"`
+	Username    string   `json:"username"`
+	Namespace   string   `json:"namespace"`
+	Pod         string   `json:"pod"`
+	Subresource string   `json:"subresource"`
+	Container   string   `json:"container,omitempty"`
+	Command     string   `json:"command,omitempty"`
+	Ports       []string `json:"ports,omitempty"`
+	Interactive bool     `json:"interactive"`
+	StatusCode  int      `json:"status_code"`
+	Failed      bool     `json:"failed"`
+	SourceIPs   []string `json:"source_ips,omitempty"`
+	UserAgent   string   `json:"user_agent,omitempty"`
+	AuditID     string   `json:"audit_id,omitempty"`
+}
+
+// SecretAccessReport groups the reads of secrets by user and namespace,
+// flagging first-time accessors: users who read a namespace's secrets without
+// having done so in the baseline before the timeframe
+type SecretAccessReport struct {
+	Timeframe         string   `json:"timeframe"`
+	BaselineTimeframe string   `json:"baseline_timeframe"`
+	QueryID           string   `json:"query_id,omitempty"`
+	Allowlist         []string `json:"allowlist"`
+	TotalReads        int      `json:"total_reads"`
+	TotalDenied       int      `json:"total_denied"`
+	// Accessors are the user and namespace pairs, first-time accessors first
+	Accessors          []SecretAccessor `json:"accessors"`
+	FirstTimeAccessors int              `json:"first_time_accessors"`
+	Summary            string           `json:"summary"`
+}
+
+// SecretAccessor is one user's reads of a namespace's secrets. Namespace is
+// empty for lists across all namespaces. Denied counts the refused requests,
+// which are not reads.
+type SecretAccessor struct {
+	Username  string         `json:"username"`
+	Namespace string         `json:"namespace,omitempty"`
+	Reads     int            `json:"reads"`
+	Denied    int            `json:"denied"`
+	Verbs     map[string]int `json:"verbs"`
+	Secrets   []string       `json:"secrets"`
+	FirstSeen string         `json:"first_seen"`
+	LastSeen  string         `json:"last_seen"`
+	// FirstTime is set when the user read the namespace's secrets and did
+	// not in the baseline; NewUser when the user read no secrets at all then
+	FirstTime bool `json:"first_time"`
+	NewUser   bool `json:"new_user"`
+}
+
+// Escalation chain event kinds
+const (
+	ChainEventFailedAuthentication = "failed_authentication"
+	ChainEventForbidden            = "forbidden"
+	ChainEventRBACModification     = "rbac_modification"
+)
+
+// EscalationReport lists the privilege escalation chains found: users with
+// at least MinFailures failed authentications or denied requests followed
+// within Window by successful RBAC modifications
+type EscalationReport struct {
+	MinFailures       int               `json:"min_failures"`
+	Window            string            `json:"window"`
+	FailedAttempts    int               `json:"failed_attempts"`
+	RBACModifications int               `json:"rbac_modifications"`
+	Chains            []EscalationChain `json:"chains"`
+	Summary           string            `json:"summary"`
+}
+
+// EscalationChain is one user's failed attempts and the RBAC modifications
+// that followed them, in time order
+type EscalationChain struct {
+	Username              string       `json:"username"`
+	FailedAuthentications int          `json:"failed_authentications"`
+	Forbidden             int          `json:"forbidden"`
+	Modifications         int          `json:"modifications"`
+	Start                 string       `json:"start"`
+	End                   string       `json:"end"`
+	Events                []ChainEvent `json:"events"`
+}
+
+// ChainEvent is an event of an escalation chain
+type ChainEvent struct {
+	Timestamp  string   `json:"timestamp"`
+	Kind       string   `json:"kind"`
+	LogSource  string   `json:"log_source"`
+	Verb       string   `json:"verb,omitempty"`
+	Resource   string   `json:"resource,omitempty"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	RequestURI string   `json:"request_uri,omitempty"`
+	StatusCode int      `json:"status_code,omitempty"`
+	SourceIPs  []string `json:"source_ips,omitempty"`
+	AuditID    string   `json:"audit_id,omitempty"`
+}
+
+// ChurnReport lists the objects changed unusually often: those created,
+// deleted, patched or updated at least MinChanges times within Window
+type ChurnReport struct {
+	Window       string `json:"window"`
+	MinChanges   int    `json:"min_changes"`
+	TotalChanges int    `json:"total_changes"`
+	// ChangedObjects counts the distinct objects changed at least once
+	ChangedObjects int `json:"changed_objects"`
+	// Objects are the churning objects, the highest peak first
+	Objects []ObjectChurn `json:"objects"`
+	Summary string        `json:"summary"`
+}
+
+// ObjectChurn is the changes of one object, identified by its resource kind,
+// API group, namespace and name. Verbs counts the changes by verb; Peak is
+// the most changes within one window, starting at PeakStart.
+type ObjectChurn struct {
+	Resource  string         `json:"resource"`
+	APIGroup  string         `json:"api_group,omitempty"`
+	Namespace string         `json:"namespace,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Changes   int            `json:"changes"`
+	Verbs     map[string]int `json:"verbs"`
+	Peak      int            `json:"peak"`
+	PeakStart string         `json:"peak_start"`
+	FirstSeen string         `json:"first_seen"`
+	LastSeen  string         `json:"last_seen"`
+	Users     []string       `json:"users"`
+}
+
+// ErrorRateReport compares the 4xx and 5xx response rates of the namespaces
+// and users of a timeframe with the previous equivalent window, flagging
+// those whose error rate spiked
+type ErrorRateReport struct {
+	Current  ErrorRateWindow `json:"current"`
+	Previous ErrorRateWindow `json:"previous"`
+	// Namespaces and Users are the heatmap rows, the highest error rate first
+	Namespaces []ErrorRateRow `json:"namespaces"`
+	Users      []ErrorRateRow `json:"users"`
+	// SpikingNamespaces and SpikingUsers list the rows flagged as spiking
+	SpikingNamespaces []string `json:"spiking_namespaces"`
+	SpikingUsers      []string `json:"spiking_users"`
+	// SpikeThreshold is the increase of the error rate, in percentage points,
+	// and MinRequests the responses a row needs to be flagged as spiking
+	SpikeThreshold float64 `json:"spike_threshold"`
+	MinRequests    int     `json:"min_requests"`
+	Summary        string  `json:"summary"`
+}
+
+// ErrorRateWindow identifies one window of an error rate comparison and its
+// overall rates. The previous window of a rolling timeframe is queried with a
+// timeframe twice as long, so Start and End give the window itself.
+type ErrorRateWindow struct {
+	Timeframe string `json:"timeframe"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	QueryID   string `json:"query_id,omitempty"`
+	ErrorRateCounts
+}
+
+// ErrorRateRow is the error rates of one namespace or user in the current
+// window, next to its rate in the previous window
+type ErrorRateRow struct {
+	Name string `json:"name"`
+	ErrorRateCounts
+	PreviousRequests  int     `json:"previous_requests"`
+	PreviousErrorRate float64 `json:"previous_error_rate"`
+	// Change is the difference of the error rates in percentage points
+	Change float64 `json:"change"`
+	Spike  bool    `json:"spike"`
+}
+
+// ErrorRateCounts counts the responses with a status code and those failing
+// with 4xx and 5xx codes; the rates are their share in percent
+type ErrorRateCounts struct {
+	Requests        int     `json:"requests"`
+	ClientErrors    int     `json:"client_errors"`
+	ServerErrors    int     `json:"server_errors"`
+	ClientErrorRate float64 `json:"client_error_rate"`
+	ServerErrorRate float64 `json:"server_error_rate"`
+	ErrorRate       float64 `json:"error_rate"`
+}
+
+// UserTimeline is a chronological reconstruction of one user's activity, with
+// bursts of identical operations collapsed and long idle periods marked as gaps
+type UserTimeline struct {
+	Username    string          `json:"username"`
+	TotalEvents int             `json:"total_events"`
+	Events      []TimelineEvent `json:"events"`
+	Gaps        []TimelineGap   `json:"gaps"`
+	Narrative   []string        `json:"narrative"`
+	Untimed     int             `json:"untimed,omitempty"`
+}
+
+// TimelineEvent is one operation, or a burst of Count identical operations
+// performed between Start and End
+type TimelineEvent struct {
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	Count       int      `json:"count"`
+	Verb        string   `json:"verb"`
+	Resource    string   `json:"resource,omitempty"`
+	Subresource string   `json:"subresource,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	StatusCode  int      `json:"status_code,omitempty"`
+	SourceIPs   []string `json:"source_ips,omitempty"`
+}
+
+// TimelineGap is a period without activity longer than the gap threshold
+type TimelineGap struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Duration string `json:"duration"`
+}
+
+// ForwardResult reports how many events were pushed to a SIEM destination
+type ForwardResult struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`
+	Events      int      `json:"events"`
+	Batches     int      `json:"batches"`
+	Sent        int      `json:"sent"`
+	Failed      int      `json:"failed"`
+	Retries     int      `json:"retries"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// Histogram counts parsed entries in fixed-size time buckets. Buckets without
+// entries are omitted.
+type Histogram struct {
+	BucketSize string            `json:"bucket_size"`
+	Buckets    []HistogramBucket `json:"buckets"`
+	Untimed    int               `json:"untimed,omitempty"`
+}
+
+// HistogramBucket holds the entries whose timestamp falls in [Start, Start+BucketSize)
+type HistogramBucket struct {
+	Start  string         `json:"start"`
+	Count  int            `json:"count"`
+	ByVerb map[string]int `json:"by_verb"`
+	ByUser map[string]int `json:"by_user"`
+}
+
+// QueryTemplate is a saved, named set of query parameters
+type QueryTemplate struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Params      AuditQueryParams `json:"params"`
+}
+
+// LogSourceDefinition is an administrator-defined audit log source, such as a
+// custom aggregated API server, read from a file on the master nodes
+type LogSourceDefinition struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
 }
 
 // AuditResult represents the parsed audit query result
 type AuditResult struct {
-	QueryID       string                   `json:"query_id"`
-	Timestamp     string                   `json:"timestamp"`
-	Command       string                   `json:"command"`
-	RawOutput     string                   `json:"raw_output"`
-	ParsedData    []map[string]interface{} `json:"parsed_data"`
+	QueryID           string                   `json:"query_id"`
+	Timestamp         string                   `json:"timestamp"`
+	Command           string                   `json:"command"`
+	RawOutput         string                   `json:"raw_output"`
+	ParsedData        []map[string]interface{} `json:"parsed_data"`
+	Summary           string                   `json:"summary"`
+	Error             string                   `json:"error,omitempty"`
+	Warnings          []string                 `json:"warnings,omitempty"`
+	DuplicatesRemoved int                      `json:"duplicates_removed,omitempty"`
+	TotalEntries      int                      `json:"total_entries,omitempty"`
+	Histogram         *Histogram               `json:"histogram,omitempty"`
+	ExecutionTime     int64                    `json:"execution_time_ms"`
+
+	// Backend is the query backend that served the result, e.g. "openshift"
+	Backend string `json:"backend,omitempty"`
+
+	// Cluster is the registered cluster the result was read from; empty for
+	// the cluster of the server's default kubeconfig context
+	Cluster string `json:"cluster,omitempty"`
+
+	// JQEngine is the engine that ran the command's jq program, "external" or
+	// "builtin"; empty when the command has no jq stage
+	JQEngine string `json:"jq_engine,omitempty"`
+
+	// FromNegativeCache marks an empty result served from the negative cache
+	// rather than re-executed
+	FromNegativeCache bool `json:"from_negative_cache,omitempty"`
+
+	// Incremental is set when the result merges a cached result with a fetch of
+	// only the events after it
+	Incremental *IncrementalInfo `json:"incremental,omitempty"`
+
+	// Sampling is set when the entries, summary and raw output cover a sample
+	// of the matching entries
+	Sampling *SamplingInfo `json:"sampling,omitempty"`
+
+	// Integrity holds the hashes of RawOutput and ParsedData, signed when the
+	// server has a result signing key
+	Integrity *ResultIntegrity `json:"integrity,omitempty"`
+
+	// Provenance records where the raw output was read and by whom, so a
+	// result attached to an incident ticket describes itself
+	Provenance *ResultProvenance `json:"provenance,omitempty"`
+}
+
+// ResultProvenance is the chain-of-custody metadata of a result. Cluster
+// fields are empty when the backend has no cluster or a lookup failed.
+type ResultProvenance struct {
+	// ClusterAPIURL is the API server oc talked to
+	ClusterAPIURL string `json:"cluster_api_url,omitempty"`
+	// NodeRole is the node role whose logs were read and Nodes the nodes of
+	// that role
+	NodeRole string   `json:"node_role,omitempty"`
+	Nodes    []string `json:"nodes,omitempty"`
+	// LogFiles are the log file paths read, on each node for oc commands
+	LogFiles []string `json:"log_files,omitempty"`
+	// Identity is the user oc ran as
+	Identity      string `json:"identity,omitempty"`
+	ServerVersion string `json:"server_version"`
+	ServerHost    string `json:"server_host,omitempty"`
+	// CommandHash is the SHA-256 of the command that produced the raw output
+	CommandHash string `json:"command_hash"`
+}
+
+// Result integrity algorithms
+const (
+	IntegrityHashSHA256    = "sha256"
+	IntegritySignatureHMAC = "hmac-sha256"
+)
+
+// ResultIntegrity holds the hashes a result's evidence can be checked against.
+// The signature covers the query ID and both hashes, so it cannot be moved to
+// another result.
+type ResultIntegrity struct {
+	Algorithm          string `json:"algorithm"`
+	RawOutputHash      string `json:"raw_output_hash"`
+	ParsedDataHash     string `json:"parsed_data_hash"`
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+	Signature          string `json:"signature,omitempty"`
+}
+
+// ResultVerification is the outcome of re-checking a result's integrity
+type ResultVerification struct {
+	QueryID         string   `json:"query_id"`
+	Valid           bool     `json:"valid"`
+	RawOutputValid  bool     `json:"raw_output_valid"`
+	ParsedDataValid bool     `json:"parsed_data_valid"`
+	Signed          bool     `json:"signed"`
+	SignatureValid  bool     `json:"signature_valid"`
+	Issues          []string `json:"issues"`
+	Summary         string   `json:"summary"`
+}
+
+// SamplingInfo describes the sample a result was built from
+type SamplingInfo struct {
+	Rate            int `json:"rate,omitempty"`
+	Size            int `json:"size,omitempty"`
+	MatchingEntries int `json:"matching_entries"`
+	SampledEntries  int `json:"sampled_entries"`
+}
+
+// Narrative is a language model's description of an audit result, written
+// from a redacted digest of its parsed entries
+type Narrative struct {
+	Text     string   `json:"text"`
+	Findings []string `json:"findings"`
+
+	// DescribedEvents is how many parsed entries the digest covered, fewer
+	// than TotalEvents when the result was paged
+	DescribedEvents int `json:"described_events"`
+	TotalEvents     int `json:"total_events"`
+
+	// Model is the language model that wrote the narrative
+	Model string `json:"model,omitempty"`
+}
+
+// BatchQueryItem is the outcome of one query of a batch: its result, or the
+// error it failed with
+type BatchQueryItem struct {
+	Index     int              `json:"index"`
+	Params    AuditQueryParams `json:"params"`
+	Result    *AuditResult     `json:"audit_result,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	ErrorType ErrorType        `json:"error_type,omitempty"`
+}
+
+// BatchQueryResult holds the results of a batch of queries in request order
+// with a combined summary
+type BatchQueryResult struct {
+	Results       []BatchQueryItem `json:"results"`
+	Succeeded     int              `json:"succeeded"`
+	Failed        int              `json:"failed"`
+	TotalEntries  int              `json:"total_entries"`
+	Summary       string           `json:"summary"`
+	ExecutionTime int64            `json:"execution_time_ms"`
+}
+
+// ClusterQueryItem is the outcome of a fan-out query on one cluster: its
+// result without the entries, which are merged, or the error it failed with
+type ClusterQueryItem struct {
+	Cluster   string       `json:"cluster"`
+	Result    *AuditResult `json:"audit_result,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	ErrorType ErrorType    `json:"error_type,omitempty"`
+}
+
+// ClusterFanOutResult holds the outcome of one query run on several
+// clusters, with the entries of all clusters merged in time order and tagged
+// with their "cluster"
+type ClusterFanOutResult struct {
+	Params        AuditQueryParams         `json:"params"`
+	Clusters      []ClusterQueryItem       `json:"clusters"`
+	Entries       []map[string]interface{} `json:"entries"`
+	Succeeded     int                      `json:"succeeded"`
+	Failed        int                      `json:"failed"`
+	TotalEntries  int                      `json:"total_entries"`
 	Summary       string                   `json:"summary"`
-	Error         string                   `json:"error,omitempty"`
 	ExecutionTime int64                    `json:"execution_time_ms"`
 }
 
+// IncrementalInfo describes how an incremental result was assembled
+type IncrementalInfo struct {
+	BaseQueryID  string `json:"base_query_id"`
+	WindowStart  string `json:"window_start"`
+	WindowEnd    string `json:"window_end"`
+	CachedUntil  string `json:"cached_until"`
+	ReusedLines  int    `json:"reused_lines"`
+	FetchedLines int    `json:"fetched_lines"`
+}
+
+// LogSourceAvailability reports whether a log source's audit logs exist on the
+// cluster's master nodes. Error is set when the probe itself failed, in which
+// case availability is unknown.
+type LogSourceAvailability struct {
+	LogSource string `json:"log_source"`
+	Available bool   `json:"available"`
+	Path      string `json:"path"`
+	// Nodes maps each master node with the log source to its audit log files
+	Nodes        map[string][]string `json:"nodes,omitempty"`
+	Alternatives []string            `json:"alternatives,omitempty"`
+	CheckedAt    string              `json:"checked_at"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// AuditConfiguration describes the cluster's APIServer audit policy and the
+// detail queries can expect from it
+type AuditConfiguration struct {
+	Profile     string             `json:"profile"`
+	Detail      AuditProfileDetail `json:"detail"`
+	CustomRules []AuditCustomRule  `json:"custom_rules,omitempty"`
+	Notes       []string           `json:"notes,omitempty"`
+	CheckedAt   string             `json:"checked_at"`
+}
+
+// PermissionCheck is one RBAC permission the server needs and whether the
+// current identity has it
+type PermissionCheck struct {
+	Verb        string `json:"verb"`
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	Group       string `json:"group,omitempty"`
+	RequiredFor string `json:"required_for"`
+	Allowed     bool   `json:"allowed"`
+	Error       string `json:"error,omitempty"`
+}
+
+// PermissionReport describes the permissions of the identity the server runs
+// oc as, and which of those needed for audit queries it lacks
+type PermissionReport struct {
+	Identity string            `json:"identity"`
+	Checks   []PermissionCheck `json:"checks"`
+	// Missing names the denied permissions, e.g. "get nodes/proxy"
+	Missing []string `json:"missing,omitempty"`
+	// NodeLogsReadable reports whether a minimal oc adm node-logs read succeeded
+	NodeLogsReadable bool   `json:"node_logs_readable"`
+	NodeLogsError    string `json:"node_logs_error,omitempty"`
+	CanQuery         bool   `json:"can_query"`
+	Remediation      string `json:"remediation,omitempty"`
+	CheckedAt        string `json:"checked_at"`
+}
+
+// ClusterContext is the current cluster state of an audit entry's subjects,
+// looked up when a query asks for enrichment. Fields are unset when a lookup
+// did not apply or failed; Errors lists the failed lookups.
+type ClusterContext struct {
+	// ResourceExists reports whether the named object the entry acted on
+	// still exists
+	ResourceExists *bool `json:"resource_exists,omitempty"`
+
+	// ServiceAccount is the service account behind a system:serviceaccount user
+	ServiceAccount *ServiceAccountRef `json:"service_account,omitempty"`
+
+	// NamespaceExists and NamespaceLabels describe the entry's namespace
+	NamespaceExists *bool             `json:"namespace_exists,omitempty"`
+	NamespaceLabels map[string]string `json:"namespace_labels,omitempty"`
+
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ServiceAccountRef names a service account, whether it still exists and the
+// workloads whose pods run as it
+type ServiceAccountRef struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Exists    *bool         `json:"exists,omitempty"`
+	Workloads []WorkloadRef `json:"workloads,omitempty"`
+}
+
+// WorkloadRef names a workload, such as a Deployment, and the operator that
+// manages it, if any
+type WorkloadRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Operator  string `json:"operator,omitempty"`
+}
+
+// String describes the workload for summaries, e.g. "the gpu-operator deployment"
+func (w WorkloadRef) String() string {
+	description := fmt.Sprintf("the %s %s", w.Name, strings.ToLower(w.Kind))
+	if w.Operator != "" {
+		description += fmt.Sprintf(" of operator %s", w.Operator)
+	}
+	return description
+}
+
+// EnrichmentStats counts the cluster lookups of an enrichment
+type EnrichmentStats struct {
+	EnrichedEntries int `json:"enriched_entries"`
+	Lookups         int `json:"lookups"`
+	CachedLookups   int `json:"cached_lookups"`
+	FailedLookups   int `json:"failed_lookups"`
+	// SkippedLookups were not made because the lookup limit was reached
+	SkippedLookups int `json:"skipped_lookups,omitempty"`
+}
+
+// Finding is an audit result, or one of its entries, flagged as notable
+// during an investigation, with a note. It keeps a copy of the flagged entry
+// and the result's summary, so it outlives the cached result.
+type Finding struct {
+	ID      string `json:"id"`
+	QueryID string `json:"query_id"`
+	Cluster string `json:"cluster,omitempty"`
+	// EntryIndex is the flagged entry's index in the result's parsed entries;
+	// nil when the whole result is flagged
+	EntryIndex *int                   `json:"entry_index,omitempty"`
+	Entry      map[string]interface{} `json:"entry,omitempty"`
+	Note       string                 `json:"note"`
+	Command    string                 `json:"command,omitempty"`
+	Summary    string                 `json:"summary,omitempty"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+// Alert is raised when a watch rule matches at least its threshold of events
+// within its window, for one value of its group_by field when it has one
+type Alert struct {
+	ID          string                   `json:"id"`
+	Rule        string                   `json:"rule"`
+	Description string                   `json:"description,omitempty"`
+	Severity    string                   `json:"severity"`
+	GroupBy     string                   `json:"group_by,omitempty"`
+	Group       string                   `json:"group,omitempty"`
+	Count       int                      `json:"count"`
+	Threshold   int                      `json:"threshold"`
+	Window      string                   `json:"window"`
+	FirstSeen   string                   `json:"first_seen"`
+	LastSeen    string                   `json:"last_seen"`
+	QueryID     string                   `json:"query_id,omitempty"`
+	Cluster     string                   `json:"cluster,omitempty"`
+	Events      []map[string]interface{} `json:"events"`
+	RaisedAt    string                   `json:"raised_at"`
+}
+
+// Indicator types
+const (
+	IndicatorTypeIP        = "ip"
+	IndicatorTypeUserAgent = "user-agent"
+)
+
+// Indicator is a threat intelligence indicator audit events are matched
+// against: an IP address or network, or a user agent
+type Indicator struct {
+	ID          string `json:"id,omitempty"`
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	// Source is the MISP event or STIX bundle file the indicator was loaded from
+	Source string `json:"source"`
+}
+
+// IndicatorMatch holds the audit events that matched one indicator
+type IndicatorMatch struct {
+	Indicator Indicator                `json:"indicator"`
+	Count     int                      `json:"count"`
+	FirstSeen string                   `json:"first_seen"`
+	LastSeen  string                   `json:"last_seen"`
+	Events    []map[string]interface{} `json:"events"`
+}
+
+// IndicatorMatchResult is the result of scanning a query's audit events for
+// indicator hits, grouped by indicator with the most matched first
+type IndicatorMatchResult struct {
+	QueryID        string           `json:"query_id"`
+	Params         AuditQueryParams `json:"params"`
+	Indicators     int              `json:"indicators"`
+	ScannedEntries int              `json:"scanned_entries"`
+	MatchedEntries int              `json:"matched_entries"`
+	Matches        []IndicatorMatch `json:"matches"`
+	Summary        string           `json:"summary"`
+}
+
+// ClusterConfig registers a cluster queries can select by name: the
+// kubeconfig context oc uses for it and, optionally, the kubeconfig file
+// holding that context
+type ClusterConfig struct {
+	Name       string `json:"name"`
+	Context    string `json:"context"`
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+}
+
+// SourceIPInfo classifies a source IP: the category of network it came from,
+// such as internal, cluster-pod or external, the matching network and its
+// label, and its GeoIP country and organization when a database is configured
+type SourceIPInfo struct {
+	IP           string `json:"ip"`
+	Category     string `json:"category,omitempty"`
+	Network      string `json:"network,omitempty"`
+	Label        string `json:"label,omitempty"`
+	Country      string `json:"country,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	External     bool   `json:"external"`
+}
+
+// SourceIPStats summarizes the source IP classification of a result
+type SourceIPStats struct {
+	Hooks           []string       `json:"hooks"`
+	ClassifiedIPs   int            `json:"classified_ips"`
+	Categories      map[string]int `json:"categories"`
+	ExternalIPs     []string       `json:"external_ips"`
+	ExternalEntries int            `json:"external_entries"`
+	Errors          []string       `json:"errors,omitempty"`
+}
+
+// AuditProfileDetail describes what an audit profile records
+type AuditProfileDetail struct {
+	Metadata           bool   `json:"metadata"`
+	WriteRequestBodies bool   `json:"write_request_bodies"`
+	ReadRequestBodies  bool   `json:"read_request_bodies"`
+	Description        string `json:"description"`
+}
+
+// AuditCustomRule is an audit profile applied to the requests of a group
+type AuditCustomRule struct {
+	Group   string             `json:"group"`
+	Profile string             `json:"profile"`
+	Detail  AuditProfileDetail `json:"detail"`
+}
+
+// QueryExplanation describes how a generated command selects audit events
+type QueryExplanation struct {
+	Command             string              `json:"command"`
+	Engine              string              `json:"engine"`
+	LogPath             string              `json:"log_path,omitempty"`
+	Filters             []FilterExplanation `json:"filters"`
+	Timeframe           string              `json:"timeframe,omitempty"`
+	TruncatedPatterns   []string            `json:"truncated_patterns,omitempty"`
+	TruncatedExclusions []string            `json:"truncated_exclusions,omitempty"`
+	Notes               []string            `json:"notes,omitempty"`
+}
+
+// Query estimate methods
+const (
+	// EstimateMethodBackendCount counts with the backend's own count API
+	EstimateMethodBackendCount = "backend_count"
+	// EstimateMethodFetchCount fetches the raw log and counts the matching
+	// lines without parsing, summarizing or caching them
+	EstimateMethodFetchCount = "fetch_count"
+)
+
+// QueryEstimate is how many events a query would return and how much log it
+// reads, measured without running the query
+type QueryEstimate struct {
+	Backend string `json:"backend"`
+	Command string `json:"command"`
+	Method  string `json:"method"`
+	// ScannedBytes and ScannedLines measure the log read; backend counts do
+	// not report them
+	ScannedBytes    int64 `json:"scanned_bytes"`
+	ScannedLines    int   `json:"scanned_lines"`
+	MatchingEntries int   `json:"matching_entries"`
+	MatchingBytes   int64 `json:"matching_bytes"`
+	// UpperBound is set when the count includes events the query's exact
+	// filters drop, as backends only narrow events with them
+	UpperBound  bool     `json:"upper_bound"`
+	MaxEntries  int      `json:"max_entries"`
+	NarrowFirst bool     `json:"narrow_first"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	// ExecutionTime is the time taken to estimate, in milliseconds
+	ExecutionTime int64  `json:"execution_time"`
+	Summary       string `json:"summary"`
+}
+
+// FilterExplanation describes a single filter stage or clause of a generated command
+type FilterExplanation struct {
+	Kind        string `json:"kind"`
+	Field       string `json:"field,omitempty"`
+	Expression  string `json:"expression"`
+	Description string `json:"description"`
+}
+
 // AuditLogEntry represents a structured audit log entry (interface version)
 type AuditLogEntry struct {
 	// Core fields
@@ -43,6 +1367,13 @@ type AuditLogEntry struct {
 	UserAgent  string   `json:"user_agent,omitempty"`
 	SourceIPs  []string `json:"source_ips,omitempty"`
 
+	// Event metadata
+	AuditID        string `json:"audit_id,omitempty"`
+	Stage          string `json:"stage,omitempty"`
+	Level          string `json:"level,omitempty"`
+	StageTimestamp string `json:"stage_timestamp,omitempty"`
+	Subresource    string `json:"subresource,omitempty"`
+
 	// Response fields
 	StatusCode    int    `json:"status_code,omitempty"`
 	StatusMessage string `json:"status_message,omitempty"`
@@ -51,8 +1382,20 @@ type AuditLogEntry struct {
 	// Authentication fields
 	AuthDecision     string `json:"auth_decision,omitempty"`
 	AuthzDecision    string `json:"authz_decision,omitempty"`
+	AuthzReason      string `json:"authz_reason,omitempty"`
 	ImpersonatedUser string `json:"impersonated_user,omitempty"`
 
+	// Derived fields, filled in by normalization
+	IsSystemUser     bool `json:"is_system_user"`
+	IsServiceAccount bool `json:"is_service_account"`
+
+	// Request and response bodies, only captured when the parser is
+	// configured to include objects. Bodies larger than the configured cap
+	// are dropped and listed in OmittedObjects instead.
+	RequestObject  map[string]interface{} `json:"request_object,omitempty"`
+	ResponseObject map[string]interface{} `json:"response_object,omitempty"`
+	OmittedObjects []string               `json:"omitted_objects,omitempty"`
+
 	// Additional fields
 	Annotations map[string]interface{} `json:"annotations,omitempty"`
 	Extra       map[string]interface{} `json:"extra,omitempty"`
@@ -62,6 +1405,10 @@ type AuditLogEntry struct {
 	RawLine     string   `json:"raw_line,omitempty"`
 	ParseErrors []string `json:"parse_errors,omitempty"`
 	ParseTime   string   `json:"parse_time,omitempty"`
+	// Truncated is set when RawLine holds only the start of a line longer
+	// than the parser's maximum, LineLength bytes long
+	Truncated  bool `json:"truncated,omitempty"`
+	LineLength int  `json:"line_length,omitempty"`
 }
 
 // ParseResult represents the result of parsing audit logs (interface version)
@@ -73,6 +1420,8 @@ type ParseResult struct {
 	ParseErrors []string         `json:"parse_errors"`
 	ParseTime   string           `json:"parse_time"`
 	Performance ParsePerformance `json:"performance"`
+	// TruncatedLines counts the parsed lines whose raw line was truncated
+	TruncatedLines int `json:"truncated_lines"`
 }
 
 // ParsePerformance tracks parsing performance metrics (interface version)
@@ -89,6 +1438,35 @@ type ParserConfig struct {
 	Timeout          string `json:"timeout"`
 	EnableValidation bool   `json:"enable_validation"`
 	EnableMetrics    bool   `json:"enable_metrics"`
+	IncludeObjects   bool   `json:"include_objects"`
+	MaxObjectSize    int    `json:"max_object_size"`
+	DisableFallback  bool   `json:"disable_fallback"`
+	Strict           bool   `json:"strict"`
+	QuarantineLines  int    `json:"quarantine_lines"`
+}
+
+// QuarantinedLine is an audit log line the parser rejected, redacted and
+// truncated, with the query it was read by
+type QuarantinedLine struct {
+	QueryID       string `json:"query_id"`
+	Cluster       string `json:"cluster,omitempty"`
+	LineNumber    int    `json:"line_number"`
+	Error         string `json:"error"`
+	Line          string `json:"line"`
+	Length        int    `json:"length"`
+	Truncated     bool   `json:"truncated,omitempty"`
+	QuarantinedAt string `json:"quarantined_at"`
+}
+
+// ParseErrorsReport lists the quarantined lines: Capacity is the number of
+// most recent lines kept, 0 when quarantine is disabled, and Total counts
+// the lines quarantined since the server started or the buffer was cleared
+type ParseErrorsReport struct {
+	Enabled  bool              `json:"enabled"`
+	Capacity int               `json:"capacity"`
+	Total    int               `json:"total_quarantined"`
+	Lines    []QuarantinedLine `json:"lines"`
+	Cleared  int               `json:"cleared,omitempty"`
 }
 
 // EnhancedAuditResult represents the enhanced audit query result with structured parsing
@@ -121,6 +1499,8 @@ func DefaultParserConfiguration() ParserConfiguration {
 			Timeout:          "30s",
 			EnableValidation: true,
 			EnableMetrics:    true,
+			IncludeObjects:   false,
+			MaxObjectSize:    64 * 1024, // 64KB
 		},
 		EnableLegacy:  true,
 		EnableMetrics: true,
@@ -138,6 +1518,8 @@ type AuditQueryConfig struct {
 	ForceSimple          bool          `json:"force_simple" default:"true"` // New default for reliability
 	EnableFileDiscovery  bool          `json:"enable_file_discovery" default:"false"`
 	MaxConcurrentQueries int           `json:"max_concurrent_queries" default:"5"`
+	MaxPatterns          int           `json:"max_patterns" default:"10"`   // 0 or less disables the limit
+	MaxExclusions        int           `json:"max_exclusions" default:"10"` // 0 or less disables the limit
 }
 
 // EnvironmentInfo represents information about the OpenShift environment
@@ -168,13 +1550,30 @@ type MigrationConfig struct {
 	FallbackOnError     bool `json:"fallback_on_error" default:"true"`
 }
 
-// CircuitBreaker represents a circuit breaker pattern for command execution
+// Default circuit breaker settings
+const (
+	DefaultCircuitFailureThreshold = 3
+	DefaultCircuitResetTimeout     = 30 * time.Second
+)
+
+// CircuitBreaker represents a circuit breaker pattern for command execution.
+// Use the methods rather than the fields when the breaker is shared, since they
+// hold the breaker's lock.
 type CircuitBreaker struct {
 	FailureThreshold int           `json:"failure_threshold"`
 	ResetTimeout     time.Duration `json:"reset_timeout"`
 	State            CircuitState  `json:"state"`
 	FailureCount     int           `json:"failure_count"`
 	LastFailureTime  time.Time     `json:"last_failure_time"`
+
+	// Totals since start: times the breaker opened, calls rejected while open,
+	// and recorded failures and successes
+	Trips          int64 `json:"trips"`
+	Rejections     int64 `json:"rejections"`
+	TotalFailures  int64 `json:"total_failures"`
+	TotalSuccesses int64 `json:"total_successes"`
+
+	mutex sync.Mutex
 }
 
 // CircuitState represents the state of a circuit breaker
@@ -186,6 +1585,169 @@ const (
 	CircuitStateHalfOpen CircuitState = "half_open"
 )
 
+// CircuitBreakerStatus is a snapshot of a circuit breaker for statistics
+type CircuitBreakerStatus struct {
+	State            CircuitState `json:"state"`
+	FailureCount     int          `json:"failure_count"`
+	FailureThreshold int          `json:"failure_threshold"`
+	ResetTimeout     string       `json:"reset_timeout"`
+	LastFailureTime  string       `json:"last_failure_time,omitempty"`
+	// RetryAt is when an open breaker lets the next call through
+	RetryAt        string `json:"retry_at,omitempty"`
+	Trips          int64  `json:"trips"`
+	Rejections     int64  `json:"rejections"`
+	TotalFailures  int64  `json:"total_failures"`
+	TotalSuccesses int64  `json:"total_successes"`
+}
+
+// InFlightQuery is a query execution still running, which can be cancelled
+type InFlightQuery struct {
+	QueryID   string `json:"query_id"`
+	Command   string `json:"command"`
+	Cluster   string `json:"cluster,omitempty"`
+	StartedAt string `json:"started_at"`
+	RunningMs int64  `json:"running_ms"`
+}
+
+// ConfigReloadResult reports the configuration files reloaded by an operator:
+// the number of query templates and indicators now loaded, and the files
+// that failed to load and kept their previous contents
+type ConfigReloadResult struct {
+	QueryTemplates int      `json:"query_templates"`
+	Indicators     int      `json:"indicators"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// PreflightStatus is the outcome of a preflight check, or of all of them
+type PreflightStatus string
+
+// Preflight statuses
+const (
+	PreflightPass PreflightStatus = "pass"
+	PreflightWarn PreflightStatus = "warn"
+	PreflightFail PreflightStatus = "fail"
+	// PreflightSkip marks a check not run because a check it needs failed
+	PreflightSkip PreflightStatus = "skip"
+)
+
+// PreflightCheck is the outcome of one check of the environment the server
+// runs in
+type PreflightCheck struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Status      PreflightStatus `json:"status"`
+	Detail      string          `json:"detail,omitempty"`
+	// Remediation says how to fix a failed or warning check
+	Remediation string `json:"remediation,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// PreflightReport is the outcome of the preflight checks; its status is fail
+// when a check failed, warn when one warned and pass otherwise
+type PreflightReport struct {
+	Status    PreflightStatus  `json:"status"`
+	Summary   string           `json:"summary"`
+	Checks    []PreflightCheck `json:"checks"`
+	CheckedAt string           `json:"checked_at"`
+}
+
+// NewCircuitBreaker creates a closed circuit breaker that opens after
+// failureThreshold consecutive failures and half-opens after resetTimeout
+func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		ResetTimeout:     resetTimeout,
+		State:            CircuitStateClosed,
+	}
+}
+
+// Configure changes the failure threshold and reset timeout
+func (cb *CircuitBreaker) Configure(failureThreshold int, resetTimeout time.Duration) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.FailureThreshold = failureThreshold
+	cb.ResetTimeout = resetTimeout
+}
+
+// Allow reports whether a call may proceed. An open breaker rejects calls until
+// the reset timeout has passed since the last failure, then half-opens to let
+// calls through until the next success or failure decides its state.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.State == CircuitStateOpen {
+		if time.Since(cb.LastFailureTime) < cb.ResetTimeout {
+			cb.Rejections++
+			return false
+		}
+		cb.State = CircuitStateHalfOpen
+	}
+	return true
+}
+
+// RecordFailure counts a failure, opening the breaker at the threshold
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.FailureCount++
+	cb.TotalFailures++
+	cb.LastFailureTime = time.Now()
+
+	if cb.FailureCount >= cb.FailureThreshold && cb.State != CircuitStateOpen {
+		cb.State = CircuitStateOpen
+		cb.Trips++
+	}
+}
+
+// RecordSuccess clears the failure count and closes a half-open breaker
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.FailureCount = 0
+	cb.TotalSuccesses++
+	if cb.State == CircuitStateHalfOpen {
+		cb.State = CircuitStateClosed
+	}
+}
+
+// Reset closes the breaker and clears the failure count, keeping the totals
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.State = CircuitStateClosed
+	cb.FailureCount = 0
+	cb.LastFailureTime = time.Time{}
+}
+
+// Status returns a snapshot of the breaker
+func (cb *CircuitBreaker) Status() CircuitBreakerStatus {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	status := CircuitBreakerStatus{
+		State:            cb.State,
+		FailureCount:     cb.FailureCount,
+		FailureThreshold: cb.FailureThreshold,
+		ResetTimeout:     cb.ResetTimeout.String(),
+		Trips:            cb.Trips,
+		Rejections:       cb.Rejections,
+		TotalFailures:    cb.TotalFailures,
+		TotalSuccesses:   cb.TotalSuccesses,
+	}
+	if !cb.LastFailureTime.IsZero() {
+		status.LastFailureTime = cb.LastFailureTime.Format(time.RFC3339)
+	}
+	if cb.State == CircuitStateOpen {
+		status.RetryAt = cb.LastFailureTime.Add(cb.ResetTimeout).Format(time.RFC3339)
+	}
+	return status
+}
+
 // LogFileInfo represents information about a log file
 type LogFileInfo struct {
 	Path      string    `json:"path"`
@@ -210,6 +1772,12 @@ type DiscoveryConfig struct {
 	FallbackFiles   []string      `json:"fallback_files"`
 }
 
+// Default complexity limits for free-text patterns and exclusions
+const (
+	DefaultMaxPatterns   = 10
+	DefaultMaxExclusions = 10
+)
+
 // DefaultAuditQueryConfig returns the default audit query configuration
 func DefaultAuditQueryConfig() AuditQueryConfig {
 	return AuditQueryConfig{
@@ -221,6 +1789,8 @@ func DefaultAuditQueryConfig() AuditQueryConfig {
 		ForceSimple:          true, // Default to simple for reliability
 		EnableFileDiscovery:  false,
 		MaxConcurrentQueries: 5,
+		MaxPatterns:          DefaultMaxPatterns,
+		MaxExclusions:        DefaultMaxExclusions,
 	}
 }
 
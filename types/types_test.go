@@ -1548,3 +1548,77 @@ func TestMCPTypes_EdgeCases(t *testing.T) {
 		t.Errorf("Failed to marshal unicode request: %v", err)
 	}
 }
+
+// TestFilterExpression_String tests the readable rendering of filter expressions
+func TestFilterExpression_String(t *testing.T) {
+	expr := FilterExpression{
+		Operator: FilterOperatorAnd,
+		Children: []FilterExpression{
+			{Operator: FilterOperatorOr, Children: []FilterExpression{{Pattern: "delete"}, {Pattern: "patch"}}},
+			{Operator: FilterOperatorNot, Children: []FilterExpression{{Pattern: "system:"}}},
+		},
+	}
+
+	expected := `(("delete" OR "patch") AND NOT ("system:"))`
+	if got := expr.String(); got != expected {
+		t.Errorf("FilterExpression.String() = %s, want %s", got, expected)
+	}
+
+	// Filter expressions round-trip through JSON
+	data, err := json.Marshal(AuditQueryParams{LogSource: "kube-apiserver", Filter: &expr})
+	if err != nil {
+		t.Fatalf("Failed to marshal params: %v", err)
+	}
+	var decoded AuditQueryParams
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal params: %v", err)
+	}
+	if decoded.Filter == nil || decoded.Filter.String() != expected {
+		t.Errorf("Filter did not round-trip through JSON: %+v", decoded.Filter)
+	}
+}
+
+// TestCircuitBreaker_Transitions tests opening, rejecting, half-opening and resetting the breaker
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Hour)
+
+	cb.RecordFailure()
+	if !cb.Allow() {
+		t.Fatal("Expected the breaker to stay closed below the threshold")
+	}
+	cb.RecordFailure()
+	if cb.Allow() {
+		t.Fatal("Expected the breaker to open at the threshold")
+	}
+
+	status := cb.Status()
+	if status.State != CircuitStateOpen || status.Trips != 1 || status.Rejections != 1 || status.TotalFailures != 2 {
+		t.Errorf("Unexpected status after opening: %+v", status)
+	}
+	if status.RetryAt == "" || status.ResetTimeout != "1h0m0s" {
+		t.Errorf("Expected retry time and reset timeout, got %+v", status)
+	}
+
+	// A short reset timeout lets the next call through half-open
+	cb.Configure(2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if !cb.Allow() || cb.Status().State != CircuitStateHalfOpen {
+		t.Fatalf("Expected the breaker to half-open, got %+v", cb.Status())
+	}
+	cb.RecordSuccess()
+	if status := cb.Status(); status.State != CircuitStateClosed || status.FailureCount != 0 || status.TotalSuccesses != 1 {
+		t.Errorf("Expected the breaker to close after a success, got %+v", status)
+	}
+
+	// Reset closes an open breaker but keeps the totals
+	cb.Configure(1, time.Hour)
+	cb.RecordFailure()
+	cb.Reset()
+	status = cb.Status()
+	if status.State != CircuitStateClosed || status.FailureCount != 0 || status.LastFailureTime != "" || status.Trips != 2 {
+		t.Errorf("Unexpected status after reset: %+v", status)
+	}
+	if !cb.Allow() {
+		t.Error("Expected a reset breaker to allow calls")
+	}
+}
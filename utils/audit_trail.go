@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -23,6 +22,13 @@ type AuditTrailEntry struct {
 	IPAddress     string                 `json:"ip_address,omitempty"`
 	UserAgent     string                 `json:"user_agent,omitempty"`
 	ExecutionTime int64                  `json:"execution_time_ms"`
+
+	// Hash chaining: Sequence numbers records, PrevHash is the previous record's
+	// Hash and Hash is the SHA-256 of this record serialized without Hash. Hash
+	// must remain the last field so it is always serialized last.
+	Sequence int64  `json:"seq,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
 }
 
 // AuditTrail provides audit logging functionality
@@ -30,7 +36,18 @@ type AuditTrail struct {
 	filePath string
 	mutex    sync.Mutex
 	file     *os.File
-	encoder  *json.Encoder
+
+	// syslog mirrors every entry to a syslog endpoint when set
+	syslog *SyslogWriter
+
+	// retention rotates and prunes the file; size and openedAt track the current file
+	retention AuditTrailRetention
+	size      int64
+	openedAt  time.Time
+
+	// lastSequence and lastHash identify the newest record, which the next record links to
+	lastSequence int64
+	lastHash     string
 }
 
 // NewAuditTrail creates a new audit trail instance
@@ -46,21 +63,55 @@ func NewAuditTrail(filePath string) (*AuditTrail, error) {
 		return nil, fmt.Errorf("failed to create audit trail directory: %w", err)
 	}
 
-	// Open or create the audit trail file
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open audit trail file: %w", err)
-	}
-
 	trail := &AuditTrail{
 		filePath: filePath,
-		file:     file,
-		encoder:  json.NewEncoder(file),
+	}
+	if err := trail.open(); err != nil {
+		return nil, err
+	}
+	if err := trail.loadChainHead(); err != nil {
+		trail.file.Close()
+		return nil, err
 	}
 
 	return trail, nil
 }
 
+// open opens or creates the audit trail file for appending
+func (at *AuditTrail) open() error {
+	file, err := os.OpenFile(at.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit trail file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit trail file: %w", err)
+	}
+
+	at.file = file
+	at.size = info.Size()
+	at.openedAt = time.Now()
+	return nil
+}
+
+// NewSyslogAuditTrail creates an audit trail that only sends entries to syslog,
+// for environments that do not allow local audit files
+func NewSyslogAuditTrail(writer *SyslogWriter) (*AuditTrail, error) {
+	if writer == nil {
+		return nil, fmt.Errorf("syslog writer cannot be nil")
+	}
+	return &AuditTrail{syslog: writer}, nil
+}
+
+// SetSyslog mirrors entries to a syslog endpoint in addition to the audit trail file
+func (at *AuditTrail) SetSyslog(writer *SyslogWriter) {
+	at.mutex.Lock()
+	defer at.mutex.Unlock()
+
+	at.syslog = writer
+}
+
 // LogQuery logs an audit query execution
 func (at *AuditTrail) LogQuery(entry AuditTrailEntry) error {
 	at.mutex.Lock()
@@ -71,14 +122,37 @@ func (at *AuditTrail) LogQuery(entry AuditTrailEntry) error {
 		entry.Timestamp = time.Now().Format(time.RFC3339)
 	}
 
-	// Write the entry as a JSON line
-	if err := at.encoder.Encode(entry); err != nil {
-		return fmt.Errorf("failed to encode audit trail entry: %w", err)
+	if at.file != nil && at.needsRotation() {
+		if err := at.rotate(); err != nil {
+			return err
+		}
 	}
 
-	// Flush to ensure data is written
-	if err := at.file.Sync(); err != nil {
-		return fmt.Errorf("failed to sync audit trail file: %w", err)
+	// Link the entry to the previous record so tampering can be detected
+	line, err := at.chainEntry(&entry)
+	if err != nil {
+		return err
+	}
+
+	if at.file != nil {
+		// Write the entry as a JSON line
+		if _, err := at.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write audit trail entry: %w", err)
+		}
+		at.size += int64(len(line)) + 1
+
+		// Flush to ensure data is written
+		if err := at.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync audit trail file: %w", err)
+		}
+	}
+	at.lastSequence = entry.Sequence
+	at.lastHash = entry.Hash
+
+	if at.syslog != nil {
+		if err := at.syslog.WriteAuditTrailEntry(entry); err != nil {
+			return fmt.Errorf("failed to send audit trail entry to syslog: %w", err)
+		}
 	}
 
 	return nil
@@ -185,11 +259,34 @@ func (at *AuditTrail) LogCacheAccess(queryID string, action string, userID, ipAd
 	return at.LogQuery(entry)
 }
 
+// LogConfigChange logs a change of a component's runtime configuration, with
+// the settings before and after it and what triggered it, such as a tool
+func (at *AuditTrail) LogConfigChange(component, trigger string, previous, current interface{}, userID, ipAddress, userAgent string) error {
+	entry := AuditTrailEntry{
+		Timestamp: time.Now().Format(time.RFC3339),
+		UserID:    userID,
+		Action:    "config_change",
+		Parameters: map[string]interface{}{
+			"component": component,
+			"trigger":   trigger,
+			"previous":  previous,
+			"current":   current,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+
+	return at.LogQuery(entry)
+}
+
 // Close closes the audit trail file
 func (at *AuditTrail) Close() error {
 	at.mutex.Lock()
 	defer at.mutex.Unlock()
 
+	if at.syslog != nil {
+		at.syslog.Close()
+	}
 	if at.file != nil {
 		return at.file.Close()
 	}
@@ -198,7 +295,7 @@ func (at *AuditTrail) Close() error {
 
 // paramsToMap converts AuditQueryParams to a map for logging
 func paramsToMap(params types.AuditQueryParams) map[string]interface{} {
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"log_source": params.LogSource,
 		"patterns":   params.Patterns,
 		"timeframe":  params.Timeframe,
@@ -208,4 +305,88 @@ func paramsToMap(params types.AuditQueryParams) map[string]interface{} {
 		"verb":       params.Verb,
 		"namespace":  params.Namespace,
 	}
+	if params.StatusCode != 0 {
+		result["status_code"] = params.StatusCode
+	}
+	if params.StatusCodeRange != "" {
+		result["status_code_range"] = params.StatusCodeRange
+	}
+	for key, values := range map[string][]string{
+		"usernames":          params.Usernames,
+		"resources":          params.Resources,
+		"verbs":              params.Verbs,
+		"namespaces":         params.Namespaces,
+		"subresources":       params.Subresources,
+		"exclude_users":      params.ExcludeUsers,
+		"exclude_namespaces": params.ExcludeNamespaces,
+		"exclude_verbs":      params.ExcludeVerbs,
+		"exclude_resources":  params.ExcludeResources,
+	} {
+		if len(values) > 0 {
+			result[key] = values
+		}
+	}
+	if len(params.Groups) > 0 {
+		result["groups"] = params.Groups
+	}
+	if params.ImpersonatedUser != "" {
+		result["impersonated_user"] = params.ImpersonatedUser
+	}
+	if params.UserAgent != "" {
+		result["user_agent"] = params.UserAgent
+	}
+	if params.Stage != "" {
+		result["stage"] = params.Stage
+	}
+	if params.Level != "" {
+		result["level"] = params.Level
+	}
+	if params.SourceIP != "" {
+		result["source_ip"] = params.SourceIP
+	}
+	if params.SourceCIDR != "" {
+		result["source_cidr"] = params.SourceCIDR
+	}
+	if params.SortBy != "" {
+		result["sort_by"] = params.SortBy
+	}
+	if params.SortOrder != "" {
+		result["sort_order"] = params.SortOrder
+	}
+	if params.Limit != 0 {
+		result["limit"] = params.Limit
+	}
+	if params.Offset != 0 {
+		result["offset"] = params.Offset
+	}
+	if params.SampleRate != 0 {
+		result["sample_rate"] = params.SampleRate
+	}
+	if params.SampleSize != 0 {
+		result["sample_size"] = params.SampleSize
+	}
+	if params.OutputMode != "" {
+		result["output_mode"] = params.OutputMode
+	}
+	if params.BucketSize != "" {
+		result["bucket_size"] = params.BucketSize
+	}
+	if params.SummaryMode != "" {
+		result["summary_mode"] = params.SummaryMode
+	}
+	for key, mode := range map[string]types.MatchMode{
+		"username_match":   params.UsernameMatch,
+		"resource_match":   params.ResourceMatch,
+		"verb_match":       params.VerbMatch,
+		"namespace_match":  params.NamespaceMatch,
+		"user_agent_match": params.UserAgentMatch,
+	} {
+		if mode != "" {
+			result[key] = string(mode)
+		}
+	}
+	if params.Filter != nil {
+		result["filter"] = params.Filter.String()
+	}
+	return result
 }
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Audit trail integrity issue types
+const (
+	IntegrityModified   = "modified"    // the record's content no longer matches its hash
+	IntegrityGap        = "gap"         // sequence numbers are missing between two records
+	IntegrityBrokenLink = "broken_link" // prev_hash does not match the previous record's hash
+	IntegrityRestart    = "restart"     // a new chain starts after chained records
+	IntegrityMalformed  = "malformed"   // the line is not a valid trail entry
+	IntegrityUnchained  = "unchained"   // the record has no hash, e.g. it predates hash chaining
+)
+
+// AuditTrailIssue describes one integrity problem found while verifying the trail
+type AuditTrailIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Sequence int64  `json:"seq,omitempty"`
+	Type     string `json:"type"`
+	Detail   string `json:"detail"`
+}
+
+// AuditTrailVerification is the result of validating the hash chain
+type AuditTrailVerification struct {
+	Valid          bool              `json:"valid"`
+	FilesChecked   int               `json:"files_checked"`
+	RecordsChecked int               `json:"records_checked"`
+	FirstSequence  int64             `json:"first_seq,omitempty"`
+	LastSequence   int64             `json:"last_seq,omitempty"`
+	Unchained      int               `json:"unchained,omitempty"`
+	Issues         []AuditTrailIssue `json:"issues"`
+	Summary        string            `json:"summary"`
+}
+
+// hashSuffix matches the hash field, which is always serialized last
+var hashSuffix = regexp.MustCompile(`,"hash":"([0-9a-f]{64})"}$`)
+
+// chainEntry assigns the next sequence number, links the entry to the previous
+// record and returns the serialized entry; the caller must hold the mutex and
+// advance the chain once the record is written. The hash covers the entry's JSON
+// without the hash field, so it can be checked against the exact bytes written.
+func (at *AuditTrail) chainEntry(entry *AuditTrailEntry) ([]byte, error) {
+	entry.Sequence = at.lastSequence + 1
+	entry.PrevHash = at.lastHash
+	entry.Hash = ""
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit trail entry: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	entry.Hash = hex.EncodeToString(sum[:])
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit trail entry: %w", err)
+	}
+	return line, nil
+}
+
+// loadChainHead continues the chain from the last record of the current file, or
+// of the newest rotated file when the current file is empty
+func (at *AuditTrail) loadChainHead() error {
+	files, err := at.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	files = append(files, at.filePath)
+
+	for i := len(files) - 1; i >= 0; i-- {
+		found := false
+		err := scanAuditTrailLines(files[i], func(_ int, line []byte) {
+			var entry AuditTrailEntry
+			if json.Unmarshal(line, &entry) == nil && entry.Hash != "" {
+				at.lastSequence = entry.Sequence
+				at.lastHash = entry.Hash
+				found = true
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if found {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Verify validates the hash chain across the rotated and current trail files
+func (at *AuditTrail) Verify() (*AuditTrailVerification, error) {
+	at.mutex.Lock()
+	defer at.mutex.Unlock()
+
+	if at.file == nil {
+		return nil, fmt.Errorf("audit trail has no local file to verify")
+	}
+
+	files, err := at.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return VerifyAuditTrailFiles(append(files, at.filePath))
+}
+
+// VerifyAuditTrail validates the hash chain of the trail at filePath, including
+// its rotated files, without opening it for writing
+func VerifyAuditTrail(filePath string) (*AuditTrailVerification, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, fmt.Errorf("failed to open audit trail file: %w", err)
+	}
+	trail := &AuditTrail{filePath: filePath}
+	files, err := trail.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return VerifyAuditTrailFiles(append(files, filePath))
+}
+
+// VerifyAuditTrailFiles validates the hash chain across files given oldest first.
+// Every record must match its own hash, link to the previous record's hash and
+// carry the next sequence number. A chain that starts mid-sequence in the first
+// file is accepted, since older files may have been removed by retention.
+func VerifyAuditTrailFiles(files []string) (*AuditTrailVerification, error) {
+	result := &AuditTrailVerification{Issues: []AuditTrailIssue{}}
+
+	var lastHash string
+	var lastSequence int64
+	for _, file := range files {
+		err := scanAuditTrailLines(file, func(lineNumber int, line []byte) {
+			issue := func(sequence int64, issueType, detail string) {
+				result.Issues = append(result.Issues, AuditTrailIssue{
+					File: file, Line: lineNumber, Sequence: sequence, Type: issueType, Detail: detail,
+				})
+			}
+
+			var entry AuditTrailEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				issue(0, IntegrityMalformed, err.Error())
+				return
+			}
+			result.RecordsChecked++
+
+			match := hashSuffix.FindSubmatchIndex(line)
+			if entry.Hash == "" || match == nil {
+				result.Unchained++
+				if lastHash != "" {
+					issue(0, IntegrityUnchained, "record without a hash after chained records")
+				}
+				return
+			}
+
+			body := append(append([]byte{}, line[:match[0]]...), '}')
+			sum := sha256.Sum256(body)
+			if hex.EncodeToString(sum[:]) != entry.Hash {
+				issue(entry.Sequence, IntegrityModified, "record content does not match its hash")
+			}
+
+			switch {
+			case result.FirstSequence == 0:
+				result.FirstSequence = entry.Sequence
+			case entry.PrevHash == "" && entry.Sequence == 1:
+				issue(entry.Sequence, IntegrityRestart, fmt.Sprintf("new chain started after seq %d", lastSequence))
+			case entry.Sequence > lastSequence+1:
+				issue(entry.Sequence, IntegrityGap, fmt.Sprintf("records %d-%d are missing", lastSequence+1, entry.Sequence-1))
+			case entry.Sequence <= lastSequence:
+				issue(entry.Sequence, IntegrityGap, fmt.Sprintf("seq %d does not follow seq %d", entry.Sequence, lastSequence))
+			case entry.PrevHash != lastHash:
+				issue(entry.Sequence, IntegrityBrokenLink, "prev_hash does not match the previous record")
+			}
+
+			lastHash = entry.Hash
+			lastSequence = entry.Sequence
+			result.LastSequence = entry.Sequence
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.FilesChecked++
+	}
+
+	// Unchained records are only reported as issues when they follow chained ones
+	result.Valid = len(result.Issues) == 0
+	result.Summary = summarizeVerification(result)
+	return result, nil
+}
+
+// summarizeVerification describes the verification result in one sentence
+func summarizeVerification(result *AuditTrailVerification) string {
+	if result.RecordsChecked == 0 {
+		return "Audit trail is empty"
+	}
+	summary := fmt.Sprintf("Checked %d records in %d files", result.RecordsChecked, result.FilesChecked)
+	if result.LastSequence > 0 {
+		summary += fmt.Sprintf(" (seq %d-%d)", result.FirstSequence, result.LastSequence)
+	}
+	if result.Valid {
+		summary += ": hash chain is intact"
+	} else {
+		counts := make(map[string]int)
+		var order []string
+		for _, issue := range result.Issues {
+			if counts[issue.Type] == 0 {
+				order = append(order, issue.Type)
+			}
+			counts[issue.Type]++
+		}
+		var parts []string
+		for _, issueType := range order {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[issueType], issueType))
+		}
+		summary += ": integrity check FAILED (" + strings.Join(parts, ", ") + ")"
+	}
+	if result.Unchained > 0 {
+		summary += fmt.Sprintf("; %d records have no hash", result.Unchained)
+	}
+	return summary
+}
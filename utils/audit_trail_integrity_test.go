@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeChainedTrail logs n cache entries to a new trail and closes it
+func writeChainedTrail(t *testing.T, filePath string, n int) {
+	t.Helper()
+	trail, err := NewAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Failed to create audit trail: %v", err)
+	}
+	defer trail.Close()
+
+	for i := 0; i < n; i++ {
+		if err := trail.LogCacheAccess("q"+string(rune('1'+i)), "hit", "", "", ""); err != nil {
+			t.Fatalf("Failed to log entry: %v", err)
+		}
+	}
+}
+
+// readTrailLines returns the lines of a trail file
+func readTrailLines(t *testing.T, filePath string) []string {
+	t.Helper()
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read trail: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+// writeTrailLines replaces the content of a trail file
+func writeTrailLines(t *testing.T, filePath string, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write trail: %v", err)
+	}
+}
+
+// TestAuditTrail_HashChain tests that records are sequenced and linked, including across reopening
+func TestAuditTrail_HashChain(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+	writeChainedTrail(t, filePath, 2)
+	// Reopening continues the chain from the last record
+	writeChainedTrail(t, filePath, 1)
+
+	lines := readTrailLines(t, filePath)
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], `"seq":1,"hash":"`) || strings.Contains(lines[0], "prev_hash") {
+		t.Errorf("Expected the first record to start the chain, got %s", lines[0])
+	}
+	if !strings.Contains(lines[2], `"seq":3,"prev_hash":"`) {
+		t.Errorf("Expected the third record to continue the chain, got %s", lines[2])
+	}
+
+	result, err := VerifyAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Valid || result.RecordsChecked != 3 || result.FirstSequence != 1 || result.LastSequence != 3 {
+		t.Errorf("Expected an intact chain of 3 records, got %+v", result)
+	}
+	if !strings.Contains(result.Summary, "hash chain is intact") {
+		t.Errorf("Unexpected summary %q", result.Summary)
+	}
+}
+
+// TestVerifyAuditTrail_Tampering tests that modified, removed and reordered records are reported
+func TestVerifyAuditTrail_Tampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(lines []string) []string
+		expected string
+	}{
+		{
+			name: "modified record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"query_id":"q2"`, `"query_id":"qX"`, 1)
+				return lines
+			},
+			expected: IntegrityModified,
+		},
+		{
+			name: "modified record with recomputed hash",
+			tamper: func(lines []string) []string {
+				line := strings.Replace(lines[1], `"query_id":"q2"`, `"query_id":"qX"`, 1)
+				match := hashSuffix.FindStringIndex(line)
+				sum := sha256.Sum256([]byte(line[:match[0]] + "}"))
+				lines[1] = line[:match[0]] + `,"hash":"` + hex.EncodeToString(sum[:]) + `"}`
+				return lines
+			},
+			expected: IntegrityBrokenLink,
+		},
+		{
+			name: "removed record",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			expected: IntegrityGap,
+		},
+		{
+			name: "inserted unchained record",
+			tamper: func(lines []string) []string {
+				return append(lines, `{"timestamp":"2024-01-15T10:00:00Z","query_id":"qX","action":"cache_hit","parameters":null,"execution_time_ms":0}`)
+			},
+			expected: IntegrityUnchained,
+		},
+		{
+			name: "malformed line",
+			tamper: func(lines []string) []string {
+				return append(lines, "not json")
+			},
+			expected: IntegrityMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+			writeChainedTrail(t, filePath, 3)
+			writeTrailLines(t, filePath, tt.tamper(readTrailLines(t, filePath)))
+
+			result, err := VerifyAuditTrail(filePath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Valid {
+				t.Fatalf("Expected verification to fail, got %+v", result)
+			}
+			if len(result.Issues) != 1 || result.Issues[0].Type != tt.expected {
+				t.Errorf("Expected a single %s issue, got %+v", tt.expected, result.Issues)
+			}
+			if !strings.Contains(result.Summary, "FAILED") {
+				t.Errorf("Unexpected summary %q", result.Summary)
+			}
+		})
+	}
+}
+
+// TestVerifyAuditTrail_AcrossRotation tests that the chain spans rotated files and survives pruning
+func TestVerifyAuditTrail_AcrossRotation(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+	trail, err := NewAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Failed to create audit trail: %v", err)
+	}
+	defer trail.Close()
+
+	if err := trail.SetRetention(AuditTrailRetention{MaxSizeBytes: 1, MaxBackups: 2, Compress: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := trail.LogCacheAccess("q", "hit", "", "", ""); err != nil {
+			t.Fatalf("Failed to log entry: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	result, err := trail.Verify()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Records 1 and 2 were pruned; the chain starting at 3 is accepted
+	if !result.Valid || result.FilesChecked != 3 || result.FirstSequence != 3 || result.LastSequence != 5 {
+		t.Errorf("Expected a valid chain of records 3-5 across 3 files, got %+v", result)
+	}
+}
+
+// TestVerifyAuditTrail_LegacyRecords tests that records written before hash chaining are counted but accepted
+func TestVerifyAuditTrail_LegacyRecords(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+	writeTrailLines(t, filePath, []string{
+		`{"timestamp":"2024-01-15T10:00:00Z","query_id":"old","action":"cache_hit","parameters":null,"execution_time_ms":0}`,
+	})
+	writeChainedTrail(t, filePath, 2)
+
+	result, err := VerifyAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Valid || result.Unchained != 1 || result.RecordsChecked != 3 {
+		t.Errorf("Expected a valid chain with 1 legacy record, got %+v", result)
+	}
+
+	if _, err := VerifyAuditTrail(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for a missing trail")
+	}
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAuditTrailQueryLimit is the number of entries a query returns when no limit is set
+const DefaultAuditTrailQueryLimit = 100
+
+// AuditTrailQuery selects audit trail entries. Empty fields match every entry.
+type AuditTrailQuery struct {
+	UserID  string
+	QueryID string
+	// Action is the trail action, such as complete_query or cache_hit
+	Action string
+	Since  time.Time
+	Until  time.Time
+	// Limit keeps the most recent matching entries; DefaultAuditTrailQueryLimit when zero
+	Limit int
+}
+
+// AuditTrailQueryResult holds the matching entries in chronological order
+type AuditTrailQueryResult struct {
+	Entries      []AuditTrailEntry `json:"entries"`
+	TotalMatches int               `json:"total_matches"`
+	FilesScanned int               `json:"files_scanned"`
+	// Malformed counts lines that could not be decoded
+	Malformed int `json:"malformed,omitempty"`
+}
+
+// matches reports whether an entry satisfies the query
+func (q AuditTrailQuery) matches(entry AuditTrailEntry) bool {
+	if q.UserID != "" && entry.UserID != q.UserID {
+		return false
+	}
+	if q.QueryID != "" && entry.QueryID != q.QueryID {
+		return false
+	}
+	if q.Action != "" && entry.Action != q.Action {
+		return false
+	}
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		timestamp, err := time.Parse(time.RFC3339, entry.Timestamp)
+		if err != nil {
+			return false
+		}
+		if !q.Since.IsZero() && timestamp.Before(q.Since) {
+			return false
+		}
+		if !q.Until.IsZero() && timestamp.After(q.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query searches the current audit trail file and its rotated (optionally
+// gzipped) predecessors
+func (at *AuditTrail) Query(query AuditTrailQuery) (*AuditTrailQueryResult, error) {
+	at.mutex.Lock()
+	defer at.mutex.Unlock()
+
+	if at.file == nil {
+		return nil, fmt.Errorf("audit trail has no local file to query")
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultAuditTrailQueryLimit
+	}
+
+	files, err := at.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, at.filePath)
+
+	result := &AuditTrailQueryResult{Entries: []AuditTrailEntry{}}
+	for _, path := range files {
+		if err := scanAuditTrailFile(path, func(entry AuditTrailEntry) {
+			if !query.matches(entry) {
+				return
+			}
+			result.TotalMatches++
+			result.Entries = append(result.Entries, entry)
+			if len(result.Entries) > limit {
+				result.Entries = result.Entries[1:]
+			}
+		}, &result.Malformed); err != nil {
+			return nil, err
+		}
+		result.FilesScanned++
+	}
+
+	return result, nil
+}
+
+// scanAuditTrailFile decodes each JSON line of a trail file, counting lines that
+// are not valid entries in malformed
+func scanAuditTrailFile(path string, visit func(AuditTrailEntry), malformed *int) error {
+	return scanAuditTrailLines(path, func(_ int, line []byte) {
+		var entry AuditTrailEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			*malformed++
+			return
+		}
+		visit(entry)
+	})
+}
+
+// scanAuditTrailLines calls visit with each non-empty line of a trail file,
+// decompressing gzipped rotated files
+func scanAuditTrailLines(path string, visit func(lineNumber int, line []byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit trail file: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	// Entries embed the full result, so lines can be large
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		visit(lineNumber, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
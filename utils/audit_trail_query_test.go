@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestAuditTrail_Query tests filtering entries by user, query ID, action and time range
+func TestAuditTrail_Query(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+	trail, err := NewAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Failed to create audit trail: %v", err)
+	}
+	defer trail.Close()
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	entries := []AuditTrailEntry{
+		{Timestamp: base.Format(time.RFC3339), QueryID: "q1", UserID: "alice", Action: "complete_query"},
+		{Timestamp: base.Add(time.Hour).Format(time.RFC3339), QueryID: "q1", UserID: "alice", Action: "cache_hit"},
+		{Timestamp: base.Add(2 * time.Hour).Format(time.RFC3339), QueryID: "q2", UserID: "bob", Action: "complete_query"},
+		{Timestamp: base.Add(3 * time.Hour).Format(time.RFC3339), QueryID: "q3", UserID: "alice", Action: "complete_query"},
+	}
+	for _, entry := range entries {
+		if err := trail.LogQuery(entry); err != nil {
+			t.Fatalf("Failed to log entry: %v", err)
+		}
+	}
+
+	// A corrupted line is skipped and counted
+	file, _ := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("not json\n")
+	file.Close()
+
+	tests := []struct {
+		name     string
+		query    AuditTrailQuery
+		expected []string
+		total    int
+	}{
+		{name: "all", query: AuditTrailQuery{}, expected: []string{"q1", "q1", "q2", "q3"}, total: 4},
+		{name: "by user", query: AuditTrailQuery{UserID: "alice"}, expected: []string{"q1", "q1", "q3"}, total: 3},
+		{name: "by action", query: AuditTrailQuery{Action: "complete_query"}, expected: []string{"q1", "q2", "q3"}, total: 3},
+		{name: "by query ID", query: AuditTrailQuery{QueryID: "q2"}, expected: []string{"q2"}, total: 1},
+		{name: "time range", query: AuditTrailQuery{Since: base.Add(30 * time.Minute), Until: base.Add(2 * time.Hour)}, expected: []string{"q1", "q2"}, total: 2},
+		{name: "limit keeps most recent", query: AuditTrailQuery{Limit: 2}, expected: []string{"q2", "q3"}, total: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := trail.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.TotalMatches != tt.total {
+				t.Errorf("Expected %d matches, got %d", tt.total, result.TotalMatches)
+			}
+			if len(result.Entries) != len(tt.expected) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.expected), len(result.Entries))
+			}
+			for i, queryID := range tt.expected {
+				if result.Entries[i].QueryID != queryID {
+					t.Errorf("Entry %d: expected %s, got %s", i, queryID, result.Entries[i].QueryID)
+				}
+			}
+			if result.Malformed != 1 {
+				t.Errorf("Expected 1 malformed line, got %d", result.Malformed)
+			}
+		})
+	}
+}
+
+// TestAuditTrail_QuerySyslogOnly tests that syslog-only trails cannot be queried
+func TestAuditTrail_QuerySyslogOnly(t *testing.T) {
+	trail := &AuditTrail{}
+	if _, err := trail.Query(AuditTrailQuery{}); err == nil {
+		t.Error("Expected error querying a trail without a local file")
+	}
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AuditTrailRetention controls when the audit trail file is rotated and how long
+// rotated files are kept. Zero values disable the corresponding limit.
+type AuditTrailRetention struct {
+	// MaxSizeBytes rotates the file once it reaches this size
+	MaxSizeBytes int64
+	// MaxAge rotates the file once it has been written to for this long
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep
+	MaxBackups int
+	// RetentionAge deletes rotated files older than this
+	RetentionAge time.Duration
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// rotatedTimeFormat is used in rotated file names; it sorts chronologically
+const rotatedTimeFormat = "20060102T150405.000"
+
+// SetRetention sets the rotation and retention policy and applies it immediately
+func (at *AuditTrail) SetRetention(policy AuditTrailRetention) error {
+	at.mutex.Lock()
+	defer at.mutex.Unlock()
+
+	at.retention = policy
+	if at.file == nil {
+		return nil
+	}
+	if at.needsRotation() {
+		return at.rotate()
+	}
+	return at.applyRetention()
+}
+
+// needsRotation reports whether the current file exceeds the size or age limit;
+// the caller must hold the mutex
+func (at *AuditTrail) needsRotation() bool {
+	if at.retention.MaxAge > 0 && time.Since(at.openedAt) >= at.retention.MaxAge {
+		return at.size > 0
+	}
+	return at.retention.MaxSizeBytes > 0 && at.size >= at.retention.MaxSizeBytes
+}
+
+// rotate moves the current file aside, compressing it if configured, opens a new
+// file and applies the retention policy; the caller must hold the mutex
+func (at *AuditTrail) rotate() error {
+	if err := at.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit trail file: %w", err)
+	}
+	at.file = nil
+
+	rotated := at.rotatedPath(time.Now())
+	if err := os.Rename(at.filePath, rotated); err != nil {
+		return fmt.Errorf("failed to rotate audit trail file: %w", err)
+	}
+	if at.retention.Compress {
+		if err := compressFile(rotated); err != nil {
+			return err
+		}
+	}
+
+	if err := at.open(); err != nil {
+		return err
+	}
+	return at.applyRetention()
+}
+
+// rotatedPath returns the name a file rotated at t is given, e.g.
+// logs/audit_trail-20240115T100000.000.json
+func (at *AuditTrail) rotatedPath(t time.Time) string {
+	ext := filepath.Ext(at.filePath)
+	base := strings.TrimSuffix(at.filePath, ext)
+	return fmt.Sprintf("%s-%s%s", base, t.UTC().Format(rotatedTimeFormat), ext)
+}
+
+// rotatedFiles returns the rotated files of this trail, oldest first
+func (at *AuditTrail) rotatedFiles() ([]string, error) {
+	ext := filepath.Ext(at.filePath)
+	base := strings.TrimSuffix(at.filePath, ext)
+
+	matches, err := filepath.Glob(base + "-*" + ext + "*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rotated audit trail files: %w", err)
+	}
+	var files []string
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, base+"-")
+		suffix = strings.TrimSuffix(strings.TrimSuffix(suffix, ".gz"), ext)
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err == nil {
+			files = append(files, match)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// applyRetention deletes rotated files beyond MaxBackups or older than RetentionAge;
+// the caller must hold the mutex
+func (at *AuditTrail) applyRetention() error {
+	if at.retention.MaxBackups <= 0 && at.retention.RetentionAge <= 0 {
+		return nil
+	}
+
+	files, err := at.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		remove := at.retention.MaxBackups > 0 && len(files)-i > at.retention.MaxBackups
+		if !remove && at.retention.RetentionAge > 0 {
+			if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) > at.retention.RetentionAge {
+				remove = true
+			}
+		}
+		if remove {
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("failed to remove old audit trail file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile replaces path with a gzipped copy at path + ".gz"
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit trail file for compression: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed audit trail file: %w", err)
+	}
+
+	writer := gzip.NewWriter(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to compress audit trail file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to compress audit trail file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to compress audit trail file: %w", err)
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAuditTrail_RotateBySize tests size-based rotation, compression, retention and querying across files
+func TestAuditTrail_RotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "audit_trail.json")
+	trail, err := NewAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Failed to create audit trail: %v", err)
+	}
+	defer trail.Close()
+
+	if err := trail.SetRetention(AuditTrailRetention{MaxSizeBytes: 1, MaxBackups: 2, Compress: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Every entry after the first rotates the file because the size limit is 1 byte
+	for i := 0; i < 4; i++ {
+		if err := trail.LogCacheAccess("q"+string(rune('1'+i)), "hit", "", "", ""); err != nil {
+			t.Fatalf("Failed to log entry: %v", err)
+		}
+		// Rotated file names have millisecond resolution
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	rotated, err := trail.rotatedFiles()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("Expected 2 rotated files to be kept, got %v", rotated)
+	}
+	for _, file := range rotated {
+		if !strings.HasSuffix(file, ".json.gz") {
+			t.Errorf("Expected rotated file to be compressed, got %s", file)
+		}
+	}
+
+	result, err := trail.Query(AuditTrailQuery{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.FilesScanned != 3 {
+		t.Errorf("Expected 3 files scanned, got %d", result.FilesScanned)
+	}
+	// The oldest rotated file was pruned, so q1 is gone
+	var queryIDs []string
+	for _, entry := range result.Entries {
+		queryIDs = append(queryIDs, entry.QueryID)
+	}
+	if strings.Join(queryIDs, ",") != "q2,q3,q4" {
+		t.Errorf("Expected entries q2,q3,q4, got %v", queryIDs)
+	}
+}
+
+// TestAuditTrail_RotateByAge tests age-based rotation and retention by age
+func TestAuditTrail_RotateByAge(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "audit_trail.json")
+	trail, err := NewAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Failed to create audit trail: %v", err)
+	}
+	defer trail.Close()
+
+	if err := trail.LogCacheAccess("q1", "hit", "", "", ""); err != nil {
+		t.Fatalf("Failed to log entry: %v", err)
+	}
+
+	// A stale rotated file from an earlier run
+	stale := trail.rotatedPath(time.Now().Add(-48 * time.Hour))
+	if err := os.WriteFile(stale, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write stale file: %v", err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(stale, old, old)
+
+	trail.openedAt = time.Now().Add(-2 * time.Hour)
+	if err := trail.SetRetention(AuditTrailRetention{MaxAge: time.Hour, RetentionAge: 24 * time.Hour}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rotated, err := trail.rotatedFiles()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rotated) != 1 || rotated[0] == stale || strings.HasSuffix(rotated[0], ".gz") {
+		t.Errorf("Expected only the new uncompressed rotated file, got %v", rotated)
+	}
+	if info, err := os.Stat(filePath); err != nil || info.Size() != 0 {
+		t.Errorf("Expected a new empty audit trail file, got %v (%v)", info, err)
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"container/list"
+	"encoding/json"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -8,27 +10,79 @@ import (
 	"audit-query-mcp-server/types"
 )
 
+// Default cache bounds
+const (
+	DefaultCacheMaxEntries = 1000
+	DefaultCacheMaxBytes   = 256 * 1024 * 1024
+	// DefaultCacheNegativeTTL is how long results with no entries are cached
+	DefaultCacheNegativeTTL = 2 * time.Minute
+)
+
 // CacheEntry represents a cached audit result
 type CacheEntry struct {
 	Result    *types.AuditResult
 	Timestamp time.Time
 	TTL       time.Duration
+	// Size is the entry's JSON-encoded size in bytes, used for the byte bound
+	Size int64
+	// Key is the canonical parameter key the entry can be looked up by, and
+	// Params the query parameters it was produced from; both are optional
+	Key    string
+	Params *types.AuditQueryParams
+	// Negative marks an empty result cached with the negative TTL
+	Negative bool
+
+	// compressedRawOutput holds the gzip-compressed raw output of Result,
+	// whose RawOutput is then empty, and rawOutputSize its uncompressed size
+	compressedRawOutput []byte
+	rawOutputSize       int64
+
+	// element is the entry's position in the LRU list
+	element *list.Element
 }
 
-// Cache provides a simple in-memory cache for audit results
+// Cache provides an in-memory TTL cache for audit results, bounded by entry count
+// and total size with least-recently-used eviction
 type Cache struct {
 	entries map[string]*CacheEntry
 	mutex   sync.RWMutex
 	ttl     time.Duration
 	hits    int64
 	misses  int64
+
+	// negativeTTL applies to empty results stored with SetForParams
+	negativeTTL  time.Duration
+	negativeHits int64
+
+	// keys maps canonical parameter keys to query IDs
+	keys map[string]string
+
+	// lru holds query IDs from most to least recently used
+	lru        *list.List
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+	evictions  int64
+	oversized  int64
+
+	// compressMinBytes is the raw output size from which entries keep it
+	// compressed; zero or less disables compression
+	compressMinBytes int
+	compressions     int64
 }
 
-// NewCache creates a new cache instance with default TTL
+// NewCache creates a new cache instance with default TTL and the default bounds
 func NewCache(defaultTTL time.Duration) *Cache {
 	cache := &Cache{
-		entries: make(map[string]*CacheEntry),
-		ttl:     defaultTTL,
+		entries:     make(map[string]*CacheEntry),
+		keys:        make(map[string]string),
+		ttl:         defaultTTL,
+		negativeTTL: DefaultCacheNegativeTTL,
+		lru:         list.New(),
+		maxEntries:  DefaultCacheMaxEntries,
+		maxBytes:    DefaultCacheMaxBytes,
+
+		compressMinBytes: DefaultCacheCompressMinBytes,
 	}
 
 	// Start cleanup goroutine
@@ -37,10 +91,37 @@ func NewCache(defaultTTL time.Duration) *Cache {
 	return cache
 }
 
-// Get retrieves a cached result by query ID
+// SetLimits changes the maximum number of entries and total bytes, evicting the
+// least recently used entries if the cache is over the new bounds. Zero disables
+// a bound.
+func (c *Cache) SetLimits(maxEntries int, maxBytes int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	c.evict()
+}
+
+// SetNegativeTTL changes how long empty results stored with SetForParams are
+// cached; zero or less disables negative caching
+func (c *Cache) SetNegativeTTL(ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.negativeTTL = ttl
+}
+
+// IsNegativeResult reports whether a successful result has no entries
+func IsNegativeResult(result *types.AuditResult) bool {
+	return result != nil && result.Error == "" && len(result.ParsedData) == 0 &&
+		result.TotalEntries == 0 && result.Histogram == nil
+}
+
+// Get retrieves a cached result by query ID and marks it as recently used
 func (c *Cache) Get(queryID string) (*types.AuditResult, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
 	entry, exists := c.entries[queryID]
 	if !exists {
@@ -50,18 +131,34 @@ func (c *Cache) Get(queryID string) (*types.AuditResult, bool) {
 
 	// Check if entry has expired
 	if time.Since(entry.Timestamp) > entry.TTL {
-		// Entry has expired, remove it
-		c.mutex.RUnlock()
-		c.mutex.Lock()
-		delete(c.entries, queryID)
-		c.mutex.Unlock()
-		c.mutex.RLock()
+		c.remove(queryID)
 		atomic.AddInt64(&c.misses, 1)
 		return nil, false
 	}
 
+	c.lru.MoveToFront(entry.element)
 	atomic.AddInt64(&c.hits, 1)
-	return entry.Result, true
+	return resultOf(entry), true
+}
+
+// Recent returns up to limit unexpired results, most recently used first,
+// without counting as hits or changing their order; zero or less returns all
+func (c *Cache) Recent(limit int) []*types.AuditResult {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var results []*types.AuditResult
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		entry := c.entries[element.Value.(string)]
+		if time.Since(entry.Timestamp) > entry.TTL {
+			continue
+		}
+		results = append(results, resultOf(entry))
+	}
+	return results
 }
 
 // Set stores a result in the cache
@@ -69,24 +166,130 @@ func (c *Cache) Set(queryID string, result *types.AuditResult) {
 	c.SetWithTTL(queryID, result, c.ttl)
 }
 
-// SetWithTTL stores a result in the cache with custom TTL
+// SetWithTTL stores a result in the cache with custom TTL. A result larger than
+// the byte bound is not cached.
 func (c *Cache) SetWithTTL(queryID string, result *types.AuditResult, ttl time.Duration) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.entries[queryID] = &CacheEntry{
-		Result:    result,
-		Timestamp: time.Now(),
-		TTL:       ttl,
+	c.store(queryID, &CacheEntry{Result: result, Timestamp: time.Now(), TTL: ttl})
+}
+
+// SetForParams stores a result under its query ID and also makes it available by
+// the canonical key of the parameters that produced it, replacing any older
+// result for the same key. Empty results are cached with the negative TTL, so
+// repeated retries of a query that matches nothing are not re-executed for a while.
+func (c *Cache) SetForParams(queryID, key string, params types.AuditQueryParams, result *types.AuditResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if previous, exists := c.keys[key]; exists && previous != queryID {
+		c.remove(previous)
+	}
+	entry := &CacheEntry{Result: result, Timestamp: time.Now(), TTL: c.ttl, Key: key, Params: &params}
+	if IsNegativeResult(result) {
+		if c.negativeTTL <= 0 {
+			c.remove(queryID)
+			return
+		}
+		entry.TTL = c.negativeTTL
+		entry.Negative = true
+	}
+	c.store(queryID, entry)
+}
+
+// GetByKey retrieves a cached result by canonical parameter key and marks it as
+// recently used. A negative entry is returned as a copy with FromNegativeCache set.
+func (c *Cache) GetByKey(key string) (*types.AuditResult, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	queryID, exists := c.keys[key]
+	if !exists {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	entry := c.entries[queryID]
+	if time.Since(entry.Timestamp) > entry.TTL {
+		c.remove(queryID)
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(entry.element)
+	atomic.AddInt64(&c.hits, 1)
+	if !entry.Negative {
+		return resultOf(entry), true
+	}
+	atomic.AddInt64(&c.negativeHits, 1)
+	result := *resultOf(entry)
+	result.FromNegativeCache = true
+	return &result, true
+}
+
+// store adds or replaces an entry and evicts to stay within bounds; the caller
+// must hold the write lock
+func (c *Cache) store(queryID string, entry *CacheEntry) {
+	c.compressEntry(entry)
+	entry.Size = resultSize(entry.Result) + int64(len(entry.compressedRawOutput))
+	if c.maxBytes > 0 && entry.Size > c.maxBytes {
+		atomic.AddInt64(&c.oversized, 1)
+		c.remove(queryID)
+		return
+	}
+
+	if _, exists := c.entries[queryID]; exists {
+		c.remove(queryID)
+	}
+	entry.element = c.lru.PushFront(queryID)
+	c.entries[queryID] = entry
+	if entry.Key != "" {
+		c.keys[entry.Key] = queryID
+	}
+	c.bytes += entry.Size
+	c.evict()
+}
+
+// remove deletes an entry; the caller must hold the write lock
+func (c *Cache) remove(queryID string) {
+	entry, exists := c.entries[queryID]
+	if !exists {
+		return
+	}
+	c.lru.Remove(entry.element)
+	c.bytes -= entry.Size
+	delete(c.entries, queryID)
+	if entry.Key != "" && c.keys[entry.Key] == queryID {
+		delete(c.keys, entry.Key)
 	}
 }
 
+// evict removes least recently used entries until the cache is within its
+// bounds; the caller must hold the write lock
+func (c *Cache) evict() {
+	for c.lru.Len() > 0 &&
+		((c.maxEntries > 0 && len(c.entries) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		oldest := c.lru.Back()
+		c.remove(oldest.Value.(string))
+		atomic.AddInt64(&c.evictions, 1)
+	}
+}
+
+// resultSize estimates an entry's memory footprint from its JSON encoding
+func resultSize(result *types.AuditResult) int64 {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
+
 // Delete removes a result from the cache
 func (c *Cache) Delete(queryID string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	delete(c.entries, queryID)
+	c.remove(queryID)
 }
 
 // Clear removes all entries from the cache
@@ -95,12 +298,18 @@ func (c *Cache) Clear() {
 	defer c.mutex.Unlock()
 
 	c.entries = make(map[string]*CacheEntry)
+	c.keys = make(map[string]string)
+	c.lru.Init()
+	c.bytes = 0
 }
 
 // ResetStats resets the cache hit/miss statistics
 func (c *Cache) ResetStats() {
 	atomic.StoreInt64(&c.hits, 0)
 	atomic.StoreInt64(&c.misses, 0)
+	atomic.StoreInt64(&c.negativeHits, 0)
+	atomic.StoreInt64(&c.evictions, 0)
+	atomic.StoreInt64(&c.oversized, 0)
 }
 
 // Size returns the number of entries in the cache
@@ -121,7 +330,7 @@ func (c *Cache) cleanup() {
 		now := time.Now()
 		for queryID, entry := range c.entries {
 			if now.Sub(entry.Timestamp) > entry.TTL {
-				delete(c.entries, queryID)
+				c.remove(queryID)
 			}
 		}
 		c.mutex.Unlock()
@@ -136,8 +345,15 @@ func (c *Cache) GetStats() map[string]interface{} {
 	stats := make(map[string]interface{})
 	stats["size"] = len(c.entries)
 	stats["default_ttl"] = c.ttl.String()
+	stats["negative_ttl"] = c.negativeTTL.String()
+	stats["negative_hits"] = atomic.LoadInt64(&c.negativeHits)
 	stats["hits"] = atomic.LoadInt64(&c.hits)
 	stats["misses"] = atomic.LoadInt64(&c.misses)
+	stats["bytes"] = c.bytes
+	stats["max_entries"] = c.maxEntries
+	stats["max_bytes"] = c.maxBytes
+	stats["evictions"] = atomic.LoadInt64(&c.evictions)
+	stats["oversized_rejected"] = atomic.LoadInt64(&c.oversized)
 
 	// Calculate hit rate
 	total := atomic.LoadInt64(&c.hits) + atomic.LoadInt64(&c.misses)
@@ -148,10 +364,20 @@ func (c *Cache) GetStats() map[string]interface{} {
 		stats["hit_rate"] = 0.0
 	}
 
-	// Count entries by age
+	// Count entries by age and find the largest entry
 	ageStats := make(map[string]int)
+	var largest, compressedBytes, uncompressedBytes int64
+	negative, compressed := 0, 0
 	now := time.Now()
 	for _, entry := range c.entries {
+		if entry.Negative {
+			negative++
+		}
+		if entry.compressedRawOutput != nil {
+			compressed++
+			compressedBytes += int64(len(entry.compressedRawOutput))
+			uncompressedBytes += entry.rawOutputSize
+		}
 		age := now.Sub(entry.Timestamp)
 		switch {
 		case age < time.Minute:
@@ -163,8 +389,16 @@ func (c *Cache) GetStats() map[string]interface{} {
 		default:
 			ageStats[">24h"]++
 		}
+		if entry.Size > largest {
+			largest = entry.Size
+		}
 	}
 	stats["age_distribution"] = ageStats
+	stats["largest_entry_bytes"] = largest
+	stats["negative_entries"] = negative
+	stats["compress_min_bytes"] = c.compressMinBytes
+	stats["compressed_entries"] = compressed
+	stats["compression_saved_bytes"] = uncompressedBytes - compressedBytes
 
 	return stats
 }
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync/atomic"
+
+	"audit-query-mcp-server/types"
+)
+
+// DefaultCacheCompressMinBytes is the raw output size from which cached
+// results keep their raw output gzip-compressed
+const DefaultCacheCompressMinBytes = 64 * 1024
+
+// SetCompression changes the raw output size from which results stored
+// afterwards keep their raw output gzip-compressed; zero or less disables
+// compression. Compression is transparent: cached results are returned with
+// their raw output.
+func (c *Cache) SetCompression(minBytes int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.compressMinBytes = minBytes
+}
+
+// compressEntry replaces the raw output of a new entry's result by its gzip
+// compression when it is large enough and compresses; the caller must hold the
+// write lock. The stored result is a copy, leaving the caller's untouched.
+func (c *Cache) compressEntry(entry *CacheEntry) {
+	if c.compressMinBytes <= 0 || entry.Result == nil || len(entry.Result.RawOutput) < c.compressMinBytes {
+		return
+	}
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(entry.Result.RawOutput)); err != nil {
+		return
+	}
+	if err := writer.Close(); err != nil || buffer.Len() >= len(entry.Result.RawOutput) {
+		return
+	}
+
+	stored := *entry.Result
+	entry.rawOutputSize = int64(len(stored.RawOutput))
+	stored.RawOutput = ""
+	entry.Result = &stored
+	entry.compressedRawOutput = buffer.Bytes()
+	atomic.AddInt64(&c.compressions, 1)
+}
+
+// resultOf returns the result of an entry with its raw output, decompressing it
+// into a copy when it is stored compressed
+func resultOf(entry *CacheEntry) *types.AuditResult {
+	if entry.compressedRawOutput == nil {
+		return entry.Result
+	}
+	result := *entry.Result
+	reader, err := gzip.NewReader(bytes.NewReader(entry.compressedRawOutput))
+	if err != nil {
+		return &result
+	}
+	defer reader.Close()
+	rawOutput, err := io.ReadAll(reader)
+	if err != nil {
+		return &result
+	}
+	result.RawOutput = string(rawOutput)
+	return &result
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCache_Compression tests that large raw outputs are stored compressed and returned whole
+func TestCache_Compression(t *testing.T) {
+	cache := NewCache(time.Hour)
+	result := MockAuditResult("large")
+	result.RawOutput = strings.Repeat(`{"kind":"Event","verb":"get","user":{"username":"system:serviceaccount:openshift-monitoring:prometheus-k8s"}}`+"\n", 2000)
+	rawOutput := result.RawOutput
+	cache.Set("large", result)
+	cache.Set("small", MockAuditResult("small"))
+
+	if result.RawOutput != rawOutput {
+		t.Fatal("Expected the caller's result to keep its raw output")
+	}
+	cached, ok := cache.Get("large")
+	if !ok || cached.RawOutput != rawOutput {
+		t.Fatal("Expected the cached result to be returned with its raw output")
+	}
+	// Reading the large result made it the most recent
+	if recent := cache.Recent(0); len(recent) != 2 || recent[0].RawOutput != rawOutput {
+		t.Error("Expected recent results to be returned with their raw output")
+	}
+
+	stats := cache.GetStats()
+	if stats["compressed_entries"] != 1 {
+		t.Errorf("Expected 1 compressed entry, got %v", stats["compressed_entries"])
+	}
+	if saved := stats["compression_saved_bytes"].(int64); saved <= int64(len(rawOutput))/2 {
+		t.Errorf("Expected compression to save most of %d bytes, saved %d", len(rawOutput), saved)
+	}
+	if size := stats["bytes"].(int64); size >= int64(len(rawOutput)) {
+		t.Errorf("Expected the byte bound to count the compressed size, got %d", size)
+	}
+
+	// Saved caches hold the raw output and compress it again when loaded
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := cache.SaveToFile(path); err != nil {
+		t.Fatalf("Failed to save cache: %v", err)
+	}
+	restored := NewCache(time.Hour)
+	if _, err := restored.LoadFromFile(path); err != nil {
+		t.Fatalf("Failed to load cache: %v", err)
+	}
+	if cached, ok := restored.Get("large"); !ok || cached.RawOutput != rawOutput {
+		t.Error("Expected the restored result to have its raw output")
+	}
+	if restored.GetStats()["compressed_entries"] != 1 {
+		t.Error("Expected the restored entry to be compressed")
+	}
+
+	// Compression can be disabled
+	uncompressed := NewCache(time.Hour)
+	uncompressed.SetCompression(0)
+	uncompressed.Set("large", result)
+	if uncompressed.GetStats()["compressed_entries"] != 0 {
+		t.Error("Expected no compressed entries with compression disabled")
+	}
+}
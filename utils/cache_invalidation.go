@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// CacheFilter selects cached results by the parameters of the query that
+// produced them. Set fields must all match; a field matches when the query's
+// single-value or list parameter contains the value, compared case-insensitively.
+type CacheFilter struct {
+	LogSource string
+	Username  string
+	Namespace string
+	Resource  string
+	Verb      string
+	Pattern   string
+}
+
+// IsEmpty reports whether no filter field is set
+func (f CacheFilter) IsEmpty() bool {
+	return f == CacheFilter{}
+}
+
+// Matches reports whether params satisfy the filter
+func (f CacheFilter) Matches(params types.AuditQueryParams) bool {
+	logSource := params.LogSource
+	if logSource == "" {
+		logSource = "kube-apiserver"
+	}
+	return matchesFilterValue(f.LogSource, logSource, nil) &&
+		matchesFilterValue(f.Username, params.Username, params.Usernames) &&
+		matchesFilterValue(f.Namespace, params.Namespace, params.Namespaces) &&
+		matchesFilterValue(f.Resource, params.Resource, params.Resources) &&
+		matchesFilterValue(f.Verb, params.Verb, params.Verbs) &&
+		matchesFilterValue(f.Pattern, "", params.Patterns)
+}
+
+// matchesFilterValue reports whether want is empty or equals single or one of list
+func matchesFilterValue(want, single string, list []string) bool {
+	if want == "" {
+		return true
+	}
+	if strings.EqualFold(want, single) {
+		return true
+	}
+	for _, value := range list {
+		if strings.EqualFold(want, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Invalidate removes the entries whose query parameters match filter and returns
+// the number removed. Entries stored without parameters never match.
+func (c *Cache) Invalidate(filter CacheFilter) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := 0
+	for queryID, entry := range c.entries {
+		if entry.Params != nil && filter.Matches(*entry.Params) {
+			c.remove(queryID)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// persistedCacheEntry is the on-disk form of a cache entry
+type persistedCacheEntry struct {
+	QueryID   string                  `json:"query_id"`
+	Key       string                  `json:"key,omitempty"`
+	Params    *types.AuditQueryParams `json:"params,omitempty"`
+	Negative  bool                    `json:"negative,omitempty"`
+	Result    *types.AuditResult      `json:"result"`
+	Timestamp time.Time               `json:"timestamp"`
+	TTL       time.Duration           `json:"ttl"`
+}
+
+// SaveToFile writes the unexpired entries to path, least recently used first,
+// so LoadFromFile restores the LRU order. The file is replaced atomically and is
+// only readable by the owner, since results can contain sensitive audit data.
+func (c *Cache) SaveToFile(path string) error {
+	c.mutex.RLock()
+	entries := make([]persistedCacheEntry, 0, len(c.entries))
+	now := time.Now()
+	for element := c.lru.Back(); element != nil; element = element.Prev() {
+		queryID := element.Value.(string)
+		entry := c.entries[queryID]
+		if now.Sub(entry.Timestamp) > entry.TTL {
+			continue
+		}
+		entries = append(entries, persistedCacheEntry{
+			QueryID:   queryID,
+			Key:       entry.Key,
+			Params:    entry.Params,
+			Negative:  entry.Negative,
+			Result:    resultOf(entry),
+			Timestamp: entry.Timestamp,
+			TTL:       entry.TTL,
+		})
+	}
+	c.mutex.RUnlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := writePrivateFile(path, data); err != nil {
+		return fmt.Errorf("failed to save cache: %w", err)
+	}
+	return nil
+}
+
+// writePrivateFile atomically replaces path with data, readable only by the
+// owner, creating its directory
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// LoadFromFile restores entries saved by SaveToFile, skipping entries that have
+// expired since, and returns the number of entries loaded. A missing file is not
+// an error. Loaded entries keep their original timestamps and TTLs and are
+// subject to the cache's bounds.
+func (c *Cache) LoadFromFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var entries []persistedCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("failed to parse cache file: %w", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	loaded := 0
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.QueryID == "" || entry.Result == nil || now.Sub(entry.Timestamp) > entry.TTL {
+			continue
+		}
+		c.store(entry.QueryID, &CacheEntry{
+			Result:    entry.Result,
+			Timestamp: entry.Timestamp,
+			TTL:       entry.TTL,
+			Key:       entry.Key,
+			Params:    entry.Params,
+			Negative:  entry.Negative,
+		})
+		loaded++
+	}
+	return loaded, nil
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -390,6 +392,265 @@ func TestCache_NilResult(t *testing.T) {
 	}
 }
 
+func TestCache_LRUEvictionByEntries(t *testing.T) {
+	cache := NewCache(1 * time.Hour)
+	cache.SetLimits(2, 0)
+
+	cache.Set("query-1", MockAuditResult("query-1"))
+	cache.Set("query-2", MockAuditResult("query-2"))
+
+	// Reading query-1 makes query-2 the least recently used entry
+	if _, found := cache.Get("query-1"); !found {
+		t.Fatal("Expected query-1 to be cached")
+	}
+	cache.Set("query-3", MockAuditResult("query-3"))
+
+	if _, found := cache.Get("query-2"); found {
+		t.Error("Expected query-2 to be evicted")
+	}
+	for _, queryID := range []string{"query-1", "query-3"} {
+		if _, found := cache.Get(queryID); !found {
+			t.Errorf("Expected %s to be cached", queryID)
+		}
+	}
+
+	stats := cache.GetStats()
+	if stats["evictions"] != int64(1) || stats["size"] != 2 {
+		t.Errorf("Expected 1 eviction and 2 entries, got %v and %v", stats["evictions"], stats["size"])
+	}
+}
+
+func TestCache_Recent(t *testing.T) {
+	cache := NewCache(1 * time.Hour)
+	cache.Set("query-1", MockAuditResult("query-1"))
+	cache.Set("query-2", MockAuditResult("query-2"))
+	cache.SetWithTTL("query-3", MockAuditResult("query-3"), time.Nanosecond)
+	cache.Get("query-1")
+	time.Sleep(time.Millisecond)
+
+	// Expired entries are skipped and the order is most recently used first
+	recent := cache.Recent(0)
+	if len(recent) != 2 || recent[0].QueryID != "query-1" || recent[1].QueryID != "query-2" {
+		t.Fatalf("Expected query-1 and query-2, got %v", recent)
+	}
+	if recent = cache.Recent(1); len(recent) != 1 || recent[0].QueryID != "query-1" {
+		t.Errorf("Expected only query-1, got %v", recent)
+	}
+
+	// Listing does not count as a hit or change the order
+	hits := cache.GetStats()["hits"]
+	cache.Recent(0)
+	if cache.GetStats()["hits"] != hits || cache.Recent(0)[0].QueryID != "query-1" {
+		t.Error("Expected Recent to leave hits and order unchanged")
+	}
+}
+
+func TestCache_LRUEvictionByBytes(t *testing.T) {
+	cache := NewCache(1 * time.Hour)
+	entrySize := resultSize(MockAuditResult("query-1"))
+	cache.SetLimits(0, 2*entrySize)
+
+	cache.Set("query-1", MockAuditResult("query-1"))
+	cache.Set("query-2", MockAuditResult("query-2"))
+	if stats := cache.GetStats(); stats["bytes"] != 2*entrySize || stats["largest_entry_bytes"] != entrySize {
+		t.Errorf("Expected %d bytes with largest entry %d, got %v and %v", 2*entrySize, entrySize, stats["bytes"], stats["largest_entry_bytes"])
+	}
+
+	cache.Set("query-3", MockAuditResult("query-3"))
+	if cache.Size() != 2 {
+		t.Errorf("Expected 2 entries within the byte bound, got %d", cache.Size())
+	}
+	if _, found := cache.Get("query-1"); found {
+		t.Error("Expected query-1 to be evicted")
+	}
+
+	// A result larger than the whole bound is not cached
+	large := MockAuditResult("large")
+	large.RawOutput = string(make([]byte, 3*entrySize))
+	cache.Set("large", large)
+	if _, found := cache.Get("large"); found {
+		t.Error("Expected the oversized result not to be cached")
+	}
+	if stats := cache.GetStats(); stats["oversized_rejected"] != int64(1) {
+		t.Errorf("Expected 1 oversized rejection, got %v", stats["oversized_rejected"])
+	}
+
+	// Replacing and deleting entries keeps the byte count accurate
+	cache.Set("query-3", MockAuditResult("query-3"))
+	cache.Delete("query-2")
+	if stats := cache.GetStats(); stats["bytes"] != entrySize {
+		t.Errorf("Expected %d bytes, got %v", entrySize, stats["bytes"])
+	}
+	cache.Clear()
+	if stats := cache.GetStats(); stats["bytes"] != int64(0) {
+		t.Errorf("Expected 0 bytes after clear, got %v", stats["bytes"])
+	}
+}
+
+func TestCache_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "cache.json")
+
+	cache := NewCache(1 * time.Hour)
+	cache.Set("query-1", MockAuditResult("query-1"))
+	cache.SetForParams("query-2", "key-2", types.AuditQueryParams{LogSource: "node"}, MockAuditResult("query-2"))
+	cache.SetWithTTL("expired", MockAuditResult("expired"), time.Millisecond)
+	cache.Get("query-1") // query-2 is now the least recently used entry
+	time.Sleep(5 * time.Millisecond)
+
+	if err := cache.SaveToFile(path); err != nil {
+		t.Fatalf("Failed to save cache: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Cache file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected cache file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	restored := NewCache(1 * time.Hour)
+	loaded, err := restored.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load cache: %v", err)
+	}
+	if loaded != 2 {
+		t.Errorf("Expected 2 entries loaded, got %d", loaded)
+	}
+	result, found := restored.Get("query-1")
+	if !found || result.Summary != "test-summary" {
+		t.Errorf("Expected query-1 to be restored, got %+v", result)
+	}
+
+	// The LRU order survives the restart
+	restored.SetLimits(1, 0)
+	if _, found := restored.Get("query-1"); !found {
+		t.Error("Expected the most recently used entry to be kept")
+	}
+
+	// Keys and parameters survive too
+	keyed := NewCache(1 * time.Hour)
+	if _, err := keyed.LoadFromFile(path); err != nil {
+		t.Fatalf("Failed to load cache: %v", err)
+	}
+	if _, found := keyed.GetByKey("key-2"); !found {
+		t.Error("Expected query-2 to be restored with its key")
+	}
+	if keyed.Invalidate(CacheFilter{LogSource: "node"}) != 1 {
+		t.Error("Expected query-2 to be restored with its parameters")
+	}
+
+	if loaded, err := NewCache(time.Hour).LoadFromFile(filepath.Join(t.TempDir(), "missing.json")); err != nil || loaded != 0 {
+		t.Errorf("Expected a missing file to load nothing, got %d (%v)", loaded, err)
+	}
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := NewCache(time.Hour).LoadFromFile(path); err == nil {
+		t.Error("Expected error for a corrupt cache file")
+	}
+}
+
+func TestCache_SetForParams(t *testing.T) {
+	cache := NewCache(1 * time.Hour)
+	params := types.AuditQueryParams{LogSource: "kube-apiserver", Username: "alice"}
+
+	cache.SetForParams("query-1", "key-a", params, MockAuditResult("query-1"))
+	if result, found := cache.GetByKey("key-a"); !found || result.QueryID != "query-1" {
+		t.Errorf("Expected query-1 by key, got %+v", result)
+	}
+	if _, found := cache.Get("query-1"); !found {
+		t.Error("Expected the result to stay available by query ID")
+	}
+
+	// A newer result for the same key replaces the older one
+	cache.SetForParams("query-2", "key-a", params, MockAuditResult("query-2"))
+	if result, found := cache.GetByKey("key-a"); !found || result.QueryID != "query-2" {
+		t.Errorf("Expected query-2 by key, got %+v", result)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected 1 entry, got %d", cache.Size())
+	}
+
+	cache.Delete("query-2")
+	if _, found := cache.GetByKey("key-a"); found {
+		t.Error("Expected the key to be removed with its entry")
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   CacheFilter
+		expected int
+	}{
+		{name: "log source", filter: CacheFilter{LogSource: "oauth-server"}, expected: 1},
+		{name: "default log source", filter: CacheFilter{LogSource: "kube-apiserver"}, expected: 2},
+		{name: "username in list", filter: CacheFilter{Username: "bob"}, expected: 2},
+		{name: "all fields must match", filter: CacheFilter{LogSource: "kube-apiserver", Username: "bob"}, expected: 1},
+		{name: "pattern", filter: CacheFilter{Pattern: "Secrets"}, expected: 1},
+		{name: "no match", filter: CacheFilter{Verb: "delete"}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCache(1 * time.Hour)
+			cache.SetForParams("query-1", "key-1", types.AuditQueryParams{Username: "alice", Patterns: []string{"secrets"}}, MockAuditResult("query-1"))
+			cache.SetForParams("query-2", "key-2", types.AuditQueryParams{LogSource: "kube-apiserver", Usernames: []string{"bob", "carol"}}, MockAuditResult("query-2"))
+			cache.SetForParams("query-3", "key-3", types.AuditQueryParams{LogSource: "oauth-server", Username: "bob"}, MockAuditResult("query-3"))
+			cache.Set("query-4", MockAuditResult("query-4"))
+
+			if removed := cache.Invalidate(tt.filter); removed != tt.expected {
+				t.Errorf("Expected %d entries invalidated, got %d", tt.expected, removed)
+			}
+			if cache.Size() != 4-tt.expected {
+				t.Errorf("Expected %d entries left, got %d", 4-tt.expected, cache.Size())
+			}
+		})
+	}
+}
+
+func TestCache_NegativeResults(t *testing.T) {
+	cache := NewCache(1 * time.Hour)
+	empty := &types.AuditResult{QueryID: "empty-query", Summary: "No audit entries found"}
+
+	cache.SetForParams("empty-query", "key-empty", types.AuditQueryParams{}, empty)
+	cache.SetForParams("full-query", "key-full", types.AuditQueryParams{}, MockAuditResult("full-query"))
+
+	result, found := cache.GetByKey("key-empty")
+	if !found || !result.FromNegativeCache {
+		t.Fatalf("Expected a negative cache hit, got %+v", result)
+	}
+	if empty.FromNegativeCache {
+		t.Error("Expected the cached result itself to be left unchanged")
+	}
+	if result, found := cache.GetByKey("key-full"); !found || result.FromNegativeCache {
+		t.Errorf("Expected a regular cache hit, got %+v", result)
+	}
+
+	stats := cache.GetStats()
+	if stats["negative_entries"] != 1 || stats["negative_hits"] != int64(1) {
+		t.Errorf("Expected 1 negative entry and hit, got %v and %v", stats["negative_entries"], stats["negative_hits"])
+	}
+
+	// Negative entries expire with the shorter TTL
+	cache.SetNegativeTTL(10 * time.Millisecond)
+	cache.SetForParams("empty-query", "key-empty", types.AuditQueryParams{}, empty)
+	time.Sleep(20 * time.Millisecond)
+	if _, found := cache.GetByKey("key-empty"); found {
+		t.Error("Expected the negative entry to expire")
+	}
+	if _, found := cache.GetByKey("key-full"); !found {
+		t.Error("Expected the regular entry to be kept")
+	}
+
+	// A zero negative TTL disables negative caching
+	cache.SetNegativeTTL(0)
+	cache.SetForParams("empty-query", "key-empty", types.AuditQueryParams{}, empty)
+	if _, found := cache.Get("empty-query"); found {
+		t.Error("Expected empty results not to be cached")
+	}
+}
+
 func BenchmarkCache_Set(b *testing.B) {
 	cache := NewCache(1 * time.Hour)
 
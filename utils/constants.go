@@ -49,6 +49,10 @@ var ValidResources = []string{
 	// API Resources
 	"apiservices", "flowschemas", "prioritylevelconfigurations",
 
+	// Cluster Infrastructure Resources
+	"machineconfigs", "machineconfigpools", "kubeletconfigs", "containerruntimeconfigs",
+	"schedulers", "apiservers", "kubeschedulers", "kubeapiservers",
+
 	// Short forms and aliases
 	"pod", "service", "deployment", "replicaset", "statefulset", "daemonset",
 	"namespace", "node", "configmap", "secret", "pv", "pvc", "endpoint", "event",
@@ -131,6 +135,12 @@ var AuditLogFields = map[string]string{
 	"SourceIPs":                "sourceIPs",
 	"UserAgent":                "userAgent",
 	"Annotations":              "annotations",
+	"AuditID":                  "auditID",
+	"Stage":                    "stage",
+	"Level":                    "level",
+	"StageTimestamp":           "stageTimestamp",
+	"RequestObject":            "requestObject",
+	"ResponseObject":           "responseObject",
 
 	// User-related fields
 	"Username": "username",
@@ -139,11 +149,12 @@ var AuditLogFields = map[string]string{
 	"Extra":    "extra",
 
 	// Object reference fields
-	"Resource":   "resource",
-	"Namespace":  "namespace",
-	"Name":       "name",
-	"APIGroup":   "apiGroup",
-	"APIVersion": "apiVersion",
+	"Resource":    "resource",
+	"Namespace":   "namespace",
+	"Name":        "name",
+	"APIGroup":    "apiGroup",
+	"APIVersion":  "apiVersion",
+	"Subresource": "subresource",
 
 	// Response status fields
 	"Code":    "code",
@@ -158,7 +169,9 @@ var AuditLogFields = map[string]string{
 
 	// Authentication fields
 	"AuthenticationDecision": "authentication.openshift.io/decision",
+	"AuthenticationUsername": "authentication.openshift.io/username",
 	"AuthorizationDecision":  "authorization.k8s.io/decision",
+	"AuthorizationReason":    "authorization.k8s.io/reason",
 	"ImpersonatedUser":       "impersonatedUser",
 	"RequestUser":            "requestUser",
 }
@@ -320,6 +333,63 @@ var AuditLogLevels = map[string]string{
 	"AllRequestBodies":   "AllRequestBodies",
 }
 
+// AuditStages lists the values of the audit event "stage" field
+var AuditStages = []string{
+	"RequestReceived",
+	"ResponseStarted",
+	"ResponseComplete",
+	"Panic",
+}
+
+// AuditEventLevels lists the values of the audit event "level" field
+var AuditEventLevels = []string{
+	"Metadata",
+	"Request",
+	"RequestResponse",
+}
+
+// ResultSortFields lists the fields parsed entries can be sorted by
+var ResultSortFields = []string{
+	"timestamp",
+	"user",
+	"resource",
+	"statusCode",
+}
+
+// ResultSortOrders lists the supported sort directions
+var ResultSortOrders = []string{
+	"asc",
+	"desc",
+}
+
+// Result output modes
+const (
+	OutputModeEntries   = "entries"
+	OutputModeHistogram = "histogram"
+)
+
+// OutputModes lists the supported result output modes
+var OutputModes = []string{OutputModeEntries, OutputModeHistogram}
+
+// Summary modes: a brief single sentence, or verbose with the top users,
+// verbs, namespaces and resources and the error rate
+const (
+	SummaryModeBrief   = "brief"
+	SummaryModeVerbose = "verbose"
+)
+
+// SummaryModes lists the supported result summary modes
+var SummaryModes = []string{SummaryModeBrief, SummaryModeVerbose}
+
+// ValidBackends lists the log backends a query can select
+var ValidBackends = []string{"openshift", "kubernetes", "in-cluster", "loki", "elasticsearch", "cloudwatch", "mock"}
+
+// HistogramBucketSizes lists the supported histogram bucket sizes
+var HistogramBucketSizes = []string{"minute", "hour", "day"}
+
+// DefaultHistogramBucketSize is used when a histogram is requested without a bucket size
+const DefaultHistogramBucketSize = "hour"
+
 // Common Security Patterns for threat detection and investigation
 var SecurityPatterns = map[string][]string{
 	"privilege_escalation": {
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"audit-query-mcp-server/types"
+)
+
+// FindingStore keeps the findings flagged during an investigation, saved to a
+// JSON file after every change so they survive restarts
+type FindingStore struct {
+	path     string
+	mutex    sync.Mutex
+	findings []types.Finding
+	// next numbers the IDs of new findings
+	next int
+}
+
+// NewFindingStore loads the findings saved at path; a missing file starts an
+// empty store
+func NewFindingStore(path string) (*FindingStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file path cannot be empty")
+	}
+	store := &FindingStore{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read findings file: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.findings); err != nil {
+		return nil, fmt.Errorf("failed to parse findings file: %w", err)
+	}
+	for _, finding := range store.findings {
+		var number int
+		if _, err := fmt.Sscanf(finding.ID, "finding-%d", &number); err == nil && number > store.next {
+			store.next = number
+		}
+	}
+	return store, nil
+}
+
+// Add assigns the finding an ID, stores and saves it, and returns it
+func (s *FindingStore) Add(finding types.Finding) (types.Finding, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.next++
+	finding.ID = fmt.Sprintf("finding-%d", s.next)
+	s.findings = append(s.findings, finding)
+	if err := s.save(); err != nil {
+		s.findings = s.findings[:len(s.findings)-1]
+		return types.Finding{}, err
+	}
+	return finding, nil
+}
+
+// Remove deletes a finding by ID and reports whether it existed
+func (s *FindingStore) Remove(id string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, finding := range s.findings {
+		if finding.ID != id {
+			continue
+		}
+		remaining := append(append([]types.Finding(nil), s.findings[:i]...), s.findings[i+1:]...)
+		previous := s.findings
+		s.findings = remaining
+		if err := s.save(); err != nil {
+			s.findings = previous
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// List returns the findings in the order they were flagged, only those of
+// queryID when it is set
+func (s *FindingStore) List(queryID string) []types.Finding {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	findings := []types.Finding{}
+	for _, finding := range s.findings {
+		if queryID == "" || finding.QueryID == queryID {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// save writes the findings to the store's file; findings hold audit data, so
+// the file is only readable by the owner
+func (s *FindingStore) save() error {
+	data, err := json.MarshalIndent(s.findings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode findings: %w", err)
+	}
+	if err := writePrivateFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to save findings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestFindingStore tests adding, listing, removing and reloading findings
+func TestFindingStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "findings.json")
+	store, err := NewFindingStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if findings := store.List(""); len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+
+	for _, queryID := range []string{"q1", "q2", "q1"} {
+		if _, err := store.Add(types.Finding{QueryID: queryID, Note: "suspicious"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if findings := store.List("q1"); len(findings) != 2 || findings[0].ID != "finding-1" || findings[1].ID != "finding-3" {
+		t.Errorf("Unexpected findings of q1: %+v", findings)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Findings file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected findings file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if removed, err := store.Remove("finding-3"); err != nil || !removed {
+		t.Errorf("Expected finding-3 to be removed, got %v, %v", removed, err)
+	}
+	if removed, _ := store.Remove("finding-3"); removed {
+		t.Error("Expected a second removal to find nothing")
+	}
+
+	reloaded, err := NewFindingStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if findings := reloaded.List(""); len(findings) != 2 {
+		t.Errorf("Expected 2 reloaded findings, got %d", len(findings))
+	}
+	finding, err := reloaded.Add(types.Finding{QueryID: "q3"})
+	if err != nil || finding.ID != "finding-3" {
+		t.Errorf("Expected the next ID after the highest kept one, got %s, %v", finding.ID, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("Failed to write findings file: %v", err)
+	}
+	if _, err := NewFindingStore(path); err == nil {
+		t.Error("Expected an error for a corrupt findings file")
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"audit-query-mcp-server/types"
+)
+
+var (
+	// logSourceNamePattern matches custom log source names (DNS labels)
+	logSourceNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+	// logSourcePathPattern matches custom audit log paths: a file ending in .log
+	// in a directory under the node's log directory
+	logSourcePathPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*(/[A-Za-z0-9][A-Za-z0-9_.-]*)*/[A-Za-z0-9][A-Za-z0-9_.-]*\.log$`)
+
+	// builtinLogSources are the log sources known without configuration
+	builtinLogSources = append([]string(nil), ValidLogSources...)
+
+	customLogSources      []types.LogSourceDefinition
+	customLogSourcesMutex sync.RWMutex
+)
+
+// LoadLogSources reads custom log sources from a JSON file of the form
+// {"log_sources": [{"name": ..., "path": ..., "description": ...}, ...]}
+// and validates them
+func LoadLogSources(path string) ([]types.LogSourceDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log sources: %w", err)
+	}
+
+	var config struct {
+		LogSources []types.LogSourceDefinition `json:"log_sources"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse log sources: %w", err)
+	}
+	if err := ValidateLogSourceDefinitions(config.LogSources); err != nil {
+		return nil, err
+	}
+	return config.LogSources, nil
+}
+
+// ValidateLogSourceDefinitions checks that custom log sources have unique DNS
+// label names that do not shadow built-in sources, and relative .log paths
+// inside a directory
+func ValidateLogSourceDefinitions(sources []types.LogSourceDefinition) error {
+	seen := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		if !logSourceNamePattern.MatchString(source.Name) || len(source.Name) > 63 {
+			return fmt.Errorf("invalid log source name: %q", source.Name)
+		}
+		if Contains(builtinLogSources, source.Name) {
+			return fmt.Errorf("log source %s is built in and cannot be redefined", source.Name)
+		}
+		if seen[source.Name] {
+			return fmt.Errorf("duplicate log source: %s", source.Name)
+		}
+		seen[source.Name] = true
+
+		if !logSourcePathPattern.MatchString(source.Path) || strings.Contains(source.Path, "..") {
+			return fmt.Errorf("invalid path for log source %s: %q (expected a relative path such as my-apiserver/audit.log)", source.Name, source.Path)
+		}
+	}
+	return nil
+}
+
+// RegisterLogSources adds custom log sources to ValidLogSources so queries,
+// validation and tool schemas accept them. It is meant to be called at startup;
+// registering a source again with the same path has no effect.
+func RegisterLogSources(sources []types.LogSourceDefinition) error {
+	if err := ValidateLogSourceDefinitions(sources); err != nil {
+		return err
+	}
+
+	customLogSourcesMutex.Lock()
+	defer customLogSourcesMutex.Unlock()
+	for _, source := range sources {
+		if existing, ok := findLogSource(source.Name); ok {
+			if existing.Path != source.Path {
+				return fmt.Errorf("log source %s is already registered with path %s", source.Name, existing.Path)
+			}
+			continue
+		}
+		customLogSources = append(customLogSources, source)
+		ValidLogSources = append(ValidLogSources, source.Name)
+	}
+	return nil
+}
+
+// CustomLogSources returns the registered custom log sources
+func CustomLogSources() []types.LogSourceDefinition {
+	customLogSourcesMutex.RLock()
+	defer customLogSourcesMutex.RUnlock()
+	return append([]types.LogSourceDefinition(nil), customLogSources...)
+}
+
+// CustomLogSourcePath returns the audit log path of a registered custom log source
+func CustomLogSourcePath(name string) (string, bool) {
+	customLogSourcesMutex.RLock()
+	defer customLogSourcesMutex.RUnlock()
+	source, ok := findLogSource(name)
+	return source.Path, ok
+}
+
+// findLogSource returns the registered custom log source with the name; callers
+// hold customLogSourcesMutex
+func findLogSource(name string) (types.LogSourceDefinition, bool) {
+	for _, source := range customLogSources {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return types.LogSourceDefinition{}, false
+}
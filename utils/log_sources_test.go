@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// resetLogSources removes custom log sources registered by a test
+func resetLogSources(t *testing.T) {
+	t.Cleanup(func() {
+		customLogSourcesMutex.Lock()
+		defer customLogSourcesMutex.Unlock()
+		customLogSources = nil
+		ValidLogSources = append([]string(nil), builtinLogSources...)
+	})
+}
+
+// TestLoadLogSources tests reading and validating custom log source files
+func TestLoadLogSources(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "log-sources.json")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	sources, err := LoadLogSources(write(`{"log_sources": [
+		{"name": "metrics-apiserver", "path": "metrics-apiserver/audit.log", "description": "Aggregated metrics API server"},
+		{"name": "custom", "path": "custom/audit/requests.log"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sources) != 2 || sources[0].Name != "metrics-apiserver" || sources[1].Path != "custom/audit/requests.log" {
+		t.Errorf("Unexpected log sources: %+v", sources)
+	}
+
+	invalid := map[string]string{
+		"invalid JSON":      `{"log_sources": [`,
+		"invalid name":      `{"log_sources": [{"name": "Metrics API", "path": "metrics/audit.log"}]}`,
+		"built-in name":     `{"log_sources": [{"name": "kube-apiserver", "path": "kube-apiserver/other.log"}]}`,
+		"duplicate name":    `{"log_sources": [{"name": "a", "path": "a/audit.log"}, {"name": "a", "path": "b/audit.log"}]}`,
+		"absolute path":     `{"log_sources": [{"name": "a", "path": "/var/log/a/audit.log"}]}`,
+		"parent directory":  `{"log_sources": [{"name": "a", "path": "a/../../etc/audit.log"}]}`,
+		"no directory":      `{"log_sources": [{"name": "a", "path": "audit.log"}]}`,
+		"not a log file":    `{"log_sources": [{"name": "a", "path": "a/shadow"}]}`,
+		"shell syntax":      `{"log_sources": [{"name": "a", "path": "a/$(id).log"}]}`,
+		"quote in the path": `{"log_sources": [{"name": "a", "path": "a/x'y.log"}]}`,
+	}
+	for name, content := range invalid {
+		if _, err := LoadLogSources(write(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := LoadLogSources(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestRegisterLogSources tests adding custom log sources to the valid log sources
+func TestRegisterLogSources(t *testing.T) {
+	resetLogSources(t)
+
+	source := types.LogSourceDefinition{Name: "metrics-apiserver", Path: "metrics-apiserver/audit.log"}
+	if err := RegisterLogSources([]types.LogSourceDefinition{source}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !Contains(ValidLogSources, "metrics-apiserver") {
+		t.Error("Expected the custom log source to be valid")
+	}
+	if path, ok := CustomLogSourcePath("metrics-apiserver"); !ok || path != "metrics-apiserver/audit.log" {
+		t.Errorf("Unexpected path %q, %t", path, ok)
+	}
+	if _, ok := CustomLogSourcePath("kube-apiserver"); ok {
+		t.Error("Built-in log sources have no custom path")
+	}
+
+	// Registering the same source again is a no-op; a different path is an error
+	if err := RegisterLogSources([]types.LogSourceDefinition{source}); err != nil {
+		t.Errorf("Unexpected error registering again: %v", err)
+	}
+	if len(ValidLogSources) != len(builtinLogSources)+1 || len(CustomLogSources()) != 1 {
+		t.Errorf("Expected one custom log source, got %v", ValidLogSources)
+	}
+	source.Path = "metrics/audit.log"
+	if err := RegisterLogSources([]types.LogSourceDefinition{source}); err == nil {
+		t.Error("Expected an error registering a different path")
+	}
+}
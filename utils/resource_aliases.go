@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+
+	"audit-query-mcp-server/types"
+)
+
+// ResourceAliases maps the short names and other names users give resources
+// to the resource strings audit events record. Kinds and singular names are
+// resolved by pluralizing them, so only names that cannot be are listed.
+var ResourceAliases = map[string]string{
+	"bc":     "buildconfigs",
+	"cj":     "cronjobs",
+	"cm":     "configmaps",
+	"crd":    "customresourcedefinitions",
+	"csr":    "certificatesigningrequests",
+	"dc":     "deploymentconfigs",
+	"deploy": "deployments",
+	"ds":     "daemonsets",
+	"ep":     "endpoints",
+	"hpa":    "horizontalpodautoscalers",
+	"ing":    "ingresses",
+	"netpol": "networkpolicies",
+	"ns":     "namespaces",
+	"pdb":    "poddisruptionbudgets",
+	"psp":    "podsecuritypolicies",
+	"pv":     "persistentvolumes",
+	"pvc":    "persistentvolumeclaims",
+	"quota":  "resourcequotas",
+	"rs":     "replicasets",
+	"sa":     "serviceaccounts",
+	"sc":     "storageclasses",
+	"scc":    "securitycontextconstraints",
+	"sts":    "statefulsets",
+	"svc":    "services",
+}
+
+// DiscoveredResource is a resource listed by a cluster's API discovery
+type DiscoveredResource struct {
+	// Name is the plural resource name audit events record
+	Name       string
+	ShortNames []string
+	Kind       string
+	Group      string
+}
+
+// discoveredResources holds the names of discovered resources, which take
+// precedence over the built-in dictionary
+var discoveredResources = struct {
+	sync.RWMutex
+	aliases map[string]string
+}{aliases: make(map[string]string)}
+
+// knownResources are the resource names of the validation list and the
+// resources aliases resolve to
+var knownResources = func() map[string]bool {
+	resources := make(map[string]bool, len(ValidResources)+len(ResourceAliases))
+	for _, resource := range ValidResources {
+		resources[resource] = true
+	}
+	for _, resource := range ResourceAliases {
+		resources[resource] = true
+	}
+	return resources
+}()
+
+// RegisterResources adds resources listed by API discovery to the
+// dictionary: their names, kinds and short names, each also qualified by the
+// API group, as in "virtualmachines.kubevirt.io". It returns the number of
+// resources registered.
+func RegisterResources(resources []DiscoveredResource) int {
+	discoveredResources.Lock()
+	defer discoveredResources.Unlock()
+	registered := 0
+	for _, resource := range resources {
+		name := strings.ToLower(resource.Name)
+		if name == "" {
+			continue
+		}
+		registered++
+		aliases := append([]string{name, strings.ToLower(resource.Kind)}, resource.ShortNames...)
+		for _, alias := range aliases {
+			alias = strings.ToLower(alias)
+			if alias == "" {
+				continue
+			}
+			discoveredResources.aliases[alias] = name
+			if resource.Group != "" {
+				discoveredResources.aliases[alias+"."+strings.ToLower(resource.Group)] = name
+			}
+		}
+	}
+	return registered
+}
+
+// ResolveResource returns the resource string audit events record for a
+// resource name as users write it: a resource, its kind or singular name, a
+// short name such as "crd" or its plural "crds", optionally qualified by the
+// API group as in "deployments.apps". Discovered resources are resolved
+// before the built-in dictionary. Unknown names are returned lower-cased
+// with ok false.
+func ResolveResource(name string) (resource string, ok bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if resource, ok := lookupResource(key); ok {
+		return resource, true
+	}
+	if i := strings.IndexByte(key, '.'); i > 0 {
+		if resource, ok := lookupResource(key[:i]); ok {
+			return resource, true
+		}
+	}
+	return key, false
+}
+
+// ResolveQueryResources replaces the resource filters and exclusions of
+// query parameters with the resources they resolve to, so they match the
+// resource strings of audit events. Resource filters are only resolved for
+// exact matches; exclusions ending in "*" and unknown names are kept.
+func ResolveQueryResources(params *types.AuditQueryParams) {
+	exact := params.ResourceMatch == "" || params.ResourceMatch == types.MatchModeExact
+	if exact {
+		params.Resource = resolveResourceValue(params.Resource)
+		for i, resource := range params.Resources {
+			params.Resources[i] = resolveResourceValue(resource)
+		}
+	}
+	for i, resource := range params.ExcludeResources {
+		params.ExcludeResources[i] = resolveResourceValue(resource)
+	}
+}
+
+// resolveResourceValue resolves a known resource name, keeping patterns and
+// unknown names as given
+func resolveResourceValue(value string) string {
+	if value == "" || strings.HasSuffix(value, "*") {
+		return value
+	}
+	if resource, ok := ResolveResource(value); ok {
+		return resource
+	}
+	return value
+}
+
+// lookupResource resolves an unqualified or discovered qualified name
+func lookupResource(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	discoveredResources.RLock()
+	defer discoveredResources.RUnlock()
+
+	// Aliases, then the plurals of short names such as "pvcs"
+	keys := []string{key}
+	if singular := strings.TrimSuffix(key, "s"); singular != key {
+		keys = append(keys, singular)
+	}
+	for _, alias := range keys {
+		if resource, ok := discoveredResources.aliases[alias]; ok {
+			return resource, true
+		}
+		if resource, ok := ResourceAliases[alias]; ok {
+			return resource, true
+		}
+	}
+
+	// Plurals of singular names, then the plural names themselves
+	candidates := []string{key + "s", key + "es"}
+	if strings.HasSuffix(key, "y") {
+		candidates = append(candidates, strings.TrimSuffix(key, "y")+"ies")
+	}
+	for _, plural := range candidates {
+		if knownResources[plural] {
+			return plural, true
+		}
+	}
+	if knownResources[key] {
+		return key, true
+	}
+	return "", false
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+func TestResolveResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		ok       bool
+	}{
+		{"pods", "pods", true},
+		{"Pod", "pods", true},
+		{"CRD", "customresourcedefinitions", true},
+		{"crds", "customresourcedefinitions", true},
+		{"customresourcedefinition", "customresourcedefinitions", true},
+		{"CustomResourceDefinition", "customresourcedefinitions", true},
+		{"pvcs", "persistentvolumeclaims", true},
+		{"svc", "services", true},
+		{"NetworkPolicy", "networkpolicies", true},
+		{"ingress", "ingresses", true},
+		{"deployments.apps", "deployments", true},
+		{"endpoints", "endpoints", true},
+		{"Gadget", "gadget", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, ok := ResolveResource(tt.name)
+			if resource != tt.resource || ok != tt.ok {
+				t.Errorf("Expected %q, %v, got %q, %v", tt.resource, tt.ok, resource, ok)
+			}
+		})
+	}
+}
+
+func TestRegisterResources(t *testing.T) {
+	t.Cleanup(func() {
+		discoveredResources.Lock()
+		discoveredResources.aliases = make(map[string]string)
+		discoveredResources.Unlock()
+	})
+
+	registered := RegisterResources([]DiscoveredResource{
+		{Name: "gadgets", ShortNames: []string{"gdg"}, Kind: "Gadget", Group: "example.com"},
+		{Name: "dashboards", ShortNames: []string{"svc"}, Kind: "Dashboard"},
+		{Kind: "Nameless"},
+	})
+	if registered != 2 {
+		t.Errorf("Expected 2 resources registered, got %d", registered)
+	}
+
+	for _, name := range []string{"gadgets", "Gadget", "gdg", "gdgs", "gadget.example.com", "gadgets.example.com"} {
+		if resource, ok := ResolveResource(name); resource != "gadgets" || !ok {
+			t.Errorf("Expected %q to resolve to gadgets, got %q, %v", name, resource, ok)
+		}
+	}
+	// Discovered short names take precedence over the built-in dictionary
+	if resource, _ := ResolveResource("svc"); resource != "dashboards" {
+		t.Errorf("Expected the discovered short name, got %q", resource)
+	}
+}
+
+func TestResolveQueryResources(t *testing.T) {
+	params := types.AuditQueryParams{
+		Resource:         "crd",
+		Resources:        []string{"Deployment", "widgets"},
+		ExcludeResources: []string{"cm", "secret*"},
+	}
+	ResolveQueryResources(&params)
+	expected := types.AuditQueryParams{
+		Resource:         "customresourcedefinitions",
+		Resources:        []string{"deployments", "widgets"},
+		ExcludeResources: []string{"configmaps", "secret*"},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, params)
+	}
+
+	params = types.AuditQueryParams{Resource: "pod", ResourceMatch: types.MatchModePrefix}
+	ResolveQueryResources(&params)
+	if params.Resource != "pod" {
+		t.Errorf("Expected prefix matches kept, got %q", params.Resource)
+	}
+}
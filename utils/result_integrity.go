@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"audit-query-mcp-server/types"
+)
+
+// resultHashes returns the SHA-256 of a result's raw output and of its parsed
+// data serialized as JSON, which orders object keys
+func resultHashes(result *types.AuditResult) (string, string, error) {
+	rawSum := sha256.Sum256([]byte(result.RawOutput))
+	parsed, err := json.Marshal(result.ParsedData)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode parsed data: %w", err)
+	}
+	parsedSum := sha256.Sum256(parsed)
+	return hex.EncodeToString(rawSum[:]), hex.EncodeToString(parsedSum[:]), nil
+}
+
+// resultSignature returns the HMAC-SHA256 with key of a result's query ID and
+// hashes
+func resultSignature(key []byte, queryID string, integrity *types.ResultIntegrity) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{queryID, integrity.RawOutputHash, integrity.ParsedDataHash}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ComputeResultIntegrity hashes a result's raw output and parsed data, and
+// signs the hashes when key is set
+func ComputeResultIntegrity(result *types.AuditResult, key []byte) (*types.ResultIntegrity, error) {
+	rawHash, parsedHash, err := resultHashes(result)
+	if err != nil {
+		return nil, err
+	}
+	integrity := &types.ResultIntegrity{Algorithm: types.IntegrityHashSHA256, RawOutputHash: rawHash, ParsedDataHash: parsedHash}
+	if len(key) > 0 {
+		integrity.SignatureAlgorithm = types.IntegritySignatureHMAC
+		integrity.Signature = resultSignature(key, result.QueryID, integrity)
+	}
+	return integrity, nil
+}
+
+// VerifyResultIntegrity re-computes a result's hashes and checks them, and the
+// signature when the result is signed, against its integrity record. A signed
+// result only verifies with the key it was signed with, since anyone changing
+// the data could also replace the hashes.
+func VerifyResultIntegrity(result *types.AuditResult, key []byte) types.ResultVerification {
+	verification := types.ResultVerification{QueryID: result.QueryID, Issues: []string{}}
+	integrity := result.Integrity
+	if integrity == nil {
+		verification.Issues = append(verification.Issues, "the result has no integrity hashes")
+		verification.Summary = summarizeResultVerification(verification)
+		return verification
+	}
+	if integrity.Algorithm != types.IntegrityHashSHA256 {
+		verification.Issues = append(verification.Issues, fmt.Sprintf("unsupported hash algorithm %q", integrity.Algorithm))
+		verification.Summary = summarizeResultVerification(verification)
+		return verification
+	}
+
+	rawHash, parsedHash, err := resultHashes(result)
+	if err != nil {
+		verification.Issues = append(verification.Issues, err.Error())
+		verification.Summary = summarizeResultVerification(verification)
+		return verification
+	}
+	verification.RawOutputValid = rawHash == integrity.RawOutputHash
+	if !verification.RawOutputValid {
+		verification.Issues = append(verification.Issues, "raw_output does not match its hash")
+	}
+	verification.ParsedDataValid = parsedHash == integrity.ParsedDataHash
+	if !verification.ParsedDataValid {
+		verification.Issues = append(verification.Issues, "parsed_data does not match its hash")
+	}
+
+	verification.Signed = integrity.Signature != ""
+	switch {
+	case !verification.Signed:
+	case integrity.SignatureAlgorithm != types.IntegritySignatureHMAC:
+		verification.Issues = append(verification.Issues, fmt.Sprintf("unsupported signature algorithm %q", integrity.SignatureAlgorithm))
+	case len(key) == 0:
+		verification.Issues = append(verification.Issues, "the result is signed but no signing key is configured to check the signature")
+	default:
+		verification.SignatureValid = hmac.Equal([]byte(resultSignature(key, result.QueryID, integrity)), []byte(integrity.Signature))
+		if !verification.SignatureValid {
+			verification.Issues = append(verification.Issues, "the signature does not match the query ID and hashes")
+		}
+	}
+
+	verification.Valid = len(verification.Issues) == 0
+	verification.Summary = summarizeResultVerification(verification)
+	return verification
+}
+
+// summarizeResultVerification produces a short human-readable description of a verification
+func summarizeResultVerification(verification types.ResultVerification) string {
+	if !verification.Valid {
+		return fmt.Sprintf("result %s failed verification: %s", verification.QueryID, strings.Join(verification.Issues, "; "))
+	}
+	if verification.Signed {
+		return fmt.Sprintf("result %s is intact and its signature is valid", verification.QueryID)
+	}
+	return fmt.Sprintf("result %s matches its hashes; it is not signed, so the hashes could have been replaced", verification.QueryID)
+}
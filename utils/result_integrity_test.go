@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"audit-query-mcp-server/types"
+)
+
+// integrityTestResult returns a result with one parsed entry
+func integrityTestResult() *types.AuditResult {
+	return &types.AuditResult{
+		QueryID:   "audit_query_1",
+		RawOutput: `{"auditID":"a1","verb":"delete"}`,
+		ParsedData: []map[string]interface{}{
+			{"audit_id": "a1", "verb": "delete", "status_code": 200, "groups": []string{"system:authenticated"}},
+		},
+	}
+}
+
+// TestVerifyResultIntegrity_Hashes tests unsigned results, including after a JSON round trip
+func TestVerifyResultIntegrity_Hashes(t *testing.T) {
+	result := integrityTestResult()
+	integrity, err := ComputeResultIntegrity(result, nil)
+	if err != nil {
+		t.Fatalf("Failed to compute integrity: %v", err)
+	}
+	if integrity.Algorithm != types.IntegrityHashSHA256 || len(integrity.RawOutputHash) != 64 || integrity.Signature != "" {
+		t.Fatalf("Expected unsigned SHA-256 hashes, got %+v", integrity)
+	}
+	result.Integrity = integrity
+
+	// Results read back from the persisted cache or an export still verify
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to encode result: %v", err)
+	}
+	var stored types.AuditResult
+	if err := json.Unmarshal(encoded, &stored); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	verification := VerifyResultIntegrity(&stored, nil)
+	if !verification.Valid || verification.Signed || !verification.RawOutputValid || !verification.ParsedDataValid {
+		t.Errorf("Expected a valid unsigned result, got %+v", verification)
+	}
+
+	stored.ParsedData[0]["verb"] = "get"
+	verification = VerifyResultIntegrity(&stored, nil)
+	if verification.Valid || verification.ParsedDataValid || !verification.RawOutputValid {
+		t.Errorf("Expected modified parsed data to fail verification, got %+v", verification)
+	}
+
+	stored.Integrity = nil
+	if verification := VerifyResultIntegrity(&stored, nil); verification.Valid || len(verification.Issues) != 1 {
+		t.Errorf("Expected a result without hashes to fail verification, got %+v", verification)
+	}
+}
+
+// TestVerifyResultIntegrity_Signature tests signed results and the key they verify with
+func TestVerifyResultIntegrity_Signature(t *testing.T) {
+	key := []byte("evidence-key")
+	result := integrityTestResult()
+	integrity, err := ComputeResultIntegrity(result, key)
+	if err != nil {
+		t.Fatalf("Failed to compute integrity: %v", err)
+	}
+	if integrity.SignatureAlgorithm != types.IntegritySignatureHMAC || integrity.Signature == "" {
+		t.Fatalf("Expected a signature, got %+v", integrity)
+	}
+	result.Integrity = integrity
+
+	if verification := VerifyResultIntegrity(result, key); !verification.Valid || !verification.SignatureValid {
+		t.Errorf("Expected a valid signature, got %+v", verification)
+	}
+	if verification := VerifyResultIntegrity(result, []byte("other-key")); verification.Valid || verification.SignatureValid {
+		t.Errorf("Expected another key to fail verification, got %+v", verification)
+	}
+	if verification := VerifyResultIntegrity(result, nil); verification.Valid {
+		t.Errorf("Expected a signed result to need the key, got %+v", verification)
+	}
+
+	// Re-hashing tampered output does not produce a valid signature
+	result.RawOutput = `{"auditID":"a2","verb":"delete"}`
+	rehashed, err := ComputeResultIntegrity(result, nil)
+	if err != nil {
+		t.Fatalf("Failed to compute integrity: %v", err)
+	}
+	rehashed.SignatureAlgorithm, rehashed.Signature = integrity.SignatureAlgorithm, integrity.Signature
+	result.Integrity = rehashed
+	verification := VerifyResultIntegrity(result, key)
+	if verification.Valid || !verification.RawOutputValid || verification.SignatureValid {
+		t.Errorf("Expected replaced hashes to fail the signature check, got %+v", verification)
+	}
+
+	// The signature is bound to the query ID
+	result.Integrity = integrity
+	result.RawOutput = integrityTestResult().RawOutput
+	result.QueryID = "audit_query_2"
+	if verification := VerifyResultIntegrity(result, key); verification.Valid {
+		t.Errorf("Expected a signature moved to another query ID to fail verification, got %+v", verification)
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// SlowQueryLog appends the MCP requests that took at least its threshold to
+// a JSON lines file, one RequestLogEntry per line
+type SlowQueryLog struct {
+	path      string
+	threshold time.Duration
+	mutex     sync.Mutex
+	// recorded counts the requests written to the log
+	recorded int64
+}
+
+// NewSlowQueryLog returns a log of the requests taking at least threshold
+// written to path, whose directory is created if needed
+func NewSlowQueryLog(path string, threshold time.Duration) (*SlowQueryLog, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file path cannot be empty")
+	}
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be positive")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create slow query log directory: %w", err)
+	}
+	return &SlowQueryLog{path: path, threshold: threshold}, nil
+}
+
+// Threshold returns the duration from which requests are logged
+func (l *SlowQueryLog) Threshold() time.Duration {
+	return l.threshold
+}
+
+// Path returns the file the log is written to
+func (l *SlowQueryLog) Path() string {
+	return l.path
+}
+
+// Recorded returns the number of requests written to the log
+func (l *SlowQueryLog) Recorded() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.recorded
+}
+
+// Record appends the entry when its duration reaches the threshold and
+// reports whether it did. The arguments of logged requests hold audit
+// filters, so the file is only readable by the owner.
+func (l *SlowQueryLog) Record(entry types.RequestLogEntry, duration time.Duration) (bool, error) {
+	if duration < l.threshold {
+		return false, nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode slow query: %w", err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, fmt.Errorf("failed to open slow query log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return false, fmt.Errorf("failed to write slow query log: %w", err)
+	}
+	l.recorded++
+	return true, nil
+}
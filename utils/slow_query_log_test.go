@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestSlowQueryLog tests that only requests reaching the threshold are appended
+func TestSlowQueryLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "slow_queries.json")
+	log, err := NewSlowQueryLog(path, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if recorded, err := log.Record(types.RequestLogEntry{Method: "tools/call", Tool: "get_cache_stats"}, 10*time.Millisecond); err != nil || recorded {
+		t.Errorf("Expected a fast request not to be recorded, got %v, %v", recorded, err)
+	}
+	for _, tool := range []string{"execute_complete_audit_query", "find_top_talkers"} {
+		if recorded, err := log.Record(types.RequestLogEntry{Method: "tools/call", Tool: tool, DurationMs: 2000}, 2*time.Second); err != nil || !recorded {
+			t.Errorf("Expected %s to be recorded, got %v, %v", tool, recorded, err)
+		}
+	}
+	if log.Recorded() != 2 {
+		t.Errorf("Expected 2 recorded requests, got %d", log.Recorded())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Slow query log not written: %v", err)
+	}
+	defer file.Close()
+	var tools []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry types.RequestLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid slow query log line %q: %v", scanner.Text(), err)
+		}
+		tools = append(tools, entry.Tool)
+	}
+	if len(tools) != 2 || tools[0] != "execute_complete_audit_query" || tools[1] != "find_top_talkers" {
+		t.Errorf("Unexpected slow queries: %v", tools)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected slow query log mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if _, err := NewSlowQueryLog(path, 0); err == nil {
+		t.Error("Expected an error for a zero threshold")
+	}
+}
@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog transports
+const (
+	SyslogUDP = "udp"
+	SyslogTCP = "tcp"
+	SyslogTLS = "tls"
+)
+
+// Syslog severities (RFC 5424 section 6.2.1)
+const (
+	SyslogSeverityAlert   = 1
+	SyslogSeverityError   = 3
+	SyslogSeverityWarning = 4
+	SyslogSeverityNotice  = 5
+	SyslogSeverityInfo    = 6
+)
+
+// SyslogFacilities maps facility names to their RFC 5424 codes
+var SyslogFacilities = map[string]int{
+	"auth":     4,
+	"authpriv": 10,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// Syslog message settings
+const (
+	SyslogAppName = "audit-query-mcp"
+	// SyslogSDID is the structured data ID of audit trail fields; 32473 is the
+	// enterprise number IANA reserves for documentation and examples
+	SyslogSDID = "audit@32473"
+	// SyslogMaxUDPMessage keeps UDP datagrams within the size RFC 5426 says
+	// receivers should accept
+	SyslogMaxUDPMessage = 2048
+)
+
+// SyslogConfig configures a SyslogWriter
+type SyslogConfig struct {
+	Network  string // udp, tcp or tls
+	Address  string // host:port
+	Facility string // defaults to local0
+	// CAFile is a PEM bundle used to verify the server for tls; the system
+	// roots are used when empty
+	CAFile  string
+	Timeout time.Duration
+}
+
+// SyslogWriter sends RFC 5424 messages to a syslog endpoint. TCP and TLS use
+// octet-counting framing (RFC 6587). A broken connection is re-dialed once per write.
+type SyslogWriter struct {
+	config    SyslogConfig
+	facility  int
+	hostname  string
+	tlsConfig *tls.Config
+	mutex     sync.Mutex
+	conn      net.Conn
+}
+
+// NewSyslogWriter validates the config and connects to the syslog endpoint
+func NewSyslogWriter(config SyslogConfig) (*SyslogWriter, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("syslog address cannot be empty")
+	}
+	if config.Network == "" {
+		config.Network = SyslogUDP
+	}
+	if config.Network != SyslogUDP && config.Network != SyslogTCP && config.Network != SyslogTLS {
+		return nil, fmt.Errorf("unsupported syslog network %q (supported: udp, tcp, tls)", config.Network)
+	}
+	if config.Facility == "" {
+		config.Facility = "local0"
+	}
+	facility, ok := SyslogFacilities[config.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unsupported syslog facility: %s", config.Facility)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	writer := &SyslogWriter{
+		config:   config,
+		facility: facility,
+		hostname: hostname,
+	}
+
+	if config.Network == SyslogTLS {
+		host, _, err := net.SplitHostPort(config.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog address: %w", err)
+		}
+		writer.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if config.CAFile != "" {
+			pem, err := os.ReadFile(config.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read syslog CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in syslog CA file")
+			}
+			writer.tlsConfig.RootCAs = pool
+		}
+	}
+
+	if err := writer.connect(); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// connect dials the syslog endpoint; the caller must hold the mutex or own the writer
+func (w *SyslogWriter) connect() error {
+	dialer := &net.Dialer{Timeout: w.config.Timeout}
+
+	var conn net.Conn
+	var err error
+	if w.config.Network == SyslogTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", w.config.Address, w.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(w.config.Network, w.config.Address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog %s: %w", w.config.Address, err)
+	}
+	w.conn = conn
+	return nil
+}
+
+// Write sends one message with the given severity, message ID, structured data
+// parameters and free-form text
+func (w *SyslogWriter) Write(severity int, msgID string, params map[string]string, message string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	line := FormatRFC5424(w.facility*8+severity, time.Now(), w.hostname, SyslogAppName, msgID, params, message)
+	if w.config.Network == SyslogUDP {
+		if len(line) > SyslogMaxUDPMessage {
+			line = line[:SyslogMaxUDPMessage]
+		}
+	} else {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return err
+		}
+	}
+	if err := w.send(line); err != nil {
+		// The endpoint may have closed an idle connection; re-dial once
+		w.conn.Close()
+		w.conn = nil
+		if err := w.connect(); err != nil {
+			return err
+		}
+		if err := w.send(line); err != nil {
+			return fmt.Errorf("failed to write syslog message: %w", err)
+		}
+	}
+	return nil
+}
+
+// send writes a framed message on the current connection
+func (w *SyslogWriter) send(line string) error {
+	w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+	_, err := w.conn.Write([]byte(line))
+	return err
+}
+
+// WriteAlert sends an alert-severity message, for conditions a compliance
+// team must act on
+func (w *SyslogWriter) WriteAlert(msgID string, params map[string]string, message string) error {
+	return w.Write(SyslogSeverityAlert, msgID, params, message)
+}
+
+// WriteAuditTrailEntry sends an audit trail entry. The query ID, action, error
+// and execution time go in structured data; the result itself is left out to
+// keep messages small.
+func (w *SyslogWriter) WriteAuditTrailEntry(entry AuditTrailEntry) error {
+	params := map[string]string{
+		"queryID":         entry.QueryID,
+		"action":          entry.Action,
+		"executionTimeMs": fmt.Sprintf("%d", entry.ExecutionTime),
+	}
+	if entry.UserID != "" {
+		params["userID"] = entry.UserID
+	}
+	if entry.IPAddress != "" {
+		params["ipAddress"] = entry.IPAddress
+	}
+	if entry.Hash != "" {
+		params["seq"] = fmt.Sprintf("%d", entry.Sequence)
+		params["hash"] = entry.Hash
+	}
+
+	severity := SyslogSeverityInfo
+	message := fmt.Sprintf("%s %s", entry.Action, entry.QueryID)
+	if entry.Result != nil && entry.Result.Command != "" {
+		message += ": " + entry.Result.Command
+	}
+	if entry.Error != "" {
+		severity = SyslogSeverityWarning
+		params["error"] = entry.Error
+		message += " failed: " + entry.Error
+	}
+
+	return w.Write(severity, entry.Action, params, message)
+}
+
+// Close closes the connection to the syslog endpoint
+func (w *SyslogWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// FormatRFC5424 builds an RFC 5424 syslog message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID PARAM="VALUE"...] MSG
+func FormatRFC5424(priority int, timestamp time.Time, hostname, appName, msgID string, params map[string]string, message string) string {
+	structuredData := "-"
+	if len(params) > 0 {
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var sd strings.Builder
+		sd.WriteString("[" + SyslogSDID)
+		for _, name := range names {
+			fmt.Fprintf(&sd, ` %s="%s"`, name, escapeSDValue(params[name]))
+		}
+		sd.WriteString("]")
+		structuredData = sd.String()
+	}
+
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s %s",
+		priority,
+		timestamp.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		syslogField(hostname, 255),
+		syslogField(appName, 48),
+		os.Getpid(),
+		syslogField(msgID, 32),
+		structuredData)
+	if message != "" {
+		line += " " + message
+	}
+	return line
+}
+
+// syslogField returns "-" for empty header fields and strips characters RFC 5424 forbids
+func syslogField(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+// escapeSDValue escapes '"', '\' and ']' in structured data parameter values
+func escapeSDValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"audit-query-mcp-server/types"
+)
+
+// TestFormatRFC5424 tests the syslog message layout
+func TestFormatRFC5424(t *testing.T) {
+	timestamp := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	line := FormatRFC5424(16*8+SyslogSeverityInfo, timestamp, "host 1", SyslogAppName, "complete_query",
+		map[string]string{"queryID": "q1", "error": `bad "value"]`}, "hello")
+
+	prefix := "<134>1 2024-01-15T10:00:00.000000Z host1 audit-query-mcp " + strconv.Itoa(os.Getpid()) + " complete_query "
+	if !strings.HasPrefix(line, prefix) {
+		t.Errorf("Expected prefix %q, got %q", prefix, line)
+	}
+	if !strings.HasSuffix(line, `[audit@32473 error="bad \"value\"\]" queryID="q1"] hello`) {
+		t.Errorf("Unexpected structured data or message: %q", line)
+	}
+
+	line = FormatRFC5424(SyslogSeverityAlert, timestamp, "", "", "", nil, "")
+	if !strings.HasSuffix(line, " - - "+strconv.Itoa(os.Getpid())+" - -") {
+		t.Errorf("Expected nil values for empty fields, got %q", line)
+	}
+}
+
+// TestNewSyslogWriter_Validation tests config validation
+func TestNewSyslogWriter_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SyslogConfig
+	}{
+		{name: "missing address", config: SyslogConfig{}},
+		{name: "unsupported network", config: SyslogConfig{Network: "http", Address: "localhost:514"}},
+		{name: "unsupported facility", config: SyslogConfig{Address: "localhost:514", Facility: "kern"}},
+		{name: "missing CA file", config: SyslogConfig{Network: SyslogTLS, Address: "localhost:6514", CAFile: "testdata/missing.pem"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSyslogWriter(tt.config); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+// TestSyslogWriter_UDP tests that audit trail entries are sent as datagrams
+func TestSyslogWriter_UDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	writer, err := NewSyslogWriter(SyslogConfig{Address: listener.LocalAddr().String(), Facility: "auth"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer writer.Close()
+
+	err = writer.WriteAuditTrailEntry(AuditTrailEntry{
+		QueryID:       "q1",
+		Action:        "query_execution",
+		Error:         "command failed",
+		ExecutionTime: 42,
+		Result:        &types.AuditResult{Command: "oc adm node-logs --role=master"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	buf := make([]byte, SyslogMaxUDPMessage)
+	listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read datagram: %v", err)
+	}
+	message := string(buf[:n])
+
+	// auth (4) * 8 + warning (4)
+	for _, want := range []string{"<36>1 ", " query_execution [audit@32473 ", `executionTimeMs="42"`, `queryID="q1"`,
+		"] query_execution q1: oc adm node-logs --role=master failed: command failed"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected message to contain %q, got %q", want, message)
+		}
+	}
+}
+
+// TestSyslogWriter_TCP tests octet-counting framing and reconnecting after the server drops the connection
+func TestSyslogWriter_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			length, err := reader.ReadString(' ')
+			if err == nil {
+				size, _ := strconv.Atoi(strings.TrimSpace(length))
+				body := make([]byte, size)
+				if _, err := io.ReadFull(reader, body); err == nil {
+					messages <- string(body)
+				}
+			}
+			// Drop the connection so the writer has to re-dial
+			conn.Close()
+		}
+	}()
+
+	writer, err := NewSyslogWriter(SyslogConfig{Network: SyslogTCP, Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer writer.Close()
+
+	if err := writer.WriteAlert("scheduled_query", map[string]string{"rule": "secret-deletes"}, "3 matches"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case message := <-messages:
+		// local0 (16) * 8 + alert (1)
+		if !strings.HasPrefix(message, "<129>1 ") || !strings.HasSuffix(message, `[audit@32473 rule="secret-deletes"] 3 matches`) {
+			t.Errorf("Unexpected alert message %q", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for alert")
+	}
+
+	// Writes on the dropped connection may succeed until the peer's reset arrives,
+	// so keep writing until the re-dialed connection delivers a message
+	deadline := time.After(2 * time.Second)
+	for {
+		if err := writer.Write(SyslogSeverityNotice, "test", nil, "after reconnect"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		select {
+		case message := <-messages:
+			if !strings.HasSuffix(message, "after reconnect") {
+				t.Errorf("Unexpected message %q", message)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Timed out waiting for message after reconnect")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// TestAuditTrail_Syslog tests that audit trail entries are mirrored to syslog
+func TestAuditTrail_Syslog(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	writer, err := NewSyslogWriter(SyslogConfig{Address: listener.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "audit_trail.json")
+	trail, err := NewAuditTrail(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trail.SetSyslog(writer)
+	defer trail.Close()
+
+	if err := trail.LogCacheAccess("q1", "hit", "", "", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil || !strings.Contains(string(content), `"cache_hit"`) {
+		t.Errorf("Expected entry in audit trail file, got %q (%v)", content, err)
+	}
+
+	buf := make([]byte, SyslogMaxUDPMessage)
+	listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read datagram: %v", err)
+	}
+	if !strings.Contains(string(buf[:n]), " cache_hit [audit@32473 ") {
+		t.Errorf("Unexpected syslog message %q", buf[:n])
+	}
+
+	if _, err := NewSyslogAuditTrail(nil); err == nil {
+		t.Error("Expected error for nil syslog writer")
+	}
+}
@@ -1,5 +1,12 @@
 package utils
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
 // Contains checks if a slice contains a string
 func Contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -9,3 +16,35 @@ func Contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+var (
+	statusClassPattern = regexp.MustCompile(`^([1-5])xx$`)
+	statusSpanPattern  = regexp.MustCompile(`^(\d{3})-(\d{3})$`)
+)
+
+// ParseStatusCodeRange resolves a status code range to the codes it selects. Named
+// ranges from StatusCodeRanges return their explicit codes; classes such as "4xx"
+// and spans such as "400-499" return inclusive low and high bounds instead.
+func ParseStatusCodeRange(value string) (codes []int, low, high int, err error) {
+	if named, ok := StatusCodeRanges[value]; ok {
+		codes = append([]int{}, named...)
+		sort.Ints(codes)
+		return codes, 0, 0, nil
+	}
+
+	if match := statusClassPattern.FindStringSubmatch(value); match != nil {
+		class, _ := strconv.Atoi(match[1])
+		return nil, class * 100, class*100 + 99, nil
+	}
+
+	if match := statusSpanPattern.FindStringSubmatch(value); match != nil {
+		low, _ = strconv.Atoi(match[1])
+		high, _ = strconv.Atoi(match[2])
+		if low < 100 || high > 599 || low > high {
+			return nil, 0, 0, fmt.Errorf("status code range %s must be within 100-599 with low <= high", value)
+		}
+		return nil, low, high, nil
+	}
+
+	return nil, 0, 0, fmt.Errorf("unknown status code range: %s", value)
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseStatusCodeRange tests resolution of named, class and span status code ranges
+func TestParseStatusCodeRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantCodes []int
+		wantLow   int
+		wantHigh  int
+		wantErr   bool
+	}{
+		{name: "named range", value: "auth_error", wantCodes: []int{401, 403}},
+		{name: "status class", value: "4xx", wantLow: 400, wantHigh: 499},
+		{name: "numeric span", value: "500-504", wantLow: 500, wantHigh: 504},
+		{name: "reversed span", value: "504-500", wantErr: true},
+		{name: "span out of range", value: "000-099", wantErr: true},
+		{name: "unknown name", value: "teapots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, low, high, err := ParseStatusCodeRange(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStatusCodeRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("ParseStatusCodeRange(%q) codes = %v, want %v", tt.value, codes, tt.wantCodes)
+			}
+			if low != tt.wantLow || high != tt.wantHigh {
+				t.Errorf("ParseStatusCodeRange(%q) bounds = %d-%d, want %d-%d", tt.value, low, high, tt.wantLow, tt.wantHigh)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AllowedJQFunctions contains the jq builtins generated jq programs may call
+var AllowedJQFunctions = map[string]bool{
+	"select":         true,
+	"test":           true,
+	"match":          true,
+	"any":            true,
+	"all":            true,
+	"index":          true,
+	"not":            true,
+	"tostring":       true,
+	"tonumber":       true,
+	"length":         true,
+	"contains":       true,
+	"startswith":     true,
+	"endswith":       true,
+	"ascii_downcase": true,
+	"has":            true,
+	"map":            true,
+	"empty":          true,
+}
+
+// allowedJQKeywords contains the jq keywords and literals generated jq programs
+// may use; def, import, include, reduce, foreach, label and try are not generated
+var allowedJQKeywords = map[string]bool{
+	"and":   true,
+	"or":    true,
+	"as":    true,
+	"if":    true,
+	"then":  true,
+	"elif":  true,
+	"else":  true,
+	"end":   true,
+	"true":  true,
+	"false": true,
+	"null":  true,
+}
+
+// jqProgramPattern matches the single-quoted program of a jq stage
+var jqProgramPattern = regexp.MustCompile(`(?:^|[|(]\s*)jq(?:\s+-[A-Za-z]+)*\s+'([^']*)'`)
+
+// JQPrograms returns the jq programs of the jq stages in a generated command
+func JQPrograms(command string) []string {
+	var programs []string
+	for _, match := range jqProgramPattern.FindAllStringSubmatch(command, -1) {
+		programs = append(programs, match[1])
+	}
+	return programs
+}
+
+// ValidateJQExpression lints a generated jq program: string literals must be
+// terminated and use only JSON escapes, brackets must balance, variables must be
+// bound with "as", and only whitelisted builtins and keywords may appear. Single
+// quotes are rejected since the program is passed as one single-quoted word.
+func ValidateJQExpression(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("jq expression is empty")
+	}
+
+	var brackets []byte
+	bound := map[string]bool{}
+	closing := map[byte]byte{')': '(', ']': '[', '}': '{'}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end, err := scanJQString(expr, i)
+			if err != nil {
+				return err
+			}
+			i = end
+		case c == '(' || c == '[' || c == '{':
+			brackets = append(brackets, c)
+			i++
+		case c == ')' || c == ']' || c == '}':
+			if len(brackets) == 0 || brackets[len(brackets)-1] != closing[c] {
+				return fmt.Errorf("jq expression has unbalanced %q at offset %d", c, i)
+			}
+			brackets = brackets[:len(brackets)-1]
+			i++
+		case c == '$':
+			name := scanJQIdentifier(expr, i+1)
+			if name == "" {
+				return fmt.Errorf("jq expression has a bare $ at offset %d", i)
+			}
+			if !bound[name] {
+				if !jqPrecededBy(expr, i, "as") {
+					return fmt.Errorf("jq expression uses unbound variable $%s", name)
+				}
+				bound[name] = true
+			}
+			i += 1 + len(name)
+		case isJQIdentifierStart(c):
+			name := scanJQIdentifier(expr, i)
+			inObject := len(brackets) > 0 && brackets[len(brackets)-1] == '{'
+			if err := checkJQIdentifier(expr, i, name, inObject); err != nil {
+				return err
+			}
+			i += len(name)
+		case c >= '0' && c <= '9':
+			i++
+		case strings.IndexByte(".|,:;=!<>+-*/%?", c) >= 0:
+			i++
+		default:
+			return fmt.Errorf("jq expression contains unsupported character %q at offset %d", c, i)
+		}
+	}
+
+	if len(brackets) > 0 {
+		return fmt.Errorf("jq expression has unclosed %q", brackets[len(brackets)-1])
+	}
+	return nil
+}
+
+// scanJQString returns the offset after the string literal starting at start
+func scanJQString(expr string, start int) (int, error) {
+	for i := start + 1; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '"':
+			return i + 1, nil
+		case c < 0x20:
+			return 0, fmt.Errorf("jq string at offset %d contains a control character", start)
+		case c == '\'':
+			return 0, fmt.Errorf("jq string at offset %d contains a single quote", start)
+		case c == '\\':
+			if i+1 >= len(expr) {
+				return 0, fmt.Errorf("jq string at offset %d is not terminated", start)
+			}
+			i++
+			switch expr[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				if i+4 >= len(expr) || !isHex(expr[i+1:i+5]) {
+					return 0, fmt.Errorf("jq string at offset %d has an invalid unicode escape", start)
+				}
+				i += 4
+			case '(':
+				return 0, fmt.Errorf("jq string at offset %d uses string interpolation", start)
+			default:
+				return 0, fmt.Errorf("jq string at offset %d has invalid escape \\%c", start, expr[i])
+			}
+		}
+	}
+	return 0, fmt.Errorf("jq string at offset %d is not terminated", start)
+}
+
+// checkJQIdentifier checks the identifier name at offset i: field names and
+// object keys are allowed, anything else must be a whitelisted keyword or builtin
+func checkJQIdentifier(expr string, i int, name string, inObject bool) error {
+	if i > 0 && expr[i-1] == '.' {
+		return nil // field access such as .user.username
+	}
+	rest := strings.TrimLeft(expr[i+len(name):], " \t\r\n")
+	if inObject && strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "::") {
+		return nil // object key such as {auditID: .auditID}
+	}
+	if allowedJQKeywords[name] || AllowedJQFunctions[name] {
+		return nil
+	}
+	return fmt.Errorf("jq expression uses disallowed function or keyword %q", name)
+}
+
+// jqPrecededBy reports whether the token before offset i is the keyword
+func jqPrecededBy(expr string, i int, keyword string) bool {
+	before := strings.TrimRight(expr[:i], " \t\r\n")
+	if !strings.HasSuffix(before, keyword) {
+		return false
+	}
+	start := len(before) - len(keyword)
+	return start == 0 || !isJQIdentifierChar(before[start-1])
+}
+
+// scanJQIdentifier returns the identifier starting at offset i
+func scanJQIdentifier(expr string, i int) string {
+	if i >= len(expr) || !isJQIdentifierStart(expr[i]) {
+		return ""
+	}
+	end := i + 1
+	for end < len(expr) && isJQIdentifierChar(expr[end]) {
+		end++
+	}
+	return expr[i:end]
+}
+
+func isJQIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isJQIdentifierChar(c byte) bool {
+	return isJQIdentifierStart(c) || (c >= '0' && c <= '9')
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,8 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -33,31 +35,238 @@ func ValidateQueryParams(params types.AuditQueryParams) error {
 		}
 	}
 
-	// Validate resource types
-	if params.Resource != "" {
-		if !utils.Contains(utils.ValidResources, params.Resource) {
-			return fmt.Errorf("invalid resource: %s", params.Resource)
-		}
+	// Validate resource types, by their names, kinds, short names or
+	// discovered custom resources
+	if err := validateFieldMatches("resource", params.Resource, params.Resources, params.ResourceMatch, func(v string) bool {
+		_, ok := utils.ResolveResource(v)
+		return ok
+	}); err != nil {
+		return err
 	}
 
 	// Validate verbs
-	if params.Verb != "" {
-		if !isValidVerbPattern(params.Verb) {
-			return fmt.Errorf("invalid verb: %s", params.Verb)
-		}
+	if err := validateFieldMatches("verb", params.Verb, params.Verbs, params.VerbMatch, isValidVerbPattern); err != nil {
+		return err
 	}
 
 	// Validate namespace patterns
-	if params.Namespace != "" {
-		if !isValidNamespace(params.Namespace) {
-			return fmt.Errorf("invalid namespace pattern: %s", params.Namespace)
-		}
+	if err := validateFieldMatches("namespace pattern", params.Namespace, params.Namespaces, params.NamespaceMatch, isValidNamespace); err != nil {
+		return err
 	}
 
 	// Validate username patterns
-	if params.Username != "" {
-		if !isValidUsername(params.Username) {
-			return fmt.Errorf("invalid username pattern: %s", params.Username)
+	if err := validateFieldMatches("username pattern", params.Username, params.Usernames, params.UsernameMatch, isValidUsername); err != nil {
+		return err
+	}
+
+	// Validate structured exclusions
+	for field, values := range map[string][]string{
+		"user":      params.ExcludeUsers,
+		"namespace": params.ExcludeNamespaces,
+		"verb":      params.ExcludeVerbs,
+		"resource":  params.ExcludeResources,
+	} {
+		for _, value := range values {
+			if !matchValuePattern.MatchString(strings.TrimSuffix(value, "*")) {
+				return fmt.Errorf("invalid excluded %s: %s", field, value)
+			}
+		}
+	}
+
+	// Validate user agent
+	if err := validateFieldMatch("user agent", params.UserAgent, params.UserAgentMatch, isValidUserAgent); err != nil {
+		return err
+	}
+
+	// Validate response status code filters
+	if params.StatusCode != 0 && !ValidateStatusCode(strconv.Itoa(params.StatusCode)) {
+		return fmt.Errorf("invalid status code: %d", params.StatusCode)
+	}
+	if params.StatusCodeRange != "" {
+		if _, _, _, err := utils.ParseStatusCodeRange(params.StatusCodeRange); err != nil {
+			return fmt.Errorf("invalid status code range: %w", err)
+		}
+	}
+
+	// Validate group and impersonation filters
+	for _, group := range params.Groups {
+		if !matchValuePattern.MatchString(group) {
+			return fmt.Errorf("invalid group: %s", group)
+		}
+	}
+	if params.ImpersonatedUser != "" && !isValidUsername(params.ImpersonatedUser) {
+		return fmt.Errorf("invalid impersonated user: %s", params.ImpersonatedUser)
+	}
+
+	// Validate subresources
+	for _, subresource := range params.Subresources {
+		if !subresourcePattern.MatchString(subresource) {
+			return fmt.Errorf("invalid subresource: %s", subresource)
+		}
+	}
+
+	// Validate audit stage and level
+	if params.Stage != "" && !utils.Contains(utils.AuditStages, params.Stage) {
+		return fmt.Errorf("invalid stage: %s", params.Stage)
+	}
+	if params.Level != "" && !utils.Contains(utils.AuditEventLevels, params.Level) {
+		return fmt.Errorf("invalid level: %s", params.Level)
+	}
+
+	// Validate source address filters
+	if params.SourceIP != "" && net.ParseIP(params.SourceIP) == nil {
+		return fmt.Errorf("invalid source IP: %s", params.SourceIP)
+	}
+	if params.SourceCIDR != "" {
+		if _, _, err := net.ParseCIDR(params.SourceCIDR); err != nil {
+			return fmt.Errorf("invalid source CIDR: %s", params.SourceCIDR)
+		}
+	}
+
+	// Validate sorting and paging
+	if params.SortBy != "" && !utils.Contains(utils.ResultSortFields, params.SortBy) {
+		return fmt.Errorf("invalid sort field: %s", params.SortBy)
+	}
+	if params.SortOrder != "" && !utils.Contains(utils.ResultSortOrders, params.SortOrder) {
+		return fmt.Errorf("invalid sort order: %s", params.SortOrder)
+	}
+	if params.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", params.Limit)
+	}
+	if params.Offset < 0 {
+		return fmt.Errorf("invalid offset: %d", params.Offset)
+	}
+	if params.SampleRate < 0 {
+		return fmt.Errorf("invalid sample rate: %d", params.SampleRate)
+	}
+	if params.SampleSize < 0 {
+		return fmt.Errorf("invalid sample size: %d", params.SampleSize)
+	}
+
+	// Validate output mode
+	if params.OutputMode != "" && !utils.Contains(utils.OutputModes, params.OutputMode) {
+		return fmt.Errorf("invalid output mode: %s", params.OutputMode)
+	}
+	if params.BucketSize != "" && !utils.Contains(utils.HistogramBucketSizes, params.BucketSize) {
+		return fmt.Errorf("invalid bucket size: %s", params.BucketSize)
+	}
+
+	// Validate summary mode
+	if params.SummaryMode != "" && !utils.Contains(utils.SummaryModes, params.SummaryMode) {
+		return fmt.Errorf("invalid summary mode: %s", params.SummaryMode)
+	}
+
+	// Validate backend
+	if params.Backend != "" && !utils.Contains(utils.ValidBackends, params.Backend) {
+		return fmt.Errorf("invalid backend: %s", params.Backend)
+	}
+
+	// Validate boolean filter expression
+	if params.Filter != nil {
+		if err := ValidateFilterExpression(*params.Filter); err != nil {
+			return fmt.Errorf("invalid filter expression: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// matchValuePattern restricts partial values used with prefix and substring match modes
+var matchValuePattern = regexp.MustCompile(`^[a-zA-Z0-9:._@/-]+$`)
+
+// validateFieldMatches validates a single-value field filter and its multi-value list
+func validateFieldMatches(field, value string, values []string, mode types.MatchMode, isValid func(string) bool) error {
+	if err := validateFieldMatch(field, value, mode, isValid); err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("invalid %s: empty value", field)
+		}
+		if err := validateFieldMatch(field, v, mode, isValid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFieldMatch validates a field filter value against its match mode.
+// Exact and legacy matching use the field's own validation; prefix and substring
+// accept partial values, and regex values must compile.
+func validateFieldMatch(field, value string, mode types.MatchMode, isValid func(string) bool) error {
+	switch mode {
+	case "", types.MatchModeExact, types.MatchModePrefix, types.MatchModeSubstring, types.MatchModeRegex:
+	default:
+		return fmt.Errorf("invalid %s match mode: %s", field, mode)
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	switch mode {
+	case types.MatchModePrefix, types.MatchModeSubstring:
+		if !matchValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid %s: %s", field, value)
+		}
+	case types.MatchModeRegex:
+		if strings.ContainsAny(value, "'`") {
+			return fmt.Errorf("invalid %s regex: %s", field, value)
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid %s regex: %w", field, err)
+		}
+	default:
+		if !isValid(value) {
+			return fmt.Errorf("invalid %s: %s", field, value)
+		}
+	}
+
+	return nil
+}
+
+// MaxFilterExpressionDepth limits how deeply filter expression groups may nest
+const MaxFilterExpressionDepth = 5
+
+// ValidateFilterExpression validates the structure and patterns of a filter expression
+func ValidateFilterExpression(expr types.FilterExpression) error {
+	return validateFilterExpression(expr, 1)
+}
+
+// validateFilterExpression validates a filter expression node at the given depth
+func validateFilterExpression(expr types.FilterExpression, depth int) error {
+	if depth > MaxFilterExpressionDepth {
+		return fmt.Errorf("nesting exceeds maximum depth of %d", MaxFilterExpressionDepth)
+	}
+
+	if expr.IsLeaf() {
+		if len(expr.Children) > 0 {
+			return fmt.Errorf("pattern %q must not have children without an operator", expr.Pattern)
+		}
+		if strings.TrimSpace(expr.Pattern) == "" {
+			return fmt.Errorf("pattern must not be empty")
+		}
+		if strings.Contains(expr.Pattern, "'") {
+			return fmt.Errorf("pattern %q must not contain single quotes", expr.Pattern)
+		}
+		return nil
+	}
+
+	switch expr.Operator {
+	case types.FilterOperatorAnd, types.FilterOperatorOr, types.FilterOperatorNot:
+	default:
+		return fmt.Errorf("unknown operator: %s", expr.Operator)
+	}
+	if expr.Pattern != "" {
+		return fmt.Errorf("%s group must not have a pattern", expr.Operator)
+	}
+	if len(expr.Children) == 0 {
+		return fmt.Errorf("%s group must have at least one child", expr.Operator)
+	}
+
+	for _, child := range expr.Children {
+		if err := validateFilterExpression(child, depth+1); err != nil {
+			return err
 		}
 	}
 
@@ -104,6 +313,13 @@ func ValidateGeneratedCommand(command string) error {
 		return fmt.Errorf("command must start with 'oc adm node-logs'")
 	}
 
+	// Lint the jq programs of jq stages
+	for _, program := range JQPrograms(command) {
+		if err := ValidateJQExpression(program); err != nil {
+			return fmt.Errorf("command contains an invalid jq program: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -208,6 +424,9 @@ func isSafeOcAdmNodeLogsCommand(command string) bool {
 		"--path=oauth-apiserver/",
 		"--path=audit/",
 	}
+	for _, source := range utils.CustomLogSources() {
+		validLogPaths = append(validLogPaths, "--path="+path.Dir(source.Path)+"/")
+	}
 
 	hasValidPath := false
 	for _, validPath := range validLogPaths {
@@ -476,6 +695,19 @@ func isValidVerbPattern(verb string) bool {
 	return utils.Contains(utils.ValidVerbs, verb)
 }
 
+// userAgentPattern allows the characters found in client user agents such as
+// "kubectl/v1.28.2 (linux/amd64) kubernetes/89a4ea3"
+var userAgentPattern = regexp.MustCompile(`^[a-zA-Z0-9:._@/()+, -]{1,256}$`)
+
+// subresourcePattern allows subresource names such as "exec", "portforward"
+// or "status"
+var subresourcePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+// isValidUserAgent validates user agent filter values
+func isValidUserAgent(userAgent string) bool {
+	return userAgentPattern.MatchString(userAgent)
+}
+
 // isValidNamespace validates namespace patterns for Kubernetes/OpenShift
 func isValidNamespace(namespace string) bool {
 	// Check length constraints first (1-63 characters for namespaces)
@@ -1107,3 +1107,438 @@ func TestValidateGeneratedCommand_MultiFileCommands(t *testing.T) {
 		})
 	}
 }
+
+// TestValidateFilterExpression tests validation of boolean filter expressions
+func TestValidateFilterExpression(t *testing.T) {
+	deep := types.FilterExpression{Pattern: "leaf"}
+	for i := 0; i < MaxFilterExpressionDepth; i++ {
+		deep = types.FilterExpression{Operator: types.FilterOperatorAnd, Children: []types.FilterExpression{deep}}
+	}
+
+	tests := []struct {
+		name    string
+		expr    types.FilterExpression
+		wantErr bool
+	}{
+		{
+			name:    "Single pattern",
+			expr:    types.FilterExpression{Pattern: "delete"},
+			wantErr: false,
+		},
+		{
+			name: "Nested groups",
+			expr: types.FilterExpression{
+				Operator: types.FilterOperatorAnd,
+				Children: []types.FilterExpression{
+					{Operator: types.FilterOperatorOr, Children: []types.FilterExpression{{Pattern: "delete"}, {Pattern: "patch"}}},
+					{Operator: types.FilterOperatorNot, Children: []types.FilterExpression{{Pattern: "system:"}}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Empty pattern",
+			expr:    types.FilterExpression{Pattern: " "},
+			wantErr: true,
+		},
+		{
+			name:    "Pattern with single quote",
+			expr:    types.FilterExpression{Pattern: "it's"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown operator",
+			expr:    types.FilterExpression{Operator: "xor", Children: []types.FilterExpression{{Pattern: "a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Group without children",
+			expr:    types.FilterExpression{Operator: types.FilterOperatorOr},
+			wantErr: true,
+		},
+		{
+			name:    "Group with pattern",
+			expr:    types.FilterExpression{Operator: types.FilterOperatorNot, Pattern: "a", Children: []types.FilterExpression{{Pattern: "b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Leaf with children",
+			expr:    types.FilterExpression{Pattern: "a", Children: []types.FilterExpression{{Pattern: "b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Nesting too deep",
+			expr:    deep,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFilterExpression(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFilterExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Invalid expressions are rejected as part of the query parameters
+	params := types.AuditQueryParams{
+		LogSource: "kube-apiserver",
+		Filter:    &types.FilterExpression{Operator: types.FilterOperatorOr},
+	}
+	if err := ValidateQueryParams(params); err == nil {
+		t.Error("ValidateQueryParams() expected error for invalid filter expression")
+	}
+}
+
+// TestValidateQueryParams_MatchModes tests validation of field match modes
+func TestValidateQueryParams_MatchModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{
+			name:    "Exact username",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Username: "admin", UsernameMatch: types.MatchModeExact},
+			wantErr: false,
+		},
+		{
+			name:    "Prefix username",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Username: "system:serviceaccount:", UsernameMatch: types.MatchModePrefix},
+			wantErr: false,
+		},
+		{
+			name:    "Substring resource not in resource list",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Resource: "role", ResourceMatch: types.MatchModeSubstring},
+			wantErr: false,
+		},
+		{
+			name:    "Regex verb",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "^(get|list)$", VerbMatch: types.MatchModeRegex},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid regex",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Namespace: "kube-(", NamespaceMatch: types.MatchModeRegex},
+			wantErr: true,
+		},
+		{
+			name:    "Regex with single quote",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Username: "a'b", UsernameMatch: types.MatchModeRegex},
+			wantErr: true,
+		},
+		{
+			name:    "Prefix with unsafe characters",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Username: "admin$(id)", UsernameMatch: types.MatchModePrefix},
+			wantErr: true,
+		},
+		{
+			name:    "Exact resource still validated",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Resource: "notaresource", ResourceMatch: types.MatchModeExact},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown match mode",
+			params:  types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "get", VerbMatch: "fuzzy"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_StatusCodes tests validation of status code filters
+func TestValidateQueryParams_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Valid status code", types.AuditQueryParams{LogSource: "kube-apiserver", StatusCode: 403}, false},
+		{"Status code out of range", types.AuditQueryParams{LogSource: "kube-apiserver", StatusCode: 700}, true},
+		{"Named range", types.AuditQueryParams{LogSource: "kube-apiserver", StatusCodeRange: "client_error"}, false},
+		{"Status class", types.AuditQueryParams{LogSource: "kube-apiserver", StatusCodeRange: "2xx"}, false},
+		{"Numeric span", types.AuditQueryParams{LogSource: "kube-apiserver", StatusCodeRange: "400-403"}, false},
+		{"Unknown range", types.AuditQueryParams{LogSource: "kube-apiserver", StatusCodeRange: "errors"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_SourceAddress tests validation of source IP and CIDR filters
+func TestValidateQueryParams_SourceAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Valid IPv4", types.AuditQueryParams{LogSource: "kube-apiserver", SourceIP: "10.0.0.1"}, false},
+		{"Valid IPv6", types.AuditQueryParams{LogSource: "kube-apiserver", SourceIP: "fd00::1"}, false},
+		{"Invalid IP", types.AuditQueryParams{LogSource: "kube-apiserver", SourceIP: "10.0.0.256"}, true},
+		{"Valid CIDR", types.AuditQueryParams{LogSource: "kube-apiserver", SourceCIDR: "10.128.0.0/14"}, false},
+		{"CIDR without prefix length", types.AuditQueryParams{LogSource: "kube-apiserver", SourceCIDR: "10.128.0.0"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_Identity tests validation of group and impersonation filters
+func TestValidateQueryParams_Identity(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Valid groups", types.AuditQueryParams{LogSource: "kube-apiserver", Groups: []string{"ops-team", "system:authenticated"}}, false},
+		{"Group with quote", types.AuditQueryParams{LogSource: "kube-apiserver", Groups: []string{"ops'team"}}, true},
+		{"Valid impersonated user", types.AuditQueryParams{LogSource: "kube-apiserver", ImpersonatedUser: "system:admin"}, false},
+		{"Invalid impersonated user", types.AuditQueryParams{LogSource: "kube-apiserver", ImpersonatedUser: "admin; rm"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_StageAndLevel tests validation of audit stage and level filters
+func TestValidateQueryParams_StageAndLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Valid stage", types.AuditQueryParams{LogSource: "kube-apiserver", Stage: "Panic"}, false},
+		{"Invalid stage", types.AuditQueryParams{LogSource: "kube-apiserver", Stage: "ResponseDone"}, true},
+		{"Valid level", types.AuditQueryParams{LogSource: "kube-apiserver", Level: "RequestResponse"}, false},
+		{"Policy profile is not a level", types.AuditQueryParams{LogSource: "kube-apiserver", Level: "AllRequestBodies"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_Subresources tests validation of subresource filters
+func TestValidateQueryParams_Subresources(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Valid subresources", types.AuditQueryParams{LogSource: "kube-apiserver", Subresources: []string{"exec", "portforward"}}, false},
+		{"Uppercase subresource", types.AuditQueryParams{LogSource: "kube-apiserver", Subresources: []string{"Exec"}}, true},
+		{"Injected subresource", types.AuditQueryParams{LogSource: "kube-apiserver", Subresources: []string{"exec'; rm -rf /"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_SortAndPaging tests validation of result sorting, paging and output mode
+func TestValidateQueryParams_SortAndPaging(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Sort by timestamp descending", types.AuditQueryParams{LogSource: "kube-apiserver", SortBy: "timestamp", SortOrder: "desc", Limit: 100}, false},
+		{"Sort by status code", types.AuditQueryParams{LogSource: "kube-apiserver", SortBy: "statusCode"}, false},
+		{"Unknown sort field", types.AuditQueryParams{LogSource: "kube-apiserver", SortBy: "namespace"}, true},
+		{"Unknown sort order", types.AuditQueryParams{LogSource: "kube-apiserver", SortBy: "user", SortOrder: "descending"}, true},
+		{"Negative limit", types.AuditQueryParams{LogSource: "kube-apiserver", Limit: -1}, true},
+		{"Negative offset", types.AuditQueryParams{LogSource: "kube-apiserver", Offset: -5}, true},
+		{"Sampled", types.AuditQueryParams{LogSource: "kube-apiserver", SampleRate: 10, SampleSize: 1000}, false},
+		{"Negative sample rate", types.AuditQueryParams{LogSource: "kube-apiserver", SampleRate: -2}, true},
+		{"Negative sample size", types.AuditQueryParams{LogSource: "kube-apiserver", SampleSize: -1}, true},
+		{"Histogram per minute", types.AuditQueryParams{LogSource: "kube-apiserver", OutputMode: "histogram", BucketSize: "minute"}, false},
+		{"Unknown output mode", types.AuditQueryParams{LogSource: "kube-apiserver", OutputMode: "chart"}, true},
+		{"Unknown bucket size", types.AuditQueryParams{LogSource: "kube-apiserver", OutputMode: "histogram", BucketSize: "week"}, true},
+		{"Verbose summary", types.AuditQueryParams{LogSource: "kube-apiserver", SummaryMode: "verbose"}, false},
+		{"Unknown summary mode", types.AuditQueryParams{LogSource: "kube-apiserver", SummaryMode: "chatty"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_UserAgent tests validation of user agent filters
+func TestValidateQueryParams_UserAgent(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Substring", types.AuditQueryParams{LogSource: "kube-apiserver", UserAgent: "kubectl"}, false},
+		{"Full user agent", types.AuditQueryParams{LogSource: "kube-apiserver", UserAgent: "kubectl/v1.28.2 (linux/amd64) kubernetes/89a4ea3"}, false},
+		{"Regex", types.AuditQueryParams{LogSource: "kube-apiserver", UserAgent: "^(kubectl|oc)/", UserAgentMatch: types.MatchModeRegex}, false},
+		{"Invalid regex", types.AuditQueryParams{LogSource: "kube-apiserver", UserAgent: "(kubectl", UserAgentMatch: types.MatchModeRegex}, true},
+		{"Quote in value", types.AuditQueryParams{LogSource: "kube-apiserver", UserAgent: "kube'ctl"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_FieldExclusions tests validation of structured exclusions
+func TestValidateQueryParams_FieldExclusions(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Exact users", types.AuditQueryParams{LogSource: "kube-apiserver", ExcludeUsers: []string{"admin", "system:admin"}}, false},
+		{"Prefix wildcard", types.AuditQueryParams{LogSource: "kube-apiserver", ExcludeUsers: []string{"system:serviceaccount:ci:*"}}, false},
+		{"Wildcard in the middle", types.AuditQueryParams{LogSource: "kube-apiserver", ExcludeNamespaces: []string{"openshift-*-operator"}}, true},
+		{"Bare wildcard", types.AuditQueryParams{LogSource: "kube-apiserver", ExcludeVerbs: []string{"*"}}, true},
+		{"Quote in resource", types.AuditQueryParams{LogSource: "kube-apiserver", ExcludeResources: []string{"pods'"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateQueryParams_MultiValue tests validation of multi-value field filters
+func TestValidateQueryParams_MultiValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  types.AuditQueryParams
+		wantErr bool
+	}{
+		{"Valid namespaces", types.AuditQueryParams{LogSource: "kube-apiserver", Namespaces: []string{"database", "customer-service"}}, false},
+		{"Valid verbs", types.AuditQueryParams{LogSource: "kube-apiserver", Verb: "delete", Verbs: []string{"patch"}}, false},
+		{"Invalid verb in list", types.AuditQueryParams{LogSource: "kube-apiserver", Verbs: []string{"get", "destroy"}}, true},
+		{"Invalid resource in list", types.AuditQueryParams{LogSource: "kube-apiserver", Resources: []string{"pods", "widgets"}}, true},
+		{"Resource aliases and kinds", types.AuditQueryParams{LogSource: "kube-apiserver", Resource: "crd", Resources: []string{"Deployment", "pvcs"}}, false},
+		{"Empty username in list", types.AuditQueryParams{LogSource: "kube-apiserver", Usernames: []string{"admin", ""}}, true},
+		{"Exact usernames", types.AuditQueryParams{LogSource: "kube-apiserver", Usernames: []string{"admin", "system:admin"}, UsernameMatch: types.MatchModeExact}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateJQExpression tests linting generated jq programs
+func TestValidateJQExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{"Identity", ".", false},
+		{"Field test", `select((.user.username | test("john\\.doe"; "i")))`, false},
+		{"Bound variables", `select((tostring as $event | ["a", "b"] | all(. as $p | $event | test($p; "i"))))`, false},
+		{"Object construction", `. | {auditID: .auditID, username: (.user.username // "unknown"), sourceIPs: (.sourceIPs // [])}`, false},
+		{"Conditional", `(.responseStatus.code // 0) as $code | if $code >= 400 then true else false end`, false},
+		{"Unicode escape", `test("it\u0027s")`, false},
+		{"Empty", " ", true},
+		{"Unterminated string", `test("abc)`, true},
+		{"Invalid escape", `test("a\.b")`, true},
+		{"Short unicode escape", `test("\u12")`, true},
+		{"String interpolation", `test("\(env.HOME)")`, true},
+		{"Control character in string", "test(\"a\nb\")", true},
+		{"Unbalanced parenthesis", `select((.verb == "get")`, true},
+		{"Mismatched brackets", `select(.verb == "get"]`, true},
+		{"Extra closing brace", `{a: .a}}`, true},
+		{"Single quote", `test("a'b")`, true},
+		{"Disallowed builtin input", `input`, true},
+		{"Disallowed builtin env", `env.HOME`, true},
+		{"Disallowed builtin in pipe", `. | input_filename`, true},
+		{"Disallowed builtin in slice", `.[input:2]`, true},
+		{"Unbound ENV variable", `$ENV.HOME`, true},
+		{"Unbound loc variable", `$__loc__`, true},
+		{"Function definition", `def f: input; f`, true},
+		{"Module import", `import "a" as a; .`, true},
+		{"Format string", `@sh "rm \(.)"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJQExpression(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJQExpression(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateGeneratedCommand_JQPrograms tests that jq stages are linted
+func TestValidateGeneratedCommand_JQPrograms(t *testing.T) {
+	base := "oc adm node-logs --role=master --path=kube-apiserver/audit.log"
+	if err := ValidateGeneratedCommand(base + ` | jq -r 'select((.verb | test("delete"; "i")))'`); err != nil {
+		t.Errorf("Expected valid jq program to pass, got %v", err)
+	}
+	if err := ValidateGeneratedCommand(base + ` | jq -r 'select(input_filename | test("audit"))'`); err == nil {
+		t.Error("Expected disallowed jq builtin to be rejected")
+	}
+	if err := ValidateGeneratedCommand(base + ` | jq -r 'select((.verb | test("a\.b"; "i")))'`); err == nil {
+		t.Error("Expected invalid jq escape to be rejected")
+	}
+
+	programs := JQPrograms("(" + base + ` | jq -r '.a' && ` + base + ` | jq -c '.b')`)
+	if len(programs) != 2 || programs[0] != ".a" || programs[1] != ".b" {
+		t.Errorf("Unexpected jq programs: %q", programs)
+	}
+}